@@ -0,0 +1,182 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// adaptiveLimiter implements ConcurrencyAndBufferSize.Adaptive. It bounds the number of concurrent
+// calls of a provider's workers by a limit that is decreased multiplicatively when the provider
+// throttles, and increased additively (by one per window of limit successful calls) otherwise.
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	changed  chan struct{} // Closed and replaced whenever a slot is released or the limit changes
+	limit    float64
+	minimum  float64
+	maximum  float64
+	inFlight int
+
+	decreaseFactor   float64
+	latencyThreshold time.Duration
+	lastDecrease     time.Time // Calls started before the last decrease do not decrease the limit again
+	throttled        int64     // Number of calls that decreased the limit or arrived while it was being decreased
+}
+
+// newAdaptiveLimiter returns the limiter of a provider with the given concurrency settings, or
+// nil if adaptive concurrency is not enabled.
+func newAdaptiveLimiter(concurrency schemas.ConcurrencyAndBufferSize) (*adaptiveLimiter, error) {
+	config := concurrency.Adaptive
+	if config == nil {
+		return nil, nil
+	}
+
+	minimum := config.MinConcurrency
+	if minimum == 0 {
+		minimum = 1
+	}
+	if minimum < 1 || minimum > concurrency.Concurrency {
+		return nil, fmt.Errorf("adaptive min_concurrency must be between 1 and concurrency (%d), got %d", concurrency.Concurrency, config.MinConcurrency)
+	}
+	decreaseFactor := config.DecreaseFactor
+	if decreaseFactor == 0 {
+		decreaseFactor = 0.5
+	}
+	if decreaseFactor <= 0 || decreaseFactor >= 1 {
+		return nil, fmt.Errorf("adaptive decrease_factor must be between 0 and 1, got %v", config.DecreaseFactor)
+	}
+	if config.LatencyThresholdMs < 0 {
+		return nil, fmt.Errorf("adaptive latency_threshold_ms must be non-negative, got %d", config.LatencyThresholdMs)
+	}
+
+	return &adaptiveLimiter{
+		changed:          make(chan struct{}),
+		limit:            float64(concurrency.Concurrency),
+		minimum:          float64(minimum),
+		maximum:          float64(concurrency.Concurrency),
+		decreaseFactor:   decreaseFactor,
+		latencyThreshold: time.Duration(config.LatencyThresholdMs) * time.Millisecond,
+	}, nil
+}
+
+// adaptiveSlot is a slot of an adaptiveLimiter held by a call. The outcome of the call is
+// recorded with record, and the slot is freed with release: streams record the outcome of their
+// setup, but hold the slot until they are closed, so that open streams count toward the limit.
+type adaptiveSlot struct {
+	limiter   *adaptiveLimiter // nil if adaptive concurrency is disabled
+	startedAt time.Time
+}
+
+// acquire blocks until the number of calls in flight is below the limit, and returns the slot of
+// the call. A nil limiter never blocks. If ctx is done while waiting, a cancellation error is
+// returned instead.
+func (l *adaptiveLimiter) acquire(ctx context.Context) (adaptiveSlot, *schemas.BifrostError) {
+	if l == nil {
+		return adaptiveSlot{}, nil
+	}
+
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return adaptiveSlot{limiter: l, startedAt: time.Now()}, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return adaptiveSlot{}, &schemas.BifrostError{
+				IsBifrostError: true,
+				Error: schemas.ErrorField{
+					Type:    Ptr(schemas.RequestCancelled),
+					Message: fmt.Sprintf("Request cancelled or timed out while waiting for an adaptive concurrency slot: %v", ctx.Err()),
+					Error:   ctx.Err(),
+				},
+			}
+		}
+	}
+}
+
+// record adjusts the limit to the outcome of the call, and to its latency so far.
+func (s adaptiveSlot) record(bifrostErr *schemas.BifrostError) {
+	l := s.limiter
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	congested := bifrostErr != nil && bifrostErr.StatusCode != nil && *bifrostErr.StatusCode == http.StatusTooManyRequests
+	if l.latencyThreshold > 0 && time.Since(s.startedAt) > l.latencyThreshold {
+		congested = true
+	}
+
+	switch {
+	case congested:
+		l.throttled++
+		// Calls that were already in flight when the limit was decreased saw the same congestion
+		if s.startedAt.After(l.lastDecrease) {
+			l.limit = max(l.limit*l.decreaseFactor, l.minimum)
+			l.lastDecrease = time.Now()
+		}
+	case bifrostErr == nil:
+		l.limit = min(l.limit+1/l.limit, l.maximum)
+	}
+	l.notify()
+}
+
+// release frees the slot.
+func (s adaptiveSlot) release() {
+	l := s.limiter
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.notify()
+}
+
+// notify wakes the calls waiting for a slot. Must be called with l.mu held.
+func (l *adaptiveLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// snapshot returns the current limit and the number of throttled calls.
+func (l *adaptiveLimiter) snapshot() (limit int, throttled int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit), l.throttled
+}
+
+// configureAdaptiveConcurrency replaces the adaptive limiter of a provider according to its
+// concurrency settings, removing it if adaptive concurrency is disabled.
+func (bifrost *Bifrost) configureAdaptiveConcurrency(providerKey schemas.ModelProvider, concurrency schemas.ConcurrencyAndBufferSize) error {
+	limiter, err := newAdaptiveLimiter(concurrency)
+	if err != nil {
+		return fmt.Errorf("invalid concurrency config for provider %s: %v", providerKey, err)
+	}
+	if limiter == nil {
+		bifrost.adaptiveLimiters.Delete(providerKey)
+		return nil
+	}
+	bifrost.adaptiveLimiters.Store(providerKey, limiter)
+	return nil
+}
+
+// getAdaptiveLimiter returns the adaptive limiter of a provider, or nil if it has none.
+func (bifrost *Bifrost) getAdaptiveLimiter(providerKey schemas.ModelProvider) *adaptiveLimiter {
+	if limiter, ok := bifrost.adaptiveLimiters.Load(providerKey); ok {
+		return limiter.(*adaptiveLimiter)
+	}
+	return nil
+}
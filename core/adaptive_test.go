@@ -0,0 +1,171 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter, err := newAdaptiveLimiter(schemas.ConcurrencyAndBufferSize{Concurrency: 4, Adaptive: &schemas.AdaptiveConcurrencyConfig{}})
+	if err != nil {
+		t.Fatalf("newAdaptiveLimiter() error = %v", err)
+	}
+	throttled := &schemas.BifrostError{StatusCode: Ptr(http.StatusTooManyRequests)}
+
+	// Calls in flight during a decrease do not decrease the limit again
+	first, _ := limiter.acquire(context.Background())
+	second, _ := limiter.acquire(context.Background())
+	first.record(throttled)
+	first.release()
+	second.record(throttled)
+	second.release()
+	if limit, count := limiter.snapshot(); limit != 2 || count != 2 {
+		t.Fatalf("limit = %d after %d throttled calls, want 2 after 2", limit, count)
+	}
+
+	// The limit is enforced
+	third, _ := limiter.acquire(context.Background())
+	fourth, _ := limiter.acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, bifrostErr := limiter.acquire(ctx); bifrostErr == nil || *bifrostErr.Error.Type != schemas.RequestCancelled {
+		t.Fatalf("acquire() beyond the limit error = %+v, want a cancellation", bifrostErr)
+	}
+	third.release()
+	fourth.release()
+
+	// Successes ramp the limit back up, one call per window, up to Concurrency
+	for range 20 {
+		slot, _ := limiter.acquire(context.Background())
+		slot.record(nil)
+		slot.release()
+	}
+	if limit, _ := limiter.snapshot(); limit != 4 {
+		t.Errorf("limit = %d after successful calls, want 4", limit)
+	}
+}
+
+func TestAdaptiveLimiterValidation(t *testing.T) {
+	for _, config := range []schemas.AdaptiveConcurrencyConfig{{MinConcurrency: 5}, {DecreaseFactor: 1.5}, {LatencyThresholdMs: -1}} {
+		if _, err := newAdaptiveLimiter(schemas.ConcurrencyAndBufferSize{Concurrency: 4, Adaptive: &config}); err == nil {
+			t.Errorf("newAdaptiveLimiter(%+v) accepted an invalid config", config)
+		}
+	}
+}
+
+func TestAdaptiveConcurrencyStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests"}}`))
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: sameConfig(schemas.ProviderConfig{
+			NetworkConfig:            schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+			ConcurrencyAndBufferSize: schemas.ConcurrencyAndBufferSize{Concurrency: 8, BufferSize: 10, Adaptive: &schemas.AdaptiveConcurrencyConfig{}},
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:   schemas.OpenAI,
+		Model:      "gpt-4o",
+		Input:      schemas.RequestInput{ChatCompletionInput: &messages},
+		MaxRetries: Ptr(0),
+	})
+	if bifrostErr == nil {
+		t.Fatal("ChatCompletionRequest() succeeded, want a rate limit error")
+	}
+	if stats := bifrost.GetQueueStats()[schemas.OpenAI]; stats.ConcurrencyLimit != 4 || stats.Throttled != 1 {
+		t.Errorf("concurrency limit = %d with %d throttled calls, want 4 with 1", stats.ConcurrencyLimit, stats.Throttled)
+	}
+}
+
+func TestAdaptiveConcurrencyHeldByOpenStreams(t *testing.T) {
+	// Every stream sends a first chunk, then stays open until finish is closed
+	finish := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-finish
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+	defer func() {
+		select {
+		case <-finish:
+		default:
+			close(finish)
+		}
+	}()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys: testKeys(),
+			config: sameConfig(schemas.ProviderConfig{
+				NetworkConfig:            schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+				ConcurrencyAndBufferSize: schemas.ConcurrencyAndBufferSize{Concurrency: 2, BufferSize: 10, Adaptive: &schemas.AdaptiveConcurrencyConfig{}},
+			}),
+		},
+		Logger: NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func(ctx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return bifrost.ChatCompletionStreamRequest(ctx, &schemas.BifrostRequest{
+			Provider:   schemas.OpenAI,
+			Model:      "gpt-4o",
+			Input:      schemas.RequestInput{ChatCompletionInput: &messages},
+			MaxRetries: Ptr(0),
+		})
+	}
+
+	var streams []chan *schemas.BifrostStream
+	for range 2 {
+		stream, bifrostErr := request(context.Background())
+		if bifrostErr != nil {
+			t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+		}
+		<-stream
+		streams = append(streams, stream)
+	}
+
+	// The open streams hold both adaptive slots, so a third request waits for one
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, bifrostErr := request(ctx); bifrostErr == nil {
+		t.Fatal("ChatCompletionStreamRequest() with every adaptive slot held by a stream succeeded, want a cancellation")
+	}
+
+	// Once the streams are closed, their slots are released
+	close(finish)
+	for _, stream := range streams {
+		for range stream {
+		}
+	}
+	stream, bifrostErr := request(context.Background())
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() after the streams closed error = %+v", bifrostErr)
+	}
+	for range stream {
+	}
+}
@@ -0,0 +1,61 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestBaseURLOverrideTargetsPrimaryOnly(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(r.URL.Path, "/canary/") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"canary rejected the request","type":"invalid_request_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys:   testKeys(),
+			config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL + "/stable", DefaultRequestTimeoutInSeconds: 5}),
+		},
+		Logger: NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:  schemas.OpenAI,
+		Model:     "gpt-4o",
+		Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+		BaseURL:   Ptr(server.URL + "/canary/"),
+		Fallbacks: []schemas.Fallback{{Provider: schemas.OpenAI, Model: "gpt-4o-mini"}},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	// The primary provider is sent to the override, the fallback to its configured base URL
+	if want := []string{"/canary/v1/chat/completions", "/stable/v1/chat/completions"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("requested paths = %q, want %q", paths, want)
+	}
+}
@@ -0,0 +1,235 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// CreateBatch submits a set of chat completion requests to the provider's batch API and
+// returns the created job. Batch APIs process requests asynchronously (within hours) at a
+// lower price, which suits jobs that are not latency sensitive, such as nightly evaluations.
+//
+// Batch requests are sent directly to the provider with its configured client and keys; they
+// do not go through the provider queue, plugins, retries or fallbacks. The returned job records
+// the key it was created with, and must be passed back to GetBatch, GetBatchResults and CancelBatch.
+func (bifrost *Bifrost) CreateBatch(ctx context.Context, req *schemas.BatchRequest) (*schemas.BatchJob, *schemas.BifrostError) {
+	if err := validateBatchRequest(req); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	provider, bifrostErr := bifrost.getBatchProvider(req.Provider)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	key, err := bifrost.selectKeyFromProviderForModel(&ctx, req.Provider, req.Model)
+	if err != nil {
+		return nil, newBifrostError(err)
+	}
+	ctx = bifrost.withBatchHeaders(ctx, req.Provider)
+
+	job, bifrostErr := provider.CreateBatch(ctx, req.Model, key, req.Items)
+	if bifrostErr != nil {
+		bifrostErr.Provider = req.Provider
+		return nil, bifrostErr
+	}
+	job.KeyID = key.ID
+
+	bifrost.logger.Info(fmt.Sprintf("Created batch %s with %d requests for provider %s", job.ID, len(req.Items), req.Provider))
+	return job, nil
+}
+
+// GetBatch fetches the current state of a batch job created with CreateBatch.
+func (bifrost *Bifrost) GetBatch(ctx context.Context, job *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	provider, key, bifrostErr := bifrost.prepareBatchOperation(ctx, job)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	ctx = bifrost.withBatchHeaders(ctx, job.Provider)
+
+	current, bifrostErr := provider.GetBatch(ctx, key, job)
+	if bifrostErr != nil {
+		bifrostErr.Provider = job.Provider
+		return nil, bifrostErr
+	}
+	current.KeyID = job.KeyID
+
+	return current, nil
+}
+
+// GetBatchResults returns the per-request results of a finished batch job, identified by the
+// CustomID of each item. It returns an error if the batch has not reached a terminal status yet.
+// Items that failed, were cancelled or expired are returned with their Error set.
+func (bifrost *Bifrost) GetBatchResults(ctx context.Context, job *schemas.BatchJob) ([]schemas.BatchResult, *schemas.BifrostError) {
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	provider, key, bifrostErr := bifrost.prepareBatchOperation(ctx, job)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	ctx = bifrost.withBatchHeaders(ctx, job.Provider)
+
+	// Always fetch the latest state, as results locations are only known once the batch has finished
+	current, bifrostErr := provider.GetBatch(ctx, key, job)
+	if bifrostErr != nil {
+		bifrostErr.Provider = job.Provider
+		return nil, bifrostErr
+	}
+	if !current.Status.IsTerminal() {
+		return nil, newBifrostErrorFromMsg(fmt.Sprintf("batch %s has not finished yet (status: %s)", job.ID, current.Status))
+	}
+
+	results, bifrostErr := provider.GetBatchResults(ctx, key, current)
+	if bifrostErr != nil {
+		bifrostErr.Provider = job.Provider
+		return nil, bifrostErr
+	}
+
+	return results, nil
+}
+
+// CancelBatch requests the cancellation of a batch job and returns its updated state.
+// Requests that were already processed are kept and can still be retrieved with GetBatchResults.
+func (bifrost *Bifrost) CancelBatch(ctx context.Context, job *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	provider, key, bifrostErr := bifrost.prepareBatchOperation(ctx, job)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	ctx = bifrost.withBatchHeaders(ctx, job.Provider)
+
+	current, bifrostErr := provider.CancelBatch(ctx, key, job)
+	if bifrostErr != nil {
+		bifrostErr.Provider = job.Provider
+		return nil, bifrostErr
+	}
+	current.KeyID = job.KeyID
+
+	bifrost.logger.Info(fmt.Sprintf("Requested cancellation of batch %s for provider %s", job.ID, job.Provider))
+	return current, nil
+}
+
+// prepareBatchOperation resolves the provider and the key of an existing batch job.
+func (bifrost *Bifrost) prepareBatchOperation(ctx context.Context, job *schemas.BatchJob) (schemas.BatchProvider, schemas.Key, *schemas.BifrostError) {
+	if job == nil || job.ID == "" {
+		return nil, schemas.Key{}, newBifrostErrorFromMsg("batch job with an ID is required")
+	}
+
+	provider, bifrostErr := bifrost.getBatchProvider(job.Provider)
+	if bifrostErr != nil {
+		return nil, schemas.Key{}, bifrostErr
+	}
+
+	key, err := bifrost.getBatchKey(ctx, job)
+	if err != nil {
+		return nil, schemas.Key{}, newBifrostError(err)
+	}
+
+	return provider, key, nil
+}
+
+// withBatchHeaders attaches the provider's User-Agent and client ID headers to the context of a
+// batch operation, as batch requests do not go through the provider queue that sets them.
+func (bifrost *Bifrost) withBatchHeaders(ctx context.Context, providerKey schemas.ModelProvider) context.Context {
+	config, err := bifrost.getProviderConfig(providerKey)
+	if err != nil {
+		return ctx
+	}
+	requestHeaders, _ := ctx.Value(schemas.BifrostContextKeyExtraHeaders).(map[string]string)
+	if headers := withIdentificationHeaders(config.NetworkConfig, scopeRequestHeaders(providerKey, requestHeaders)); len(headers) > 0 {
+		ctx = context.WithValue(ctx, schemas.BifrostContextKeyExtraHeaders, headers)
+	}
+	return ctx
+}
+
+// getBatchProvider returns the provider instance if it implements the batch API.
+// The provider is initialized from the account's configuration if it has not been used yet.
+func (bifrost *Bifrost) getBatchProvider(providerKey schemas.ModelProvider) (schemas.BatchProvider, *schemas.BifrostError) {
+	if _, err := bifrost.getProviderQueue(providerKey); err != nil {
+		return nil, newBifrostError(err)
+	}
+
+	providerValue, exists := bifrost.providerInstances.Load(providerKey)
+	if !exists {
+		return nil, newBifrostErrorFromMsg(fmt.Sprintf("provider %s is not initialized", providerKey))
+	}
+
+	provider, ok := providerValue.(schemas.BatchProvider)
+	if !ok {
+		bifrostErr := newBifrostErrorFromMsg(fmt.Sprintf("batch API is not supported by %s provider", providerKey))
+		bifrostErr.Provider = providerKey
+		bifrostErr.Error.Type = Ptr(schemas.UnsupportedOperation)
+		return nil, bifrostErr
+	}
+
+	return provider, nil
+}
+
+// getBatchKey returns the key a batch job was created with, as batches can only be accessed
+// with keys of the account that created them. Jobs without a key ID use the regular key selection.
+func (bifrost *Bifrost) getBatchKey(ctx context.Context, job *schemas.BatchJob) (schemas.Key, error) {
+	if job.KeyID == "" {
+		return bifrost.selectKeyFromProviderForModel(&ctx, job.Provider, job.Model)
+	}
+	if bifrost.isKeyRevoked(job.Provider, schemas.Key{ID: job.KeyID}) {
+		return schemas.Key{}, fmt.Errorf("key %s of batch %s has been revoked", job.KeyID, job.ID)
+	}
+
+	keys, err := bifrost.account.GetKeysForProvider(&ctx, job.Provider)
+	if err != nil {
+		return schemas.Key{}, err
+	}
+	for _, key := range keys {
+		if key.ID == job.KeyID {
+			return key, nil
+		}
+	}
+
+	return schemas.Key{}, fmt.Errorf("key %s of batch %s is no longer configured for provider %s", job.KeyID, job.ID, job.Provider)
+}
+
+// validateBatchRequest checks that a batch request has a provider, a model and valid items.
+func validateBatchRequest(req *schemas.BatchRequest) *schemas.BifrostError {
+	if req == nil {
+		return newBifrostErrorFromMsg("batch request cannot be nil")
+	}
+	if req.Provider == "" {
+		return newBifrostErrorFromMsg("provider is required")
+	}
+	if req.Model == "" {
+		return newBifrostErrorFromMsg("model is required")
+	}
+	if len(req.Items) == 0 {
+		return newBifrostErrorFromMsg("batch request must contain at least one item")
+	}
+
+	customIDs := make(map[string]struct{}, len(req.Items))
+	for i, item := range req.Items {
+		if item.CustomID == "" {
+			return newBifrostErrorFromMsg(fmt.Sprintf("batch item %d: custom_id is required", i))
+		}
+		if _, exists := customIDs[item.CustomID]; exists {
+			return newBifrostErrorFromMsg(fmt.Sprintf("batch item %d: duplicate custom_id %q", i, item.CustomID))
+		}
+		customIDs[item.CustomID] = struct{}{}
+		if len(item.Messages) == 0 {
+			return newBifrostErrorFromMsg(fmt.Sprintf("batch item %q: at least one message is required", item.CustomID))
+		}
+	}
+
+	return nil
+}
@@ -5,8 +5,9 @@ package bifrost
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"slices"
 	"strings"
 	"sync"
@@ -40,6 +41,7 @@ type ChannelMessage struct {
 	ResponseStream chan chan *schemas.BifrostStream
 	Err            chan schemas.BifrostError
 	Type           RequestType
+	EnqueuedAt     time.Time // When the message was put on the provider queue, to measure the queue wait
 }
 
 // Bifrost manages providers and maintains sepcified open channels for concurrent processing.
@@ -50,6 +52,9 @@ type Bifrost struct {
 	requestQueues       sync.Map         // provider request queues (thread-safe)
 	waitGroups          sync.Map         // wait groups for each provider (thread-safe)
 	providerMutexes     sync.Map         // mutexes for each provider to prevent concurrent updates (thread-safe)
+	providerInstances   sync.Map         // current provider instance for each provider, used for capability lookups (thread-safe)
+	providerGates       sync.Map         // dispatch gates for each provider, used to pause and resume workers (thread-safe)
+	keepalives          sync.Map         // functions stopping the keepalive loop of each provider (thread-safe)
 	channelMessagePool  sync.Pool        // Pool for ChannelMessage objects, initial pool size is set in Init
 	responseChannelPool sync.Pool        // Pool for response channels, initial pool size is set in Init
 	errorChannelPool    sync.Pool        // Pool for error channels, initial pool size is set in Init
@@ -59,6 +64,39 @@ type Bifrost struct {
 	backgroundCtx       context.Context  // Shared background context for nil context handling
 	mcpManager          *MCPManager      // MCP integration manager (nil if MCP not configured)
 	dropExcessRequests  atomic.Bool      // If true, in cases where the queue is full, requests will not wait for the queue to be empty and will be dropped instead.
+
+	noMatchingKeyBehavior schemas.NoMatchingKeyBehavior      // Behavior when no key supports the requested model
+	emptyStreamBehavior   schemas.EmptyStreamBehavior        // Behavior when a stream ends without content
+	keySelection          *schemas.KeySelectionConfig        // Adjustments of key selection weights (nil if not configured)
+	keyOutcomes           *keyOutcomes                       // Recent outcomes by key, for error rate aware key selection (nil if disabled)
+	initSummary           InitSummary                        // Providers that were up or failed at Init
+	globalConcurrency     chan struct{}                      // Semaphore bounding in-flight provider calls across all providers (nil if unbounded)
+	streamBufferSize      int                                // Buffer size of the chunk channels of provider streams
+	onClientDisconnect    func(schemas.ClientDisconnectInfo) // Optional hook called when a result cannot be delivered
+	onRequestTiming       func(schemas.RequestTiming)        // Optional hook called with the queue wait and service time of each request
+	onFirstToken          func(schemas.FirstTokenTiming)     // Optional hook called with the time to first token of each stream
+	onStreamAbort         func(schemas.StreamAbortInfo)      // Optional hook called with the usage of cancelled streams
+	onFailover            func(schemas.FailoverEvent)        // Optional hook called when a request falls back to another provider
+	queueStats            sync.Map                           // queue wait and service time totals for each provider (thread-safe)
+	adaptiveLimiters      sync.Map                           // adaptive concurrency limiter for each provider with Adaptive concurrency (thread-safe)
+	providerHealth        sync.Map                           // circuit breaker of each provider, if ProviderHealth is configured (thread-safe)
+	embeddingRoutersMu    sync.Mutex                         // guards embeddingRouters and embeddingRoutersSeq
+	embeddingRouters      map[string]*embeddingRouter        // round-robin state of each set of EmbeddingRouting targets, at most maxEmbeddingRouters
+	embeddingRoutersSeq   uint64                             // incremented on each use of a router, to find the least recently used one
+	providerHealthConfig  *schemas.ProviderHealthConfig      // Circuit breaker settings (nil if provider health is not tracked)
+	clientDisconnects     atomic.Int64                       // Number of results that could not be delivered because the client was gone
+	streamFallbackAdapter bool                               // If true, streaming fallbacks to non-streaming providers are served as a single chunk
+	streamSequencing      *schemas.StreamSequencingConfig    // Numbering of the chunks of streams returned to callers (nil if disabled)
+
+	defaultNetworkConfig     *schemas.NetworkConfig            // Network settings inherited by providers that leave them unset (nil if not configured)
+	defaultConcurrencyConfig *schemas.ConcurrencyAndBufferSize // Concurrency settings inherited by providers that leave them unset (nil if not configured)
+
+	revokedMu     sync.RWMutex                                  // guards revokedKeys
+	revokedKeys   map[schemas.ModelProvider]map[string]struct{} // keys revoked via RevokeKeys for each provider, by revokedKeyID
+	inFlightMu    sync.Mutex                                    // guards inFlightByKey and inFlightSeq
+	inFlightByKey map[string]map[uint64]context.CancelFunc      // cancel functions of in-flight requests, by revokedKeyID
+	inFlightSeq   uint64                                        // sequence used to identify in-flight requests
+	keyRateLimits sync.Map                                      // latest KeyRateLimit reported for each key ID (thread-safe)
 }
 
 // PluginPipeline encapsulates the execution of plugin PreHooks and PostHooks, tracks how many plugins ran, and manages short-circuiting and error aggregation.
@@ -68,12 +106,15 @@ type PluginPipeline struct {
 
 	// Number of PreHooks that were executed (used to determine which PostHooks to run in reverse order)
 	executedPreHooks int
+	// Plugins skipped for this request because their ShouldRun returned false, by index
+	skipped []bool
 	// Errors from PreHooks and PostHooks
 	preHookErrors  []error
 	postHookErrors []error
 }
 
-// Define a set of retryable status codes
+// Define the default set of retryable status codes.
+// Providers can extend it through NetworkConfig.RetryableStatusCodes.
 var retryableStatusCodes = map[int]bool{
 	500: true, // Internal Server Error
 	502: true, // Bad Gateway
@@ -93,12 +134,55 @@ func Init(config schemas.BifrostConfig) (*Bifrost, error) {
 		return nil, fmt.Errorf("account is required to initialize Bifrost")
 	}
 
+	if config.NoMatchingKeyBehavior == "" {
+		config.NoMatchingKeyBehavior = schemas.NoMatchingKeyBehaviorError
+	} else if !config.NoMatchingKeyBehavior.IsValid() {
+		return nil, fmt.Errorf("invalid no matching key behavior: %s", config.NoMatchingKeyBehavior)
+	}
+	if config.KeySelection != nil && config.KeySelection.ErrorRateHalfLife < 0 {
+		return nil, fmt.Errorf("key selection error rate half-life must be non-negative, got %s", config.KeySelection.ErrorRateHalfLife)
+	}
+	if config.ProviderHealth != nil && (config.ProviderHealth.FailureThreshold < 0 || config.ProviderHealth.ProbeInterval < 0) {
+		return nil, fmt.Errorf("provider health failure threshold and probe interval must be non-negative, got %d and %s", config.ProviderHealth.FailureThreshold, config.ProviderHealth.ProbeInterval)
+	}
+
 	bifrost := &Bifrost{
-		account:       config.Account,
-		plugins:       config.Plugins,
-		requestQueues: sync.Map{},
-		waitGroups:    sync.Map{},
-		backgroundCtx: context.Background(),
+		account:               config.Account,
+		plugins:               config.Plugins,
+		requestQueues:         sync.Map{},
+		waitGroups:            sync.Map{},
+		backgroundCtx:         context.Background(),
+		noMatchingKeyBehavior: config.NoMatchingKeyBehavior,
+		emptyStreamBehavior:   config.EmptyStreamBehavior,
+		keySelection:          config.KeySelection,
+		inFlightByKey:         make(map[string]map[uint64]context.CancelFunc),
+		onClientDisconnect:    config.OnClientDisconnect,
+		onRequestTiming:       config.OnRequestTiming,
+		onFirstToken:          config.OnFirstToken,
+		onStreamAbort:         config.OnStreamAbort,
+		onFailover:            config.OnFailover,
+		streamFallbackAdapter: config.StreamFallbackAdapter,
+		providerHealthConfig:  config.ProviderHealth,
+		streamSequencing:      config.StreamSequencing,
+
+		defaultNetworkConfig:     config.DefaultNetworkConfig,
+		defaultConcurrencyConfig: config.DefaultConcurrencyAndBufferSize,
+	}
+	if config.KeySelection != nil && config.KeySelection.ErrorRateHalfLife > 0 {
+		bifrost.keyOutcomes = newKeyOutcomes(config.KeySelection.ErrorRateHalfLife)
+	}
+	if config.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("max concurrent requests cannot be negative: %d", config.MaxConcurrentRequests)
+	}
+	if config.MaxConcurrentRequests > 0 {
+		bifrost.globalConcurrency = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	if config.StreamBufferSize < 0 {
+		return nil, fmt.Errorf("stream buffer size cannot be negative: %d", config.StreamBufferSize)
+	}
+	bifrost.streamBufferSize = config.StreamBufferSize
+	if bifrost.streamBufferSize == 0 {
+		bifrost.streamBufferSize = schemas.DefaultStreamBufferSize
 	}
 	bifrost.dropExcessRequests.Store(config.DropExcessRequests)
 
@@ -167,10 +251,12 @@ func Init(config schemas.BifrostConfig) (*Bifrost, error) {
 	}
 
 	// Create buffered channels for each provider and start workers
+	summary := InitSummary{Failed: make(map[schemas.ModelProvider]error)}
 	for _, providerKey := range providerKeys {
-		config, err := bifrost.account.GetConfigForProvider(providerKey)
+		config, err := bifrost.getProviderConfig(providerKey)
 		if err != nil {
 			bifrost.logger.Warn(fmt.Sprintf("failed to get config for provider, skipping init: %v", err))
+			summary.Failed[providerKey] = err
 			continue
 		}
 
@@ -182,124 +268,181 @@ func Init(config schemas.BifrostConfig) (*Bifrost, error) {
 
 		if err != nil {
 			bifrost.logger.Warn(fmt.Sprintf("failed to prepare provider %s: %v", providerKey, err))
+			summary.Failed[providerKey] = err
+			continue
 		}
+		summary.Ready = append(summary.Ready, providerKey)
+	}
+
+	bifrost.initSummary = summary
+	bifrost.logger.Info(summary.String())
+	if config.StrictInit && len(summary.Ready) == 0 {
+		if bifrost.mcpManager != nil {
+			if err := bifrost.mcpManager.cleanup(); err != nil {
+				bifrost.logger.Warn(fmt.Sprintf("Error cleaning up MCP manager: %s", err.Error()))
+			}
+		}
+		return nil, &NoProvidersError{Summary: summary}
 	}
 
 	return bifrost, nil
 }
 
+// InitSummary reports which of the account's configured providers were initialized by Init.
+// Providers that fail to initialize are retried when a request first uses them.
+type InitSummary struct {
+	Ready  []schemas.ModelProvider         // Providers initialized successfully, in the account's order
+	Failed map[schemas.ModelProvider]error // Providers that failed to initialize, with the reason
+}
+
+// String describes the providers that are up and the ones that failed, e.g. for startup logs.
+func (s InitSummary) String() string {
+	ready := make([]string, 0, len(s.Ready))
+	for _, provider := range s.Ready {
+		ready = append(ready, string(provider))
+	}
+	failed := make([]string, 0, len(s.Failed))
+	for provider, err := range s.Failed {
+		failed = append(failed, fmt.Sprintf("%s (%v)", provider, err))
+	}
+	slices.Sort(failed)
+
+	description := fmt.Sprintf("initialized %d of %d providers: [%s]", len(s.Ready), len(s.Ready)+len(s.Failed), strings.Join(ready, ", "))
+	if len(failed) > 0 {
+		description += fmt.Sprintf("; failed: [%s]", strings.Join(failed, ", "))
+	}
+	return description
+}
+
+// NoProvidersError is returned by Init in strict mode (BifrostConfig.StrictInit) when no provider
+// could be initialized, either because the account configures none or because all of them failed.
+type NoProvidersError struct {
+	Summary InitSummary
+}
+
+// Error implements the error interface, listing why each provider failed.
+func (e *NoProvidersError) Error() string {
+	if len(e.Summary.Failed) == 0 {
+		return "no providers configured"
+	}
+	return "no providers could be initialized: " + e.Summary.String()
+}
+
+// InitSummary returns which providers were initialized by Init. It does not reflect providers
+// added, updated or lazily initialized afterwards.
+func (bifrost *Bifrost) InitSummary() InitSummary {
+	return bifrost.initSummary
+}
+
 // PUBLIC API METHODS
 
 // TextCompletionRequest sends a text completion request to the specified provider.
 func (bifrost *Bifrost) TextCompletionRequest(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req.Input.TextCompletionInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "text not provided for text completion request",
-			},
-		}
+		return nil, newValidationError("Input.TextCompletionInput", "required")
 	}
 
-	return bifrost.handleRequest(ctx, req, TextCompletionRequest)
+	result, bifrostErr := bifrost.handleRequest(ctx, req, TextCompletionRequest)
+	return bifrost.postProcessResponse(req, result), bifrostErr
 }
 
 // ChatCompletionRequest sends a chat completion request to the specified provider.
 func (bifrost *Bifrost) ChatCompletionRequest(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req.Input.ChatCompletionInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "chats not provided for chat completion request",
-			},
-		}
+		return nil, newValidationError("Input.ChatCompletionInput", "required")
+	}
+
+	result, bifrostErr := bifrost.handleRequest(ctx, req, ChatCompletionRequest)
+	if bifrostErr != nil {
+		return result, bifrostErr
+	}
+
+	result = bifrost.checkToolCallArguments(ctx, req, result)
+	if req.AutoContinue != nil {
+		result = bifrost.continueTruncatedResponse(ctx, req, result)
 	}
 
-	return bifrost.handleRequest(ctx, req, ChatCompletionRequest)
+	return bifrost.postProcessResponse(req, result), nil
 }
 
 // ChatCompletionStreamRequest sends a chat completion stream request to the specified provider.
 func (bifrost *Bifrost) ChatCompletionStreamRequest(ctx context.Context, req *schemas.BifrostRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	if req.Input.ChatCompletionInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "chats not provided for chat completion request",
-			},
-		}
+		return nil, newValidationError("Input.ChatCompletionInput", "required")
 	}
 
-	return bifrost.handleStreamRequest(ctx, req, ChatCompletionStreamRequest)
+	stream, bifrostErr := bifrost.handleStreamRequest(ctx, req, ChatCompletionStreamRequest)
+	return bifrost.sequenceStream(ctx, bifrost.postProcessStreamChunks(ctx, req, stream)), bifrostErr
 }
 
 // EmbeddingRequest sends an embedding request to the specified provider.
 func (bifrost *Bifrost) EmbeddingRequest(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req.Input.EmbeddingInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "embedding input not provided for embedding request",
-			},
+		return nil, newValidationError("Input.EmbeddingInput", "required")
+	}
+
+	var result *schemas.BifrostResponse
+	var bifrostErr *schemas.BifrostError
+	if req.EmbeddingRouting != nil {
+		if req.ExactMatch {
+			return nil, newValidationError("EmbeddingRouting", "cannot be combined with ExactMatch")
 		}
+		result, bifrostErr = bifrost.handleRoutedEmbedding(ctx, req)
+	} else {
+		result, bifrostErr = bifrost.handleRequest(ctx, req, EmbeddingRequest)
 	}
 
-	return bifrost.handleRequest(ctx, req, EmbeddingRequest)
+	if bifrostErr == nil && req.NormalizeEmbeddings {
+		if err := normalizeEmbeddings(result); err != nil {
+			return nil, newBifrostError(err)
+		}
+	}
+	return bifrost.postProcessResponse(req, result), bifrostErr
 }
 
 // SpeechRequest sends a speech request to the specified provider.
 func (bifrost *Bifrost) SpeechRequest(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req.Input.SpeechInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "speech input not provided for speech request",
-			},
-		}
+		return nil, newValidationError("Input.SpeechInput", "required")
 	}
 
-	return bifrost.handleRequest(ctx, req, SpeechRequest)
+	result, bifrostErr := bifrost.handleRequest(ctx, req, SpeechRequest)
+	return bifrost.postProcessResponse(req, result), bifrostErr
 }
 
 // SpeechStreamRequest sends a speech stream request to the specified provider.
 func (bifrost *Bifrost) SpeechStreamRequest(ctx context.Context, req *schemas.BifrostRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	if req.Input.SpeechInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "speech input not provided for speech stream request",
-			},
-		}
+		return nil, newValidationError("Input.SpeechInput", "required")
 	}
 
-	return bifrost.handleStreamRequest(ctx, req, SpeechStreamRequest)
+	stream, bifrostErr := bifrost.handleStreamRequest(ctx, req, SpeechStreamRequest)
+	return bifrost.sequenceStream(ctx, bifrost.postProcessStreamChunks(ctx, req, stream)), bifrostErr
 }
 
 // TranscriptionRequest sends a transcription request to the specified provider.
 func (bifrost *Bifrost) TranscriptionRequest(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req.Input.TranscriptionInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "transcription input not provided for transcription request",
-			},
-		}
+		return nil, newValidationError("Input.TranscriptionInput", "required")
+	}
+
+	if req.TranscriptionChunking != nil {
+		result, bifrostErr := bifrost.handleChunkedTranscription(ctx, req)
+		return bifrost.postProcessResponse(req, result), bifrostErr
 	}
 
-	return bifrost.handleRequest(ctx, req, TranscriptionRequest)
+	result, bifrostErr := bifrost.handleRequest(ctx, req, TranscriptionRequest)
+	return bifrost.postProcessResponse(req, result), bifrostErr
 }
 
 // TranscriptionStreamRequest sends a transcription stream request to the specified provider.
 func (bifrost *Bifrost) TranscriptionStreamRequest(ctx context.Context, req *schemas.BifrostRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	if req.Input.TranscriptionInput == nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: "transcription input not provided for transcription stream request",
-			},
-		}
+		return nil, newValidationError("Input.TranscriptionInput", "required")
 	}
 
-	return bifrost.handleStreamRequest(ctx, req, TranscriptionStreamRequest)
+	stream, bifrostErr := bifrost.handleStreamRequest(ctx, req, TranscriptionStreamRequest)
+	return bifrost.sequenceStream(ctx, bifrost.postProcessStreamChunks(ctx, req, stream)), bifrostErr
 }
 
 // UpdateProviderConcurrency dynamically updates the queue size and concurrency for an existing provider.
@@ -319,10 +462,16 @@ func (bifrost *Bifrost) UpdateProviderConcurrency(providerKey schemas.ModelProvi
 	bifrost.logger.Info(fmt.Sprintf("Updating concurrency configuration for provider %s", providerKey))
 
 	// Get the updated configuration from the account
-	providerConfig, err := bifrost.account.GetConfigForProvider(providerKey)
+	providerConfig, err := bifrost.getProviderConfig(providerKey)
 	if err != nil {
 		return fmt.Errorf("failed to get updated config for provider %s: %v", providerKey, err)
 	}
+	if err := validateKeepaliveInterval(providerConfig.NetworkConfig); err != nil {
+		return fmt.Errorf("invalid network config for provider %s: %v", providerKey, err)
+	}
+	if err := bifrost.configureAdaptiveConcurrency(providerKey, providerConfig.ConcurrencyAndBufferSize); err != nil {
+		return err
+	}
 
 	// Lock the provider to prevent concurrent access during update
 	providerMutex := bifrost.getProviderMutex(providerKey)
@@ -337,6 +486,11 @@ func (bifrost *Bifrost) UpdateProviderConcurrency(providerKey schemas.ModelProvi
 		return bifrost.prepareProvider(providerKey, providerConfig)
 	}
 
+	// Paused workers do not dequeue, so they would never notice the old queue being closed
+	if bifrost.getProviderGate(providerKey).isPaused() {
+		return fmt.Errorf("provider %s is paused, resume it before updating its concurrency", providerKey)
+	}
+
 	oldQueue := oldQueueValue.(chan ChannelMessage)
 
 	bifrost.logger.Debug(fmt.Sprintf("Gracefully stopping existing workers for provider %s", providerKey))
@@ -415,8 +569,12 @@ transferComplete:
 	if err != nil {
 		return fmt.Errorf("failed to create provider instance for %s: %v", providerKey, err)
 	}
+	bifrost.providerInstances.Store(providerKey, provider)
 
-	// Step 8: Start new workers with updated concurrency
+	// Step 8: Restart the keepalive loop with the updated configuration
+	bifrost.startKeepalive(providerKey, providerConfig)
+
+	// Step 9: Start new workers with updated concurrency
 	bifrost.logger.Debug(fmt.Sprintf("Starting %d new workers for provider %s with buffer size %d",
 		providerConfig.ConcurrencyAndBufferSize.Concurrency,
 		providerKey,
@@ -433,6 +591,78 @@ transferComplete:
 	return nil
 }
 
+// PauseProvider stops the workers of a provider from dequeuing new requests, and waits until
+// the requests they are processing have finished. Workers are kept alive, and requests can still
+// be queued while the provider is paused (subject to DropExcessRequests); they are processed once
+// ResumeProvider is called. Streams that have already started are not interrupted.
+//
+// If ctx is done before in-flight requests finish, ctx.Err() is returned and the provider stays
+// paused. Pausing a provider that is not initialized yet applies once it is.
+func (bifrost *Bifrost) PauseProvider(ctx context.Context, providerKey schemas.ModelProvider) error {
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	gate := bifrost.getProviderGate(providerKey)
+	gate.setPaused(true)
+	bifrost.logger.Info(fmt.Sprintf("Paused request dispatch for provider %s, draining in-flight requests", providerKey))
+
+	if err := gate.waitIdle(ctx); err != nil {
+		return fmt.Errorf("provider %s is paused but in-flight requests did not finish: %w", providerKey, err)
+	}
+
+	bifrost.logger.Info(fmt.Sprintf("Provider %s is paused and drained", providerKey))
+	return nil
+}
+
+// ResumeProvider resumes the dispatch of requests paused with PauseProvider.
+// Requests queued while the provider was paused are processed in order.
+func (bifrost *Bifrost) ResumeProvider(providerKey schemas.ModelProvider) {
+	bifrost.getProviderGate(providerKey).setPaused(false)
+	bifrost.logger.Info(fmt.Sprintf("Resumed request dispatch for provider %s", providerKey))
+}
+
+// IsProviderPaused reports whether the dispatch of requests is paused for a provider.
+func (bifrost *Bifrost) IsProviderPaused(providerKey schemas.ModelProvider) bool {
+	return bifrost.getProviderGate(providerKey).isPaused()
+}
+
+// GetCapabilities returns the operations and features supported by a provider.
+// The provider is initialized from the account's configuration if it has not been used yet.
+func (bifrost *Bifrost) GetCapabilities(providerKey schemas.ModelProvider) (schemas.ProviderCapabilities, error) {
+	provider, err := bifrost.getProvider(providerKey)
+	if err != nil {
+		return schemas.ProviderCapabilities{}, err
+	}
+	return provider.Capabilities(), nil
+}
+
+// GetModelCapabilities returns the operations and features supported by a provider for a model.
+// They differ from GetCapabilities for providers serving model families with different features,
+// e.g. Claude models on Vertex have no seed parameter. The provider is initialized from the
+// account's configuration if it has not been used yet.
+func (bifrost *Bifrost) GetModelCapabilities(providerKey schemas.ModelProvider, model string) (schemas.ProviderCapabilities, error) {
+	provider, err := bifrost.getProvider(providerKey)
+	if err != nil {
+		return schemas.ProviderCapabilities{}, err
+	}
+	return modelCapabilities(provider, model), nil
+}
+
+// getProvider returns the instance of a provider, initializing it from the account's
+// configuration if it has not been used yet.
+func (bifrost *Bifrost) getProvider(providerKey schemas.ModelProvider) (schemas.Provider, error) {
+	if _, err := bifrost.getProviderQueue(providerKey); err != nil {
+		return nil, err
+	}
+
+	providerValue, exists := bifrost.providerInstances.Load(providerKey)
+	if !exists {
+		return nil, fmt.Errorf("provider %s is not initialized", providerKey)
+	}
+	return providerValue.(schemas.Provider), nil
+}
+
 // GetDropExcessRequests returns the current value of DropExcessRequests
 func (bifrost *Bifrost) GetDropExcessRequests() bool {
 	return bifrost.dropExcessRequests.Load()
@@ -451,6 +681,112 @@ func (bifrost *Bifrost) getProviderMutex(providerKey schemas.ModelProvider) *syn
 	return mutexValue.(*sync.RWMutex)
 }
 
+// getProviderConfig returns the account's configuration for a provider, with the settings it leaves
+// unset taken from the Bifrost-level defaults, then from the built-in defaults. The account's config
+// is copied and never modified.
+func (bifrost *Bifrost) getProviderConfig(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	accountConfig, err := bifrost.account.GetConfigForProvider(providerKey)
+	if err != nil {
+		return nil, err
+	}
+	if accountConfig == nil {
+		return nil, fmt.Errorf("no config found for provider %s", providerKey)
+	}
+
+	config := *accountConfig
+	config.ApplyDefaults(bifrost.defaultNetworkConfig, bifrost.defaultConcurrencyConfig)
+	config.CheckAndSetDefaults()
+	return &config, nil
+}
+
+// getProviderGate returns the dispatch gate for a provider, creating it if it doesn't exist.
+// Gates outlive the provider's workers, so a paused provider stays paused across updates.
+func (bifrost *Bifrost) getProviderGate(providerKey schemas.ModelProvider) *providerGate {
+	if gateValue, ok := bifrost.providerGates.Load(providerKey); ok {
+		return gateValue.(*providerGate)
+	}
+	gateValue, _ := bifrost.providerGates.LoadOrStore(providerKey, newProviderGate())
+	return gateValue.(*providerGate)
+}
+
+// providerGate controls whether the workers of a provider dequeue requests, and counts the
+// requests they are processing so that pausing can wait for them to finish.
+type providerGate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+	active int // requests being processed by the provider's workers
+}
+
+func newProviderGate() *providerGate {
+	gate := &providerGate{}
+	gate.cond = sync.NewCond(&gate.mu)
+	return gate
+}
+
+// waitResumed blocks while the provider is paused.
+func (gate *providerGate) waitResumed() {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	for gate.paused {
+		gate.cond.Wait()
+	}
+}
+
+// enter marks a dequeued request as being processed. A request dequeued just as the provider
+// was paused is held here until the provider is resumed.
+func (gate *providerGate) enter() {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	for gate.paused {
+		gate.cond.Wait()
+	}
+	gate.active++
+}
+
+// leave marks a request as done and wakes up callers waiting for the provider to be idle.
+func (gate *providerGate) leave() {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	gate.active--
+	gate.cond.Broadcast()
+}
+
+// setPaused pauses or resumes the dispatch of requests.
+func (gate *providerGate) setPaused(paused bool) {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	gate.paused = paused
+	gate.cond.Broadcast()
+}
+
+// isPaused reports whether the dispatch of requests is paused.
+func (gate *providerGate) isPaused() bool {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	return gate.paused
+}
+
+// waitIdle blocks until no request is being processed, or until ctx is done.
+func (gate *providerGate) waitIdle(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		gate.mu.Lock()
+		defer gate.mu.Unlock()
+		gate.cond.Broadcast()
+	})
+	defer stop()
+
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	for gate.active > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		gate.cond.Wait()
+	}
+	return nil
+}
+
 // MCP PUBLIC API
 
 // RegisterMCPTool registers a typed tool handler with the MCP integration.
@@ -484,6 +820,27 @@ func (bifrost *Bifrost) RegisterMCPTool(name, description string, handler func(a
 	return bifrost.mcpManager.registerTool(name, description, handler, toolSchema)
 }
 
+// RegisterMCPMultimodalTool registers a tool handler that returns multimodal content.
+// The returned content blocks (text and images) are passed back to the model in the
+// tool message, which lets tools such as chart renderers return the image itself.
+//
+// Parameters:
+//   - name: Unique tool name
+//   - description: Human-readable tool description
+//   - handler: Function that handles tool execution and returns content blocks.
+//     Image blocks must use base64 data URLs (data:<mime>;base64,<data>).
+//   - toolSchema: Bifrost tool schema for function calling
+//
+// Returns:
+//   - error: Any registration error
+func (bifrost *Bifrost) RegisterMCPMultimodalTool(name, description string, handler func(args any) ([]schemas.ContentBlock, error), toolSchema schemas.Tool) error {
+	if bifrost.mcpManager == nil {
+		return fmt.Errorf("MCP is not configured in this Bifrost instance")
+	}
+
+	return bifrost.mcpManager.registerMultimodalTool(name, description, handler, toolSchema)
+}
+
 // ExecuteMCPTool executes an MCP tool call and returns the result as a tool message.
 // This is the main public API for manual MCP tool execution.
 //
@@ -518,6 +875,54 @@ func (bifrost *Bifrost) ExecuteMCPTool(ctx context.Context, toolCall schemas.Too
 	return result, nil
 }
 
+// WithMCPToolCallTracking returns a context that tracks tool calls executed through
+// ExecuteMCPTool for a single logical request (e.g. one agentic loop). Each chat completion
+// request made with the context starts a new iteration. Once the configured
+// MaxToolCallIterations is reached, tool calls of further iterations fail with an
+// MCPToolCallLimitError carrying the accumulated tool call history. Contexts already being
+// tracked are returned as-is.
+//
+// Without tracking, the limit is still enforced on chat completion requests, from the tool
+// calls in their conversation.
+//
+// Parameters:
+//   - ctx: Parent context for the logical request
+//
+// Returns:
+//   - context.Context: Context carrying the tool call tracker
+//   - *MCPToolCallTracker: The tracker, for inspecting the count and history
+func (bifrost *Bifrost) WithMCPToolCallTracking(ctx context.Context) (context.Context, *MCPToolCallTracker) {
+	if tracker, ok := ctx.Value(MCPContextKeyToolCallTracker).(*MCPToolCallTracker); ok && tracker != nil {
+		return ctx, tracker
+	}
+
+	maxIterations := schemas.DefaultMaxToolCallIterations
+	if bifrost.mcpManager != nil {
+		maxIterations = bifrost.mcpManager.maxToolCallIterations
+	}
+
+	tracker := newToolCallTracker(maxIterations)
+	return context.WithValue(ctx, MCPContextKeyToolCallTracker, tracker), tracker
+}
+
+// trackMCPIteration starts a new iteration of the context's tool call tracker for chat
+// completion requests, and fails requests whose conversation already holds more tool call
+// iterations than MaxToolCallIterations allows, see MCPManager.checkToolCallIterations.
+func (bifrost *Bifrost) trackMCPIteration(ctx context.Context, req *schemas.BifrostRequest, requestType RequestType) *schemas.BifrostError {
+	if bifrost.mcpManager == nil || (requestType != ChatCompletionRequest && requestType != ChatCompletionStreamRequest) {
+		return nil
+	}
+
+	if err := bifrost.mcpManager.checkToolCallIterations(req); err != nil {
+		bifrost.logger.Warn(fmt.Sprintf("%s %v", MCPLogPrefix, err))
+		return newBifrostError(err)
+	}
+	if tracker, ok := ctx.Value(MCPContextKeyToolCallTracker).(*MCPToolCallTracker); ok && tracker != nil {
+		tracker.NextIteration()
+	}
+	return nil
+}
+
 // IMPORTANT: Running the MCP client management operations (GetMCPClients, AddMCPClient, RemoveMCPClient, EditMCPClientTools)
 // may temporarily increase latency for incoming requests while the operations are being processed.
 // These operations involve network I/O and connection management that require mutex locks
@@ -548,14 +953,22 @@ func (bifrost *Bifrost) GetMCPClients() ([]schemas.MCPClient, error) {
 		state := schemas.MCPConnectionStateConnected
 		if client.Conn == nil {
 			state = schemas.MCPConnectionStateDisconnected
+			if client.healthErr != nil {
+				state = schemas.MCPConnectionStateError
+			}
 		}
 
-		clientsInConfig = append(clientsInConfig, schemas.MCPClient{
+		mcpClient := schemas.MCPClient{
 			Name:   client.Name,
 			Config: client.ExecutionConfig,
 			Tools:  tools,
 			State:  state,
-		})
+		}
+		if !client.LastHealthCheck.IsZero() {
+			lastHealthCheck := client.LastHealthCheck
+			mcpClient.LastHealthCheck = &lastHealthCheck
+		}
+		clientsInConfig = append(clientsInConfig, mcpClient)
 	}
 
 	return clientsInConfig, nil
@@ -580,8 +993,9 @@ func (bifrost *Bifrost) GetMCPClients() ([]schemas.MCPClient, error) {
 func (bifrost *Bifrost) AddMCPClient(config schemas.MCPClientConfig) error {
 	if bifrost.mcpManager == nil {
 		manager := &MCPManager{
-			clientMap: make(map[string]*MCPClient),
-			logger:    bifrost.logger,
+			clientMap:             make(map[string]*MCPClient),
+			logger:                bifrost.logger,
+			maxToolCallIterations: schemas.DefaultMaxToolCallIterations,
 		}
 
 		bifrost.mcpManager = manager
@@ -638,7 +1052,9 @@ func (bifrost *Bifrost) EditMCPClientTools(name string, toolsToAdd []string, too
 	return bifrost.mcpManager.EditClientTools(name, toolsToAdd, toolsToRemove)
 }
 
-// ReconnectMCPClient attempts to reconnect an MCP client if it is disconnected.
+// ReconnectMCPClient attempts to reconnect an MCP client if it is disconnected. If the attempt
+// fails, the client keeps being reconnected in the background with a jittered exponential backoff,
+// as configured by its MCPClientConfig.Reconnect, until Cleanup is called.
 //
 // Parameters:
 //   - name: Name of the client to reconnect
@@ -660,19 +1076,19 @@ func (bifrost *Bifrost) ReconnectMCPClient(name string) error {
 func (bifrost *Bifrost) createProviderFromProviderKey(providerKey schemas.ModelProvider, config *schemas.ProviderConfig) (schemas.Provider, error) {
 	switch providerKey {
 	case schemas.OpenAI:
-		return providers.NewOpenAIProvider(config, bifrost.logger), nil
+		return providers.NewOpenAIProvider(config, bifrost.logger)
 	case schemas.Anthropic:
-		return providers.NewAnthropicProvider(config, bifrost.logger), nil
+		return providers.NewAnthropicProvider(config, bifrost.logger)
 	case schemas.Bedrock:
 		return providers.NewBedrockProvider(config, bifrost.logger)
 	case schemas.Cohere:
-		return providers.NewCohereProvider(config, bifrost.logger), nil
+		return providers.NewCohereProvider(config, bifrost.logger)
 	case schemas.Azure:
 		return providers.NewAzureProvider(config, bifrost.logger)
 	case schemas.Vertex:
 		return providers.NewVertexProvider(config, bifrost.logger)
 	case schemas.Mistral:
-		return providers.NewMistralProvider(config, bifrost.logger), nil
+		return providers.NewMistralProvider(config, bifrost.logger)
 	case schemas.Ollama:
 		return providers.NewOllamaProvider(config, bifrost.logger)
 	case schemas.Groq:
@@ -688,10 +1104,19 @@ func (bifrost *Bifrost) createProviderFromProviderKey(providerKey schemas.ModelP
 // It initializes the request queue and starts worker goroutines for processing requests.
 // Note: This function assumes the caller has already acquired the appropriate mutex for the provider.
 func (bifrost *Bifrost) prepareProvider(providerKey schemas.ModelProvider, config *schemas.ProviderConfig) error {
-	providerConfig, err := bifrost.account.GetConfigForProvider(providerKey)
+	providerConfig, err := bifrost.getProviderConfig(providerKey)
 	if err != nil {
 		return fmt.Errorf("failed to get config for provider: %v", err)
 	}
+	if err := validateRequestTimeouts(providerConfig.NetworkConfig); err != nil {
+		return fmt.Errorf("invalid network config for provider %s: %v", providerKey, err)
+	}
+	if err := validateKeepaliveInterval(providerConfig.NetworkConfig); err != nil {
+		return fmt.Errorf("invalid network config for provider %s: %v", providerKey, err)
+	}
+	if err := bifrost.configureAdaptiveConcurrency(providerKey, providerConfig.ConcurrencyAndBufferSize); err != nil {
+		return err
+	}
 
 	queue := make(chan ChannelMessage, providerConfig.ConcurrencyAndBufferSize.BufferSize) // Buffered channel per provider
 
@@ -704,6 +1129,13 @@ func (bifrost *Bifrost) prepareProvider(providerKey schemas.ModelProvider, confi
 	if err != nil {
 		return fmt.Errorf("failed to create provider for the given key: %v", err)
 	}
+	bifrost.providerInstances.Store(providerKey, provider)
+
+	if providerConfig.NetworkConfig.WarmupOnInit {
+		// Warm up in the background, so that Init does not wait for a round trip to every provider
+		go bifrost.warmupProvider(provider, providerConfig)
+	}
+	bifrost.startKeepalive(providerKey, providerConfig)
 
 	for range providerConfig.ConcurrencyAndBufferSize.Concurrency {
 		waitGroupValue, _ := bifrost.waitGroups.Load(providerKey)
@@ -715,6 +1147,30 @@ func (bifrost *Bifrost) prepareProvider(providerKey schemas.ModelProvider, confi
 	return nil
 }
 
+// warmupProvider pre-establishes a connection to the provider's API if the provider supports it.
+// Failures are logged and otherwise ignored, so the provider still starts normally.
+func (bifrost *Bifrost) warmupProvider(provider schemas.Provider, config *schemas.ProviderConfig) {
+	warmupProvider, ok := provider.(schemas.WarmupProvider)
+	if !ok {
+		bifrost.logger.Debug(fmt.Sprintf("Provider %s does not support warmup, skipping", provider.GetProviderKey()))
+		return
+	}
+
+	timeout := time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = time.Duration(schemas.DefaultRequestTimeoutInSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(bifrost.backgroundCtx, timeout)
+	defer cancel()
+
+	if err := warmupProvider.Warmup(ctx); err != nil {
+		bifrost.logger.Warn(fmt.Sprintf("Warmup failed for provider %s: %v", provider.GetProviderKey(), err))
+		return
+	}
+
+	bifrost.logger.Debug(fmt.Sprintf("Warmed up connection for provider %s", provider.GetProviderKey()))
+}
+
 // getProviderQueue returns the request queue for a given provider key.
 // If the queue doesn't exist, it creates one at runtime and initializes the provider,
 // given the provider config is provided in the account interface implementation.
@@ -744,7 +1200,7 @@ func (bifrost *Bifrost) getProviderQueue(providerKey schemas.ModelProvider) (cha
 
 	bifrost.logger.Debug(fmt.Sprintf("Creating new request queue for provider %s at runtime", providerKey))
 
-	config, err := bifrost.account.GetConfigForProvider(providerKey)
+	config, err := bifrost.getProviderConfig(providerKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config for provider: %v", err)
 	}
@@ -791,21 +1247,132 @@ func (bifrost *Bifrost) shouldTryFallbacks(req *schemas.BifrostRequest, primaryE
 	return true
 }
 
+// replaceDecommissionedModel returns a copy of req that uses the replacement of its model from the
+// provider's DecommissionedModels, if err reports that the model was not found. It returns nil if
+// the request should not be retried with another model, e.g. because it is an ExactMatch request.
+func (bifrost *Bifrost) replaceDecommissionedModel(req *schemas.BifrostRequest, err *schemas.BifrostError) *schemas.BifrostRequest {
+	if req.ExactMatch || !isModelNotFoundError(err) {
+		return nil
+	}
+
+	config, configErr := bifrost.getProviderConfig(req.Provider)
+	if configErr != nil {
+		return nil
+	}
+	replacement, ok := config.DecommissionedModels[req.Model]
+	if !ok || replacement == "" || replacement == req.Model {
+		return nil
+	}
+
+	bifrost.logger.Warn(fmt.Sprintf("Model %s of provider %s was not found, retrying with its replacement %s", req.Model, req.Provider, replacement))
+	replacementReq := *req
+	replacementReq.Model = replacement
+	return &replacementReq
+}
+
+// validateCapabilities rejects requests with parameters that the target provider does not support
+// for the model. Requests to providers that cannot be initialized are left to fail when sent.
+func (bifrost *Bifrost) validateCapabilities(req *schemas.BifrostRequest) *schemas.BifrostError {
+	capabilities, err := bifrost.GetModelCapabilities(req.Provider, req.Model)
+	if err != nil {
+		return nil
+	}
+	if param := unsupportedParam(req.Params, capabilities); param != "" {
+		return &schemas.BifrostError{
+			IsBifrostError: false,
+			Provider:       req.Provider,
+			Error: schemas.ErrorField{
+				Type:    Ptr(schemas.UnsupportedOperation),
+				Message: fmt.Sprintf("%s is not supported by %s provider for model %s", param, req.Provider, req.Model),
+			},
+		}
+	}
+	return nil
+}
+
 // prepareFallbackRequest creates a fallback request and validates the provider config
-// Returns the fallback request or nil if this fallback should be skipped
-func (bifrost *Bifrost) prepareFallbackRequest(req *schemas.BifrostRequest, fallback schemas.Fallback) *schemas.BifrostRequest {
+// Returns the fallback request, or nil and the reason if this fallback should be skipped
+func (bifrost *Bifrost) prepareFallbackRequest(req *schemas.BifrostRequest, fallback schemas.Fallback) (*schemas.BifrostRequest, string) {
 	// Check if we have config for this fallback provider
 	_, err := bifrost.account.GetConfigForProvider(fallback.Provider)
 	if err != nil {
 		bifrost.logger.Warn(fmt.Sprintf("Config not found for provider %s, skipping fallback: %v", fallback.Provider, err))
-		return nil
+		return nil, fmt.Sprintf("config not found: %v", err)
+	}
+
+	// Providers that cannot be initialized are tried anyway, so that the attempt records the error
+	if capabilities, err := bifrost.GetModelCapabilities(fallback.Provider, fallback.Model); err == nil {
+		if param := unsupportedParam(req.Params, capabilities); param != "" {
+			bifrost.logger.Warn(fmt.Sprintf("Provider %s does not support %s for model %s, skipping fallback", fallback.Provider, param, fallback.Model))
+			return nil, fmt.Sprintf("%s is not supported", param)
+		}
 	}
 
 	// Create a new request with the fallback provider and model
 	fallbackReq := *req
 	fallbackReq.Provider = fallback.Provider
 	fallbackReq.Model = fallback.Model
-	return &fallbackReq
+	fallbackReq.BaseURL = nil // Base URL overrides target the primary provider only
+	return &fallbackReq, ""
+}
+
+// pinExactTarget undoes the changes of plugin PreHooks to the provider and model of an
+// ExactMatch request, so that it is sent to exactly the provider and model it specified before
+// the PreHooks ran. preReq is the request returned by the PreHooks, and exactMatch, provider and
+// model are taken from the request before they ran. The flag is kept on the result, since a
+// PreHook may have cleared it.
+func (bifrost *Bifrost) pinExactTarget(preReq *schemas.BifrostRequest, exactMatch bool, provider schemas.ModelProvider, model string) *schemas.BifrostRequest {
+	if !exactMatch || (preReq.ExactMatch && preReq.Provider == provider && preReq.Model == model) {
+		return preReq
+	}
+	if preReq.Provider != provider || preReq.Model != model {
+		bifrost.logger.Debug(fmt.Sprintf("Ignoring the plugin rewrite of exact match request for provider %s with model %s to %s/%s", provider, model, preReq.Provider, preReq.Model))
+	}
+	pinnedReq := *preReq
+	pinnedReq.Provider = provider
+	pinnedReq.Model = model
+	pinnedReq.ExactMatch = true
+	return &pinnedReq
+}
+
+// selectPrimary picks the primary provider and model of a request among its PrimaryCandidates,
+// by weight. It returns a copy of the request targeting the chosen candidate, and a context that
+// carries the choice so it can be reported on the response. Requests without candidates are
+// returned unchanged.
+func (bifrost *Bifrost) selectPrimary(ctx context.Context, req *schemas.BifrostRequest) (context.Context, *schemas.BifrostRequest, *schemas.BifrostError) {
+	if req == nil || len(req.PrimaryCandidates) == 0 {
+		return ctx, req, nil
+	}
+	if req.ExactMatch {
+		return ctx, req, newValidationError("PrimaryCandidates", "cannot be combined with ExactMatch")
+	}
+	if err := validatePrimaryCandidates(req.PrimaryCandidates); err != nil {
+		return ctx, req, err
+	}
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	weights := make([]float64, len(req.PrimaryCandidates))
+	for i, candidate := range req.PrimaryCandidates {
+		weights[i] = candidate.Weight
+	}
+	candidate := req.PrimaryCandidates[PickWeighted(weights)]
+
+	primaryReq := *req
+	primaryReq.Provider = candidate.Provider
+	primaryReq.Model = candidate.Model
+	primaryReq.PrimaryCandidates = nil
+	if candidate.Provider != req.Provider {
+		primaryReq.BaseURL = nil // Base URL overrides target the request's provider only
+	}
+
+	bifrost.logger.Debug(fmt.Sprintf("Selected primary provider %s with model %s", candidate.Provider, candidate.Model))
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeySelectedPrimary, &schemas.SelectedPrimary{
+		Provider: candidate.Provider,
+		Model:    candidate.Model,
+	})
+	return ctx, &primaryReq, nil
 }
 
 // shouldContinueWithFallbacks processes errors from fallback attempts
@@ -826,18 +1393,67 @@ func (bifrost *Bifrost) shouldContinueWithFallbacks(fallback schemas.Fallback, f
 	return true
 }
 
+// reportFailover calls the OnFailover hook, if configured, when a request falls back from a failed
+// attempt to the attempt-th fallback tried. A panicking hook is recovered so that it cannot fail
+// the request.
+func (bifrost *Bifrost) reportFailover(requestType RequestType, failed schemas.AttemptError, fallback schemas.Fallback, attempt int) {
+	if bifrost.onFailover == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("OnFailover hook panicked: %v", r))
+		}
+	}()
+
+	bifrost.onFailover(schemas.FailoverEvent{
+		RequestType: string(requestType),
+		Failed:      failed,
+		Fallback:    fallback,
+		Attempt:     attempt,
+	})
+}
+
 // handleRequest handles the request to the provider based on the request type
 // It handles plugin hooks, request validation, response processing, and fallback providers.
 // If the primary provider fails, it will try each fallback provider in order until one succeeds.
+// Hedged requests race the primary and fallbacks instead (see handleHedgedRequest).
 // It is the wrapper for all non-streaming public API methods.
 func (bifrost *Bifrost) handleRequest(ctx context.Context, req *schemas.BifrostRequest, requestType RequestType) (*schemas.BifrostResponse, *schemas.BifrostError) {
-	if err := validateRequest(req); err != nil {
+	ctx = withRequestedModel(ctx, req)
+	ctx, req, err := bifrost.selectPrimary(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRequest(req, requestType); err != nil {
 		err.Provider = req.Provider
 		return nil, err
 	}
+	if err := bifrost.validateCapabilities(req); err != nil {
+		return nil, err
+	}
+	if err := bifrost.trackMCPIteration(ctx, req, requestType); err != nil {
+		err.Provider = req.Provider
+		return nil, err
+	}
+	req = withOutputLanguage(req, requestType)
+
+	ctx, req = bifrost.skipUnhealthyPrimary(ctx, req, requestType)
+	ctx = withImageCache(ctx)
+	ctx = bifrost.withCorrelationIDs(ctx, req)
+
+	if req.Hedging != nil && len(req.Fallbacks) > 0 {
+		return bifrost.handleHedgedRequest(ctx, req, requestType)
+	}
 
 	// Try the primary provider first
 	primaryResult, primaryErr := bifrost.tryRequest(req, ctx, requestType)
+	if replacementReq := bifrost.replaceDecommissionedModel(req, primaryErr); replacementReq != nil {
+		req = replacementReq
+		primaryResult, primaryErr = bifrost.tryRequest(req, ctx, requestType)
+	}
 
 	// Check if we should proceed with fallbacks
 	shouldTryFallbacks := bifrost.shouldTryFallbacks(req, primaryErr)
@@ -845,27 +1461,49 @@ func (bifrost *Bifrost) handleRequest(ctx context.Context, req *schemas.BifrostR
 		return primaryResult, primaryErr
 	}
 
-	// Try fallbacks in order
-	for _, fallback := range req.Fallbacks {
-		fallbackReq := bifrost.prepareFallbackRequest(req, fallback)
+	// Record every failed attempt and skipped fallback so the final error explains why each
+	// provider failed or was not tried
+	attempts := []schemas.AttemptError{newAttemptError(req, primaryErr)}
+	var skipped []schemas.SkippedFallback
+
+	// Try fallbacks in order, up to MaxFallbacks
+	for i, fallback := range req.Fallbacks {
+		if untried, reached := fallbackLimitReached(req, len(attempts)-1, req.Fallbacks[i:]); reached {
+			skipped = append(skipped, untried...)
+			break
+		}
+		fallbackReq, reason := bifrost.prepareFallbackRequest(req, fallback)
 		if fallbackReq == nil {
+			skipped = append(skipped, schemas.SkippedFallback{Provider: fallback.Provider, Model: fallback.Model, Reason: reason})
 			continue
 		}
+		bifrost.reportFailover(requestType, attempts[len(attempts)-1], fallback, len(attempts))
 
 		// Try the fallback provider
 		result, fallbackErr := bifrost.tryRequest(fallbackReq, ctx, requestType)
+		if replacementReq := bifrost.replaceDecommissionedModel(fallbackReq, fallbackErr); replacementReq != nil {
+			fallbackReq = replacementReq
+			result, fallbackErr = bifrost.tryRequest(fallbackReq, ctx, requestType)
+		}
 		if fallbackErr == nil {
 			bifrost.logger.Info(fmt.Sprintf("Successfully used fallback provider %s with model %s", fallback.Provider, fallback.Model))
 			return result, nil
 		}
+		attempts = append(attempts, newAttemptError(fallbackReq, fallbackErr))
 
 		// Check if we should continue with more fallbacks
 		if !bifrost.shouldContinueWithFallbacks(fallback, fallbackErr) {
+			fallbackErr.AttemptHistory = attempts
+			fallbackErr.SkippedFallbacks = skipped
 			return nil, fallbackErr
 		}
 	}
 
 	primaryErr.Provider = req.Provider
+	if len(attempts) > 1 {
+		primaryErr.AttemptHistory = attempts
+	}
+	attachSkippedFallbacks(primaryErr, skipped, len(req.Fallbacks))
 	// All providers failed, return the original error
 	return nil, primaryErr
 }
@@ -875,13 +1513,35 @@ func (bifrost *Bifrost) handleRequest(ctx context.Context, req *schemas.BifrostR
 // If the primary provider fails, it will try each fallback provider in order until one succeeds.
 // It is the wrapper for all streaming public API methods.
 func (bifrost *Bifrost) handleStreamRequest(ctx context.Context, req *schemas.BifrostRequest, requestType RequestType) (chan *schemas.BifrostStream, *schemas.BifrostError) {
-	if err := validateRequest(req); err != nil {
+	ctx = withRequestedModel(ctx, req)
+	ctx, req, err := bifrost.selectPrimary(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRequest(req, requestType); err != nil {
+		err.Provider = req.Provider
+		return nil, err
+	}
+	if err := bifrost.validateCapabilities(req); err != nil {
+		return nil, err
+	}
+	if err := bifrost.trackMCPIteration(ctx, req, requestType); err != nil {
 		err.Provider = req.Provider
 		return nil, err
 	}
+	req = withOutputLanguage(req, requestType)
+
+	ctx, req = bifrost.skipUnhealthyPrimary(ctx, req, requestType)
+	ctx = withImageCache(ctx)
+	ctx = bifrost.withCorrelationIDs(ctx, req)
 
 	// Try the primary provider first
 	primaryResult, primaryErr := bifrost.tryStreamRequest(req, ctx, requestType)
+	if replacementReq := bifrost.replaceDecommissionedModel(req, primaryErr); replacementReq != nil {
+		req = replacementReq
+		primaryResult, primaryErr = bifrost.tryStreamRequest(req, ctx, requestType)
+	}
 
 	// Check if we should proceed with fallbacks
 	shouldTryFallbacks := bifrost.shouldTryFallbacks(req, primaryErr)
@@ -889,31 +1549,86 @@ func (bifrost *Bifrost) handleStreamRequest(ctx context.Context, req *schemas.Bi
 		return primaryResult, primaryErr
 	}
 
-	// Try fallbacks in order
-	for _, fallback := range req.Fallbacks {
-		fallbackReq := bifrost.prepareFallbackRequest(req, fallback)
+	// Record every failed attempt and skipped fallback so the final error explains why each
+	// provider failed or was not tried
+	attempts := []schemas.AttemptError{newAttemptError(req, primaryErr)}
+	var skipped []schemas.SkippedFallback
+
+	// Try fallbacks in order, up to MaxFallbacks
+	for i, fallback := range req.Fallbacks {
+		if untried, reached := fallbackLimitReached(req, len(attempts)-1, req.Fallbacks[i:]); reached {
+			skipped = append(skipped, untried...)
+			break
+		}
+		fallbackReq, reason := bifrost.prepareFallbackRequest(req, fallback)
 		if fallbackReq == nil {
+			skipped = append(skipped, schemas.SkippedFallback{Provider: fallback.Provider, Model: fallback.Model, Reason: reason})
 			continue
 		}
+		bifrost.reportFailover(requestType, attempts[len(attempts)-1], fallback, len(attempts))
 
 		// Try the fallback provider
 		result, fallbackErr := bifrost.tryStreamRequest(fallbackReq, ctx, requestType)
+		if replacementReq := bifrost.replaceDecommissionedModel(fallbackReq, fallbackErr); replacementReq != nil {
+			fallbackReq = replacementReq
+			result, fallbackErr = bifrost.tryStreamRequest(fallbackReq, ctx, requestType)
+		}
+		if fallbackErr != nil && bifrost.streamFallbackAdapter && isUnsupportedOperationError(fallbackErr) {
+			result, fallbackErr = bifrost.tryStreamRequestAsNonStreaming(fallbackReq, ctx, requestType, fallbackErr)
+		}
 		if fallbackErr == nil {
 			bifrost.logger.Info(fmt.Sprintf("Successfully used fallback provider %s with model %s", fallback.Provider, fallback.Model))
 			return result, nil
 		}
+		attempts = append(attempts, newAttemptError(fallbackReq, fallbackErr))
 
 		// Check if we should continue with more fallbacks
 		if !bifrost.shouldContinueWithFallbacks(fallback, fallbackErr) {
+			fallbackErr.AttemptHistory = attempts
+			fallbackErr.SkippedFallbacks = skipped
 			return nil, fallbackErr
 		}
 	}
 
 	primaryErr.Provider = req.Provider
+	if len(attempts) > 1 {
+		primaryErr.AttemptHistory = attempts
+	}
+	attachSkippedFallbacks(primaryErr, skipped, len(req.Fallbacks))
 	// All providers failed, return the original error
 	return nil, primaryErr
 }
 
+// tryStreamRequestAsNonStreaming issues the non-streaming equivalent of a stream request and
+// wraps the response as a single-chunk stream. It returns streamErr unchanged if the request
+// type has no non-streaming equivalent.
+func (bifrost *Bifrost) tryStreamRequestAsNonStreaming(req *schemas.BifrostRequest, ctx context.Context, requestType RequestType, streamErr *schemas.BifrostError) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	var nonStreamType RequestType
+	switch requestType {
+	case ChatCompletionStreamRequest:
+		nonStreamType = ChatCompletionRequest
+	case SpeechStreamRequest:
+		nonStreamType = SpeechRequest
+	case TranscriptionStreamRequest:
+		nonStreamType = TranscriptionRequest
+	default:
+		return nil, streamErr
+	}
+
+	bifrost.logger.Debug(fmt.Sprintf("Provider %s does not support %s, falling back to %s", req.Provider, requestType, nonStreamType))
+
+	resp, bifrostErr := bifrost.tryRequest(req, ctx, nonStreamType)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	if nonStreamType == ChatCompletionRequest {
+		convertToStreamChunk(resp)
+	}
+
+	return newBifrostMessageChan(resp), nil
+}
+
 // tryRequest is a generic function that handles common request processing logic
 // It consolidates queue setup, plugin pipeline execution, enqueue logic, and response handling
 func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Context, requestType RequestType) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -935,11 +1650,14 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 	pipeline := bifrost.getPluginPipeline()
 	defer bifrost.releasePluginPipeline(pipeline)
 
+	provider, model, exactMatch := req.Provider, req.Model, req.ExactMatch
 	preReq, shortCircuit, preCount := pipeline.RunPreHooks(&ctx, req)
 	if shortCircuit != nil {
 		// Handle short-circuit with response (success case)
 		if shortCircuit.Response != nil {
-			resp, bifrostErr := pipeline.RunPostHooks(&ctx, shortCircuit.Response, nil, preCount)
+			attachRequestedModel(ctx, shortCircuit.Response, req.Model)
+			accepted, rejection := bifrost.acceptResponse(req, shortCircuit.Response, nil)
+			resp, bifrostErr := pipeline.RunPostHooks(&ctx, accepted, rejection, preCount)
 			if bifrostErr != nil {
 				return nil, bifrostErr
 			}
@@ -958,8 +1676,12 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 		return nil, newBifrostErrorFromMsg("bifrost request after plugin hooks cannot be nil")
 	}
 
+	preReq = bifrost.pinExactTarget(preReq, exactMatch, provider, model)
+	preReq = bifrost.downscaleImages(ctx, preReq)
+
 	msg := bifrost.getChannelMessage(*preReq, requestType)
 	msg.Context = ctx
+	msg.EnqueuedAt = time.Now()
 
 	select {
 	case queue <- *msg:
@@ -986,7 +1708,9 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 	var resp *schemas.BifrostResponse
 	select {
 	case result = <-msg.Response:
-		resp, bifrostErr := pipeline.RunPostHooks(&ctx, result, nil, len(bifrost.plugins))
+		// Rejected responses reach the post-hooks as errors, so that plugins never cache or log them as successes
+		accepted, rejection := bifrost.acceptResponse(req, result, nil)
+		resp, bifrostErr := pipeline.RunPostHooks(&ctx, accepted, rejection, len(bifrost.plugins))
 		if bifrostErr != nil {
 			bifrost.releaseChannelMessage(msg)
 			return nil, bifrostErr
@@ -1025,10 +1749,12 @@ func (bifrost *Bifrost) tryStreamRequest(req *schemas.BifrostRequest, ctx contex
 	pipeline := bifrost.getPluginPipeline()
 	defer bifrost.releasePluginPipeline(pipeline)
 
+	provider, model, exactMatch := req.Provider, req.Model, req.ExactMatch
 	preReq, shortCircuit, preCount := pipeline.RunPreHooks(&ctx, req)
 	if shortCircuit != nil {
 		// Handle short-circuit with response (success case)
 		if shortCircuit.Response != nil {
+			attachRequestedModel(ctx, shortCircuit.Response, req.Model)
 			resp, bifrostErr := pipeline.RunPostHooks(&ctx, shortCircuit.Response, nil, preCount)
 			if bifrostErr != nil {
 				return nil, bifrostErr
@@ -1048,8 +1774,12 @@ func (bifrost *Bifrost) tryStreamRequest(req *schemas.BifrostRequest, ctx contex
 		return nil, newBifrostErrorFromMsg("bifrost request after plugin hooks cannot be nil")
 	}
 
+	preReq = bifrost.pinExactTarget(preReq, exactMatch, provider, model)
+	preReq = bifrost.downscaleImages(ctx, preReq)
+
 	msg := bifrost.getChannelMessage(*preReq, requestType)
 	msg.Context = ctx
+	msg.EnqueuedAt = time.Now()
 
 	select {
 	case queue <- *msg:
@@ -1092,7 +1822,17 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, queue chan Chan
 		}
 	}()
 
-	for req := range queue {
+	gate := bifrost.getProviderGate(provider.GetProviderKey())
+	for {
+		// Stop dequeuing while the provider is paused, requests stay in the queue meanwhile
+		gate.waitResumed()
+		req, ok := <-queue
+		if !ok {
+			break
+		}
+		gate.enter()
+		dequeuedAt := time.Now()
+
 		var result *schemas.BifrostResponse
 		var stream chan *schemas.BifrostStream
 		var bifrostError *schemas.BifrostError
@@ -1110,11 +1850,13 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, queue chan Chan
 						Error:   err,
 					},
 				}
+				bifrost.recordRequestTiming(&req, dequeuedAt, true)
+				gate.leave()
 				continue
 			}
 		}
 
-		config, err := bifrost.account.GetConfigForProvider(provider.GetProviderKey())
+		config, err := bifrost.getProviderConfig(provider.GetProviderKey())
 		if err != nil {
 			bifrost.logger.Warn(fmt.Sprintf("Error getting config for provider %s: %v", provider.GetProviderKey(), err))
 			req.Err <- schemas.BifrostError{
@@ -1124,98 +1866,260 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, queue chan Chan
 					Error:   err,
 				},
 			}
+			bifrost.recordRequestTiming(&req, dequeuedAt, true)
+			gate.leave()
 			continue
 		}
 
-		// Track attempts
+		// Track in-flight non-streaming requests by key so that revoking the key can cancel them.
+		// The cancellable context is only used for the provider calls; results are delivered
+		// on the caller's context so a revocation can never drop a response on the floor.
+		callerCtx := req.Context
+		if req.BaseURL != nil && *req.BaseURL != "" {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyBaseURL, *req.BaseURL)
+		}
+		extraHeaders := withContextHeaders(req.Context, config.NetworkConfig.ContextHeaders, scopeRequestHeaders(provider.GetProviderKey(), req.ExtraHeaders))
+		if extraHeaders = withIdentificationHeaders(config.NetworkConfig, extraHeaders); len(extraHeaders) > 0 {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyExtraHeaders, extraHeaders)
+		}
+		// Providers record the raw HTTP status and allowlisted headers of the last attempt here
+		metadata := &schemas.ResponseMetadata{
+			CaptureHeaders:     config.NetworkConfig.CaptureResponseHeaders,
+			CaptureRequestBody: config.SendBackRawRequest,
+		}
+		req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyResponseMetadata, metadata)
+		if config.RequestTransform != nil || config.ResponseTransform != nil {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyBodyTransforms, &schemas.BodyTransforms{
+				Request:  config.RequestTransform,
+				Response: config.ResponseTransform,
+			})
+		}
+		if config.NetworkConfig.RequestCompression != nil {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyRequestCompression, config.NetworkConfig.RequestCompression)
+		}
+		if config.RequestSigner != nil {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyRequestSigner, config.RequestSigner)
+		}
+		if config.LogStreamChunks && isStreamRequestType(req.Type) {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyStreamTap, true)
+		}
+		if bifrost.streamBufferSize > 0 && isStreamRequestType(req.Type) {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyStreamBufferSize, bifrost.streamBufferSize)
+		}
+		// Penalties the provider does not accept, e.g. after a fallback, are translated or dropped,
+		// unless the request must reach the provider as specified
+		if !req.ExactMatch {
+			req.Params = bifrost.normalizePenalties(modelCapabilities(provider, req.Model).Penalties, provider.GetProviderKey(), req.Model, req.Params)
+		}
+		untrack := func() {}
+		if keyID := revokedKeyID(key); keyID != "" && !isStreamRequestType(req.Type) {
+			req.Context, untrack = bifrost.trackInFlightKey(req.Context, keyID)
+		}
+
+		// Track attempts, and the status code of each retried attempt (0 if it had none)
 		var attempts int
+		var retryStatusCodes []int
+
+		// Measures the time to first token of streams from the start of the provider call
+		var firstToken firstTokenTimer
+		var chunkTiming *chunkTimer
+		if req.CaptureStreamTiming {
+			chunkTiming = &chunkTimer{}
+		}
 
 		// Create plugin pipeline for streaming requests outside retry loop to prevent leaks
 		var postHookRunner schemas.PostHookRunner
+		var usageTracker *streamUsageTracker
 		if isStreamRequestType(req.Type) {
 			pipeline := bifrost.getPluginPipeline()
 			defer bifrost.releasePluginPipeline(pipeline)
+			pipeline.skipInapplicablePlugins(req.Context, &req.BifrostRequest)
 
+			if req.Type == ChatCompletionStreamRequest && (req.OnUsageUpdate != nil || bifrost.onStreamAbort != nil) {
+				usageTracker = newStreamUsageTracker(&req.BifrostRequest)
+			}
+
+			metadataAttached := false
 			postHookRunner = func(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+				attachRequestedModel(req.Context, result, req.Model)
+				if firstToken.observe(result) {
+					bifrost.recordFirstToken(&req, firstToken.elapsed)
+				}
+				if chunkTiming != nil {
+					chunkTiming.observe(result)
+				}
+				// The response metadata is only attached to the first chunk of the stream
+				if result != nil && !metadataAttached {
+					attachResponseMetadata(result, metadata)
+					attachReproducibility(result, modelCapabilities(provider, req.Model), req.Params)
+					attachSelectedPrimary(*ctx, result)
+					attachSkippedProviders(*ctx, result)
+					attachRetries(result, retryStatusCodes)
+					metadataAttached = true
+				}
+				if err != nil {
+					attachProviderRequestID(err, metadata)
+				}
 				resp, bifrostErr := pipeline.RunPostHooks(ctx, result, err, len(bifrost.plugins))
 				if bifrostErr != nil {
 					return nil, bifrostErr
 				}
+				usageTracker.update(resp)
 				return resp, nil
 			}
 		}
 
-		// Execute request with retries
-		for attempts = 0; attempts <= config.NetworkConfig.MaxRetries; attempts++ {
+		// The provider's HTTP clients time out after the longest timeout of any request type,
+		// shorter timeouts of this request type are enforced on each attempt's context
+		timeout := config.NetworkConfig.RequestTimeout(string(req.Type))
+		enforceTimeout := timeout < config.NetworkConfig.MaxRequestTimeout()
+		providerCtx := req.Context
+
+		// Execute request with retries. Connection errors and retryable HTTP statuses share
+		// MaxRetries, unless they have separate budgets (see retryBudget).
+		retries := newRetryBudget(config.NetworkConfig, req.MaxRetries)
+		for attempts = 0; ; attempts++ {
 			if attempts > 0 {
 				// Log retry attempt
 				bifrost.logger.Info(fmt.Sprintf(
-					"Retrying request (attempt %d/%d) for model %s: %s",
-					attempts, config.NetworkConfig.MaxRetries, req.Model,
+					"Retrying request (attempt %d) for model %s: %s",
+					attempts, req.Model,
 					bifrostError.Error.Message,
 				))
 
 				// Calculate and apply backoff
-				backoff := calculateBackoff(attempts-1, config)
+				backoff := ComputeBackoff(attempts-1, config.NetworkConfig)
 				time.Sleep(backoff)
+
+				// Drop the context of the previous attempt, which may have timed out, and the
+				// metadata of its response, which must not be reported if this attempt gets none
+				req.Context = providerCtx
+				metadata.Reset(0)
 			}
 
 			bifrost.logger.Debug(fmt.Sprintf("Attempting request for provider %s", provider.GetProviderKey()))
 
+			// Wait for a slot of the provider's adaptive concurrency limit, if enabled
+			slot, acquireErr := bifrost.getAdaptiveLimiter(provider.GetProviderKey()).acquire(req.Context)
+			if acquireErr != nil {
+				bifrostError = acquireErr
+				break
+			}
+
+			// Wait for a global concurrency slot, if a global cap is configured
+			release, acquireErr := bifrost.acquireGlobalConcurrencySlot(req.Context)
+			if acquireErr != nil {
+				slot.release()
+				bifrostError = acquireErr
+				break
+			}
+
+			cancelAttempt := func() {}
+			if enforceTimeout {
+				req.Context, cancelAttempt = withAttemptTimeout(providerCtx, timeout, isStreamRequestType(req.Type))
+			}
+
 			// Attempt the request
 			if isStreamRequestType(req.Type) {
-				stream, bifrostError = handleProviderStreamRequest(provider, &req, key, postHookRunner, req.Type)
-				if bifrostError != nil && !bifrostError.IsBifrostError {
-					break // Don't retry client errors
-				}
+				firstToken.start = time.Now()
+				stream, bifrostError = bifrost.startProviderStream(provider, config, &req, key, postHookRunner)
 			} else {
-				result, bifrostError = handleProviderRequest(provider, &req, key, req.Type)
-				if bifrostError != nil {
-					break // Don't retry client errors
-				}
+				result, bifrostError = handleProviderRequest(provider, config, &req, key, req.Type)
+			}
+			bifrostError = requestTimeoutError(bifrostError, req.Context, providerCtx, timeout)
+			slot.record(bifrostError)
+			// Streams hold their concurrency slots until they are closed
+			if bifrostError == nil && isStreamRequestType(req.Type) {
+				stream = releaseOnStreamEnd(req.Context, stream, func() {
+					release()
+					slot.release()
+				})
+			} else {
+				release()
+				slot.release()
+			}
+			if bifrostError != nil || !isStreamRequestType(req.Type) {
+				cancelAttempt()
 			}
+			bifrost.keyOutcomes.record(key.ID, provider.GetProviderKey(), bifrostError)
+			bifrost.recordKeyRateLimit(key.ID, provider.GetProviderKey(), metadata.RateLimitHeaders)
 
 			bifrost.logger.Debug(fmt.Sprintf("Request for provider %s completed", provider.GetProviderKey()))
 
-			// Check if successful or if we should retry
-			if bifrostError == nil ||
-				bifrostError.IsBifrostError ||
-				(bifrostError.StatusCode != nil && !retryableStatusCodes[*bifrostError.StatusCode]) ||
-				(bifrostError.Error.Type != nil && *bifrostError.Error.Type == schemas.RequestCancelled) {
+			if bifrostError == nil {
+				if !isStreamRequestType(req.Type) {
+					attachResponseMetadata(result, metadata)
+					attachReproducibility(result, modelCapabilities(provider, req.Model), req.Params)
+					attachSelectedPrimary(req.Context, result)
+					attachSkippedProviders(req.Context, result)
+					attachRequestedModel(req.Context, result, req.Model)
+					attachRetries(result, retryStatusCodes)
+				}
+				break
+			}
+
+			// Check if we should retry, and if the retry budget of this kind of failure allows it
+			class := classifyRetry(bifrostError, config)
+			if !retries.take(class) {
 				break
 			}
+			statusCode := 0
+			if bifrostError.StatusCode != nil {
+				statusCode = *bifrostError.StatusCode
+			}
+			retryStatusCodes = append(retryStatusCodes, statusCode)
 		}
 
+		req.Context = callerCtx
+		untrack()
+		bifrost.recordRequestTiming(&req, dequeuedAt, bifrostError != nil)
+		bifrost.getProviderHealth(provider.GetProviderKey()).record(bifrostError)
+
 		if bifrostError != nil {
+			attachProviderRequestID(bifrostError, metadata)
 			// Add retry information to error
 			if attempts > 0 {
 				bifrost.logger.Warn(fmt.Sprintf("Request failed after %d %s",
 					attempts,
 					map[bool]string{true: "retries", false: "retry"}[attempts > 1]))
 			}
-			// Send error with context awareness to prevent deadlock
+			// Send error with context awareness to prevent deadlock. The channel is buffered, so a
+			// request cancelled while waiting (e.g. for a concurrency slot) still gets its error,
+			// instead of the select picking the done context and leaving the caller waiting.
 			select {
 			case req.Err <- *bifrostError:
 				// Error sent successfully
-			case <-req.Context.Done():
-				// Client no longer listening, log and continue
-				bifrost.logger.Debug("Client context cancelled while sending error response")
-			case <-time.After(5 * time.Second):
-				// Timeout to prevent indefinite blocking
-				bifrost.logger.Warn("Timeout while sending error response, client may have disconnected")
+			default:
+				select {
+				case req.Err <- *bifrostError:
+					// Error sent successfully
+				case <-req.Context.Done():
+					// Client no longer listening, record and continue
+					bifrost.logger.Debug("Client context cancelled while sending error response")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonContextDone, bifrostError)
+				case <-time.After(5 * time.Second):
+					// Timeout to prevent indefinite blocking
+					bifrost.logger.Warn("Timeout while sending error response, client may have disconnected")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonSendTimeout, bifrostError)
+				}
 			}
 		} else {
 			if isStreamRequestType(req.Type) {
+				if usageTracker != nil && bifrost.onStreamAbort != nil {
+					stream = bifrost.watchStreamAbort(req.Context, &req.BifrostRequest, stream, usageTracker)
+				}
 				// Send stream with context awareness to prevent deadlock
 				select {
 				case req.ResponseStream <- stream:
 					// Stream sent successfully
 				case <-req.Context.Done():
-					// Client no longer listening, log and continue
+					// Client no longer listening, record and continue
 					bifrost.logger.Debug("Client context cancelled while sending stream response")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonContextDone, nil)
 				case <-time.After(5 * time.Second):
 					// Timeout to prevent indefinite blocking
 					bifrost.logger.Warn("Timeout while sending stream response, client may have disconnected")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonSendTimeout, nil)
 				}
 			} else {
 				// Send response with context awareness to prevent deadlock
@@ -1223,26 +2127,95 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, queue chan Chan
 				case req.Response <- result:
 					// Response sent successfully
 				case <-req.Context.Done():
-					// Client no longer listening, log and continue
+					// Client no longer listening, record and continue
 					bifrost.logger.Debug("Client context cancelled while sending response")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonContextDone, nil)
 				case <-time.After(5 * time.Second):
 					// Timeout to prevent indefinite blocking
 					bifrost.logger.Warn("Timeout while sending response, client may have disconnected")
+					bifrost.recordClientDisconnect(&req, schemas.ClientDisconnectReasonSendTimeout, nil)
 				}
 			}
 		}
+
+		gate.leave()
 	}
 
 	bifrost.logger.Debug(fmt.Sprintf("Worker for provider %s exiting...", provider.GetProviderKey()))
 }
 
-// handleProviderRequest handles the request to the provider based on the request type
-func handleProviderRequest(provider schemas.Provider, req *ChannelMessage, key schemas.Key, reqType RequestType) (*schemas.BifrostResponse, *schemas.BifrostError) {
+// recordClientDisconnect counts a result that could not be delivered because the client was gone
+// and reports it to the OnClientDisconnect hook, if configured. A panicking hook is recovered
+// so that it cannot take down the worker.
+func (bifrost *Bifrost) recordClientDisconnect(req *ChannelMessage, reason schemas.ClientDisconnectReason, bifrostErr *schemas.BifrostError) {
+	bifrost.clientDisconnects.Add(1)
+
+	if bifrost.onClientDisconnect == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("OnClientDisconnect hook panicked: %v", r))
+		}
+	}()
+
+	bifrost.onClientDisconnect(schemas.ClientDisconnectInfo{
+		Provider:    req.Provider,
+		Model:       req.Model,
+		RequestType: string(req.Type),
+		Reason:      reason,
+		Error:       bifrostErr,
+	})
+}
+
+// GetClientDisconnectCount returns the number of results that could not be delivered because the
+// client had already gone away (context cancelled or send timed out) since Init.
+func (bifrost *Bifrost) GetClientDisconnectCount() int64 {
+	return bifrost.clientDisconnects.Load()
+}
+
+// acquireGlobalConcurrencySlot blocks until a global concurrency slot is available and returns
+// a function that releases it. Slots are only held for the duration of a single provider call
+// (never across retry backoff or while waiting on the queue), so workers holding a slot always
+// make progress and cannot deadlock with the per-provider wait groups.
+// For streaming requests the slot is held until the stream is closed (see releaseOnStreamEnd),
+// since open streams hold connections too, but not by the worker, which moves on.
+// If the request context is done while waiting, a cancellation error is returned instead.
+func (bifrost *Bifrost) acquireGlobalConcurrencySlot(ctx context.Context) (func(), *schemas.BifrostError) {
+	if bifrost.globalConcurrency == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case bifrost.globalConcurrency <- struct{}{}:
+		return func() { <-bifrost.globalConcurrency }, nil
+	case <-ctx.Done():
+		return nil, &schemas.BifrostError{
+			IsBifrostError: true,
+			Error: schemas.ErrorField{
+				Type:    Ptr(schemas.RequestCancelled),
+				Message: fmt.Sprintf("Request cancelled or timed out while waiting for a global concurrency slot: %v", ctx.Err()),
+				Error:   ctx.Err(),
+			},
+		}
+	}
+}
+
+// handleProviderRequest handles the request to the provider based on the request type.
+// Text and chat completions are converted to the other kind for models that only support it.
+func handleProviderRequest(provider schemas.Provider, config *schemas.ProviderConfig, req *ChannelMessage, key schemas.Key, reqType RequestType) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	switch reqType {
 	case TextCompletionRequest:
+		if capabilities := provider.Capabilities(); !capabilities.TextCompletion && capabilities.ChatCompletion && !req.ExactMatch {
+			return textCompletionAsChat(provider, req, key)
+		}
 		return provider.TextCompletion(req.Context, req.Model, key, *req.Input.TextCompletionInput, req.Params)
 	case ChatCompletionRequest:
-		return provider.ChatCompletion(req.Context, req.Model, key, *req.Input.ChatCompletionInput, req.Params)
+		if isTextCompletionModel(config, req.Model) && !req.ExactMatch {
+			return chatCompletionAsText(provider, config, req, key)
+		}
+		return provider.ChatCompletion(req.Context, req.Model, key, *req.Input.ChatCompletionInput, stopOnToolCallParams(req.Params))
 	case EmbeddingRequest:
 		return provider.Embedding(req.Context, req.Model, key, req.Input.EmbeddingInput, req.Params)
 	case SpeechRequest:
@@ -1260,10 +2233,13 @@ func handleProviderRequest(provider schemas.Provider, req *ChannelMessage, key s
 }
 
 // handleProviderStreamRequest handles the stream request to the provider based on the request type
-func handleProviderStreamRequest(provider schemas.Provider, req *ChannelMessage, key schemas.Key, postHookRunner schemas.PostHookRunner, reqType RequestType) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+func handleProviderStreamRequest(provider schemas.Provider, config *schemas.ProviderConfig, req *ChannelMessage, key schemas.Key, postHookRunner schemas.PostHookRunner, reqType RequestType) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	switch reqType {
 	case ChatCompletionStreamRequest:
-		return provider.ChatCompletionStream(req.Context, postHookRunner, req.Model, key, *req.Input.ChatCompletionInput, req.Params)
+		if isTextCompletionModel(config, req.Model) && !req.ExactMatch {
+			return nil, newTextCompletionStreamError(provider.GetProviderKey(), req.Model)
+		}
+		return provider.ChatCompletionStream(req.Context, postHookRunner, req.Model, key, *req.Input.ChatCompletionInput, stopOnToolCallParams(req.Params))
 	case SpeechStreamRequest:
 		return provider.SpeechStream(req.Context, postHookRunner, req.Model, key, req.Input.SpeechInput, req.Params)
 	case TranscriptionStreamRequest:
@@ -1285,6 +2261,10 @@ func (p *PluginPipeline) RunPreHooks(ctx *context.Context, req *schemas.BifrostR
 	var shortCircuit *schemas.PluginShortCircuit
 	var err error
 	for i, plugin := range p.plugins {
+		if !p.shouldRun(*ctx, i, req) {
+			p.executedPreHooks = i + 1
+			continue
+		}
 		req, shortCircuit, err = plugin.PreHook(ctx, req)
 		if err != nil {
 			p.preHookErrors = append(p.preHookErrors, err)
@@ -1311,6 +2291,9 @@ func (p *PluginPipeline) RunPostHooks(ctx *context.Context, resp *schemas.Bifros
 	}
 	var err error
 	for i := count - 1; i >= 0; i-- {
+		if i < len(p.skipped) && p.skipped[i] {
+			continue
+		}
 		plugin := p.plugins[i]
 		resp, bifrostErr, err = plugin.PostHook(ctx, resp, bifrostErr)
 		if err != nil {
@@ -1332,9 +2315,33 @@ func (p *PluginPipeline) RunPostHooks(ctx *context.Context, resp *schemas.Bifros
 	return resp, nil
 }
 
+// shouldRun consults the plugin at index i if it implements ConditionalPlugin, and records
+// the plugin as skipped so that its PostHook is skipped as well.
+func (p *PluginPipeline) shouldRun(ctx context.Context, i int, req *schemas.BifrostRequest) bool {
+	conditional, ok := p.plugins[i].(schemas.ConditionalPlugin)
+	if !ok || req == nil || conditional.ShouldRun(ctx, req) {
+		return true
+	}
+
+	if len(p.skipped) < len(p.plugins) {
+		p.skipped = append(p.skipped, make([]bool, len(p.plugins)-len(p.skipped))...)
+	}
+	p.skipped[i] = true
+	return false
+}
+
+// skipInapplicablePlugins evaluates every conditional plugin against the request, for pipelines
+// that only run PostHooks (e.g. the stream pipeline of a worker).
+func (p *PluginPipeline) skipInapplicablePlugins(ctx context.Context, req *schemas.BifrostRequest) {
+	for i := range p.plugins {
+		p.shouldRun(ctx, i, req)
+	}
+}
+
 // resetPluginPipeline resets a PluginPipeline instance for reuse
 func (p *PluginPipeline) resetPluginPipeline() {
 	p.executedPreHooks = 0
+	p.skipped = p.skipped[:0]
 	p.preHookErrors = p.preHookErrors[:0]
 	p.postHookErrors = p.postHookErrors[:0]
 }
@@ -1429,6 +2436,16 @@ func (bifrost *Bifrost) selectKeyFromProviderForModel(ctx *context.Context, prov
 		return schemas.Key{}, fmt.Errorf("no keys found for provider: %v", providerKey)
 	}
 
+	// drop keys that have been revoked, even if the account still returns them
+	bifrost.pruneRevokedKeys(providerKey, keys)
+	bifrost.keyOutcomes.prune(providerKey, keys)
+	keys = slices.DeleteFunc(slices.Clone(keys), func(key schemas.Key) bool {
+		return bifrost.isKeyRevoked(providerKey, key)
+	})
+	if len(keys) == 0 {
+		return schemas.Key{}, fmt.Errorf("no keys found for provider: %v (all keys revoked)", providerKey)
+	}
+
 	// filter out keys which dont support the model, if the key has no models, it is supported for all models
 	var supportedKeys []schemas.Key
 	for _, key := range keys {
@@ -1438,34 +2455,201 @@ func (bifrost *Bifrost) selectKeyFromProviderForModel(ctx *context.Context, prov
 	}
 
 	if len(supportedKeys) == 0 {
-		return schemas.Key{}, fmt.Errorf("no keys found that support model: %s", model)
+		if bifrost.noMatchingKeyBehavior != schemas.NoMatchingKeyBehaviorAnyKey {
+			return schemas.Key{}, fmt.Errorf("no keys found that support model: %s (available keys support: %s)", model, strings.Join(supportedModelsForKeys(keys), ", "))
+		}
+
+		// Fall back to any usable key, ignoring the Models restriction
+		for _, key := range keys {
+			if strings.TrimSpace(key.Value) != "" || providerKey == schemas.Vertex {
+				supportedKeys = append(supportedKeys, key)
+			}
+		}
+		if len(supportedKeys) == 0 {
+			return schemas.Key{}, fmt.Errorf("no usable keys found for provider: %v", providerKey)
+		}
+		bifrost.logger.Debug(fmt.Sprintf("no keys list model %s for provider %s, falling back to any key", model, providerKey))
 	}
 
 	if len(supportedKeys) == 1 {
 		return supportedKeys[0], nil
 	}
 
-	// Use a weighted random selection based on key weights
-	totalWeight := 0
-	for _, key := range supportedKeys {
-		totalWeight += int(key.Weight * 100) // Convert float to int for better performance
+	// Use a weighted random selection based on key weights, kept on the stack for usual key counts
+	var buffer [maxStackKeyWeights]float64
+	return supportedKeys[PickWeighted(bifrost.appendKeyWeights(buffer[:0], supportedKeys, keys, model))], nil
+}
+
+// RevokeKeys marks the given keys of a provider as revoked, for fast rotation of leaked keys.
+//
+// Keys are read from the Account on every request (GetKeysForProvider is called per request,
+// not cached), so rotating keys in the Account takes effect on the next request. RevokeKeys
+// additionally guarantees that the revoked keys are not selected again while the Account still
+// returns them, and optionally cancels non-streaming requests that are currently in flight
+// with one of those keys (including their pending retries). Streams already established with a
+// revoked key are not interrupted.
+//
+// Keys are identified by their ID, or by their value if they have none. A revocation lasts until
+// the Account stops returning the key, or until UnrevokeKeys.
+//
+// Parameters:
+//   - providerKey: The provider the keys belong to
+//   - keys: The keys to revoke
+//   - cancelInFlight: If true, in-flight requests using the revoked keys are cancelled
+//
+// Returns:
+//   - int: Number of in-flight requests cancelled
+func (bifrost *Bifrost) RevokeKeys(providerKey schemas.ModelProvider, keys []schemas.Key, cancelInFlight bool) int {
+	cancelled, revoked := 0, 0
+	for _, key := range keys {
+		id := revokedKeyID(key)
+		if id == "" {
+			continue
+		}
+		bifrost.revokedMu.Lock()
+		if bifrost.revokedKeys == nil {
+			bifrost.revokedKeys = make(map[schemas.ModelProvider]map[string]struct{})
+		}
+		if bifrost.revokedKeys[providerKey] == nil {
+			bifrost.revokedKeys[providerKey] = make(map[string]struct{})
+		}
+		bifrost.revokedKeys[providerKey][id] = struct{}{}
+		bifrost.revokedMu.Unlock()
+		revoked++
+
+		if !cancelInFlight {
+			continue
+		}
+
+		bifrost.inFlightMu.Lock()
+		for _, cancel := range bifrost.inFlightByKey[id] {
+			cancel()
+			cancelled++
+		}
+		delete(bifrost.inFlightByKey, id)
+		bifrost.inFlightMu.Unlock()
+	}
+
+	bifrost.logger.Info(fmt.Sprintf("Revoked %d key(s) for provider %s, cancelled %d in-flight request(s)", revoked, providerKey, cancelled))
+	return cancelled
+}
+
+// UnrevokeKeys lifts the revocation of the given keys of a provider, so that they can be selected
+// again, e.g. after a key was revoked by mistake.
+//
+// Returns:
+//   - int: Number of keys that were revoked
+func (bifrost *Bifrost) UnrevokeKeys(providerKey schemas.ModelProvider, keys []schemas.Key) int {
+	bifrost.revokedMu.Lock()
+	defer bifrost.revokedMu.Unlock()
+
+	unrevoked := 0
+	for _, key := range keys {
+		id := revokedKeyID(key)
+		if _, ok := bifrost.revokedKeys[providerKey][id]; ok {
+			delete(bifrost.revokedKeys[providerKey], id)
+			unrevoked++
+		}
+	}
+	if len(bifrost.revokedKeys[providerKey]) == 0 {
+		delete(bifrost.revokedKeys, providerKey)
+	}
+	return unrevoked
+}
+
+// revokedKeyID returns the identifier of a key in the revoked set and the in-flight requests: its
+// ID, or a hash of its value for keys without one. Keys with neither have no identifier ("").
+func revokedKeyID(key schemas.Key) string {
+	if key.ID != "" {
+		return key.ID
+	}
+	if key.Value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key.Value))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// isKeyRevoked reports whether a key of a provider has been revoked through RevokeKeys.
+func (bifrost *Bifrost) isKeyRevoked(providerKey schemas.ModelProvider, key schemas.Key) bool {
+	id := revokedKeyID(key)
+	if id == "" {
+		return false
+	}
+	bifrost.revokedMu.RLock()
+	defer bifrost.revokedMu.RUnlock()
+	_, revoked := bifrost.revokedKeys[providerKey][id]
+	return revoked
+}
+
+// pruneRevokedKeys drops the revocations of a provider's keys that the account no longer returns,
+// so that the revoked set does not grow as keys are rotated.
+func (bifrost *Bifrost) pruneRevokedKeys(providerKey schemas.ModelProvider, keys []schemas.Key) {
+	bifrost.revokedMu.RLock()
+	hasRevoked := len(bifrost.revokedKeys[providerKey]) > 0
+	bifrost.revokedMu.RUnlock()
+	if !hasRevoked {
+		return
 	}
 
-	// Use a fast random number generator
-	randomSource := rand.New(rand.NewSource(time.Now().UnixNano()))
-	randomValue := randomSource.Intn(totalWeight)
+	current := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		current[revokedKeyID(key)] = true
+	}
+
+	bifrost.revokedMu.Lock()
+	defer bifrost.revokedMu.Unlock()
+	for id := range bifrost.revokedKeys[providerKey] {
+		if !current[id] {
+			delete(bifrost.revokedKeys[providerKey], id)
+		}
+	}
+	if len(bifrost.revokedKeys[providerKey]) == 0 {
+		delete(bifrost.revokedKeys, providerKey)
+	}
+}
 
-	// Select key based on weight
-	currentWeight := 0
-	for _, key := range supportedKeys {
-		currentWeight += int(key.Weight * 100)
-		if randomValue < currentWeight {
-			return key, nil
+// trackInFlightKey registers a cancellable context for a request using the key with the given
+// revokedKeyID.
+// The returned function unregisters the request and releases the context; it must be called
+// once the provider call (including retries) has finished.
+func (bifrost *Bifrost) trackInFlightKey(ctx context.Context, keyID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	bifrost.inFlightMu.Lock()
+	bifrost.inFlightSeq++
+	id := bifrost.inFlightSeq
+	if bifrost.inFlightByKey[keyID] == nil {
+		bifrost.inFlightByKey[keyID] = make(map[uint64]context.CancelFunc)
+	}
+	bifrost.inFlightByKey[keyID][id] = cancel
+	bifrost.inFlightMu.Unlock()
+
+	return ctx, func() {
+		bifrost.inFlightMu.Lock()
+		if requests, ok := bifrost.inFlightByKey[keyID]; ok {
+			delete(requests, id)
+			if len(requests) == 0 {
+				delete(bifrost.inFlightByKey, keyID)
+			}
 		}
+		bifrost.inFlightMu.Unlock()
+		cancel()
 	}
+}
 
-	// Fallback to first key if something goes wrong
-	return supportedKeys[0], nil
+// supportedModelsForKeys returns the sorted, de-duplicated list of models explicitly listed across the given keys.
+func supportedModelsForKeys(keys []schemas.Key) []string {
+	var models []string
+	for _, key := range keys {
+		for _, model := range key.Models {
+			if !slices.Contains(models, model) {
+				models = append(models, model)
+			}
+		}
+	}
+	slices.Sort(models)
+	return models
 }
 
 // CLEANUP
@@ -1475,6 +2659,18 @@ func (bifrost *Bifrost) selectKeyFromProviderForModel(ctx *context.Context, prov
 func (bifrost *Bifrost) Cleanup() {
 	bifrost.logger.Info("Graceful Cleanup Initiated - Closing all request channels...")
 
+	// Resume paused providers, so that their workers drain the queues and exit
+	bifrost.providerGates.Range(func(key, value interface{}) bool {
+		value.(*providerGate).setPaused(false)
+		return true
+	})
+
+	// Stop the keepalive loops
+	bifrost.keepalives.Range(func(key, value interface{}) bool {
+		value.(context.CancelFunc)()
+		return true
+	})
+
 	// Close all provider queues to signal workers to stop
 	bifrost.requestQueues.Range(func(key, value interface{}) bool {
 		close(value.(chan ChannelMessage))
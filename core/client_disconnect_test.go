@@ -0,0 +1,81 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestUndeliveredResultsAreReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var disconnects []schemas.ClientDisconnectInfo
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys:   testKeys(),
+			config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+		},
+		Logger: NewDefaultLogger(schemas.LogLevelError),
+		OnClientDisconnect: func(info schemas.ClientDisconnectInfo) {
+			mu.Lock()
+			disconnects = append(disconnects, info)
+			mu.Unlock()
+			panic("hooks must not take down the worker")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	// A client that is gone by the time the result is ready: its context is done and nobody
+	// receives on its channels
+	queue, err := bifrost.getProviderQueue(schemas.OpenAI)
+	if err != nil {
+		t.Fatalf("getProviderQueue() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	queue <- ChannelMessage{
+		BifrostRequest: schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &messages}},
+		Context:        ctx,
+		Response:       make(chan *schemas.BifrostResponse),
+		Err:            make(chan schemas.BifrostError),
+		Type:           ChatCompletionRequest,
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for bifrost.GetClientDisconnectCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if count := bifrost.GetClientDisconnectCount(); count != 1 {
+		t.Fatalf("GetClientDisconnectCount() = %d, want 1", count)
+	}
+	mu.Lock()
+	if len(disconnects) != 1 || disconnects[0].Provider != schemas.OpenAI || disconnects[0].Model != "gpt-4o" ||
+		disconnects[0].RequestType != string(ChatCompletionRequest) || disconnects[0].Reason != schemas.ClientDisconnectReasonContextDone {
+		t.Errorf("OnClientDisconnect calls = %+v, want the undelivered chat completion", disconnects)
+	}
+	mu.Unlock()
+
+	// The worker survived the panicking hook and keeps serving requests
+	if _, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	}); bifrostErr != nil {
+		t.Errorf("ChatCompletionRequest() after the hook panicked error = %+v", bifrostErr)
+	}
+}
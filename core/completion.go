@@ -0,0 +1,150 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// isTextCompletionModel reports whether chat completions to a model are sent as text completions.
+func isTextCompletionModel(config *schemas.ProviderConfig, model string) bool {
+	return slices.Contains(config.TextCompletionModels, model)
+}
+
+// chatTemplatePrefix returns the prefix of the messages of a role in a chat template.
+func chatTemplatePrefix(template schemas.ChatTemplate, role schemas.ModelChatMessageRole) string {
+	switch role {
+	case schemas.ModelChatMessageRoleSystem:
+		return template.SystemPrefix
+	case schemas.ModelChatMessageRoleAssistant:
+		return template.AssistantPrefix
+	case schemas.ModelChatMessageRoleTool:
+		return template.ToolPrefix
+	default:
+		return template.UserPrefix
+	}
+}
+
+// renderChatPrompt renders chat messages as a text completion prompt ending with the cue of the
+// assistant's reply. Only the text of the messages is rendered: images and tool calls are dropped.
+func renderChatPrompt(messages []schemas.BifrostMessage, template schemas.ChatTemplate) string {
+	var prompt strings.Builder
+	for _, message := range messages {
+		text := messageText(message)
+		if text == "" {
+			continue
+		}
+		prompt.WriteString(chatTemplatePrefix(template, message.Role))
+		prompt.WriteString(text)
+		prompt.WriteString(template.MessageSuffix)
+	}
+	// A trailing space would be a token of its own, the model writes it before its reply
+	prompt.WriteString(strings.TrimRight(template.AssistantPrefix, " "))
+	return prompt.String()
+}
+
+// maxStopSequences is the number of stop sequences OpenAI compatible APIs accept.
+const maxStopSequences = 4
+
+// chatTemplateStops returns the sequences that start a new message in prompts rendered with a
+// chat template, where the model's reply ends, in order of importance.
+func chatTemplateStops(template schemas.ChatTemplate) []string {
+	var stops []string
+	for _, prefix := range []string{template.UserPrefix, template.SystemPrefix, template.ToolPrefix, template.AssistantPrefix} {
+		if strings.TrimSpace(prefix) == "" {
+			continue
+		}
+		if stop := strings.TrimRight(template.MessageSuffix+prefix, " "); !slices.Contains(stops, stop) {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
+
+// chatTemplateFor returns the chat template of a provider's text completion model: the configured
+// one, or the template of the model's native prompt framing.
+func chatTemplateFor(providerKey schemas.ModelProvider, config *schemas.ProviderConfig, model string) schemas.ChatTemplate {
+	switch {
+	case config.ChatTemplate != nil:
+		return *config.ChatTemplate
+	case providerKey == schemas.Anthropic, providerKey == schemas.Bedrock && strings.Contains(model, "anthropic."):
+		return schemas.AnthropicChatTemplate
+	default:
+		return schemas.DefaultChatTemplate
+	}
+}
+
+// chatCompletionAsText sends a chat completion to a model that only supports text completion, as
+// a text completion of its messages rendered with the provider's chat template. The completion is
+// returned as the assistant message of a chat completion.
+func chatCompletionAsText(provider schemas.Provider, config *schemas.ProviderConfig, req *ChannelMessage, key schemas.Key) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	template := chatTemplateFor(provider.GetProviderKey(), config, req.Model)
+	prompt := renderChatPrompt(*req.Input.ChatCompletionInput, template)
+	stops := chatTemplateStops(template)
+
+	// Tools cannot be described to a text completion model, e.g. those added by MCP are dropped
+	var params schemas.ModelParameters
+	if req.Params != nil {
+		params = *req.Params
+	}
+	params.Tools, params.ToolChoice, params.ParallelToolCalls = nil, nil, nil
+
+	// The caller's stop sequences are sent with as many of the template's as the limit of stop
+	// sequences allows. The reply is cut at all of the template's anyway.
+	var sentStops []string
+	if params.StopSequences != nil {
+		sentStops = slices.Clone(*params.StopSequences)
+	}
+	sentStops = append(sentStops, stops[:min(len(stops), max(0, maxStopSequences-len(sentStops)))]...)
+	params.StopSequences = &sentStops
+
+	ctx := context.WithValue(req.Context, schemas.BifrostContextKeyRenderedPrompt, true)
+	result, bifrostErr := provider.TextCompletion(ctx, req.Model, key, prompt, &params)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	for i := range result.Choices {
+		choice := &result.Choices[i]
+		if choice.BifrostNonStreamResponseChoice == nil {
+			continue
+		}
+		reply := messageText(choice.Message)
+		for _, stop := range stops {
+			if cut, _, found := strings.Cut(reply, stop); found {
+				reply = cut
+			}
+		}
+		reply = strings.TrimSpace(reply)
+		choice.Message = schemas.BifrostMessage{
+			Role:    schemas.ModelChatMessageRoleAssistant,
+			Content: schemas.MessageContent{ContentStr: &reply},
+		}
+	}
+	if req.Params != nil {
+		result.ExtraFields.Params = *req.Params
+	}
+	return result, nil
+}
+
+// textCompletionAsChat sends a text completion to a provider that only supports chat completion,
+// as a chat completion of a single user message. Its reply is already shaped like a completion.
+func textCompletionAsChat(provider schemas.Provider, req *ChannelMessage, key schemas.Key) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	messages := []schemas.BifrostMessage{schemas.UserMessage(*req.Input.TextCompletionInput)}
+	return provider.ChatCompletion(req.Context, req.Model, key, messages, req.Params)
+}
+
+// newTextCompletionStreamError reports a chat completion stream to a model that only supports
+// text completion, which has no streaming API to convert it to.
+func newTextCompletionStreamError(providerKey schemas.ModelProvider, model string) *schemas.BifrostError {
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		Error: schemas.ErrorField{
+			Type:    Ptr(schemas.UnsupportedOperation),
+			Message: fmt.Sprintf("chat completion streaming is not supported by text completion model %s of provider %s", model, providerKey),
+		},
+	}
+}
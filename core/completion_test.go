@@ -0,0 +1,140 @@
+package bifrost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestRenderChatPrompt(t *testing.T) {
+	messages := []schemas.BifrostMessage{
+		schemas.SystemMessage("Be brief."),
+		schemas.UserMessage("Capital of France?"),
+		schemas.AssistantTextMessage("Paris."),
+		schemas.UserMessage("And Italy?"),
+	}
+
+	want := "System: Be brief.\n\nUser: Capital of France?\n\nAssistant: Paris.\n\nUser: And Italy?\n\nAssistant:"
+	if got := renderChatPrompt(messages, schemas.DefaultChatTemplate); got != want {
+		t.Errorf("prompt = %q, want %q", got, want)
+	}
+	wantStops := []string{"\n\nUser:", "\n\nSystem:", "\n\nTool:", "\n\nAssistant:"}
+	if got := chatTemplateStops(schemas.DefaultChatTemplate); !slices.Equal(got, wantStops) {
+		t.Errorf("stops = %q, want %q", got, wantStops)
+	}
+
+	want = "Be brief.\n\nHuman: Capital of France?\n\nAssistant: Paris.\n\nHuman: And Italy?\n\nAssistant:"
+	if got := renderChatPrompt(messages, schemas.AnthropicChatTemplate); got != want {
+		t.Errorf("Anthropic prompt = %q, want %q", got, want)
+	}
+	wantStops = []string{"\n\nHuman:", "\n\nAssistant:"}
+	if got := chatTemplateStops(schemas.AnthropicChatTemplate); !slices.Equal(got, wantStops) {
+		t.Errorf("Anthropic stops = %q, want %q", got, wantStops)
+	}
+}
+
+func TestCompletionModeConversion(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/complete":
+			fmt.Fprint(w, `{"id":"compl_1","type":"completion","completion":" Rome.\n\nHuman: And Spain?","model":"claude-2.1"}`)
+		case "/v1/chat/completions":
+			fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Bonjour"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Anthropic's claude-2.1 is a text completion model
+	account := &testAccount{
+		providers: []schemas.ModelProvider{schemas.Anthropic, schemas.OpenAI},
+		keys:      testKeys(),
+		config: func(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+			config := &schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}}
+			if providerKey == schemas.Anthropic {
+				config.TextCompletionModels = []string{"claude-2.1"}
+			}
+			return config, nil
+		},
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	// A chat completion to a text completion model is sent as a rendered prompt
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Capital of Italy?")}
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.Anthropic,
+		Model:    "claude-2.1",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:   &schemas.ModelParameters{MaxTokens: Ptr(100)},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	// The messages are framed once, in Anthropic's native Human/Assistant turns
+	if prompt, _ := body["prompt"].(string); prompt != "\n\nHuman: Capital of Italy?\n\nAssistant:" {
+		t.Errorf("prompt = %q, want the rendered messages", prompt)
+	}
+	if stops := fmt.Sprint(body["stop_sequences"]); stops != fmt.Sprint([]string{"\n\nHuman:", "\n\nAssistant:"}) {
+		t.Errorf("stop_sequences = %q, want the template's role prefixes", stops)
+	}
+	reply := result.Choices[0].Message
+	if reply.Role != schemas.ModelChatMessageRoleAssistant || reply.Content.ContentStr == nil || *reply.Content.ContentStr != "Rome." {
+		t.Errorf("reply = %+v, want the assistant message Rome.", reply)
+	}
+
+	// The template's stop sequences fill what the caller's leave of the limit
+	_, bifrostErr = bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.Anthropic,
+		Model:    "claude-2.1",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:   &schemas.ModelParameters{StopSequences: &[]string{"a", "b", "c"}},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if stops := fmt.Sprint(body["stop_sequences"]); stops != fmt.Sprint([]string{"a", "b", "c", "\n\nHuman:"}) {
+		t.Errorf("stop_sequences = %q, want the caller's and one of the template's", stops)
+	}
+
+	// Streaming has no text completion counterpart
+	_, bifrostErr = bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.Anthropic,
+		Model:    "claude-2.1",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+	if bifrostErr == nil || bifrostErr.Error.Type == nil || *bifrostErr.Error.Type != schemas.UnsupportedOperation {
+		t.Errorf("ChatCompletionStreamRequest() error = %+v, want an unsupported operation", bifrostErr)
+	}
+
+	// A text completion to a chat only provider is sent as a user message
+	text := "Say hello in French"
+	result, bifrostErr = bifrost.TextCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{TextCompletionInput: &text},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("TextCompletionRequest() error = %+v", bifrostErr)
+	}
+	sent, _ := body["messages"].([]interface{})
+	if len(sent) != 1 || sent[0].(map[string]interface{})["content"] != text {
+		t.Errorf("messages = %v, want a single user message", body["messages"])
+	}
+	if content := result.Choices[0].Message.Content.ContentStr; content == nil || *content != "Bonjour" {
+		t.Errorf("completion = %+v, want Bonjour", result.Choices[0].Message)
+	}
+}
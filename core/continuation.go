@@ -0,0 +1,165 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// defaultContinuePrompt is the user turn sent to request a continuation when none is configured.
+const defaultContinuePrompt = "Continue exactly where you left off. Do not repeat any text you have already written."
+
+// Bounds of the overlap removed between a truncated response and its continuation. Models
+// sometimes repeat the last few words before continuing; short overlaps are kept as they are
+// as likely to be legitimate text.
+const (
+	minBoundaryOverlap = 8
+	maxBoundaryOverlap = 200
+)
+
+// continueTruncatedResponse implements AutoContinue for non-streaming chat completions. While the
+// response is truncated by the token limit, it requests a continuation from the provider and model
+// that produced the response, and appends the new text to the response's message. Usage is summed
+// across all requests. If a continuation fails, the response gathered so far is returned.
+func (bifrost *Bifrost) continueTruncatedResponse(ctx context.Context, req *schemas.BifrostRequest, result *schemas.BifrostResponse) *schemas.BifrostResponse {
+	if !isTruncatedResponse(result) {
+		return result
+	}
+
+	prompt := req.AutoContinue.ContinuePrompt
+	if prompt == "" {
+		prompt = defaultContinuePrompt
+	}
+
+	// Providers return pooled responses to their pool once the request is done, so a continuation
+	// may be decoded into the very response being continued. Work on a copy of it instead.
+	merged := *result
+	result = &merged
+	result.Choices = slices.Clone(result.Choices)
+	if result.Usage != nil {
+		usage := *result.Usage
+		result.Usage = &usage
+	}
+
+	choice := &result.Choices[0]
+	text := *choice.Message.Content.ContentStr
+	continuationReq := newContinuationRequest(req, result.ExtraFields)
+
+	for result.ExtraFields.Continuations < req.AutoContinue.MaxContinuations {
+		messages := append(append([]schemas.BifrostMessage{}, *req.Input.ChatCompletionInput...),
+			schemas.AssistantTextMessage(text),
+			schemas.UserMessage(prompt),
+		)
+		continuationReq.Input.ChatCompletionInput = &messages
+
+		continuation, bifrostErr := bifrost.handleRequest(ctx, continuationReq, ChatCompletionRequest)
+		if bifrostErr != nil {
+			bifrost.logger.Warn(fmt.Sprintf("Auto-continue request failed, returning truncated response: %s", bifrostErr.Error.Message))
+			break
+		}
+		result.ExtraFields.Continuations++
+
+		if len(continuation.Choices) == 0 || continuation.Choices[0].BifrostNonStreamResponseChoice == nil ||
+			continuation.Choices[0].Message.Content.ContentStr == nil {
+			break
+		}
+		text = mergeContinuation(text, *continuation.Choices[0].Message.Content.ContentStr)
+		choice.FinishReason = continuation.Choices[0].FinishReason
+		addUsage(result, continuation.Usage)
+
+		if !isTruncatedResponse(continuation) {
+			break
+		}
+	}
+
+	choice.Message.Content.ContentStr = &text
+	return result
+}
+
+// newContinuationRequest returns a copy of the request that targets the provider which served the
+// original response, so continuations are generated by the same model even after a fallback or
+// the selection of a primary candidate.
+func newContinuationRequest(req *schemas.BifrostRequest, servedBy schemas.BifrostResponseExtraFields) *schemas.BifrostRequest {
+	continuationReq := *req
+	continuationReq.AutoContinue = nil
+	continuationReq.OnUsageUpdate = nil
+	continuationReq.Fallbacks = nil
+	continuationReq.PrimaryCandidates = nil
+
+	if selected := servedBy.SelectedPrimary; selected != nil {
+		if selected.Provider != req.Provider {
+			continuationReq.BaseURL = nil
+		}
+		continuationReq.Provider = selected.Provider
+		continuationReq.Model = selected.Model
+	}
+
+	if servedBy.Provider != "" && servedBy.Provider != continuationReq.Provider {
+		for _, fallback := range req.Fallbacks {
+			if fallback.Provider == servedBy.Provider {
+				continuationReq.Provider = fallback.Provider
+				continuationReq.Model = fallback.Model
+				continuationReq.BaseURL = nil // Base URL overrides target the primary provider only
+				break
+			}
+		}
+	}
+
+	return &continuationReq
+}
+
+// isTruncatedResponse reports whether a chat completion with a single text choice stopped because
+// of the token limit. Responses with tool calls are never continued.
+func isTruncatedResponse(resp *schemas.BifrostResponse) bool {
+	if resp == nil || len(resp.Choices) != 1 {
+		return false
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason == nil || choice.BifrostNonStreamResponseChoice == nil || choice.Message.Content.ContentStr == nil {
+		return false
+	}
+	if choice.Message.AssistantMessage != nil && choice.Message.ToolCalls != nil && len(*choice.Message.ToolCalls) > 0 {
+		return false
+	}
+
+	return isTokenLimitFinishReason(*choice.FinishReason)
+}
+
+// isTokenLimitFinishReason reports whether a finish reason means the output was cut off by the
+// token limit ("length" for OpenAI compatible providers, "max_tokens" for Anthropic).
+func isTokenLimitFinishReason(finishReason string) bool {
+	switch strings.ToLower(finishReason) {
+	case "length", "max_tokens":
+		return true
+	}
+	return false
+}
+
+// mergeContinuation appends a continuation to the text, dropping the part of the continuation
+// that repeats the end of the text.
+func mergeContinuation(text, continuation string) string {
+	maxOverlap := min(len(text), len(continuation), maxBoundaryOverlap)
+	for overlap := maxOverlap; overlap >= minBoundaryOverlap; overlap-- {
+		if strings.HasSuffix(text, continuation[:overlap]) {
+			return text + continuation[overlap:]
+		}
+	}
+	return text + continuation
+}
+
+// addUsage adds the token usage of a continuation to the response's usage.
+func addUsage(resp *schemas.BifrostResponse, usage *schemas.LLMUsage) {
+	if usage == nil {
+		return
+	}
+	if resp.Usage == nil {
+		resp.Usage = &schemas.LLMUsage{}
+	}
+	resp.Usage.PromptTokens += usage.PromptTokens
+	resp.Usage.CompletionTokens += usage.CompletionTokens
+	resp.Usage.TotalTokens += usage.TotalTokens
+}
@@ -0,0 +1,101 @@
+package bifrost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestMergeContinuation(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		continuation string
+		want         string
+	}{
+		{"no overlap", "The quick brown fox", " jumps over the lazy dog.", "The quick brown fox jumps over the lazy dog."},
+		{"repeated boundary", "The quick brown fox jumps", "brown fox jumps over the lazy dog.", "The quick brown fox jumps over the lazy dog."},
+		{"short overlap is kept", "one two", "two three", "one twotwo three"},
+		{"empty continuation", "The quick brown fox", "", "The quick brown fox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeContinuation(tt.text, tt.continuation); got != tt.want {
+				t.Errorf("mergeContinuation(%q, %q) = %q, want %q", tt.text, tt.continuation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoContinueCompletesTruncatedResponses(t *testing.T) {
+	// The model is cut off twice by the token limit, repeating the end of its answer once
+	parts := []struct{ content, finishReason string }{
+		{"The quick brown fox jumps", "length"},
+		{"brown fox jumps over the lazy", "length"},
+		{" dog.", "stop"},
+	}
+	var mu sync.Mutex
+	var requests [][]map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		part := parts[len(requests)]
+		requests = append(requests, body.Messages)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":%q}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+			part.content, part.finishReason)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{keys: testKeys(), config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5})},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Describe the fox.")}
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:     schemas.OpenAI,
+		Model:        "gpt-4o",
+		Input:        schemas.RequestInput{ChatCompletionInput: &messages},
+		AutoContinue: &schemas.AutoContinueConfig{MaxContinuations: 3, ContinuePrompt: "Go on."},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	choice := result.Choices[0]
+	if got := *choice.Message.Content.ContentStr; got != "The quick brown fox jumps over the lazy dog." {
+		t.Errorf("content = %q, want the merged answer", got)
+	}
+	if *choice.FinishReason != "stop" || result.ExtraFields.Continuations != 2 {
+		t.Errorf("finish reason = %s, continuations = %d, want stop after 2 continuations", *choice.FinishReason, result.ExtraFields.Continuations)
+	}
+	if result.Usage == nil || *result.Usage != (schemas.LLMUsage{PromptTokens: 30, CompletionTokens: 15, TotalTokens: 45}) {
+		t.Errorf("usage = %+v, want the sum of the 3 requests", result.Usage)
+	}
+
+	// Continuations send the answer so far as an assistant turn, followed by the continue prompt
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+	last := requests[2]
+	if len(last) != 3 || last[1]["role"] != "assistant" || last[1]["content"] != "The quick brown fox jumps over the lazy" ||
+		last[2]["role"] != "user" || last[2]["content"] != "Go on." {
+		t.Errorf("last request messages = %v, want the answer so far and the continue prompt", last)
+	}
+}
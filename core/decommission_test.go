@@ -0,0 +1,51 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestIsModelNotFoundError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *schemas.BifrostError
+		want bool
+	}{
+		{"openai model_not_found", &schemas.BifrostError{StatusCode: Ptr(404), Error: schemas.ErrorField{Code: Ptr("model_not_found")}}, true},
+		{"404 naming the model without the code", &schemas.BifrostError{StatusCode: Ptr(404), Error: schemas.ErrorField{Message: "model: claude-2.0"}}, false},
+		{"other 404", &schemas.BifrostError{StatusCode: Ptr(404), Error: schemas.ErrorField{Message: "batch not found"}}, false},
+		{"other error mentioning the model", &schemas.BifrostError{StatusCode: Ptr(400), Error: schemas.ErrorField{Message: "model does not support tools"}}, false},
+		{"bifrost error", &schemas.BifrostError{IsBifrostError: true, StatusCode: Ptr(404), Error: schemas.ErrorField{Message: "model not found"}}, false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isModelNotFoundError(tt.err); got != tt.want {
+				t.Errorf("isModelNotFoundError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceDecommissionedModel(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{config: sameConfig(schemas.ProviderConfig{DecommissionedModels: map[string]string{"gpt-4-0314": "gpt-4o"}})})
+	notFound := &schemas.BifrostError{StatusCode: Ptr(404), Error: schemas.ErrorField{Code: Ptr("model_not_found")}}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4-0314"}
+
+	replacementReq := bifrost.replaceDecommissionedModel(req, notFound)
+	if replacementReq == nil || replacementReq.Model != "gpt-4o" {
+		t.Fatalf("replaceDecommissionedModel() = %+v, want model gpt-4o", replacementReq)
+	}
+	if req.Model != "gpt-4-0314" {
+		t.Errorf("original request was modified: model = %s", req.Model)
+	}
+
+	if got := bifrost.replaceDecommissionedModel(req, &schemas.BifrostError{StatusCode: Ptr(500)}); got != nil {
+		t.Errorf("replaceDecommissionedModel() on a server error = %+v, want nil", got)
+	}
+	if got := bifrost.replaceDecommissionedModel(&schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}, notFound); got != nil {
+		t.Errorf("replaceDecommissionedModel() for a model without replacement = %+v, want nil", got)
+	}
+}
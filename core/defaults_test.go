@@ -0,0 +1,62 @@
+package bifrost
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestProviderConfigInheritsBifrostDefaults(t *testing.T) {
+	noRetries := 0
+	providerConfig := &schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{
+			DefaultRequestTimeoutInSeconds: 120,
+			ExtraHeaders:                   map[string]string{"X-Team": "search"},
+			RequestMaxRetries:              &noRetries,
+		},
+	}
+	bifrost := newTestBifrost(&testAccount{config: func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+		return providerConfig, nil
+	}})
+	bifrost.defaultNetworkConfig = &schemas.NetworkConfig{
+		BaseURL:                        "https://gateway.internal",
+		PathOverrides:                  map[string]string{"/v1/chat/completions": "/api/chat/completions"},
+		DefaultRequestTimeoutInSeconds: 60,
+		MaxRetries:                     3,
+		RetryBackoffInitial:            time.Second,
+		ExtraHeaders:                   map[string]string{"X-Org": "acme", "X-Team": "platform"},
+	}
+	bifrost.defaultConcurrencyConfig = &schemas.ConcurrencyAndBufferSize{Concurrency: 50}
+
+	config, err := bifrost.getProviderConfig(schemas.OpenAI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	network := config.NetworkConfig
+	if network.DefaultRequestTimeoutInSeconds != 120 || network.MaxRetries != 3 || network.RetryBackoffInitial != time.Second {
+		t.Errorf("expected provider timeout with inherited retries, got %+v", network)
+	}
+	if network.RetryBackoffMax != schemas.DefaultRetryBackoffMax {
+		t.Errorf("expected built-in max backoff for a setting unset everywhere, got %v", network.RetryBackoffMax)
+	}
+	if network.BaseURL != "" || network.PathOverrides != nil {
+		t.Errorf("expected base URL and path overrides not to be inherited, got %s and %v", network.BaseURL, network.PathOverrides)
+	}
+	if network.RequestMaxRetries == nil || *network.RequestMaxRetries != 0 {
+		t.Errorf("expected the provider's request retry opt-out to be kept, got %v", network.RequestMaxRetries)
+	}
+	if want := map[string]string{"X-Org": "acme", "X-Team": "search"}; !reflect.DeepEqual(network.ExtraHeaders, want) {
+		t.Errorf("expected merged headers %v, got %v", want, network.ExtraHeaders)
+	}
+	if config.ConcurrencyAndBufferSize.Concurrency != 50 || config.ConcurrencyAndBufferSize.BufferSize != schemas.DefaultBufferSize {
+		t.Errorf("unexpected concurrency settings %+v", config.ConcurrencyAndBufferSize)
+	}
+
+	// The account's config is never modified
+	if providerConfig.NetworkConfig.MaxRetries != 0 || len(providerConfig.NetworkConfig.ExtraHeaders) != 1 {
+		t.Errorf("account config was modified: %+v", providerConfig.NetworkConfig)
+	}
+}
@@ -0,0 +1,309 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// maxEmbeddingRouters bounds the number of sets of EmbeddingRouting targets whose round-robin
+// state is kept. Beyond it, the state of the least recently used set is dropped, which only
+// restarts the interleaving of its picks.
+const maxEmbeddingRouters = 256
+
+// embeddingRouter is the smooth weighted round-robin state of a set of EmbeddingRouting targets.
+type embeddingRouter struct {
+	mu      sync.Mutex
+	current []float64
+
+	lastUsed uint64 // Value of Bifrost.embeddingRoutersSeq when last returned (guarded by Bifrost.embeddingRoutersMu)
+}
+
+// next returns the index of the target of the next batch. Each pick adds the weight of every
+// target to its current weight, then picks the target with the largest current weight and takes
+// the total weight off it. This interleaves the picks of each target (e.g. A B A for weights 2
+// and 1) instead of sending consecutive batches to the same one.
+func (r *embeddingRouter) next(weights []float64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.current) != len(weights) {
+		r.current = make([]float64, len(weights))
+	}
+	picked, total := 0, 0.0
+	for i, weight := range weights {
+		r.current[i] += weight
+		total += weight
+		if r.current[i] > r.current[picked] {
+			picked = i
+		}
+	}
+	r.current[picked] -= total
+	return picked
+}
+
+// getEmbeddingRouter returns the round-robin state shared by the requests routed to the same
+// targets. At most maxEmbeddingRouters states are kept, evicting the least recently used one.
+func (bifrost *Bifrost) getEmbeddingRouter(targets []schemas.EmbeddingRoutingTarget) *embeddingRouter {
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = fmt.Sprintf("%s/%s:%v", target.Provider, target.Model, target.Weight)
+	}
+	key := strings.Join(names, ",")
+
+	bifrost.embeddingRoutersMu.Lock()
+	defer bifrost.embeddingRoutersMu.Unlock()
+
+	router, ok := bifrost.embeddingRouters[key]
+	if !ok {
+		if bifrost.embeddingRouters == nil {
+			bifrost.embeddingRouters = make(map[string]*embeddingRouter)
+		}
+		if len(bifrost.embeddingRouters) >= maxEmbeddingRouters {
+			oldest := ""
+			for candidate, state := range bifrost.embeddingRouters {
+				if oldest == "" || state.lastUsed < bifrost.embeddingRouters[oldest].lastUsed {
+					oldest = candidate
+				}
+			}
+			delete(bifrost.embeddingRouters, oldest)
+		}
+		router = &embeddingRouter{}
+		bifrost.embeddingRouters[key] = router
+	}
+	bifrost.embeddingRoutersSeq++
+	router.lastUsed = bifrost.embeddingRoutersSeq
+	return router
+}
+
+// validateEmbeddingRouting checks the EmbeddingRouting settings of a request.
+func validateEmbeddingRouting(config *schemas.EmbeddingRoutingConfig) *schemas.BifrostError {
+	if len(config.Targets) == 0 {
+		return newValidationError("EmbeddingRouting.Targets", "at least one target required")
+	}
+	var totalWeight float64
+	for i, target := range config.Targets {
+		if target.Provider == "" || target.Model == "" {
+			return newValidationError(fmt.Sprintf("EmbeddingRouting.Targets[%d]", i), "provider and model required")
+		}
+		if target.Weight < 0 {
+			return newValidationError(fmt.Sprintf("EmbeddingRouting.Targets[%d].Weight", i), fmt.Sprintf("must be non-negative, got %v", target.Weight))
+		}
+		totalWeight += target.Weight
+	}
+	if totalWeight <= 0 {
+		return newValidationError("EmbeddingRouting.Targets", "at least one target must have a positive weight")
+	}
+	if config.BatchSize <= 0 {
+		return newValidationError("EmbeddingRouting.BatchSize", fmt.Sprintf("must be positive, got %d", config.BatchSize))
+	}
+	if config.MaxConcurrentBatches < 0 {
+		return newValidationError("EmbeddingRouting.MaxConcurrentBatches", fmt.Sprintf("must be non-negative, got %d", config.MaxConcurrentBatches))
+	}
+	return nil
+}
+
+// handleRoutedEmbedding implements BifrostRequest.EmbeddingRouting. The texts are split into
+// batches that are assigned to the targets by weighted round-robin and sent in parallel, each
+// with the request's fallbacks. The first failed batch fails the request and cancels the others.
+func (bifrost *Bifrost) handleRoutedEmbedding(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	config := req.EmbeddingRouting
+	if err := validateEmbeddingRouting(config); err != nil {
+		return nil, err
+	}
+	texts := req.Input.EmbeddingInput.Texts
+	if len(texts) == 0 {
+		return nil, newValidationError("Input.EmbeddingInput.Texts", "at least one text required")
+	}
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	weights := make([]float64, len(config.Targets))
+	for i, target := range config.Targets {
+		weights[i] = target.Weight
+	}
+	router := bifrost.getEmbeddingRouter(config.Targets)
+	var batches []schemas.EmbeddingBatch
+	for offset := 0; offset < len(texts); offset += config.BatchSize {
+		target := config.Targets[router.next(weights)]
+		batches = append(batches, schemas.EmbeddingBatch{
+			Provider: target.Provider,
+			Model:    target.Model,
+			Offset:   offset,
+			Count:    min(config.BatchSize, len(texts)-offset),
+		})
+	}
+
+	concurrency := config.MaxConcurrentBatches
+	if concurrency <= 0 || concurrency > len(batches) {
+		concurrency = len(batches)
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*schemas.BifrostResponse, len(batches))
+	errs := make([]*schemas.BifrostError, len(batches))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+launch:
+	for i, batch := range batches {
+		select {
+		case slots <- struct{}{}:
+		case <-batchCtx.Done():
+			break launch
+		}
+
+		batchReq := *req
+		batchReq.Provider = batch.Provider
+		batchReq.Model = batch.Model
+		batchReq.EmbeddingRouting = nil
+		batchReq.PrimaryCandidates = nil
+		if batch.Provider != req.Provider {
+			batchReq.BaseURL = nil // Base URL overrides target the request's provider only
+		}
+		input := *req.Input.EmbeddingInput
+		input.Texts = texts[batch.Offset : batch.Offset+batch.Count]
+		batchReq.Input = schemas.RequestInput{EmbeddingInput: &input}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = bifrost.handleRequest(batchCtx, &batchReq, EmbeddingRequest)
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the other batches, rather than one of the cancellations
+	var failure *schemas.BifrostError
+	failedBatch := 0
+	for i, err := range errs {
+		if err != nil && (failure == nil || (isCancellationError(failure) && !isCancellationError(err))) {
+			failure, failedBatch = err, i
+		}
+	}
+	if failure != nil {
+		batch := batches[failedBatch]
+		failure.Error.Message = fmt.Sprintf("embedding batch %d of %d (%s/%s): %s", failedBatch+1, len(batches), batch.Provider, batch.Model, failure.Error.Message)
+		return nil, failure
+	}
+	if ctx.Err() != nil {
+		return nil, &schemas.BifrostError{
+			IsBifrostError: true,
+			Error: schemas.ErrorField{
+				Type:    Ptr(schemas.RequestCancelled),
+				Message: fmt.Sprintf("Request cancelled or timed out while embedding batches: %v", ctx.Err()),
+				Error:   ctx.Err(),
+			},
+		}
+	}
+
+	return mergeEmbeddingBatches(req, batches, results)
+}
+
+// isCancellationError reports whether an error is due to a cancelled request.
+func isCancellationError(bifrostErr *schemas.BifrostError) bool {
+	return bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.RequestCancelled
+}
+
+// mergeEmbeddingBatches reassembles the embeddings of the batches of a routed embedding request in
+// the order of its texts, and adds up their usage. Batches must have embeddings of the same dimension.
+func mergeEmbeddingBatches(req *schemas.BifrostRequest, batches []schemas.EmbeddingBatch, results []*schemas.BifrostResponse) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	merged := &schemas.BifrostResponse{
+		Object: "list",
+		Model:  results[0].Model,
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			Provider:         batches[0].Provider,
+			EmbeddingBatches: batches,
+		},
+	}
+	if req.Params != nil {
+		merged.ExtraFields.Params = *req.Params
+	}
+
+	dimension := 0
+	for i, result := range results {
+		batch := batches[i]
+		if count := len(result.Embedding) + len(result.EmbeddingBase64); count != batch.Count {
+			return nil, newBifrostErrorFromMsg(fmt.Sprintf("embedding batch %d of %d (%s/%s) returned %d embeddings for %d texts", i+1, len(batches), batch.Provider, batch.Model, count, batch.Count))
+		}
+
+		batchDimension, err := embeddingDimension(result)
+		if err != nil {
+			return nil, newBifrostError(err)
+		}
+		if i > 0 && batchDimension != dimension {
+			return nil, newBifrostErrorFromMsg(fmt.Sprintf("embedding routing targets returned embeddings of different dimensions: %d from %s/%s and %d from %s/%s; set the dimensions parameter or route to models of the same dimension",
+				dimension, batches[0].Provider, batches[0].Model, batchDimension, batch.Provider, batch.Model))
+		}
+		dimension = batchDimension
+
+		merged.Embedding = append(merged.Embedding, result.Embedding...)
+		merged.EmbeddingBase64 = append(merged.EmbeddingBase64, result.EmbeddingBase64...)
+		if result.Usage != nil {
+			if merged.Usage == nil {
+				merged.Usage = &schemas.LLMUsage{}
+			}
+			merged.Usage.PromptTokens += result.Usage.PromptTokens
+			merged.Usage.TotalTokens += result.Usage.TotalTokens
+		}
+	}
+	return merged, nil
+}
+
+// embeddingDimension returns the dimension of the embeddings of a response, in either encoding.
+func embeddingDimension(result *schemas.BifrostResponse) (int, error) {
+	if len(result.Embedding) > 0 {
+		return len(result.Embedding[0]), nil
+	}
+	if len(result.EmbeddingBase64) > 0 {
+		embedding, err := schemas.DecodeBase64Embedding(result.EmbeddingBase64[0])
+		if err != nil {
+			return 0, err
+		}
+		return len(embedding), nil
+	}
+	return 0, nil
+}
+
+// normalizeEmbeddings implements BifrostRequest.NormalizeEmbeddings: it scales the embeddings of
+// a response to unit L2 norm in place, in either encoding.
+func normalizeEmbeddings(result *schemas.BifrostResponse) error {
+	for _, embedding := range result.Embedding {
+		normalizeEmbedding(embedding)
+	}
+	for i, encoded := range result.EmbeddingBase64 {
+		embedding, err := schemas.DecodeBase64Embedding(encoded)
+		if err != nil {
+			return fmt.Errorf("failed to normalize embedding %d: %w", i, err)
+		}
+		normalizeEmbedding(embedding)
+		result.EmbeddingBase64[i] = schemas.EncodeBase64Embedding(embedding)
+	}
+	return nil
+}
+
+// normalizeEmbedding scales an embedding to unit L2 norm in place. The norm is summed in float64
+// to keep the precision of large dimensions. Zero vectors are left as is.
+func normalizeEmbedding(embedding []float32) {
+	var sumOfSquares float64
+	for _, value := range embedding {
+		sumOfSquares += float64(value) * float64(value)
+	}
+	if sumOfSquares == 0 {
+		return
+	}
+	norm := math.Sqrt(sumOfSquares)
+	for i, value := range embedding {
+		embedding[i] = float32(float64(value) / norm)
+	}
+}
@@ -0,0 +1,199 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// embeddingPlugin embeds each text, a number, as a vector of that number repeated as many times
+// as the dimension of the provider, and counts the batches of each provider.
+type embeddingPlugin struct {
+	dimensions map[schemas.ModelProvider]int
+
+	mu      sync.Mutex
+	batches map[schemas.ModelProvider]int
+}
+
+func (p *embeddingPlugin) GetName() string { return "embedding" }
+
+func (p *embeddingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	p.mu.Lock()
+	p.batches[req.Provider]++
+	p.mu.Unlock()
+
+	var embeddings [][]float32
+	for _, text := range req.Input.EmbeddingInput.Texts {
+		value, _ := strconv.Atoi(text)
+		embedding := make([]float32, p.dimensions[req.Provider])
+		for i := range embedding {
+			embedding[i] = float32(value)
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{
+		Embedding: embeddings,
+		Usage:     &schemas.LLMUsage{PromptTokens: len(embeddings), TotalTokens: len(embeddings)},
+	}}, nil
+}
+
+func (p *embeddingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *embeddingPlugin) Cleanup() error { return nil }
+
+func TestRoutedEmbedding(t *testing.T) {
+	plugin := &embeddingPlugin{
+		dimensions: map[schemas.ModelProvider]int{schemas.OpenAI: 2, schemas.Cohere: 2, schemas.Vertex: 3},
+		batches:    map[schemas.ModelProvider]int{},
+	}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Cohere, schemas.Vertex}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	req := &schemas.BifrostRequest{
+		Input: schemas.RequestInput{EmbeddingInput: &schemas.EmbeddingInput{Texts: texts}},
+		EmbeddingRouting: &schemas.EmbeddingRoutingConfig{
+			Targets: []schemas.EmbeddingRoutingTarget{
+				{Provider: schemas.OpenAI, Model: "text-embedding-3-small", Weight: 2},
+				{Provider: schemas.Cohere, Model: "embed-english-v3.0", Weight: 1},
+			},
+			BatchSize:            2,
+			MaxConcurrentBatches: 2,
+		},
+	}
+
+	result, bifrostErr := bifrost.EmbeddingRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("EmbeddingRequest() error = %+v", bifrostErr)
+	}
+	if len(result.Embedding) != len(texts) {
+		t.Fatalf("got %d embeddings, want %d", len(result.Embedding), len(texts))
+	}
+	for i, embedding := range result.Embedding {
+		if embedding[0] != float32(i) {
+			t.Errorf("embedding %d = %v, want the embedding of text %d", i, embedding, i)
+		}
+	}
+	if result.Usage == nil || result.Usage.TotalTokens != len(texts) {
+		t.Errorf("usage = %+v, want %d total tokens", result.Usage, len(texts))
+	}
+	// 5 batches with weights 2:1 go to OpenAI, Cohere, OpenAI, OpenAI, Cohere
+	if plugin.batches[schemas.OpenAI] != 3 || plugin.batches[schemas.Cohere] != 2 {
+		t.Errorf("batches = %v, want 3 for openai and 2 for cohere", plugin.batches)
+	}
+	batches := result.ExtraFields.EmbeddingBatches
+	if len(batches) != 5 || batches[1].Provider != schemas.Cohere || batches[4].Offset != 8 || batches[4].Count != 2 {
+		t.Errorf("embedding batches = %+v, want 5 interleaved batches of 2 texts", batches)
+	}
+
+	// Targets of different dimensions are rejected
+	req.EmbeddingRouting.Targets = []schemas.EmbeddingRoutingTarget{
+		{Provider: schemas.OpenAI, Model: "text-embedding-3-small", Weight: 1},
+		{Provider: schemas.Vertex, Model: "text-embedding-004", Weight: 1},
+	}
+	_, bifrostErr = bifrost.EmbeddingRequest(context.Background(), req)
+	if bifrostErr == nil || !strings.Contains(bifrostErr.Error.Message, "different dimensions") {
+		t.Errorf("EmbeddingRequest() error = %+v, want a dimension mismatch", bifrostErr)
+	}
+}
+
+func TestEmbeddingRouterInterleavesTargets(t *testing.T) {
+	router := &embeddingRouter{}
+	var picks []int
+	for range 6 {
+		picks = append(picks, router.next([]float64{2, 1}))
+	}
+	if want := []int{0, 1, 0, 0, 1, 0}; !slices.Equal(picks, want) {
+		t.Errorf("picks = %v, want %v", picks, want)
+	}
+}
+
+func TestEmbeddingRoutersAreBounded(t *testing.T) {
+	bifrost := &Bifrost{}
+	target := func(i int) []schemas.EmbeddingRoutingTarget {
+		return []schemas.EmbeddingRoutingTarget{{Provider: schemas.OpenAI, Model: fmt.Sprintf("model-%d", i), Weight: 1}}
+	}
+	first := bifrost.getEmbeddingRouter(target(0))
+	for i := 1; i < maxEmbeddingRouters; i++ {
+		bifrost.getEmbeddingRouter(target(i))
+	}
+	// Using the first targets again keeps their state, so the second ones are evicted instead
+	if bifrost.getEmbeddingRouter(target(0)) != first {
+		t.Error("getEmbeddingRouter() returned a new router for known targets")
+	}
+	bifrost.getEmbeddingRouter(target(maxEmbeddingRouters))
+	if len(bifrost.embeddingRouters) != maxEmbeddingRouters {
+		t.Errorf("kept %d routers, want %d", len(bifrost.embeddingRouters), maxEmbeddingRouters)
+	}
+	if bifrost.getEmbeddingRouter(target(0)) != first {
+		t.Error("the most recently used router was evicted")
+	}
+	if _, ok := bifrost.embeddingRouters["openai/model-1:1"]; ok {
+		t.Error("the least recently used router was kept")
+	}
+}
+
+func TestNormalizeEmbeddings(t *testing.T) {
+	plugin := &embeddingPlugin{
+		dimensions: map[schemas.ModelProvider]int{schemas.OpenAI: 4},
+		batches:    map[schemas.ModelProvider]int{},
+	}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	result, bifrostErr := bifrost.EmbeddingRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:            schemas.OpenAI,
+		Model:               "text-embedding-3-small",
+		Input:               schemas.RequestInput{EmbeddingInput: &schemas.EmbeddingInput{Texts: []string{"3", "0"}}},
+		NormalizeEmbeddings: true,
+	})
+	if bifrostErr != nil {
+		t.Fatalf("EmbeddingRequest() error = %+v", bifrostErr)
+	}
+	// [3 3 3 3] has a norm of 6
+	if !slices.Equal(result.Embedding[0], []float32{0.5, 0.5, 0.5, 0.5}) {
+		t.Errorf("embedding = %v, want [0.5 0.5 0.5 0.5]", result.Embedding[0])
+	}
+	if !slices.Equal(result.Embedding[1], []float32{0, 0, 0, 0}) {
+		t.Errorf("zero embedding = %v, want it unchanged", result.Embedding[1])
+	}
+
+	// Base64 embeddings are normalized too
+	encoded := &schemas.BifrostResponse{EmbeddingBase64: []string{schemas.EncodeBase64Embedding([]float32{3, 4})}}
+	if err := normalizeEmbeddings(encoded); err != nil {
+		t.Fatalf("normalizeEmbeddings() error = %v", err)
+	}
+	embeddings, err := encoded.DecodeEmbeddings()
+	if err != nil {
+		t.Fatalf("DecodeEmbeddings() error = %v", err)
+	}
+	if got := embeddings[0]; math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("base64 embedding = %v, want [0.6 0.8]", got)
+	}
+}
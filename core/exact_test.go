@@ -0,0 +1,167 @@
+package bifrost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// rewritingPlugin rewrites the model of every request in place, like a routing plugin, and
+// optionally clears its ExactMatch flag.
+type rewritingPlugin struct {
+	model           string
+	clearExactMatch bool
+}
+
+func (p *rewritingPlugin) GetName() string { return "rewriting" }
+
+func (p *rewritingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	req.Model = p.model
+	if p.clearExactMatch {
+		req.ExactMatch = false
+	}
+	return req, nil, nil
+}
+
+func (p *rewritingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *rewritingPlugin) Cleanup() error { return nil }
+
+func TestExactMatchBypassesRewrites(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{config: sameConfig(schemas.ProviderConfig{DecommissionedModels: map[string]string{"gpt-4-0314": "gpt-4o"}})})
+	bifrost.providerHealthConfig = &schemas.ProviderHealthConfig{FailureThreshold: 1, ProbeInterval: time.Hour}
+	bifrost.getProviderHealth(schemas.OpenAI).record(&schemas.BifrostError{StatusCode: Ptr(500)})
+
+	req := &schemas.BifrostRequest{
+		Provider:   schemas.OpenAI,
+		Model:      "gpt-4-0314",
+		Fallbacks:  []schemas.Fallback{{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet-20241022"}},
+		ExactMatch: true,
+	}
+	if _, unchanged := bifrost.skipUnhealthyPrimary(context.Background(), req, ChatCompletionRequest); unchanged != req {
+		t.Errorf("request starts from %s, want the unhealthy primary", unchanged.Provider)
+	}
+	notFound := &schemas.BifrostError{StatusCode: Ptr(404), Error: schemas.ErrorField{Code: Ptr("model_not_found")}}
+	if replacementReq := bifrost.replaceDecommissionedModel(req, notFound); replacementReq != nil {
+		t.Errorf("replaceDecommissionedModel() = %+v, want nil", replacementReq)
+	}
+
+	req.PrimaryCandidates = []schemas.PrimaryCandidate{{Provider: schemas.OpenAI, Model: "gpt-4o", Weight: 1}}
+	if _, _, err := bifrost.selectPrimary(context.Background(), req); err == nil || err.Error.Message != "PrimaryCandidates: cannot be combined with ExactMatch" {
+		t.Errorf("selectPrimary() error = %+v, want PrimaryCandidates rejected", err)
+	}
+}
+
+func TestExactMatchIgnoresPluginRewrites(t *testing.T) {
+	var mu sync.Mutex
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		models = append(models, body.Model)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"model":%q,"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`, body.Model)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys:   testKeys(),
+			config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+		},
+		Plugins: []schemas.Plugin{&rewritingPlugin{model: "gpt-4o-mini"}},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	for _, exactMatch := range []bool{false, true} {
+		_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+			Provider:   schemas.OpenAI,
+			Model:      "gpt-4o",
+			Input:      schemas.RequestInput{ChatCompletionInput: &messages},
+			ExactMatch: exactMatch,
+		})
+		if bifrostErr != nil {
+			t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+		}
+	}
+
+	if len(models) != 2 || models[0] != "gpt-4o-mini" || models[1] != "gpt-4o" {
+		t.Errorf("models sent = %v, want the plugin's rewrite, then the exact model", models)
+	}
+}
+
+func TestExactMatchSkipsRequestRewrites(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/completions" {
+			fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"text":"Hello"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+	}))
+	defer server.Close()
+
+	// gpt-4o is configured as a text completion model, and a plugin clears the flag
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys: testKeys(),
+			config: sameConfig(schemas.ProviderConfig{
+				NetworkConfig:        schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+				TextCompletionModels: []string{"gpt-4o"},
+			}),
+		},
+		Plugins: []schemas.Plugin{&rewritingPlugin{model: "gpt-4o-mini", clearExactMatch: true}},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:   schemas.OpenAI,
+		Model:      "gpt-4o",
+		Input:      schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:     &schemas.ModelParameters{RepetitionPenalty: Ptr(1.25)},
+		ExactMatch: true,
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	// The request is sent as specified: as a chat completion to gpt-4o, with its own penalty
+	if len(paths) != 1 || paths[0] != "/v1/chat/completions" {
+		t.Fatalf("paths = %v, want a single chat completion", paths)
+	}
+	if body := bodies[0]; body["model"] != "gpt-4o" || body["repetition_penalty"] != 1.25 || body["frequency_penalty"] != nil {
+		t.Errorf("body = %v, want gpt-4o with the repetition penalty as is", body)
+	}
+}
@@ -0,0 +1,209 @@
+package bifrost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestAllFallbacksSkipped(t *testing.T) {
+	plugin := &latencyPlugin{failing: map[schemas.ModelProvider]bool{schemas.OpenAI: true}}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{config: func(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+			if providerKey != schemas.OpenAI {
+				return nil, fmt.Errorf("provider %s is not configured", providerKey)
+			}
+			return &schemas.ProviderConfig{}, nil
+		}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func(fallbacks ...schemas.Fallback) *schemas.BifrostError {
+		_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+			Provider:  schemas.OpenAI,
+			Model:     "gpt-4o",
+			Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+			Fallbacks: fallbacks,
+		})
+		if bifrostErr == nil {
+			t.Fatal("ChatCompletionRequest() succeeded, want an error")
+		}
+		return bifrostErr
+	}
+
+	// Without fallbacks, the primary's error is returned as is
+	if bifrostErr := request(); bifrostErr.Error.Message != "unavailable" || bifrostErr.SkippedFallbacks != nil {
+		t.Errorf("error = %+v, want the primary's error", bifrostErr)
+	}
+
+	// A misspelled fallback provider is reported on the error
+	bifrostErr := request(schemas.Fallback{Provider: "antropic", Model: "claude-3-5-sonnet"})
+	if !strings.Contains(bifrostErr.Error.Message, "all 1 fallbacks were skipped: antropic/claude-3-5-sonnet: config not found") {
+		t.Errorf("error message = %q, want the skipped fallback", bifrostErr.Error.Message)
+	}
+	if len(bifrostErr.SkippedFallbacks) != 1 || bifrostErr.SkippedFallbacks[0].Provider != "antropic" {
+		t.Errorf("skipped fallbacks = %+v, want the misspelled provider", bifrostErr.SkippedFallbacks)
+	}
+	if bifrostErr.AttemptHistory != nil {
+		t.Errorf("attempt history = %+v, want none", bifrostErr.AttemptHistory)
+	}
+}
+
+func TestAttemptHistoryListsEveryFailure(t *testing.T) {
+	// Each model fails with its own status and message
+	failures := map[string]struct {
+		status  int
+		message string
+	}{
+		"gpt-4o":      {http.StatusUnauthorized, "invalid api key"},
+		"gpt-4o-mini": {http.StatusNotFound, "model not found"},
+		"o1":          {http.StatusBadRequest, "context too long"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		failure := failures[body.Model]
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(failure.status)
+		fmt.Fprintf(w, `{"error":{"message":%q,"type":"invalid_request_error"}}`, failure.message)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{keys: testKeys(), config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5})},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:  schemas.OpenAI,
+		Model:     "gpt-4o",
+		Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+		Fallbacks: []schemas.Fallback{{Provider: schemas.OpenAI, Model: "gpt-4o-mini"}, {Provider: schemas.OpenAI, Model: "o1"}},
+	})
+	if bifrostErr == nil {
+		t.Fatal("ChatCompletionRequest() succeeded, want an error")
+	}
+
+	// The primary's error is returned, with the error of every attempt in order
+	if bifrostErr.Error.Message != "invalid api key" {
+		t.Errorf("error message = %q, want the primary's", bifrostErr.Error.Message)
+	}
+	if len(bifrostErr.AttemptHistory) != 3 {
+		t.Fatalf("attempt history = %+v, want 3 attempts", bifrostErr.AttemptHistory)
+	}
+	for i, model := range []string{"gpt-4o", "gpt-4o-mini", "o1"} {
+		attempt := bifrostErr.AttemptHistory[i]
+		if attempt.Provider != schemas.OpenAI || attempt.Model != model || attempt.StatusCode == nil ||
+			*attempt.StatusCode != failures[model].status || attempt.Message != failures[model].message {
+			t.Errorf("attempt %d = %+v, want the failure of %s", i, attempt, model)
+		}
+	}
+}
+
+func TestOnFailoverReportsEachFallback(t *testing.T) {
+	var events []schemas.FailoverEvent
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account:    &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic, schemas.Cohere}},
+		Plugins:    []schemas.Plugin{&latencyPlugin{failing: map[schemas.ModelProvider]bool{schemas.OpenAI: true, schemas.Anthropic: true}}},
+		Logger:     NewDefaultLogger(schemas.LogLevelError),
+		OnFailover: func(event schemas.FailoverEvent) { events = append(events, event) },
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Fallbacks: []schemas.Fallback{
+			{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet"},
+			{Provider: schemas.Cohere, Model: "command-r"},
+		},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d failover events, want 2: %+v", len(events), events)
+	}
+	first, second := events[0], events[1]
+	if first.RequestType != "chat_completion" || first.Failed.Provider != schemas.OpenAI || first.Failed.StatusCode == nil || *first.Failed.StatusCode != 503 ||
+		first.Fallback.Provider != schemas.Anthropic || first.Attempt != 1 {
+		t.Errorf("first event = %+v, want openai failing over to anthropic", first)
+	}
+	if second.Failed.Provider != schemas.Anthropic || second.Fallback.Provider != schemas.Cohere || second.Attempt != 2 {
+		t.Errorf("second event = %+v, want anthropic failing over to cohere", second)
+	}
+}
+
+func TestMaxFallbacks(t *testing.T) {
+	plugin := &latencyPlugin{failing: map[schemas.ModelProvider]bool{schemas.OpenAI: true, schemas.Anthropic: true, schemas.Cohere: true}}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic, schemas.Cohere}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func(maxFallbacks int, hedging *schemas.HedgingConfig) *schemas.BifrostError {
+		_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+			Provider: schemas.OpenAI,
+			Model:    "gpt-4o",
+			Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+			Fallbacks: []schemas.Fallback{
+				{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet"},
+				{Provider: schemas.Cohere, Model: "command-r"},
+			},
+			MaxFallbacks: &maxFallbacks,
+			Hedging:      hedging,
+		})
+		if bifrostErr == nil {
+			t.Fatal("ChatCompletionRequest() succeeded, want an error")
+		}
+		return bifrostErr
+	}
+
+	for _, hedging := range []*schemas.HedgingConfig{nil, {DelayMs: 0}} {
+		bifrostErr := request(1, hedging)
+		if len(bifrostErr.AttemptHistory) != 2 || bifrostErr.AttemptHistory[1].Provider != schemas.Anthropic {
+			t.Errorf("hedging %v: attempt history = %+v, want openai and anthropic", hedging, bifrostErr.AttemptHistory)
+		}
+		skipped := bifrostErr.SkippedFallbacks
+		if len(skipped) != 1 || skipped[0].Provider != schemas.Cohere || skipped[0].Reason != "max_fallbacks of 1 reached" {
+			t.Errorf("hedging %v: skipped fallbacks = %+v, want cohere", hedging, skipped)
+		}
+	}
+
+	// With no fallbacks allowed, only the primary is tried
+	bifrostErr := request(0, nil)
+	if bifrostErr.AttemptHistory != nil || len(bifrostErr.SkippedFallbacks) != 2 {
+		t.Errorf("error = %+v, want the primary's error with all fallbacks skipped", bifrostErr)
+	}
+}
@@ -0,0 +1,189 @@
+package bifrost
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"slices"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// requestHashVersion is the version of the canonical form hashed by HashRequest. It is part of
+// the hash, and is incremented whenever the canonical form changes, so that hashes computed by
+// different versions of Bifrost never match by accident.
+const requestHashVersion = 2
+
+// RequestHashOptions selects the volatile fields of a request included in its hash. They are left
+// out by default, so that requests expected to get the same response share a hash.
+type RequestHashOptions struct {
+	IncludeUser         bool     // Include Params.User, the ID of the end user
+	IncludeToolCallIDs  bool     // Include the IDs of tool calls and tool results, which providers generate for each response
+	IncludeExtraHeaders bool     // Include ExtraHeaders (e.g. Openai-Project)
+	ExcludeExtraParams  []string // Keys of Params.ExtraParams to leave out, e.g. per-request IDs or trace metadata
+}
+
+// hashedRequest is the canonical form of a request encoded by HashRequest.
+type hashedRequest struct {
+	Version               int                           `json:"version"`
+	Provider              schemas.ModelProvider         `json:"provider"`
+	Model                 string                        `json:"model"`
+	Input                 schemas.RequestInput          `json:"input"`
+	Params                json.RawMessage               `json:"params,omitempty"`
+	ExtraParams           map[string]interface{}        `json:"extra_params,omitempty"`
+	ExtraHeaders          map[string]string             `json:"extra_headers,omitempty"`
+	OutputLanguage        *schemas.OutputLanguageConfig `json:"output_language,omitempty"`
+	StreamMaxOutputTokens int                           `json:"stream_max_output_tokens,omitempty"`
+	NormalizeEmbeddings   bool                          `json:"normalize_embeddings,omitempty"`
+	ExactMatch            bool                          `json:"exact_match,omitempty"`
+	AutoContinue          *schemas.AutoContinueConfig   `json:"auto_continue,omitempty"`
+}
+
+// HashRequest returns the canonical hash of a request, as a hex-encoded SHA-256, with the default
+// RequestHashOptions. It is the single definition of request identity shared by caches,
+// deduplication and record/replay, see HashRequestWithOptions.
+func HashRequest(req *schemas.BifrostRequest) (string, error) {
+	return HashRequestWithOptions(req, RequestHashOptions{})
+}
+
+// HashRequestWithOptions returns the canonical hash of a request, as a hex-encoded SHA-256.
+//
+// The hash covers what determines the response: the provider, model, input and parameters, and
+// the options that change the output (OutputLanguage, StreamMaxOutputTokens, NormalizeEmbeddings,
+// ExactMatch and AutoContinue). Routing and delivery options such as Fallbacks, retries, BaseURL or
+// Hedging are left out, as are the volatile fields not selected by opts. Order is ignored where
+// it carries no meaning: in stop sequences, tools, map keys, header names and the keys of tool
+// call arguments. The order of messages and embedding texts is kept. The request type is not part
+// of a BifrostRequest; callers that serve several request types should combine it with the hash.
+// The request is not modified.
+func HashRequestWithOptions(req *schemas.BifrostRequest, opts RequestHashOptions) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("bifrost request cannot be nil")
+	}
+
+	hashed := hashedRequest{
+		Version:               requestHashVersion,
+		Provider:              req.Provider,
+		Model:                 req.Model,
+		Input:                 req.Input,
+		OutputLanguage:        req.OutputLanguage,
+		StreamMaxOutputTokens: req.StreamMaxOutputTokens,
+		NormalizeEmbeddings:   req.NormalizeEmbeddings,
+		ExactMatch:            req.ExactMatch,
+		AutoContinue:          req.AutoContinue,
+	}
+	if req.Input.ChatCompletionInput != nil {
+		messages := canonicalMessages(*req.Input.ChatCompletionInput, opts.IncludeToolCallIDs)
+		hashed.Input.ChatCompletionInput = &messages
+	}
+	if req.Params != nil {
+		params, err := canonicalParams(*req.Params, opts.IncludeUser)
+		if err != nil {
+			return "", err
+		}
+		hashed.Params = params
+		for key, value := range req.Params.ExtraParams {
+			if slices.Contains(opts.ExcludeExtraParams, key) {
+				continue
+			}
+			if hashed.ExtraParams == nil {
+				hashed.ExtraParams = make(map[string]interface{})
+			}
+			hashed.ExtraParams[key] = value
+		}
+	}
+	if opts.IncludeExtraHeaders && len(req.ExtraHeaders) > 0 {
+		hashed.ExtraHeaders = make(map[string]string, len(req.ExtraHeaders))
+		for name, value := range req.ExtraHeaders {
+			hashed.ExtraHeaders[textproto.CanonicalMIMEHeaderKey(name)] = value
+		}
+	}
+
+	// encoding/json sorts map keys, which makes the encoding independent of map iteration order
+	hash := sha256.New()
+	if err := json.NewEncoder(hash).Encode(hashed); err != nil {
+		return "", fmt.Errorf("failed to encode request for hashing: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// canonicalParams returns the JSON encoding of the parameters of a request for HashRequest, with
+// stop sequences and tools sorted and Params.User dropped unless includeUser is set. It returns
+// nil if no parameter is set, so that empty parameters hash like missing ones.
+func canonicalParams(params schemas.ModelParameters, includeUser bool) (json.RawMessage, error) {
+	if !includeUser {
+		params.User = nil
+	}
+	if params.StopSequences != nil {
+		stops := slices.Clone(*params.StopSequences)
+		slices.Sort(stops)
+		params.StopSequences = &stops
+	}
+	if params.Tools != nil {
+		tools := slices.Clone(*params.Tools)
+		slices.SortStableFunc(tools, func(a, b schemas.Tool) int {
+			return strings.Compare(a.Function.Name, b.Function.Name)
+		})
+		params.Tools = &tools
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request parameters for hashing: %w", err)
+	}
+	if string(data) == "{}" {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// canonicalMessages returns a copy of chat messages for HashRequest, with the arguments of tool
+// calls in canonical JSON and, unless includeToolCallIDs is set, without tool call IDs. Messages
+// without tool calls or tool results are shared with the original.
+func canonicalMessages(messages []schemas.BifrostMessage, includeToolCallIDs bool) []schemas.BifrostMessage {
+	canonical := slices.Clone(messages)
+	for i, message := range canonical {
+		if message.ToolMessage != nil && !includeToolCallIDs {
+			toolMessage := *message.ToolMessage
+			toolMessage.ToolCallID = nil
+			canonical[i].ToolMessage = &toolMessage
+		}
+		if message.AssistantMessage == nil || message.AssistantMessage.ToolCalls == nil {
+			continue
+		}
+
+		assistantMessage := *message.AssistantMessage
+		toolCalls := slices.Clone(*assistantMessage.ToolCalls)
+		for j := range toolCalls {
+			toolCalls[j].Function.Arguments = canonicalJSON(toolCalls[j].Function.Arguments)
+			if !includeToolCallIDs {
+				toolCalls[j].ID = nil
+			}
+		}
+		assistantMessage.ToolCalls = &toolCalls
+		canonical[i].AssistantMessage = &assistantMessage
+	}
+	return canonical
+}
+
+// canonicalJSON re-encodes a JSON document with sorted object keys and without insignificant
+// whitespace. Numbers are kept as written. Invalid JSON is returned as is.
+func canonicalJSON(document string) string {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return document
+	}
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return document
+	}
+	return strings.TrimSuffix(encoded.String(), "\n")
+}
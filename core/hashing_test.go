@@ -0,0 +1,158 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// hashTestRequest returns a chat completion request with a tool call, built anew on each call.
+func hashTestRequest() *schemas.BifrostRequest {
+	messages := []schemas.BifrostMessage{
+		schemas.UserMessage("What is the weather in Paris?"),
+		{
+			Role: schemas.ModelChatMessageRoleAssistant,
+			AssistantMessage: &schemas.AssistantMessage{ToolCalls: &[]schemas.ToolCall{{
+				ID:       Ptr("call_1"),
+				Function: schemas.FunctionCall{Name: Ptr("get_weather"), Arguments: `{"city": "Paris", "unit": "celsius"}`},
+			}}},
+		},
+		{
+			Role:        schemas.ModelChatMessageRoleTool,
+			Content:     schemas.MessageContent{ContentStr: Ptr("18°C")},
+			ToolMessage: &schemas.ToolMessage{ToolCallID: Ptr("call_1")},
+		},
+	}
+	return &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params: &schemas.ModelParameters{
+			Temperature:   Ptr(0.2),
+			StopSequences: &[]string{"END", "STOP"},
+			Tools: &[]schemas.Tool{
+				{Type: "function", Function: schemas.Function{Name: "get_time"}},
+				{Type: "function", Function: schemas.Function{Name: "get_weather"}},
+			},
+			User:        Ptr("user-1"),
+			ExtraParams: map[string]interface{}{"request_id": "req-1", "reasoning_effort": "low"},
+		},
+		Fallbacks: []schemas.Fallback{{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet-20241022"}},
+	}
+}
+
+func mustHashRequest(t *testing.T, req *schemas.BifrostRequest, opts RequestHashOptions) string {
+	t.Helper()
+	hash, err := HashRequestWithOptions(req, opts)
+	if err != nil {
+		t.Fatalf("HashRequestWithOptions() error = %v", err)
+	}
+	return hash
+}
+
+func TestHashRequest(t *testing.T) {
+	opts := RequestHashOptions{ExcludeExtraParams: []string{"request_id"}}
+	base := mustHashRequest(t, hashTestRequest(), opts)
+	if hash, err := HashRequest(hashTestRequest()); err != nil || len(hash) != 64 {
+		t.Errorf("HashRequest() = %q, %v, want a hex-encoded SHA-256", hash, err)
+	}
+
+	same := map[string]func(req *schemas.BifrostRequest){
+		"reordered stop sequences": func(req *schemas.BifrostRequest) { req.Params.StopSequences = &[]string{"STOP", "END"} },
+		"reordered tools": func(req *schemas.BifrostRequest) {
+			tools := *req.Params.Tools
+			req.Params.Tools = &[]schemas.Tool{tools[1], tools[0]}
+		},
+		"reordered tool call arguments": func(req *schemas.BifrostRequest) {
+			(*(*req.Input.ChatCompletionInput)[1].ToolCalls)[0].Function.Arguments = `{"unit":"celsius","city":"Paris"}`
+		},
+		"other tool call IDs": func(req *schemas.BifrostRequest) {
+			messages := *req.Input.ChatCompletionInput
+			(*messages[1].ToolCalls)[0].ID = Ptr("call_2")
+			messages[2].ToolCallID = Ptr("call_2")
+		},
+		"other user":           func(req *schemas.BifrostRequest) { req.Params.User = Ptr("user-2") },
+		"excluded extra param": func(req *schemas.BifrostRequest) { req.Params.ExtraParams["request_id"] = "req-2" },
+		"other fallbacks":      func(req *schemas.BifrostRequest) { req.Fallbacks = nil },
+		"extra headers":        func(req *schemas.BifrostRequest) { req.ExtraHeaders = map[string]string{"Openai-Project": "proj"} },
+	}
+	for name, change := range same {
+		req := hashTestRequest()
+		change(req)
+		if hash := mustHashRequest(t, req, opts); hash != base {
+			t.Errorf("%s: hash changed, want the same hash", name)
+		}
+	}
+
+	different := map[string]func(req *schemas.BifrostRequest){
+		"other model":       func(req *schemas.BifrostRequest) { req.Model = "gpt-4o-mini" },
+		"other temperature": func(req *schemas.BifrostRequest) { req.Params.Temperature = Ptr(0.3) },
+		"reordered messages": func(req *schemas.BifrostRequest) {
+			messages := *req.Input.ChatCompletionInput
+			messages[0], messages[2] = messages[2], messages[0]
+		},
+		"other extra param": func(req *schemas.BifrostRequest) { req.Params.ExtraParams["reasoning_effort"] = "high" },
+		"output language": func(req *schemas.BifrostRequest) {
+			req.OutputLanguage = &schemas.OutputLanguageConfig{Language: "French"}
+		},
+		"no parameters": func(req *schemas.BifrostRequest) { req.Params = nil },
+		"exact match":   func(req *schemas.BifrostRequest) { req.ExactMatch = true },
+		"auto continue": func(req *schemas.BifrostRequest) {
+			req.AutoContinue = &schemas.AutoContinueConfig{MaxContinuations: 2}
+		},
+	}
+	for name, change := range different {
+		req := hashTestRequest()
+		change(req)
+		if hash := mustHashRequest(t, req, opts); hash == base {
+			t.Errorf("%s: same hash, want a different hash", name)
+		}
+	}
+
+	// Volatile fields count when included
+	for name, includeOpts := range map[string]RequestHashOptions{
+		"user":          {IncludeUser: true},
+		"tool call IDs": {IncludeToolCallIDs: true},
+	} {
+		req := hashTestRequest()
+		req.Params.User = Ptr("user-2")
+		messages := *req.Input.ChatCompletionInput
+		(*messages[1].ToolCalls)[0].ID = Ptr("call_2")
+		if mustHashRequest(t, req, includeOpts) == mustHashRequest(t, hashTestRequest(), includeOpts) {
+			t.Errorf("%s included: same hash, want a different hash", name)
+		}
+	}
+	if mustHashRequest(t, hashTestRequest(), RequestHashOptions{}) == base {
+		t.Error("request_id included: same hash, want a different hash")
+	}
+}
+
+func TestHashRequestDoesNotModifyRequest(t *testing.T) {
+	req := hashTestRequest()
+	if _, err := HashRequest(req); err != nil {
+		t.Fatalf("HashRequest() error = %v", err)
+	}
+	messages := *req.Input.ChatCompletionInput
+	toolCall := (*messages[1].ToolCalls)[0]
+	if toolCall.ID == nil || toolCall.Function.Arguments != `{"city": "Paris", "unit": "celsius"}` || messages[2].ToolCallID == nil {
+		t.Errorf("tool call = %+v, want the request's tool calls unchanged", toolCall)
+	}
+	if req.Params.User == nil || (*req.Params.StopSequences)[0] != "END" || (*req.Params.Tools)[0].Function.Name != "get_time" {
+		t.Errorf("params = %+v, want the request's parameters unchanged", req.Params)
+	}
+
+	if _, err := HashRequest(nil); err == nil {
+		t.Error("HashRequest(nil) succeeded, want an error")
+	}
+}
+
+func TestHashRequestEmptyParams(t *testing.T) {
+	req := hashTestRequest()
+	req.Params = nil
+	withoutParams := mustHashRequest(t, req, RequestHashOptions{})
+	// Parameters that only hold volatile fields hash like no parameters
+	req.Params = &schemas.ModelParameters{User: Ptr("user-1")}
+	if hash := mustHashRequest(t, req, RequestHashOptions{}); hash != withoutParams {
+		t.Error("hash of empty parameters differs from the hash without parameters")
+	}
+}
@@ -0,0 +1,144 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestWithContextHeaders(t *testing.T) {
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-123")
+	contextHeaders := []schemas.ContextHeader{
+		{ContextKey: schemas.BifrostContextKeyRequestID, Header: "x-request-id"},
+		{ContextKey: "trace-id", Header: "X-Trace-ID", Generate: true},
+		{ContextKey: "missing", Header: "X-Missing"},
+		{ContextKey: schemas.BifrostContextKeyRequestID, Header: "Authorization"},
+	}
+	requestHeaders := map[string]string{"OpenAI-Project": "proj_tenant"}
+
+	headers := withContextHeaders(ctx, contextHeaders, requestHeaders)
+
+	if got := headers["X-Request-Id"]; got != "req-123" {
+		t.Errorf("X-Request-Id = %q, want %q", got, "req-123")
+	}
+	for _, name := range []string{"X-Missing", "X-Trace-Id"} {
+		if _, ok := headers[name]; ok {
+			t.Errorf("%s is set, want headers without a context value skipped, as IDs are generated beforehand", name)
+		}
+	}
+	if _, ok := headers["Authorization"]; ok {
+		t.Error("Authorization is set, want it skipped")
+	}
+	if got := headers["Openai-Project"]; got != "proj_tenant" {
+		t.Errorf("Openai-Project = %q, want the request's header", got)
+	}
+	if len(requestHeaders) != 1 {
+		t.Errorf("request headers were modified: %v", requestHeaders)
+	}
+}
+
+func TestGeneratedCorrelationIDSharedAcrossAttempts(t *testing.T) {
+	var mu sync.Mutex
+	var traceIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceIDs = append(traceIDs, r.Header.Get("X-Trace-ID"))
+		attempt := len(traceIDs)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		// The primary fails after its retry, the fallback succeeds
+		if attempt <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"message":"overloaded","type":"server_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{keys: testKeys(), config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			MaxRetries:                     1,
+			RetryBackoffInitial:            time.Millisecond,
+			RetryBackoffMax:                time.Millisecond,
+			ContextHeaders:                 []schemas.ContextHeader{{ContextKey: "trace-id", Header: "X-Trace-ID", Generate: true}},
+		})},
+		Logger: NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func() *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{
+			Provider:  schemas.OpenAI,
+			Model:     "gpt-4o",
+			Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+			Fallbacks: []schemas.Fallback{{Provider: schemas.OpenAI, Model: "gpt-4o-mini"}},
+		}
+	}
+	if _, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), request()); bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	// The retry and the fallback of the request send the ID generated for it
+	if len(traceIDs) != 3 || traceIDs[0] == "" || traceIDs[1] != traceIDs[0] || traceIDs[2] != traceIDs[0] {
+		t.Fatalf("X-Trace-ID of the attempts = %q, want one generated ID", traceIDs)
+	}
+
+	// Another request gets its own ID
+	first := traceIDs[0]
+	traceIDs = nil
+	bifrost.ChatCompletionRequest(context.Background(), request())
+	if len(traceIDs) == 0 || traceIDs[0] == first {
+		t.Errorf("X-Trace-ID of the next request = %q, want a new ID", traceIDs)
+	}
+}
+
+func TestScopeRequestHeaders(t *testing.T) {
+	requestHeaders := map[string]string{"OpenAI-Organization": "org-tenant", "openai-project": "proj_tenant"}
+	if headers := scopeRequestHeaders(schemas.OpenAI, requestHeaders); len(headers) != 2 {
+		t.Errorf("headers for openai = %v, want the OpenAI headers", headers)
+	}
+	if headers := scopeRequestHeaders(schemas.Anthropic, requestHeaders); len(headers) != 0 {
+		t.Errorf("headers for anthropic = %v, want the OpenAI headers dropped", headers)
+	}
+	if len(requestHeaders) != 2 {
+		t.Errorf("request headers were modified: %v", requestHeaders)
+	}
+}
+
+func TestWithIdentificationHeaders(t *testing.T) {
+	config := schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{ClientID: "edge-eu"}}
+	config.CheckAndSetDefaults()
+
+	requestHeaders := map[string]string{"x-bifrost-client-id": "tenant-override"}
+	headers := withIdentificationHeaders(config.NetworkConfig, requestHeaders)
+	if got := headers["User-Agent"]; got != schemas.DefaultUserAgent || !strings.HasPrefix(got, "bifrost/") {
+		t.Errorf("User-Agent = %q, want %q", got, schemas.DefaultUserAgent)
+	}
+	if got := headers[schemas.ClientIDHeader]; got != "tenant-override" {
+		t.Errorf("%s = %q, want the request's header", schemas.ClientIDHeader, got)
+	}
+	if len(requestHeaders) != 1 {
+		t.Errorf("request headers were modified: %v", requestHeaders)
+	}
+
+	// A User-Agent in ExtraHeaders is kept, as request-level headers override them
+	config.NetworkConfig.ExtraHeaders = map[string]string{"user-agent": "custom/1.0"}
+	headers = withIdentificationHeaders(config.NetworkConfig, nil)
+	if _, ok := headers["User-Agent"]; ok {
+		t.Errorf("User-Agent = %q, want the ExtraHeaders value left in place", headers["User-Agent"])
+	}
+}
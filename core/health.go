@@ -0,0 +1,180 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// providerHealth is the circuit breaker of a provider for BifrostConfig.ProviderHealth. The
+// circuit opens after threshold consecutive failed requests. While it is open, the provider is
+// unhealthy, except that one request is let through every probeInterval to detect its recovery.
+// A successful request closes the circuit.
+type providerHealth struct {
+	threshold     int
+	probeInterval time.Duration
+	now           func() time.Time
+
+	mu             sync.Mutex
+	failures       int       // Consecutive failed requests
+	openedAt       time.Time // Zero while the circuit is closed
+	probeStartedAt time.Time // Start of the last probe of the open circuit
+}
+
+// newProviderHealth returns the circuit breaker of a provider with the given settings.
+func newProviderHealth(config *schemas.ProviderHealthConfig) *providerHealth {
+	threshold := config.FailureThreshold
+	if threshold <= 0 {
+		threshold = schemas.DefaultProviderHealthFailureThreshold
+	}
+	probeInterval := config.ProbeInterval
+	if probeInterval <= 0 {
+		probeInterval = schemas.DefaultProviderHealthProbeInterval
+	}
+	return &providerHealth{threshold: threshold, probeInterval: probeInterval, now: time.Now}
+}
+
+// allow reports whether a request may be sent to the provider: always while its circuit is
+// closed, and once per probe interval while it is open. A nil tracker always allows requests.
+func (h *providerHealth) allow() bool {
+	if h == nil {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.openedAt.IsZero() {
+		return true
+	}
+	now := h.now()
+	if now.Sub(h.openedAt) < h.probeInterval || now.Sub(h.probeStartedAt) < h.probeInterval {
+		return false
+	}
+	h.probeStartedAt = now
+	return true
+}
+
+// healthy reports whether the provider's circuit is closed.
+func (h *providerHealth) healthy() bool {
+	if h == nil {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.openedAt.IsZero()
+}
+
+// record accounts for the outcome of a request sent to the provider. Errors that do not reflect
+// on the provider, such as cancellations or invalid requests, are ignored.
+func (h *providerHealth) record(bifrostErr *schemas.BifrostError) {
+	if h == nil {
+		return
+	}
+	failed := bifrostErr != nil
+	if failed && !isProviderFailure(bifrostErr) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !failed {
+		h.failures = 0
+		h.openedAt = time.Time{}
+		return
+	}
+	h.failures++
+	// A failed probe keeps the circuit open for another interval
+	if !h.openedAt.IsZero() || h.failures >= h.threshold {
+		h.openedAt = h.now()
+	}
+}
+
+// isProviderFailure reports whether an error shows that the provider is down: it could not be
+// reached or timed out, it was rate limited (429) or it failed (5xx).
+func isProviderFailure(bifrostErr *schemas.BifrostError) bool {
+	if bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.RequestCancelled {
+		return false
+	}
+	if bifrostErr.StatusCode != nil {
+		status := *bifrostErr.StatusCode
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	return bifrostErr.Kind == schemas.ErrorKindConnection
+}
+
+// getProviderHealth returns the circuit breaker of a provider, or nil if ProviderHealth is not configured.
+func (bifrost *Bifrost) getProviderHealth(providerKey schemas.ModelProvider) *providerHealth {
+	if bifrost.providerHealthConfig == nil {
+		return nil
+	}
+	if health, ok := bifrost.providerHealth.Load(providerKey); ok {
+		return health.(*providerHealth)
+	}
+	health, _ := bifrost.providerHealth.LoadOrStore(providerKey, newProviderHealth(bifrost.providerHealthConfig))
+	return health.(*providerHealth)
+}
+
+// IsProviderHealthy reports whether a provider's circuit is closed. Providers are always healthy
+// if ProviderHealth is not configured.
+func (bifrost *Bifrost) IsProviderHealthy(providerKey schemas.ModelProvider) bool {
+	return bifrost.getProviderHealth(providerKey).healthy()
+}
+
+// skipUnhealthyPrimary implements ProviderHealth for a request with fallbacks: if the circuit of
+// its primary is open, it returns a copy of the request that starts from its first fallback that
+// is healthy (or due for a probe), with the later fallbacks. The skipped providers are carried by
+// the returned context so they can be reported on the response, and the move from the primary to
+// the fallback is reported to OnFailover with an error of type ProviderUnhealthy. If no fallback
+// is healthy, the request is returned unchanged and the primary is tried anyway. ExactMatch
+// requests are never rerouted.
+func (bifrost *Bifrost) skipUnhealthyPrimary(ctx context.Context, req *schemas.BifrostRequest, requestType RequestType) (context.Context, *schemas.BifrostRequest) {
+	if bifrost.providerHealthConfig == nil || req.ExactMatch || len(req.Fallbacks) == 0 || bifrost.getProviderHealth(req.Provider).allow() {
+		return ctx, req
+	}
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	skipped := []schemas.Fallback{{Provider: req.Provider, Model: req.Model}}
+	for i, fallback := range req.Fallbacks {
+		fallbackReq, _ := bifrost.prepareFallbackRequest(req, fallback)
+		if fallbackReq == nil {
+			continue
+		}
+		if !bifrost.getProviderHealth(fallback.Provider).allow() {
+			skipped = append(skipped, fallback)
+			continue
+		}
+
+		bifrost.logger.Info(fmt.Sprintf("Provider %s is unhealthy, starting from fallback provider %s with model %s", req.Provider, fallback.Provider, fallback.Model))
+		fallbackReq.Fallbacks = req.Fallbacks[i+1:]
+		bifrost.reportFailover(requestType, schemas.AttemptError{
+			Provider: req.Provider,
+			Model:    req.Model,
+			Type:     Ptr(schemas.ProviderUnhealthy),
+			Message:  fmt.Sprintf("provider %s is unhealthy", req.Provider),
+		}, fallback, 1)
+		return context.WithValue(ctx, schemas.BifrostContextKeySkippedProviders, skipped), fallbackReq
+	}
+
+	bifrost.logger.Debug(fmt.Sprintf("Provider %s and its fallbacks are unhealthy, trying it anyway", req.Provider))
+	return ctx, req
+}
+
+// attachSkippedProviders reports on the response the unhealthy providers skipped by
+// skipUnhealthyPrimary, if any.
+func attachSkippedProviders(ctx context.Context, resp *schemas.BifrostResponse) {
+	if resp == nil || ctx == nil {
+		return
+	}
+	if skipped, ok := ctx.Value(schemas.BifrostContextKeySkippedProviders).([]schemas.Fallback); ok {
+		resp.ExtraFields.SkippedProviders = skipped
+	}
+}
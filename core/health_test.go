@@ -0,0 +1,89 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestProviderHealthCircuit(t *testing.T) {
+	health := newProviderHealth(&schemas.ProviderHealthConfig{FailureThreshold: 2, ProbeInterval: time.Minute})
+	now := time.Now()
+	health.now = func() time.Time { return now }
+
+	serverError := &schemas.BifrostError{StatusCode: Ptr(503)}
+	badRequest := &schemas.BifrostError{StatusCode: Ptr(400)}
+	health.record(serverError)
+	health.record(badRequest) // not the provider's fault
+	if !health.allow() {
+		t.Fatal("circuit opened after a single provider failure")
+	}
+	health.record(serverError)
+	if health.allow() || health.healthy() {
+		t.Fatal("circuit is still closed after 2 consecutive failures")
+	}
+
+	// One probe is let through per interval, and a failed probe keeps the circuit open
+	now = now.Add(time.Minute)
+	if !health.allow() || health.allow() {
+		t.Fatal("want exactly one probe after the probe interval")
+	}
+	health.record(serverError)
+	now = now.Add(30 * time.Second)
+	if health.allow() {
+		t.Fatal("request allowed before the next probe interval")
+	}
+
+	// A successful probe closes the circuit
+	now = now.Add(30 * time.Second)
+	if !health.allow() {
+		t.Fatal("probe not allowed after the probe interval")
+	}
+	health.record(nil)
+	if !health.healthy() || !health.allow() {
+		t.Fatal("circuit is still open after a successful probe")
+	}
+}
+
+func TestSkipUnhealthyPrimary(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	bifrost.providerHealthConfig = &schemas.ProviderHealthConfig{FailureThreshold: 1, ProbeInterval: time.Hour}
+	serverError := &schemas.BifrostError{StatusCode: Ptr(500)}
+	bifrost.getProviderHealth(schemas.OpenAI).record(serverError)
+	bifrost.getProviderHealth(schemas.Anthropic).record(serverError)
+
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Fallbacks: []schemas.Fallback{
+			{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet-20241022"},
+			{Provider: schemas.Mistral, Model: "mistral-large-latest"},
+			{Provider: schemas.Groq, Model: "llama-3.3-70b-versatile"},
+		},
+	}
+	var failovers []schemas.FailoverEvent
+	bifrost.onFailover = func(event schemas.FailoverEvent) { failovers = append(failovers, event) }
+	ctx, skippedReq := bifrost.skipUnhealthyPrimary(context.Background(), req, ChatCompletionRequest)
+	if skippedReq.Provider != schemas.Mistral || len(skippedReq.Fallbacks) != 1 || skippedReq.Fallbacks[0].Provider != schemas.Groq {
+		t.Fatalf("request starts from %s with fallbacks %v, want mistral with groq", skippedReq.Provider, skippedReq.Fallbacks)
+	}
+	// Skipping the primary is reported as a failover to the fallback that is tried
+	if len(failovers) != 1 || failovers[0].Failed.Provider != schemas.OpenAI || *failovers[0].Failed.Type != schemas.ProviderUnhealthy ||
+		failovers[0].Fallback.Provider != schemas.Mistral || failovers[0].RequestType != string(ChatCompletionRequest) {
+		t.Errorf("failovers = %+v, want one from openai to mistral", failovers)
+	}
+
+	resp := &schemas.BifrostResponse{}
+	attachSkippedProviders(ctx, resp)
+	if skipped := resp.ExtraFields.SkippedProviders; len(skipped) != 2 || skipped[0].Provider != schemas.OpenAI || skipped[1].Provider != schemas.Anthropic {
+		t.Errorf("skipped providers = %v, want openai and anthropic", skipped)
+	}
+
+	// Without a healthy fallback, the primary is tried anyway
+	req.Fallbacks = req.Fallbacks[:1]
+	if _, unchanged := bifrost.skipUnhealthyPrimary(context.Background(), req, ChatCompletionRequest); unchanged != req {
+		t.Errorf("request starts from %s, want the unchanged request", unchanged.Provider)
+	}
+}
@@ -0,0 +1,136 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// hedgedAttempt is the outcome of one attempt of a hedged request.
+type hedgedAttempt struct {
+	index  int
+	req    *schemas.BifrostRequest
+	result *schemas.BifrostResponse
+	err    *schemas.BifrostError
+}
+
+// handleHedgedRequest implements BifrostRequest.Hedging. The primary is started first, and each
+// fallback is started after the hedging delay, or as soon as a running attempt fails. The first
+// successful response is returned, and the attempts still running are cancelled.
+// If every attempt fails, the primary's error is returned with the history of all attempts.
+func (bifrost *Bifrost) handleHedgedRequest(ctx context.Context, req *schemas.BifrostRequest, requestType RequestType) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	candidates := []*schemas.BifrostRequest{req}
+	var skipped []schemas.SkippedFallback
+	for i, fallback := range req.Fallbacks {
+		if untried, reached := fallbackLimitReached(req, len(candidates)-1, req.Fallbacks[i:]); reached {
+			skipped = append(skipped, untried...)
+			break
+		}
+		fallbackReq, reason := bifrost.prepareFallbackRequest(req, fallback)
+		if fallbackReq == nil {
+			skipped = append(skipped, schemas.SkippedFallback{Provider: fallback.Provider, Model: fallback.Model, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, fallbackReq)
+	}
+
+	// Cancelling the shared context stops the attempts that lost the race
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so that cancelled attempts can report their outcome after we returned
+	results := make(chan hedgedAttempt, len(candidates))
+	launched, running := 0, 0
+	launchNext := func() {
+		index := launched
+		launched++
+		running++
+		go func() {
+			attemptReq := candidates[index]
+			result, bifrostErr := bifrost.tryRequest(attemptReq, hedgeCtx, requestType)
+			if replacementReq := bifrost.replaceDecommissionedModel(attemptReq, bifrostErr); replacementReq != nil {
+				attemptReq = replacementReq
+				result, bifrostErr = bifrost.tryRequest(attemptReq, hedgeCtx, requestType)
+			}
+			results <- hedgedAttempt{index: index, req: attemptReq, result: result, err: bifrostErr}
+		}()
+	}
+
+	delay := time.Duration(req.Hedging.DelayMs) * time.Millisecond
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	launchNext()
+
+	// Record every failed attempt, in the order of the candidates, so the final error explains
+	// why each provider failed
+	failures := make([]*hedgedAttempt, len(candidates))
+	for running > 0 {
+		select {
+		case <-timer.C:
+			if launched < len(candidates) {
+				bifrost.logger.Debug(fmt.Sprintf("Hedging request with provider %s after %s", candidates[launched].Provider, delay))
+				launchNext()
+				timer.Reset(delay)
+			}
+		case attempt := <-results:
+			running--
+			if attempt.err == nil {
+				if attempt.index > 0 {
+					bifrost.logger.Info(fmt.Sprintf("Hedged request answered by fallback provider %s with model %s", attempt.req.Provider, attempt.req.Model))
+				}
+				return attempt.result, nil
+			}
+
+			attempt.err.Provider = attempt.req.Provider
+			failures[attempt.index] = &attempt
+			if !allowsFallbacks(attempt.err) {
+				attempt.err.AttemptHistory = hedgedAttemptHistory(failures)
+				attempt.err.SkippedFallbacks = skipped
+				return nil, attempt.err
+			}
+
+			bifrost.logger.Warn(fmt.Sprintf("Hedged attempt with provider %s failed: %s", attempt.req.Provider, attempt.err.Error.Message))
+			if launched < len(candidates) {
+				next := candidates[launched]
+				bifrost.reportFailover(requestType, newAttemptError(attempt.req, attempt.err), schemas.Fallback{Provider: next.Provider, Model: next.Model}, launched)
+				launchNext()
+				timer.Reset(delay)
+			}
+		}
+	}
+
+	// All attempts failed, return the primary's error
+	primaryErr := failures[0].err
+	if history := hedgedAttemptHistory(failures); len(history) > 1 {
+		primaryErr.AttemptHistory = history
+	}
+	attachSkippedFallbacks(primaryErr, skipped, len(req.Fallbacks))
+	return nil, primaryErr
+}
+
+// allowsFallbacks reports whether another provider may be tried after bifrostErr, which is not
+// the case for cancelled requests and for errors with AllowFallbacks set to false.
+func allowsFallbacks(bifrostErr *schemas.BifrostError) bool {
+	if bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.RequestCancelled {
+		return false
+	}
+	return bifrostErr.AllowFallbacks == nil || *bifrostErr.AllowFallbacks
+}
+
+// hedgedAttemptHistory returns the errors of the failed attempts of a hedged request.
+func hedgedAttemptHistory(failures []*hedgedAttempt) []schemas.AttemptError {
+	var history []schemas.AttemptError
+	for _, failure := range failures {
+		if failure != nil {
+			history = append(history, newAttemptError(failure.req, failure.err))
+		}
+	}
+	return history
+}
@@ -0,0 +1,133 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// latencyPlugin answers requests itself after a per-provider delay, or fails them immediately
+// for providers in failing. Attempts cancelled before their delay are reported on cancelled.
+type latencyPlugin struct {
+	delays    map[schemas.ModelProvider]time.Duration
+	failing   map[schemas.ModelProvider]bool
+	cancelled chan schemas.ModelProvider
+}
+
+func (p *latencyPlugin) GetName() string { return "latency" }
+
+func (p *latencyPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if p.failing[req.Provider] {
+		return req, &schemas.PluginShortCircuit{Error: &schemas.BifrostError{StatusCode: Ptr(503), Error: schemas.ErrorField{Message: "unavailable"}}}, nil
+	}
+
+	select {
+	case <-time.After(p.delays[req.Provider]):
+		response := &schemas.BifrostResponse{}
+		response.ExtraFields.Provider = req.Provider
+		return req, &schemas.PluginShortCircuit{Response: response}, nil
+	case <-(*ctx).Done():
+		p.cancelled <- req.Provider
+		return req, &schemas.PluginShortCircuit{Error: &schemas.BifrostError{Error: schemas.ErrorField{Type: Ptr(schemas.RequestCancelled), Message: "cancelled"}}}, nil
+	}
+}
+
+func (p *latencyPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *latencyPlugin) Cleanup() error { return nil }
+
+func newHedgingBifrost(t *testing.T, plugin *latencyPlugin) *Bifrost {
+	t.Helper()
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(bifrost.Cleanup)
+	return bifrost
+}
+
+func hedgedRequest(delayMs int) *schemas.BifrostRequest {
+	return &schemas.BifrostRequest{
+		Provider:  schemas.OpenAI,
+		Model:     "gpt-4o",
+		Fallbacks: []schemas.Fallback{{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet"}},
+		Hedging:   &schemas.HedgingConfig{DelayMs: delayMs},
+		Input:     schemas.RequestInput{ChatCompletionInput: &[]schemas.BifrostMessage{schemas.UserMessage("Hello")}},
+	}
+}
+
+func TestHedgedRequestCancelsSlowPrimary(t *testing.T) {
+	plugin := &latencyPlugin{
+		delays:    map[schemas.ModelProvider]time.Duration{schemas.OpenAI: time.Minute},
+		cancelled: make(chan schemas.ModelProvider, 2),
+	}
+	bifrost := newHedgingBifrost(t, plugin)
+
+	result, bifrostErr := bifrost.handleRequest(context.Background(), hedgedRequest(10), ChatCompletionRequest)
+	if bifrostErr != nil {
+		t.Fatalf("handleRequest() error = %+v", bifrostErr)
+	}
+	if result.ExtraFields.Provider != schemas.Anthropic {
+		t.Errorf("answered by %s, want anthropic", result.ExtraFields.Provider)
+	}
+
+	select {
+	case provider := <-plugin.cancelled:
+		if provider != schemas.OpenAI {
+			t.Errorf("cancelled attempt = %s, want openai", provider)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("primary attempt was not cancelled")
+	}
+}
+
+func TestHedgedRequestStartsFallbackOnFailure(t *testing.T) {
+	plugin := &latencyPlugin{
+		failing:   map[schemas.ModelProvider]bool{schemas.OpenAI: true},
+		cancelled: make(chan schemas.ModelProvider, 2),
+	}
+	bifrost := newHedgingBifrost(t, plugin)
+
+	// The fallback must not wait for the hedging delay once the primary failed
+	done := make(chan *schemas.BifrostResponse, 1)
+	go func() {
+		result, _ := bifrost.handleRequest(context.Background(), hedgedRequest(int(time.Hour.Milliseconds())), ChatCompletionRequest)
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		if result == nil || result.ExtraFields.Provider != schemas.Anthropic {
+			t.Errorf("result = %+v, want a response from anthropic", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fallback was not started after the primary failed")
+	}
+}
+
+func TestHedgedRequestAllFailed(t *testing.T) {
+	plugin := &latencyPlugin{
+		failing:   map[schemas.ModelProvider]bool{schemas.OpenAI: true, schemas.Anthropic: true},
+		cancelled: make(chan schemas.ModelProvider, 2),
+	}
+	bifrost := newHedgingBifrost(t, plugin)
+
+	_, bifrostErr := bifrost.handleRequest(context.Background(), hedgedRequest(0), ChatCompletionRequest)
+	if bifrostErr == nil {
+		t.Fatal("handleRequest() succeeded, want an error")
+	}
+	if bifrostErr.Provider != schemas.OpenAI {
+		t.Errorf("error provider = %s, want the primary", bifrostErr.Provider)
+	}
+	if len(bifrostErr.AttemptHistory) != 2 || bifrostErr.AttemptHistory[1].Provider != schemas.Anthropic {
+		t.Errorf("attempt history = %+v, want the primary and the fallback", bifrostErr.AttemptHistory)
+	}
+}
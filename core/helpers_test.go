@@ -0,0 +1,73 @@
+package bifrost
+
+import (
+	"context"
+	"sync"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// testAccount is a configurable schemas.Account for tests. It configures providers (OpenAI if
+// nil) with the config returned by config (an empty config if nil), and returns the same keys
+// for every provider. The keys can be replaced at runtime with setKeys.
+type testAccount struct {
+	providers []schemas.ModelProvider
+	config    func(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error)
+
+	mu    sync.Mutex
+	keys  []schemas.Key
+	calls int // Number of GetKeysForProvider calls
+}
+
+func (a *testAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	if a.providers == nil {
+		return []schemas.ModelProvider{schemas.OpenAI}, nil
+	}
+	return a.providers, nil
+}
+
+func (a *testAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	return a.keys, nil
+}
+
+func (a *testAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	if a.config == nil {
+		return &schemas.ProviderConfig{}, nil
+	}
+	return a.config(providerKey)
+}
+
+func (a *testAccount) setKeys(keys []schemas.Key) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = keys
+}
+
+// sameConfig returns a testAccount config function giving every provider a copy of config.
+func sameConfig(config schemas.ProviderConfig) func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+		copied := config
+		return &copied, nil
+	}
+}
+
+// networkConfig returns a testAccount config function giving every provider the network settings.
+func networkConfig(network schemas.NetworkConfig) func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return sameConfig(schemas.ProviderConfig{NetworkConfig: network})
+}
+
+// testKeys returns a single key for test servers.
+func testKeys() []schemas.Key {
+	return []schemas.Key{{ID: "key", Value: "sk-test", Weight: 1}}
+}
+
+func newTestBifrost(account schemas.Account) *Bifrost {
+	return &Bifrost{
+		account:       account,
+		logger:        NewDefaultLogger(schemas.LogLevelError),
+		inFlightByKey: make(map[string]map[uint64]context.CancelFunc),
+	}
+}
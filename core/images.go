@@ -0,0 +1,366 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/maximhq/bifrost/core/providers"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	// maxImageFetchSize bounds the size of the images fetched from URLs to be downscaled.
+	maxImageFetchSize = 32 << 20
+	// maxImagePixels bounds the width x height of the images decoded to be downscaled, since a
+	// small compressed image can decode to gigabytes of pixels.
+	maxImagePixels = 64 << 20
+	// minImageDimension is the smallest width or height an image is shrunk to when fitting MaxBytes.
+	minImageDimension = 16
+	// downscaledJPEGQuality is the quality JPEG images are re-encoded with after resizing.
+	downscaledJPEGQuality = 85
+)
+
+// imageCacheContextKey holds the imageCache of a request in its context.
+const imageCacheContextKey schemas.BifrostContextKey = "bifrost-image-cache"
+
+// imageHTTPClient fetches the images given as URLs; each fetch is also bound to the request context.
+// It only connects to public addresses, so that callers cannot make the server fetch internal URLs,
+// including through redirects or hostnames resolving to internal addresses. It ignores the proxy
+// environment variables, as the address checked would then be the proxy's rather than the image's.
+var imageHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		Proxy:       nil,
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: refuseNonPublicAddress}).DialContext,
+	},
+}
+
+// imageCache holds the images downscaled for a request, so that its fallbacks and hedged attempts
+// do not fetch and decode them again. Results are cached per URL and limits, errors included.
+type imageCache struct {
+	mu     sync.Mutex
+	images map[imageCacheKey]imageCacheEntry
+}
+
+type imageCacheKey struct {
+	url    string
+	limits schemas.ImageLimits
+}
+
+type imageCacheEntry struct {
+	url string
+	err error
+}
+
+// withImageCache returns ctx with an empty imageCache, unless it already has one.
+func withImageCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(imageCacheContextKey).(*imageCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, imageCacheContextKey, &imageCache{images: map[imageCacheKey]imageCacheEntry{}})
+}
+
+// downscaleCachedImage is downscaleImage, reusing the result cached in ctx if any.
+func downscaleCachedImage(ctx context.Context, rawURL string, limits schemas.ImageLimits) (string, error) {
+	cache, ok := ctx.Value(imageCacheContextKey).(*imageCache)
+	if !ok {
+		return downscaleImage(ctx, rawURL, limits)
+	}
+
+	key := imageCacheKey{url: rawURL, limits: limits}
+	cache.mu.Lock()
+	entry, found := cache.images[key]
+	cache.mu.Unlock()
+	if found {
+		return entry.url, entry.err
+	}
+
+	downscaledURL, err := downscaleImage(ctx, rawURL, limits)
+	if ctx.Err() == nil {
+		cache.mu.Lock()
+		cache.images[key] = imageCacheEntry{url: downscaledURL, err: err}
+		cache.mu.Unlock()
+	}
+	return downscaledURL, err
+}
+
+// downscaleImages returns req with the images of its chat messages downscaled to the provider's
+// ImageLimits. Messages are copied when one of their images changes, so req is never modified.
+// Images that cannot be loaded or decoded are logged and sent unchanged.
+func (bifrost *Bifrost) downscaleImages(ctx context.Context, req *schemas.BifrostRequest) *schemas.BifrostRequest {
+	if req.ExactMatch || req.Input.ChatCompletionInput == nil || !hasImageContent(*req.Input.ChatCompletionInput) {
+		return req
+	}
+
+	config, err := bifrost.getProviderConfig(req.Provider)
+	if err != nil || config.ImageLimits == nil {
+		return req
+	}
+	limits := *config.ImageLimits
+	if limits.MaxDimension <= 0 && limits.MaxBytes <= 0 {
+		return req
+	}
+
+	var messages []schemas.BifrostMessage
+	for i, message := range *req.Input.ChatCompletionInput {
+		if message.Content.ContentBlocks == nil {
+			continue
+		}
+
+		var blocks []schemas.ContentBlock
+		for j, block := range *message.Content.ContentBlocks {
+			if block.ImageURL == nil || block.ImageURL.URL == "" {
+				continue
+			}
+			if !limits.FetchURLs && isImageLink(block.ImageURL.URL) {
+				continue
+			}
+			downscaledURL, err := downscaleCachedImage(ctx, block.ImageURL.URL, limits)
+			if err != nil {
+				bifrost.logger.Warn(fmt.Sprintf("Sending image unchanged to provider %s: %v", req.Provider, err))
+				continue
+			}
+			if downscaledURL == "" {
+				continue
+			}
+			if blocks == nil {
+				blocks = slices.Clone(*message.Content.ContentBlocks)
+			}
+			blocks[j].ImageURL = &schemas.ImageURLStruct{URL: downscaledURL, Detail: block.ImageURL.Detail}
+		}
+		if blocks == nil {
+			continue
+		}
+
+		if messages == nil {
+			messages = slices.Clone(*req.Input.ChatCompletionInput)
+		}
+		messages[i].Content = schemas.MessageContent{ContentBlocks: &blocks}
+	}
+	if messages == nil {
+		return req
+	}
+
+	downscaledReq := *req
+	downscaledReq.Input.ChatCompletionInput = &messages
+	return &downscaledReq
+}
+
+// hasImageContent reports whether any of the messages contains an image.
+func hasImageContent(messages []schemas.BifrostMessage) bool {
+	for _, message := range messages {
+		if message.Content.ContentBlocks == nil {
+			continue
+		}
+		for _, block := range *message.Content.ContentBlocks {
+			if block.ImageURL != nil && block.ImageURL.URL != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// downscaleImage returns the image at rawURL (a data URL, raw base64 or an HTTP URL) resized to fit
+// the limits, as a data URL. It returns an empty string if the image is already within the limits.
+// The aspect ratio is preserved. PNG and GIF images are re-encoded as PNG to keep their
+// transparency, and JPEG images as JPEG.
+func downscaleImage(ctx context.Context, rawURL string, limits schemas.ImageLimits) (string, error) {
+	data, err := loadImage(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if fitsImageLimits(imageConfig.Width, imageConfig.Height, len(data), limits) {
+		return "", nil
+	}
+	if pixels := int64(imageConfig.Width) * int64(imageConfig.Height); pixels > maxImagePixels {
+		return "", fmt.Errorf("image of %dx%d pixels is larger than %d pixels", imageConfig.Width, imageConfig.Height, maxImagePixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := fitImageDimensions(imageConfig.Width, imageConfig.Height, limits.MaxDimension)
+	for {
+		encoded, mediaType, err := encodeImage(resizeImage(img, width, height), format)
+		if err != nil {
+			return "", err
+		}
+		if limits.MaxBytes <= 0 || len(encoded) <= limits.MaxBytes {
+			return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(encoded), nil
+		}
+
+		// The encoded size grows with the area, so shrink both sides by the square root of the excess
+		scale := math.Sqrt(float64(limits.MaxBytes)/float64(len(encoded))) * 0.9
+		width, height = int(float64(width)*scale), int(float64(height)*scale)
+		if width < minImageDimension || height < minImageDimension {
+			return "", fmt.Errorf("image cannot be downscaled to %d bytes", limits.MaxBytes)
+		}
+	}
+}
+
+// isImageLink reports whether an image is given as a URL to fetch, rather than inline data.
+func isImageLink(rawURL string) bool {
+	sanitizedURL, err := providers.SanitizeImageURL(rawURL)
+	return err == nil && !strings.HasPrefix(sanitizedURL, "data:")
+}
+
+// loadImage returns the encoded bytes of an image given as a data URL, raw base64 or an HTTP URL.
+func loadImage(ctx context.Context, rawURL string) ([]byte, error) {
+	sanitizedURL, err := providers.SanitizeImageURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(sanitizedURL, "data:") {
+		return fetchImage(ctx, sanitizedURL)
+	}
+
+	info := providers.ExtractURLTypeInfo(sanitizedURL)
+	if info.Type != providers.ImageContentTypeBase64 || info.DataURLWithoutPrefix == nil {
+		return nil, fmt.Errorf("unsupported image data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(*info.DataURLWithoutPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 image: %w", err)
+	}
+	return data, nil
+}
+
+// fetchImage downloads the image at an http or https URL, up to maxImageFetchSize bytes.
+func fetchImage(ctx context.Context, rawURL string) ([]byte, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported image URL scheme %q", parsedURL.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := imageHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch image: status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageFetchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+	if len(data) > maxImageFetchSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxImageFetchSize)
+	}
+	return data, nil
+}
+
+// refuseNonPublicAddress is a net.Dialer Control function refusing connections to loopback,
+// private, link-local and unspecified addresses. It runs after name resolution, for every connection.
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to fetch image from non-public address %s", host)
+	}
+	return nil
+}
+
+// fitsImageLimits reports whether an image of the given dimensions and encoded size is within limits.
+func fitsImageLimits(width, height, size int, limits schemas.ImageLimits) bool {
+	if limits.MaxDimension > 0 && (width > limits.MaxDimension || height > limits.MaxDimension) {
+		return false
+	}
+	return limits.MaxBytes <= 0 || size <= limits.MaxBytes
+}
+
+// fitImageDimensions scales width and height down so that neither exceeds maxDimension,
+// preserving the aspect ratio. Dimensions within the limit are returned unchanged.
+func fitImageDimensions(width, height, maxDimension int) (int, int) {
+	if maxDimension <= 0 || (width <= maxDimension && height <= maxDimension) {
+		return width, height
+	}
+
+	scale := float64(maxDimension) / float64(max(width, height))
+	return max(int(math.Round(float64(width)*scale)), 1), max(int(math.Round(float64(height)*scale)), 1)
+}
+
+// resizeImage downscales src to width x height by averaging the source pixels covered by each
+// destination pixel (a box filter).
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := max(bounds.Min.Y+(y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := max(bounds.Min.X+(x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: uint16(a / count)})
+		}
+	}
+
+	return dst
+}
+
+// encodeImage encodes a resized image in the format of the original, returning the bytes and their media type.
+func encodeImage(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: downscaledJPEGQuality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,230 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// testImage returns a width x height image filled with varied colors.
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x * y), A: 255})
+		}
+	}
+	return img
+}
+
+func pngDataURL(t *testing.T, img image.Image) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// decodeDataURL decodes an image data URL, returning the image and its format.
+func decodeDataURL(t *testing.T, dataURL string) (image.Image, string) {
+	t.Helper()
+	_, encoded, found := strings.Cut(dataURL, ";base64,")
+	if !found {
+		t.Fatalf("not a base64 data URL: %.40s", dataURL)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("base64 decode error = %v", err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("image.Decode() error = %v", err)
+	}
+	return img, format
+}
+
+func TestFitImageDimensions(t *testing.T) {
+	tests := []struct {
+		width, height, maxDimension int
+		wantWidth, wantHeight       int
+	}{
+		{400, 200, 100, 100, 50},
+		{200, 400, 100, 50, 100},
+		{80, 60, 100, 80, 60},
+		{4000, 3, 100, 100, 1},
+		{400, 200, 0, 400, 200},
+	}
+
+	for _, tt := range tests {
+		width, height := fitImageDimensions(tt.width, tt.height, tt.maxDimension)
+		if width != tt.wantWidth || height != tt.wantHeight {
+			t.Errorf("fitImageDimensions(%d, %d, %d) = %dx%d, want %dx%d", tt.width, tt.height, tt.maxDimension, width, height, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
+
+func TestDownscaleImageDataURL(t *testing.T) {
+	dataURL := pngDataURL(t, testImage(400, 200))
+
+	downscaled, err := downscaleImage(context.Background(), dataURL, schemas.ImageLimits{MaxDimension: 100})
+	if err != nil {
+		t.Fatalf("downscaleImage() error = %v", err)
+	}
+	img, format := decodeDataURL(t, downscaled)
+	if format != "png" || img.Bounds().Dx() != 100 || img.Bounds().Dy() != 50 {
+		t.Errorf("downscaled image = %s %v, want png 100x50", format, img.Bounds())
+	}
+
+	// Images within the limits are left unchanged
+	unchanged, err := downscaleImage(context.Background(), dataURL, schemas.ImageLimits{MaxDimension: 400})
+	if err != nil || unchanged != "" {
+		t.Errorf("downscaleImage() within limits = %.40q, %v, want no change", unchanged, err)
+	}
+}
+
+func TestDownscaleImageURL(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "image/jpeg")
+		jpeg.Encode(w, testImage(300, 300), nil)
+	}))
+	defer server.Close()
+
+	// Internal addresses and other schemes are never fetched, and images are fetched directly so
+	// that the address checked is the image's
+	if transport := imageHTTPClient.Transport.(*http.Transport); transport.Proxy != nil {
+		t.Error("imageHTTPClient uses a proxy, whose address would be checked instead of the image's")
+	}
+	limits := schemas.ImageLimits{MaxDimension: 150, FetchURLs: true}
+	if _, err := downscaleImage(context.Background(), server.URL+"/photo.jpg", limits); err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("downscaleImage() of a loopback URL error = %v, want a refusal", err)
+	}
+	if _, err := fetchImage(context.Background(), "file:///etc/passwd"); err == nil || fetches.Load() != 0 {
+		t.Errorf("fetchImage() of a file URL error = %v after %d fetches, want a refusal", err, fetches.Load())
+	}
+
+	client := imageHTTPClient
+	imageHTTPClient = server.Client()
+	defer func() { imageHTTPClient = client }()
+
+	downscaled, err := downscaleImage(context.Background(), server.URL+"/photo.jpg", limits)
+	if err != nil {
+		t.Fatalf("downscaleImage() error = %v", err)
+	}
+	img, format := decodeDataURL(t, downscaled)
+	if format != "jpeg" || img.Bounds().Dx() != 150 || img.Bounds().Dy() != 150 {
+		t.Errorf("downscaled image = %s %v, want jpeg 150x150", format, img.Bounds())
+	}
+}
+
+func TestDownscaleImagesFetchesOncePerRequest(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		jpeg.Encode(w, testImage(300, 300), nil)
+	}))
+	defer server.Close()
+	client := imageHTTPClient
+	imageHTTPClient = server.Client()
+	defer func() { imageHTTPClient = client }()
+
+	limits := &schemas.ImageLimits{MaxDimension: 150}
+	bifrost := newTestBifrost(&testAccount{config: func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+		return &schemas.ProviderConfig{ImageLimits: limits}, nil
+	}})
+	blocks := []schemas.ContentBlock{{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: server.URL + "/photo.jpg"}}}
+	messages := []schemas.BifrostMessage{{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentBlocks: &blocks}}}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &messages}}
+
+	// Without FetchURLs, image URLs are sent unchanged
+	if got := bifrost.downscaleImages(context.Background(), req); got != req || fetches.Load() != 0 {
+		t.Errorf("downscaleImages() changed the request after %d fetches, want the URL unchanged", fetches.Load())
+	}
+
+	// With it, the image is fetched once for all the attempts of a request
+	limits.FetchURLs = true
+	ctx := withImageCache(context.Background())
+	for range 3 {
+		got := bifrost.downscaleImages(ctx, req)
+		if url := (*(*got.Input.ChatCompletionInput)[0].Content.ContentBlocks)[0].ImageURL.URL; !strings.HasPrefix(url, "data:image/jpeg;base64,") {
+			t.Fatalf("image URL = %.40q, want the downscaled image", url)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("image fetched %d times, want once", fetches.Load())
+	}
+}
+
+func TestDownscaleImagePixelLimit(t *testing.T) {
+	// A PNG header claiming 20000x20000 pixels, which would decode to 1.6GB
+	ihdr := []byte("IHDR\x00\x00\x4e\x20\x00\x00\x4e\x20\x08\x06\x00\x00\x00")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(ihdr)-4))
+	chunk = append(chunk, ihdr...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(ihdr))
+	data := append([]byte("\x89PNG\r\n\x1a\n"), chunk...)
+
+	_, err := downscaleImage(context.Background(), "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data), schemas.ImageLimits{MaxDimension: 1000})
+	if err == nil || !strings.Contains(err.Error(), "20000x20000 pixels is larger than") {
+		t.Errorf("downscaleImage() error = %v, want the pixel limit", err)
+	}
+}
+
+func TestDownscaleImageMaxBytes(t *testing.T) {
+	const maxBytes = 8000
+
+	downscaled, err := downscaleImage(context.Background(), pngDataURL(t, testImage(300, 200)), schemas.ImageLimits{MaxBytes: maxBytes})
+	if err != nil {
+		t.Fatalf("downscaleImage() error = %v", err)
+	}
+	_, encoded, _ := strings.Cut(downscaled, ";base64,")
+	if data, _ := base64.StdEncoding.DecodeString(encoded); len(data) > maxBytes {
+		t.Errorf("downscaled image is %d bytes, want at most %d", len(data), maxBytes)
+	}
+	img, _ := decodeDataURL(t, downscaled)
+	if ratio := float64(img.Bounds().Dx()) / float64(img.Bounds().Dy()); ratio < 1.45 || ratio > 1.55 {
+		t.Errorf("downscaled image is %v, want the 3:2 aspect ratio preserved", img.Bounds())
+	}
+}
+
+func TestDownscaleImagesCopiesMessages(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{config: sameConfig(schemas.ProviderConfig{ImageLimits: &schemas.ImageLimits{MaxDimension: 50}})})
+	original := pngDataURL(t, testImage(100, 100))
+	text := "What is in this image?"
+	blocks := []schemas.ContentBlock{
+		{Type: schemas.ContentBlockTypeText, Text: &text},
+		{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: original}},
+	}
+	messages := []schemas.BifrostMessage{{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentBlocks: &blocks}}}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &messages}}
+
+	downscaledReq := bifrost.downscaleImages(context.Background(), req)
+	if downscaledReq == req {
+		t.Fatal("downscaleImages() returned the original request")
+	}
+	downscaledBlocks := *(*downscaledReq.Input.ChatCompletionInput)[0].Content.ContentBlocks
+	img, _ := decodeDataURL(t, downscaledBlocks[1].ImageURL.URL)
+	if img.Bounds().Dx() != 50 {
+		t.Errorf("downscaled image is %v, want 50x50", img.Bounds())
+	}
+	if *downscaledBlocks[0].Text != text {
+		t.Errorf("text block = %q, want %q", *downscaledBlocks[0].Text, text)
+	}
+	if blocks[1].ImageURL.URL != original {
+		t.Error("original request was modified")
+	}
+}
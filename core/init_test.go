@@ -0,0 +1,47 @@
+package bifrost
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestStrictInitFailsWithoutProviders(t *testing.T) {
+	for _, providers := range [][]schemas.ModelProvider{{}, {schemas.Bedrock}} {
+		_, err := Init(schemas.BifrostConfig{
+			Account:    &testAccount{providers: providers},
+			Logger:     NewDefaultLogger(schemas.LogLevelError),
+			StrictInit: true,
+		})
+
+		var noProviders *NoProvidersError
+		if !errors.As(err, &noProviders) {
+			t.Fatalf("Init() with providers %v error = %v, want a *NoProvidersError", providers, err)
+		}
+		if len(providers) > 0 && noProviders.Summary.Failed[schemas.Bedrock] == nil {
+			t.Errorf("summary = %+v, want the bedrock failure", noProviders.Summary)
+		}
+	}
+}
+
+func TestInitSummary(t *testing.T) {
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account:    &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Bedrock}},
+		Logger:     NewDefaultLogger(schemas.LogLevelError),
+		StrictInit: true,
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	summary := bifrost.InitSummary()
+	if !slices.Equal(summary.Ready, []schemas.ModelProvider{schemas.OpenAI}) {
+		t.Errorf("ready providers = %v, want [openai]", summary.Ready)
+	}
+	if _, failed := summary.Failed[schemas.Bedrock]; !failed || len(summary.Failed) != 1 {
+		t.Errorf("failed providers = %v, want bedrock only", summary.Failed)
+	}
+}
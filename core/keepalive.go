@@ -0,0 +1,74 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// maxKeepaliveInterval is the idle time after which provider clients close pooled connections.
+// A longer keepalive interval would find every connection already closed and keep none warm.
+const maxKeepaliveInterval = fasthttp.DefaultMaxIdleConnDuration
+
+// validateKeepaliveInterval checks that a provider's keepalive interval is shorter than the idle
+// time after which its connections are closed.
+func validateKeepaliveInterval(network schemas.NetworkConfig) error {
+	if interval := time.Duration(network.KeepaliveIntervalInSeconds) * time.Second; interval >= maxKeepaliveInterval {
+		return fmt.Errorf("keepalive_interval_in_seconds must be less than the idle connection timeout of %v, got %v", maxKeepaliveInterval, interval)
+	}
+	return nil
+}
+
+// startKeepalive starts the background keepalive loop of a provider if its
+// KeepaliveIntervalInSeconds is positive and the provider supports warmup, replacing the loop
+// of its previous configuration if any. The loop runs until the provider is set up again or Cleanup.
+func (bifrost *Bifrost) startKeepalive(providerKey schemas.ModelProvider, config *schemas.ProviderConfig) {
+	bifrost.stopKeepalive(providerKey)
+
+	interval := time.Duration(config.NetworkConfig.KeepaliveIntervalInSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	providerValue, ok := bifrost.providerInstances.Load(providerKey)
+	if !ok {
+		return
+	}
+	if _, ok := providerValue.(schemas.WarmupProvider); !ok {
+		bifrost.logger.Warn(fmt.Sprintf("Provider %s does not support warmup, keepalive is disabled", providerKey))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(bifrost.backgroundCtx)
+	bifrost.keepalives.Store(providerKey, cancel)
+	go bifrost.runKeepalive(ctx, providerKey, config, interval)
+}
+
+// stopKeepalive stops the keepalive loop of a provider, if it has one.
+func (bifrost *Bifrost) stopKeepalive(providerKey schemas.ModelProvider) {
+	if previous, loaded := bifrost.keepalives.LoadAndDelete(providerKey); loaded {
+		previous.(context.CancelFunc)()
+	}
+}
+
+// runKeepalive re-opens a connection to the provider's API at the given interval until ctx is
+// cancelled. The current provider instance is used at each tick, since updates replace it.
+func (bifrost *Bifrost) runKeepalive(ctx context.Context, providerKey schemas.ModelProvider, config *schemas.ProviderConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if providerValue, ok := bifrost.providerInstances.Load(providerKey); ok {
+			bifrost.warmupProvider(providerValue.(schemas.Provider), config)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package bifrost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// newKeepaliveServer returns a server counting the HEAD requests of the fasthttp client and of
+// the streaming net/http client.
+func newKeepaliveServer() (server *httptest.Server, pings, streamPings *atomic.Int32) {
+	pings, streamPings = &atomic.Int32{}, &atomic.Int32{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			return
+		}
+		if strings.HasPrefix(r.UserAgent(), "Go-http-client") {
+			streamPings.Add(1)
+		} else {
+			pings.Add(1)
+		}
+	}))
+	return server, pings, streamPings
+}
+
+func waitForPings(pings *atomic.Int32, want int32) int32 {
+	deadline := time.Now().Add(5 * time.Second)
+	for pings.Load() < want && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return pings.Load()
+}
+
+func TestProviderKeepalive(t *testing.T) {
+	server, pings, streamPings := newKeepaliveServer()
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			KeepaliveIntervalInSeconds:     1,
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	if got := waitForPings(pings, 2); got < 2 {
+		t.Fatalf("got %d keepalive pings, want at least 2", got)
+	}
+	// The streaming client's connections are kept warm too
+	if got := waitForPings(streamPings, 2); got < 2 {
+		t.Errorf("got %d keepalive pings from the streaming client, want at least 2", got)
+	}
+
+	// No pings are sent after Cleanup
+	bifrost.Cleanup()
+	time.Sleep(100 * time.Millisecond)
+	after := pings.Load()
+	time.Sleep(1500 * time.Millisecond)
+	if got := pings.Load(); got != after {
+		t.Errorf("got %d keepalive pings after Cleanup, want none", got-after)
+	}
+}
+
+func TestKeepaliveFollowsProviderUpdates(t *testing.T) {
+	server, pings, _ := newKeepaliveServer()
+	defer server.Close()
+
+	var interval atomic.Int32
+	interval.Store(1)
+	account := &testAccount{
+		keys: testKeys(),
+		config: func(schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+			return &schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{
+				BaseURL:                        server.URL,
+				DefaultRequestTimeoutInSeconds: 5,
+				KeepaliveIntervalInSeconds:     int(interval.Load()),
+			}}, nil
+		},
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	if got := waitForPings(pings, 1); got < 1 {
+		t.Fatalf("got %d keepalive pings, want at least 1", got)
+	}
+
+	// An interval the connections would not survive is rejected
+	interval.Store(int32(maxKeepaliveInterval/time.Second) + 5)
+	if err := bifrost.UpdateProviderConcurrency(schemas.OpenAI); err == nil {
+		t.Error("UpdateProviderConcurrency() accepted a keepalive interval longer than the idle timeout")
+	}
+
+	// Replacing the provider restarts the loop with the new configuration, here disabling it
+	interval.Store(0)
+	if err := bifrost.UpdateProviderConcurrency(schemas.OpenAI); err != nil {
+		t.Fatalf("UpdateProviderConcurrency() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	after := pings.Load()
+	time.Sleep(1500 * time.Millisecond)
+	if got := pings.Load(); got != after {
+		t.Errorf("got %d keepalive pings after keepalive was disabled, want none", got-after)
+	}
+}
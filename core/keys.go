@@ -0,0 +1,186 @@
+package bifrost
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// minKeySuccessRateFactor is the smallest factor a key's weight is multiplied by because of its
+// recent failures, so that a failing key still gets some traffic and is noticed once it recovers.
+const minKeySuccessRateFactor = 0.05
+
+// maxStackKeyWeights is the number of keys whose selection weights are computed without
+// allocating. Providers with more keys fall back to a heap buffer.
+const maxStackKeyWeights = 16
+
+// keyOutcomes tracks the recent successes and failures of each key with exponentially decaying
+// counts, for KeySelectionConfig.ErrorRateHalfLife.
+type keyOutcomes struct {
+	halfLife time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]*keyOutcomeStats // by key ID
+}
+
+// keyOutcomeStats holds the decayed outcome counts of a key as of updatedAt.
+type keyOutcomeStats struct {
+	provider  schemas.ModelProvider
+	successes float64
+	failures  float64
+	updatedAt time.Time
+}
+
+// newKeyOutcomes returns a tracker whose counts lose half their weight every halfLife.
+func newKeyOutcomes(halfLife time.Duration) *keyOutcomes {
+	return &keyOutcomes{
+		halfLife: halfLife,
+		now:      time.Now,
+		stats:    make(map[string]*keyOutcomeStats),
+	}
+}
+
+// record counts the outcome of a provider call made with the key. Errors that do not reflect
+// on the key, such as cancellations or invalid requests, are ignored.
+func (o *keyOutcomes) record(keyID string, providerKey schemas.ModelProvider, bifrostErr *schemas.BifrostError) {
+	if o == nil || keyID == "" {
+		return
+	}
+	failed := bifrostErr != nil
+	if failed && !isKeyFailure(bifrostErr) {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats := o.decayedStats(keyID)
+	stats.provider = providerKey
+	if failed {
+		stats.failures++
+	} else {
+		stats.successes++
+	}
+}
+
+// successRate returns the key's recent success rate, smoothed so that a key without recorded
+// outcomes has a rate of 1 and a single failure does not exclude a key.
+func (o *keyOutcomes) successRate(keyID string) float64 {
+	if o == nil {
+		return 1
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats, ok := o.stats[keyID]
+	if !ok {
+		return 1
+	}
+	stats = o.decayedStats(keyID)
+	return (stats.successes + 1) / (stats.successes + stats.failures + 1)
+}
+
+// prune drops the outcomes of a provider's keys that the account no longer returns, so that
+// the tracked keys do not grow as keys are rotated.
+func (o *keyOutcomes) prune(providerKey schemas.ModelProvider, keys []schemas.Key) {
+	if o == nil {
+		return
+	}
+
+	current := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		current[key.ID] = true
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for id, stats := range o.stats {
+		if stats.provider == providerKey && !current[id] {
+			delete(o.stats, id)
+		}
+	}
+}
+
+// decayedStats returns the stats of the key, decayed to the current time. Callers must hold mu.
+func (o *keyOutcomes) decayedStats(keyID string) *keyOutcomeStats {
+	now := o.now()
+	stats, ok := o.stats[keyID]
+	if !ok {
+		stats = &keyOutcomeStats{updatedAt: now}
+		o.stats[keyID] = stats
+		return stats
+	}
+
+	if elapsed := now.Sub(stats.updatedAt); elapsed > 0 {
+		decay := math.Pow(0.5, float64(elapsed)/float64(o.halfLife))
+		stats.successes *= decay
+		stats.failures *= decay
+		stats.updatedAt = now
+	}
+	return stats
+}
+
+// isKeyFailure reports whether an error reflects on the key used for the request: it was
+// rejected (401, 403), rate limited (429) or the provider failed (5xx).
+func isKeyFailure(bifrostErr *schemas.BifrostError) bool {
+	if bifrostErr.StatusCode == nil {
+		return false
+	}
+	switch status := *bifrostErr.StatusCode; {
+	case status == 401, status == 403, status == 429:
+		return true
+	default:
+		return status >= 500
+	}
+}
+
+// appendKeyWeights appends the selection weights of the candidate keys for a model to weights and
+// returns the extended slice: their ModelWeights for the model if set, otherwise their Weight,
+// adjusted as configured by KeySelectionConfig. allKeys are all of the provider's keys, used to
+// count the models of keys without a Models list. Callers pass a buffer with enough capacity, so
+// that picking a key does not allocate.
+func (bifrost *Bifrost) appendKeyWeights(weights []float64, candidates []schemas.Key, allKeys []schemas.Key, model string) []float64 {
+	start := len(weights)
+	for _, key := range candidates {
+		weight, ok := key.ModelWeights[model]
+		if !ok {
+			weight = key.Weight
+		}
+		weights = append(weights, weight)
+	}
+	if bifrost.keySelection == nil {
+		return weights
+	}
+	keyWeights := weights[start:]
+
+	if bifrost.keySelection.NormalizeByModelCount {
+		allModels := max(len(supportedModelsForKeys(allKeys)), 1)
+		for i, key := range candidates {
+			if _, ok := key.ModelWeights[model]; ok {
+				continue // Already the key's weight for this model alone
+			}
+			if len(key.Models) > 0 {
+				keyWeights[i] /= float64(len(key.Models))
+			} else {
+				keyWeights[i] /= float64(allModels)
+			}
+		}
+	}
+
+	for i, key := range candidates {
+		keyWeights[i] *= max(bifrost.keyOutcomes.successRate(key.ID), minKeySuccessRateFactor)
+	}
+
+	if bifrost.keySelection.RateLimitAware {
+		now := time.Now()
+		for i, key := range candidates {
+			keyWeights[i] *= bifrost.keyRateLimitFactor(key.ID, now)
+		}
+	}
+
+	return weights
+}
@@ -0,0 +1,248 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestSelectKeyReadsAccountPerRequest(t *testing.T) {
+	account := &testAccount{keys: []schemas.Key{{ID: "old", Value: "sk-old", Weight: 1}}}
+	bifrost := newTestBifrost(account)
+	ctx := context.Background()
+
+	key, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.ID != "old" {
+		t.Fatalf("expected key old, got %s", key.ID)
+	}
+
+	// Rotate the key in the account; the very next selection must use the new key
+	account.setKeys([]schemas.Key{{ID: "new", Value: "sk-new", Weight: 1}})
+
+	key, err = bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.ID != "new" {
+		t.Fatalf("expected rotated key new, got %s", key.ID)
+	}
+	if account.calls != 2 {
+		t.Fatalf("expected GetKeysForProvider to be called once per selection, got %d calls", account.calls)
+	}
+}
+
+func TestRevokedKeysAreNeverSelected(t *testing.T) {
+	account := &testAccount{
+		keys: []schemas.Key{
+			{ID: "leaked", Value: "sk-leaked", Weight: 1},
+			{ID: "safe", Value: "sk-safe", Weight: 1},
+		},
+	}
+	bifrost := newTestBifrost(account)
+	ctx := context.Background()
+
+	bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "leaked"}}, false)
+
+	for i := 0; i < 50; i++ {
+		key, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if key.ID == "leaked" {
+			t.Fatalf("revoked key was selected")
+		}
+	}
+
+	bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "safe"}}, false)
+	if _, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o"); err == nil {
+		t.Fatalf("expected an error when all keys are revoked")
+	}
+}
+
+func TestRevokeKeysCancelsInFlightRequests(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+
+	leakedCtx, untrackLeaked := bifrost.trackInFlightKey(context.Background(), "leaked")
+	defer untrackLeaked()
+	safeCtx, untrackSafe := bifrost.trackInFlightKey(context.Background(), "safe")
+	defer untrackSafe()
+
+	if cancelled := bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "leaked"}}, true); cancelled != 1 {
+		t.Fatalf("expected 1 cancelled request, got %d", cancelled)
+	}
+
+	if leakedCtx.Err() == nil {
+		t.Fatalf("expected request using the revoked key to be cancelled")
+	}
+	if safeCtx.Err() != nil {
+		t.Fatalf("request using another key must not be cancelled")
+	}
+}
+
+func TestUnrevokeKeys(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{keys: []schemas.Key{{ID: "revoked", Value: "sk-revoked", Weight: 1}}})
+	ctx := context.Background()
+
+	bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "revoked"}}, false)
+	if _, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o"); err == nil {
+		t.Fatalf("expected an error while the only key is revoked")
+	}
+
+	// Revocations are per provider
+	if n := bifrost.UnrevokeKeys(schemas.Anthropic, []schemas.Key{{ID: "revoked"}}); n != 0 {
+		t.Fatalf("UnrevokeKeys() for another provider = %d, want 0", n)
+	}
+	if n := bifrost.UnrevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "revoked"}}); n != 1 {
+		t.Fatalf("UnrevokeKeys() = %d, want 1", n)
+	}
+	if key, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o"); err != nil || key.ID != "revoked" {
+		t.Fatalf("selected %+v, %v, want the un-revoked key", key, err)
+	}
+}
+
+func TestRevokeKeysWithoutID(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{keys: []schemas.Key{{Value: "sk-leaked", Weight: 1}, {Value: "sk-safe", Weight: 1}}})
+	ctx := context.Background()
+
+	leakedCtx, untrack := bifrost.trackInFlightKey(context.Background(), revokedKeyID(schemas.Key{Value: "sk-leaked"}))
+	defer untrack()
+
+	// Keys without an ID are identified by their value
+	if cancelled := bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{Value: "sk-leaked"}}, true); cancelled != 1 || leakedCtx.Err() == nil {
+		t.Fatalf("RevokeKeys() cancelled %d request(s), want the one using the key", cancelled)
+	}
+	for i := 0; i < 20; i++ {
+		key, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o")
+		if err != nil || key.Value != "sk-safe" {
+			t.Fatalf("selected %+v, %v, want the key that was not revoked", key, err)
+		}
+	}
+}
+
+func TestRevokedKeysArePrunedOnceRemoved(t *testing.T) {
+	account := &testAccount{keys: []schemas.Key{{ID: "leaked", Value: "sk-leaked", Weight: 1}, {ID: "safe", Value: "sk-safe", Weight: 1}}}
+	bifrost := newTestBifrost(account)
+	ctx := context.Background()
+
+	bifrost.RevokeKeys(schemas.OpenAI, []schemas.Key{{ID: "leaked"}}, false)
+	account.setKeys([]schemas.Key{{ID: "safe", Value: "sk-safe", Weight: 1}})
+	if _, err := bifrost.selectKeyFromProviderForModel(&ctx, schemas.OpenAI, "gpt-4o"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The account no longer returns the revoked key, so its revocation is forgotten
+	if bifrost.isKeyRevoked(schemas.OpenAI, schemas.Key{ID: "leaked"}) || len(bifrost.revokedKeys) != 0 {
+		t.Errorf("revoked keys = %v, want the removed key pruned", bifrost.revokedKeys)
+	}
+}
+
+func TestKeyWeightsNormalizeByModelCount(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	bifrost.keySelection = &schemas.KeySelectionConfig{NormalizeByModelCount: true}
+
+	keys := []schemas.Key{
+		{ID: "broad", Weight: 1, Models: []string{"gpt-4o", "gpt-4o-mini", "gpt-4.1", "o3"}},
+		{ID: "narrow", Weight: 1, Models: []string{"gpt-4o"}},
+		{ID: "any", Weight: 1},
+	}
+	weights := bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o")
+
+	// The broad key serves 4 models, the narrow one 1, and the key without a list all 4 listed models
+	if weights[1] != 1 || weights[0] != 0.25 || weights[2] != 0.25 {
+		t.Errorf("weights = %v, want [0.25 1 0.25]", weights)
+	}
+}
+
+func TestKeyWeightsDeprioritizeFailingKeys(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	bifrost.keySelection = &schemas.KeySelectionConfig{ErrorRateHalfLife: time.Minute}
+	bifrost.keyOutcomes = newKeyOutcomes(time.Minute)
+	now := time.Now()
+	bifrost.keyOutcomes.now = func() time.Time { return now }
+
+	serverError := &schemas.BifrostError{StatusCode: Ptr(500)}
+	badRequest := &schemas.BifrostError{StatusCode: Ptr(400)}
+	for i := 0; i < 3; i++ {
+		bifrost.keyOutcomes.record("failing", schemas.OpenAI, serverError)
+		bifrost.keyOutcomes.record("healthy", schemas.OpenAI, nil)
+		bifrost.keyOutcomes.record("healthy", schemas.OpenAI, badRequest) // not the key's fault
+	}
+
+	keys := []schemas.Key{{ID: "failing", Weight: 1}, {ID: "healthy", Weight: 1}}
+	weights := bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o")
+	if weights[1] != 1 || weights[0] != 0.25 {
+		t.Errorf("weights = %v, want [0.25 1]", weights)
+	}
+
+	// After many half-lives, the failures are forgotten
+	now = now.Add(time.Hour)
+	weights = bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o")
+	if weights[0] < 0.99 {
+		t.Errorf("weight of the recovered key = %v, want about 1", weights[0])
+	}
+}
+
+func TestKeyOutcomesPruneRemovedKeys(t *testing.T) {
+	outcomes := newKeyOutcomes(time.Minute)
+	serverError := &schemas.BifrostError{StatusCode: Ptr(500)}
+	outcomes.record("kept", schemas.OpenAI, serverError)
+	outcomes.record("removed", schemas.OpenAI, serverError)
+	outcomes.record("other-provider", schemas.Anthropic, serverError)
+
+	outcomes.prune(schemas.OpenAI, []schemas.Key{{ID: "kept"}})
+
+	if _, ok := outcomes.stats["removed"]; ok {
+		t.Error("outcomes of the removed key were kept")
+	}
+	if _, ok := outcomes.stats["kept"]; !ok {
+		t.Error("outcomes of the current key were dropped")
+	}
+	// Keys of other providers are left alone
+	if _, ok := outcomes.stats["other-provider"]; !ok {
+		t.Error("outcomes of another provider's key were dropped")
+	}
+}
+
+func TestKeyWeightsDoNotAllocate(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	bifrost.keySelection = &schemas.KeySelectionConfig{ErrorRateHalfLife: time.Minute, RateLimitAware: true}
+	bifrost.keyOutcomes = newKeyOutcomes(time.Minute)
+	bifrost.keyOutcomes.record("failing", schemas.OpenAI, &schemas.BifrostError{StatusCode: Ptr(500)})
+
+	keys := []schemas.Key{
+		{ID: "failing", Weight: 1},
+		{ID: "per-model", Weight: 1, ModelWeights: map[string]float64{"gpt-4o": 3}},
+		{ID: "healthy", Weight: 1},
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		var buffer [maxStackKeyWeights]float64
+		PickWeighted(bifrost.appendKeyWeights(buffer[:0], keys, keys, "gpt-4o"))
+	})
+	if allocs != 0 {
+		t.Errorf("picking a key allocates %v times, want 0", allocs)
+	}
+}
+
+func TestKeyWeightsPerModel(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+
+	keys := []schemas.Key{
+		{ID: "high-quota", Weight: 1, ModelWeights: map[string]float64{"gpt-4o": 9, "gpt-4o-mini": 0}},
+		{ID: "standard", Weight: 1},
+	}
+	if weights := bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o"); weights[0] != 9 || weights[1] != 1 {
+		t.Errorf("weights for gpt-4o = %v, want [9 1]", weights)
+	}
+	if weights := bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o-mini"); weights[0] != 0 || weights[1] != 1 {
+		t.Errorf("weights for gpt-4o-mini = %v, want [0 1]", weights)
+	}
+	// Models without a per-model weight use the flat weight
+	if weights := bifrost.appendKeyWeights(nil, keys, keys, "o3"); weights[0] != 1 || weights[1] != 1 {
+		t.Errorf("weights for o3 = %v, want [1 1]", weights)
+	}
+}
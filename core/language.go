@@ -0,0 +1,42 @@
+package bifrost
+
+import (
+	"fmt"
+	"slices"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// defaultOutputLanguageInstruction is the system instruction of BifrostRequest.OutputLanguage when
+// it sets no Instruction of its own.
+const defaultOutputLanguageInstruction = "Respond in %s."
+
+// withOutputLanguage implements BifrostRequest.OutputLanguage. It returns a copy of a chat
+// completion request with the language instruction added as a system message after the request's
+// leading system messages, so that the caller's system prompt still comes first. None of the
+// providers has a parameter for the output language, so the instruction is always a message.
+// Other requests are returned unchanged.
+func withOutputLanguage(req *schemas.BifrostRequest, requestType RequestType) *schemas.BifrostRequest {
+	if req.OutputLanguage == nil || req.Input.ChatCompletionInput == nil {
+		return req
+	}
+	if requestType != ChatCompletionRequest && requestType != ChatCompletionStreamRequest {
+		return req
+	}
+
+	instruction := req.OutputLanguage.Instruction
+	if instruction == "" {
+		instruction = fmt.Sprintf(defaultOutputLanguageInstruction, req.OutputLanguage.Language)
+	}
+
+	messages := *req.Input.ChatCompletionInput
+	position := 0
+	for position < len(messages) && messages[position].Role == schemas.ModelChatMessageRoleSystem {
+		position++
+	}
+	hinted := slices.Insert(slices.Clone(messages), position, schemas.SystemMessage(instruction))
+
+	hintedReq := *req
+	hintedReq.Input.ChatCompletionInput = &hinted
+	return &hintedReq
+}
@@ -0,0 +1,42 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestWithOutputLanguage(t *testing.T) {
+	messages := []schemas.BifrostMessage{schemas.SystemMessage("Be brief."), schemas.UserMessage("Hello")}
+	req := &schemas.BifrostRequest{
+		Provider:       schemas.OpenAI,
+		Model:          "gpt-4o",
+		Input:          schemas.RequestInput{ChatCompletionInput: &messages},
+		OutputLanguage: &schemas.OutputLanguageConfig{Language: "French"},
+	}
+
+	hinted := *withOutputLanguage(req, ChatCompletionRequest).Input.ChatCompletionInput
+	if len(hinted) != 3 || messageText(hinted[0]) != "Be brief." || hinted[1].Role != schemas.ModelChatMessageRoleSystem || messageText(hinted[1]) != "Respond in French." {
+		t.Errorf("messages = %+v, want the instruction after the caller's system message", hinted)
+	}
+	if len(messages) != 2 {
+		t.Errorf("request messages = %+v, want them unchanged", messages)
+	}
+
+	// The instruction can be replaced
+	req.OutputLanguage.Instruction = "Réponds en français, avec des dates au format JJ/MM/AAAA."
+	hinted = *withOutputLanguage(req, ChatCompletionStreamRequest).Input.ChatCompletionInput
+	if messageText(hinted[1]) != req.OutputLanguage.Instruction {
+		t.Errorf("instruction = %q, want %q", messageText(hinted[1]), req.OutputLanguage.Instruction)
+	}
+
+	// Other request types are left alone
+	if got := withOutputLanguage(req, TextCompletionRequest); got != req {
+		t.Errorf("text completion request = %+v, want it unchanged", got)
+	}
+
+	req.OutputLanguage = &schemas.OutputLanguageConfig{}
+	if err := validateRequest(req, ChatCompletionRequest); err == nil {
+		t.Error("validateRequest() succeeded, want an error for an output language without language")
+	}
+}
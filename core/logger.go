@@ -71,3 +71,9 @@ func (logger *DefaultLogger) Error(err error) {
 func (logger *DefaultLogger) SetLevel(level schemas.LogLevel) {
 	logger.level = level
 }
+
+// GetLevel returns the logging level of the logger, so that callers can skip building messages
+// that would not be output.
+func (logger *DefaultLogger) GetLevel() schemas.LogLevel {
+	return logger.level
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"net/http"
 	"os"
 	"slices"
 	"strings"
@@ -30,12 +31,17 @@ const (
 	BifrostMCPClientKey                 = "bifrost-internal" // Key for internal Bifrost client in clientMap
 	MCPLogPrefix                        = "[Bifrost MCP]"    // Consistent logging prefix
 	MCPClientConnectionEstablishTimeout = 30 * time.Second   // Timeout for MCP client connection establishment
+	MCPHealthCheckTimeout               = 10 * time.Second   // Timeout for each health check ping
+	MCPToolCancelGracePeriod            = 2 * time.Second    // Time cancelled tool calls have to return during cleanup
 
 	// Context keys for client filtering in requests
 	MCPContextKeyIncludeClients = "mcp-include-clients" // Context key for whitelist client filtering
 	MCPContextKeyExcludeClients = "mcp-exclude-clients" // Context key for blacklist client filtering
 	MCPContextKeyIncludeTools   = "mcp-include-tools"   // Context key for whitelist tool filtering
 	MCPContextKeyExcludeTools   = "mcp-exclude-tools"   // Context key for blacklist tool filtering
+
+	// Context key for per-request tool call iteration tracking
+	MCPContextKeyToolCallTracker = "mcp-tool-call-tracker"
 )
 
 // ============================================================================
@@ -46,11 +52,41 @@ const (
 // It provides a bridge between Bifrost and various MCP servers, supporting
 // both local tool hosting and external MCP server connections.
 type MCPManager struct {
-	server        *server.MCPServer     // Local MCP server instance for hosting tools (STDIO-based)
-	clientMap     map[string]*MCPClient // Map of MCP client names to their configurations
-	mu            sync.RWMutex          // Read-write mutex for thread-safe operations
-	serverRunning bool                  // Track whether local MCP server is running
-	logger        schemas.Logger        // Logger instance for structured logging
+	server                *server.MCPServer     // Local MCP server instance for hosting tools (STDIO-based)
+	clientMap             map[string]*MCPClient // Map of MCP client names to their configurations
+	mu                    sync.RWMutex          // Read-write mutex for thread-safe operations
+	serverRunning         bool                  // Track whether local MCP server is running
+	logger                schemas.Logger        // Logger instance for structured logging
+	maxToolCallIterations int                   // Maximum tool call iterations per logical request
+	drainTimeout          time.Duration         // Time cleanup waits for in-flight tool calls (DefaultMCPDrainTimeoutSeconds if zero)
+
+	// In-flight tool calls, tracked so that cleanup can wait for them or cancel them
+	executionsMu sync.Mutex
+	executions   map[uint64]context.CancelFunc
+	executionSeq uint64
+	executionsWG sync.WaitGroup
+	draining     bool // Set by cleanup, new tool calls are refused
+}
+
+// MCPToolCallTracker counts the tool call iterations executed for a single logical request and
+// keeps the history of their tool calls. An iteration is one model turn: all the tool calls
+// executed between two chat completion requests made with the tracked context belong to the
+// same iteration. It is attached to the request context and consulted before every tool
+// execution, so an agentic loop cannot keep calling tools indefinitely.
+type MCPToolCallTracker struct {
+	mu            sync.Mutex
+	maxIterations int
+	iterations    int // Iterations with executed tool calls
+	turn          int // Model turns started with the tracked context
+	toolTurn      int // Turn of the last executed tool call
+	history       []schemas.ToolCall
+}
+
+// MCPToolCallLimitError is returned when a logical request exceeds its tool call iteration limit.
+// History contains every tool call executed before the limit was hit, in order.
+type MCPToolCallLimitError struct {
+	Limit   int
+	History []schemas.ToolCall
 }
 
 // MCPClient represents a connected MCP client with its configuration and tools.
@@ -59,8 +95,22 @@ type MCPClient struct {
 	Conn            *client.Client          // Active MCP client connection
 	ExecutionConfig schemas.MCPClientConfig // Tool filtering settings
 	ToolMap         map[string]schemas.Tool // Available tools mapped by name
-	ConnectionInfo  MCPClientConnectionInfo `json:"connection_info"` // Connection metadata for management
-	cancelFunc      context.CancelFunc      `json:"-"`               // Cancel function for SSE connections (not serialized)
+	ConnectionInfo  MCPClientConnectionInfo `json:"connection_info"`   // Connection metadata for management
+	cancelFunc      context.CancelFunc      `json:"-"`                 // Cancel function for SSE connections (not serialized)
+	pool            *mcpConnectionPool      `json:"-"`                 // Connection pool for HTTP and SSE connections (not serialized)
+	LastHealthCheck time.Time               `json:"last_health_check"` // Time of the last health check ping (zero if health checks are disabled)
+	failedPings     int                     // Consecutive failed health check pings of the current connection
+	healthErr       error                   // Error of the last failed health check or reconnect, cleared on reconnection
+	stopHealthCheck context.CancelFunc      // Stops the health check loop (nil if health checks are disabled)
+	stopReconnect   context.CancelFunc      // Stops the background reconnect loop (nil if it is not running)
+}
+
+// mcpConnectionPool bounds the number of concurrent tool calls made through an HTTP or SSE
+// MCP client, and the number of connections its transport opens to the server. The HTTP
+// client keeps idle connections alive so that parallel tool calls reuse them.
+type mcpConnectionPool struct {
+	httpClient *http.Client
+	slots      chan struct{}
 }
 
 // MCPClientConnectionInfo stores metadata about how a client is connected.
@@ -74,6 +124,10 @@ type MCPClientConnectionInfo struct {
 // T represents the expected argument structure for the tool.
 type MCPToolHandler[T any] func(args T) (string, error)
 
+// MCPMultimodalToolHandler is a tool handler that returns multimodal content (text and images).
+// Image blocks must carry base64 data URLs (data:<mime>;base64,<data>).
+type MCPMultimodalToolHandler[T any] func(args T) ([]schemas.ContentBlock, error)
+
 // ============================================================================
 // CONSTRUCTOR AND INITIALIZATION
 // ============================================================================
@@ -93,9 +147,16 @@ func newMCPManager(config schemas.MCPConfig, logger schemas.Logger) (*MCPManager
 		logger = NewDefaultLogger(schemas.LogLevelInfo)
 	}
 
+	maxToolCallIterations := config.MaxToolCallIterations
+	if maxToolCallIterations <= 0 {
+		maxToolCallIterations = schemas.DefaultMaxToolCallIterations
+	}
+
 	manager := &MCPManager{
-		clientMap: make(map[string]*MCPClient),
-		logger:    logger,
+		clientMap:             make(map[string]*MCPClient),
+		logger:                logger,
+		maxToolCallIterations: maxToolCallIterations,
+		drainTimeout:          time.Duration(config.DrainTimeoutSeconds) * time.Second,
 	}
 
 	// Process client configs: create client map entries and establish connections
@@ -127,8 +188,15 @@ func (m *MCPManager) GetClients() ([]MCPClient, error) {
 	return clients, nil
 }
 
-// ReconnectClient attempts to reconnect an MCP client if it is disconnected.
+// ReconnectClient attempts to reconnect an MCP client if it is disconnected. If the attempt
+// fails and the client has a Reconnect config, it keeps being reconnected in the background.
 func (m *MCPManager) ReconnectClient(name string) error {
+	return m.reconnectClient(name, true)
+}
+
+// reconnectClient makes a single attempt to reconnect a disconnected client. If it fails and
+// retryInBackground is set, the background reconnect loop of the client is started.
+func (m *MCPManager) reconnectClient(name string, retryInBackground bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -141,11 +209,23 @@ func (m *MCPManager) ReconnectClient(name string) error {
 		return fmt.Errorf("client %s is already connected", name)
 	}
 
+	// Drop the pool of the previous connection, so the new one starts with fresh connections
+	// sized from the current configuration
+	if client.pool != nil {
+		client.pool.close()
+		client.pool = nil
+	}
+	config := client.ExecutionConfig
+
 	m.mu.Unlock()
 
 	// connectToMCPClient handles locking internally
-	err := m.connectToMCPClient(client.ExecutionConfig)
+	err := m.connectToMCPClient(config)
+	m.mu.Lock()
 	if err != nil {
+		if client, ok := m.clientMap[name]; ok && retryInBackground {
+			m.startReconnectUnsafe(client)
+		}
 		return fmt.Errorf("failed to connect to MCP client %s: %w", name, err)
 	}
 
@@ -194,6 +274,12 @@ func (m *MCPManager) AddClient(config schemas.MCPClientConfig) error {
 		return fmt.Errorf("failed to connect to MCP client %s: %w", config.Name, err)
 	}
 
+	m.mu.Lock()
+	if client, ok := m.clientMap[config.Name]; ok {
+		m.startHealthCheckUnsafe(client)
+	}
+	m.mu.Unlock()
+
 	return nil
 }
 
@@ -217,6 +303,28 @@ func (m *MCPManager) removeClientUnsafe(name string) error {
 
 	m.logger.Info(fmt.Sprintf("%s Disconnecting MCP client: %s", MCPLogPrefix, name))
 
+	if client.stopHealthCheck != nil {
+		client.stopHealthCheck()
+		client.stopHealthCheck = nil
+	}
+	if client.stopReconnect != nil {
+		client.stopReconnect()
+		client.stopReconnect = nil
+	}
+
+	m.disconnectClientUnsafe(client)
+
+	delete(m.clientMap, name)
+	return nil
+}
+
+// disconnectClientUnsafe closes the connection of a client and clears its tools, keeping the
+// client entry so that it can be reconnected. Tool calls in flight keep the connection and pool
+// they looked up, and fail on the closed connection rather than seeing them cleared.
+// Must be called with m.mu held.
+func (m *MCPManager) disconnectClientUnsafe(client *MCPClient) {
+	name := client.Name
+
 	// Cancel SSE context if present (required for proper SSE cleanup)
 	if client.cancelFunc != nil {
 		client.cancelFunc()
@@ -232,11 +340,14 @@ func (m *MCPManager) removeClientUnsafe(name string) error {
 		client.Conn = nil
 	}
 
+	// Release the idle connections of HTTP and SSE clients
+	if client.pool != nil {
+		client.pool.close()
+		client.pool = nil
+	}
+
 	// Clear client tool map
 	client.ToolMap = make(map[string]schemas.Tool)
-
-	delete(m.clientMap, name)
-	return nil
 }
 
 func (m *MCPManager) EditClientTools(name string, toolsToAdd []string, toolsToRemove []string) error {
@@ -354,6 +465,44 @@ func (m *MCPManager) getAvailableTools(ctx context.Context) []schemas.Tool {
 //	        return args.Message, nil
 //	    }, toolSchema)
 func (m *MCPManager) registerTool(name, description string, handler MCPToolHandler[any], toolSchema schemas.Tool) error {
+	// Create MCP handler wrapper that converts between typed and MCP interfaces
+	mcpHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments from the request using the request's methods
+		args := request.GetArguments()
+		result, err := handler(args)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+
+	return m.registerMCPHandler(name, description, mcpHandler, toolSchema)
+}
+
+// registerMultimodalTool registers a tool whose handler returns text and image content blocks.
+// The blocks are converted to MCP content so that images survive the round trip through the
+// local MCP server and reach the model as image content in the tool message.
+func (m *MCPManager) registerMultimodalTool(name, description string, handler MCPMultimodalToolHandler[any], toolSchema schemas.Tool) error {
+	mcpHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		blocks, err := handler(args)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error: %s", err.Error())), nil
+		}
+
+		content, err := convertContentBlocksToMCPContent(blocks)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error: %s", err.Error())), nil
+		}
+		return &mcp.CallToolResult{Content: content}, nil
+	}
+
+	return m.registerMCPHandler(name, description, mcpHandler, toolSchema)
+}
+
+// registerMCPHandler registers a raw MCP tool handler with the local MCP server and
+// stores the tool definition for Bifrost integration.
+func (m *MCPManager) registerMCPHandler(name, description string, mcpHandler server.ToolHandlerFunc, toolSchema schemas.Tool) error {
 	// Ensure local server is set up
 	if err := m.setupLocalHost(); err != nil {
 		return fmt.Errorf("failed to setup local host: %w", err)
@@ -374,17 +523,6 @@ func (m *MCPManager) registerTool(name, description string, handler MCPToolHandl
 
 	m.logger.Info(fmt.Sprintf("%s Registering typed tool: %s", MCPLogPrefix, name))
 
-	// Create MCP handler wrapper that converts between typed and MCP interfaces
-	mcpHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract arguments from the request using the request's methods
-		args := request.GetArguments()
-		result, err := handler(args)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error: %s", err.Error())), nil
-		}
-		return mcp.NewToolResultText(result), nil
-	}
-
 	// Register the tool with the local MCP server using AddTool
 	if m.server != nil {
 		tool := mcp.NewTool(name, mcp.WithDescription(description))
@@ -558,20 +696,36 @@ func (m *MCPManager) executeTool(ctx context.Context, toolCall schemas.ToolCall)
 	}
 	toolName := *toolCall.Function.Name
 
+	// Track the call so that cleanup waits for it, or cancels it, before closing its client
+	ctx, done, err := m.trackExecution(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Enforce the per-request tool call limit if tracking is enabled for this request
+	if tracker, ok := ctx.Value(MCPContextKeyToolCallTracker).(*MCPToolCallTracker); ok && tracker != nil {
+		if err := tracker.record(toolCall); err != nil {
+			m.logger.Warn(fmt.Sprintf("%s %v", MCPLogPrefix, err))
+			return nil, err
+		}
+	}
+
 	// Parse tool arguments
 	var arguments map[string]interface{}
 	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &arguments); err != nil {
 		return nil, fmt.Errorf("failed to parse tool arguments for '%s': %v", toolName, err)
 	}
 
-	// Find which client has this tool
-	client := m.findMCPClientForTool(toolName)
-	if client == nil {
+	// Find which client has this tool. Its connection and pool are used from the snapshot taken
+	// under the lock, as a health check or reconnect may clear them while the call runs.
+	mcpClient, conn, pool := m.findMCPClientForTool(toolName)
+	if mcpClient == nil {
 		return nil, fmt.Errorf("tool '%s' not found in any connected MCP client", toolName)
 	}
 
-	if client.Conn == nil {
-		return nil, fmt.Errorf("client '%s' has no active connection", client.Name)
+	if conn == nil {
+		return nil, fmt.Errorf("client '%s' has no active connection", mcpClient.Name)
 	}
 
 	// Call the tool via MCP client -> MCP server
@@ -585,21 +739,212 @@ func (m *MCPManager) executeTool(ctx context.Context, toolCall schemas.ToolCall)
 		},
 	}
 
-	m.logger.Debug(fmt.Sprintf("%s Starting tool execution: %s via client: %s", MCPLogPrefix, toolName, client.Name))
+	// Wait for a free connection slot, so parallel tool calls cannot open unbounded connections
+	if pool != nil {
+		if err := pool.acquire(ctx); err != nil {
+			return nil, fmt.Errorf("failed waiting for a free connection to MCP client '%s': %w", mcpClient.Name, err)
+		}
+		defer pool.release()
+	}
+
+	m.logger.Debug(fmt.Sprintf("%s Starting tool execution: %s via client: %s", MCPLogPrefix, toolName, mcpClient.Name))
 
-	toolResponse, callErr := client.Conn.CallTool(ctx, callRequest)
+	toolResponse, callErr := conn.CallTool(ctx, callRequest)
 	if callErr != nil {
-		m.logger.Error(fmt.Errorf("%s Tool execution failed for %s via client %s: %v", MCPLogPrefix, toolName, client.Name, callErr))
+		m.logger.Error(fmt.Errorf("%s Tool execution failed for %s via client %s: %v", MCPLogPrefix, toolName, mcpClient.Name, callErr))
 		return nil, fmt.Errorf("MCP tool call failed: %v", callErr)
 	}
 
 	m.logger.Debug(fmt.Sprintf("%s Tool execution completed: %s", MCPLogPrefix, toolName))
 
-	// Extract text from MCP response
-	responseText := m.extractTextFromMCPResponse(toolResponse, toolName)
+	// Extract text and image content from MCP response
+	responseContent := m.extractContentFromMCPResponse(toolResponse, toolName)
 
 	// Create tool response message
-	return m.createToolResponseMessage(toolCall, responseText), nil
+	return m.createToolResponseMessage(toolCall, responseContent), nil
+}
+
+// trackExecution registers an in-flight tool call, so that cleanup can wait for it or cancel it.
+// It fails once cleanup has started. The returned function must be called when the call returns.
+func (m *MCPManager) trackExecution(ctx context.Context) (context.Context, func(), error) {
+	m.executionsMu.Lock()
+	defer m.executionsMu.Unlock()
+
+	if m.draining {
+		return nil, nil, fmt.Errorf("MCP manager is shutting down, tool calls are no longer accepted")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	if m.executions == nil {
+		m.executions = make(map[uint64]context.CancelFunc)
+	}
+	m.executionSeq++
+	id := m.executionSeq
+	m.executions[id] = cancel
+	m.executionsWG.Add(1)
+
+	return ctx, func() {
+		m.executionsMu.Lock()
+		delete(m.executions, id)
+		m.executionsMu.Unlock()
+		cancel()
+		m.executionsWG.Done()
+	}, nil
+}
+
+// drainExecutions refuses new tool calls and waits up to timeout for the in-flight ones to
+// finish. Calls still running after the timeout are cancelled and given MCPToolCancelGracePeriod
+// to return, so that they fail rather than being cut off by the closing connection.
+//
+// Returns:
+//   - int: Number of tool calls cancelled
+func (m *MCPManager) drainExecutions(timeout time.Duration) int {
+	m.executionsMu.Lock()
+	m.draining = true
+	pending := len(m.executions)
+	m.executionsMu.Unlock()
+	if pending == 0 {
+		return 0
+	}
+
+	m.logger.Info(fmt.Sprintf("%s Waiting up to %s for %d in-flight tool call(s)", MCPLogPrefix, timeout, pending))
+	drained := make(chan struct{})
+	go func() {
+		m.executionsWG.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		return 0
+	case <-timer.C:
+	}
+
+	m.executionsMu.Lock()
+	cancelled := len(m.executions)
+	for _, cancel := range m.executions {
+		cancel()
+	}
+	m.executionsMu.Unlock()
+	m.logger.Warn(fmt.Sprintf("%s Cancelled %d tool call(s) still running after %s", MCPLogPrefix, cancelled, timeout))
+
+	timer.Reset(MCPToolCancelGracePeriod)
+	select {
+	case <-drained:
+	case <-timer.C:
+		m.logger.Warn(fmt.Sprintf("%s Cancelled tool calls did not return within %s, closing their clients anyway", MCPLogPrefix, MCPToolCancelGracePeriod))
+	}
+	return cancelled
+}
+
+// ============================================================================
+// TOOL CALL ITERATION TRACKING
+// ============================================================================
+
+// newToolCallTracker creates a tracker with the given limit.
+func newToolCallTracker(maxIterations int) *MCPToolCallTracker {
+	return &MCPToolCallTracker{maxIterations: maxIterations}
+}
+
+// record registers a tool call against the tracker, returning an MCPToolCallLimitError
+// if it would start an iteration beyond the limit.
+func (t *MCPToolCallTracker) record(toolCall schemas.ToolCall) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.iterations == 0 || t.toolTurn != t.turn {
+		if t.iterations >= t.maxIterations {
+			return &MCPToolCallLimitError{
+				Limit:   t.maxIterations,
+				History: slices.Clone(t.history),
+			}
+		}
+		t.iterations++
+		t.toolTurn = t.turn
+	}
+	t.history = append(t.history, toolCall)
+	return nil
+}
+
+// NextIteration marks the start of a new model turn, so that the tool calls executed from then
+// on count as a new iteration. Bifrost calls it for every chat completion request made with the
+// tracked context; callers only need it when they request completions with another context.
+func (t *MCPToolCallTracker) NextIteration() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.turn++
+}
+
+// Iterations returns the number of iterations in which tool calls were executed so far.
+func (t *MCPToolCallTracker) Iterations() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.iterations
+}
+
+// History returns a copy of the tool calls executed so far, in order.
+func (t *MCPToolCallTracker) History() []schemas.ToolCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.history)
+}
+
+// checkToolCallIterations enforces MaxToolCallIterations on chat completion requests, whether or
+// not the caller tracks its tool calls. The iterations of the current logical request are the
+// assistant messages with MCP tool calls since the last user message. A request carrying the
+// results of more iterations than allowed fails with an MCPToolCallLimitError, which bounds
+// agentic loops that execute tools without a tracker.
+func (m *MCPManager) checkToolCallIterations(req *schemas.BifrostRequest) error {
+	if req.Input.ChatCompletionInput == nil {
+		return nil
+	}
+	messages := *req.Input.ChatCompletionInput
+
+	start := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schemas.ModelChatMessageRoleUser {
+			start = i + 1
+			break
+		}
+	}
+
+	iterations := 0
+	var history []schemas.ToolCall
+	for _, message := range messages[start:] {
+		if message.AssistantMessage == nil || message.AssistantMessage.ToolCalls == nil {
+			continue
+		}
+		isMCPIteration := false
+		for _, toolCall := range *message.AssistantMessage.ToolCalls {
+			if toolCall.Function.Name == nil {
+				continue
+			}
+			if mcpClient, _, _ := m.findMCPClientForTool(*toolCall.Function.Name); mcpClient != nil {
+				isMCPIteration = true
+				history = append(history, toolCall)
+			}
+		}
+		if isMCPIteration {
+			iterations++
+		}
+	}
+
+	if iterations > m.maxToolCallIterations {
+		return &MCPToolCallLimitError{Limit: m.maxToolCallIterations, History: history}
+	}
+	return nil
+}
+
+// Error implements the error interface, listing the executed tool calls by name.
+func (e *MCPToolCallLimitError) Error() string {
+	names := make([]string, 0, len(e.History))
+	for _, toolCall := range e.History {
+		if toolCall.Function.Name != nil {
+			names = append(names, *toolCall.Function.Name)
+		}
+	}
+	return fmt.Sprintf("maximum tool call iterations (%d) exceeded for this request; executed tool calls: [%s]", e.Limit, strings.Join(names, ", "))
 }
 
 // ============================================================================
@@ -637,21 +982,28 @@ func (m *MCPManager) connectToMCPClient(config schemas.MCPClientConfig) error {
 	// Heavy operations performed outside lock
 	var externalClient *client.Client
 	var connectionInfo MCPClientConnectionInfo
+	var pool *mcpConnectionPool
 	var err error
 
 	// Create appropriate transport based on connection type
 	switch config.ConnectionType {
 	case schemas.MCPConnectionTypeHTTP:
-		externalClient, connectionInfo, err = m.createHTTPConnection(config)
+		pool = newMCPConnectionPool(config.MaxConcurrentConnections, 0)
+		externalClient, connectionInfo, err = m.createHTTPConnection(config, pool)
 	case schemas.MCPConnectionTypeSTDIO:
 		externalClient, connectionInfo, err = m.createSTDIOConnection(config)
 	case schemas.MCPConnectionTypeSSE:
-		externalClient, connectionInfo, err = m.createSSEConnection(config)
+		// One extra connection is reserved for the persistent event stream
+		pool = newMCPConnectionPool(config.MaxConcurrentConnections, 1)
+		externalClient, connectionInfo, err = m.createSSEConnection(config, pool)
 	default:
 		return fmt.Errorf("unknown connection type: %s", config.ConnectionType)
 	}
 
 	if err != nil {
+		if pool != nil {
+			pool.close()
+		}
 		return fmt.Errorf("failed to create connection: %w", err)
 	}
 
@@ -675,6 +1027,9 @@ func (m *MCPManager) connectToMCPClient(config schemas.MCPClientConfig) error {
 		if config.ConnectionType == schemas.MCPConnectionTypeSSE {
 			cancel() // Cancel SSE context only on error
 		}
+		if pool != nil {
+			pool.close()
+		}
 		return fmt.Errorf("failed to start MCP client transport %s: %v", config.Name, err)
 	}
 
@@ -698,6 +1053,9 @@ func (m *MCPManager) connectToMCPClient(config schemas.MCPClientConfig) error {
 		if config.ConnectionType == schemas.MCPConnectionTypeSSE {
 			cancel() // Cancel SSE context only on error
 		}
+		if pool != nil {
+			pool.close()
+		}
 		return fmt.Errorf("failed to initialize MCP client %s: %v", config.Name, err)
 	}
 
@@ -718,6 +1076,8 @@ func (m *MCPManager) connectToMCPClient(config schemas.MCPClientConfig) error {
 		// Store the external client connection and details
 		client.Conn = externalClient
 		client.ConnectionInfo = connectionInfo
+		client.pool = pool
+		client.healthErr = nil
 
 		// Store cancel function for SSE connections to enable proper cleanup
 		if config.ConnectionType == schemas.MCPConnectionTypeSSE {
@@ -731,6 +1091,9 @@ func (m *MCPManager) connectToMCPClient(config schemas.MCPClientConfig) error {
 
 		m.logger.Info(fmt.Sprintf("%s Connected to MCP client: %s", MCPLogPrefix, config.Name))
 	} else {
+		if pool != nil {
+			pool.close()
+		}
 		return fmt.Errorf("client %s was removed during connection setup", config.Name)
 	}
 
@@ -838,53 +1201,122 @@ func (m *MCPManager) convertMCPToolToBifrostSchema(mcpTool *mcp.Tool) schemas.To
 	}
 }
 
-// extractTextFromMCPResponse extracts text content from an MCP tool response.
-func (m *MCPManager) extractTextFromMCPResponse(toolResponse *mcp.CallToolResult, toolName string) string {
+// extractContentFromMCPResponse converts an MCP tool response into message content.
+// Text-only responses are returned as a single string for maximum provider compatibility.
+// If the response contains images, the content is returned as ordered content blocks with
+// images encoded as data URLs so that the model can consume them directly.
+func (m *MCPManager) extractContentFromMCPResponse(toolResponse *mcp.CallToolResult, toolName string) schemas.MessageContent {
 	if toolResponse == nil {
-		return fmt.Sprintf("MCP tool '%s' executed successfully", toolName)
+		return textMessageContent(fmt.Sprintf("MCP tool '%s' executed successfully", toolName))
+	}
+
+	var blocks []schemas.ContentBlock
+	var text strings.Builder
+	hasImage := false
+
+	// flushText moves any accumulated text into a text block, preserving ordering relative to images
+	flushText := func() {
+		if trimmed := strings.TrimSpace(text.String()); trimmed != "" {
+			blocks = append(blocks, schemas.ContentBlock{
+				Type: schemas.ContentBlockTypeText,
+				Text: &trimmed,
+			})
+		}
+		text.Reset()
 	}
 
-	var result strings.Builder
 	for _, contentBlock := range toolResponse.Content {
 		// Handle typed content
 		switch content := contentBlock.(type) {
 		case mcp.TextContent:
-			result.WriteString(content.Text)
+			text.WriteString(content.Text)
 		case mcp.ImageContent:
-			result.WriteString(fmt.Sprintf("[Image Response: %s, MIME: %s]\n", content.Data, content.MIMEType))
+			hasImage = true
+			flushText()
+			blocks = append(blocks, schemas.ContentBlock{
+				Type: schemas.ContentBlockTypeImage,
+				ImageURL: &schemas.ImageURLStruct{
+					URL: fmt.Sprintf("data:%s;base64,%s", content.MIMEType, content.Data),
+				},
+			})
 		case mcp.AudioContent:
-			result.WriteString(fmt.Sprintf("[Audio Response: %s, MIME: %s]\n", content.Data, content.MIMEType))
+			text.WriteString(fmt.Sprintf("[Audio Response: %s, MIME: %s]\n", content.Data, content.MIMEType))
 		case mcp.EmbeddedResource:
-			result.WriteString(fmt.Sprintf("[Embedded Resource Response: %s]\n", content.Type))
+			text.WriteString(fmt.Sprintf("[Embedded Resource Response: %s]\n", content.Type))
 		default:
 			// Fallback: try to extract from map structure
 			if jsonBytes, err := json.Marshal(contentBlock); err == nil {
 				var contentMap map[string]interface{}
 				if json.Unmarshal(jsonBytes, &contentMap) == nil {
-					if text, ok := contentMap["text"].(string); ok {
-						result.WriteString(fmt.Sprintf("[Text Response: %s]\n", text))
+					if textValue, ok := contentMap["text"].(string); ok {
+						text.WriteString(fmt.Sprintf("[Text Response: %s]\n", textValue))
 						continue
 					}
 				}
 				// Final fallback: serialize as JSON
-				result.WriteString(string(jsonBytes))
+				text.Write(jsonBytes)
 			}
 		}
 	}
 
-	if result.Len() > 0 {
-		return strings.TrimSpace(result.String())
+	if !hasImage {
+		if trimmed := strings.TrimSpace(text.String()); trimmed != "" {
+			return textMessageContent(trimmed)
+		}
+		return textMessageContent(fmt.Sprintf("MCP tool '%s' executed successfully", toolName))
 	}
-	return fmt.Sprintf("MCP tool '%s' executed successfully", toolName)
+
+	flushText()
+	return schemas.MessageContent{ContentBlocks: &blocks}
+}
+
+// textMessageContent wraps a string as message content.
+func textMessageContent(text string) schemas.MessageContent {
+	return schemas.MessageContent{ContentStr: &text}
+}
+
+// convertContentBlocksToMCPContent converts Bifrost content blocks returned by a multimodal
+// tool handler into MCP content. Images must be base64 data URLs, since MCP image content
+// carries the raw image data rather than a link.
+func convertContentBlocksToMCPContent(blocks []schemas.ContentBlock) ([]mcp.Content, error) {
+	content := make([]mcp.Content, 0, len(blocks))
+	for _, block := range blocks {
+		switch {
+		case block.Text != nil:
+			content = append(content, mcp.NewTextContent(*block.Text))
+		case block.ImageURL != nil:
+			mimeType, data, ok := parseBase64DataURL(block.ImageURL.URL)
+			if !ok {
+				return nil, fmt.Errorf("image content must be a base64 data URL")
+			}
+			content = append(content, mcp.NewImageContent(data, mimeType))
+		}
+	}
+	return content, nil
+}
+
+// parseBase64DataURL splits a data URL of the form data:<mime>;base64,<data> into its MIME type and data.
+func parseBase64DataURL(url string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", false
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", "", false
+	}
+	mimeType, ok := strings.CutSuffix(header, ";base64")
+	if !ok || mimeType == "" {
+		return "", "", false
+	}
+	return mimeType, data, true
 }
 
 // createToolResponseMessage creates a tool response message with the execution result.
-func (m *MCPManager) createToolResponseMessage(toolCall schemas.ToolCall, responseText string) *schemas.BifrostMessage {
+func (m *MCPManager) createToolResponseMessage(toolCall schemas.ToolCall, content schemas.MessageContent) *schemas.BifrostMessage {
 	return &schemas.BifrostMessage{
-		Role: schemas.ModelChatMessageRoleTool,
-		Content: schemas.MessageContent{
-			ContentStr: &responseText,
-		},
+		Role:    schemas.ModelChatMessageRoleTool,
+		Content: content,
 		ToolMessage: &schemas.ToolMessage{
 			ToolCallID: toolCall.ID,
 		},
@@ -949,6 +1381,18 @@ func validateMCPClientConfig(config *schemas.MCPClientConfig) error {
 		return fmt.Errorf("unknown connection type '%s' in client '%s'", config.ConnectionType, config.Name)
 	}
 
+	if config.MaxConcurrentConnections < 0 {
+		return fmt.Errorf("max concurrent connections cannot be negative in client '%s'", config.Name)
+	}
+
+	if config.HealthCheck != nil && (config.HealthCheck.IntervalSeconds < 0 || config.HealthCheck.FailureThreshold < 0) {
+		return fmt.Errorf("health check interval and failure threshold cannot be negative in client '%s'", config.Name)
+	}
+
+	if config.Reconnect != nil && (config.Reconnect.MaxAttempts < 0 || config.Reconnect.InitialBackoffSeconds < 0 || config.Reconnect.MaxBackoffSeconds < 0) {
+		return fmt.Errorf("reconnect attempts and backoffs cannot be negative in client '%s'", config.Name)
+	}
+
 	// Check for overlapping tools between ToolsToSkip and ToolsToExecute
 	if len(config.ToolsToSkip) > 0 && len(config.ToolsToExecute) > 0 {
 		skipMap := make(map[string]bool)
@@ -975,17 +1419,19 @@ func validateMCPClientConfig(config *schemas.MCPClientConfig) error {
 // HELPER METHODS
 // ============================================================================
 
-// findMCPClientForTool safely finds a client that has the specified tool.
-func (m *MCPManager) findMCPClientForTool(toolName string) *MCPClient {
+// findMCPClientForTool safely finds a client that has the specified tool, along with its
+// connection and connection pool at the time of the lookup. The connection is nil if the
+// client is disconnected.
+func (m *MCPManager) findMCPClientForTool(toolName string) (*MCPClient, *client.Client, *mcpConnectionPool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for _, client := range m.clientMap {
-		if _, exists := client.ToolMap[toolName]; exists {
-			return client
+	for _, mcpClient := range m.clientMap {
+		if _, exists := mcpClient.ToolMap[toolName]; exists {
+			return mcpClient, mcpClient.Conn, mcpClient.pool
 		}
 	}
-	return nil
+	return nil, nil, nil
 }
 
 // shouldIncludeClient determines if a client should be included based on filtering rules.
@@ -1005,7 +1451,8 @@ func (m *MCPManager) shouldIncludeClient(clientName string, includeClients, excl
 }
 
 // createHTTPConnection creates an HTTP-based MCP client connection without holding locks.
-func (m *MCPManager) createHTTPConnection(config schemas.MCPClientConfig) (*client.Client, MCPClientConnectionInfo, error) {
+// Requests are sent through the HTTP client of the given connection pool.
+func (m *MCPManager) createHTTPConnection(config schemas.MCPClientConfig, pool *mcpConnectionPool) (*client.Client, MCPClientConnectionInfo, error) {
 	if config.ConnectionString == nil {
 		return nil, MCPClientConnectionInfo{}, fmt.Errorf("HTTP connection string is required")
 	}
@@ -1017,7 +1464,7 @@ func (m *MCPManager) createHTTPConnection(config schemas.MCPClientConfig) (*clie
 	}
 
 	// Create StreamableHTTP transport
-	httpTransport, err := transport.NewStreamableHTTP(*config.ConnectionString)
+	httpTransport, err := transport.NewStreamableHTTP(*config.ConnectionString, transport.WithHTTPBasicClient(pool.httpClient))
 	if err != nil {
 		return nil, MCPClientConnectionInfo{}, fmt.Errorf("failed to create HTTP transport: %w", err)
 	}
@@ -1063,7 +1510,8 @@ func (m *MCPManager) createSTDIOConnection(config schemas.MCPClientConfig) (*cli
 }
 
 // createSSEConnection creates a SSE-based MCP client connection without holding locks.
-func (m *MCPManager) createSSEConnection(config schemas.MCPClientConfig) (*client.Client, MCPClientConnectionInfo, error) {
+// The event stream and requests are sent through the HTTP client of the given connection pool.
+func (m *MCPManager) createSSEConnection(config schemas.MCPClientConfig, pool *mcpConnectionPool) (*client.Client, MCPClientConnectionInfo, error) {
 	if config.ConnectionString == nil {
 		return nil, MCPClientConnectionInfo{}, fmt.Errorf("SSE connection string is required")
 	}
@@ -1075,7 +1523,7 @@ func (m *MCPManager) createSSEConnection(config schemas.MCPClientConfig) (*clien
 	}
 
 	// Create SSE transport
-	sseTransport, err := transport.NewSSE(*config.ConnectionString)
+	sseTransport, err := transport.NewSSE(*config.ConnectionString, transport.WithHTTPClient(pool.httpClient))
 	if err != nil {
 		return nil, MCPClientConnectionInfo{}, fmt.Errorf("failed to create SSE transport: %w", err)
 	}
@@ -1085,14 +1533,257 @@ func (m *MCPManager) createSSEConnection(config schemas.MCPClientConfig) (*clien
 	return client, connectionInfo, nil
 }
 
+// newMCPConnectionPool creates a connection pool allowing maxConnections concurrent tool calls
+// (DefaultMCPMaxConcurrentConnections if not positive). reservedConnections are added to the
+// transport's connection limit for long-lived connections that do not carry tool calls.
+func newMCPConnectionPool(maxConnections int, reservedConnections int) *mcpConnectionPool {
+	if maxConnections <= 0 {
+		maxConnections = schemas.DefaultMCPMaxConcurrentConnections
+	}
+
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.MaxConnsPerHost = maxConnections + reservedConnections
+	httpTransport.MaxIdleConnsPerHost = maxConnections + reservedConnections
+
+	return &mcpConnectionPool{
+		httpClient: &http.Client{Transport: httpTransport},
+		slots:      make(chan struct{}, maxConnections),
+	}
+}
+
+// acquire blocks until a slot is free or the context is done.
+func (p *mcpConnectionPool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken with acquire.
+func (p *mcpConnectionPool) release() {
+	<-p.slots
+}
+
+// close closes the idle connections of the pool. Connections in use are closed once released.
+func (p *mcpConnectionPool) close() {
+	p.httpClient.CloseIdleConnections()
+}
+
+// ============================================================================
+// HEALTH CHECKS
+// ============================================================================
+
+// startHealthCheckUnsafe starts the background health check of a client if it is configured
+// and not already running. Must be called with m.mu held.
+func (m *MCPManager) startHealthCheckUnsafe(client *MCPClient) {
+	config := client.ExecutionConfig.HealthCheck
+	if config == nil || client.stopHealthCheck != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.stopHealthCheck = cancel
+	go m.runHealthCheck(ctx, client.Name, *config)
+}
+
+// runHealthCheck pings the server of a client at the configured interval until ctx is cancelled.
+func (m *MCPManager) runHealthCheck(ctx context.Context, name string, config schemas.MCPHealthCheckConfig) {
+	interval := time.Duration(config.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = schemas.DefaultMCPHealthCheckIntervalSeconds * time.Second
+	}
+	failureThreshold := config.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = schemas.DefaultMCPHealthCheckFailureThreshold
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		m.checkClientHealth(ctx, name, failureThreshold)
+	}
+}
+
+// checkClientHealth pings the server of a client and records the time of the check. After
+// failureThreshold consecutive failed pings, the client is disconnected, so that it is reported as
+// errored and its tools are no longer offered, and it is reconnected in the background if its
+// Reconnect config is set. It reports whether the client is connected.
+func (m *MCPManager) checkClientHealth(ctx context.Context, name string, failureThreshold int) bool {
+	m.mu.RLock()
+	client, ok := m.clientMap[name]
+	if !ok {
+		m.mu.RUnlock()
+		return true
+	}
+	conn := client.Conn
+	m.mu.RUnlock()
+
+	var err error
+	if conn == nil {
+		err = fmt.Errorf("client %s is not connected", name)
+	} else {
+		pingCtx, cancel := context.WithTimeout(ctx, MCPHealthCheckTimeout)
+		err = conn.Ping(pingCtx)
+		cancel()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, ok = m.clientMap[name]
+	if !ok {
+		return true
+	}
+	client.LastHealthCheck = time.Now()
+
+	// Only account for the connection that was checked, it may have been replaced meanwhile
+	if conn == nil || client.Conn != conn {
+		return client.Conn != nil
+	}
+	if err == nil {
+		client.failedPings = 0
+		return true
+	}
+
+	client.failedPings++
+	m.logger.Warn(fmt.Sprintf("%s Health check %d/%d failed for MCP client %s: %v", MCPLogPrefix, client.failedPings, failureThreshold, name, err))
+	if client.failedPings < failureThreshold {
+		return true
+	}
+
+	m.disconnectClientUnsafe(client)
+	client.failedPings = 0
+	client.healthErr = err
+	m.startReconnectUnsafe(client)
+	return false
+}
+
+// ============================================================================
+// BACKGROUND RECONNECTION
+// ============================================================================
+
+// startReconnectUnsafe starts reconnecting a client in the background, unless it has no
+// Reconnect config or background reconnects are already running. Must be called with m.mu held.
+func (m *MCPManager) startReconnectUnsafe(client *MCPClient) {
+	config := client.ExecutionConfig.Reconnect
+	if config == nil || client.stopReconnect != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.stopReconnect = cancel
+	go m.runReconnect(ctx, client.Name, *config)
+}
+
+// runReconnect reconnects a client with an exponential backoff with jitter, until it is
+// connected, ctx is cancelled (the client was removed), or the attempts are exhausted, in
+// which case the client is left disconnected.
+func (m *MCPManager) runReconnect(ctx context.Context, name string, config schemas.MCPReconnectConfig) {
+	maxAttempts := config.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = schemas.DefaultMCPReconnectMaxAttempts
+	}
+	initialBackoff := time.Duration(config.InitialBackoffSeconds) * time.Second
+	if initialBackoff == 0 {
+		initialBackoff = schemas.DefaultMCPReconnectInitialBackoffSeconds * time.Second
+	}
+	maxBackoff := time.Duration(config.MaxBackoffSeconds) * time.Second
+	if maxBackoff == 0 {
+		maxBackoff = schemas.DefaultMCPReconnectMaxBackoffSeconds * time.Second
+	}
+	// Reuse the backoff of provider retries, which doubles the delay and applies jitter
+	backoff := schemas.NetworkConfig{RetryBackoffInitial: initialBackoff, RetryBackoffMax: max(maxBackoff, initialBackoff)}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		timer := time.NewTimer(ComputeBackoff(attempt, backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Stop if the client was reconnected meanwhile (e.g. by ReconnectMCPClient)
+		if !m.isClientDisconnected(name) {
+			m.finishReconnect(ctx, name)
+			return
+		}
+
+		lastErr = m.reconnectClient(name, false)
+		if lastErr == nil {
+			m.logger.Info(fmt.Sprintf("%s Reconnected MCP client %s after %d background attempts", MCPLogPrefix, name, attempt+1))
+			m.finishReconnect(ctx, name)
+			return
+		}
+
+		m.logger.Warn(fmt.Sprintf("%s Background reconnect %d/%d of MCP client %s failed: %v", MCPLogPrefix, attempt+1, maxAttempts, name, lastErr))
+		m.setHealthError(name, lastErr)
+	}
+
+	m.logger.Error(fmt.Errorf("%s Gave up reconnecting MCP client %s after %d attempts: %w", MCPLogPrefix, name, maxAttempts, lastErr))
+	m.finishReconnect(ctx, name)
+}
+
+// isClientDisconnected reports whether a client exists and has no connection.
+func (m *MCPManager) isClientDisconnected(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, ok := m.clientMap[name]
+	return ok && client.Conn == nil
+}
+
+// finishReconnect marks the background reconnect loop of a client as stopped, and clears its
+// health error so that a client that is still down is reported as disconnected rather than
+// errored. It does nothing if ctx was cancelled, as the client was then removed.
+func (m *MCPManager) finishReconnect(ctx context.Context, name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+	if client, ok := m.clientMap[name]; ok {
+		client.stopReconnect = nil
+		client.healthErr = nil
+	}
+}
+
+// setHealthError records the error of a failed reconnect of a client.
+func (m *MCPManager) setHealthError(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if client, ok := m.clientMap[name]; ok {
+		client.healthErr = err
+	}
+}
+
 // cleanup performs cleanup of all MCP resources including clients and local server.
 // This function safely disconnects all MCP clients (HTTP, STDIO, and SSE) and
 // cleans up the local MCP server. It handles proper cancellation of SSE contexts
-// and closes all transport connections.
+// and closes all transport connections. In-flight tool calls are drained first: they get
+// MCPConfig.DrainTimeoutSeconds to finish and are cancelled after it.
 //
 // Returns:
 //   - error: Always returns nil, but maintains error interface for consistency
 func (m *MCPManager) cleanup() error {
+	// Drain before locking: in-flight calls look up their client under the read lock
+	drainTimeout := m.drainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = schemas.DefaultMCPDrainTimeoutSeconds * time.Second
+	}
+	m.drainExecutions(drainTimeout)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
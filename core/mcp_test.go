@@ -0,0 +1,483 @@
+package bifrost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestMCPConnectionPoolLimitsConcurrency(t *testing.T) {
+	pool := newMCPConnectionPool(2, 1)
+	defer pool.close()
+
+	if got := pool.httpClient.Transport.(*http.Transport).MaxConnsPerHost; got != 3 {
+		t.Errorf("MaxConnsPerHost = %d, want 3", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := pool.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire() %d: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() on a full pool = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	pool.release()
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() after release: %v", err)
+	}
+}
+
+func TestMCPConnectionPoolDefaultLimit(t *testing.T) {
+	pool := newMCPConnectionPool(0, 0)
+	defer pool.close()
+
+	if got := cap(pool.slots); got != schemas.DefaultMCPMaxConcurrentConnections {
+		t.Errorf("slots = %d, want %d", got, schemas.DefaultMCPMaxConcurrentConnections)
+	}
+}
+
+func TestMCPHealthCheckRecordsDisconnectedClient(t *testing.T) {
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {Name: "tools"}},
+		logger:    NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	if manager.checkClientHealth(context.Background(), "tools", 1) {
+		t.Fatal("checkClientHealth() = true for a client without a connection")
+	}
+	if manager.clientMap["tools"].LastHealthCheck.IsZero() {
+		t.Error("LastHealthCheck was not recorded")
+	}
+
+	// Removed clients are reported healthy so that their loop has nothing to reconnect
+	if !manager.checkClientHealth(context.Background(), "removed", 1) {
+		t.Error("checkClientHealth() = false for a removed client")
+	}
+}
+
+func TestMCPHealthCheckDisconnectsAfterConsecutiveFailures(t *testing.T) {
+	// Pings to a server that is down fail
+	httpTransport, err := transport.NewStreamableHTTP("http://127.0.0.1:1/mcp")
+	if err != nil {
+		t.Fatalf("NewStreamableHTTP() error = %v", err)
+	}
+	conn := client.NewClient(httpTransport)
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {Name: "tools", Conn: conn, ToolMap: make(map[string]schemas.Tool)}},
+		logger:    NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	for i := 1; i < 3; i++ {
+		if !manager.checkClientHealth(context.Background(), "tools", 3) {
+			t.Fatalf("checkClientHealth() disconnected the client after %d failed ping(s), want 3", i)
+		}
+	}
+	if manager.checkClientHealth(context.Background(), "tools", 3) {
+		t.Fatal("checkClientHealth() kept the client connected after 3 failed pings")
+	}
+
+	// Without a Reconnect config, the client is not reconnected in the background
+	mcpClient := manager.clientMap["tools"]
+	if mcpClient.Conn != nil || mcpClient.healthErr == nil || mcpClient.stopReconnect != nil {
+		t.Errorf("client = %+v, want it disconnected with its error and no background reconnect", mcpClient)
+	}
+}
+
+func TestMCPHealthCheckDisconnectDuringToolCall(t *testing.T) {
+	httpTransport, err := transport.NewStreamableHTTP("http://127.0.0.1:1/mcp")
+	if err != nil {
+		t.Fatalf("NewStreamableHTTP() error = %v", err)
+	}
+	pool := newMCPConnectionPool(1, 0)
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {
+			Name:    "tools",
+			Conn:    client.NewClient(httpTransport),
+			ToolMap: map[string]schemas.Tool{"search": {}},
+			pool:    pool,
+		}},
+		logger: NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	// A tool call waits for the only connection slot while the health check drops the client
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := manager.executeTool(context.Background(), schemas.ToolCall{
+			Function: schemas.FunctionCall{Name: Ptr("search"), Arguments: "{}"},
+		})
+		callErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if manager.checkClientHealth(context.Background(), "tools", 1) {
+		t.Fatal("checkClientHealth() kept the client connected after a failed ping")
+	}
+	pool.release()
+
+	if err := <-callErr; err == nil {
+		t.Error("executeTool() succeeded on the dropped connection")
+	}
+}
+
+func TestMCPReconnectGivesUpAfterMaxAttempts(t *testing.T) {
+	url := "http://127.0.0.1:1/mcp"
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {
+			Name: "tools",
+			ExecutionConfig: schemas.MCPClientConfig{
+				Name:             "tools",
+				ConnectionType:   schemas.MCPConnectionTypeHTTP,
+				ConnectionString: &url,
+				Reconnect:        &schemas.MCPReconnectConfig{MaxAttempts: 1, InitialBackoffSeconds: 1},
+			},
+			ToolMap: make(map[string]schemas.Tool),
+		}},
+		logger: NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	if err := manager.ReconnectClient("tools"); err == nil {
+		t.Fatal("ReconnectClient() succeeded for an unreachable server")
+	}
+	manager.mu.RLock()
+	started := manager.clientMap["tools"].stopReconnect != nil
+	manager.mu.RUnlock()
+	if !started {
+		t.Fatal("background reconnect was not started after a failed reconnect")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		manager.mu.RLock()
+		client := manager.clientMap["tools"]
+		done, healthErr := client.stopReconnect == nil, client.healthErr
+		manager.mu.RUnlock()
+		if done {
+			if healthErr != nil {
+				t.Errorf("healthErr = %v, want nil so that the client is reported disconnected", healthErr)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background reconnect did not give up")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestMCPReconnectIsOptIn(t *testing.T) {
+	url := "http://127.0.0.1:1/mcp"
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {
+			Name: "tools",
+			ExecutionConfig: schemas.MCPClientConfig{
+				Name:             "tools",
+				ConnectionType:   schemas.MCPConnectionTypeHTTP,
+				ConnectionString: &url,
+			},
+			ToolMap: make(map[string]schemas.Tool),
+		}},
+		logger: NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	if err := manager.ReconnectClient("tools"); err == nil {
+		t.Fatal("ReconnectClient() succeeded for an unreachable server")
+	}
+	if manager.clientMap["tools"].stopReconnect != nil {
+		t.Error("background reconnect was started without a Reconnect config")
+	}
+}
+
+func TestMCPReconnectStoppedOnRemove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{"tools": {Name: "tools", stopReconnect: cancel}},
+		logger:    NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	if err := manager.cleanup(); err != nil {
+		t.Fatalf("cleanup() error = %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("cleanup() did not stop the background reconnect")
+	}
+}
+
+func TestMCPCleanupDrainsToolCalls(t *testing.T) {
+	manager := &MCPManager{
+		clientMap: map[string]*MCPClient{},
+		logger:    NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	// A call that finishes within the timeout is waited for
+	_, finish, err := manager.trackExecution(context.Background())
+	if err != nil {
+		t.Fatalf("trackExecution() error = %v", err)
+	}
+	time.AfterFunc(20*time.Millisecond, finish)
+	if cancelled := manager.drainExecutions(time.Second); cancelled != 0 {
+		t.Errorf("drainExecutions() cancelled %d calls, want 0", cancelled)
+	}
+
+	// New calls are refused once draining started
+	if _, _, err := manager.trackExecution(context.Background()); err == nil {
+		t.Error("trackExecution() succeeded after draining, want an error")
+	}
+}
+
+func TestMCPCleanupCancelsSlowToolCalls(t *testing.T) {
+	manager := &MCPManager{
+		clientMap:    map[string]*MCPClient{},
+		logger:       NewDefaultLogger(schemas.LogLevelError),
+		drainTimeout: 20 * time.Millisecond,
+	}
+
+	ctx, finish, err := manager.trackExecution(context.Background())
+	if err != nil {
+		t.Fatalf("trackExecution() error = %v", err)
+	}
+	callErr := make(chan error, 1)
+	go func() {
+		defer finish()
+		<-ctx.Done() // A tool call that only returns when cancelled
+		callErr <- ctx.Err()
+	}()
+
+	start := time.Now()
+	if err := manager.cleanup(); err != nil {
+		t.Fatalf("cleanup() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > MCPToolCancelGracePeriod {
+		t.Errorf("cleanup() took %s, want the call cancelled after the drain timeout", elapsed)
+	}
+	if err := <-callErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("tool call context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// newInProcessMCPManager returns a manager whose local MCP server is reached in process, as the
+// STDIO transport of the local client cannot be used in tests.
+func newInProcessMCPManager(t *testing.T) *MCPManager {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	conn, err := client.NewInProcessClient(mcpServer)
+	if err != nil {
+		t.Fatalf("NewInProcessClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if _, err := conn.Initialize(context.Background(), mcp.InitializeRequest{
+		Params: mcp.InitializeParams{ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION},
+	}); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	return &MCPManager{
+		clientMap: map[string]*MCPClient{BifrostMCPClientKey: {
+			Name:    BifrostMCPClientName,
+			Conn:    conn,
+			ToolMap: make(map[string]schemas.Tool),
+		}},
+		server:        mcpServer,
+		serverRunning: true,
+		logger:        NewDefaultLogger(schemas.LogLevelError),
+	}
+}
+
+func TestMCPMultimodalToolReturnsImages(t *testing.T) {
+	manager := newInProcessMCPManager(t)
+	summary, chart := "Monthly revenue", "data:image/png;base64,iVBORw0KGgo="
+	err := manager.registerMultimodalTool("render_chart", "Render a chart", func(args any) ([]schemas.ContentBlock, error) {
+		return []schemas.ContentBlock{
+			{Type: schemas.ContentBlockTypeText, Text: &summary},
+			{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: chart}},
+		}, nil
+	}, schemas.Tool{Type: "function", Function: schemas.Function{Name: "render_chart"}})
+	if err != nil {
+		t.Fatalf("registerMultimodalTool() error = %v", err)
+	}
+	if err := manager.registerMultimodalTool("render_chart", "", nil, schemas.Tool{}); err == nil {
+		t.Error("registerMultimodalTool() registered a duplicate tool")
+	}
+
+	message, err := manager.executeTool(context.Background(), schemas.ToolCall{
+		ID:       Ptr("call_1"),
+		Function: schemas.FunctionCall{Name: Ptr("render_chart"), Arguments: "{}"},
+	})
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if message.Role != schemas.ModelChatMessageRoleTool || message.ToolMessage == nil || *message.ToolMessage.ToolCallID != "call_1" {
+		t.Errorf("message = %+v, want a tool message answering call_1", message)
+	}
+	blocks := message.Content.ContentBlocks
+	if blocks == nil || len(*blocks) != 2 || *(*blocks)[0].Text != summary || (*blocks)[1].ImageURL.URL != chart {
+		t.Fatalf("content = %+v, want the summary and the chart", message.Content)
+	}
+}
+
+func TestMCPMultimodalToolRejectsImageLinks(t *testing.T) {
+	manager := newInProcessMCPManager(t)
+	err := manager.registerMultimodalTool("screenshot", "Take a screenshot", func(args any) ([]schemas.ContentBlock, error) {
+		return []schemas.ContentBlock{{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: "https://example.com/shot.png"}}}, nil
+	}, schemas.Tool{Type: "function", Function: schemas.Function{Name: "screenshot"}})
+	if err != nil {
+		t.Fatalf("registerMultimodalTool() error = %v", err)
+	}
+
+	// The error is returned to the model as the tool's result
+	message, err := manager.executeTool(context.Background(), schemas.ToolCall{
+		ID:       Ptr("call_1"),
+		Function: schemas.FunctionCall{Name: Ptr("screenshot"), Arguments: "{}"},
+	})
+	if err != nil {
+		t.Fatalf("executeTool() error = %v", err)
+	}
+	if text := message.Content.ContentStr; text == nil || !strings.Contains(*text, "base64 data URL") {
+		t.Errorf("content = %+v, want the data URL error", message.Content)
+	}
+}
+
+func TestMCPToolCallSurvivesDisconnect(t *testing.T) {
+	manager := newInProcessMCPManager(t)
+	started, unblock := make(chan struct{}, 1), make(chan struct{})
+	err := manager.registerTool("wait", "Wait until unblocked", func(args any) (string, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-unblock
+		return "done", nil
+	}, schemas.Tool{Type: "function", Function: schemas.Function{Name: "wait"}})
+	if err != nil {
+		t.Fatalf("registerTool() error = %v", err)
+	}
+	manager.clientMap[BifrostMCPClientKey].pool = newMCPConnectionPool(1, 0)
+
+	// One call holds the only connection slot while the others wait for it
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manager.executeTool(context.Background(), schemas.ToolCall{
+				ID:       Ptr("call_1"),
+				Function: schemas.FunctionCall{Name: Ptr("wait"), Arguments: "{}"},
+			})
+		}()
+	}
+	<-started
+	time.Sleep(20 * time.Millisecond)
+
+	// The waiting calls get their slot after a health check dropped the connection, and must
+	// still use the connection and pool they found instead of the cleared ones
+	manager.mu.Lock()
+	manager.disconnectClientUnsafe(manager.clientMap[BifrostMCPClientKey])
+	manager.mu.Unlock()
+	close(unblock)
+	wg.Wait()
+
+	if _, err := manager.executeTool(context.Background(), schemas.ToolCall{
+		ID:       Ptr("call_2"),
+		Function: schemas.FunctionCall{Name: Ptr("wait"), Arguments: "{}"},
+	}); err == nil {
+		t.Error("executeTool() succeeded on a disconnected client")
+	}
+}
+
+func TestMCPToolCallTrackerCountsIterations(t *testing.T) {
+	tracker := newToolCallTracker(2)
+	search := schemas.ToolCall{Function: schemas.FunctionCall{Name: Ptr("search")}}
+
+	// Parallel tool calls of the same model turn are a single iteration
+	for i := 0; i < 3; i++ {
+		if err := tracker.record(search); err != nil {
+			t.Fatalf("record() %d error = %v", i, err)
+		}
+	}
+	tracker.NextIteration()
+	if err := tracker.record(search); err != nil {
+		t.Fatalf("record() in the second iteration error = %v", err)
+	}
+
+	// Turns without tool calls, such as fallback attempts, do not count
+	tracker.NextIteration()
+	tracker.NextIteration()
+	err := tracker.record(search)
+	var limitErr *MCPToolCallLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != 2 || len(limitErr.History) != 4 {
+		t.Fatalf("record() in the third iteration error = %v, want the limit error with 4 tool calls", err)
+	}
+	if tracker.Iterations() != 2 || len(tracker.History()) != 4 {
+		t.Errorf("Iterations() = %d, History() has %d calls, want 2 and 4", tracker.Iterations(), len(tracker.History()))
+	}
+}
+
+func TestMCPToolCallIterationsLimitedByDefault(t *testing.T) {
+	bifrost := newTestBifrost(nil)
+	bifrost.mcpManager = &MCPManager{
+		clientMap:             map[string]*MCPClient{"tools": {Name: "tools", ToolMap: map[string]schemas.Tool{"search": {}}}},
+		logger:                NewDefaultLogger(schemas.LogLevelError),
+		maxToolCallIterations: 1,
+	}
+
+	toolRound := func(name string) []schemas.BifrostMessage {
+		return []schemas.BifrostMessage{
+			{
+				Role:             schemas.ModelChatMessageRoleAssistant,
+				AssistantMessage: &schemas.AssistantMessage{ToolCalls: &[]schemas.ToolCall{{Function: schemas.FunctionCall{Name: Ptr(name)}}}},
+			},
+			{Role: schemas.ModelChatMessageRoleTool, Content: schemas.MessageContent{ContentStr: Ptr("result")}},
+		}
+	}
+	request := func(messages ...[]schemas.BifrostMessage) *schemas.BifrostRequest {
+		input := []schemas.BifrostMessage{{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentStr: Ptr("Find it")}}}
+		for _, round := range messages {
+			input = append(input, round...)
+		}
+		return &schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &input}}
+	}
+
+	ctx, tracker := bifrost.WithMCPToolCallTracking(context.Background())
+	if err := bifrost.trackMCPIteration(ctx, request(toolRound("search")), ChatCompletionRequest); err != nil {
+		t.Fatalf("trackMCPIteration() at the limit error = %v", err.Error.Message)
+	}
+	if tracker.turn != 1 {
+		t.Errorf("tracker turn = %d, want the request to start a new iteration", tracker.turn)
+	}
+
+	// Rounds of tools that are not MCP tools do not count
+	if err := bifrost.trackMCPIteration(ctx, request(toolRound("search"), toolRound("client_tool")), ChatCompletionStreamRequest); err != nil {
+		t.Errorf("trackMCPIteration() with a client tool error = %v", err.Error.Message)
+	}
+
+	err := bifrost.trackMCPIteration(context.Background(), request(toolRound("search"), toolRound("search")), ChatCompletionRequest)
+	var limitErr *MCPToolCallLimitError
+	if err == nil || !errors.As(err.Error.Error, &limitErr) || len(limitErr.History) != 2 {
+		t.Fatalf("trackMCPIteration() above the limit error = %+v, want the limit error with 2 tool calls", err)
+	}
+
+	// A new user message starts a new logical request
+	next := append(*request(toolRound("search"), toolRound("search")).Input.ChatCompletionInput, schemas.BifrostMessage{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentStr: Ptr("Thanks")}})
+	if err := bifrost.trackMCPIteration(context.Background(), &schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &next}}, ChatCompletionRequest); err != nil {
+		t.Errorf("trackMCPIteration() after a new user message error = %v", err.Error.Message)
+	}
+}
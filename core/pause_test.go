@@ -0,0 +1,56 @@
+package bifrost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestPauseProviderWaitsForInFlightRequests(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	gate := bifrost.getProviderGate(schemas.OpenAI)
+	gate.enter()
+
+	// The in-flight request does not finish before the deadline, the provider stays paused
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bifrost.PauseProvider(ctx, schemas.OpenAI); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !bifrost.IsProviderPaused(schemas.OpenAI) {
+		t.Fatal("expected provider to stay paused after a timed out drain")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		gate.leave()
+	}()
+	if err := bifrost.PauseProvider(context.Background(), schemas.OpenAI); err != nil {
+		t.Fatalf("expected drain to complete, got %v", err)
+	}
+
+	// A request dequeued while paused is held until the provider is resumed
+	entered := make(chan struct{})
+	go func() {
+		gate.enter()
+		close(entered)
+	}()
+	select {
+	case <-entered:
+		t.Fatal("request was dispatched while the provider was paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	bifrost.ResumeProvider(schemas.OpenAI)
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("request was not dispatched after the provider was resumed")
+	}
+	if bifrost.IsProviderPaused(schemas.OpenAI) {
+		t.Fatal("expected provider to be resumed")
+	}
+}
@@ -0,0 +1,81 @@
+package bifrost
+
+import (
+	"fmt"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// repetitionToFrequencyPenalty and frequencyToRepetitionPenalty approximate one kind of penalty
+// with the other, for providers that only accept one of them: a repetition penalty of 1 (none)
+// is a frequency penalty of 0, and the strongest frequency penalty (2) is a repetition penalty of 1.5.
+func repetitionToFrequencyPenalty(repetition float64) float64 {
+	return min(max((repetition-1)*4, -2), 2)
+}
+
+func frequencyToRepetitionPenalty(frequency float64) float64 {
+	return 1 + frequency/4
+}
+
+// normalizePenalties returns the parameters to send to a provider, with penalties it does not
+// accept translated to the ones it does, or dropped, and additive penalties clamped to its range.
+// This keeps the behavior of a request close when it moves to a fallback with other penalties.
+// The provider key and model are only used to report the changes. The caller's parameters are
+// not modified.
+func (bifrost *Bifrost) normalizePenalties(support schemas.PenaltySupport, providerKey schemas.ModelProvider, model string, params *schemas.ModelParameters) *schemas.ModelParameters {
+	if params == nil || (params.PresencePenalty == nil && params.FrequencyPenalty == nil && params.RepetitionPenalty == nil) {
+		return params
+	}
+
+	normalized := *params
+	var changes []string
+
+	if !support.Repetition && normalized.RepetitionPenalty != nil {
+		if support.Additive && normalized.FrequencyPenalty == nil {
+			normalized.FrequencyPenalty = Ptr(repetitionToFrequencyPenalty(*normalized.RepetitionPenalty))
+			changes = append(changes, fmt.Sprintf("repetition_penalty %v translated to frequency_penalty %v", *normalized.RepetitionPenalty, *normalized.FrequencyPenalty))
+		} else {
+			changes = append(changes, "repetition_penalty dropped")
+		}
+		normalized.RepetitionPenalty = nil
+	}
+
+	if !support.Additive {
+		if support.Repetition && normalized.RepetitionPenalty == nil && normalized.FrequencyPenalty != nil {
+			normalized.RepetitionPenalty = Ptr(frequencyToRepetitionPenalty(*normalized.FrequencyPenalty))
+			changes = append(changes, fmt.Sprintf("frequency_penalty %v translated to repetition_penalty %v", *normalized.FrequencyPenalty, *normalized.RepetitionPenalty))
+			normalized.FrequencyPenalty = nil
+		}
+		if normalized.PresencePenalty != nil {
+			changes = append(changes, "presence_penalty dropped")
+		}
+		if normalized.FrequencyPenalty != nil {
+			changes = append(changes, "frequency_penalty dropped")
+		}
+		normalized.PresencePenalty = nil
+		normalized.FrequencyPenalty = nil
+	}
+
+	for _, penalty := range []struct {
+		name  string
+		value **float64
+	}{
+		{"presence_penalty", &normalized.PresencePenalty},
+		{"frequency_penalty", &normalized.FrequencyPenalty},
+	} {
+		if *penalty.value == nil {
+			continue
+		}
+		if value := **penalty.value; value < support.Min || value > support.Max {
+			*penalty.value = Ptr(min(max(value, support.Min), support.Max))
+			changes = append(changes, fmt.Sprintf("%s %v clamped to %v", penalty.name, value, **penalty.value))
+		}
+	}
+
+	if len(changes) == 0 {
+		return params
+	}
+	bifrost.logger.Warn(fmt.Sprintf("Penalties adjusted for provider %s with model %s: %s", providerKey, model, strings.Join(changes, ", ")))
+	return &normalized
+}
@@ -0,0 +1,62 @@
+package bifrost
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestNormalizePenalties(t *testing.T) {
+	bifrost := newTestBifrost(nil)
+
+	additive := schemas.PenaltySupport{Additive: true, Min: -2, Max: 2}
+	tests := []struct {
+		name                            string
+		support                         schemas.PenaltySupport
+		params                          schemas.ModelParameters
+		presence, frequency, repetition *float64
+	}{
+		{"supported", additive, schemas.ModelParameters{PresencePenalty: Ptr(0.5), FrequencyPenalty: Ptr(-1.0)}, Ptr(0.5), Ptr(-1.0), nil},
+		{"clamped to range", schemas.PenaltySupport{Additive: true, Min: 0, Max: 1}, schemas.ModelParameters{PresencePenalty: Ptr(-0.5), FrequencyPenalty: Ptr(1.5)}, Ptr(0.0), Ptr(1.0), nil},
+		{"unsupported", schemas.PenaltySupport{}, schemas.ModelParameters{PresencePenalty: Ptr(0.5), RepetitionPenalty: Ptr(1.2)}, nil, nil, nil},
+		{"repetition to frequency", additive, schemas.ModelParameters{RepetitionPenalty: Ptr(1.25)}, nil, Ptr(1.0), nil},
+		{"repetition kept", schemas.PenaltySupport{Additive: true, Min: -2, Max: 2, Repetition: true}, schemas.ModelParameters{FrequencyPenalty: Ptr(0.5), RepetitionPenalty: Ptr(1.1)}, nil, Ptr(0.5), Ptr(1.1)},
+		{"explicit frequency wins", additive, schemas.ModelParameters{FrequencyPenalty: Ptr(0.5), RepetitionPenalty: Ptr(1.5)}, nil, Ptr(0.5), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			got := bifrost.normalizePenalties(tt.support, schemas.OpenAI, "test-model", &params)
+			for _, check := range []struct {
+				name      string
+				got, want *float64
+			}{
+				{"presence_penalty", got.PresencePenalty, tt.presence},
+				{"frequency_penalty", got.FrequencyPenalty, tt.frequency},
+				{"repetition_penalty", got.RepetitionPenalty, tt.repetition},
+			} {
+				if (check.got == nil) != (check.want == nil) || (check.got != nil && *check.got != *check.want) {
+					t.Errorf("%s = %v, want %v", check.name, formatPenalty(check.got), formatPenalty(check.want))
+				}
+			}
+			if params.PresencePenalty != tt.params.PresencePenalty || params.FrequencyPenalty != tt.params.FrequencyPenalty || params.RepetitionPenalty != tt.params.RepetitionPenalty {
+				t.Error("caller's parameters were modified")
+			}
+		})
+	}
+}
+
+func TestFrequencyToRepetitionPenaltyRoundTrip(t *testing.T) {
+	for _, frequency := range []float64{-2, 0, 1, 2} {
+		if got := repetitionToFrequencyPenalty(frequencyToRepetitionPenalty(frequency)); got != frequency {
+			t.Errorf("round trip of frequency_penalty %v = %v", frequency, got)
+		}
+	}
+}
+
+func formatPenalty(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
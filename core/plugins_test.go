@@ -0,0 +1,71 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// recordingPlugin records which of its hooks ran, and only applies to one provider.
+type recordingPlugin struct {
+	name     string
+	provider schemas.ModelProvider
+	calls    *[]string
+}
+
+func (p *recordingPlugin) GetName() string { return p.name }
+
+func (p *recordingPlugin) ShouldRun(ctx context.Context, req *schemas.BifrostRequest) bool {
+	return p.provider == "" || req.Provider == p.provider
+}
+
+func (p *recordingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	*p.calls = append(*p.calls, p.name+".pre")
+	return req, nil, nil
+}
+
+func (p *recordingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	*p.calls = append(*p.calls, p.name+".post")
+	return result, err, nil
+}
+
+func (p *recordingPlugin) Cleanup() error { return nil }
+
+func TestPluginPipelineSkipsInapplicablePlugins(t *testing.T) {
+	var calls []string
+	pipeline := &PluginPipeline{
+		plugins: []schemas.Plugin{
+			&recordingPlugin{name: "a", calls: &calls},
+			&recordingPlugin{name: "b", provider: schemas.Anthropic, calls: &calls},
+			&recordingPlugin{name: "c", provider: schemas.OpenAI, calls: &calls},
+		},
+		logger: NewDefaultLogger(schemas.LogLevelError),
+	}
+
+	ctx := context.Background()
+	req, shortCircuit, count := pipeline.RunPreHooks(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"})
+	if shortCircuit != nil {
+		t.Fatalf("unexpected short-circuit")
+	}
+	pipeline.RunPostHooks(&ctx, &schemas.BifrostResponse{}, nil, count)
+
+	want := []string{"a.pre", "c.pre", "c.post", "a.post"}
+	if len(calls) != len(want) {
+		t.Fatalf("hooks ran %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("hooks ran %v, want %v", calls, want)
+		}
+	}
+
+	// A pipeline that only runs PostHooks skips the same plugins once evaluated against the request
+	calls = calls[:0]
+	pipeline.resetPluginPipeline()
+	pipeline.skipInapplicablePlugins(ctx, req)
+	pipeline.RunPostHooks(&ctx, &schemas.BifrostResponse{}, nil, len(pipeline.plugins))
+	if len(calls) != 2 || calls[0] != "c.post" || calls[1] != "a.post" {
+		t.Fatalf("post hooks ran %v, want [c.post a.post]", calls)
+	}
+}
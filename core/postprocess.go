@@ -0,0 +1,48 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// postProcessResponse applies the request's PostProcess callback, if any, to a response that
+// went through the post-hooks.
+func (bifrost *Bifrost) postProcessResponse(req *schemas.BifrostRequest, result *schemas.BifrostResponse) *schemas.BifrostResponse {
+	bifrost.runPostProcess("PostProcess", req.PostProcess, result)
+	return result
+}
+
+// runPostProcess calls a post-processing callback with a response. A panicking callback is
+// recovered and the response left as the callback left it.
+func (bifrost *Bifrost) runPostProcess(name string, callback func(result *schemas.BifrostResponse), result *schemas.BifrostResponse) {
+	if callback == nil || result == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("%s callback panicked: %v", name, r))
+		}
+	}()
+
+	callback(result)
+}
+
+// postProcessStreamChunks applies the request's PostProcessChunk callback, if any, to the
+// response of each chunk of a stream before it reaches the caller.
+func (bifrost *Bifrost) postProcessStreamChunks(ctx context.Context, req *schemas.BifrostRequest, stream chan *schemas.BifrostStream) chan *schemas.BifrostStream {
+	if req.PostProcessChunk == nil || stream == nil {
+		return stream
+	}
+
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return forwardStreamUntil(ctx, cancel, stream, func(chunk *schemas.BifrostStream) bool {
+		bifrost.runPostProcess("PostProcessChunk", req.PostProcessChunk, chunk.BifrostResponse)
+		return false
+	})
+}
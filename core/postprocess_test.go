@@ -0,0 +1,82 @@
+package bifrost
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestPostProcessResponse(t *testing.T) {
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI}},
+		Plugins: []schemas.Plugin{&maxTokensPlugin{}},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	calls := 0
+	messages := []schemas.BifrostMessage{schemas.UserMessage("What is the weather in Paris?")}
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:   &schemas.ModelParameters{MaxTokens: Ptr(1000)},
+		PostProcess: func(result *schemas.BifrostResponse) {
+			calls++
+			toolCall := &(*result.Choices[0].Message.ToolCalls)[0]
+			toolCall.Function.Name = Ptr(strings.ToUpper(*toolCall.Function.Name))
+		},
+	}
+
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if name := *(*result.Choices[0].Message.ToolCalls)[0].Function.Name; name != "GET_WEATHER" || calls != 1 {
+		t.Errorf("tool call name = %q after %d calls, want GET_WEATHER after 1 call", name, calls)
+	}
+
+	// A panicking callback does not fail the request
+	req.PostProcess = func(result *schemas.BifrostResponse) { panic("boom") }
+	if result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), req); bifrostErr != nil || result == nil {
+		t.Errorf("ChatCompletionRequest() with a panicking callback = %v, %+v, want the response", result, bifrostErr)
+	}
+}
+
+func TestPostProcessStreamChunks(t *testing.T) {
+	bifrost := newTestBifrost(nil)
+	stream := make(chan *schemas.BifrostStream, 2)
+	stream <- streamChunk("Answer: Paris")
+	stream <- &schemas.BifrostStream{BifrostError: &schemas.BifrostError{Error: schemas.ErrorField{Message: "stream failed"}}}
+	close(stream)
+
+	req := &schemas.BifrostRequest{PostProcessChunk: func(result *schemas.BifrostResponse) {
+		delta := &result.Choices[0].Delta
+		delta.Content = Ptr(strings.TrimPrefix(*delta.Content, "Answer: "))
+	}}
+
+	var chunks []*schemas.BifrostStream
+	for chunk := range bifrost.postProcessStreamChunks(context.Background(), req, stream) {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	if content := *chunks[0].BifrostResponse.Choices[0].Delta.Content; content != "Paris" {
+		t.Errorf("content = %q, want %q", content, "Paris")
+	}
+	if chunks[1].BifrostError == nil {
+		t.Error("error chunk was not forwarded")
+	}
+
+	// PostProcess applies to whole responses only, so it leaves streams untouched
+	untouched := make(chan *schemas.BifrostStream)
+	if got := bifrost.postProcessStreamChunks(context.Background(), &schemas.BifrostRequest{PostProcess: req.PostProcessChunk}, untouched); got != untouched {
+		t.Error("PostProcess was applied to a stream")
+	}
+}
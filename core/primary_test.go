@@ -0,0 +1,52 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestSelectPrimary(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		BaseURL:  Ptr("https://canary.example.com"),
+		PrimaryCandidates: []schemas.PrimaryCandidate{
+			{Provider: schemas.OpenAI, Model: "gpt-4o", Weight: 0},
+			{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet-20241022", Weight: 1},
+		},
+	}
+
+	ctx, primaryReq, err := bifrost.selectPrimary(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err.Error.Message)
+	}
+	if primaryReq.Provider != schemas.Anthropic || primaryReq.Model != "claude-3-5-sonnet-20241022" {
+		t.Fatalf("expected the only weighted candidate, got %s/%s", primaryReq.Provider, primaryReq.Model)
+	}
+	if primaryReq.PrimaryCandidates != nil || primaryReq.BaseURL != nil {
+		t.Errorf("expected candidates and base URL to be cleared on the selected request")
+	}
+	if req.Provider != schemas.OpenAI {
+		t.Errorf("the caller's request must not be modified")
+	}
+
+	resp := &schemas.BifrostResponse{}
+	attachSelectedPrimary(ctx, resp)
+	if selected := resp.ExtraFields.SelectedPrimary; selected == nil || selected.Provider != schemas.Anthropic {
+		t.Errorf("expected the selection to be reported on the response, got %+v", selected)
+	}
+}
+
+func TestSelectPrimaryRejectsInvalidCandidates(t *testing.T) {
+	bifrost := newTestBifrost(&testAccount{})
+	req := &schemas.BifrostRequest{
+		PrimaryCandidates: []schemas.PrimaryCandidate{{Provider: schemas.OpenAI, Model: "gpt-4o", Weight: 0}},
+	}
+
+	if _, _, err := bifrost.selectPrimary(context.Background(), req); err == nil {
+		t.Fatal("expected an error when no candidate has a positive weight")
+	}
+}
@@ -3,14 +3,12 @@
 package providers
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"sync"
-	"time"
+	"unicode/utf8"
 
 	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -28,14 +26,11 @@ type AnthropicToolChoice struct {
 // AnthropicTextResponse represents the response structure from Anthropic's text completion API.
 // It includes the completion text, model information, and token usage statistics.
 type AnthropicTextResponse struct {
-	ID         string `json:"id"`         // Unique identifier for the completion
-	Type       string `json:"type"`       // Type of completion
-	Completion string `json:"completion"` // Generated completion text
-	Model      string `json:"model"`      // Model used for the completion
-	Usage      struct {
-		InputTokens  int `json:"input_tokens"`  // Number of input tokens used
-		OutputTokens int `json:"output_tokens"` // Number of output tokens generated
-	} `json:"usage"` // Token usage statistics
+	ID         string         `json:"id"`         // Unique identifier for the completion
+	Type       string         `json:"type"`       // Type of completion
+	Completion string         `json:"completion"` // Generated completion text
+	Model      string         `json:"model"`      // Model used for the completion
+	Usage      AnthropicUsage `json:"usage"`      // Token usage statistics
 }
 
 // AnthropicChatResponse represents the response structure from Anthropic's chat completion API.
@@ -45,20 +40,45 @@ type AnthropicChatResponse struct {
 	Type    string `json:"type"` // Type of completion
 	Role    string `json:"role"` // Role of the message sender
 	Content []struct {
-		Type     string                 `json:"type"`               // Type of content
-		Text     string                 `json:"text,omitempty"`     // Text content
-		Thinking string                 `json:"thinking,omitempty"` // Thinking process
-		ID       string                 `json:"id"`                 // Content identifier
-		Name     string                 `json:"name"`               // Name of the content
-		Input    map[string]interface{} `json:"input"`              // Input parameters
+		Type      string                 `json:"type"`                // Type of content
+		Text      string                 `json:"text,omitempty"`      // Text content
+		Citations []AnthropicCitation    `json:"citations,omitempty"` // Sources cited by the text content
+		Thinking  string                 `json:"thinking,omitempty"`  // Thinking process
+		ID        string                 `json:"id"`                  // Content identifier
+		Name      string                 `json:"name"`                // Name of the content
+		Input     map[string]interface{} `json:"input"`               // Input parameters
 	} `json:"content"` // Array of content items
-	Model        string  `json:"model"`                   // Model used for the completion
-	StopReason   string  `json:"stop_reason,omitempty"`   // Reason for completion termination
-	StopSequence *string `json:"stop_sequence,omitempty"` // Sequence that caused completion to stop
-	Usage        struct {
-		InputTokens  int `json:"input_tokens"`  // Number of input tokens used
-		OutputTokens int `json:"output_tokens"` // Number of output tokens generated
-	} `json:"usage"` // Token usage statistics
+	Model        string         `json:"model"`                   // Model used for the completion
+	StopReason   string         `json:"stop_reason,omitempty"`   // Reason for completion termination
+	StopSequence *string        `json:"stop_sequence,omitempty"` // Sequence that caused completion to stop
+	Usage        AnthropicUsage `json:"usage"`                   // Token usage statistics
+}
+
+// AnthropicUsage represents the token usage reported by Anthropic.
+// Input tokens exclude the tokens read from or written to the prompt cache, which are reported separately.
+type AnthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`                // Number of uncached input tokens used
+	OutputTokens             int `json:"output_tokens"`               // Number of output tokens generated
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"` // Number of input tokens written to the cache
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`     // Number of input tokens read from the cache
+}
+
+// toLLMUsage converts Anthropic usage into Bifrost usage. Prompt tokens include the cached
+// tokens, as in OpenAI's usage, and the cache reads and writes are reported in the token details.
+func (usage *AnthropicUsage) toLLMUsage() *schemas.LLMUsage {
+	promptTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	llmUsage := &schemas.LLMUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      promptTokens + usage.OutputTokens,
+	}
+	if usage.CacheCreationInputTokens > 0 || usage.CacheReadInputTokens > 0 {
+		llmUsage.TokenDetails = &schemas.TokenDetails{
+			CachedTokens:        usage.CacheReadInputTokens,
+			CacheCreationTokens: usage.CacheCreationInputTokens,
+		}
+	}
+	return llmUsage
 }
 
 // AnthropicStreamEvent represents a single event in the Anthropic streaming response.
@@ -69,7 +89,7 @@ type AnthropicStreamEvent struct {
 	Index        *int                    `json:"index,omitempty"`
 	ContentBlock *AnthropicContentBlock  `json:"content_block,omitempty"`
 	Delta        *AnthropicDelta         `json:"delta,omitempty"`
-	Usage        *schemas.LLMUsage       `json:"usage,omitempty"`
+	Usage        *AnthropicUsage         `json:"usage,omitempty"`
 	Error        *AnthropicStreamError   `json:"error,omitempty"`
 }
 
@@ -83,7 +103,7 @@ type AnthropicStreamMessage struct {
 	Model        string                  `json:"model"`
 	StopReason   *string                 `json:"stop_reason"`
 	StopSequence *string                 `json:"stop_sequence"`
-	Usage        *schemas.LLMUsage       `json:"usage"`
+	Usage        *AnthropicUsage         `json:"usage"`
 }
 
 // AnthropicContentBlock represents a content block in Anthropic responses.
@@ -109,6 +129,16 @@ type AnthropicToolContent struct {
 	PageAge          *string `json:"page_age,omitempty"`
 }
 
+// AnthropicCitation is a source cited by a text content block: a location in a document of the
+// request, or a web search result.
+type AnthropicCitation struct {
+	Type          string `json:"type"`                     // char_location, page_location, content_block_location or web_search_result_location
+	CitedText     string `json:"cited_text"`               // Text of the source the block is based on
+	DocumentTitle string `json:"document_title,omitempty"` // Title of the cited document
+	Title         string `json:"title,omitempty"`          // Title of the cited web page
+	URL           string `json:"url,omitempty"`            // URL of the cited web page
+}
+
 // AnthropicDelta represents incremental updates to content blocks during streaming.
 // This includes all delta types: text_delta, input_json_delta, thinking_delta, and signature_delta.
 type AnthropicDelta struct {
@@ -198,18 +228,18 @@ func releaseAnthropicTextResponse(resp *AnthropicTextResponse) {
 // NewAnthropicProvider creates a new Anthropic provider instance.
 // It initializes the HTTP client with the provided configuration and sets up response pools.
 // The client is configured with timeouts, concurrency limits, and optional proxy settings.
-func NewAnthropicProvider(config *schemas.ProviderConfig, logger schemas.Logger) *AnthropicProvider {
+func NewAnthropicProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*AnthropicProvider, error) {
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -222,6 +252,11 @@ func NewAnthropicProvider(config *schemas.ProviderConfig, logger schemas.Logger)
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.anthropic.com"
@@ -235,7 +270,7 @@ func NewAnthropicProvider(config *schemas.ProviderConfig, logger schemas.Logger)
 		apiVersion:          "2023-06-01",
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
-	}
+	}, nil
 }
 
 // GetProviderKey returns the provider identifier for Anthropic.
@@ -243,10 +278,31 @@ func (provider *AnthropicProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Anthropic
 }
 
+// Capabilities returns the operations and features supported by the Anthropic provider.
+func (provider *AnthropicProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		TextCompletion:       true,
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Tools:                true,
+		Vision:               true,
+		Batch:                true,
+		ParallelToolCalls:    true,
+	}
+}
+
+// Warmup opens a connection to the Anthropic API so the first request skips connection setup.
+func (provider *AnthropicProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // prepareTextCompletionParams prepares text completion parameters for Anthropic's API.
 // It handles parameter mapping and conversion to the format expected by Anthropic.
 // Returns the modified parameters map.
 func (provider *AnthropicProvider) prepareTextCompletionParams(params map[string]interface{}) map[string]interface{} {
+	delete(params, "seed")                // Anthropic has no seed parameter
+	delete(params, "parallel_tool_calls") // Text completions have no tools
+
 	// Check if there is a key entry for max_tokens
 	if maxTokens, exists := params["max_tokens"]; exists {
 		// Check if max_tokens_to_sample is already present
@@ -263,12 +319,6 @@ func (provider *AnthropicProvider) prepareTextCompletionParams(params map[string
 // It constructs the API URL, sets up authentication, and processes the response.
 // Returns the response body or an error if the request fails.
 func (provider *AnthropicProvider) completeRequest(ctx context.Context, requestBody map[string]interface{}, url string, key string) ([]byte, *schemas.BifrostError) {
-	// Marshal the request body
-	jsonData, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Anthropic)
-	}
-
 	// Create the request with the JSON body
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -284,10 +334,12 @@ func (provider *AnthropicProvider) completeRequest(ctx context.Context, requestB
 	req.Header.Set("x-api-key", key)
 	req.Header.Set("anthropic-version", provider.apiVersion)
 
-	req.SetBody(jsonData)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Anthropic)
+	}
 
 	// Send the request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -301,6 +353,10 @@ func (provider *AnthropicProvider) completeRequest(ctx context.Context, requestB
 		bifrostErr := handleProviderAPIError(resp, &errorResp)
 		bifrostErr.Error.Type = &errorResp.Error.Type
 		bifrostErr.Error.Message = errorResp.Error.Message
+		// The model is the only resource of the completion endpoints that can be missing
+		if errorResp.Error.Type == "not_found_error" {
+			bifrostErr.Error.Code = StrPtr(schemas.ErrorCodeModelNotFound)
+		}
 
 		return nil, bifrostErr
 	}
@@ -317,13 +373,19 @@ func (provider *AnthropicProvider) completeRequest(ctx context.Context, requestB
 func (provider *AnthropicProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	preparedParams := provider.prepareTextCompletionParams(prepareParams(params))
 
+	// Prompts rendered from chat messages already have the Human/Assistant framing
+	prompt := fmt.Sprintf("\n\nHuman: %s\n\nAssistant:", text)
+	if rendered, _ := ctx.Value(schemas.BifrostContextKeyRenderedPrompt).(bool); rendered {
+		prompt = text
+	}
+
 	// Merge additional parameters
 	requestBody := mergeConfig(map[string]interface{}{
 		"model":  model,
-		"prompt": fmt.Sprintf("\n\nHuman: %s\n\nAssistant:", text),
+		"prompt": prompt,
 	}, preparedParams)
 
-	responseBody, err := provider.completeRequest(ctx, requestBody, provider.networkConfig.BaseURL+"/v1/complete", key.Value)
+	responseBody, err := provider.completeRequest(ctx, requestBody, requestURL(ctx, provider.networkConfig, "/v1/complete"), key.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -353,11 +415,7 @@ func (provider *AnthropicProvider) TextCompletion(ctx context.Context, model str
 				},
 			},
 		},
-		Usage: &schemas.LLMUsage{
-			PromptTokens:     response.Usage.InputTokens,
-			CompletionTokens: response.Usage.OutputTokens,
-			TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
-		},
+		Usage: response.Usage.toLLMUsage(),
 		Model: response.Model,
 		ExtraFields: schemas.BifrostResponseExtraFields{
 			Provider: schemas.Anthropic,
@@ -388,7 +446,7 @@ func (provider *AnthropicProvider) ChatCompletion(ctx context.Context, model str
 		"messages": formattedMessages,
 	}, preparedParams)
 
-	responseBody, err := provider.completeRequest(ctx, requestBody, provider.networkConfig.BaseURL+"/v1/messages", key.Value)
+	responseBody, err := provider.completeRequest(ctx, requestBody, requestURL(ctx, provider.networkConfig, "/v1/messages"), key.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -403,6 +461,7 @@ func (provider *AnthropicProvider) ChatCompletion(ctx context.Context, model str
 	}
 
 	// Create final response
+	response.stopAtToolUse(params)
 	bifrostResponse := &schemas.BifrostResponse{}
 	bifrostResponse, err = parseAnthropicResponse(response, bifrostResponse)
 	if err != nil {
@@ -463,22 +522,36 @@ func buildAnthropicImageSourceMap(imgContent *schemas.ImageURLStruct) map[string
 	return sourceMap
 }
 
+// anthropicContentParts renders message content as Anthropic text and image content parts.
+func anthropicContentParts(content schemas.MessageContent) []interface{} {
+	var parts []interface{}
+	for _, block := range normalizeMessageContent(content) {
+		if block.Text != nil {
+			parts = append(parts, map[string]interface{}{
+				"type": "text",
+				"text": *block.Text,
+			})
+		}
+		if block.ImageURL != nil {
+			parts = append(parts, map[string]interface{}{
+				"type":   "image",
+				"source": buildAnthropicImageSourceMap(block.ImageURL),
+			})
+		}
+	}
+	return parts
+}
+
 func prepareAnthropicChatRequest(messages []schemas.BifrostMessage, params *schemas.ModelParameters) ([]map[string]interface{}, map[string]interface{}) {
 	// Add system messages if present
 	var systemMessages []BedrockAnthropicSystemMessage
 	for _, msg := range messages {
 		if msg.Role == schemas.ModelChatMessageRoleSystem {
-			if msg.Content.ContentStr != nil {
-				systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
-					Text: *msg.Content.ContentStr,
-				})
-			} else if msg.Content.ContentBlocks != nil {
-				for _, block := range *msg.Content.ContentBlocks {
-					if block.Text != nil {
-						systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
-							Text: *block.Text,
-						})
-					}
+			for _, block := range normalizeMessageContent(msg.Content) {
+				if block.Text != nil {
+					systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
+						Text: *block.Text,
+					})
 				}
 			}
 		}
@@ -496,52 +569,13 @@ func prepareAnthropicChatRequest(messages []schemas.BifrostMessage, params *sche
 					"tool_use_id": *msg.ToolMessage.ToolCallID,
 				}
 
-				var toolCallResultContent []map[string]interface{}
-
-				if msg.Content.ContentStr != nil {
-					toolCallResultContent = append(toolCallResultContent, map[string]interface{}{
-						"type": "text",
-						"text": *msg.Content.ContentStr,
-					})
-				} else if msg.Content.ContentBlocks != nil {
-					for _, block := range *msg.Content.ContentBlocks {
-						if block.Text != nil {
-							toolCallResultContent = append(toolCallResultContent, map[string]interface{}{
-								"type": "text",
-								"text": *block.Text,
-							})
-						}
-					}
+				if toolCallResultContent := anthropicContentParts(msg.Content); len(toolCallResultContent) > 0 {
+					toolCallResult["content"] = toolCallResultContent
 				}
-
-				toolCallResult["content"] = toolCallResultContent
 				content = append(content, toolCallResult)
 			} else {
-				// Add text content if present
-				if msg.Content.ContentStr != nil && *msg.Content.ContentStr != "" {
-					content = append(content, map[string]interface{}{
-						"type": "text",
-						"text": *msg.Content.ContentStr,
-					})
-				} else if msg.Content.ContentBlocks != nil {
-					for _, block := range *msg.Content.ContentBlocks {
-						if block.Text != nil && *block.Text != "" {
-							content = append(content, map[string]interface{}{
-								"type": "text",
-								"text": *block.Text,
-							})
-						}
-						if block.ImageURL != nil {
-							imageSource := buildAnthropicImageSourceMap(block.ImageURL)
-							if imageSource != nil {
-								content = append(content, map[string]interface{}{
-									"type":   "image",
-									"source": imageSource,
-								})
-							}
-						}
-					}
-				}
+				// Add text and image content if present
+				content = append(content, anthropicContentParts(msg.Content)...)
 
 				// Add thinking content if present in AssistantMessage
 				if msg.AssistantMessage != nil && msg.AssistantMessage.Thought != nil {
@@ -589,6 +623,7 @@ func prepareAnthropicChatRequest(messages []schemas.BifrostMessage, params *sche
 	}
 
 	preparedParams := prepareParams(params)
+	delete(preparedParams, "seed") // Anthropic has no seed parameter
 
 	// Transform tools if present
 	if params != nil && params.Tools != nil && len(*params.Tools) > 0 {
@@ -627,6 +662,19 @@ func prepareAnthropicChatRequest(messages []schemas.BifrostMessage, params *sche
 		}
 	}
 
+	// Anthropic disables parallel tool use through the tool choice, which defaults to auto
+	delete(preparedParams, "parallel_tool_calls")
+	if params != nil && params.ParallelToolCalls != nil && !*params.ParallelToolCalls && params.Tools != nil && len(*params.Tools) > 0 {
+		toolChoice, ok := preparedParams["tool_choice"].(map[string]interface{})
+		if !ok {
+			toolChoice = map[string]interface{}{"type": "auto"}
+			preparedParams["tool_choice"] = toolChoice
+		}
+		if fmt.Sprint(toolChoice["type"]) != string(schemas.ToolChoiceTypeNone) {
+			toolChoice["disable_parallel_tool_use"] = true
+		}
+	}
+
 	if len(systemMessages) > 0 {
 		var messages []string
 		for _, message := range systemMessages {
@@ -700,12 +748,28 @@ func prepareAnthropicChatRequest(messages []schemas.BifrostMessage, params *sche
 	return formattedMessages, preparedParams
 }
 
+// stopAtToolUse drops the content blocks that follow the first tool call of a response to a
+// request with StopOnToolCall, so that text the model wrote after the call is not returned.
+func (response *AnthropicChatResponse) stopAtToolUse(params *schemas.ModelParameters) {
+	if params == nil || !params.StopOnToolCall {
+		return
+	}
+	for i, content := range response.Content {
+		if content.Type == "tool_use" {
+			response.Content = response.Content[:i+1]
+			return
+		}
+	}
+}
+
 func parseAnthropicResponse(response *AnthropicChatResponse, bifrostResponse *schemas.BifrostResponse) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	// Collect all content and tool calls into a single message
 	var toolCalls []schemas.ToolCall
 	var thinking string
 
 	var contentBlocks []schemas.ContentBlock
+	var grounding schemas.GroundingMetadata
+	textLength := 0 // Length in characters of the text blocks so far, for the spans of citations
 	// Process content and tool calls
 	for _, c := range response.Content {
 		switch c.Type {
@@ -716,6 +780,15 @@ func parseAnthropicResponse(response *AnthropicChatResponse, bifrostResponse *sc
 				Type: "text",
 				Text: &c.Text,
 			})
+			start := textLength
+			textLength += utf8.RuneCountInString(c.Text)
+			for _, citation := range c.Citations {
+				grounding.Citations = append(grounding.Citations, citation.toGroundingCitation(start, textLength))
+			}
+		case "server_tool_use":
+			if query, ok := c.Input["query"].(string); ok && c.Name == "web_search" {
+				grounding.SearchQueries = append(grounding.SearchQueries, query)
+			}
 		case "tool_use":
 			function := schemas.FunctionCall{
 				Name: &c.Name,
@@ -768,16 +841,32 @@ func parseAnthropicResponse(response *AnthropicChatResponse, bifrostResponse *sc
 			FinishReason: &response.StopReason,
 		},
 	}
-	bifrostResponse.Usage = &schemas.LLMUsage{
-		PromptTokens:     response.Usage.InputTokens,
-		CompletionTokens: response.Usage.OutputTokens,
-		TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
-	}
+	bifrostResponse.Usage = response.Usage.toLLMUsage()
 	bifrostResponse.Model = response.Model
+	if len(grounding.Citations) > 0 || len(grounding.SearchQueries) > 0 {
+		bifrostResponse.Grounding = &grounding
+	}
+	applyContentFilter(bifrostResponse.Choices)
 
 	return bifrostResponse, nil
 }
 
+// toGroundingCitation converts a citation of the text block spanning the characters from start to
+// end of the response's text content.
+func (citation AnthropicCitation) toGroundingCitation(start, end int) schemas.GroundingCitation {
+	title := citation.Title
+	if title == "" {
+		title = citation.DocumentTitle
+	}
+	return schemas.GroundingCitation{
+		StartIndex: intPtr(start),
+		EndIndex:   intPtr(end),
+		Title:      title,
+		URL:        citation.URL,
+		CitedText:  citation.CitedText,
+	}
+}
+
 // Embedding is not supported by the Anthropic provider.
 func (provider *AnthropicProvider) Embedding(ctx context.Context, model string, key schemas.Key, input *schemas.EmbeddingInput, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("embedding", "anthropic")
@@ -809,7 +898,7 @@ func (provider *AnthropicProvider) ChatCompletionStream(ctx context.Context, pos
 	return handleAnthropicStreaming(
 		ctx,
 		provider.streamClient,
-		provider.networkConfig.BaseURL+"/v1/messages",
+		requestURL(ctx, provider.networkConfig, "/v1/messages"),
 		requestBody,
 		headers,
 		provider.networkConfig.ExtraHeaders,
@@ -840,6 +929,12 @@ func handleAnthropicStreaming(
 		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, providerType)
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, providerType)
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
 	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
 	if err != nil {
@@ -854,32 +949,40 @@ func handleAnthropicStreaming(
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, extraHeaders, nil)
 
+	if bifrostErr := signHTTPRequest(ctx, req, jsonBody, providerType); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
 	// Make the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, providerType)
+		return nil, newProviderRequestError(err, providerType)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		body := readStreamErrorBody(resp)
 		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerType, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerType, nil, nil)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, providerType, logger)
+		scanner := newStreamScanner(resp.Body)
 
 		// Track minimal state needed for response format
 		var messageID string
 		var modelName string
+		// Input and cache usage is reported in message_start, output usage in message_delta
+		var startUsage AnthropicUsage
 
 		// Track SSE event parsing state
 		var eventType string
@@ -887,6 +990,7 @@ func handleAnthropicStreaming(
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines and comments
 			if line == "" || strings.HasPrefix(line, ":") {
@@ -919,6 +1023,9 @@ func handleAnthropicStreaming(
 				if event.Message != nil {
 					messageID = event.Message.ID
 					modelName = event.Message.Model
+					if event.Message.Usage != nil {
+						startUsage = *event.Message.Usage
+					}
 				}
 
 			case "content_block_start":
@@ -1140,11 +1247,18 @@ func handleAnthropicStreaming(
 
 				// Send usage information immediately if present
 				if event.Usage != nil {
+					usage := *event.Usage
+					if usage.InputTokens == 0 && usage.CacheCreationInputTokens == 0 && usage.CacheReadInputTokens == 0 {
+						usage.InputTokens = startUsage.InputTokens
+						usage.CacheCreationInputTokens = startUsage.CacheCreationInputTokens
+						usage.CacheReadInputTokens = startUsage.CacheReadInputTokens
+					}
+
 					streamResponse := &schemas.BifrostResponse{
 						ID:     messageID,
 						Object: "chat.completion.chunk",
 						Model:  modelName,
-						Usage:  event.Usage,
+						Usage:  usage.toLLMUsage(),
 						Choices: []schemas.BifrostResponseChoice{
 							{
 								Index: 0,
@@ -1159,6 +1273,8 @@ func handleAnthropicStreaming(
 						},
 					}
 
+					applyContentFilter(streamResponse.Choices)
+
 					if params != nil {
 						streamResponse.ExtraFields.Params = *params
 					}
@@ -1198,6 +1314,8 @@ func handleAnthropicStreaming(
 					},
 				}
 
+				applyContentFilter(streamResponse.Choices)
+
 				if params != nil {
 					streamResponse.ExtraFields.Params = *params
 				}
@@ -1252,7 +1370,7 @@ func handleAnthropicStreaming(
 		}
 
 		if err := scanner.Err(); err != nil {
-			logger.Warn(fmt.Sprintf("Error reading %s stream: %v", providerType, err))
+			logStreamReadError(ctx, logger, fmt.Sprintf("%s stream", providerType), err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
@@ -0,0 +1,242 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the Anthropic message batches API implementation.
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// AnthropicMessageBatch represents a message batch object returned by the Anthropic API.
+type AnthropicMessageBatch struct {
+	ID                string  `json:"id"`
+	ProcessingStatus  string  `json:"processing_status"` // in_progress, canceling or ended
+	CreatedAt         string  `json:"created_at"`
+	ExpiresAt         string  `json:"expires_at"`
+	CancelInitiatedAt *string `json:"cancel_initiated_at"`
+	ResultsURL        *string `json:"results_url"`
+	RequestCounts     struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+}
+
+// AnthropicBatchResultLine represents one line of a message batch results file.
+type AnthropicBatchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string          `json:"type"` // succeeded, errored, canceled or expired
+		Message json.RawMessage `json:"message"`
+		Error   *AnthropicError `json:"error"`
+	} `json:"result"`
+}
+
+// CreateBatch submits the items as a message batch.
+func (provider *AnthropicProvider) CreateBatch(ctx context.Context, model string, key schemas.Key, items []schemas.BatchRequestItem) (*schemas.BatchJob, *schemas.BifrostError) {
+	requests := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		formattedMessages, preparedParams := prepareAnthropicChatRequest(item.Messages, item.Params)
+
+		requests = append(requests, map[string]interface{}{
+			"custom_id": item.CustomID,
+			"params": mergeConfig(map[string]interface{}{
+				"model":    model,
+				"messages": formattedMessages,
+			}, preparedParams),
+		})
+	}
+
+	jsonBody, err := sonic.Marshal(map[string]interface{}{
+		"requests": requests,
+	})
+	if err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Anthropic)
+	}
+
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodPost, resolveBaseURL(ctx, provider.networkConfig.BaseURL)+"/v1/messages/batches", jsonBody)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseAnthropicMessageBatch(responseBody, model)
+}
+
+// GetBatch returns the current state of a message batch.
+func (provider *AnthropicProvider) GetBatch(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodGet, resolveBaseURL(ctx, provider.networkConfig.BaseURL)+"/v1/messages/batches/"+batch.ID, nil)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseAnthropicMessageBatch(responseBody, batch.Model)
+}
+
+// CancelBatch requests the cancellation of a message batch.
+func (provider *AnthropicProvider) CancelBatch(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodPost, resolveBaseURL(ctx, provider.networkConfig.BaseURL)+"/v1/messages/batches/"+batch.ID+"/cancel", nil)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseAnthropicMessageBatch(responseBody, batch.Model)
+}
+
+// GetBatchResults downloads the results file of an ended message batch and converts each line
+// into a BatchResult. The batch must have been fetched after it ended, so that its results URL is set.
+func (provider *AnthropicProvider) GetBatchResults(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) ([]schemas.BatchResult, *schemas.BifrostError) {
+	if batch.ResultsLocation == "" {
+		return nil, newBifrostOperationError(fmt.Sprintf("results of batch %s are not available (status: %s)", batch.ID, batch.Status), nil, schemas.Anthropic)
+	}
+
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodGet, batch.ResultsLocation, nil)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	lines := splitJSONLines(responseBody)
+	results := make([]schemas.BatchResult, 0, len(lines))
+	for _, line := range lines {
+		var resultLine AnthropicBatchResultLine
+		if err := sonic.Unmarshal(line, &resultLine); err != nil {
+			return nil, newBifrostOperationError(schemas.ErrProviderResponseUnmarshal, err, schemas.Anthropic)
+		}
+		results = append(results, provider.parseAnthropicBatchResult(&resultLine))
+	}
+
+	return results, nil
+}
+
+// parseAnthropicBatchResult converts one line of a message batch results file into a BatchResult.
+func (provider *AnthropicProvider) parseAnthropicBatchResult(resultLine *AnthropicBatchResultLine) schemas.BatchResult {
+	result := schemas.BatchResult{CustomID: resultLine.CustomID}
+
+	switch resultLine.Result.Type {
+	case "succeeded":
+		response := &AnthropicChatResponse{}
+		rawResponse, bifrostErr := handleProviderResponse(resultLine.Result.Message, response, provider.sendBackRawResponse)
+		if bifrostErr != nil {
+			result.Error = bifrostErr
+			return result
+		}
+
+		bifrostResponse, bifrostErr := parseAnthropicResponse(response, &schemas.BifrostResponse{})
+		if bifrostErr != nil {
+			result.Error = bifrostErr
+			return result
+		}
+		bifrostResponse.ExtraFields.Provider = schemas.Anthropic
+		if provider.sendBackRawResponse {
+			bifrostResponse.ExtraFields.RawResponse = rawResponse
+		}
+		result.Response = bifrostResponse
+	case "errored":
+		if resultLine.Result.Error != nil {
+			result.Error = newBatchItemError(resultLine.Result.Error.Error.Message, resultLine.Result.Error.Error.Type, schemas.Anthropic)
+		} else {
+			result.Error = newBatchItemError("batch request failed", "", schemas.Anthropic)
+		}
+	default:
+		// canceled or expired before the request was processed
+		result.Error = newBatchItemError(fmt.Sprintf("batch request was not processed (%s)", resultLine.Result.Type), resultLine.Result.Type, schemas.Anthropic)
+	}
+
+	return result
+}
+
+// batchRequest sends a request to the Anthropic message batches API and returns a copy of the response body.
+func (provider *AnthropicProvider) batchRequest(ctx context.Context, key schemas.Key, method string, url string, body []byte) ([]byte, *schemas.BifrostError) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	// Set any extra headers from network config
+	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(method)
+	req.Header.Set("x-api-key", key.Value)
+	req.Header.Set("anthropic-version", provider.apiVersion)
+	if body != nil {
+		req.Header.SetContentType("application/json")
+		req.SetBody(body)
+	}
+
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	// Handle error response
+	if resp.StatusCode() != fasthttp.StatusOK {
+		provider.logger.Debug(fmt.Sprintf("error from anthropic provider: %s", string(resp.Body())))
+
+		var errorResp AnthropicError
+
+		bifrostErr := handleProviderAPIError(resp, &errorResp)
+		bifrostErr.Error.Type = &errorResp.Error.Type
+		bifrostErr.Error.Message = errorResp.Error.Message
+
+		return nil, bifrostErr
+	}
+
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// parseAnthropicMessageBatch converts an Anthropic message batch object into a BatchJob.
+func parseAnthropicMessageBatch(responseBody []byte, model string) (*schemas.BatchJob, *schemas.BifrostError) {
+	var batch AnthropicMessageBatch
+	if err := sonic.Unmarshal(responseBody, &batch); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderResponseUnmarshal, err, schemas.Anthropic)
+	}
+
+	counts := batch.RequestCounts
+	job := &schemas.BatchJob{
+		ID:        batch.ID,
+		Provider:  schemas.Anthropic,
+		Model:     model,
+		CreatedAt: parseAnthropicTimestamp(batch.CreatedAt),
+		ExpiresAt: parseAnthropicTimestamp(batch.ExpiresAt),
+		RequestCounts: schemas.BatchRequestCounts{
+			Total:     counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+			Completed: counts.Succeeded,
+			Failed:    counts.Errored + counts.Canceled + counts.Expired,
+		},
+	}
+	if batch.ResultsURL != nil {
+		job.ResultsLocation = *batch.ResultsURL
+	}
+
+	switch batch.ProcessingStatus {
+	case "canceling":
+		job.Status = schemas.BatchStatusCancelling
+	case "ended":
+		if batch.CancelInitiatedAt != nil {
+			job.Status = schemas.BatchStatusCancelled
+		} else {
+			job.Status = schemas.BatchStatusCompleted
+		}
+	default:
+		job.Status = schemas.BatchStatusInProgress
+	}
+
+	return job, nil
+}
+
+// parseAnthropicTimestamp converts an RFC 3339 timestamp into Unix seconds, or 0 if it cannot be parsed.
+func parseAnthropicTimestamp(value string) int64 {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
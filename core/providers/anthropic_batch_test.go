@@ -0,0 +1,140 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func newTestAnthropicBatchProvider(handler http.HandlerFunc) (*AnthropicProvider, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	provider, _ := NewAnthropicProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	return provider, server
+}
+
+func TestAnthropicCreateBatch(t *testing.T) {
+	var body struct {
+		Requests []struct {
+			CustomID string                 `json:"custom_id"`
+			Params   map[string]interface{} `json:"params"`
+		} `json:"requests"`
+	}
+	provider, server := newTestAnthropicBatchProvider(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages/batches" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("headers = %v, want the key and API version", r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress","created_at":"2024-09-24T18:37:24.100435Z","expires_at":"2024-09-25T18:37:24.100435Z","request_counts":{"processing":2}}`)
+	})
+	defer server.Close()
+
+	items := []schemas.BatchRequestItem{
+		{CustomID: "a", Messages: []schemas.BifrostMessage{schemas.SystemMessage("Be brief."), schemas.UserMessage("Hello")}},
+		{CustomID: "b", Messages: []schemas.BifrostMessage{schemas.UserMessage("Bonjour")}, Params: &schemas.ModelParameters{MaxTokens: intPtr(10)}},
+	}
+	job, bifrostErr := provider.CreateBatch(context.Background(), "claude-3-5-sonnet", schemas.Key{Value: "sk-ant-test"}, items)
+	if bifrostErr != nil {
+		t.Fatalf("CreateBatch() error = %+v", bifrostErr)
+	}
+
+	// Each item is sent as the params of a Messages API request
+	if len(body.Requests) != 2 || body.Requests[0].CustomID != "a" || body.Requests[1].CustomID != "b" {
+		t.Fatalf("requests = %+v, want the two items", body.Requests)
+	}
+	first, second := body.Requests[0].Params, body.Requests[1].Params
+	if first["model"] != "claude-3-5-sonnet" || first["system"] == nil || len(first["messages"].([]interface{})) != 1 {
+		t.Errorf("first params = %v, want the system prompt apart from the messages", first)
+	}
+	if second["max_tokens"] != float64(10) {
+		t.Errorf("second params = %v, want max_tokens 10", second)
+	}
+
+	if job.ID != "msgbatch_1" || job.Provider != schemas.Anthropic || job.Model != "claude-3-5-sonnet" || job.Status != schemas.BatchStatusInProgress ||
+		job.CreatedAt != 1727203044 || job.ExpiresAt != 1727289444 || job.RequestCounts.Total != 2 {
+		t.Errorf("job = %+v, want the created batch", job)
+	}
+}
+
+func TestParseAnthropicMessageBatchStatus(t *testing.T) {
+	for _, tt := range []struct {
+		batch string
+		want  schemas.BatchStatus
+	}{
+		{`{"processing_status":"in_progress"}`, schemas.BatchStatusInProgress},
+		{`{"processing_status":"canceling","cancel_initiated_at":"2024-09-24T18:40:00Z"}`, schemas.BatchStatusCancelling},
+		{`{"processing_status":"ended"}`, schemas.BatchStatusCompleted},
+		{`{"processing_status":"ended","cancel_initiated_at":"2024-09-24T18:40:00Z"}`, schemas.BatchStatusCancelled},
+	} {
+		job, bifrostErr := parseAnthropicMessageBatch([]byte(tt.batch), "claude-3-5-sonnet")
+		if bifrostErr != nil || job.Status != tt.want {
+			t.Errorf("parseAnthropicMessageBatch(%s) = %+v, %+v, want status %s", tt.batch, job, bifrostErr, tt.want)
+		}
+	}
+
+	// Canceled and expired requests count as failed
+	job, _ := parseAnthropicMessageBatch([]byte(`{"processing_status":"ended","results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_1/results","request_counts":{"succeeded":3,"errored":1,"canceled":1,"expired":1}}`), "")
+	if job.RequestCounts != (schemas.BatchRequestCounts{Total: 6, Completed: 3, Failed: 3}) || job.ResultsLocation == "" {
+		t.Errorf("job = %+v, want 6 requests of which 3 failed, and the results URL", job)
+	}
+}
+
+func TestAnthropicBatchResults(t *testing.T) {
+	var server *httptest.Server
+	provider, server := newTestAnthropicBatchProvider(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/messages/batches/msgbatch_1":
+			fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"ended","results_url":"%s/v1/messages/batches/msgbatch_1/results","request_counts":{"succeeded":1,"errored":1,"expired":1}}`, server.URL)
+		case "/v1/messages/batches/msgbatch_1/results":
+			fmt.Fprintln(w, `{"custom_id":"a","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":2}}}}`)
+			fmt.Fprintln(w, `{"custom_id":"b","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: Field required"}}}}`)
+			fmt.Fprintln(w, `{"custom_id":"c","result":{"type":"expired"}}`)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`)
+		}
+	})
+	defer server.Close()
+	key := schemas.Key{Value: "sk-ant-test"}
+
+	// Results are only available once the batch ended
+	if _, bifrostErr := provider.GetBatchResults(context.Background(), key, &schemas.BatchJob{ID: "msgbatch_1", Status: schemas.BatchStatusInProgress}); bifrostErr == nil {
+		t.Error("GetBatchResults() of a batch in progress succeeded, want an error")
+	}
+
+	job, bifrostErr := provider.GetBatch(context.Background(), key, &schemas.BatchJob{ID: "msgbatch_1"})
+	if bifrostErr != nil {
+		t.Fatalf("GetBatch() error = %+v", bifrostErr)
+	}
+	results, bifrostErr := provider.GetBatchResults(context.Background(), key, job)
+	if bifrostErr != nil {
+		t.Fatalf("GetBatchResults() error = %+v", bifrostErr)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if a := results[0]; a.Error != nil || a.Response == nil || *(*a.Response.Choices[0].Message.Content.ContentBlocks)[0].Text != "Hi" || a.Response.Usage.CompletionTokens != 2 {
+		t.Errorf("result a = %+v, want the message", a)
+	}
+	if b := results[1]; b.Response != nil || b.Error == nil || b.Error.Error.Message != "max_tokens: Field required" || *b.Error.Error.Type != "invalid_request_error" {
+		t.Errorf("result b = %+v, want the request's error", b.Error)
+	}
+	if c := results[2]; c.Error == nil || *c.Error.Error.Type != "expired" {
+		t.Errorf("result c = %+v, want the expired request", c.Error)
+	}
+
+	// API errors are returned with their status code and type
+	_, bifrostErr = provider.CancelBatch(context.Background(), key, job)
+	if bifrostErr == nil || bifrostErr.StatusCode == nil || *bifrostErr.StatusCode != 429 || *bifrostErr.Error.Type != "rate_limit_error" {
+		t.Errorf("CancelBatch() error = %+v, want the rate limit error", bifrostErr)
+	}
+}
@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestPrepareAnthropicChatRequestParallelToolCalls(t *testing.T) {
+	tools := []schemas.Tool{{Type: "function", Function: schemas.Function{Name: "get_weather"}}}
+	toolChoiceAny := "any"
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name       string
+		params     *schemas.ModelParameters
+		toolChoice interface{}
+	}{
+		{
+			name:       "disabled without tool choice",
+			params:     &schemas.ModelParameters{Tools: &tools, ParallelToolCalls: &disabled},
+			toolChoice: map[string]interface{}{"type": "auto", "disable_parallel_tool_use": true},
+		},
+		{
+			name:       "disabled with tool choice",
+			params:     &schemas.ModelParameters{Tools: &tools, ToolChoice: &schemas.ToolChoice{ToolChoiceStr: &toolChoiceAny}, ParallelToolCalls: &disabled},
+			toolChoice: map[string]interface{}{"type": "any", "disable_parallel_tool_use": true},
+		},
+		{
+			name:       "enabled is the default",
+			params:     &schemas.ModelParameters{Tools: &tools, ParallelToolCalls: &enabled},
+			toolChoice: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, preparedParams := prepareAnthropicChatRequest(nil, tt.params)
+
+			if _, exists := preparedParams["parallel_tool_calls"]; exists {
+				t.Errorf("parallel_tool_calls should not be sent to Anthropic")
+			}
+			if got := preparedParams["tool_choice"]; !reflect.DeepEqual(got, tt.toolChoice) {
+				t.Errorf("tool_choice = %v, want %v", got, tt.toolChoice)
+			}
+		})
+	}
+}
+
+func TestAnthropicUsageIncludesCacheTokens(t *testing.T) {
+	usage := AnthropicUsage{InputTokens: 10, OutputTokens: 5, CacheCreationInputTokens: 100, CacheReadInputTokens: 1000}
+
+	got := usage.toLLMUsage()
+	want := &schemas.LLMUsage{
+		PromptTokens:     1110,
+		CompletionTokens: 5,
+		TotalTokens:      1115,
+		TokenDetails:     &schemas.TokenDetails{CachedTokens: 1000, CacheCreationTokens: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	uncached := (&AnthropicUsage{InputTokens: 10, OutputTokens: 5}).toLLMUsage()
+	if uncached.TokenDetails != nil {
+		t.Fatalf("expected no token details without cache usage, got %+v", uncached.TokenDetails)
+	}
+}
+
+func TestParseAnthropicResponseGrounding(t *testing.T) {
+	body := `{"content":[
+		{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"bifrost gateway"}},
+		{"type":"text","text":"Bifrost is "},
+		{"type":"text","text":"an LLM gateway.","citations":[{"type":"web_search_result_location","url":"https://example.com/bifrost","title":"Bifrost","cited_text":"Bifrost, the LLM gateway"}]}
+	],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`
+	var response AnthropicChatResponse
+	if err := sonic.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	result, bifrostErr := parseAnthropicResponse(&response, &schemas.BifrostResponse{})
+	if bifrostErr != nil {
+		t.Fatalf("parseAnthropicResponse() error = %+v", bifrostErr)
+	}
+	want := &schemas.GroundingMetadata{
+		Citations: []schemas.GroundingCitation{{
+			StartIndex: intPtr(11),
+			EndIndex:   intPtr(26),
+			Title:      "Bifrost",
+			URL:        "https://example.com/bifrost",
+			CitedText:  "Bifrost, the LLM gateway",
+		}},
+		SearchQueries: []string{"bifrost gateway"},
+	}
+	if !reflect.DeepEqual(result.Grounding, want) {
+		t.Errorf("grounding = %+v, want %+v", result.Grounding, want)
+	}
+}
+
+func TestAnthropicStopAtToolUse(t *testing.T) {
+	body := `{"content":[
+		{"type":"text","text":"Let me check."},
+		{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}},
+		{"type":"text","text":"It is sunny in Paris."}
+	],"stop_reason":"tool_use","usage":{"input_tokens":1,"output_tokens":1}}`
+	var response AnthropicChatResponse
+	if err := sonic.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	response.stopAtToolUse(&schemas.ModelParameters{StopOnToolCall: true})
+	result, bifrostErr := parseAnthropicResponse(&response, &schemas.BifrostResponse{})
+	if bifrostErr != nil {
+		t.Fatalf("parseAnthropicResponse() error = %+v", bifrostErr)
+	}
+
+	// The text written after the tool call is dropped
+	message := result.Choices[0].Message
+	if blocks := *message.Content.ContentBlocks; len(blocks) != 1 || *blocks[0].Text != "Let me check." {
+		t.Errorf("content = %+v, want only the text before the tool call", blocks)
+	}
+	if message.AssistantMessage == nil || len(*message.ToolCalls) != 1 {
+		t.Errorf("tool calls = %+v, want the tool call", message.AssistantMessage)
+	}
+}
+
+func TestAnthropicModelNotFoundError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-2.0"}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewAnthropicProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider() error = %v", err)
+	}
+	_, bifrostErr := provider.ChatCompletion(context.Background(), "claude-2.0", schemas.Key{Value: "sk-ant-test"}, []schemas.BifrostMessage{schemas.UserMessage("Hello")}, nil)
+	if bifrostErr == nil || bifrostErr.Error.Code == nil || *bifrostErr.Error.Code != schemas.ErrorCodeModelNotFound {
+		t.Errorf("ChatCompletion() error = %+v, want a model_not_found error", bifrostErr)
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
-	"time"
 
 	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -43,6 +43,16 @@ type AzureChatResponse struct {
 	Usage             schemas.LLMUsage                `json:"usage"`              // Token usage statistics
 }
 
+// AzureContentFilterResults represents the content filter results Azure reports for each choice,
+// keyed by category (e.g. "hate", "sexual", "violence", "self_harm").
+type AzureContentFilterResults struct {
+	Choices []struct {
+		ContentFilterResults map[string]struct {
+			Filtered bool `json:"filtered"`
+		} `json:"content_filter_results"`
+	} `json:"choices"`
+}
+
 // AzureEmbeddingResponse represents the response structure from Azure's embedding API.
 type AzureEmbeddingResponse struct {
 	Object string `json:"object"`
@@ -127,14 +137,14 @@ func NewAzureProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*A
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -147,6 +157,11 @@ func NewAzureProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*A
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	return &AzureProvider{
 		logger:              logger,
 		client:              client,
@@ -161,6 +176,22 @@ func (provider *AzureProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Azure
 }
 
+// Capabilities returns the operations and features supported by the Azure provider.
+func (provider *AzureProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		TextCompletion:       true,
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Embedding:            true,
+		Tools:                true,
+		Vision:               true,
+		Seed:                 true,
+		LogitBias:            true,
+		ParallelToolCalls:    true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
 // completeRequest sends a request to Azure's API and handles the response.
 // It constructs the API URL, sets up authentication, and processes the response.
 // Returns the response body or an error if the request fails.
@@ -169,12 +200,6 @@ func (provider *AzureProvider) completeRequest(ctx context.Context, requestBody
 		return nil, newConfigurationError("azure key config not set", schemas.Azure)
 	}
 
-	// Marshal the request body
-	jsonData, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Azure)
-	}
-
 	if key.AzureKeyConfig.Endpoint == "" {
 		return nil, newConfigurationError("endpoint not set", schemas.Azure)
 	}
@@ -217,10 +242,12 @@ func (provider *AzureProvider) completeRequest(ctx context.Context, requestBody
 		req.Header.Set("api-key", key.Value)
 	}
 
-	req.SetBody(jsonData)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Azure)
+	}
 
 	// Send the request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -249,6 +276,9 @@ func (provider *AzureProvider) completeRequest(ctx context.Context, requestBody
 // Returns a BifrostResponse containing the completion results or an error if the request fails.
 func (provider *AzureProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	preparedParams := prepareParams(params)
+	if params != nil && len(params.LogitBias) > 0 {
+		preparedParams["logit_bias"] = params.LogitBias
+	}
 
 	// Merge additional parameters
 	requestBody := mergeConfig(map[string]interface{}{
@@ -290,6 +320,8 @@ func (provider *AzureProvider) TextCompletion(ctx context.Context, model string,
 			FinishReason: response.Choices[0].FinishReason,
 		})
 	}
+	setAzureContentFilterCategories(responseBody, choices)
+	applyContentFilter(choices)
 
 	// Create final response
 	bifrostResponse := &schemas.BifrostResponse{
@@ -342,6 +374,9 @@ func (provider *AzureProvider) ChatCompletion(ctx context.Context, model string,
 		return nil, bifrostErr
 	}
 
+	setAzureContentFilterCategories(responseBody, response.Choices)
+	applyContentFilter(response.Choices)
+
 	// Create final response
 	bifrostResponse := &schemas.BifrostResponse{
 		ID:                response.ID,
@@ -350,6 +385,7 @@ func (provider *AzureProvider) ChatCompletion(ctx context.Context, model string,
 		Created:           response.Created,
 		SystemFingerprint: response.SystemFingerprint,
 		Usage:             &response.Usage,
+		Grounding:         groundingFromAnnotations(response.Choices),
 		ExtraFields: schemas.BifrostResponseExtraFields{
 			Provider: schemas.Azure,
 		},
@@ -386,6 +422,9 @@ func (provider *AzureProvider) Embedding(ctx context.Context, model string, key
 			requestBody["encoding_format"] = *params.EncodingFormat
 		}
 		if params.Dimensions != nil {
+			if bifrostErr := validateEmbeddingDimensions(model, *params.Dimensions, schemas.Azure); bifrostErr != nil {
+				return nil, bifrostErr
+			}
 			requestBody["dimensions"] = *params.Dimensions
 		}
 		if params.User != nil {
@@ -419,34 +458,13 @@ func (provider *AzureProvider) Embedding(ctx context.Context, model string, key
 
 	// Extract embeddings from response data
 	if len(response.Data) > 0 {
-		embeddings := make([][]float32, len(response.Data))
+		values := make([]interface{}, len(response.Data))
 		for i, data := range response.Data {
-			switch v := data.Embedding.(type) {
-			case []float32:
-				embeddings[i] = v
-			case []float64:
-				// Direct conversion from []float64 to []float32
-				floatArray := make([]float32, len(v))
-				for j := range v {
-					floatArray[j] = float32(v[j])
-				}
-				embeddings[i] = floatArray
-			case []interface{}:
-				// Fallback: element-by-element conversion for []interface{}
-				floatArray := make([]float32, len(v))
-				for j := range v {
-					if num, ok := v[j].(float64); ok {
-						floatArray[j] = float32(num)
-					} else {
-						return nil, newBifrostOperationError(fmt.Sprintf("unsupported number type in embedding array: %T", v[j]), nil, schemas.Azure)
-					}
-				}
-				embeddings[i] = floatArray
-			default:
-				return nil, newBifrostOperationError(fmt.Sprintf("unsupported embedding type: %T", data.Embedding), nil, schemas.Azure)
-			}
+			values[i] = data.Embedding
+		}
+		if bifrostErr := setEmbeddings(bifrostResponse, values, schemas.Azure); bifrostErr != nil {
+			return nil, bifrostErr
 		}
-		bifrostResponse.Embedding = embeddings
 	}
 
 	if params != nil {
@@ -541,3 +559,42 @@ func (provider *AzureProvider) Transcription(ctx context.Context, model string,
 func (provider *AzureProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, model string, key schemas.Key, input *schemas.TranscriptionInput, params *schemas.ModelParameters) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("transcription stream", "azure")
 }
+
+// setAzureContentFilterCategories records the categories that triggered Azure's content filter
+// on the choices that were filtered.
+func setAzureContentFilterCategories(responseBody []byte, choices []schemas.BifrostResponseChoice) {
+	filtered := false
+	for _, choice := range choices {
+		if choice.FinishReason != nil && *choice.FinishReason == schemas.FinishReasonContentFilter {
+			filtered = true
+			break
+		}
+	}
+	if !filtered {
+		return
+	}
+
+	var results AzureContentFilterResults
+	if err := sonic.Unmarshal(responseBody, &results); err != nil {
+		return
+	}
+
+	for i := range choices {
+		if i >= len(results.Choices) || choices[i].FinishReason == nil || *choices[i].FinishReason != schemas.FinishReasonContentFilter {
+			continue
+		}
+
+		var categories []string
+		for category, result := range results.Choices[i].ContentFilterResults {
+			if result.Filtered {
+				categories = append(categories, category)
+			}
+		}
+		sort.Strings(categories)
+
+		choices[i].ContentFilter = &schemas.ContentFilterResult{
+			Reason:     *choices[i].FinishReason,
+			Categories: categories,
+		}
+	}
+}
@@ -14,12 +14,12 @@ import (
 	"maps"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"bufio"
-
 	"github.com/aws/aws-sdk-go-v2/aws"
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -60,12 +60,35 @@ type BedrockChatResponse struct {
 			Role string `json:"role"` // Role of the message sender
 		} `json:"message"` // Message structure
 	} `json:"output"` // Output structure
-	StopReason string `json:"stopReason"` // Reason for completion termination
-	Usage      struct {
-		InputTokens  int `json:"inputTokens"`  // Number of input tokens used
-		OutputTokens int `json:"outputTokens"` // Number of output tokens generated
-		TotalTokens  int `json:"totalTokens"`  // Total number of tokens used
-	} `json:"usage"` // Token usage statistics
+	StopReason string       `json:"stopReason"` // Reason for completion termination
+	Usage      BedrockUsage `json:"usage"`      // Token usage statistics
+}
+
+// BedrockUsage represents the token usage reported by the Bedrock Converse API.
+// Input tokens exclude the tokens read from or written to the prompt cache, which are reported separately.
+type BedrockUsage struct {
+	InputTokens           int `json:"inputTokens"`           // Number of uncached input tokens used
+	OutputTokens          int `json:"outputTokens"`          // Number of output tokens generated
+	TotalTokens           int `json:"totalTokens"`           // Total number of tokens used, including cached tokens
+	CacheReadInputTokens  int `json:"cacheReadInputTokens"`  // Number of input tokens read from the cache
+	CacheWriteInputTokens int `json:"cacheWriteInputTokens"` // Number of input tokens written to the cache
+}
+
+// toLLMUsage converts Bedrock usage into Bifrost usage. Prompt tokens include the cached
+// tokens, as in OpenAI's usage, and the cache reads and writes are reported in the token details.
+func (usage *BedrockUsage) toLLMUsage() *schemas.LLMUsage {
+	llmUsage := &schemas.LLMUsage{
+		PromptTokens:     usage.InputTokens + usage.CacheReadInputTokens + usage.CacheWriteInputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+	if usage.CacheReadInputTokens > 0 || usage.CacheWriteInputTokens > 0 {
+		llmUsage.TokenDetails = &schemas.TokenDetails{
+			CachedTokens:        usage.CacheReadInputTokens,
+			CacheCreationTokens: usage.CacheWriteInputTokens,
+		}
+	}
+	return llmUsage
 }
 
 // BedrockAnthropicSystemMessage represents a system message for Anthropic models.
@@ -180,11 +203,7 @@ type BedrockStreamMessageStopEvent struct {
 // BedrockStreamMetadataEvent contains metadata after streaming ends.
 type BedrockStreamMetadataEvent struct {
 	Metadata struct {
-		Usage struct {
-			InputTokens  int `json:"inputTokens"`
-			OutputTokens int `json:"outputTokens"`
-			TotalTokens  int `json:"totalTokens"`
-		} `json:"usage"`
+		Usage   BedrockUsage `json:"usage"`
 		Metrics struct {
 			LatencyMs float64 `json:"latencyMs"`
 		} `json:"metrics"`
@@ -230,8 +249,16 @@ func NewBedrockProvider(config *schemas.ProviderConfig, logger schemas.Logger) (
 	if config.MetaConfig == nil {
 		return nil, fmt.Errorf("meta config is not set")
 	}
+	if config.RequestSigner != nil {
+		return nil, fmt.Errorf("request signer is not supported by bedrock, which signs requests with AWS SigV4")
+	}
 
-	client := &http.Client{Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds)}
+	client := &http.Client{Timeout: config.NetworkConfig.MaxRequestTimeout()}
+
+	// Configure TLS if provided
+	if err := configureTLS(nil, client, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
 
 	// Pre-warm response pools
 	for range config.ConcurrencyAndBufferSize.Concurrency {
@@ -253,10 +280,111 @@ func (provider *BedrockProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Bedrock
 }
 
+// Capabilities returns the operations and features supported by the Bedrock provider.
+func (provider *BedrockProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		TextCompletion:       true,
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Embedding:            true,
+		Tools:                true,
+		Vision:               true,
+	}
+}
+
+// ModelCapabilities returns the features supported for a model. Anthropic models on Bedrock have
+// no penalty parameters, Cohere models accept penalties between 0 and 1 and the others between -2 and 2.
+func (provider *BedrockProvider) ModelCapabilities(model string) schemas.ProviderCapabilities {
+	capabilities := provider.Capabilities()
+	switch baseModel := bedrockBaseModelID(model); {
+	case strings.HasPrefix(baseModel, "anthropic."):
+	case strings.HasPrefix(baseModel, "cohere."):
+		capabilities.Penalties = schemas.PenaltySupport{Additive: true, Min: 0, Max: 1}
+	default:
+		capabilities.Penalties = schemas.PenaltySupport{Additive: true, Min: -2, Max: 2}
+	}
+	return capabilities
+}
+
+// bedrockCrossRegionPrefixes are the geography prefixes of cross-region inference profile IDs,
+// e.g. "us" in "us.anthropic.claude-3-5-haiku-20241022-v1:0".
+var bedrockCrossRegionPrefixes = []string{"us", "us-gov", "eu", "apac", "ca", "jp", "au", "global"}
+
+// parseBedrockModel reduces a model identifier to a model or inference profile ID and returns its
+// cross-region profile prefix, if any. Inference profile and foundation model ARNs are reduced to
+// the ID after the resource type, and their region is returned.
+func parseBedrockModel(model string) (id string, profilePrefix string, arnRegion string) {
+	id = model
+	if strings.HasPrefix(model, "arn:") {
+		// arn:aws:bedrock:<region>:<account>:<resource-type>/<id>
+		if parts := strings.SplitN(model, ":", 6); len(parts) == 6 {
+			arnRegion = parts[3]
+			if _, resourceID, found := strings.Cut(parts[5], "/"); found {
+				id = resourceID
+			}
+		}
+	}
+
+	if prefix, _, found := strings.Cut(id, "."); found && slices.Contains(bedrockCrossRegionPrefixes, prefix) {
+		profilePrefix = prefix
+	}
+
+	return id, profilePrefix, arnRegion
+}
+
+// bedrockBaseModelID returns the foundation model ID behind a model identifier, stripping the
+// cross-region prefix of inference profiles, so request formats can be chosen per model family.
+func bedrockBaseModelID(model string) string {
+	id, profilePrefix, _ := parseBedrockModel(model)
+	if profilePrefix != "" {
+		return strings.TrimPrefix(id, profilePrefix+".")
+	}
+	return id
+}
+
+// getRegion returns the region to invoke a model in. Inference profile ARNs are invoked in their own
+// region and cross-region profiles in the source region configured for their prefix; everything else
+// uses the configured region (us-east-1 by default).
+func (provider *BedrockProvider) getRegion(model string) string {
+	_, profilePrefix, arnRegion := parseBedrockModel(model)
+	if arnRegion != "" {
+		return arnRegion
+	}
+
+	if provider.meta == nil {
+		return "us-east-1"
+	}
+
+	if profilePrefix != "" {
+		if region, ok := provider.meta.GetCrossRegionSourceRegions()[profilePrefix]; ok && region != "" {
+			return region
+		}
+	}
+
+	if provider.meta.GetRegion() != nil {
+		return *provider.meta.GetRegion()
+	}
+	return "us-east-1"
+}
+
+// getModelPath returns the escaped model identifier followed by the invocation action (e.g. "converse").
+// Models mapped to an inference profile in the meta config are invoked through the profile's ARN.
+func (provider *BedrockProvider) getModelPath(model string, action string) string {
+	modelIdentifier := model
+	if provider.meta != nil && provider.meta.GetInferenceProfiles() != nil && provider.meta.GetARN() != nil {
+		if inferenceProfileId, ok := provider.meta.GetInferenceProfiles()[model]; ok {
+			modelIdentifier = fmt.Sprintf("%s/%s", *provider.meta.GetARN(), inferenceProfileId)
+		}
+	}
+
+	// Properly escape the model identifier for URL path to ensure AWS SIGv4 signing works correctly
+	return url.PathEscape(modelIdentifier) + "/" + action
+}
+
 // CompleteRequest sends a request to Bedrock's API and handles the response.
 // It constructs the API URL, sets up AWS authentication, and processes the response.
 // Returns the response body or an error if the request fails.
-func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBody map[string]interface{}, path string, accessKey string) ([]byte, *schemas.BifrostError) {
+func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBody map[string]interface{}, region string, path string, accessKey string) ([]byte, *schemas.BifrostError) {
 	if provider.meta == nil {
 		return nil, &schemas.BifrostError{
 			IsBifrostError: false,
@@ -266,11 +394,6 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 		}
 	}
 
-	region := "us-east-1"
-	if provider.meta.GetRegion() != nil {
-		region = *provider.meta.GetRegion()
-	}
-
 	jsonBody, err := sonic.Marshal(requestBody)
 	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
@@ -292,6 +415,12 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 		}
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create the request with the JSON body
 	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s", region, path), bytes.NewBuffer(jsonBody))
 	if err != nil {
@@ -306,6 +435,8 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setAcceptEncodingHTTP(req)
+	compressHTTPRequestBody(ctx, req, jsonBody)
 
 	if provider.meta.GetSecretAccessKey() != nil {
 		if err := signAWSRequest(req, accessKey, *provider.meta.GetSecretAccessKey(), provider.meta.GetSessionToken(), region, "bedrock"); err != nil {
@@ -323,15 +454,10 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 	// Execute the request
 	resp, err := provider.client.Do(req)
 	if err != nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: schemas.ErrProviderRequest,
-				Error:   err,
-			},
-		}
+		return nil, newProviderRequestError(err, schemas.Bedrock)
 	}
 	defer resp.Body.Close()
+	recordHTTPResponseMetadata(ctx, resp)
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
@@ -345,6 +471,16 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 		}
 	}
 
+	body, bifrostErr := decompressHTTPResponseBody(resp, body, schemas.Bedrock)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	body, transformErr = transformResponseBody(ctx, body, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		var errorResp BedrockError
 
@@ -359,12 +495,21 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 			}
 		}
 
-		return nil, &schemas.BifrostError{
+		bifrostErr := &schemas.BifrostError{
 			StatusCode: &resp.StatusCode,
 			Error: schemas.ErrorField{
 				Message: errorResp.Message,
 			},
 		}
+		// The exception name, e.g. "ResourceNotFoundException:http://internal.amazon.com/coral/com.amazon.bedrock/"
+		if errorType, _, _ := strings.Cut(resp.Header.Get("X-Amzn-Errortype"), ":"); errorType != "" {
+			bifrostErr.Error.Type = &errorType
+			// The model is the only resource of the invoke and converse endpoints that can be missing
+			if errorType == "ResourceNotFoundException" {
+				bifrostErr.Error.Code = StrPtr(schemas.ErrorCodeModelNotFound)
+			}
+		}
+		return nil, bifrostErr
 	}
 
 	return body, nil
@@ -374,7 +519,7 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 // It handles different model types (Anthropic and Mistral) and formats the response.
 // Returns a BifrostResponse containing the completion results or an error if processing fails.
 func (provider *BedrockProvider) getTextCompletionResult(result []byte, model string) (*schemas.BifrostResponse, *schemas.BifrostError) {
-	switch model {
+	switch bedrockBaseModelID(model) {
 	case "anthropic.claude-instant-v1:2":
 		fallthrough
 	case "anthropic.claude-v2":
@@ -461,6 +606,21 @@ func (provider *BedrockProvider) getTextCompletionResult(result []byte, model st
 	return nil, newConfigurationError(fmt.Sprintf("invalid model choice: %s", model), schemas.Bedrock)
 }
 
+// newBedrockAnthropicImage converts a sanitized image URL to an image block of Anthropic models.
+func newBedrockAnthropicImage(sanitizedURL string) BedrockAnthropicImage {
+	urlTypeInfo := ExtractURLTypeInfo(sanitizedURL)
+
+	image := BedrockAnthropicImage{Source: BedrockAnthropicImageSource{Bytes: sanitizedURL}}
+	if urlTypeInfo.MediaType != nil {
+		// Remove "image/" prefix if present, since normalizeMediaType ensures full format
+		image.Format = strings.TrimPrefix(*urlTypeInfo.MediaType, "image/")
+	}
+	if urlTypeInfo.DataURLWithoutPrefix != nil {
+		image.Source.Bytes = *urlTypeInfo.DataURLWithoutPrefix
+	}
+	return image
+}
+
 // parseBedrockAnthropicMessageToolCallContent parses the content of a tool call message.
 // It handles both text and JSON content.
 // Returns a map containing the parsed content.
@@ -485,7 +645,7 @@ func parseBedrockAnthropicMessageToolCallContent(content string) map[string]inte
 // It handles different model types (Anthropic and Mistral) and formats messages accordingly.
 // Returns a map containing the formatted messages and any system messages, or an error if formatting fails.
 func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schemas.BifrostMessage, model string) (map[string]interface{}, *schemas.BifrostError) {
-	switch model {
+	switch bedrockBaseModelID(model) {
 	case "anthropic.claude-instant-v1:2":
 		fallthrough
 	case "anthropic.claude-v2":
@@ -507,17 +667,11 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 		var systemMessages []BedrockAnthropicSystemMessage
 		for _, msg := range messages {
 			if msg.Role == schemas.ModelChatMessageRoleSystem {
-				if msg.Content.ContentStr != nil {
-					systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
-						Text: *msg.Content.ContentStr,
-					})
-				} else if msg.Content.ContentBlocks != nil {
-					for _, block := range *msg.Content.ContentBlocks {
-						if block.Text != nil {
-							systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
-								Text: *block.Text,
-							})
-						}
+				for _, block := range normalizeMessageContent(msg.Content) {
+					if block.Text != nil {
+						systemMessages = append(systemMessages, BedrockAnthropicSystemMessage{
+							Text: *block.Text,
+						})
 					}
 				}
 			}
@@ -533,13 +687,14 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 						"toolUseId": *msg.ToolCallID,
 					}
 					var toolResultContentBlocks []map[string]interface{}
-					if msg.Content.ContentStr != nil {
-						toolResultContentBlocks = append(toolResultContentBlocks, parseBedrockAnthropicMessageToolCallContent(*msg.Content.ContentStr))
-					} else if msg.Content.ContentBlocks != nil {
-						for _, block := range *msg.Content.ContentBlocks {
-							if block.Text != nil {
-								toolResultContentBlocks = append(toolResultContentBlocks, parseBedrockAnthropicMessageToolCallContent(*block.Text))
-							}
+					for _, block := range normalizeMessageContent(msg.Content) {
+						if block.Text != nil {
+							toolResultContentBlocks = append(toolResultContentBlocks, parseBedrockAnthropicMessageToolCallContent(*block.Text))
+						}
+						if block.ImageURL != nil {
+							toolResultContentBlocks = append(toolResultContentBlocks, map[string]interface{}{
+								"image": newBedrockAnthropicImage(block.ImageURL.URL),
+							})
 						}
 					}
 					toolCallResult["content"] = toolResultContentBlocks
@@ -566,55 +721,18 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 						}
 					}
 
-					if msg.Content.ContentStr != nil {
-						content = append(content, BedrockAnthropicTextMessage{
-							Type: "text",
-							Text: *msg.Content.ContentStr,
-						})
-					} else if msg.Content.ContentBlocks != nil {
-						for _, block := range *msg.Content.ContentBlocks {
-							if block.Text != nil {
-								content = append(content, BedrockAnthropicTextMessage{
-									Type: "text",
-									Text: *block.Text,
-								})
-							}
-							if block.ImageURL != nil {
-								sanitizedURL, _ := SanitizeImageURL(block.ImageURL.URL)
-								urlTypeInfo := ExtractURLTypeInfo(sanitizedURL)
-
-								formattedImgContent := AnthropicImageContent{
-									Type: urlTypeInfo.Type,
-								}
-
-								if urlTypeInfo.MediaType != nil {
-									formattedImgContent.MediaType = *urlTypeInfo.MediaType
-								}
-
-								if urlTypeInfo.DataURLWithoutPrefix != nil {
-									formattedImgContent.URL = *urlTypeInfo.DataURLWithoutPrefix
-								} else {
-									formattedImgContent.URL = sanitizedURL
-								}
-
-								content = append(content, BedrockAnthropicImageMessage{
-									Type: "image",
-									Image: BedrockAnthropicImage{
-										Format: func() string {
-											if formattedImgContent.MediaType != "" {
-												mediaType := formattedImgContent.MediaType
-												// Remove "image/" prefix if present, since normalizeMediaType ensures full format
-												mediaType = strings.TrimPrefix(mediaType, "image/")
-												return mediaType
-											}
-											return ""
-										}(),
-										Source: BedrockAnthropicImageSource{
-											Bytes: formattedImgContent.URL,
-										},
-									},
-								})
-							}
+					for _, block := range normalizeMessageContent(msg.Content) {
+						if block.Text != nil {
+							content = append(content, BedrockAnthropicTextMessage{
+								Type: "text",
+								Text: *block.Text,
+							})
+						}
+						if block.ImageURL != nil {
+							content = append(content, BedrockAnthropicImageMessage{
+								Type:  "image",
+								Image: newBedrockAnthropicImage(block.ImageURL.URL),
+							})
 						}
 					}
 				}
@@ -707,6 +825,11 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 	case "mistral.mistral-large-2402-v1:0":
 		fallthrough
 	case "mistral.mistral-large-2407-v1:0":
+		// Tool results of Mistral models can only carry text
+		if hasToolResultImages(messages) {
+			return nil, newUnsupportedOperationError("image content in tool results", "bedrock")
+		}
+
 		var bedrockMessages []BedrockMistralChatMessage
 		for _, msg := range messages {
 			var filteredToolCalls []BedrockMistralToolCall
@@ -725,14 +848,9 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 				Role: msg.Role,
 			}
 
-			switch {
-			case msg.Content.ContentStr != nil:
-				message.Content = []BedrockMistralContent{{Text: *msg.Content.ContentStr}}
-			case msg.Content.ContentBlocks != nil:
-				for _, b := range *msg.Content.ContentBlocks {
-					if b.Text != nil {
-						message.Content = append(message.Content, BedrockMistralContent{Text: *b.Text})
-					}
+			for _, block := range normalizeMessageContent(msg.Content) {
+				if block.Text != nil {
+					message.Content = append(message.Content, BedrockMistralContent{Text: *block.Text})
 				}
 			}
 
@@ -759,7 +877,7 @@ func (provider *BedrockProvider) prepareChatCompletionMessages(messages []schema
 func (provider *BedrockProvider) getChatCompletionTools(params *schemas.ModelParameters, model string) []BedrockAnthropicToolCall {
 	var tools []BedrockAnthropicToolCall
 
-	switch model {
+	switch bedrockBaseModelID(model) {
 	case "anthropic.claude-instant-v1:2":
 		fallthrough
 	case "anthropic.claude-v2":
@@ -799,7 +917,7 @@ func (provider *BedrockProvider) getChatCompletionTools(params *schemas.ModelPar
 // It handles parameter mapping and conversion for different model types.
 // Returns the modified parameters map with model-specific adjustments.
 func (provider *BedrockProvider) prepareTextCompletionParams(params map[string]interface{}, model string) map[string]interface{} {
-	switch model {
+	switch bedrockBaseModelID(model) {
 	case "anthropic.claude-instant-v1:2":
 		fallthrough
 	case "anthropic.claude-v2":
@@ -823,12 +941,13 @@ func (provider *BedrockProvider) prepareTextCompletionParams(params map[string]i
 // Returns a BifrostResponse containing the completion results or an error if the request fails.
 func (provider *BedrockProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	preparedParams := provider.prepareTextCompletionParams(prepareParams(params), model)
+	delete(preparedParams, "seed") // Bedrock model APIs have no seed parameter
 
 	requestBody := mergeConfig(map[string]interface{}{
 		"prompt": text,
 	}, preparedParams)
 
-	body, err := provider.completeRequest(ctx, requestBody, fmt.Sprintf("%s/invoke", model), key.Value)
+	body, err := provider.completeRequest(ctx, requestBody, provider.getRegion(model), provider.getModelPath(model, "invoke"), key.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -914,6 +1033,7 @@ func (provider *BedrockProvider) ChatCompletion(ctx context.Context, model strin
 	}
 
 	preparedParams := prepareParams(params)
+	delete(preparedParams, "seed") // The Converse API has no seed parameter
 
 	// Transform tools if present
 	if params != nil && params.Tools != nil && len(*params.Tools) > 0 {
@@ -935,21 +1055,10 @@ func (provider *BedrockProvider) ChatCompletion(ctx context.Context, model strin
 	}
 
 	requestBody := mergeConfig(messageBody, preparedParams)
-
-	// Format the path with proper model identifier
-	path := fmt.Sprintf("%s/converse", model)
-
-	if provider.meta != nil && provider.meta.GetInferenceProfiles() != nil {
-		if inferenceProfileId, ok := provider.meta.GetInferenceProfiles()[model]; ok {
-			if provider.meta.GetARN() != nil {
-				encodedModelIdentifier := url.PathEscape(fmt.Sprintf("%s/%s", *provider.meta.GetARN(), inferenceProfileId))
-				path = fmt.Sprintf("%s/converse", encodedModelIdentifier)
-			}
-		}
-	}
+	addBedrockGuardrailConfig(requestBody, params, provider.logger)
 
 	// Create the signed request
-	responseBody, err := provider.completeRequest(ctx, requestBody, path, key.Value)
+	responseBody, err := provider.completeRequest(ctx, requestBody, provider.getRegion(model), provider.getModelPath(model, "converse"), key.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -1023,18 +1132,15 @@ func (provider *BedrockProvider) ChatCompletion(ctx context.Context, model strin
 			FinishReason: &response.StopReason,
 		},
 	}
+	applyContentFilter(choices)
 
 	latency := float64(response.Metrics.Latency)
 
 	// Create final response
 	bifrostResponse := &schemas.BifrostResponse{
 		Choices: choices,
-		Usage: &schemas.LLMUsage{
-			PromptTokens:     response.Usage.InputTokens,
-			CompletionTokens: response.Usage.OutputTokens,
-			TotalTokens:      response.Usage.TotalTokens,
-		},
-		Model: model,
+		Usage:   response.Usage.toLLMUsage(),
+		Model:   model,
 		ExtraFields: schemas.BifrostResponseExtraFields{
 			Latency:  &latency,
 			Provider: schemas.Bedrock,
@@ -1118,10 +1224,10 @@ func signAWSRequest(req *http.Request, accessKey, secretKey string, sessionToken
 // Embedding generates embeddings for the given input text(s) using Amazon Bedrock.
 // Supports Titan and Cohere embedding models. Returns a BifrostResponse containing the embedding(s) and any error that occurred.
 func (provider *BedrockProvider) Embedding(ctx context.Context, model string, key schemas.Key, input *schemas.EmbeddingInput, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
-	switch {
-	case strings.HasPrefix(model, "amazon.titan-embed-text"):
+	switch baseModel := bedrockBaseModelID(model); {
+	case strings.HasPrefix(baseModel, "amazon.titan-embed-text"):
 		return provider.handleTitanEmbedding(ctx, model, key.Value, input, params)
-	case strings.HasPrefix(model, "cohere.embed"):
+	case strings.HasPrefix(baseModel, "cohere.embed"):
 		return provider.handleCohereEmbedding(ctx, model, key.Value, input, params)
 	default:
 		return nil, newConfigurationError("embedding is not supported for this Bedrock model", schemas.Bedrock)
@@ -1154,9 +1260,7 @@ func (provider *BedrockProvider) handleTitanEmbedding(ctx context.Context, model
 		}
 	}
 
-	// Properly escape model name for URL path to ensure AWS SIGv4 signing works correctly
-	path := url.PathEscape(model) + "/invoke"
-	rawResponse, err := provider.completeRequest(ctx, requestBody, path, key)
+	rawResponse, err := provider.completeRequest(ctx, requestBody, provider.getRegion(model), provider.getModelPath(model, "invoke"), key)
 	if err != nil {
 		return nil, err
 	}
@@ -1200,13 +1304,17 @@ func (provider *BedrockProvider) handleCohereEmbedding(ctx context.Context, mode
 		"texts":      input.Texts,
 		"input_type": "search_document",
 	}
-	if params != nil && params.ExtraParams != nil {
-		maps.Copy(requestBody, params.ExtraParams)
+	if params != nil {
+		// Cohere models on Bedrock do not support the dimensions parameter - they have fixed dimensions
+		if params.Dimensions != nil {
+			return nil, newConfigurationError("Cohere embedding models on Bedrock do not support custom dimensions parameter", schemas.Bedrock)
+		}
+		if params.ExtraParams != nil {
+			maps.Copy(requestBody, params.ExtraParams)
+		}
 	}
 
-	// Properly escape model name for URL path to ensure AWS SIGv4 signing works correctly
-	path := url.PathEscape(model) + "/invoke"
-	rawResponse, err := provider.completeRequest(ctx, requestBody, path, key)
+	rawResponse, err := provider.completeRequest(ctx, requestBody, provider.getRegion(model), provider.getModelPath(model, "invoke"), key)
 	if err != nil {
 		return nil, err
 	}
@@ -1255,6 +1363,7 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	}
 
 	preparedParams := prepareParams(params)
+	delete(preparedParams, "seed") // The Converse API has no seed parameter
 
 	// Transform tools if present
 	if params != nil && params.Tools != nil && len(*params.Tools) > 0 {
@@ -1276,27 +1385,15 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	}
 
 	requestBody := mergeConfig(messageBody, preparedParams)
-
-	// Format the path with proper model identifier for streaming
-	path := fmt.Sprintf("%s/converse-stream", model)
-
-	if provider.meta != nil && provider.meta.GetInferenceProfiles() != nil {
-		if inferenceProfileId, ok := provider.meta.GetInferenceProfiles()[model]; ok {
-			if provider.meta.GetARN() != nil {
-				encodedModelIdentifier := url.PathEscape(fmt.Sprintf("%s/%s", *provider.meta.GetARN(), inferenceProfileId))
-				path = fmt.Sprintf("%s/converse-stream", encodedModelIdentifier)
-			}
-		}
-	}
+	addBedrockGuardrailConfig(requestBody, params, provider.logger)
 
 	if provider.meta == nil {
 		return nil, newConfigurationError("meta config for bedrock is not provided", schemas.Bedrock)
 	}
 
-	region := "us-east-1"
-	if provider.meta.GetRegion() != nil {
-		region = *provider.meta.GetRegion()
-	}
+	// Format the path with proper model identifier for streaming
+	path := provider.getModelPath(model, "converse-stream")
+	region := provider.getRegion(model)
 
 	// Create the streaming request
 	jsonBody, jsonErr := sonic.Marshal(requestBody)
@@ -1304,6 +1401,12 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, jsonErr, schemas.Bedrock)
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
 	req, reqErr := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s", region, path), strings.NewReader(string(jsonBody)))
 	if reqErr != nil {
@@ -1325,34 +1428,34 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	// Make the request
 	resp, respErr := provider.client.Do(req)
 	if respErr != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderRequest, respErr, schemas.Bedrock)
+		return nil, newProviderRequestError(respErr, schemas.Bedrock)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		body := readStreamErrorBody(resp)
 		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from Bedrock: %d", resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, schemas.Bedrock, nil, nil)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, schemas.Bedrock, provider.logger)
+
 		// Create a buffer scanner to process the AWS Event Stream format
-		scanner := bufio.NewScanner(resp.Body)
+		scanner := newStreamScanner(resp.Body)
 		var messageID string
 
-		// AWS Event Streaming can have large buffers
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines
 			if line == "" {
@@ -1557,6 +1660,8 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 						},
 					}
 
+					applyContentFilter(finalResponse.Choices)
+
 					if params != nil {
 						finalResponse.ExtraFields.Params = *params
 					}
@@ -1569,18 +1674,22 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 			case event["usage"] != nil:
 				// This is a metadata event with usage information
 				if usage, ok := event["usage"].(map[string]interface{}); ok {
-					inputTokens := 0
-					outputTokens := 0
-					totalTokens := 0
+					var bedrockUsage BedrockUsage
 
 					if val, exists := usage["inputTokens"].(float64); exists {
-						inputTokens = int(val)
+						bedrockUsage.InputTokens = int(val)
 					}
 					if val, exists := usage["outputTokens"].(float64); exists {
-						outputTokens = int(val)
+						bedrockUsage.OutputTokens = int(val)
 					}
 					if val, exists := usage["totalTokens"].(float64); exists {
-						totalTokens = int(val)
+						bedrockUsage.TotalTokens = int(val)
+					}
+					if val, exists := usage["cacheReadInputTokens"].(float64); exists {
+						bedrockUsage.CacheReadInputTokens = int(val)
+					}
+					if val, exists := usage["cacheWriteInputTokens"].(float64); exists {
+						bedrockUsage.CacheWriteInputTokens = int(val)
 					}
 
 					// Send usage information
@@ -1588,11 +1697,7 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 						ID:     messageID,
 						Object: "chat.completion.chunk",
 						Model:  model,
-						Usage: &schemas.LLMUsage{
-							PromptTokens:     inputTokens,
-							CompletionTokens: outputTokens,
-							TotalTokens:      totalTokens,
-						},
+						Usage:  bedrockUsage.toLLMUsage(),
 						Choices: []schemas.BifrostResponseChoice{
 							{
 								Index: 0,
@@ -1617,7 +1722,7 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 		}
 
 		if err := scanner.Err(); err != nil {
-			provider.logger.Warn(fmt.Sprintf("Error reading Bedrock stream: %v", err))
+			logStreamReadError(ctx, provider.logger, "Bedrock stream", err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
@@ -1625,6 +1730,53 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	return responseChan, nil
 }
 
+// addBedrockGuardrailConfig maps the reserved guardrail keys of params.SafetySettings
+// onto the Converse API guardrailConfig. Other safety categories have no Bedrock equivalent,
+// so they are dropped with a warning.
+func addBedrockGuardrailConfig(requestBody map[string]interface{}, params *schemas.ModelParameters, logger schemas.Logger) {
+	if params == nil || len(params.SafetySettings) == 0 {
+		return
+	}
+
+	var dropped []string
+	for key := range params.SafetySettings {
+		if !isBedrockGuardrailSetting(key) {
+			dropped = append(dropped, key)
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		logger.Warn(fmt.Sprintf("bedrock: dropping safety settings without a Bedrock equivalent: %s", strings.Join(dropped, ", ")))
+	}
+
+	identifier, ok := params.SafetySettings[schemas.SafetySettingBedrockGuardrailIdentifier]
+	if !ok || identifier == "" {
+		return
+	}
+
+	guardrailConfig := map[string]interface{}{
+		"guardrailIdentifier": identifier,
+		"guardrailVersion":    "DRAFT",
+	}
+	if version, ok := params.SafetySettings[schemas.SafetySettingBedrockGuardrailVersion]; ok && version != "" {
+		guardrailConfig["guardrailVersion"] = version
+	}
+	if trace, ok := params.SafetySettings[schemas.SafetySettingBedrockGuardrailTrace]; ok && trace != "" {
+		guardrailConfig["trace"] = trace
+	}
+
+	requestBody["guardrailConfig"] = guardrailConfig
+}
+
+// isBedrockGuardrailSetting reports whether a SafetySettings key configures a Bedrock guardrail.
+func isBedrockGuardrailSetting(key string) bool {
+	switch key {
+	case schemas.SafetySettingBedrockGuardrailIdentifier, schemas.SafetySettingBedrockGuardrailVersion, schemas.SafetySettingBedrockGuardrailTrace:
+		return true
+	}
+	return false
+}
+
 func (provider *BedrockProvider) Speech(ctx context.Context, model string, key schemas.Key, input *schemas.SpeechInput, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("speech", "bedrock")
 }
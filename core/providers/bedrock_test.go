@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/meta"
+)
+
+// warnLogger records the warnings logged by a provider.
+type warnLogger struct {
+	testLogger
+	warnings *[]string
+}
+
+func (l warnLogger) Warn(msg string) { *l.warnings = append(*l.warnings, msg) }
+
+func TestAddBedrockGuardrailConfig(t *testing.T) {
+	var warnings []string
+	requestBody := map[string]interface{}{}
+	addBedrockGuardrailConfig(requestBody, &schemas.ModelParameters{SafetySettings: map[string]string{
+		schemas.SafetySettingBedrockGuardrailIdentifier: "gr-123",
+		schemas.SafetySettingBedrockGuardrailTrace:      "enabled",
+		"HARM_CATEGORY_HATE_SPEECH":                     "BLOCK_ONLY_HIGH",
+	}}, warnLogger{warnings: &warnings})
+
+	want := map[string]interface{}{"guardrailIdentifier": "gr-123", "guardrailVersion": "DRAFT", "trace": "enabled"}
+	if !reflect.DeepEqual(requestBody["guardrailConfig"], want) {
+		t.Errorf("guardrailConfig = %v, want %v", requestBody["guardrailConfig"], want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "HARM_CATEGORY_HATE_SPEECH") {
+		t.Errorf("warnings = %q, want the dropped category", warnings)
+	}
+
+	// Vertex categories alone configure no guardrail
+	warnings = nil
+	requestBody = map[string]interface{}{}
+	addBedrockGuardrailConfig(requestBody, &schemas.ModelParameters{SafetySettings: map[string]string{
+		"HARM_CATEGORY_HARASSMENT": "BLOCK_NONE",
+	}}, warnLogger{warnings: &warnings})
+	if _, ok := requestBody["guardrailConfig"]; ok || len(warnings) != 1 {
+		t.Errorf("requestBody = %v, warnings = %q, want no guardrail and a warning", requestBody, warnings)
+	}
+}
+
+func TestBedrockCrossRegionInferenceProfiles(t *testing.T) {
+	provider, err := NewBedrockProvider(&schemas.ProviderConfig{MetaConfig: &meta.BedrockMetaConfig{
+		SecretAccessKey:          "secret",
+		Region:                   StrPtr("us-west-2"),
+		CrossRegionSourceRegions: map[string]string{"eu": "eu-west-1"},
+	}}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewBedrockProvider() error = %v", err)
+	}
+	var requestURL string
+	var body map[string]interface{}
+	provider.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requestURL = r.URL.Host + r.URL.EscapedPath()
+		data, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+		response := `{"output":{"message":{"role":"assistant","content":[{"text":"Hi"}]}},"stopReason":"end_turn","usage":{"inputTokens":3,"outputTokens":1,"totalTokens":4}}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(response))}, nil
+	})
+
+	messages := []schemas.BifrostMessage{schemas.SystemMessage("Be brief."), schemas.UserMessage("Hello")}
+	for _, tt := range []struct {
+		model   string
+		wantURL string
+	}{
+		// Profiles are invoked from the source region configured for their prefix, or the default region
+		{"eu.anthropic.claude-3-5-sonnet-20240620-v1:0", "bedrock-runtime.eu-west-1.amazonaws.com/model/eu.anthropic.claude-3-5-sonnet-20240620-v1:0/converse"},
+		{"us.anthropic.claude-3-5-sonnet-20240620-v1:0", "bedrock-runtime.us-west-2.amazonaws.com/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse"},
+		// Profile ARNs are invoked in their own region
+		{"arn:aws:bedrock:ap-northeast-1:123456789012:inference-profile/apac.anthropic.claude-3-5-sonnet-20240620-v1:0",
+			"bedrock-runtime.ap-northeast-1.amazonaws.com/model/arn:aws:bedrock:ap-northeast-1:123456789012:inference-profile%2Fapac.anthropic.claude-3-5-sonnet-20240620-v1:0/converse"},
+	} {
+		response, bifrostErr := provider.ChatCompletion(context.Background(), tt.model, schemas.Key{Value: "access"}, messages, nil)
+		if bifrostErr != nil {
+			t.Fatalf("ChatCompletion(%s) error = %+v", tt.model, bifrostErr)
+		}
+		if requestURL != tt.wantURL {
+			t.Errorf("ChatCompletion(%s) requested %s, want %s", tt.model, requestURL, tt.wantURL)
+		}
+		// The request is in the format of the profile's model family, Anthropic here
+		if content := response.Choices[0].Message.Content.ContentBlocks; body["system"] == nil || content == nil || *(*content)[0].Text != "Hi" {
+			t.Errorf("ChatCompletion(%s) sent %v and returned %+v, want an Anthropic request and its response", tt.model, body, response.Choices[0].Message)
+		}
+	}
+}
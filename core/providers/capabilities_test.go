@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/meta"
+)
+
+// testLogger discards all log output.
+type testLogger struct{}
+
+func (testLogger) Debug(msg string) {}
+func (testLogger) Info(msg string)  {}
+func (testLogger) Warn(msg string)  {}
+func (testLogger) Error(err error)  {}
+
+func newTestProviders(t *testing.T) []schemas.Provider {
+	t.Helper()
+
+	config := func() *schemas.ProviderConfig {
+		return &schemas.ProviderConfig{
+			NetworkConfig: schemas.NetworkConfig{BaseURL: "http://127.0.0.1:1"},
+		}
+	}
+	region := "us-east-1"
+	bedrockConfig := config()
+	bedrockConfig.MetaConfig = &meta.BedrockMetaConfig{SecretAccessKey: "secret", Region: &region}
+
+	logger := testLogger{}
+	var providers []schemas.Provider
+	constructors := []func() (schemas.Provider, error){
+		func() (schemas.Provider, error) { return NewOpenAIProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewAnthropicProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewCohereProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewMistralProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewAzureProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewBedrockProvider(bedrockConfig, logger) },
+		func() (schemas.Provider, error) { return NewVertexProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewOllamaProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewGroqProvider(config(), logger) },
+		func() (schemas.Provider, error) { return NewSGLProvider(config(), logger) },
+	}
+	for _, constructor := range constructors {
+		provider, err := constructor()
+		if err != nil {
+			t.Fatalf("failed to create provider: %v", err)
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// TestCapabilitiesMatchUnsupportedOperations cross-checks each provider's declared capabilities
+// against the operations that return an unsupported-operation error. Requests use a cancelled
+// context, so supported operations fail fast without reaching the network.
+func TestCapabilitiesMatchUnsupportedOperations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	key := schemas.Key{Value: "test-key"}
+	text := "hello"
+	messages := []schemas.BifrostMessage{{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentStr: &text}}}
+	postHookRunner := func(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		return result, err
+	}
+
+	for _, provider := range newTestProviders(t) {
+		capabilities := provider.Capabilities()
+
+		if _, ok := provider.(schemas.BatchProvider); ok != capabilities.Batch {
+			t.Errorf("%s: batch capability is %v but BatchProvider implemented is %v", provider.GetProviderKey(), capabilities.Batch, ok)
+		}
+
+		operations := []struct {
+			name      string
+			supported bool
+			call      func() (chan *schemas.BifrostStream, *schemas.BifrostError)
+		}{
+			{"text completion", capabilities.TextCompletion, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				_, err := provider.TextCompletion(ctx, "model", key, text, nil)
+				return nil, err
+			}},
+			{"chat completion", capabilities.ChatCompletion, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				_, err := provider.ChatCompletion(ctx, "model", key, messages, nil)
+				return nil, err
+			}},
+			{"chat completion stream", capabilities.ChatCompletionStream, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				return provider.ChatCompletionStream(ctx, postHookRunner, "model", key, messages, nil)
+			}},
+			{"embedding", capabilities.Embedding, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				_, err := provider.Embedding(ctx, "model", key, &schemas.EmbeddingInput{Texts: []string{text}}, nil)
+				return nil, err
+			}},
+			{"speech", capabilities.Speech, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				_, err := provider.Speech(ctx, "model", key, &schemas.SpeechInput{Input: text}, nil)
+				return nil, err
+			}},
+			{"speech stream", capabilities.SpeechStream, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				return provider.SpeechStream(ctx, postHookRunner, "model", key, &schemas.SpeechInput{Input: text}, nil)
+			}},
+			{"transcription", capabilities.Transcription, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				_, err := provider.Transcription(ctx, "model", key, &schemas.TranscriptionInput{File: []byte("audio")}, nil)
+				return nil, err
+			}},
+			{"transcription stream", capabilities.TranscriptionStream, func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
+				return provider.TranscriptionStream(ctx, postHookRunner, "model", key, &schemas.TranscriptionInput{File: []byte("audio")}, nil)
+			}},
+		}
+
+		for _, operation := range operations {
+			stream, err := operation.call()
+			if stream != nil {
+				for range stream {
+					// Drain streams opened by supported operations
+				}
+			}
+
+			unsupported := err != nil && err.Error.Type != nil && *err.Error.Type == schemas.UnsupportedOperation
+			if operation.supported && unsupported {
+				t.Errorf("%s: %s is declared supported but returned an unsupported-operation error", provider.GetProviderKey(), operation.name)
+			}
+			if !operation.supported && !unsupported {
+				t.Errorf("%s: %s is declared unsupported but did not return an unsupported-operation error", provider.GetProviderKey(), operation.name)
+			}
+		}
+	}
+}
+
+func TestModelCapabilities(t *testing.T) {
+	providers := make(map[schemas.ModelProvider]schemas.Provider)
+	for _, provider := range newTestProviders(t) {
+		providers[provider.GetProviderKey()] = provider
+	}
+	capabilities := func(providerKey schemas.ModelProvider, model string) schemas.ProviderCapabilities {
+		provider := providers[providerKey]
+		if modelProvider, ok := provider.(schemas.ModelCapabilitiesProvider); ok {
+			return modelProvider.ModelCapabilities(model)
+		}
+		return provider.Capabilities()
+	}
+
+	noPenalties := schemas.PenaltySupport{}
+	penalties := schemas.PenaltySupport{Additive: true, Min: -2, Max: 2}
+	for _, tt := range []struct {
+		provider                           schemas.ModelProvider
+		model                              string
+		seed, logitBias, parallelToolCalls bool
+		penalties                          schemas.PenaltySupport
+	}{
+		{schemas.OpenAI, "gpt-4o", true, true, true, penalties},
+		{schemas.Azure, "gpt-4o", true, true, true, penalties},
+		{schemas.Anthropic, "claude-3-5-sonnet", false, false, true, noPenalties},
+		{schemas.Cohere, "command-r", true, false, false, schemas.PenaltySupport{Additive: true, Min: 0, Max: 1}},
+		{schemas.Bedrock, "mistral.mistral-large-2402-v1:0", false, false, false, penalties},
+		{schemas.Bedrock, "us.anthropic.claude-3-5-haiku-20241022-v1:0", false, false, false, noPenalties},
+		{schemas.Bedrock, "cohere.command-r-v1:0", false, false, false, schemas.PenaltySupport{Additive: true, Min: 0, Max: 1}},
+		{schemas.Vertex, "gemini-1.5-pro", true, false, false, penalties},
+		{schemas.Vertex, "claude-3-5-sonnet@20240620", false, false, true, noPenalties},
+	} {
+		got := capabilities(tt.provider, tt.model)
+		if got.Seed != tt.seed || got.LogitBias != tt.logitBias || got.ParallelToolCalls != tt.parallelToolCalls {
+			t.Errorf("%s %s: Seed = %v, LogitBias = %v, ParallelToolCalls = %v, want %v, %v and %v", tt.provider, tt.model,
+				got.Seed, got.LogitBias, got.ParallelToolCalls, tt.seed, tt.logitBias, tt.parallelToolCalls)
+		}
+		if got.Penalties != tt.penalties {
+			t.Errorf("%s %s: Penalties = %+v, want %+v", tt.provider, tt.model, got.Penalties, tt.penalties)
+		}
+	}
+}
@@ -3,14 +3,12 @@
 package providers
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"slices"
 	"strings"
 	"sync"
-	"time"
 
 	"net/http"
 
@@ -19,6 +17,9 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
+// errCohereToolResultImages rejects tool results with images, which Cohere v1 does not support.
+var errCohereToolResultImages = errors.New("image content in tool results is not supported by cohere provider")
+
 // cohereResponsePool provides a pool for Cohere response objects.
 var cohereResponsePool = sync.Pool{
 	New: func() interface{} {
@@ -88,6 +89,57 @@ type CohereChatResponse struct {
 		} `json:"tokens"` // Token usage statistics
 	} `json:"meta"` // Metadata about the response
 	ToolCalls []CohereToolCall `json:"tool_calls"` // Tool calls made in the response
+	Citations []struct {
+		Start       int      `json:"start"`        // Start of the citing span in the text, in characters
+		End         int      `json:"end"`          // End (exclusive) of the citing span in the text
+		Text        string   `json:"text"`         // Citing span of the text
+		DocumentIDs []string `json:"document_ids"` // IDs of the cited documents
+	} `json:"citations,omitempty"` // Spans of the text grounded in documents
+	Documents     []map[string]interface{} `json:"documents,omitempty"` // Documents cited, from the request or retrieved by connectors (e.g. web search)
+	SearchQueries []struct {
+		Text string `json:"text"` // Query text
+	} `json:"search_queries,omitempty"` // Queries generated to retrieve documents
+}
+
+// grounding returns the citations and search queries of the response as grounding metadata, with
+// one citation per cited document of each span, or nil if the response is not grounded.
+func (response *CohereChatResponse) grounding() *schemas.GroundingMetadata {
+	if len(response.Citations) == 0 && len(response.SearchQueries) == 0 {
+		return nil
+	}
+
+	documents := make(map[string]map[string]interface{}, len(response.Documents))
+	for _, document := range response.Documents {
+		if id, ok := document["id"].(string); ok {
+			documents[id] = document
+		}
+	}
+	field := func(document map[string]interface{}, names ...string) string {
+		for _, name := range names {
+			if value, ok := document[name].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	grounding := &schemas.GroundingMetadata{}
+	for _, citation := range response.Citations {
+		for _, id := range citation.DocumentIDs {
+			document := documents[id]
+			grounding.Citations = append(grounding.Citations, schemas.GroundingCitation{
+				StartIndex: intPtr(citation.Start),
+				EndIndex:   intPtr(citation.End),
+				Title:      field(document, "title"),
+				URL:        field(document, "url"),
+				CitedText:  field(document, "snippet", "text"),
+			})
+		}
+	}
+	for _, query := range response.SearchQueries {
+		grounding.SearchQueries = append(grounding.SearchQueries, query.Text)
+	}
+	return grounding
 }
 
 // CohereError represents an error response from the Cohere API.
@@ -95,6 +147,14 @@ type CohereError struct {
 	Message string `json:"message"` // Error message
 }
 
+// setCohereModelNotFound marks the 404 errors of Cohere's chat and embedding endpoints, whose only
+// resource that can be missing is the model, with schemas.ErrorCodeModelNotFound.
+func setCohereModelNotFound(bifrostErr *schemas.BifrostError) {
+	if bifrostErr.StatusCode != nil && *bifrostErr.StatusCode == fasthttp.StatusNotFound {
+		bifrostErr.Error.Code = StrPtr(schemas.ErrorCodeModelNotFound)
+	}
+}
+
 // CohereEmbeddingResponse represents the response from Cohere's embedding API.
 type CohereEmbeddingResponse struct {
 	ID         string `json:"id"` // Unique identifier for the embedding request
@@ -143,18 +203,18 @@ type CohereStreamStopEvent struct {
 // NewCohereProvider creates a new Cohere provider instance.
 // It initializes the HTTP client with the provided configuration and sets up response pools.
 // The client is configured with timeouts and connection limits.
-func NewCohereProvider(config *schemas.ProviderConfig, logger schemas.Logger) *CohereProvider {
+func NewCohereProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*CohereProvider, error) {
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -163,6 +223,11 @@ func NewCohereProvider(config *schemas.ProviderConfig, logger schemas.Logger) *C
 
 	}
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.cohere.ai"
@@ -175,7 +240,7 @@ func NewCohereProvider(config *schemas.ProviderConfig, logger schemas.Logger) *C
 		streamClient:        streamClient,
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
-	}
+	}, nil
 }
 
 // GetProviderKey returns the provider identifier for Cohere.
@@ -183,6 +248,23 @@ func (provider *CohereProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Cohere
 }
 
+// Capabilities returns the operations and features supported by the Cohere provider.
+func (provider *CohereProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Embedding:            true,
+		Tools:                true,
+		Seed:                 true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: 0, Max: 1},
+	}
+}
+
+// Warmup opens a connection to the Cohere API so the first request skips connection setup.
+func (provider *CohereProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // TextCompletion is not supported by the Cohere provider.
 // Returns an error indicating that text completion is not supported.
 func (provider *CohereProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -195,23 +277,14 @@ func (provider *CohereProvider) TextCompletion(ctx context.Context, model string
 func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string, key schemas.Key, messages []schemas.BifrostMessage, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	// Prepare request body using shared function
 	requestBody, err := prepareCohereChatRequest(messages, params, model, false)
-	if err != nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: true,
-			Error: schemas.ErrorField{
-				Message: "failed to prepare Cohere chat request",
-				Error:   err,
-			},
-		}
+	if errors.Is(err, errCohereToolResultImages) {
+		return nil, newUnsupportedOperationError("image content in tool results", "cohere")
 	}
-
-	// Marshal request body
-	jsonBody, err := sonic.Marshal(requestBody)
 	if err != nil {
 		return nil, &schemas.BifrostError{
 			IsBifrostError: true,
 			Error: schemas.ErrorField{
-				Message: schemas.ErrProviderJSONMarshaling,
+				Message: "failed to prepare Cohere chat request",
 				Error:   err,
 			},
 		}
@@ -226,15 +299,23 @@ func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/chat")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/chat"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, &schemas.BifrostError{
+			IsBifrostError: true,
+			Error: schemas.ErrorField{
+				Message: schemas.ErrProviderJSONMarshaling,
+				Error:   err,
+			},
+		}
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -247,6 +328,7 @@ func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string
 
 		bifrostErr := handleProviderAPIError(resp, &errorResp)
 		bifrostErr.Error.Message = errorResp.Message
+		setCohereModelNotFound(bifrostErr)
 
 		return nil, bifrostErr
 	}
@@ -321,7 +403,8 @@ func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string
 			CompletionTokens: int(response.Meta.Tokens.OutputTokens),
 			TotalTokens:      int(response.Meta.Tokens.InputTokens + response.Meta.Tokens.OutputTokens),
 		},
-		Model: model,
+		Model:     model,
+		Grounding: response.grounding(),
 		ExtraFields: schemas.BifrostResponseExtraFields{
 			Provider: schemas.Cohere,
 			BilledUsage: &schemas.BilledLLMUsage{
@@ -331,6 +414,7 @@ func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string
 			ChatHistory: convertChatHistory(response.ChatHistory),
 		},
 	}
+	applyContentFilter(bifrostResponse.Choices)
 
 	if provider.sendBackRawResponse {
 		bifrostResponse.ExtraFields.RawResponse = rawResponse
@@ -346,6 +430,11 @@ func (provider *CohereProvider) ChatCompletion(ctx context.Context, model string
 // prepareCohereChatRequest prepares the request body for Cohere chat completion requests.
 // It transforms the messages into Cohere format and handles tools, parameters, and content formatting.
 func prepareCohereChatRequest(messages []schemas.BifrostMessage, params *schemas.ModelParameters, model string, stream bool) (map[string]interface{}, error) {
+	// Cohere v1 does not support images, which would be silently dropped from tool results
+	if hasToolResultImages(messages) {
+		return nil, errCohereToolResultImages
+	}
+
 	// Get the last message and chat history
 	lastMessage := messages[len(messages)-1]
 	chatHistory := messages[:len(messages)-1]
@@ -425,13 +514,14 @@ func prepareCohereChatRequest(messages []schemas.BifrostMessage, params *schemas
 				toolCallParameters = map[string]interface{}{}
 			}
 
+			toolOutputs, _ := splitToolResultContent(msg.Content)
 			toolResults := []map[string]interface{}{
 				{
 					"call": map[string]interface{}{
 						"name":       *msg.ToolMessage.ToolCallID,
 						"parameters": toolCallParameters,
 					},
-					"outputs": *msg.Content.ContentStr,
+					"outputs": toolOutputs,
 				},
 			}
 
@@ -444,8 +534,8 @@ func prepareCohereChatRequest(messages []schemas.BifrostMessage, params *schemas
 			// Create content array with text and image
 			contentArray := []map[string]interface{}{}
 
-			// Iterate over ContentBlocks to build the content array
-			for _, block := range *msg.Content.ContentBlocks {
+			// Iterate over the normalized content blocks to build the content array
+			for _, block := range normalizeMessageContent(msg.Content) {
 				if block.Text != nil {
 					contentArray = append(contentArray, map[string]interface{}{
 						"type": "text",
@@ -482,7 +572,7 @@ func prepareCohereChatRequest(messages []schemas.BifrostMessage, params *schemas
 		requestBody["message"] = *lastMessage.Content.ContentStr
 	} else if lastMessage.Content.ContentBlocks != nil {
 		message := ""
-		for _, block := range *lastMessage.Content.ContentBlocks {
+		for _, block := range normalizeMessageContent(lastMessage.Content) {
 			if block.Text != nil {
 				message += *block.Text + "\n"
 			}
@@ -619,6 +709,14 @@ func (provider *CohereProvider) Embedding(ctx context.Context, model string, key
 			requestBody["embedding_types"] = []string{*params.EncodingFormat}
 		}
 
+		// Map dimensions to Cohere's parameter name (supported by embed-v4.0 and later)
+		if params.Dimensions != nil {
+			if bifrostErr := validateEmbeddingDimensions(model, *params.Dimensions, schemas.Cohere); bifrostErr != nil {
+				return nil, bifrostErr
+			}
+			requestBody["output_dimension"] = *params.Dimensions
+		}
+
 		// Merge extra parameters - this allows overriding input_type and other parameters
 		if params.ExtraParams != nil {
 			for k, v := range params.ExtraParams {
@@ -627,12 +725,6 @@ func (provider *CohereProvider) Embedding(ctx context.Context, model string, key
 		}
 	}
 
-	// Marshal request body
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Cohere)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -642,15 +734,17 @@ func (provider *CohereProvider) Embedding(ctx context.Context, model string, key
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v2/embed")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v2/embed"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Cohere)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -662,6 +756,7 @@ func (provider *CohereProvider) Embedding(ctx context.Context, model string, key
 		var errorResp CohereError
 		bifrostErr := handleProviderAPIError(resp, &errorResp)
 		bifrostErr.Error.Message = errorResp.Message
+		setCohereModelNotFound(bifrostErr)
 
 		return nil, bifrostErr
 	}
@@ -709,6 +804,9 @@ func (provider *CohereProvider) Embedding(ctx context.Context, model string, key
 func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, model string, key schemas.Key, messages []schemas.BifrostMessage, params *schemas.ModelParameters) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	// Prepare request body using shared function
 	requestBody, err := prepareCohereChatRequest(messages, params, model, true)
+	if errors.Is(err, errCohereToolResultImages) {
+		return nil, newUnsupportedOperationError("image content in tool results", "cohere")
+	}
 	if err != nil {
 		return nil, newBifrostOperationError("failed to prepare Cohere chat request", err, schemas.Cohere)
 	}
@@ -718,8 +816,14 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Cohere)
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
-	req, err := http.NewRequestWithContext(ctx, "POST", provider.networkConfig.BaseURL+"/v1/chat", strings.NewReader(string(jsonBody)))
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL(ctx, provider.networkConfig, "/v1/chat"), strings.NewReader(string(jsonBody)))
 	if err != nil {
 		return nil, newBifrostOperationError("failed to create HTTP request", err, schemas.Cohere)
 	}
@@ -733,38 +837,39 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
 
+	if bifrostErr := signHTTPRequest(ctx, req, jsonBody, provider.GetProviderKey()); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
 	// Make the request
 	resp, err := provider.streamClient.Do(req)
 	if err != nil {
-		return nil, &schemas.BifrostError{
-			IsBifrostError: false,
-			Error: schemas.ErrorField{
-				Message: schemas.ErrProviderRequest,
-				Error:   err,
-			},
-		}
+		return nil, newProviderRequestError(err, schemas.Cohere)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		body := readStreamErrorBody(resp)
 		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from Cohere: %d", resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, schemas.Cohere, nil, nil)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, schemas.Cohere, provider.logger)
+		scanner := newStreamScanner(resp.Body)
 		var responseID string
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines and comments
 			if line == "" || strings.HasPrefix(line, ":") {
@@ -953,6 +1058,8 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 						},
 					}
 
+					applyContentFilter(response.Choices)
+
 					if params != nil {
 						response.ExtraFields.Params = *params
 					}
@@ -970,7 +1077,7 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 		}
 
 		if err := scanner.Err(); err != nil {
-			provider.logger.Warn(fmt.Sprintf("Error reading Cohere stream: %v", err))
+			logStreamReadError(ctx, provider.logger, "Cohere stream", err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
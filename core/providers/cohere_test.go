@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestCohereGrounding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"generation_id":"gen-1","text":"Paris is the capital.","finish_reason":"COMPLETE",`+
+			`"citations":[{"start":0,"end":5,"text":"Paris","document_ids":["web-0","doc-1"]}],`+
+			`"documents":[{"id":"web-0","title":"Paris","url":"https://en.wikipedia.org/wiki/Paris","snippet":"Paris is the capital of France."},{"id":"doc-1","text":"France's capital is Paris."}],`+
+			`"search_queries":[{"text":"capital of France"}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewCohereProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewCohereProvider() error = %v", err)
+	}
+	response, bifrostErr := provider.ChatCompletion(context.Background(), "command-r", schemas.Key{Value: "co-test"}, []schemas.BifrostMessage{schemas.UserMessage("Capital of France?")}, nil)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletion() error = %+v", bifrostErr)
+	}
+
+	// Each cited document of a span is a citation of the span
+	want := &schemas.GroundingMetadata{
+		Citations: []schemas.GroundingCitation{
+			{StartIndex: intPtr(0), EndIndex: intPtr(5), Title: "Paris", URL: "https://en.wikipedia.org/wiki/Paris", CitedText: "Paris is the capital of France."},
+			{StartIndex: intPtr(0), EndIndex: intPtr(5), CitedText: "France's capital is Paris."},
+		},
+		SearchQueries: []string{"capital of France"},
+	}
+	if !reflect.DeepEqual(response.Grounding, want) {
+		t.Errorf("grounding = %+v, want %+v", response.Grounding, want)
+	}
+
+	// Responses without citations are not grounded
+	if grounding := (&CohereChatResponse{Text: "Hello"}).grounding(); grounding != nil {
+		t.Errorf("grounding() of an ungrounded response = %+v, want nil", grounding)
+	}
+}
+
+func TestCohereModelNotFoundError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"model 'command-x' not found, make sure the correct model ID was used and that you have access to the model."}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewCohereProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewCohereProvider() error = %v", err)
+	}
+	key := schemas.Key{Value: "co-test"}
+	_, bifrostErr := provider.ChatCompletion(context.Background(), "command-x", key, []schemas.BifrostMessage{schemas.UserMessage("Hello")}, nil)
+	if bifrostErr == nil || bifrostErr.Error.Code == nil || *bifrostErr.Error.Code != schemas.ErrorCodeModelNotFound {
+		t.Errorf("ChatCompletion() error = %+v, want a model_not_found error", bifrostErr)
+	}
+	_, bifrostErr = provider.Embedding(context.Background(), "embed-x", key, &schemas.EmbeddingInput{Texts: []string{"Hello"}}, nil)
+	if bifrostErr == nil || bifrostErr.Error.Code == nil || *bifrostErr.Error.Code != schemas.ErrorCodeModelNotFound {
+		t.Errorf("Embedding() error = %+v, want a model_not_found error", bifrostErr)
+	}
+}
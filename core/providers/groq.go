@@ -8,9 +8,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"time"
 
-	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
 	"github.com/valyala/fasthttp"
 )
@@ -52,14 +50,14 @@ func NewGroqProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*Gr
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.BufferSize,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -70,6 +68,11 @@ func NewGroqProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*Gr
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.groq.com/openai"
@@ -90,6 +93,24 @@ func (provider *GroqProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Groq
 }
 
+// Capabilities returns the operations and features supported by the Groq provider.
+func (provider *GroqProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Tools:                true,
+		Vision:               true,
+		Seed:                 true,
+		ParallelToolCalls:    true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
+// Warmup opens a connection to the Groq API so the first request skips connection setup.
+func (provider *GroqProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // TextCompletion is not supported by the Groq provider.
 func (provider *GroqProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("text completion", "groq")
@@ -104,11 +125,6 @@ func (provider *GroqProvider) ChatCompletion(ctx context.Context, model string,
 		"messages": formattedMessages,
 	}, preparedParams)
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Groq)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -118,15 +134,17 @@ func (provider *GroqProvider) ChatCompletion(ctx context.Context, model string,
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/chat/completions")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/chat/completions"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Groq)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -153,6 +171,8 @@ func (provider *GroqProvider) ChatCompletion(ctx context.Context, model string,
 		return nil, bifrostErr
 	}
 
+	applyContentFilter(response.Choices)
+
 	// Create final response
 	response.ExtraFields.Provider = schemas.Groq
 
@@ -198,7 +218,7 @@ func (provider *GroqProvider) ChatCompletionStream(ctx context.Context, postHook
 	return handleOpenAIStreaming(
 		ctx,
 		provider.streamClient,
-		provider.networkConfig.BaseURL+"/v1/chat/completions",
+		requestURL(ctx, provider.networkConfig, "/v1/chat/completions"),
 		requestBody,
 		headers,
 		provider.networkConfig.ExtraHeaders,
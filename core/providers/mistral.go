@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -50,6 +49,19 @@ func releaseMistralResponse(resp *schemas.BifrostResponse) {
 	}
 }
 
+// setMistralErrorType sets the type of a Mistral error response on its BifrostError, and marks
+// invalid_model errors with schemas.ErrorCodeModelNotFound.
+func setMistralErrorType(bifrostErr *schemas.BifrostError, errorResp map[string]interface{}) {
+	errorType, _ := errorResp["type"].(string)
+	if errorType == "" {
+		return
+	}
+	bifrostErr.Error.Type = &errorType
+	if errorType == "invalid_model" {
+		bifrostErr.Error.Code = StrPtr(schemas.ErrorCodeModelNotFound)
+	}
+}
+
 // MistralProvider implements the Provider interface for Mistral's API.
 type MistralProvider struct {
 	logger              schemas.Logger        // Logger for provider operations
@@ -62,18 +74,18 @@ type MistralProvider struct {
 // NewMistralProvider creates a new Mistral provider instance.
 // It initializes the HTTP client with the provided configuration and sets up response pools.
 // The client is configured with timeouts, concurrency limits, and optional proxy settings.
-func NewMistralProvider(config *schemas.ProviderConfig, logger schemas.Logger) *MistralProvider {
+func NewMistralProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*MistralProvider, error) {
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -84,6 +96,11 @@ func NewMistralProvider(config *schemas.ProviderConfig, logger schemas.Logger) *
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.mistral.ai"
@@ -96,7 +113,7 @@ func NewMistralProvider(config *schemas.ProviderConfig, logger schemas.Logger) *
 		streamClient:        streamClient,
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
-	}
+	}, nil
 }
 
 // GetProviderKey returns the provider identifier for Mistral.
@@ -104,6 +121,25 @@ func (provider *MistralProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Mistral
 }
 
+// Capabilities returns the operations and features supported by the Mistral provider.
+func (provider *MistralProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Embedding:            true,
+		Tools:                true,
+		Vision:               true,
+		Seed:                 true,
+		ParallelToolCalls:    true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
+// Warmup opens a connection to the Mistral API so the first request skips connection setup.
+func (provider *MistralProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // TextCompletion is not supported by the Mistral provider.
 func (provider *MistralProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("text completion", "mistral")
@@ -112,17 +148,13 @@ func (provider *MistralProvider) TextCompletion(ctx context.Context, model strin
 // ChatCompletion performs a chat completion request to the Mistral API.
 func (provider *MistralProvider) ChatCompletion(ctx context.Context, model string, key schemas.Key, messages []schemas.BifrostMessage, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	formattedMessages, preparedParams := prepareOpenAIChatRequest(messages, params)
+	renameMistralSeed(preparedParams)
 
 	requestBody := mergeConfig(map[string]interface{}{
 		"model":    model,
 		"messages": formattedMessages,
 	}, preparedParams)
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Mistral)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -132,15 +164,17 @@ func (provider *MistralProvider) ChatCompletion(ctx context.Context, model strin
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/chat/completions")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/chat/completions"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Mistral)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -152,6 +186,7 @@ func (provider *MistralProvider) ChatCompletion(ctx context.Context, model strin
 		var errorResp map[string]interface{}
 		bifrostErr := handleProviderAPIError(resp, &errorResp)
 		bifrostErr.Error.Message = fmt.Sprintf("Mistral error: %v", errorResp)
+		setMistralErrorType(bifrostErr, errorResp)
 		return nil, bifrostErr
 	}
 
@@ -167,6 +202,8 @@ func (provider *MistralProvider) ChatCompletion(ctx context.Context, model strin
 		return nil, bifrostErr
 	}
 
+	applyContentFilter(response.Choices)
+
 	response.ExtraFields.Provider = schemas.Mistral
 
 	if provider.sendBackRawResponse {
@@ -206,6 +243,9 @@ func (provider *MistralProvider) Embedding(ctx context.Context, model string, ke
 
 		// Map dimensions to Mistral's parameter name
 		if params.Dimensions != nil {
+			if bifrostErr := validateEmbeddingDimensions(model, *params.Dimensions, schemas.Mistral); bifrostErr != nil {
+				return nil, bifrostErr
+			}
 			requestBody["output_dimension"] = *params.Dimensions
 		}
 
@@ -217,11 +257,6 @@ func (provider *MistralProvider) Embedding(ctx context.Context, model string, ke
 		}
 	}
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Mistral)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -231,15 +266,17 @@ func (provider *MistralProvider) Embedding(ctx context.Context, model string, ke
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/embeddings")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/embeddings"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Mistral)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -251,6 +288,7 @@ func (provider *MistralProvider) Embedding(ctx context.Context, model string, ke
 		var errorResp map[string]interface{}
 		bifrostErr := handleProviderAPIError(resp, &errorResp)
 		bifrostErr.Error.Message = fmt.Sprintf("Mistral embedding error: %v", errorResp)
+		setMistralErrorType(bifrostErr, errorResp)
 		return nil, bifrostErr
 	}
 
@@ -302,6 +340,7 @@ func (provider *MistralProvider) Embedding(ctx context.Context, model string, ke
 // Returns a channel containing BifrostResponse objects representing the stream or an error if the request fails.
 func (provider *MistralProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, model string, key schemas.Key, messages []schemas.BifrostMessage, params *schemas.ModelParameters) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	formattedMessages, preparedParams := prepareOpenAIChatRequest(messages, params)
+	renameMistralSeed(preparedParams)
 
 	requestBody := mergeConfig(map[string]interface{}{
 		"model":    model,
@@ -321,7 +360,7 @@ func (provider *MistralProvider) ChatCompletionStream(ctx context.Context, postH
 	return handleOpenAIStreaming(
 		ctx,
 		provider.streamClient,
-		provider.networkConfig.BaseURL+"/v1/chat/completions",
+		requestURL(ctx, provider.networkConfig, "/v1/chat/completions"),
 		requestBody,
 		headers,
 		provider.networkConfig.ExtraHeaders,
@@ -347,3 +386,11 @@ func (provider *MistralProvider) Transcription(ctx context.Context, model string
 func (provider *MistralProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, model string, key schemas.Key, input *schemas.TranscriptionInput, params *schemas.ModelParameters) (chan *schemas.BifrostStream, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("transcription stream", "mistral")
 }
+
+// renameMistralSeed renames the seed parameter to random_seed, the name used by the Mistral API.
+func renameMistralSeed(params map[string]interface{}) {
+	if seed, exists := params["seed"]; exists {
+		params["random_seed"] = seed
+		delete(params, "seed")
+	}
+}
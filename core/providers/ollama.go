@@ -8,9 +8,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
-	"time"
 
-	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
 	"github.com/valyala/fasthttp"
 )
@@ -52,14 +50,14 @@ func NewOllamaProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.BufferSize,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -70,6 +68,11 @@ func NewOllamaProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
 
 	// BaseURL is required for Ollama
@@ -91,6 +94,23 @@ func (provider *OllamaProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Ollama
 }
 
+// Capabilities returns the operations and features supported by the Ollama provider.
+func (provider *OllamaProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Tools:                true,
+		Vision:               true,
+		Seed:                 true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
+// Warmup opens a connection to the Ollama API so the first request skips connection setup.
+func (provider *OllamaProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // TextCompletion is not supported by the Ollama provider.
 func (provider *OllamaProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("text completion", "ollama")
@@ -105,11 +125,6 @@ func (provider *OllamaProvider) ChatCompletion(ctx context.Context, model string
 		"messages": formattedMessages,
 	}, preparedParams)
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Ollama)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -119,17 +134,19 @@ func (provider *OllamaProvider) ChatCompletion(ctx context.Context, model string
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/chat/completions")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/chat/completions"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	if key.Value != "" {
 		req.Header.Set("Authorization", "Bearer "+key.Value)
 	}
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Ollama)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -156,6 +173,8 @@ func (provider *OllamaProvider) ChatCompletion(ctx context.Context, model string
 		return nil, bifrostErr
 	}
 
+	applyContentFilter(response.Choices)
+
 	response.ExtraFields.Provider = schemas.Ollama
 
 	if provider.sendBackRawResponse {
@@ -203,7 +222,7 @@ func (provider *OllamaProvider) ChatCompletionStream(ctx context.Context, postHo
 	return handleOpenAIStreaming(
 		ctx,
 		provider.streamClient,
-		provider.networkConfig.BaseURL+"/v1/chat/completions",
+		requestURL(ctx, provider.networkConfig, "/v1/chat/completions"),
 		requestBody,
 		headers,
 		provider.networkConfig.ExtraHeaders,
@@ -3,19 +3,13 @@
 package providers
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/binary"
 	"fmt"
-	"io"
-	"math"
 	"mime/multipart"
 	"net/http"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/bytedance/sonic"
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -73,18 +67,18 @@ type OpenAIProvider struct {
 // NewOpenAIProvider creates a new OpenAI provider instance.
 // It initializes the HTTP client with the provided configuration and sets up response pools.
 // The client is configured with timeouts, concurrency limits, and optional proxy settings.
-func NewOpenAIProvider(config *schemas.ProviderConfig, logger schemas.Logger) *OpenAIProvider {
+func NewOpenAIProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*OpenAIProvider, error) {
 	config.CheckAndSetDefaults()
 
 	client := &fasthttp.Client{
-		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
-		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		ReadTimeout:     config.NetworkConfig.MaxRequestTimeout(),
+		WriteTimeout:    config.NetworkConfig.MaxRequestTimeout(),
 		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
 	}
 
 	// Initialize streaming HTTP client
 	streamClient := &http.Client{
-		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		Timeout: config.NetworkConfig.MaxRequestTimeout(),
 	}
 
 	// Pre-warm response pools
@@ -95,6 +89,11 @@ func NewOpenAIProvider(config *schemas.ProviderConfig, logger schemas.Logger) *O
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Configure TLS if provided
+	if err := configureTLS(client, streamClient, config.TLSConfig, logger); err != nil {
+		return nil, err
+	}
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.openai.com"
@@ -107,7 +106,7 @@ func NewOpenAIProvider(config *schemas.ProviderConfig, logger schemas.Logger) *O
 		streamClient:        streamClient,
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
-	}
+	}, nil
 }
 
 // GetProviderKey returns the provider identifier for OpenAI.
@@ -115,6 +114,31 @@ func (provider *OpenAIProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.OpenAI
 }
 
+// Capabilities returns the operations and features supported by the OpenAI provider.
+func (provider *OpenAIProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Embedding:            true,
+		Speech:               true,
+		SpeechStream:         true,
+		Transcription:        true,
+		TranscriptionStream:  true,
+		Tools:                true,
+		Vision:               true,
+		Batch:                true,
+		Seed:                 true,
+		LogitBias:            true,
+		ParallelToolCalls:    true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
+// Warmup opens a connection to the OpenAI API so the first request skips connection setup.
+func (provider *OpenAIProvider) Warmup(ctx context.Context) error {
+	return warmupConnection(ctx, provider.client, provider.streamClient, provider.networkConfig.BaseURL, provider.GetProviderKey())
+}
+
 // TextCompletion is not supported by the OpenAI provider.
 // Returns an error indicating that text completion is not available.
 func (provider *OpenAIProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -132,11 +156,6 @@ func (provider *OpenAIProvider) ChatCompletion(ctx context.Context, model string
 		"messages": formattedMessages,
 	}, preparedParams)
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -146,15 +165,17 @@ func (provider *OpenAIProvider) ChatCompletion(ctx context.Context, model string
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/chat/completions")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/chat/completions"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -177,6 +198,9 @@ func (provider *OpenAIProvider) ChatCompletion(ctx context.Context, model string
 		return nil, bifrostErr
 	}
 
+	applyContentFilter(response.Choices)
+	response.Grounding = groundingFromAnnotations(response.Choices)
+
 	// Set raw response if enabled
 	if provider.sendBackRawResponse {
 		response.ExtraFields.RawResponse = rawResponse
@@ -192,14 +216,30 @@ func (provider *OpenAIProvider) ChatCompletion(ctx context.Context, model string
 // prepareOpenAIChatRequest formats messages for the OpenAI API.
 // It handles both text and image content in messages.
 // Returns a slice of formatted messages and any additional parameters.
+//
+// Tool messages can only carry text, so images returned by tools (e.g. by multimodal MCP tools)
+// are sent in a user message that follows the tool messages answering the same assistant turn.
 func prepareOpenAIChatRequest(messages []schemas.BifrostMessage, params *schemas.ModelParameters) ([]map[string]interface{}, map[string]interface{}) {
 	// Format messages for OpenAI API
 	var formattedMessages []map[string]interface{}
+	var toolImages []schemas.ContentBlock
+	flushToolImages := func() {
+		if len(toolImages) > 0 {
+			formattedMessages = append(formattedMessages, map[string]interface{}{
+				"role":    schemas.ModelChatMessageRoleUser,
+				"content": toolImages,
+			})
+			toolImages = nil
+		}
+	}
 	for _, msg := range messages {
+		if msg.Role != schemas.ModelChatMessageRoleTool {
+			flushToolImages()
+		}
 		if msg.Role == schemas.ModelChatMessageRoleAssistant {
 			assistantMessage := map[string]interface{}{
 				"role":    msg.Role,
-				"content": msg.Content,
+				"content": openAIMessageContent(msg.Content),
 			}
 			if msg.AssistantMessage != nil && msg.AssistantMessage.ToolCalls != nil {
 				assistantMessage["tool_calls"] = *msg.AssistantMessage.ToolCalls
@@ -210,33 +250,53 @@ func prepareOpenAIChatRequest(messages []schemas.BifrostMessage, params *schemas
 				"role": msg.Role,
 			}
 
-			if msg.Content.ContentStr != nil {
-				message["content"] = *msg.Content.ContentStr
-			} else if msg.Content.ContentBlocks != nil {
-				contentBlocks := *msg.Content.ContentBlocks
-				for i := range contentBlocks {
-					if contentBlocks[i].Type == schemas.ContentBlockTypeImage && contentBlocks[i].ImageURL != nil {
-						sanitizedURL, _ := SanitizeImageURL(contentBlocks[i].ImageURL.URL)
-						contentBlocks[i].ImageURL.URL = sanitizedURL
-					}
-				}
-
-				message["content"] = contentBlocks
+			if content := openAIMessageContent(msg.Content); content != nil {
+				message["content"] = content
 			}
 
 			if msg.ToolMessage != nil && msg.ToolMessage.ToolCallID != nil {
 				message["tool_call_id"] = *msg.ToolMessage.ToolCallID
 			}
 
+			if msg.Role == schemas.ModelChatMessageRoleTool && hasToolResultImages([]schemas.BifrostMessage{msg}) {
+				text, images := splitToolResultContent(msg.Content)
+				if text == "" {
+					text = "The tool returned the images in the next message."
+				}
+				message["content"] = text
+				label := "Images returned by the tool:"
+				if msg.ToolMessage != nil && msg.ToolMessage.ToolCallID != nil {
+					label = fmt.Sprintf("Images returned by tool call %s:", *msg.ToolMessage.ToolCallID)
+				}
+				toolImages = append(toolImages, schemas.ContentBlock{Type: schemas.ContentBlockTypeText, Text: &label})
+				toolImages = append(toolImages, images...)
+			}
+
 			formattedMessages = append(formattedMessages, message)
 		}
 	}
+	flushToolImages()
 
 	preparedParams := prepareParams(params)
+	if params != nil && len(params.LogitBias) > 0 {
+		preparedParams["logit_bias"] = params.LogitBias
+	}
 
 	return formattedMessages, preparedParams
 }
 
+// openAIMessageContent renders message content for the OpenAI API: string content is sent as
+// is, and content blocks are sent as normalized text and image_url parts.
+func openAIMessageContent(content schemas.MessageContent) interface{} {
+	if content.ContentStr != nil {
+		return *content.ContentStr
+	}
+	if content.ContentBlocks != nil {
+		return normalizeMessageContent(content)
+	}
+	return nil
+}
+
 // Embedding generates embeddings for the given input text(s).
 // The input can be either a single string or a slice of strings for batch embedding.
 // Returns a BifrostResponse containing the embedding(s) and any error that occurred.
@@ -259,6 +319,9 @@ func (provider *OpenAIProvider) Embedding(ctx context.Context, model string, key
 			requestBody["encoding_format"] = *params.EncodingFormat
 		}
 		if params.Dimensions != nil {
+			if bifrostErr := validateEmbeddingDimensions(model, *params.Dimensions, schemas.OpenAI); bifrostErr != nil {
+				return nil, bifrostErr
+			}
 			requestBody["dimensions"] = *params.Dimensions
 		}
 		if params.User != nil {
@@ -269,11 +332,6 @@ func (provider *OpenAIProvider) Embedding(ctx context.Context, model string, key
 		requestBody = mergeConfig(requestBody, params.ExtraParams)
 	}
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -283,15 +341,17 @@ func (provider *OpenAIProvider) Embedding(ctx context.Context, model string, key
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/embeddings")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/embeddings"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -324,45 +384,13 @@ func (provider *OpenAIProvider) Embedding(ctx context.Context, model string, key
 
 	// Extract embeddings from response data
 	if len(response.Data) > 0 {
-		embeddings := make([][]float32, len(response.Data))
+		values := make([]interface{}, len(response.Data))
 		for i, data := range response.Data {
-			switch v := data.Embedding.(type) {
-			case []float32:
-				embeddings[i] = v
-			case []interface{}:
-				// Convert []interface{} to []float32
-				floatArray := make([]float32, len(v))
-				for j := range v {
-					if num, ok := v[j].(float64); ok {
-						floatArray[j] = float32(num)
-					} else {
-						return nil, newBifrostOperationError(fmt.Sprintf("unsupported number type in embedding array: %T", v[j]), nil, schemas.OpenAI)
-					}
-				}
-				embeddings[i] = floatArray
-			case string:
-				// Decode base64 string into float32 array
-				decodedData, err := base64.StdEncoding.DecodeString(v)
-				if err != nil {
-					return nil, newBifrostOperationError("failed to decode base64 embedding", err, schemas.OpenAI)
-				}
-
-				// Validate that decoded data length is divisible by 4 (size of float32)
-				const sizeOfFloat32 = 4
-				if len(decodedData)%sizeOfFloat32 != 0 {
-					return nil, newBifrostOperationError("malformed base64 embedding data: length not divisible by 4", nil, schemas.OpenAI)
-				}
-
-				floats := make([]float32, len(decodedData)/sizeOfFloat32)
-				for i := 0; i < len(floats); i++ {
-					floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(decodedData[i*4 : (i+1)*4]))
-				}
-				embeddings[i] = floats
-			default:
-				return nil, newBifrostOperationError(fmt.Sprintf("unsupported embedding type: %T", data.Embedding), nil, schemas.OpenAI)
-			}
+			values[i] = data.Embedding
+		}
+		if bifrostErr := setEmbeddings(bifrostResponse, values, schemas.OpenAI); bifrostErr != nil {
+			return nil, bifrostErr
 		}
-		bifrostResponse.Embedding = embeddings
 	}
 
 	if params != nil {
@@ -396,7 +424,7 @@ func (provider *OpenAIProvider) ChatCompletionStream(ctx context.Context, postHo
 	return handleOpenAIStreaming(
 		ctx,
 		provider.streamClient,
-		provider.networkConfig.BaseURL+"/v1/chat/completions",
+		requestURL(ctx, provider.networkConfig, "/v1/chat/completions"),
 		requestBody,
 		headers,
 		provider.networkConfig.ExtraHeaders,
@@ -427,6 +455,12 @@ func handleOpenAIStreaming(
 		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, providerType)
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
 	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonBody)))
 	if err != nil {
@@ -441,29 +475,37 @@ func handleOpenAIStreaming(
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, extraHeaders, nil)
 
+	if bifrostErr := signHTTPRequest(ctx, req, jsonBody, providerType); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
 	// Make the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, schemas.OpenAI)
+		return nil, newProviderRequestError(err, schemas.OpenAI)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
 		return nil, parseStreamOpenAIError(resp)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, providerType, logger)
+		scanner := newStreamScanner(resp.Body)
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines and comments
 			if line == "" || strings.HasPrefix(line, ":") {
@@ -540,6 +582,7 @@ func handleOpenAIStreaming(
 			choice := response.Choices[0]
 			if choice.FinishReason != nil && *choice.FinishReason != "" {
 				// This is the final chunk with finish reason
+				applyContentFilter(response.Choices)
 				if params != nil {
 					response.ExtraFields.Params = *params
 				}
@@ -564,7 +607,7 @@ func handleOpenAIStreaming(
 
 		// Handle scanner errors
 		if err := scanner.Err(); err != nil {
-			logger.Warn(fmt.Sprintf("Error reading stream: %v", err))
+			logStreamReadError(ctx, logger, "stream", err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
@@ -593,11 +636,6 @@ func (provider *OpenAIProvider) Speech(ctx context.Context, model string, key sc
 		requestBody = mergeConfig(requestBody, params.ExtraParams)
 	}
 
-	jsonBody, err := sonic.Marshal(requestBody)
-	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
-	}
-
 	// Create request
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -607,15 +645,17 @@ func (provider *OpenAIProvider) Speech(ctx context.Context, model string, key sc
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/audio/speech")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/audio/speech"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType("application/json")
 	req.Header.Set("Authorization", "Bearer "+key.Value)
 
-	req.SetBody(jsonBody)
+	if err := setJSONBody(req, requestBody); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
+	}
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -685,8 +725,14 @@ func (provider *OpenAIProvider) SpeechStream(ctx context.Context, postHookRunner
 		"Cache-Control": "no-cache",
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
-	req, err := http.NewRequestWithContext(ctx, "POST", provider.networkConfig.BaseURL+"/v1/audio/speech", strings.NewReader(string(jsonBody)))
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL(ctx, provider.networkConfig, "/v1/audio/speech"), strings.NewReader(string(jsonBody)))
 	if err != nil {
 		return nil, newBifrostOperationError("failed to create HTTP request", err, schemas.OpenAI)
 	}
@@ -699,29 +745,37 @@ func (provider *OpenAIProvider) SpeechStream(ctx context.Context, postHookRunner
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
 
+	if bifrostErr := signHTTPRequest(ctx, req, jsonBody, provider.GetProviderKey()); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
 	// Make the request
 	resp, err := provider.streamClient.Do(req)
 	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, schemas.OpenAI)
+		return nil, newProviderRequestError(err, schemas.OpenAI)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
 		return nil, parseStreamOpenAIError(resp)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, schemas.OpenAI, provider.logger)
+		scanner := newStreamScanner(resp.Body)
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines and comments
 			if line == "" || strings.HasPrefix(line, ":") {
@@ -795,7 +849,7 @@ func (provider *OpenAIProvider) SpeechStream(ctx context.Context, postHookRunner
 
 		// Handle scanner errors
 		if err := scanner.Err(); err != nil {
-			provider.logger.Warn(fmt.Sprintf("Error reading stream: %v", err))
+			logStreamReadError(ctx, provider.logger, "stream", err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
@@ -824,7 +878,7 @@ func (provider *OpenAIProvider) Transcription(ctx context.Context, model string,
 	// Set any extra headers from network config
 	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
 
-	req.SetRequestURI(provider.networkConfig.BaseURL + "/v1/audio/transcriptions")
+	req.SetRequestURI(requestURL(ctx, provider.networkConfig, "/v1/audio/transcriptions"))
 	req.Header.SetMethod("POST")
 	req.Header.SetContentType(writer.FormDataContentType()) // This sets multipart/form-data with boundary
 	req.Header.Set("Authorization", "Bearer "+key.Value)
@@ -832,7 +886,7 @@ func (provider *OpenAIProvider) Transcription(ctx context.Context, model string,
 	req.SetBody(body.Bytes())
 
 	// Make request
-	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
 	if bifrostErr != nil {
 		return nil, bifrostErr
 	}
@@ -903,8 +957,14 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 		"Cache-Control": "no-cache",
 	}
 
+	// Apply the provider's request transform, if configured
+	requestBody, transformErr := transformRequestBody(ctx, body.Bytes(), provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create HTTP request for streaming
-	req, err := http.NewRequestWithContext(ctx, "POST", provider.networkConfig.BaseURL+"/v1/audio/transcriptions", &body)
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL(ctx, provider.networkConfig, "/v1/audio/transcriptions"), bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, newBifrostOperationError("failed to create HTTP request", err, schemas.OpenAI)
 	}
@@ -917,29 +977,37 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
 
+	if bifrostErr := signHTTPRequest(ctx, req, requestBody, provider.GetProviderKey()); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
 	// Make the request
 	resp, err := provider.streamClient.Do(req)
 	if err != nil {
-		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, schemas.OpenAI)
+		return nil, newProviderRequestError(err, schemas.OpenAI)
 	}
 
+	recordHTTPResponseMetadata(ctx, resp)
+
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
 		return nil, parseStreamOpenAIError(resp)
 	}
 
 	// Create response channel
-	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	responseChan := newStreamChannel(ctx)
 
 	// Start streaming in a goroutine
 	go func() {
 		defer close(responseChan)
 		defer resp.Body.Close()
 
-		scanner := bufio.NewScanner(resp.Body)
+		tapLine, postHookRunner := tapStream(ctx, postHookRunner, schemas.OpenAI, provider.logger)
+		scanner := newStreamScanner(resp.Body)
 
 		for scanner.Scan() {
 			line := scanner.Text()
+			tapLine(line)
 
 			// Skip empty lines and comments
 			if line == "" {
@@ -995,6 +1063,12 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 				continue
 			}
 
+			// Deltas are interim until the done event delivers the complete transcript
+			if transcriptionResponse.BifrostTranscribeStreamResponse != nil {
+				eventType := transcriptionResponse.BifrostTranscribeStreamResponse.Type
+				transcriptionResponse.BifrostTranscribeStreamResponse.IsFinal = eventType == nil || *eventType != "transcript.text.delta"
+			}
+
 			response.Transcribe = &transcriptionResponse
 			response.Object = "audio.transcription.chunk"
 			response.Model = model
@@ -1011,7 +1085,7 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 
 		// Handle scanner errors
 		if err := scanner.Err(); err != nil {
-			provider.logger.Warn(fmt.Sprintf("Error reading stream: %v", err))
+			logStreamReadError(ctx, provider.logger, "stream", err)
 			processAndSendError(ctx, postHookRunner, err, responseChan)
 		}
 	}()
@@ -1114,8 +1188,7 @@ func parseStreamOpenAIError(resp *http.Response) *schemas.BifrostError {
 	var errorResp schemas.BifrostError
 
 	statusCode := resp.StatusCode
-	body, _ := io.ReadAll(resp.Body)
-	resp.Body.Close()
+	body := readStreamErrorBody(resp)
 
 	if err := sonic.Unmarshal(body, &errorResp); err != nil {
 		return &schemas.BifrostError{
@@ -0,0 +1,270 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the OpenAI batch API implementation.
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// openAIBatchEndpoint is the endpoint every request of a batch is sent to.
+const openAIBatchEndpoint = "/v1/chat/completions"
+
+// openAIBatchCompletionWindow is the time frame within which a batch is processed.
+// It is the only window supported by OpenAI.
+const openAIBatchCompletionWindow = "24h"
+
+// OpenAIBatch represents a batch object returned by the OpenAI batches API.
+type OpenAIBatch struct {
+	ID            string  `json:"id"`
+	Status        string  `json:"status"`
+	InputFileID   string  `json:"input_file_id"`
+	OutputFileID  *string `json:"output_file_id"`
+	ErrorFileID   *string `json:"error_file_id"`
+	CreatedAt     int64   `json:"created_at"`
+	ExpiresAt     *int64  `json:"expires_at"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+// OpenAIBatchResultLine represents one line of a batch output or error file.
+type OpenAIBatchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CreateBatch uploads the items as a JSONL input file and creates a batch for it.
+func (provider *OpenAIProvider) CreateBatch(ctx context.Context, model string, key schemas.Key, items []schemas.BatchRequestItem) (*schemas.BatchJob, *schemas.BifrostError) {
+	var input bytes.Buffer
+	for _, item := range items {
+		formattedMessages, preparedParams := prepareOpenAIChatRequest(item.Messages, item.Params)
+
+		line, err := sonic.Marshal(map[string]interface{}{
+			"custom_id": item.CustomID,
+			"method":    "POST",
+			"url":       openAIBatchEndpoint,
+			"body": mergeConfig(map[string]interface{}{
+				"model":    model,
+				"messages": formattedMessages,
+			}, preparedParams),
+		})
+		if err != nil {
+			return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
+		}
+		input.Write(line)
+		input.WriteByte('\n')
+	}
+
+	// Upload the input file
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", "batch"); err != nil {
+		return nil, newBifrostOperationError("failed to write purpose field", err, schemas.OpenAI)
+	}
+	fileWriter, err := writer.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return nil, newBifrostOperationError("failed to create form file", err, schemas.OpenAI)
+	}
+	if _, err := fileWriter.Write(input.Bytes()); err != nil {
+		return nil, newBifrostOperationError("failed to write batch input file", err, schemas.OpenAI)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, newBifrostOperationError("failed to close multipart writer", err, schemas.OpenAI)
+	}
+
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodPost, "/v1/files", writer.FormDataContentType(), body.Bytes())
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := sonic.Unmarshal(responseBody, &file); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderResponseUnmarshal, err, schemas.OpenAI)
+	}
+
+	// Create the batch
+	jsonBody, err := sonic.Marshal(map[string]interface{}{
+		"input_file_id":     file.ID,
+		"endpoint":          openAIBatchEndpoint,
+		"completion_window": openAIBatchCompletionWindow,
+	})
+	if err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.OpenAI)
+	}
+
+	responseBody, bifrostErr = provider.batchRequest(ctx, key, fasthttp.MethodPost, "/v1/batches", "application/json", jsonBody)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseOpenAIBatch(responseBody, model)
+}
+
+// GetBatch returns the current state of a batch.
+func (provider *OpenAIProvider) GetBatch(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodGet, "/v1/batches/"+batch.ID, "", nil)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseOpenAIBatch(responseBody, batch.Model)
+}
+
+// CancelBatch requests the cancellation of a batch.
+func (provider *OpenAIProvider) CancelBatch(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) (*schemas.BatchJob, *schemas.BifrostError) {
+	responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodPost, "/v1/batches/"+batch.ID+"/cancel", "", nil)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	return parseOpenAIBatch(responseBody, batch.Model)
+}
+
+// GetBatchResults downloads the output and error files of a batch and converts each line
+// into a BatchResult. The batch must have been fetched after it completed, so that its file IDs are set.
+func (provider *OpenAIProvider) GetBatchResults(ctx context.Context, key schemas.Key, batch *schemas.BatchJob) ([]schemas.BatchResult, *schemas.BifrostError) {
+	var results []schemas.BatchResult
+	for _, fileID := range []string{batch.ResultsLocation, batch.ErrorsLocation} {
+		if fileID == "" {
+			continue
+		}
+
+		responseBody, bifrostErr := provider.batchRequest(ctx, key, fasthttp.MethodGet, "/v1/files/"+fileID+"/content", "", nil)
+		if bifrostErr != nil {
+			return nil, bifrostErr
+		}
+
+		for _, line := range splitJSONLines(responseBody) {
+			var resultLine OpenAIBatchResultLine
+			if err := sonic.Unmarshal(line, &resultLine); err != nil {
+				return nil, newBifrostOperationError(schemas.ErrProviderResponseUnmarshal, err, schemas.OpenAI)
+			}
+			results = append(results, provider.parseOpenAIBatchResult(&resultLine))
+		}
+	}
+
+	return results, nil
+}
+
+// parseOpenAIBatchResult converts one line of a batch output or error file into a BatchResult.
+func (provider *OpenAIProvider) parseOpenAIBatchResult(resultLine *OpenAIBatchResultLine) schemas.BatchResult {
+	result := schemas.BatchResult{CustomID: resultLine.CustomID}
+
+	if resultLine.Error != nil {
+		result.Error = newBatchItemError(resultLine.Error.Message, resultLine.Error.Code, schemas.OpenAI)
+		return result
+	}
+	if resultLine.Response == nil {
+		result.Error = newBatchItemError("batch result has neither a response nor an error", "", schemas.OpenAI)
+		return result
+	}
+
+	if resultLine.Response.StatusCode != fasthttp.StatusOK {
+		var errorResp schemas.BifrostError
+		if err := sonic.Unmarshal(resultLine.Response.Body, &errorResp); err != nil || errorResp.Error.Message == "" {
+			errorResp.Error.Message = fmt.Sprintf("batch request failed with status code %d", resultLine.Response.StatusCode)
+		}
+		result.Error = newProviderAPIError(errorResp.Error.Message, nil, resultLine.Response.StatusCode, schemas.OpenAI, errorResp.Error.Type, nil)
+		result.Error.Error.Code = errorResp.Error.Code
+		return result
+	}
+
+	response := &schemas.BifrostResponse{}
+	rawResponse, bifrostErr := handleProviderResponse(resultLine.Response.Body, response, provider.sendBackRawResponse)
+	if bifrostErr != nil {
+		result.Error = bifrostErr
+		return result
+	}
+
+	response.ExtraFields.Provider = schemas.OpenAI
+	if provider.sendBackRawResponse {
+		response.ExtraFields.RawResponse = rawResponse
+	}
+	result.Response = response
+	return result
+}
+
+// batchRequest sends a request to the OpenAI files or batches API and returns a copy of the response body.
+func (provider *OpenAIProvider) batchRequest(ctx context.Context, key schemas.Key, method string, path string, contentType string, body []byte) ([]byte, *schemas.BifrostError) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	// Set any extra headers from network config
+	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
+
+	req.SetRequestURI(resolveBaseURL(ctx, provider.networkConfig.BaseURL) + path)
+	req.Header.SetMethod(method)
+	req.Header.Set("Authorization", "Bearer "+key.Value)
+	if contentType != "" {
+		req.Header.SetContentType(contentType)
+	}
+	if body != nil {
+		req.SetBody(body)
+	}
+
+	bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp, provider.GetProviderKey())
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	// Handle error response
+	if resp.StatusCode() != fasthttp.StatusOK {
+		provider.logger.Debug(fmt.Sprintf("error from openai provider: %s", string(resp.Body())))
+		return nil, parseOpenAIError(resp)
+	}
+
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// parseOpenAIBatch converts an OpenAI batch object into a BatchJob.
+func parseOpenAIBatch(responseBody []byte, model string) (*schemas.BatchJob, *schemas.BifrostError) {
+	var batch OpenAIBatch
+	if err := sonic.Unmarshal(responseBody, &batch); err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderResponseUnmarshal, err, schemas.OpenAI)
+	}
+
+	job := &schemas.BatchJob{
+		ID:        batch.ID,
+		Provider:  schemas.OpenAI,
+		Model:     model,
+		Status:    schemas.BatchStatus(batch.Status), // OpenAI statuses match the normalized ones
+		CreatedAt: batch.CreatedAt,
+		RequestCounts: schemas.BatchRequestCounts{
+			Total:     batch.RequestCounts.Total,
+			Completed: batch.RequestCounts.Completed,
+			Failed:    batch.RequestCounts.Failed,
+		},
+	}
+	if batch.ExpiresAt != nil {
+		job.ExpiresAt = *batch.ExpiresAt
+	}
+	if batch.OutputFileID != nil {
+		job.ResultsLocation = *batch.OutputFileID
+	}
+	if batch.ErrorFileID != nil {
+		job.ErrorsLocation = *batch.ErrorFileID
+	}
+
+	return job, nil
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func newTestOpenAIBatchProvider(handler http.HandlerFunc) (*OpenAIProvider, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	provider, _ := NewOpenAIProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	return provider, server
+}
+
+func TestOpenAICreateBatch(t *testing.T) {
+	var inputLines []map[string]interface{}
+	var batchBody map[string]interface{}
+	provider, server := newTestOpenAIBatchProvider(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Authorization = %q, want the key", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v1/files":
+			if purpose := r.FormValue("purpose"); purpose != "batch" {
+				t.Errorf("purpose = %q, want batch", purpose)
+			}
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("FormFile() error = %v", err)
+			}
+			content, _ := io.ReadAll(file)
+			for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+				var inputLine map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &inputLine); err != nil {
+					t.Fatalf("input line %q is not JSON: %v", line, err)
+				}
+				inputLines = append(inputLines, inputLine)
+			}
+			fmt.Fprint(w, `{"id":"file-in"}`)
+		case "/v1/batches":
+			json.NewDecoder(r.Body).Decode(&batchBody)
+			fmt.Fprint(w, `{"id":"batch_1","status":"validating","input_file_id":"file-in","created_at":1700000000,"expires_at":1700086400,"request_counts":{"total":0,"completed":0,"failed":0}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer server.Close()
+
+	items := []schemas.BatchRequestItem{
+		{CustomID: "a", Messages: []schemas.BifrostMessage{schemas.UserMessage("Hello")}},
+		{CustomID: "b", Messages: []schemas.BifrostMessage{schemas.UserMessage("Bonjour")}, Params: &schemas.ModelParameters{MaxTokens: intPtr(10)}},
+	}
+	job, bifrostErr := provider.CreateBatch(context.Background(), "gpt-4o-mini", schemas.Key{Value: "sk-test"}, items)
+	if bifrostErr != nil {
+		t.Fatalf("CreateBatch() error = %+v", bifrostErr)
+	}
+
+	// Each item is a line of the input file, sent to the chat completions endpoint
+	if len(inputLines) != 2 {
+		t.Fatalf("input file has %d lines, want 2", len(inputLines))
+	}
+	second := inputLines[1]
+	body, _ := second["body"].(map[string]interface{})
+	if second["custom_id"] != "b" || second["method"] != "POST" || second["url"] != "/v1/chat/completions" ||
+		body["model"] != "gpt-4o-mini" || body["max_tokens"] != float64(10) || len(body["messages"].([]interface{})) != 1 {
+		t.Errorf("input line = %v, want the second item", second)
+	}
+	if batchBody["input_file_id"] != "file-in" || batchBody["endpoint"] != "/v1/chat/completions" || batchBody["completion_window"] != "24h" {
+		t.Errorf("batch body = %v, want the uploaded file", batchBody)
+	}
+
+	if job.ID != "batch_1" || job.Provider != schemas.OpenAI || job.Model != "gpt-4o-mini" || job.Status != schemas.BatchStatusValidating ||
+		job.CreatedAt != 1700000000 || job.ExpiresAt != 1700086400 {
+		t.Errorf("job = %+v, want the created batch", job)
+	}
+}
+
+func TestOpenAIBatchPollingAndResults(t *testing.T) {
+	provider, server := newTestOpenAIBatchProvider(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/batches/batch_1":
+			fmt.Fprint(w, `{"id":"batch_1","status":"completed","output_file_id":"file-out","error_file_id":"file-err","created_at":1700000000,"request_counts":{"total":3,"completed":2,"failed":1}}`)
+		case "POST /v1/batches/batch_1/cancel":
+			fmt.Fprint(w, `{"id":"batch_1","status":"cancelling","created_at":1700000000,"request_counts":{"total":3,"completed":1,"failed":0}}`)
+		case "GET /v1/files/file-out/content":
+			fmt.Fprintln(w, `{"custom_id":"a","response":{"status_code":200,"body":{"id":"chatcmpl-1","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}}}`)
+			fmt.Fprintln(w, `{"custom_id":"b","response":{"status_code":400,"body":{"error":{"message":"max_tokens is too large","type":"invalid_request_error"}}}}`)
+		case "GET /v1/files/file-err/content":
+			fmt.Fprintln(w, `{"custom_id":"c","error":{"code":"batch_expired","message":"This request could not be executed before the completion window expired."}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"No batch found","type":"invalid_request_error"}}`)
+		}
+	})
+	defer server.Close()
+	key := schemas.Key{Value: "sk-test"}
+
+	job, bifrostErr := provider.GetBatch(context.Background(), key, &schemas.BatchJob{ID: "batch_1", Model: "gpt-4o-mini"})
+	if bifrostErr != nil {
+		t.Fatalf("GetBatch() error = %+v", bifrostErr)
+	}
+	if job.Status != schemas.BatchStatusCompleted || !job.Status.IsTerminal() || job.ResultsLocation != "file-out" || job.ErrorsLocation != "file-err" ||
+		job.RequestCounts != (schemas.BatchRequestCounts{Total: 3, Completed: 2, Failed: 1}) {
+		t.Errorf("job = %+v, want the completed batch", job)
+	}
+
+	results, bifrostErr := provider.GetBatchResults(context.Background(), key, job)
+	if bifrostErr != nil {
+		t.Fatalf("GetBatchResults() error = %+v", bifrostErr)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if a := results[0]; a.CustomID != "a" || a.Error != nil || a.Response == nil || *a.Response.Choices[0].Message.Content.ContentStr != "Hi" {
+		t.Errorf("result a = %+v, want the completion", a)
+	}
+	if b := results[1]; b.CustomID != "b" || b.Response != nil || b.Error == nil || *b.Error.StatusCode != 400 ||
+		b.Error.Error.Message != "max_tokens is too large" || *b.Error.Error.Type != "invalid_request_error" {
+		t.Errorf("result b = %+v, want the request's error", b.Error)
+	}
+	if c := results[2]; c.CustomID != "c" || c.Error == nil || *c.Error.Error.Type != "batch_expired" {
+		t.Errorf("result c = %+v, want the expired request", c.Error)
+	}
+
+	cancelled, bifrostErr := provider.CancelBatch(context.Background(), key, job)
+	if bifrostErr != nil || cancelled.Status != schemas.BatchStatusCancelling || cancelled.Status.IsTerminal() {
+		t.Errorf("CancelBatch() = %+v, %+v, want a cancelling batch", cancelled, bifrostErr)
+	}
+
+	// API errors are returned with their status code and message
+	_, bifrostErr = provider.GetBatch(context.Background(), key, &schemas.BatchJob{ID: "batch_missing"})
+	if bifrostErr == nil || bifrostErr.StatusCode == nil || *bifrostErr.StatusCode != 404 || bifrostErr.Error.Message != "No batch found" {
+		t.Errorf("GetBatch() of a missing batch error = %+v, want the 404", bifrostErr)
+	}
+}
+
+func TestOpenAIBatchResultsRejectMalformedLines(t *testing.T) {
+	provider, server := newTestOpenAIBatchProvider(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"custom_id":"a","response":`)
+	})
+	defer server.Close()
+
+	_, bifrostErr := provider.GetBatchResults(context.Background(), schemas.Key{Value: "sk-test"}, &schemas.BatchJob{ID: "batch_1", ResultsLocation: "file-out"})
+	if bifrostErr == nil || bifrostErr.Error.Message != schemas.ErrProviderResponseUnmarshal {
+		t.Errorf("GetBatchResults() error = %+v, want an unmarshal error", bifrostErr)
+	}
+}
@@ -0,0 +1,140 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+func TestOpenAITranscriptionStreamMarksFinalChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"transcript.text.delta\",\"delta\":\"Hello\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"transcript.text.delta\",\"delta\":\" world\"}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"transcript.text.done\",\"text\":\"Hello world\"}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error = %v", err)
+	}
+	postHookRunner := func(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		return result, err
+	}
+
+	stream, bifrostErr := provider.TranscriptionStream(context.Background(), postHookRunner, "gpt-4o-transcribe", schemas.Key{Value: "test-key"}, &schemas.TranscriptionInput{File: []byte("audio")}, nil)
+	if bifrostErr != nil {
+		t.Fatalf("TranscriptionStream() error = %v", bifrostErr.Error.Message)
+	}
+
+	var finals []bool
+	for chunk := range stream {
+		if chunk.BifrostError != nil {
+			t.Fatalf("stream error = %v", chunk.BifrostError.Error.Message)
+		}
+		finals = append(finals, chunk.Transcribe.IsFinal)
+	}
+
+	want := []bool{false, false, true}
+	if fmt.Sprint(finals) != fmt.Sprint(want) {
+		t.Errorf("IsFinal = %v, want %v", finals, want)
+	}
+}
+
+// BenchmarkOpenAIChatJSON measures the JSON hot path of a chat completion: encoding the request
+// body of a multi-turn conversation and decoding the provider's response.
+func BenchmarkOpenAIChatJSON(b *testing.B) {
+	var messages []schemas.BifrostMessage
+	for i := 0; i < 10; i++ {
+		messages = append(messages,
+			schemas.UserMessage(strings.Repeat("What is the weather like in Paris today? ", 20)),
+			schemas.AssistantTextMessage(strings.Repeat("It is sunny in Paris, with a high of 24 degrees. ", 20)))
+	}
+	maxTokens, temperature := 512, 0.7
+	params := &schemas.ModelParameters{MaxTokens: &maxTokens, Temperature: &temperature}
+	responseBody := []byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"` +
+		strings.Repeat("It is sunny in Paris. ", 50) + `"},"finish_reason":"stop"}],"usage":{"prompt_tokens":2000,"completion_tokens":250,"total_tokens":2250}}`)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		formattedMessages, preparedParams := prepareOpenAIChatRequest(messages, params)
+		requestBody := mergeConfig(map[string]interface{}{
+			"model":    "gpt-4o",
+			"messages": formattedMessages,
+		}, preparedParams)
+
+		req := fasthttp.AcquireRequest()
+		if err := setJSONBody(req, requestBody); err != nil {
+			b.Fatal(err)
+		}
+		fasthttp.ReleaseRequest(req)
+
+		response := acquireOpenAIResponse()
+		if _, bifrostErr := handleProviderResponse(responseBody, response, false); bifrostErr != nil {
+			b.Fatal(bifrostErr.Error.Error)
+		}
+		releaseOpenAIResponse(response)
+	}
+}
+
+func TestOpenAIGroundingFromAnnotations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"gpt-4o-search-preview","choices":[{"index":0,"message":{"role":"assistant","content":"Paris is the capital.","annotations":[`+
+			`{"type":"url_citation","url_citation":{"start_index":0,"end_index":21,"title":"Paris","url":"https://en.wikipedia.org/wiki/Paris"}},`+
+			`{"type":"file_citation"}]}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider() error = %v", err)
+	}
+	response, bifrostErr := provider.ChatCompletion(context.Background(), "gpt-4o-search-preview", schemas.Key{Value: "sk-test"}, []schemas.BifrostMessage{schemas.UserMessage("Capital of France?")}, nil)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletion() error = %+v", bifrostErr)
+	}
+
+	// Only URL citations are sources
+	want := schemas.GroundingCitation{StartIndex: intPtr(0), EndIndex: intPtr(21), Title: "Paris", URL: "https://en.wikipedia.org/wiki/Paris"}
+	if response.Grounding == nil || len(response.Grounding.Citations) != 1 || !reflect.DeepEqual(response.Grounding.Citations[0], want) {
+		t.Errorf("grounding = %+v, want the URL citation", response.Grounding)
+	}
+}
+
+func TestOpenAISendsLogitBias(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+	provider, _ := NewOpenAIProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+	}, testLogger{})
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	params := &schemas.ModelParameters{LogitBias: map[int]float64{42: -100, 1000: 5.5}}
+	if _, bifrostErr := provider.ChatCompletion(context.Background(), "gpt-4o", schemas.Key{Value: "sk-test"}, messages, params); bifrostErr != nil {
+		t.Fatalf("ChatCompletion() error = %+v", bifrostErr)
+	}
+
+	// Token IDs are sent as the string keys of a JSON object
+	want := map[string]interface{}{"42": float64(-100), "1000": 5.5}
+	if !reflect.DeepEqual(body["logit_bias"], want) {
+		t.Errorf("logit_bias = %v, want %v", body["logit_bias"], want)
+	}
+}
@@ -3,18 +3,30 @@
 package providers
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"os"
 	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/andybalholm/brotli"
 	"github.com/bytedance/sonic"
+	"github.com/bytedance/sonic/encoder"
+	"github.com/klauspost/compress/zstd"
 	schemas "github.com/maximhq/bifrost/core/schemas"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fasthttp/fasthttpproxy"
@@ -42,6 +54,23 @@ var fileExtensionToMediaType = map[string]string{
 	".bmp":  "image/bmp",
 }
 
+// embeddingModelDimensions maps embedding models to the output dimensions they can produce when
+// a reduced dimension count is requested. Models not listed here are passed through and validated
+// by the provider API itself.
+var embeddingModelDimensions = map[string]embeddingDimensions{
+	"text-embedding-3-small": {max: 1536},
+	"text-embedding-3-large": {max: 3072},
+	"text-embedding-ada-002": {},
+	"embed-v4.0":             {max: 1536, allowed: []int{256, 512, 1024, 1536}},
+	"codestral-embed":        {max: 3072},
+}
+
+// embeddingDimensions describes the output dimensions an embedding model accepts.
+type embeddingDimensions struct {
+	max     int   // Maximum number of dimensions, 0 if the model has a fixed output size
+	allowed []int // The only accepted values, if the model does not accept any value up to max
+}
+
 // ImageContentType represents the type of image content
 type ImageContentType string
 
@@ -62,6 +91,164 @@ type URLTypeInfo struct {
 // across different packages.
 type ContextKey string
 
+// resolveBaseURL returns the per-request base URL override from the context if present,
+// otherwise the provider's configured base URL.
+func resolveBaseURL(ctx context.Context, configuredBaseURL string) string {
+	if baseURL, ok := ctx.Value(schemas.BifrostContextKeyBaseURL).(string); ok && baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return configuredBaseURL
+}
+
+// requestURL returns the URL of an API endpoint: the resolved base URL followed by the path,
+// or its override in the network config.
+func requestURL(ctx context.Context, network schemas.NetworkConfig, path string) string {
+	return resolveBaseURL(ctx, network.BaseURL) + network.RequestPath(path)
+}
+
+// warmupConnection sends a HEAD request to baseURL through both the client and the streaming
+// client, so each establishes (and pools) a connection before the first real request. Any HTTP
+// response counts as success, since only connection setup matters here.
+func warmupConnection(ctx context.Context, client *fasthttp.Client, streamClient *http.Client, baseURL string, providerName schemas.ModelProvider) error {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(baseURL)
+	req.Header.SetMethod(fasthttp.MethodHead)
+
+	if bifrostErr := makeRequestWithContext(ctx, client, req, resp, providerName); bifrostErr != nil {
+		if bifrostErr.Error.Error != nil {
+			return bifrostErr.Error.Error
+		}
+		return fmt.Errorf("%s", bifrostErr.Error.Message)
+	}
+
+	if streamClient == nil {
+		return nil
+	}
+	streamReq, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return err
+	}
+	streamResp, err := streamClient.Do(streamReq)
+	if err != nil {
+		return err
+	}
+	// Drain the body so the connection goes back to the pool
+	io.Copy(io.Discard, streamResp.Body)
+	streamResp.Body.Close()
+
+	return nil
+}
+
+// recordFastHTTPResponseMetadata records the status code and selected headers of a fasthttp
+// response into the request's ResponseMetadata, if Bifrost attached one to the context.
+func recordFastHTTPResponseMetadata(ctx context.Context, resp *fasthttp.Response) {
+	metadata, ok := ctx.Value(schemas.BifrostContextKeyResponseMetadata).(*schemas.ResponseMetadata)
+	if !ok || metadata == nil {
+		return
+	}
+
+	metadata.Reset(resp.StatusCode())
+	metadata.RequestID = providerRequestID(func(name string) string { return string(resp.Header.Peek(name)) })
+	resp.Header.VisitAll(func(key, value []byte) {
+		metadata.SetHeader(string(key), string(value))
+	})
+}
+
+// maxStreamLineSize is the longest line accepted in a provider stream. Stream parsers only
+// buffer the line being read, so this bounds their memory regardless of the stream's length.
+// It leaves room for large events, such as tool call arguments or audio sent in one piece.
+const maxStreamLineSize = 8 * 1024 * 1024
+
+// maxStreamErrorBodySize is the largest error body read from a failed stream request.
+const maxStreamErrorBodySize = 1024 * 1024
+
+// newStreamScanner returns a scanner that reads a provider stream line by line. Lines are read
+// incrementally from body into a reused buffer, which grows up to maxStreamLineSize, so a long
+// stream is never accumulated in memory. Lines longer than that fail the scan with bufio.ErrTooLong.
+func newStreamScanner(body io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	return scanner
+}
+
+// tapStream implements ProviderConfig.LogStreamChunks. If Bifrost enabled the stream tap in the
+// context, it returns a function that logs each raw line read from the provider's stream, and a
+// post-hook runner that logs each chunk parsed from them, both at debug level with base64 payloads
+// redacted. Otherwise, the returned function does nothing and the runner is returned as is.
+func tapStream(ctx context.Context, postHookRunner schemas.PostHookRunner, providerType schemas.ModelProvider, logger schemas.Logger) (func(line string), schemas.PostHookRunner) {
+	if enabled, _ := ctx.Value(schemas.BifrostContextKeyStreamTap).(bool); !enabled {
+		return func(string) {}, postHookRunner
+	}
+
+	tapLine := func(line string) {
+		if line != "" {
+			logger.Debug(fmt.Sprintf("[stream tap] %s raw chunk: %s", providerType, redactStreamLine(line)))
+		}
+	}
+	tappedRunner := func(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		result, bifrostErr = postHookRunner(ctx, result, bifrostErr)
+
+		var parsed []byte
+		if bifrostErr != nil {
+			parsed, _ = sonic.Marshal(bifrostErr)
+		} else {
+			parsed, _ = sonic.Marshal(result)
+		}
+		logger.Debug(fmt.Sprintf("[stream tap] %s parsed chunk: %s", providerType, redactRequestBody(parsed)))
+		return result, bifrostErr
+	}
+	return tapLine, tappedRunner
+}
+
+// redactStreamLine redacts the base64 payloads of the JSON data of a raw stream line (e.g. an SSE
+// "data:" line). Lines without JSON data are returned as is.
+func redactStreamLine(line string) string {
+	if start := strings.IndexByte(line, '{'); start >= 0 && sonic.ValidString(line[start:]) {
+		return line[:start] + string(redactRequestBody([]byte(line[start:])))
+	}
+	return line
+}
+
+// readStreamErrorBody reads at most maxStreamErrorBodySize bytes of the body of a failed
+// stream request, and closes it.
+func readStreamErrorBody(resp *http.Response) []byte {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxStreamErrorBodySize))
+	return body
+}
+
+// recordHTTPResponseMetadata records the status code and selected headers of a net/http
+// response into the request's ResponseMetadata, if Bifrost attached one to the context.
+func recordHTTPResponseMetadata(ctx context.Context, resp *http.Response) {
+	metadata, ok := ctx.Value(schemas.BifrostContextKeyResponseMetadata).(*schemas.ResponseMetadata)
+	if !ok || metadata == nil {
+		return
+	}
+
+	metadata.Reset(resp.StatusCode)
+	metadata.RequestID = providerRequestID(resp.Header.Get)
+	for name, values := range resp.Header {
+		if len(values) > 0 {
+			metadata.SetHeader(name, values[0])
+		}
+	}
+}
+
+// providerRequestID returns the value of the first of schemas.ProviderRequestIDHeaders present in
+// a response, read with getHeader, or an empty string if the provider sent none of them.
+func providerRequestID(getHeader func(name string) string) string {
+	for _, name := range schemas.ProviderRequestIDHeaders {
+		if value := getHeader(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 // mergeConfig merges a default configuration map with custom parameters.
 // It creates a new map containing all default values, then overrides them with any custom values.
 // Returns a new map containing the merged configuration.
@@ -128,22 +315,227 @@ func prepareParams(params *schemas.ModelParameters) map[string]interface{} {
 	return flatParams
 }
 
+// transformRequestBody applies the provider's RequestTransform to a request body,
+// if Bifrost attached body transforms to the context.
+func transformRequestBody(ctx context.Context, body []byte, providerName schemas.ModelProvider) ([]byte, *schemas.BifrostError) {
+	transforms, ok := ctx.Value(schemas.BifrostContextKeyBodyTransforms).(*schemas.BodyTransforms)
+	if ok && transforms != nil && transforms.Request != nil {
+		transformed, err := transforms.Request(body)
+		if err != nil {
+			return nil, newBifrostOperationError(schemas.ErrProviderRequestTransform, err, providerName)
+		}
+		body = transformed
+	}
+
+	recordRequestBody(ctx, body)
+	return body, nil
+}
+
+// recordRequestBody stores a redacted copy of the final request body in the response metadata
+// attached to the context, if the provider is configured to send it back.
+func recordRequestBody(ctx context.Context, body []byte) {
+	metadata, ok := ctx.Value(schemas.BifrostContextKeyResponseMetadata).(*schemas.ResponseMetadata)
+	if !ok || metadata == nil || !metadata.CaptureRequestBody {
+		return
+	}
+	metadata.RequestBody = redactRequestBody(body)
+}
+
+// redactRequestBody returns a copy of a request body that can be returned to callers. In JSON
+// bodies, data URLs and long base64 strings (e.g. images and audio) are replaced with a placeholder,
+// and the rest is kept byte for byte. Other bodies (e.g. multipart uploads) are summarized as a JSON string.
+func redactRequestBody(body []byte) []byte {
+	if !sonic.Valid(body) {
+		summary, _ := sonic.Marshal(fmt.Sprintf("[%d bytes of non-JSON data omitted]", len(body)))
+		return summary
+	}
+
+	redacted := make([]byte, 0, len(body))
+	for i := 0; i < len(body); i++ {
+		if body[i] != '"' {
+			redacted = append(redacted, body[i])
+			continue
+		}
+
+		// Find the closing quote of the string literal
+		end := i + 1
+		for body[end] != '"' {
+			if body[end] == '\\' {
+				end++
+			}
+			end++
+		}
+
+		literal := body[i+1 : end]
+		if placeholder, ok := redactBase64String(literal); ok {
+			redacted = append(redacted, '"')
+			redacted = append(redacted, placeholder...)
+			redacted = append(redacted, '"')
+		} else {
+			redacted = append(redacted, body[i:end+1]...)
+		}
+		i = end
+	}
+	return redacted
+}
+
+// minRedactedBase64Length is the length from which strings made of base64 characters only are redacted.
+const minRedactedBase64Length = 256
+
+// redactBase64String returns a placeholder for a JSON string literal holding a data URL or a long
+// base64 payload, which are not useful for debugging and can be very large.
+func redactBase64String(literal []byte) (string, bool) {
+	if prefix, data, found := bytes.Cut(literal, []byte(";base64,")); found && bytes.HasPrefix(prefix, []byte("data:")) {
+		return fmt.Sprintf("%s;base64,[%d base64 characters omitted]", prefix, len(data)), true
+	}
+	if len(literal) < minRedactedBase64Length {
+		return "", false
+	}
+	for _, c := range literal {
+		isBase64 := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '+' || c == '/' || c == '=' || c == '-' || c == '_'
+		// JSON escapes of '/' are allowed, as some encoders escape it
+		if !isBase64 && c != '\\' {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("[%d base64 characters omitted]", len(literal)), true
+}
+
+// transformResponseBody applies the provider's ResponseTransform to a response body,
+// if Bifrost attached body transforms to the context.
+func transformResponseBody(ctx context.Context, body []byte, providerName schemas.ModelProvider) ([]byte, *schemas.BifrostError) {
+	transforms, ok := ctx.Value(schemas.BifrostContextKeyBodyTransforms).(*schemas.BodyTransforms)
+	if !ok || transforms == nil || transforms.Response == nil {
+		return body, nil
+	}
+
+	transformed, err := transforms.Response(body)
+	if err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderResponseTransform, err, providerName)
+	}
+	return transformed, nil
+}
+
+// signRequest runs the provider's RequestSigner on a fasthttp request, if Bifrost attached one to
+// the context, and applies the headers it set, changed or removed.
+func signRequest(ctx context.Context, req *fasthttp.Request, providerName schemas.ModelProvider) *schemas.BifrostError {
+	signer, ok := ctx.Value(schemas.BifrostContextKeyRequestSigner).(schemas.RequestSigner)
+	if !ok || signer == nil {
+		return nil
+	}
+
+	header := make(http.Header)
+	req.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	signable := &schemas.SignableRequest{
+		Method: string(req.Header.Method()),
+		URL:    req.URI().String(),
+		Path:   string(req.URI().Path()),
+		Body:   req.Body(),
+		Header: header.Clone(),
+	}
+	if err := signer(signable); err != nil {
+		return newBifrostOperationError(schemas.ErrProviderRequestSigning, err, providerName)
+	}
+
+	for key := range header {
+		if _, kept := signable.Header[key]; !kept {
+			req.Header.Del(key)
+		}
+	}
+	for key, values := range signable.Header {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return nil
+}
+
+// signHTTPRequest runs the provider's RequestSigner on a net/http request with the given body,
+// if Bifrost attached one to the context. The signer modifies the request's headers directly.
+func signHTTPRequest(ctx context.Context, req *http.Request, body []byte, providerName schemas.ModelProvider) *schemas.BifrostError {
+	signer, ok := ctx.Value(schemas.BifrostContextKeyRequestSigner).(schemas.RequestSigner)
+	if !ok || signer == nil {
+		return nil
+	}
+
+	if err := signer(&schemas.SignableRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Path:   req.URL.Path,
+		Body:   body,
+		Header: req.Header,
+	}); err != nil {
+		return newBifrostOperationError(schemas.ErrProviderRequestSigning, err, providerName)
+	}
+	return nil
+}
+
+// setJSONBody encodes v as the JSON body of a provider request, with the options of sonic.Marshal.
+// It encodes directly into the request's pooled body buffer rather than into a new byte slice that
+// is then copied into it, which saves an allocation and a copy of every request body.
+func setJSONBody(req *fasthttp.Request, v any) error {
+	body := req.SwapBody(nil)[:0]
+	err := encoder.EncodeInto(&body, v, 0)
+	// Where sonic falls back to encoding/json (unsupported Go versions or CPUs), the encoder
+	// appends a newline that sonic.Marshal does not. A JSON value never ends with one itself.
+	body = bytes.TrimSuffix(body, []byte("\n"))
+	req.SwapBody(body)
+	if err != nil {
+		req.ResetBody()
+	}
+	return err
+}
+
 // IMPORTANT: This function does NOT truly cancel the underlying fasthttp network request if the
 // context is done. The fasthttp client call will continue in its goroutine until it completes
 // or times out based on its own settings. This function merely stops *waiting* for the
 // fasthttp call and returns an error related to the context.
-func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *fasthttp.Request, resp *fasthttp.Response) *schemas.BifrostError {
+//
+// The call runs on copies of req and resp owned by its goroutine, so that the caller can release
+// req and resp as soon as this function returns, even if the call is still in flight. The copies
+// are released by the goroutine once the abandoned call completes.
+func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *fasthttp.Request, resp *fasthttp.Response, providerName schemas.ModelProvider) *schemas.BifrostError {
+	if len(req.Body()) > 0 {
+		body, bifrostErr := transformRequestBody(ctx, req.Body(), providerName)
+		if bifrostErr != nil {
+			return bifrostErr
+		}
+		req.SetBody(body)
+		compressRequestBody(ctx, req)
+	}
+	for key, value := range requestExtraHeaders(ctx) {
+		req.Header.Set(key, value)
+	}
+	if len(req.Header.Peek(fasthttp.HeaderAcceptEncoding)) == 0 {
+		req.Header.Set(fasthttp.HeaderAcceptEncoding, acceptedEncodings)
+	}
+	if bifrostErr := signRequest(ctx, req, providerName); bifrostErr != nil {
+		return bifrostErr
+	}
+
+	callReq := fasthttp.AcquireRequest()
+	callResp := fasthttp.AcquireResponse()
+	req.CopyTo(callReq)
 	errChan := make(chan error, 1)
 
 	go func() {
 		// client.Do is a blocking call.
 		// It will send an error (or nil for success) to errChan when it completes.
-		errChan <- client.Do(req, resp)
+		errChan <- client.Do(callReq, callResp)
 	}()
 
 	select {
 	case <-ctx.Done():
 		// Context was cancelled (e.g., deadline exceeded or manual cancellation).
+		// The call still uses its request and response, release them once it completes.
+		go func() {
+			<-errChan
+			fasthttp.ReleaseRequest(callReq)
+			fasthttp.ReleaseResponse(callResp)
+		}()
 		// Return a BifrostError indicating this.
 		return &schemas.BifrostError{
 			IsBifrostError: true,
@@ -155,22 +547,166 @@ func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *f
 		}
 	case err := <-errChan:
 		// The fasthttp.Do call completed.
+		callResp.CopyTo(resp)
+		fasthttp.ReleaseRequest(callReq)
+		fasthttp.ReleaseResponse(callResp)
 		if err != nil {
 			// The HTTP request itself failed (e.g., connection error, fasthttp timeout).
-			return &schemas.BifrostError{
-				IsBifrostError: false,
-				Error: schemas.ErrorField{
-					Message: schemas.ErrProviderRequest,
-					Error:   err,
-				},
-			}
+			return newProviderRequestError(err, providerName)
 		}
 		// HTTP request was successful from fasthttp's perspective (err is nil).
 		// The caller should check resp.StatusCode() for HTTP-level errors (4xx, 5xx).
+		recordFastHTTPResponseMetadata(ctx, resp)
+		if bifrostErr := decompressResponseBody(resp, providerName); bifrostErr != nil {
+			return bifrostErr
+		}
+		body, bifrostErr := transformResponseBody(ctx, resp.Body(), providerName)
+		if bifrostErr != nil {
+			return bifrostErr
+		}
+		resp.SetBody(body)
 		return nil
 	}
 }
 
+// compressRequestBody gzips the body of a request if the provider is configured to compress
+// request bodies and the body is large enough. Bodies that already have a Content-Encoding are
+// left alone. Compression runs before signing, so that signatures cover the bytes that are sent.
+func compressRequestBody(ctx context.Context, req *fasthttp.Request) {
+	if len(req.Header.ContentEncoding()) > 0 {
+		return
+	}
+	if compressed, ok := compressBody(ctx, req.Body()); ok {
+		req.SetBody(compressed)
+		req.Header.SetContentEncoding("gzip")
+	}
+}
+
+// compressHTTPRequestBody is compressRequestBody for net/http requests, whose body is given.
+func compressHTTPRequestBody(ctx context.Context, req *http.Request, body []byte) {
+	if req.Header.Get("Content-Encoding") != "" {
+		return
+	}
+	compressed, ok := compressBody(ctx, body)
+	if !ok {
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+}
+
+// compressBody gzips a request body if the provider is configured to compress request bodies
+// and the body is at least RequestCompressionConfig.MinSizeBytes long, and reports whether it did.
+func compressBody(ctx context.Context, body []byte) ([]byte, bool) {
+	compression, ok := ctx.Value(schemas.BifrostContextKeyRequestCompression).(*schemas.RequestCompressionConfig)
+	if !ok || compression == nil {
+		return nil, false
+	}
+	minSize := compression.MinSizeBytes
+	if minSize <= 0 {
+		minSize = schemas.DefaultRequestCompressionMinSize
+	}
+	if len(body) < minSize {
+		return nil, false
+	}
+	return fasthttp.AppendGzipBytes(nil, body), true
+}
+
+// acceptedEncodings is the Accept-Encoding sent with requests that do not set one, listing the
+// encodings decompressResponseBody supports.
+const acceptedEncodings = "gzip, deflate, br"
+
+// maxDecompressedResponseSize bounds the size of a decompressed response body, so that a small
+// compressed body cannot expand into an unbounded amount of memory.
+const maxDecompressedResponseSize = 256 << 20
+
+// decompressResponseBody replaces a compressed response body (gzip, deflate, Brotli or zstd) with
+// its decompressed content, so that responses are parsed the same whether or not the provider, or
+// a proxy in front of it, compressed them. Bodies that decompress to more than
+// maxDecompressedResponseSize bytes are rejected.
+func decompressResponseBody(resp *fasthttp.Response, providerName schemas.ModelProvider) *schemas.BifrostError {
+	encoding := strings.ToLower(strings.TrimSpace(string(resp.Header.ContentEncoding())))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+
+	body, err := decompress(encoding, resp.Body(), maxDecompressedResponseSize)
+	if err != nil {
+		return newBifrostOperationError(schemas.ErrProviderDecompress, fmt.Errorf("content encoding %q: %w", encoding, err), providerName)
+	}
+	resp.Header.Del(fasthttp.HeaderContentEncoding)
+	resp.SetBody(body)
+	return nil
+}
+
+// setAcceptEncodingHTTP sets acceptedEncodings as the Accept-Encoding of a net/http request that
+// does not set one. The transport then leaves response bodies as sent, and they must be passed
+// through decompressHTTPResponseBody.
+func setAcceptEncodingHTTP(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptedEncodings)
+	}
+}
+
+// decompressHTTPResponseBody is decompressResponseBody for net/http responses, whose body has
+// been read.
+func decompressHTTPResponseBody(resp *http.Response, body []byte, providerName schemas.ModelProvider) ([]byte, *schemas.BifrostError) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return body, nil
+	}
+
+	decompressed, err := decompress(encoding, body, maxDecompressedResponseSize)
+	if err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderDecompress, fmt.Errorf("content encoding %q: %w", encoding, err), providerName)
+	}
+	resp.Header.Del("Content-Encoding")
+	return decompressed, nil
+}
+
+// decompress decodes a body in the given content encoding, failing if it exceeds maxSize bytes.
+func decompress(encoding string, body []byte, maxSize int) ([]byte, error) {
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reader = gzipReader
+	case "deflate":
+		zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		reader = zlibReader
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	default:
+		return nil, fmt.Errorf("unsupported encoding")
+	}
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decompressed) > maxSize {
+		return nil, fmt.Errorf("decompressed body is larger than %d bytes", maxSize)
+	}
+	return decompressed, nil
+}
+
 // configureProxy sets up a proxy for the fasthttp client based on the provided configuration.
 // It supports HTTP, SOCKS5, and environment-based proxy configurations.
 // Returns the configured client or the original client if proxy configuration is invalid.
@@ -224,6 +760,53 @@ func configureProxy(client *fasthttp.Client, proxyConfig *schemas.ProxyConfig, l
 	return client
 }
 
+// configureTLS applies the TLS configuration to the provider's clients: its CAs are trusted in
+// addition to the system's root CAs. A CA bundle file that cannot be read is an error, while
+// bundles without certificates are logged and skipped, like invalid proxy configurations. Either
+// client may be nil.
+func configureTLS(client *fasthttp.Client, streamClient *http.Client, tlsConfig *schemas.TLSConfig, logger schemas.Logger) error {
+	if tlsConfig == nil {
+		return nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: tlsConfig.InsecureSkipVerify}
+	if tlsConfig.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is disabled by insecure_skip_verify, do not use it in production")
+	}
+
+	if tlsConfig.CACertPath != "" || tlsConfig.CACertPEM != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		bundles := []string{tlsConfig.CACertPEM}
+		if tlsConfig.CACertPath != "" {
+			pem, err := os.ReadFile(tlsConfig.CACertPath)
+			if err != nil {
+				return fmt.Errorf("invalid TLS configuration: failed to read CA bundle: %w", err)
+			}
+			bundles = append(bundles, string(pem))
+		}
+		for _, bundle := range bundles {
+			if bundle != "" && !rootCAs.AppendCertsFromPEM([]byte(bundle)) {
+				logger.Warn("Invalid TLS configuration: no certificates found in CA bundle")
+			}
+		}
+		config.RootCAs = rootCAs
+	}
+
+	if client != nil {
+		client.TLSConfig = config
+	}
+	if streamClient != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		streamClient.Transport = transport
+	}
+	return nil
+}
+
 // setExtraHeaders sets additional headers from NetworkConfig to the fasthttp request.
 // This allows users to configure custom headers for their provider requests.
 // Header keys are canonicalized using textproto.CanonicalMIMEHeaderKey to avoid duplicates.
@@ -258,11 +841,8 @@ func setExtraHeaders(req *fasthttp.Request, extraHeaders map[string]string, skip
 // Header keys are canonicalized using textproto.CanonicalMIMEHeaderKey to avoid duplicates.
 // It accepts a list of headers (all canonicalized) to skip for security reasons.
 // Headers are only set if they don't already exist on the request to avoid overwriting important headers.
+// The request-level headers carried by the request's context are then set over them.
 func setExtraHeadersHTTP(req *http.Request, extraHeaders map[string]string, skipHeaders *[]string) {
-	if extraHeaders == nil {
-		return
-	}
-
 	for key, value := range extraHeaders {
 		canonicalKey := textproto.CanonicalMIMEHeaderKey(key)
 		// Skip Authorization header for security reasons
@@ -279,6 +859,21 @@ func setExtraHeadersHTTP(req *http.Request, extraHeaders map[string]string, skip
 			req.Header.Set(canonicalKey, value)
 		}
 	}
+
+	for key, value := range requestExtraHeaders(req.Context()) {
+		req.Header.Set(key, value)
+	}
+}
+
+// requestExtraHeaders returns the request-level headers carried by the context, if any.
+// They are validated against schemas.AllowedRequestHeaders by Bifrost before reaching providers,
+// and override the headers from NetworkConfig. fasthttp requests get them in makeRequestWithContext.
+func requestExtraHeaders(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	headers, _ := ctx.Value(schemas.BifrostContextKeyExtraHeaders).(map[string]string)
+	return headers
 }
 
 // handleProviderAPIError processes error responses from provider APIs.
@@ -312,21 +907,20 @@ func handleProviderAPIError(resp *fasthttp.Response, errorResp any) *schemas.Bif
 func handleProviderResponse[T any](responseBody []byte, response *T, sendBackRawResponse bool) (interface{}, *schemas.BifrostError) {
 	var rawResponse interface{}
 
-	var wg sync.WaitGroup
 	var structuredErr, rawErr error
-
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		structuredErr = sonic.Unmarshal(responseBody, response)
-	}()
-	go func() {
-		defer wg.Done()
-		if sendBackRawResponse {
+	if sendBackRawResponse {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 			rawErr = sonic.Unmarshal(responseBody, &rawResponse)
-		}
-	}()
-	wg.Wait()
+		}()
+		structuredErr = sonic.Unmarshal(responseBody, response)
+		wg.Wait()
+	} else {
+		// Most requests do not need the raw response, and are not worth a goroutine
+		structuredErr = sonic.Unmarshal(responseBody, response)
+	}
 
 	if structuredErr != nil {
 		return nil, &schemas.BifrostError{
@@ -383,12 +977,84 @@ func float64Ptr(f float64) *float64 {
 	return &f
 }
 
+// intPtr creates a pointer to an int value.
+func intPtr(i int) *int {
+	return &i
+}
+
 // StrPtr creates a pointer to a string value.
 // This is a helper function for creating pointers to string values.
 func StrPtr(s string) *string {
 	return &s
 }
 
+//* MESSAGE UTILS *//
+
+// normalizeMessageContent returns the content of a message as content blocks, the canonical form
+// provider serializers render from: string content becomes a single text block, empty text blocks
+// and images without a URL are dropped, and image URLs are sanitized. The blocks are copies, so
+// the message is never modified and renders the same for every provider it is sent to, e.g. when
+// a request falls back to another provider.
+func normalizeMessageContent(content schemas.MessageContent) []schemas.ContentBlock {
+	if content.ContentStr != nil {
+		if *content.ContentStr == "" {
+			return nil
+		}
+		text := *content.ContentStr
+		return []schemas.ContentBlock{{Type: schemas.ContentBlockTypeText, Text: &text}}
+	}
+	if content.ContentBlocks == nil {
+		return nil
+	}
+
+	blocks := make([]schemas.ContentBlock, 0, len(*content.ContentBlocks))
+	for _, block := range *content.ContentBlocks {
+		if block.Text != nil && *block.Text != "" {
+			text := *block.Text
+			blocks = append(blocks, schemas.ContentBlock{Type: schemas.ContentBlockTypeText, Text: &text})
+		}
+		if block.ImageURL != nil && block.ImageURL.URL != "" {
+			sanitizedURL, _ := SanitizeImageURL(block.ImageURL.URL)
+			blocks = append(blocks, schemas.ContentBlock{
+				Type:     schemas.ContentBlockTypeImage,
+				ImageURL: &schemas.ImageURLStruct{URL: sanitizedURL, Detail: block.ImageURL.Detail},
+			})
+		}
+	}
+	return blocks
+}
+
+// hasToolResultImages reports whether any of the messages is a tool result with image content.
+func hasToolResultImages(messages []schemas.BifrostMessage) bool {
+	for _, msg := range messages {
+		if msg.Role != schemas.ModelChatMessageRoleTool || msg.Content.ContentBlocks == nil {
+			continue
+		}
+		for _, block := range *msg.Content.ContentBlocks {
+			if block.ImageURL != nil && block.ImageURL.URL != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitToolResultContent splits the normalized content of a tool message into its text, joined
+// with newlines, and its images, for APIs whose tool results can only carry text.
+func splitToolResultContent(content schemas.MessageContent) (string, []schemas.ContentBlock) {
+	var texts []string
+	var images []schemas.ContentBlock
+	for _, block := range normalizeMessageContent(content) {
+		if block.Text != nil {
+			texts = append(texts, *block.Text)
+		}
+		if block.ImageURL != nil {
+			images = append(images, block)
+		}
+	}
+	return strings.Join(texts, "\n"), images
+}
+
 //* IMAGE UTILS *//
 
 // SanitizeImageURL sanitizes and validates an image URL.
@@ -555,6 +1221,7 @@ func newUnsupportedOperationError(operation string, providerName string) *schema
 	return &schemas.BifrostError{
 		IsBifrostError: false,
 		Error: schemas.ErrorField{
+			Type:    StrPtr(schemas.UnsupportedOperation),
 			Message: fmt.Sprintf("%s is not supported by %s provider", operation, providerName),
 		},
 	}
@@ -585,6 +1252,20 @@ func newBifrostOperationError(message string, err error, providerType schemas.Mo
 	}
 }
 
+// newProviderRequestError creates the error of a provider request that could not be sent or got
+// no response, of kind schemas.ErrorKindConnection.
+func newProviderRequestError(err error, providerType schemas.ModelProvider) *schemas.BifrostError {
+	return &schemas.BifrostError{
+		IsBifrostError: false,
+		Provider:       providerType,
+		Kind:           schemas.ErrorKindConnection,
+		Error: schemas.ErrorField{
+			Message: schemas.ErrProviderRequest,
+			Error:   err,
+		},
+	}
+}
+
 // newProviderAPIError creates a standardized error for provider API errors.
 // This helper reduces code duplication across providers that have provider API errors.
 func newProviderAPIError(message string, err error, statusCode int, providerType schemas.ModelProvider, errorType *string, eventID *string) *schemas.BifrostError {
@@ -602,6 +1283,65 @@ func newProviderAPIError(message string, err error, statusCode int, providerType
 	}
 }
 
+// newBatchItemError creates an error for a single item of a batch that the provider reported as failed.
+func newBatchItemError(message string, errorType string, providerType schemas.ModelProvider) *schemas.BifrostError {
+	bifrostErr := &schemas.BifrostError{
+		IsBifrostError: false,
+		Provider:       providerType,
+		Error: schemas.ErrorField{
+			Message: message,
+		},
+	}
+	if errorType != "" {
+		bifrostErr.Error.Type = &errorType
+	}
+	return bifrostErr
+}
+
+// splitJSONLines splits a JSONL body into its non-empty lines.
+func splitJSONLines(body []byte) [][]byte {
+	var lines [][]byte
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// validateEmbeddingDimensions validates the requested embedding output dimensions against the model's limits.
+// It returns a configuration error if the dimensions are not positive, if the model does not support
+// dimension reduction, or if the requested dimensions are not among the values the model accepts.
+func validateEmbeddingDimensions(model string, dimensions int, providerType schemas.ModelProvider) *schemas.BifrostError {
+	if dimensions <= 0 {
+		return newConfigurationError(fmt.Sprintf("embedding dimensions must be a positive integer, received: %d", dimensions), providerType)
+	}
+
+	limits, ok := embeddingModelDimensions[model]
+	if !ok {
+		return nil
+	}
+
+	if limits.max == 0 {
+		return newConfigurationError(fmt.Sprintf("model %s does not support the dimensions parameter", model), providerType)
+	}
+
+	if dimensions > limits.max {
+		return newConfigurationError(fmt.Sprintf("requested dimensions %d exceed the maximum of %d for model %s", dimensions, limits.max, model), providerType)
+	}
+
+	if len(limits.allowed) > 0 && !slices.Contains(limits.allowed, dimensions) {
+		allowed := make([]string, len(limits.allowed))
+		for i, value := range limits.allowed {
+			allowed[i] = strconv.Itoa(value)
+		}
+		return newConfigurationError(fmt.Sprintf("requested dimensions %d are not supported by model %s, which accepts: %s", dimensions, model, strings.Join(allowed, ", ")), providerType)
+	}
+
+	return nil
+}
+
 // approximateTokenCount provides a rough approximation of token count for text.
 // WARNING: This is a best-effort approximation using 1 token per 4 characters.
 // This heuristic is particularly inaccurate for:
@@ -661,6 +1401,27 @@ func processAndSendResponse(
 	}
 }
 
+// newStreamChannel returns the channel of a stream's chunks, with the buffer size configured in
+// the context, or schemas.DefaultStreamBufferSize if none is.
+func newStreamChannel(ctx context.Context) chan *schemas.BifrostStream {
+	bufferSize, ok := ctx.Value(schemas.BifrostContextKeyStreamBufferSize).(int)
+	if !ok || bufferSize <= 0 {
+		bufferSize = schemas.DefaultStreamBufferSize
+	}
+	return make(chan *schemas.BifrostStream, bufferSize)
+}
+
+// logStreamReadError logs an error reading a provider's stream. An error caused by the
+// cancellation of ctx (e.g. the client disconnected) is expected: the connection to the provider
+// was closed, which stops the generation, so it is only logged at debug level.
+func logStreamReadError(ctx context.Context, logger schemas.Logger, stream string, err error) {
+	if ctx.Err() != nil {
+		logger.Debug(fmt.Sprintf("Stopped reading %s after cancellation (%v), closed the connection to abort the generation: %v", stream, ctx.Err(), err))
+		return
+	}
+	logger.Warn(fmt.Sprintf("Error reading %s: %v", stream, err))
+}
+
 // processAndSendError handles post-hook processing and sends the error to the channel.
 // This utility reduces code duplication across streaming implementations by encapsulating
 // the common pattern of running post hooks, handling errors, and sending responses with
@@ -690,3 +1451,113 @@ func processAndSendError(
 	case <-ctx.Done():
 	}
 }
+
+// setEmbeddings sets the embeddings of an OpenAI compatible embedding response on the Bifrost response.
+// Float arrays are converted to float32 slices on Embedding, while base64 strings (returned for the
+// "base64" encoding format) are kept as-is on EmbeddingBase64, so they are only decoded on demand.
+func setEmbeddings(bifrostResponse *schemas.BifrostResponse, values []interface{}, providerType schemas.ModelProvider) *schemas.BifrostError {
+	for i, value := range values {
+		if encoded, ok := value.(string); ok {
+			if bifrostResponse.EmbeddingBase64 == nil {
+				bifrostResponse.EmbeddingBase64 = make([]string, len(values))
+			}
+			bifrostResponse.EmbeddingBase64[i] = encoded
+			continue
+		}
+
+		var embedding []float32
+		switch v := value.(type) {
+		case []float32:
+			embedding = v
+		case []float64:
+			embedding = make([]float32, len(v))
+			for j := range v {
+				embedding[j] = float32(v[j])
+			}
+		case []interface{}:
+			embedding = make([]float32, len(v))
+			for j := range v {
+				num, ok := v[j].(float64)
+				if !ok {
+					return newBifrostOperationError(fmt.Sprintf("unsupported number type in embedding array: %T", v[j]), nil, providerType)
+				}
+				embedding[j] = float32(num)
+			}
+		default:
+			return newBifrostOperationError(fmt.Sprintf("unsupported embedding type: %T", value), nil, providerType)
+		}
+
+		if bifrostResponse.Embedding == nil {
+			bifrostResponse.Embedding = make([][]float32, len(values))
+		}
+		bifrostResponse.Embedding[i] = embedding
+	}
+
+	return nil
+}
+
+// contentFilterFinishReasons lists the provider finish reasons reporting that the provider's
+// safety system blocked or truncated the output, keyed by lower-case reason.
+var contentFilterFinishReasons = map[string]bool{
+	"content_filter":       true, // OpenAI, Azure and OpenAI compatible providers
+	"refusal":              true, // Anthropic
+	"error_toxic":          true, // Cohere
+	"safety":               true, // Gemini (Vertex)
+	"prohibited_content":   true, // Gemini (Vertex)
+	"blocklist":            true, // Gemini (Vertex)
+	"spii":                 true, // Gemini (Vertex)
+	"image_safety":         true, // Gemini (Vertex)
+	"guardrail_intervened": true, // Bedrock guardrails
+	"content_filtered":     true, // Bedrock
+}
+
+// groundingFromAnnotations returns the URL citations annotated on the messages of OpenAI
+// compatible choices as grounding metadata, or nil if there are none.
+func groundingFromAnnotations(choices []schemas.BifrostResponseChoice) *schemas.GroundingMetadata {
+	var citations []schemas.GroundingCitation
+	for _, choice := range choices {
+		if choice.BifrostNonStreamResponseChoice == nil || choice.Message.AssistantMessage == nil {
+			continue
+		}
+		for _, annotation := range choice.Message.Annotations {
+			if annotation.Type != "url_citation" {
+				continue
+			}
+			citation := schemas.GroundingCitation{
+				ChoiceIndex: choice.Index,
+				StartIndex:  intPtr(annotation.Citation.StartIndex),
+				EndIndex:    intPtr(annotation.Citation.EndIndex),
+				Title:       annotation.Citation.Title,
+			}
+			if annotation.Citation.URL != nil {
+				citation.URL = *annotation.Citation.URL
+			}
+			citations = append(citations, citation)
+		}
+	}
+
+	if len(citations) == 0 {
+		return nil
+	}
+	return &schemas.GroundingMetadata{Citations: citations}
+}
+
+// applyContentFilter marks the choices blocked by the provider's safety system: their finish reason
+// is normalized to schemas.FinishReasonContentFilter, and ContentFilter records the provider's
+// original reason. Providers reporting the triggered categories set ContentFilter beforehand.
+func applyContentFilter(choices []schemas.BifrostResponseChoice) {
+	for i := range choices {
+		choice := &choices[i]
+		if choice.FinishReason == nil || !contentFilterFinishReasons[strings.ToLower(*choice.FinishReason)] {
+			continue
+		}
+
+		if choice.ContentFilter == nil {
+			choice.ContentFilter = &schemas.ContentFilterResult{}
+		}
+		if choice.ContentFilter.Reason == "" {
+			choice.ContentFilter.Reason = *choice.FinishReason
+		}
+		choice.FinishReason = StrPtr(schemas.FinishReasonContentFilter)
+	}
+}
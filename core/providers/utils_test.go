@@ -0,0 +1,1055 @@
+package providers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/bytedance/sonic"
+	"github.com/klauspost/compress/zstd"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/meta"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSetEmbeddingsBase64(t *testing.T) {
+	want := []float32{0.5, -1.25, 3}
+	raw := make([]byte, 4*len(want))
+	for i, v := range want {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(v))
+	}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	response := &schemas.BifrostResponse{}
+	if bifrostErr := setEmbeddings(response, []interface{}{encoded}, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("setEmbeddings() error = %v", bifrostErr.Error.Message)
+	}
+	if response.Embedding != nil {
+		t.Errorf("Embedding = %v, want nil for base64 input", response.Embedding)
+	}
+	if !reflect.DeepEqual(response.EmbeddingBase64, []string{encoded}) {
+		t.Errorf("EmbeddingBase64 = %v, want raw input", response.EmbeddingBase64)
+	}
+
+	decoded, err := response.DecodeEmbeddings()
+	if err != nil {
+		t.Fatalf("DecodeEmbeddings() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, [][]float32{want}) {
+		t.Errorf("DecodeEmbeddings() = %v, want %v", decoded, [][]float32{want})
+	}
+}
+
+func TestValidateEmbeddingDimensions(t *testing.T) {
+	for _, tt := range []struct {
+		model      string
+		dimensions int
+		wantErr    bool
+	}{
+		{"text-embedding-3-small", 512, false},
+		{"text-embedding-3-small", 1537, true},
+		{"text-embedding-3-large", 3072, false},
+		{"text-embedding-ada-002", 512, true}, // Fixed output size
+		{"embed-v4.0", 1024, false},
+		{"embed-v4.0", 1000, true}, // Below the maximum, but not one of the accepted values
+		{"codestral-embed", 100, false},
+		{"unknown-embed", 100000, false}, // Left to the provider
+		{"unknown-embed", 0, true},
+	} {
+		bifrostErr := validateEmbeddingDimensions(tt.model, tt.dimensions, schemas.OpenAI)
+		if (bifrostErr != nil) != tt.wantErr {
+			t.Errorf("validateEmbeddingDimensions(%s, %d) = %+v, want error %v", tt.model, tt.dimensions, bifrostErr, tt.wantErr)
+		}
+	}
+}
+
+func TestEmbeddingDimensionsAreSent(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path == "/v2/embed" {
+			fmt.Fprint(w, `{"id":"emb-1","embeddings":{"float":[[0.1,0.2]]}}`)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"model":"m","usage":{"prompt_tokens":1,"total_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	config := func() *schemas.ProviderConfig {
+		return &schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}}
+	}
+	openAI, _ := NewOpenAIProvider(config(), testLogger{})
+	cohere, _ := NewCohereProvider(config(), testLogger{})
+	mistral, _ := NewMistralProvider(config(), testLogger{})
+	input := &schemas.EmbeddingInput{Texts: []string{"Hello"}}
+	key := schemas.Key{Value: "test-key"}
+
+	for _, tt := range []struct {
+		provider   schemas.Provider
+		model      string
+		dimensions int
+		field      string // Name of the dimensions in the request body, empty if the request is rejected
+	}{
+		{openAI, "text-embedding-3-small", 512, "dimensions"},
+		{openAI, "text-embedding-ada-002", 512, ""},
+		{cohere, "embed-v4.0", 256, "output_dimension"},
+		{cohere, "embed-v4.0", 300, ""},
+		{mistral, "codestral-embed", 1024, "output_dimension"},
+		{mistral, "codestral-embed", 4096, ""},
+	} {
+		body = nil
+		params := &schemas.ModelParameters{Dimensions: intPtr(tt.dimensions)}
+		_, bifrostErr := tt.provider.Embedding(context.Background(), tt.model, key, input, params)
+		name := fmt.Sprintf("%s %s with %d dimensions", tt.provider.GetProviderKey(), tt.model, tt.dimensions)
+
+		if tt.field == "" {
+			if bifrostErr == nil || body != nil {
+				t.Errorf("%s: error = %+v, sent %v, want the request rejected before it is sent", name, bifrostErr, body)
+			}
+			continue
+		}
+		if bifrostErr != nil {
+			t.Errorf("%s: error = %+v", name, bifrostErr)
+			continue
+		}
+		if body[tt.field] != float64(tt.dimensions) {
+			t.Errorf("%s: request body = %v, want %s = %d", name, body, tt.field, tt.dimensions)
+		}
+	}
+}
+
+func TestSetEmbeddingsFloat(t *testing.T) {
+	response := &schemas.BifrostResponse{}
+	if bifrostErr := setEmbeddings(response, []interface{}{[]interface{}{0.5, 2.0}}, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("setEmbeddings() error = %v", bifrostErr.Error.Message)
+	}
+
+	decoded, err := response.DecodeEmbeddings()
+	if err != nil {
+		t.Fatalf("DecodeEmbeddings() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, [][]float32{{0.5, 2}}) {
+		t.Errorf("DecodeEmbeddings() = %v, want [[0.5 2]]", decoded)
+	}
+}
+
+func TestMakeRequestWithContextBodyTransforms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyBodyTransforms, &schemas.BodyTransforms{
+		Request: func(body []byte) ([]byte, error) {
+			return append(body, " request"...), nil
+		},
+		Response: func(body []byte) ([]byte, error) {
+			return append(body, " response"...), nil
+		},
+	})
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(server.URL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.SetBodyString("body")
+
+	if bifrostErr := makeRequestWithContext(ctx, &fasthttp.Client{}, req, resp, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("makeRequestWithContext() error = %v", bifrostErr.Error.Message)
+	}
+	if got := string(resp.Body()); got != "body request response" {
+		t.Errorf("response body = %q, want %q", got, "body request response")
+	}
+}
+
+func TestMakeRequestWithContextDecompressesResponse(t *testing.T) {
+	const payload = `{"data":[{"embedding":[0.5,-1.25,3]}]}`
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(payload))
+		gz.Close()
+	}))
+	defer server.Close()
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(server.URL)
+	if bifrostErr := makeRequestWithContext(context.Background(), &fasthttp.Client{}, req, resp, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("makeRequestWithContext() error = %v", bifrostErr.Error.Message)
+	}
+	if acceptEncoding != acceptedEncodings {
+		t.Errorf("Accept-Encoding = %q, want %q", acceptEncoding, acceptedEncodings)
+	}
+	if got := string(resp.Body()); got != payload || len(resp.Header.ContentEncoding()) != 0 {
+		t.Errorf("response body = %q with encoding %q, want the decompressed payload", got, resp.Header.ContentEncoding())
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	const payload = `{"data":[{"embedding":[0.5,-1.25,3]}]}`
+	var zlibBody, brotliBody bytes.Buffer
+	zw := zlib.NewWriter(&zlibBody)
+	zw.Write([]byte(payload))
+	zw.Close()
+	bw := brotli.NewWriter(&brotliBody)
+	bw.Write([]byte(payload))
+	bw.Close()
+	encoder, _ := zstd.NewWriter(nil)
+	bodies := map[string][]byte{
+		"gzip":    fasthttp.AppendGzipBytes(nil, []byte(payload)),
+		"deflate": zlibBody.Bytes(),
+		"br":      brotliBody.Bytes(),
+		"zstd":    encoder.EncodeAll([]byte(payload), nil),
+	}
+	for encoding, body := range bodies {
+		if got, err := decompress(encoding, body, len(payload)); err != nil || string(got) != payload {
+			t.Errorf("decompress(%s) = %q, %v, want the payload", encoding, got, err)
+		}
+		// Bodies that decompress beyond the limit are rejected
+		if _, err := decompress(encoding, body, len(payload)-1); err == nil {
+			t.Errorf("decompress(%s) with a limit below the payload size succeeded, want an error", encoding)
+		}
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	resp.Header.SetContentEncoding("gzip")
+	resp.SetBody([]byte("not gzip"))
+	if bifrostErr := decompressResponseBody(resp, schemas.Anthropic); bifrostErr == nil || bifrostErr.Error.Message != schemas.ErrProviderDecompress || bifrostErr.Provider != schemas.Anthropic {
+		t.Errorf("decompressResponseBody() error = %+v, want a decompression error of the provider", bifrostErr)
+	}
+}
+
+func TestHTTPProvidersDecompressResponses(t *testing.T) {
+	var brotliBody, zlibBody bytes.Buffer
+	bw := brotli.NewWriter(&brotliBody)
+	bw.Write([]byte(`{"output":{"message":{"role":"assistant","content":[{"text":"Hi"}]}},"stopReason":"end_turn","usage":{"inputTokens":3,"outputTokens":1,"totalTokens":4}}`))
+	bw.Close()
+	zw := zlib.NewWriter(&zlibBody)
+	zw.Write([]byte(`{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	zw.Close()
+	compressed := func(encoding string, body []byte, acceptEncoding *string) roundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			*acceptEncoding = r.Header.Get("Accept-Encoding")
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Encoding": {encoding}}, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		}
+	}
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+
+	// Bedrock and Vertex use net/http, whose transport only decodes gzip on its own
+	var bedrockAccept string
+	bedrock, _ := NewBedrockProvider(&schemas.ProviderConfig{MetaConfig: &meta.BedrockMetaConfig{SecretAccessKey: "secret", Region: StrPtr("us-east-1")}}, testLogger{})
+	bedrock.client.Transport = compressed("br", brotliBody.Bytes(), &bedrockAccept)
+	response, bifrostErr := bedrock.ChatCompletion(context.Background(), "anthropic.claude-3-5-sonnet-20240620-v1:0", schemas.Key{Value: "access"}, messages, nil)
+	if bifrostErr != nil {
+		t.Fatalf("Bedrock ChatCompletion() error = %+v", bifrostErr)
+	}
+	if content := response.Choices[0].Message.Content.ContentBlocks; content == nil || *(*content)[0].Text != "Hi" || bedrockAccept != acceptedEncodings {
+		t.Errorf("Bedrock sent Accept-Encoding %q and returned %+v, want %q and the decompressed response", bedrockAccept, response.Choices[0].Message, acceptedEncodings)
+	}
+
+	var vertexAccept string
+	vertex, _ := NewVertexProvider(&schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{DefaultRequestTimeoutInSeconds: 5}}, testLogger{})
+	vertex.clientPool.Store(getClientKey("credentials"), &http.Client{Transport: compressed("deflate", zlibBody.Bytes(), &vertexAccept)})
+	vertexKey := schemas.Key{VertexKeyConfig: &schemas.VertexKeyConfig{ProjectID: "project", Region: "us-central1", AuthCredentials: "credentials"}}
+	response, bifrostErr = vertex.ChatCompletion(context.Background(), "gemini-1.5-pro", vertexKey, messages, nil)
+	if bifrostErr != nil {
+		t.Fatalf("Vertex ChatCompletion() error = %+v", bifrostErr)
+	}
+	if content := response.Choices[0].Message.Content.ContentStr; content == nil || *content != "Hi" || vertexAccept != acceptedEncodings {
+		t.Errorf("Vertex sent Accept-Encoding %q and returned %+v, want %q and the decompressed response", vertexAccept, response.Choices[0].Message, acceptedEncodings)
+	}
+}
+
+func TestMakeRequestWithContextCancelledReleasesSafely(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"late":true}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	req.SetRequestURI(server.URL)
+	req.SetBodyString(`{"model":"gpt-4o"}`)
+	cancel()
+
+	bifrostErr := makeRequestWithContext(ctx, &fasthttp.Client{}, req, resp, schemas.OpenAI)
+	if bifrostErr == nil || bifrostErr.Error.Type == nil || *bifrostErr.Error.Type != schemas.RequestCancelled {
+		t.Fatalf("makeRequestWithContext() error = %+v, want a cancellation", bifrostErr)
+	}
+	// The caller releases req and resp while the call is still in flight, and they are reused
+	// right away: under -race, this fails if the call still uses them
+	fasthttp.ReleaseRequest(req)
+	fasthttp.ReleaseResponse(resp)
+	reused := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(reused)
+	reused.SetBodyString("reused")
+	if got := string(reused.Body()); got != "reused" {
+		t.Errorf("reused response body = %q", got)
+	}
+}
+
+func TestMakeRequestWithContextCompressesRequest(t *testing.T) {
+	var encoding, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		reader := io.Reader(r.Body)
+		if encoding == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			reader = gz
+		}
+		raw, _ := io.ReadAll(reader)
+		body = string(raw)
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestCompression, &schemas.RequestCompressionConfig{MinSizeBytes: 100})
+	for _, payload := range []string{`{"prompt":"short"}`, `{"prompt":"` + strings.Repeat("long ", 100) + `"}`} {
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+		req.SetRequestURI(server.URL)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.SetBodyString(payload)
+
+		if bifrostErr := makeRequestWithContext(ctx, &fasthttp.Client{}, req, resp, schemas.OpenAI); bifrostErr != nil {
+			t.Fatalf("makeRequestWithContext() error = %v", bifrostErr.Error.Message)
+		}
+		if wantEncoding := map[bool]string{true: "gzip"}[len(payload) >= 100]; encoding != wantEncoding {
+			t.Errorf("Content-Encoding of a %d byte body = %q, want %q", len(payload), encoding, wantEncoding)
+		}
+		if body != payload {
+			t.Errorf("received body = %q, want %q", body, payload)
+		}
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+	}
+}
+
+func TestCompressHTTPRequestBody(t *testing.T) {
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestCompression, &schemas.RequestCompressionConfig{MinSizeBytes: 100})
+	for _, payload := range []string{`{"prompt":"short"}`, `{"prompt":"` + strings.Repeat("long ", 100) + `"}`} {
+		req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/m/invoke", strings.NewReader(payload))
+		compressHTTPRequestBody(ctx, req, []byte(payload))
+
+		wantEncoding := map[bool]string{true: "gzip"}[len(payload) >= 100]
+		if encoding := req.Header.Get("Content-Encoding"); encoding != wantEncoding {
+			t.Errorf("Content-Encoding of a %d byte body = %q, want %q", len(payload), encoding, wantEncoding)
+		}
+		reader := io.Reader(req.Body)
+		if wantEncoding == "gzip" {
+			reader, _ = gzip.NewReader(req.Body)
+		}
+		if body, _ := io.ReadAll(reader); string(body) != payload {
+			t.Errorf("request body = %q, want %q", body, payload)
+		}
+		if wantEncoding == "gzip" && req.ContentLength >= int64(len(payload)) {
+			t.Errorf("ContentLength = %d, want the compressed length", req.ContentLength)
+		}
+	}
+}
+
+func TestSetJSONBodyMatchesMarshal(t *testing.T) {
+	body := struct {
+		Model    string                   `json:"model"`
+		Messages []schemas.BifrostMessage `json:"messages"`
+	}{"gpt-4o", []schemas.BifrostMessage{schemas.UserMessage("<b>Hi</b>")}}
+	want, err := sonic.Marshal(body)
+	if err != nil {
+		t.Fatalf("sonic.Marshal() error = %v", err)
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetBodyString(strings.Repeat("previous body ", 100))
+	if err := setJSONBody(req, body); err != nil {
+		t.Fatalf("setJSONBody() error = %v", err)
+	}
+	if got := string(req.Body()); got != string(want) {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	if err := setJSONBody(req, map[string]interface{}{"bad": make(chan int)}); err == nil || len(req.Body()) != 0 {
+		t.Errorf("setJSONBody() error = %v with body %q, want an error and no body", err, req.Body())
+	}
+}
+
+func TestMakeRequestWithContextRequestSigner(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestSigner, schemas.RequestSigner(func(req *schemas.SignableRequest) error {
+		if req.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("signer saw Authorization = %q, want the provider's header", req.Header.Get("Authorization"))
+		}
+		req.Header.Set("X-Signature", fmt.Sprintf("%s %s %s", req.Method, req.Path, req.Body))
+		req.Header.Del("Authorization")
+		return nil
+	}))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(server.URL + "/v1/chat/completions")
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer sk-test")
+	req.SetBodyString("body")
+
+	if bifrostErr := makeRequestWithContext(ctx, &fasthttp.Client{}, req, resp, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("makeRequestWithContext() error = %v", bifrostErr.Error.Message)
+	}
+	if got := received.Get("X-Signature"); got != "POST /v1/chat/completions body" {
+		t.Errorf("X-Signature = %q, want %q", got, "POST /v1/chat/completions body")
+	}
+	if got := received.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want it removed by the signer", got)
+	}
+
+	failing := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestSigner, schemas.RequestSigner(func(req *schemas.SignableRequest) error {
+		return fmt.Errorf("no signing key")
+	}))
+	if bifrostErr := makeRequestWithContext(failing, &fasthttp.Client{}, req, resp, schemas.OpenAI); bifrostErr == nil || bifrostErr.Error.Message != schemas.ErrProviderRequestSigning || bifrostErr.Provider != schemas.OpenAI {
+		t.Errorf("makeRequestWithContext() error = %v, want a signing error of the provider", bifrostErr)
+	}
+}
+
+func TestExtraHeadersRequestLevelOverride(t *testing.T) {
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyExtraHeaders, map[string]string{
+		"OpenAI-Project": "proj_tenant",
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setExtraHeadersHTTP(req, map[string]string{"OpenAI-Project": "proj_default", "X-Static": "static"}, nil)
+
+	if got := req.Header.Get("OpenAI-Project"); got != "proj_tenant" {
+		t.Errorf("OpenAI-Project = %q, want %q", got, "proj_tenant")
+	}
+	if got := req.Header.Get("X-Static"); got != "static" {
+		t.Errorf("X-Static = %q, want %q", got, "static")
+	}
+}
+
+func TestRequestURLPathOverrides(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	// Ollama behind a gateway that mounts it under a prefix and exposes a nonstandard chat path
+	provider, err := NewOllamaProvider(&schemas.ProviderConfig{
+		NetworkConfig: schemas.NetworkConfig{
+			BaseURL:       server.URL + "/llm/ollama/",
+			PathOverrides: map[string]string{"/v1/chat/completions": "/api/chat/completions"},
+		},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewOllamaProvider() error = %v", err)
+	}
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	if _, bifrostErr := provider.ChatCompletion(context.Background(), "llama3", schemas.Key{}, messages, nil); bifrostErr != nil {
+		t.Fatalf("ChatCompletion() error = %v", bifrostErr.Error.Message)
+	}
+	if gotPath != "/llm/ollama/api/chat/completions" {
+		t.Errorf("path = %q, want %q", gotPath, "/llm/ollama/api/chat/completions")
+	}
+
+	network := schemas.NetworkConfig{BaseURL: "http://localhost:11434", PathOverrides: map[string]string{"/v1/chat/completions": "/chat"}}
+	if got := requestURL(context.Background(), network, "/v1/embeddings"); got != "http://localhost:11434/v1/embeddings" {
+		t.Errorf("requestURL() without override = %q, want the default path", got)
+	}
+}
+
+func TestConfigureTLSCustomRootCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+
+	for _, tt := range []struct {
+		name    string
+		tls     *schemas.TLSConfig
+		wantErr bool
+	}{
+		{"system roots only", nil, true},
+		{"custom root CA", &schemas.TLSConfig{CACertPEM: caPEM}, false},
+		{"insecure skip verify", &schemas.TLSConfig{InsecureSkipVerify: true}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewOllamaProvider(&schemas.ProviderConfig{
+				NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL},
+				TLSConfig:     tt.tls,
+			}, testLogger{})
+			if err != nil {
+				t.Fatalf("NewOllamaProvider() error = %v", err)
+			}
+
+			_, bifrostErr := provider.ChatCompletion(context.Background(), "llama3", schemas.Key{}, messages, nil)
+			if (bifrostErr != nil) != tt.wantErr {
+				t.Errorf("ChatCompletion() error = %v, want error %v", bifrostErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigureTLSUnreadableCAFile(t *testing.T) {
+	tlsConfig := &schemas.TLSConfig{CACertPath: "/nonexistent/ca.pem"}
+	region := "us-east-1"
+
+	for name, constructor := range map[string]func() (schemas.Provider, error){
+		"openai": func() (schemas.Provider, error) {
+			return NewOpenAIProvider(&schemas.ProviderConfig{TLSConfig: tlsConfig}, testLogger{})
+		},
+		"bedrock": func() (schemas.Provider, error) {
+			return NewBedrockProvider(&schemas.ProviderConfig{
+				TLSConfig:  tlsConfig,
+				MetaConfig: &meta.BedrockMetaConfig{SecretAccessKey: "secret", Region: &region},
+			}, testLogger{})
+		},
+		"vertex": func() (schemas.Provider, error) {
+			return NewVertexProvider(&schemas.ProviderConfig{TLSConfig: tlsConfig}, testLogger{})
+		},
+	} {
+		// Falling back to the system roots would fail later with a less obvious error
+		if _, err := constructor(); err == nil {
+			t.Errorf("New%sProvider() with an unreadable CA bundle succeeded, want an error", name)
+		}
+	}
+}
+
+func TestConfigureTLSAppliesToNetHTTPClients(t *testing.T) {
+	tlsConfig := &schemas.TLSConfig{InsecureSkipVerify: true}
+	region := "us-east-1"
+
+	bedrock, err := NewBedrockProvider(&schemas.ProviderConfig{
+		TLSConfig:  tlsConfig,
+		MetaConfig: &meta.BedrockMetaConfig{SecretAccessKey: "secret", Region: &region},
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewBedrockProvider() error = %v", err)
+	}
+	if transport, ok := bedrock.client.Transport.(*http.Transport); !ok || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("Bedrock client does not use the TLS configuration")
+	}
+
+	vertex, err := NewVertexProvider(&schemas.ProviderConfig{TLSConfig: tlsConfig}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewVertexProvider() error = %v", err)
+	}
+	if transport, ok := vertex.baseClient.Transport.(*http.Transport); !ok || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("Vertex base client does not use the TLS configuration")
+	}
+}
+
+func TestApplyContentFilter(t *testing.T) {
+	choices := []schemas.BifrostResponseChoice{
+		{Index: 0, FinishReason: StrPtr("SAFETY")},
+		{Index: 1, FinishReason: StrPtr("stop")},
+		{Index: 2, FinishReason: StrPtr(schemas.FinishReasonContentFilter), ContentFilter: &schemas.ContentFilterResult{Categories: []string{"hate"}}},
+	}
+	applyContentFilter(choices)
+
+	if *choices[0].FinishReason != schemas.FinishReasonContentFilter || choices[0].ContentFilter == nil || choices[0].ContentFilter.Reason != "SAFETY" {
+		t.Errorf("blocked choice = %q, %+v, want content_filter with reason SAFETY", *choices[0].FinishReason, choices[0].ContentFilter)
+	}
+	if *choices[1].FinishReason != "stop" || choices[1].ContentFilter != nil {
+		t.Errorf("regular choice was marked as filtered: %q, %+v", *choices[1].FinishReason, choices[1].ContentFilter)
+	}
+	if filter := choices[2].ContentFilter; filter.Reason != schemas.FinishReasonContentFilter || !reflect.DeepEqual(filter.Categories, []string{"hate"}) {
+		t.Errorf("reported categories were not kept: %+v", filter)
+	}
+}
+
+func TestMultimodalMessageRendersForEveryProvider(t *testing.T) {
+	text, empty := "What is in these images?", ""
+	rawImage := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk"
+	messages := []schemas.BifrostMessage{{
+		Role: schemas.ModelChatMessageRoleUser,
+		Content: schemas.MessageContent{ContentBlocks: &[]schemas.ContentBlock{
+			{Type: schemas.ContentBlockTypeText, Text: &text},
+			{Type: schemas.ContentBlockTypeText, Text: &empty},
+			{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: rawImage}},
+			{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: "https://example.com/cat.jpg"}},
+		}},
+	}}
+
+	// OpenAI renders text and image_url parts, with raw base64 turned into a data URL
+	openAIMessages, _ := prepareOpenAIChatRequest(messages, nil)
+	openAIContent, ok := openAIMessages[0]["content"].([]schemas.ContentBlock)
+	if !ok || len(openAIContent) != 3 {
+		t.Fatalf("expected 3 OpenAI content parts, got %#v", openAIMessages[0]["content"])
+	}
+	if got := openAIContent[1].ImageURL.URL; got != "data:image/png;base64,"+rawImage {
+		t.Errorf("expected OpenAI image as data URL, got %s", got)
+	}
+	if got := (*messages[0].Content.ContentBlocks)[2].ImageURL.URL; got != rawImage {
+		t.Fatalf("serializing for OpenAI modified the message image URL: %s", got)
+	}
+
+	// Anthropic, as used by a fallback after OpenAI, renders base64 and URL image sources
+	anthropicMessages, _ := prepareAnthropicChatRequest(messages, nil)
+	wantAnthropic := []interface{}{
+		map[string]interface{}{"type": "text", "text": text},
+		map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": rawImage}},
+		map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "url", "url": "https://example.com/cat.jpg"}},
+	}
+	if !reflect.DeepEqual(anthropicMessages[0]["content"], wantAnthropic) {
+		t.Errorf("unexpected Anthropic content:\n got %#v\nwant %#v", anthropicMessages[0]["content"], wantAnthropic)
+	}
+
+	// Bedrock renders text and image blocks for Anthropic models
+	bedrockBody, bifrostErr := (&BedrockProvider{}).prepareChatCompletionMessages(messages, "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	if bifrostErr != nil {
+		t.Fatalf("unexpected Bedrock error: %v", bifrostErr.Error.Message)
+	}
+	bedrockMessages := bedrockBody["messages"].([]map[string]interface{})
+	bedrockContent := bedrockMessages[0]["content"].([]interface{})
+	if len(bedrockContent) != 3 {
+		t.Fatalf("expected 3 Bedrock content blocks, got %#v", bedrockContent)
+	}
+	if image, ok := bedrockContent[1].(BedrockAnthropicImageMessage); !ok || image.Image.Format != "png" || image.Image.Source.Bytes != rawImage {
+		t.Errorf("unexpected Bedrock image block: %#v", bedrockContent[1])
+	}
+
+	// Cohere does not support images and receives the text only
+	cohereBody, err := prepareCohereChatRequest(messages, nil, "command-r", false)
+	if err != nil {
+		t.Fatalf("unexpected Cohere error: %v", err)
+	}
+	if cohereBody["message"] != text {
+		t.Errorf("expected Cohere message %q, got %#v", text, cohereBody["message"])
+	}
+
+	// Mistral and Vertex send the OpenAI parts, and Vertex the Anthropic content for Claude models
+	wantOpenAI := []interface{}{
+		map[string]interface{}{"type": "text", "text": text},
+		map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "data:image/png;base64," + rawImage}},
+		map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/cat.jpg"}},
+	}
+	response := `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Two images."},"finish_reason":"stop"}]}`
+	sentContent := func(body []byte) interface{} {
+		var request struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil || len(request.Messages) != 1 {
+			t.Fatalf("unexpected request body %s", body)
+		}
+		return request.Messages[0]["content"]
+	}
+
+	var mistralBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mistralBody, _ = io.ReadAll(r.Body)
+		fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+	mistral, _ := NewMistralProvider(&schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}}, testLogger{})
+	if _, bifrostErr := mistral.ChatCompletion(context.Background(), "pixtral-12b", schemas.Key{Value: "key"}, messages, nil); bifrostErr != nil {
+		t.Fatalf("unexpected Mistral error: %+v", bifrostErr)
+	}
+	if got := sentContent(mistralBody); !reflect.DeepEqual(got, wantOpenAI) {
+		t.Errorf("unexpected Mistral content:\n got %#v\nwant %#v", got, wantOpenAI)
+	}
+
+	vertex, _ := NewVertexProvider(&schemas.ProviderConfig{NetworkConfig: schemas.NetworkConfig{DefaultRequestTimeoutInSeconds: 5}}, testLogger{})
+	vertexBody, vertexResponse := []byte(nil), response
+	vertexKey := schemas.Key{VertexKeyConfig: &schemas.VertexKeyConfig{ProjectID: "project", Region: "us-central1", AuthCredentials: "credentials"}}
+	vertex.clientPool.Store(getClientKey("credentials"), &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		vertexBody, _ = io.ReadAll(r.Body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(vertexResponse))}, nil
+	})})
+	if _, bifrostErr := vertex.ChatCompletion(context.Background(), "gemini-1.5-pro", vertexKey, messages, nil); bifrostErr != nil {
+		t.Fatalf("unexpected Vertex error: %+v", bifrostErr)
+	}
+	if got := sentContent(vertexBody); !reflect.DeepEqual(got, wantOpenAI) {
+		t.Errorf("unexpected Vertex content:\n got %#v\nwant %#v", got, wantOpenAI)
+	}
+	vertexResponse = `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Two images."}],"stop_reason":"end_turn"}`
+	if _, bifrostErr := vertex.ChatCompletion(context.Background(), "claude-3-5-sonnet@20240620", vertexKey, messages, nil); bifrostErr != nil {
+		t.Fatalf("unexpected Vertex Claude error: %+v", bifrostErr)
+	}
+	if got := sentContent(vertexBody); !reflect.DeepEqual(got, wantAnthropic) {
+		t.Errorf("unexpected Vertex Claude content:\n got %#v\nwant %#v", got, wantAnthropic)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper that answers requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestToolResultImagesRenderForEveryProvider(t *testing.T) {
+	summary, answer := "Monthly revenue", "Revenue is flat."
+	rawImage := "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk"
+	toolCalls := []schemas.ToolCall{
+		{ID: StrPtr("call_chart"), Function: schemas.FunctionCall{Name: StrPtr("render_chart"), Arguments: "{}"}},
+		{ID: StrPtr("call_total"), Function: schemas.FunctionCall{Name: StrPtr("total"), Arguments: "{}"}},
+	}
+	messages := []schemas.BifrostMessage{
+		schemas.UserMessage("Chart the revenue"),
+		{Role: schemas.ModelChatMessageRoleAssistant, AssistantMessage: &schemas.AssistantMessage{ToolCalls: &toolCalls}},
+		{
+			Role: schemas.ModelChatMessageRoleTool,
+			Content: schemas.MessageContent{ContentBlocks: &[]schemas.ContentBlock{
+				{Type: schemas.ContentBlockTypeText, Text: &summary},
+				{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: "data:image/png;base64," + rawImage}},
+			}},
+			ToolMessage: &schemas.ToolMessage{ToolCallID: StrPtr("call_chart")},
+		},
+		{Role: schemas.ModelChatMessageRoleTool, Content: schemas.MessageContent{ContentStr: StrPtr("42")}, ToolMessage: &schemas.ToolMessage{ToolCallID: StrPtr("call_total")}},
+		schemas.AssistantTextMessage(answer),
+	}
+
+	// OpenAI compatible APIs get the text in the tool message, and the image in a user message
+	// after all the tool messages of the turn
+	openAIMessages, _ := prepareOpenAIChatRequest(messages, nil)
+	var roles []string
+	for _, message := range openAIMessages {
+		roles = append(roles, fmt.Sprint(message["role"]))
+	}
+	if want := []string{"user", "assistant", "tool", "tool", "user", "assistant"}; !reflect.DeepEqual(roles, want) {
+		t.Fatalf("OpenAI roles = %v, want %v", roles, want)
+	}
+	if openAIMessages[2]["content"] != summary {
+		t.Errorf("OpenAI tool content = %#v, want the text only", openAIMessages[2]["content"])
+	}
+	images, _ := openAIMessages[4]["content"].([]schemas.ContentBlock)
+	if len(images) != 2 || !strings.Contains(*images[0].Text, "call_chart") || images[1].ImageURL.URL != "data:image/png;base64,"+rawImage {
+		t.Errorf("OpenAI tool images = %#v, want the labelled image", openAIMessages[4]["content"])
+	}
+
+	// Anthropic carries images in the tool result
+	anthropicMessages, _ := prepareAnthropicChatRequest(messages, nil)
+	toolResult := fmt.Sprint(anthropicMessages[2]["content"])
+	if !strings.Contains(toolResult, "tool_result") || !strings.Contains(toolResult, "type:image") || !strings.Contains(toolResult, rawImage) {
+		t.Errorf("Anthropic tool result = %s, want the image in it", toolResult)
+	}
+
+	// Bedrock carries images in the tool result of Anthropic models, and rejects them for Mistral
+	bedrockBody, bifrostErr := (&BedrockProvider{}).prepareChatCompletionMessages(messages, "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	if bifrostErr != nil {
+		t.Fatalf("unexpected Bedrock error: %v", bifrostErr.Error.Message)
+	}
+	bedrockResults := bedrockBody["messages"].([]map[string]interface{})[2]["content"].([]interface{})
+	chartResult := bedrockResults[0].(map[string]interface{})["toolResult"].(map[string]interface{})["content"].([]map[string]interface{})
+	if image, ok := chartResult[1]["image"].(BedrockAnthropicImage); !ok || image.Format != "png" || image.Source.Bytes != rawImage {
+		t.Errorf("Bedrock tool result = %#v, want the image block", chartResult)
+	}
+	_, bifrostErr = (&BedrockProvider{}).prepareChatCompletionMessages(messages, "mistral.mistral-large-2407-v1:0")
+	if bifrostErr == nil || *bifrostErr.Error.Type != schemas.UnsupportedOperation {
+		t.Errorf("Bedrock Mistral error = %+v, want an unsupported operation", bifrostErr)
+	}
+
+	// Cohere rejects images, and sends text content blocks of tool results as their output
+	if _, err := prepareCohereChatRequest(messages, nil, "command-r", false); err != errCohereToolResultImages {
+		t.Errorf("Cohere error = %v, want %v", err, errCohereToolResultImages)
+	}
+	(*messages[2].Content.ContentBlocks) = (*messages[2].Content.ContentBlocks)[:1]
+	cohereBody, err := prepareCohereChatRequest(messages, nil, "command-r", false)
+	if err != nil {
+		t.Fatalf("unexpected Cohere error: %v", err)
+	}
+	history := cohereBody["chat_history"].([]map[string]interface{})
+	if outputs := history[2]["tool_results"].([]map[string]interface{})[0]["outputs"]; outputs != summary {
+		t.Errorf("Cohere tool outputs = %#v, want %q", outputs, summary)
+	}
+}
+
+func TestInterleavedContentKeepsOrder(t *testing.T) {
+	first, second := "Look at this:", "Now this:"
+	messages := []schemas.BifrostMessage{{
+		Role: schemas.ModelChatMessageRoleUser,
+		Content: schemas.MessageContent{ContentBlocks: &[]schemas.ContentBlock{
+			{Type: schemas.ContentBlockTypeText, Text: &first},
+			{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: "https://example.com/1.jpg"}},
+			{Type: schemas.ContentBlockTypeText, Text: &second},
+			{Type: schemas.ContentBlockTypeImage, ImageURL: &schemas.ImageURLStruct{URL: "https://example.com/2.jpg"}},
+		}},
+	}}
+	want := []string{first, "https://example.com/1.jpg", second, "https://example.com/2.jpg"}
+
+	openAIMessages, _ := prepareOpenAIChatRequest(messages, nil)
+	var openAIParts []string
+	for _, block := range openAIMessages[0]["content"].([]schemas.ContentBlock) {
+		if block.Text != nil {
+			openAIParts = append(openAIParts, *block.Text)
+		} else {
+			openAIParts = append(openAIParts, block.ImageURL.URL)
+		}
+	}
+	if !reflect.DeepEqual(openAIParts, want) {
+		t.Errorf("OpenAI content parts = %v, want %v", openAIParts, want)
+	}
+
+	anthropicMessages, _ := prepareAnthropicChatRequest(messages, nil)
+	var anthropicParts []string
+	for _, part := range anthropicMessages[0]["content"].([]interface{}) {
+		part := part.(map[string]interface{})
+		if text, ok := part["text"].(string); ok {
+			anthropicParts = append(anthropicParts, text)
+		} else {
+			anthropicParts = append(anthropicParts, part["source"].(map[string]interface{})["url"].(string))
+		}
+	}
+	if !reflect.DeepEqual(anthropicParts, want) {
+		t.Errorf("Anthropic content parts = %v, want %v", anthropicParts, want)
+	}
+
+	bedrockBody, bifrostErr := (&BedrockProvider{}).prepareChatCompletionMessages(messages, "anthropic.claude-3-5-sonnet-20240620-v1:0")
+	if bifrostErr != nil {
+		t.Fatalf("unexpected Bedrock error: %v", bifrostErr.Error.Message)
+	}
+	var bedrockParts []string
+	for _, part := range bedrockBody["messages"].([]map[string]interface{})[0]["content"].([]interface{}) {
+		switch part := part.(type) {
+		case BedrockAnthropicTextMessage:
+			bedrockParts = append(bedrockParts, part.Text)
+		case BedrockAnthropicImageMessage:
+			bedrockParts = append(bedrockParts, part.Image.Source.Bytes)
+		}
+	}
+	if !reflect.DeepEqual(bedrockParts, want) {
+		t.Errorf("Bedrock content parts = %v, want %v", bedrockParts, want)
+	}
+}
+
+func TestTransformRequestBodyRecordsRedactedBody(t *testing.T) {
+	image := strings.Repeat("iVBORw0KGgo", 40)
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"What is \"this\"?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,` + image + `"}}]}],"audio":"` + image + `"}`)
+
+	metadata := &schemas.ResponseMetadata{CaptureRequestBody: true}
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyResponseMetadata, metadata)
+	if _, bifrostErr := transformRequestBody(ctx, body, schemas.OpenAI); bifrostErr != nil {
+		t.Fatalf("transformRequestBody() error = %v", bifrostErr.Error.Message)
+	}
+
+	want := `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"What is \"this\"?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,[440 base64 characters omitted]"}}]}],"audio":"[440 base64 characters omitted]"}`
+	if got := string(metadata.RequestBody); got != want {
+		t.Errorf("RequestBody = %s, want %s", got, want)
+	}
+
+	if got := string(redactRequestBody([]byte("--boundary\r\n"))); got != `"[12 bytes of non-JSON data omitted]"` {
+		t.Errorf("redactRequestBody(multipart) = %s", got)
+	}
+}
+
+// sseEventsReader generates a synthetic stream of count SSE events without holding it in memory.
+type sseEventsReader struct {
+	event []byte
+	count int
+	pos   int
+}
+
+func (r *sseEventsReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && r.count > 0 {
+		copied := copy(p[n:], r.event[r.pos:])
+		n += copied
+		r.pos += copied
+		if r.pos == len(r.event) {
+			r.pos = 0
+			r.count--
+		}
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func newSSEEventsReader(count int) *sseEventsReader {
+	return &sseEventsReader{
+		event: []byte(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"token "}}]}` + "\n\n"),
+		count: count,
+	}
+}
+
+func TestStreamScannerBoundedMemory(t *testing.T) {
+	const events = 20_000 // about 2.5MB of stream data
+
+	// Scanning must not allocate per event: the allocations of a run are those of the scanner
+	// and its buffer, whatever the length of the stream
+	var lines int
+	var scanErr error
+	allocs := testing.AllocsPerRun(5, func() {
+		scanner := newStreamScanner(newSSEEventsReader(events))
+		lines = 0
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				lines++
+			}
+		}
+		scanErr = scanner.Err()
+	})
+
+	if scanErr != nil {
+		t.Fatalf("scanner error: %v", scanErr)
+	}
+	if lines != events {
+		t.Fatalf("scanned %d events, want %d", lines, events)
+	}
+	if allocs > 10 {
+		t.Errorf("scanning %d events made %v allocations, want at most 10", events, allocs)
+	}
+}
+
+func TestStreamScannerLongLine(t *testing.T) {
+	line := "data: " + strings.Repeat("a", 2*1024*1024)
+	scanner := newStreamScanner(strings.NewReader(line + "\n\ndata: [DONE]\n"))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scanner error: %v", err)
+	}
+	if len(lines) != 3 || lines[0] != line || lines[2] != "data: [DONE]" {
+		t.Errorf("scanned %d lines, want the long line, an empty line and [DONE]", len(lines))
+	}
+}
+
+// debugLogger records the debug messages it is given.
+type debugLogger struct {
+	testLogger
+	messages []string
+}
+
+func (l *debugLogger) Debug(msg string) { l.messages = append(l.messages, msg) }
+
+func TestTapStreamLogsRawAndParsedChunks(t *testing.T) {
+	audio := strings.Repeat("QUJD", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\",\"audio\":\"%s\"},\"finish_reason\":\"stop\"}]}\n\n", audio)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	passThrough := func(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		return result, err
+	}
+	for _, enabled := range []bool{false, true} {
+		logger := &debugLogger{}
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyStreamTap, enabled)
+		stream, bifrostErr := handleOpenAIStreaming(ctx, http.DefaultClient, server.URL, map[string]interface{}{}, nil, nil, schemas.SGL, nil, passThrough, logger)
+		if bifrostErr != nil {
+			t.Fatalf("handleOpenAIStreaming() error = %+v", bifrostErr)
+		}
+		for range stream {
+		}
+
+		if !enabled {
+			if len(logger.messages) != 0 {
+				t.Errorf("logged %q with the tap disabled, want nothing", logger.messages)
+			}
+			continue
+		}
+		if len(logger.messages) != 2 {
+			t.Fatalf("logged %q, want the raw and the parsed chunk", logger.messages)
+		}
+		raw, parsed := logger.messages[0], logger.messages[1]
+		if !strings.HasPrefix(raw, "[stream tap] sgl raw chunk: data: {") || strings.Contains(raw, audio) || !strings.Contains(raw, "[400 base64 characters omitted]") {
+			t.Errorf("raw chunk log = %q, want the SSE line with the audio redacted", raw)
+		}
+		if !strings.HasPrefix(parsed, "[stream tap] sgl parsed chunk: {") || !strings.Contains(parsed, `"content":"Hi"`) {
+			t.Errorf("parsed chunk log = %q, want the parsed response", parsed)
+		}
+	}
+}
+
+func BenchmarkStreamScanner(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		scanner := newStreamScanner(newSSEEventsReader(10_000))
+		for scanner.Scan() {
+		}
+	}
+}
+
+// BenchmarkStreamChannelBufferSize measures the throughput of a stream between a read loop that
+// scans and parses SSE events and a consumer that serializes each chunk, as the HTTP transport
+// does, for several buffer sizes of the stream channel.
+func BenchmarkStreamChannelBufferSize(b *testing.B) {
+	const events = 10_000
+	for _, bufferSize := range []int{1, 16, schemas.DefaultStreamBufferSize} {
+		b.Run(fmt.Sprintf("buffer=%d", bufferSize), func(b *testing.B) {
+			ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyStreamBufferSize, bufferSize)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				responseChan := newStreamChannel(ctx)
+				go func() {
+					defer close(responseChan)
+					scanner := newStreamScanner(newSSEEventsReader(events))
+					for scanner.Scan() {
+						line := scanner.Bytes()
+						if len(line) == 0 {
+							continue
+						}
+						var response schemas.BifrostResponse
+						if err := sonic.Unmarshal(line[len("data: "):], &response); err != nil {
+							b.Error(err)
+							return
+						}
+						responseChan <- &schemas.BifrostStream{BifrostResponse: &response}
+					}
+				}()
+				for chunk := range responseChan {
+					if _, err := sonic.Marshal(chunk); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			b.ReportMetric(float64(events*b.N)/b.Elapsed().Seconds(), "chunks/s")
+		})
+	}
+}
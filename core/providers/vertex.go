@@ -11,9 +11,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 
 	"github.com/bytedance/sonic"
@@ -28,10 +31,159 @@ type VertexError struct {
 	} `json:"error"`
 }
 
-// vertexClientPool provides a pool/cache for authenticated Vertex HTTP clients.
-// This avoids creating and authenticating clients for every request.
-// Uses sync.Map for atomic operations without explicit locking.
-var vertexClientPool sync.Map
+// VertexGroundingMetadata is the grounding metadata Gemini models attach to a choice when they
+// are grounded with Google Search or a retrieval source.
+type VertexGroundingMetadata struct {
+	WebSearchQueries []string `json:"webSearchQueries,omitempty"` // Queries searched for
+	GroundingChunks  []struct {
+		Web *struct {
+			URI   string `json:"uri"`
+			Title string `json:"title"`
+		} `json:"web,omitempty"` // Web page found by Google Search
+		RetrievedContext *struct {
+			URI   string `json:"uri"`
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		} `json:"retrievedContext,omitempty"` // Document found by a retrieval source
+	} `json:"groundingChunks,omitempty"` // Sources the response is grounded in
+	GroundingSupports []struct {
+		Segment struct {
+			StartIndex int `json:"startIndex"` // Start of the segment in the choice's text, in bytes
+			EndIndex   int `json:"endIndex"`   // End (exclusive) of the segment, in bytes
+		} `json:"segment"`
+		GroundingChunkIndices []int `json:"groundingChunkIndices"` // Sources supporting the segment
+	} `json:"groundingSupports,omitempty"` // Segments of the text and the sources supporting them
+}
+
+// vertexGroundingResponse holds the grounding metadata of the choices of a Gemini response, which
+// the OpenAI compatible response format has no field for.
+type vertexGroundingResponse struct {
+	Choices []struct {
+		Index             int                      `json:"index"`
+		GroundingMetadata *VertexGroundingMetadata `json:"groundingMetadata,omitempty"`
+	} `json:"choices"`
+}
+
+// vertexPromptFeedbackResponse is the feedback of a Gemini response on its prompt. A prompt
+// blocked by the safety filters has a block reason, and the response has no candidates.
+type vertexPromptFeedbackResponse struct {
+	PromptFeedback *struct {
+		BlockReason   string `json:"blockReason"`
+		SafetyRatings []struct {
+			Category string `json:"category"`
+			Blocked  bool   `json:"blocked"`
+		} `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+}
+
+// vertexBlockedPromptChoices returns the choices of a Gemini response, with a content-filtered
+// choice if it has none: Gemini returns no candidates when it blocks the prompt, which would
+// otherwise look like an empty success. The choice reports the prompt's block reason and the
+// categories that blocked it, if any.
+func vertexBlockedPromptChoices(body []byte, choices []schemas.BifrostResponseChoice) []schemas.BifrostResponseChoice {
+	if len(choices) > 0 {
+		return choices
+	}
+
+	filter := &schemas.ContentFilterResult{Reason: "OTHER"} // Gemini's block reason when it gives none
+	var response vertexPromptFeedbackResponse
+	if err := sonic.Unmarshal(body, &response); err == nil && response.PromptFeedback != nil {
+		if response.PromptFeedback.BlockReason != "" {
+			filter.Reason = response.PromptFeedback.BlockReason
+		}
+		for _, rating := range response.PromptFeedback.SafetyRatings {
+			if rating.Blocked {
+				filter.Categories = append(filter.Categories, rating.Category)
+			}
+		}
+	}
+
+	return []schemas.BifrostResponseChoice{{
+		Index:         0,
+		FinishReason:  StrPtr(schemas.FinishReasonContentFilter),
+		ContentFilter: filter,
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: schemas.BifrostMessage{Role: schemas.ModelChatMessageRoleAssistant, Content: schemas.MessageContent{ContentStr: StrPtr("")}},
+		},
+	}}
+}
+
+// vertexGrounding returns the grounding metadata of a Gemini response body merged into the URL
+// citations annotated on its choices, if any. Each source supporting a segment of a choice's text
+// is a citation of that segment; sources supporting no segment are cited without a span.
+func vertexGrounding(body []byte, choices []schemas.BifrostResponseChoice) *schemas.GroundingMetadata {
+	grounding := groundingFromAnnotations(choices)
+
+	var response vertexGroundingResponse
+	if err := sonic.Unmarshal(body, &response); err != nil {
+		return grounding
+	}
+
+	for _, choice := range response.Choices {
+		metadata := choice.GroundingMetadata
+		if metadata == nil {
+			continue
+		}
+		if grounding == nil {
+			grounding = &schemas.GroundingMetadata{}
+		}
+		grounding.SearchQueries = append(grounding.SearchQueries, metadata.WebSearchQueries...)
+
+		text := choiceText(choices, choice.Index)
+		source := func(index int) schemas.GroundingCitation {
+			citation := schemas.GroundingCitation{ChoiceIndex: choice.Index}
+			if index < 0 || index >= len(metadata.GroundingChunks) {
+				return citation
+			}
+			if chunk := metadata.GroundingChunks[index]; chunk.Web != nil {
+				citation.Title, citation.URL = chunk.Web.Title, chunk.Web.URI
+			} else if chunk.RetrievedContext != nil {
+				citation.Title, citation.URL, citation.CitedText = chunk.RetrievedContext.Title, chunk.RetrievedContext.URI, chunk.RetrievedContext.Text
+			}
+			return citation
+		}
+
+		supported := make(map[int]bool)
+		for _, support := range metadata.GroundingSupports {
+			for _, index := range support.GroundingChunkIndices {
+				citation := source(index)
+				citation.StartIndex = intPtr(charIndex(text, support.Segment.StartIndex))
+				citation.EndIndex = intPtr(charIndex(text, support.Segment.EndIndex))
+				grounding.Citations = append(grounding.Citations, citation)
+				supported[index] = true
+			}
+		}
+		for index := range metadata.GroundingChunks {
+			if !supported[index] {
+				grounding.Citations = append(grounding.Citations, source(index))
+			}
+		}
+	}
+
+	if grounding != nil && len(grounding.Citations) == 0 && len(grounding.SearchQueries) == 0 {
+		return nil
+	}
+	return grounding
+}
+
+// choiceText returns the text content of the choice with the given index, or "" if it has none.
+func choiceText(choices []schemas.BifrostResponseChoice, index int) string {
+	for _, choice := range choices {
+		if choice.Index == index && choice.BifrostNonStreamResponseChoice != nil && choice.Message.Content.ContentStr != nil {
+			return *choice.Message.Content.ContentStr
+		}
+	}
+	return ""
+}
+
+// charIndex converts a byte offset into text to a character offset. Offsets past the end of the
+// text, e.g. when the text is unknown, are returned as is.
+func charIndex(text string, byteIndex int) int {
+	if byteIndex < 0 || byteIndex > len(text) {
+		return byteIndex
+	}
+	return utf8.RuneCountInString(text[:byteIndex])
+}
 
 // getClientKey generates a unique key for caching authenticated clients.
 // It uses a hash of the auth credentials for security.
@@ -46,16 +198,22 @@ func getClientKey(authCredentials string) string {
 // - Auth client creation fails
 // - Network errors that might indicate credential issues
 // This ensures we don't keep using potentially invalid clients.
-func removeVertexClient(authCredentials string) {
+func (provider *VertexProvider) removeVertexClient(authCredentials string) {
 	clientKey := getClientKey(authCredentials)
-	vertexClientPool.Delete(clientKey)
+	provider.clientPool.Delete(clientKey)
 }
 
 // VertexProvider implements the Provider interface for Google's Vertex AI API.
 type VertexProvider struct {
 	logger              schemas.Logger        // Logger for provider operations
+	baseClient          *http.Client          // Client the authenticated clients send their requests with (nil for the default)
 	networkConfig       schemas.NetworkConfig // Network configuration including extra headers
 	sendBackRawResponse bool                  // Whether to include raw response in BifrostResponse
+
+	// clientPool caches the authenticated HTTP clients by credentials, to avoid creating and
+	// authenticating a client for every request. It is a sync.Map for atomic operations without
+	// explicit locking.
+	clientPool sync.Map
 }
 
 // NewVertexProvider creates a new Vertex provider instance.
@@ -64,15 +222,29 @@ type VertexProvider struct {
 func NewVertexProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*VertexProvider, error) {
 	config.CheckAndSetDefaults()
 
+	if config.RequestSigner != nil {
+		return nil, fmt.Errorf("request signer is not supported by vertex, which authenticates requests with Google credentials")
+	}
+
+	// Configure TLS if provided, for the API and the token requests
+	var baseClient *http.Client
+	if config.TLSConfig != nil {
+		baseClient = &http.Client{}
+		if err := configureTLS(nil, baseClient, config.TLSConfig, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	// Pre-warm response pools
 	for range config.ConcurrencyAndBufferSize.Concurrency {
-		openAIResponsePool.Put(&OpenAIResponse{})
+		openAIResponsePool.Put(&schemas.BifrostResponse{})
 		anthropicChatResponsePool.Put(&AnthropicChatResponse{})
 
 	}
 
 	return &VertexProvider{
 		logger:              logger,
+		baseClient:          baseClient,
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
 	}, nil
@@ -84,7 +256,7 @@ func NewVertexProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*
 // - Avoiding repeated JWT config creation
 // - Reusing OAuth2 token refresh logic
 // - Reducing authentication overhead
-func getAuthClient(key schemas.Key) (*http.Client, error) {
+func (provider *VertexProvider) getAuthClient(key schemas.Key) (*http.Client, error) {
 	if key.VertexKeyConfig == nil {
 		return nil, fmt.Errorf("vertex key config is not set")
 	}
@@ -99,7 +271,7 @@ func getAuthClient(key schemas.Key) (*http.Client, error) {
 	clientKey := getClientKey(authCredentials)
 
 	// Try to get existing client from pool
-	if value, exists := vertexClientPool.Load(clientKey); exists {
+	if value, exists := provider.clientPool.Load(clientKey); exists {
 		return value.(*http.Client), nil
 	}
 
@@ -109,11 +281,15 @@ func getAuthClient(key schemas.Key) (*http.Client, error) {
 		return nil, fmt.Errorf("failed to create JWT config: %w", err)
 	}
 
-	client := conf.Client(context.Background())
+	ctx := context.Background()
+	if provider.baseClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, provider.baseClient)
+	}
+	client := conf.Client(ctx)
 
 	// Store the client using LoadOrStore to handle race conditions
 	// If another goroutine stored a client while we were creating ours, use theirs
-	actual, _ := vertexClientPool.LoadOrStore(clientKey, client)
+	actual, _ := provider.clientPool.LoadOrStore(clientKey, client)
 	return actual.(*http.Client), nil
 }
 
@@ -122,6 +298,30 @@ func (provider *VertexProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Vertex
 }
 
+// Capabilities returns the operations and features supported by the Vertex provider.
+func (provider *VertexProvider) Capabilities() schemas.ProviderCapabilities {
+	return schemas.ProviderCapabilities{
+		ChatCompletion:       true,
+		ChatCompletionStream: true,
+		Tools:                true,
+		Vision:               true,
+		Seed:                 true,
+		Penalties:            schemas.PenaltySupport{Additive: true, Min: -2, Max: 2},
+	}
+}
+
+// ModelCapabilities returns the features supported for a model: Claude models are served through
+// Anthropic's API on Vertex and have its features, other models those of the Vertex API.
+func (provider *VertexProvider) ModelCapabilities(model string) schemas.ProviderCapabilities {
+	capabilities := provider.Capabilities()
+	if strings.Contains(model, "claude") {
+		capabilities.Seed = false
+		capabilities.ParallelToolCalls = true
+		capabilities.Penalties = schemas.PenaltySupport{}
+	}
+	return capabilities
+}
+
 // TextCompletion is not supported by the Vertex provider.
 // Returns an error indicating that text completion is not available.
 func (provider *VertexProvider) TextCompletion(ctx context.Context, model string, key schemas.Key, text string, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -161,6 +361,8 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 
 	delete(requestBody, "region")
 
+	addVertexSafetySettings(requestBody, model, params, provider.logger)
+
 	jsonBody, err := sonic.Marshal(requestBody)
 	if err != nil {
 		return nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, schemas.Vertex)
@@ -182,6 +384,12 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 		url = fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/anthropic/models/%s:streamRawPredict", region, projectID, region, model)
 	}
 
+	// Apply the provider's request transform, if configured
+	jsonBody, transformErr := transformRequestBody(ctx, jsonBody, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
@@ -198,11 +406,13 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
 
 	req.Header.Set("Content-Type", "application/json")
+	setAcceptEncodingHTTP(req)
+	compressHTTPRequestBody(ctx, req, jsonBody)
 
-	client, err := getAuthClient(key)
+	client, err := provider.getAuthClient(key)
 	if err != nil {
 		// Remove client from pool if auth client creation fails
-		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
+		provider.removeVertexClient(key.VertexKeyConfig.AuthCredentials)
 		return nil, newBifrostOperationError("error creating auth client", err, schemas.Vertex)
 	}
 
@@ -220,10 +430,11 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 			}
 		}
 		// Remove client from pool for non-context errors (could be auth/network issues)
-		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
-		return nil, newBifrostOperationError("error creating request", err, schemas.Vertex)
+		provider.removeVertexClient(key.VertexKeyConfig.AuthCredentials)
+		return nil, newProviderRequestError(err, schemas.Vertex)
 	}
 	defer resp.Body.Close()
+	recordHTTPResponseMetadata(ctx, resp)
 
 	// Handle error response
 	// Read response body
@@ -232,10 +443,20 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 		return nil, newBifrostOperationError("error reading response", err, schemas.Vertex)
 	}
 
+	body, bifrostErr := decompressHTTPResponseBody(resp, body, schemas.Vertex)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	body, transformErr = transformResponseBody(ctx, body, provider.GetProviderKey())
+	if transformErr != nil {
+		return nil, transformErr
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		// Remove client from pool for authentication/authorization errors
 		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-			removeVertexClient(key.VertexKeyConfig.AuthCredentials)
+			provider.removeVertexClient(key.VertexKeyConfig.AuthCredentials)
 		}
 
 		var openAIErr schemas.BifrostError
@@ -250,11 +471,18 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 			}
 
 			if len(vertexErr) > 0 {
-				return nil, newProviderAPIError(vertexErr[0].Error.Message, nil, resp.StatusCode, schemas.Vertex, nil, nil)
+				bifrostErr := newProviderAPIError(vertexErr[0].Error.Message, nil, resp.StatusCode, schemas.Vertex, nil, nil)
+				// The model is the only resource of the chat endpoints that can be missing
+				if vertexErr[0].Error.Status == "NOT_FOUND" {
+					bifrostErr.Error.Code = StrPtr(schemas.ErrorCodeModelNotFound)
+				}
+				return nil, bifrostErr
 			}
 		}
 
-		return nil, newProviderAPIError(openAIErr.Error.Message, nil, resp.StatusCode, schemas.Vertex, nil, nil)
+		bifrostErr := newProviderAPIError(openAIErr.Error.Message, nil, resp.StatusCode, schemas.Vertex, nil, nil)
+		bifrostErr.Error.Code = openAIErr.Error.Code
+		return nil, bifrostErr
 	}
 
 	if strings.Contains(model, "claude") {
@@ -268,6 +496,7 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 		}
 
 		// Create final response
+		response.stopAtToolUse(params)
 		bifrostResponse := &schemas.BifrostResponse{}
 		var err *schemas.BifrostError
 		bifrostResponse, err = parseAnthropicResponse(response, bifrostResponse)
@@ -296,6 +525,9 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 			return nil, bifrostErr
 		}
 
+		response.Choices = vertexBlockedPromptChoices(body, response.Choices)
+		applyContentFilter(response.Choices)
+
 		// Create final response
 		bifrostResponse := &schemas.BifrostResponse{
 			ID:                response.ID,
@@ -306,6 +538,7 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, model string
 			ServiceTier:       response.ServiceTier,
 			SystemFingerprint: response.SystemFingerprint,
 			Usage:             response.Usage,
+			Grounding:         vertexGrounding(body, response.Choices),
 			ExtraFields: schemas.BifrostResponseExtraFields{
 				Provider:    schemas.Vertex,
 				RawResponse: rawResponse,
@@ -343,10 +576,10 @@ func (provider *VertexProvider) ChatCompletionStream(ctx context.Context, postHo
 		return nil, newConfigurationError("region is not set in meta config", schemas.Vertex)
 	}
 
-	client, err := getAuthClient(key)
+	client, err := provider.getAuthClient(key)
 	if err != nil {
 		// Remove client from pool if auth client creation fails
-		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
+		provider.removeVertexClient(key.VertexKeyConfig.AuthCredentials)
 		return nil, newBifrostOperationError("error creating auth client", err, schemas.Vertex)
 	}
 
@@ -400,6 +633,8 @@ func (provider *VertexProvider) ChatCompletionStream(ctx context.Context, postHo
 
 		delete(requestBody, "region")
 
+		addVertexSafetySettings(requestBody, model, params, provider.logger)
+
 		url := fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1beta1/projects/%s/locations/%s/endpoints/openapi/chat/completions", region, projectID, region)
 
 		// Prepare headers for Vertex OpenAI-compatible
@@ -425,6 +660,47 @@ func (provider *VertexProvider) ChatCompletionStream(ctx context.Context, postHo
 	}
 }
 
+// addVertexSafetySettings maps the harm categories of params.SafetySettings onto the Gemini
+// safety_settings extension of Vertex's OpenAI-compatible endpoint. Other keys (such as the
+// Bedrock guardrail keys of a request falling back to Vertex) and all settings for Claude
+// models, which have no safety settings on Vertex, are dropped with a warning.
+func addVertexSafetySettings(requestBody map[string]interface{}, model string, params *schemas.ModelParameters, logger schemas.Logger) {
+	if params == nil || len(params.SafetySettings) == 0 {
+		return
+	}
+
+	var categories, dropped []string
+	for key := range params.SafetySettings {
+		if strings.HasPrefix(key, "HARM_CATEGORY_") && !strings.Contains(model, "claude") {
+			categories = append(categories, key)
+		} else {
+			dropped = append(dropped, key)
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		logger.Warn(fmt.Sprintf("vertex: dropping safety settings not supported by %s: %s", model, strings.Join(dropped, ", ")))
+	}
+	if len(categories) == 0 {
+		return
+	}
+	sort.Strings(categories)
+
+	safetySettings := make([]map[string]interface{}, 0, len(categories))
+	for _, category := range categories {
+		safetySettings = append(safetySettings, map[string]interface{}{
+			"category":  category,
+			"threshold": params.SafetySettings[category],
+		})
+	}
+
+	requestBody["extra_body"] = map[string]interface{}{
+		"google": map[string]interface{}{
+			"safety_settings": safetySettings,
+		},
+	}
+}
+
 func (provider *VertexProvider) Speech(ctx context.Context, model string, key schemas.Key, input *schemas.SpeechInput, params *schemas.ModelParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("speech", "vertex")
 }
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestAddVertexSafetySettings(t *testing.T) {
+	params := &schemas.ModelParameters{SafetySettings: map[string]string{
+		"HARM_CATEGORY_HATE_SPEECH":                     "BLOCK_ONLY_HIGH",
+		"HARM_CATEGORY_HARASSMENT":                      "BLOCK_NONE",
+		schemas.SafetySettingBedrockGuardrailIdentifier: "gr-123",
+		schemas.SafetySettingBedrockGuardrailVersion:    "1",
+	}}
+
+	var warnings []string
+	requestBody := map[string]interface{}{}
+	addVertexSafetySettings(requestBody, "gemini-1.5-pro", params, warnLogger{warnings: &warnings})
+
+	want := map[string]interface{}{"google": map[string]interface{}{"safety_settings": []map[string]interface{}{
+		{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_NONE"},
+		{"category": "HARM_CATEGORY_HATE_SPEECH", "threshold": "BLOCK_ONLY_HIGH"},
+	}}}
+	if !reflect.DeepEqual(requestBody["extra_body"], want) {
+		t.Errorf("extra_body = %v, want %v", requestBody["extra_body"], want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "guardrail_identifier, guardrail_version") {
+		t.Errorf("warnings = %q, want the dropped guardrail keys", warnings)
+	}
+
+	// Claude models on Vertex have no safety settings
+	warnings = nil
+	requestBody = map[string]interface{}{}
+	addVertexSafetySettings(requestBody, "claude-3-5-sonnet@20240620", params, warnLogger{warnings: &warnings})
+	if _, ok := requestBody["extra_body"]; ok || len(warnings) != 1 {
+		t.Errorf("requestBody = %v, warnings = %q, want no safety settings and a warning", requestBody, warnings)
+	}
+}
+
+func TestVertexGrounding(t *testing.T) {
+	// "Café" is 5 bytes but 4 characters, so the second sentence starts at byte 7 and character 6
+	content := "Café. Open at 9."
+	choices := []schemas.BifrostResponseChoice{{
+		Index: 0,
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: schemas.BifrostMessage{Role: schemas.ModelChatMessageRoleAssistant, Content: schemas.MessageContent{ContentStr: &content}},
+		},
+	}}
+	body := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Café. Open at 9."},"groundingMetadata":{` +
+		`"webSearchQueries":["cafe opening hours"],` +
+		`"groundingChunks":[{"web":{"uri":"https://example.com/cafe","title":"Café"}},{"retrievedContext":{"uri":"gs://docs/hours.txt","title":"Hours","text":"Opens at 9am."}},{"web":{"uri":"https://example.com/unused","title":"Unused"}}],` +
+		`"groundingSupports":[{"segment":{"startIndex":7,"endIndex":17},"groundingChunkIndices":[0,1]}]}}]}`)
+
+	want := &schemas.GroundingMetadata{
+		Citations: []schemas.GroundingCitation{
+			{StartIndex: intPtr(6), EndIndex: intPtr(16), Title: "Café", URL: "https://example.com/cafe"},
+			{StartIndex: intPtr(6), EndIndex: intPtr(16), Title: "Hours", URL: "gs://docs/hours.txt", CitedText: "Opens at 9am."},
+			{Title: "Unused", URL: "https://example.com/unused"},
+		},
+		SearchQueries: []string{"cafe opening hours"},
+	}
+	if got := vertexGrounding(body, choices); !reflect.DeepEqual(got, want) {
+		t.Errorf("vertexGrounding() = %+v, want %+v", got, want)
+	}
+
+	// Responses without grounding metadata or annotations are not grounded
+	if got := vertexGrounding([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`), choices); got != nil {
+		t.Errorf("vertexGrounding() of an ungrounded response = %+v, want nil", got)
+	}
+}
+
+func TestVertexBlockedPromptChoices(t *testing.T) {
+	body := []byte(`{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[` +
+		`{"category":"HARM_CATEGORY_HARASSMENT","probability":"HIGH","blocked":true},` +
+		`{"category":"HARM_CATEGORY_HATE_SPEECH","probability":"NEGLIGIBLE"}]},"usageMetadata":{"promptTokenCount":8}}`)
+
+	choices := vertexBlockedPromptChoices(body, nil)
+	if len(choices) != 1 || *choices[0].FinishReason != schemas.FinishReasonContentFilter {
+		t.Fatalf("choices = %+v, want a single content-filtered choice", choices)
+	}
+	if filter := choices[0].ContentFilter; filter.Reason != "SAFETY" || !reflect.DeepEqual(filter.Categories, []string{"HARM_CATEGORY_HARASSMENT"}) {
+		t.Errorf("content filter = %+v, want SAFETY on the harassment category", filter)
+	}
+
+	// Without feedback, an empty response is still reported as blocked
+	if choices := vertexBlockedPromptChoices([]byte(`{"choices":[]}`), nil); len(choices) != 1 || choices[0].ContentFilter.Reason != "OTHER" {
+		t.Errorf("choices of an empty response = %+v, want a choice blocked for OTHER", choices)
+	}
+
+	// Responses with choices are left alone
+	answered := []schemas.BifrostResponseChoice{{Index: 0, FinishReason: StrPtr("stop")}}
+	if choices := vertexBlockedPromptChoices(body, answered); len(choices) != 1 || choices[0].ContentFilter != nil {
+		t.Errorf("choices of an answered response = %+v, want them unchanged", choices)
+	}
+}
@@ -0,0 +1,67 @@
+package bifrost
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// acceptResponse applies the request's AcceptResponse predicate, if any, to the outcome of an
+// attempt. A rejected response is turned into an error of type ResponseRejected, so that the
+// fallbacks are tried. A panicking predicate is recovered and the response accepted.
+func (bifrost *Bifrost) acceptResponse(req *schemas.BifrostRequest, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if req.AcceptResponse == nil || bifrostErr != nil || result == nil {
+		return result, bifrostErr
+	}
+
+	rejection := func() (rejection error) {
+		defer func() {
+			if r := recover(); r != nil {
+				bifrost.logger.Warn(fmt.Sprintf("AcceptResponse predicate panicked: %v", r))
+				rejection = nil
+			}
+		}()
+		return req.AcceptResponse(result)
+	}()
+	if rejection == nil {
+		return result, nil
+	}
+
+	bifrost.logger.Debug(fmt.Sprintf("Response of provider %s with model %s rejected: %v", req.Provider, req.Model, rejection))
+	errorType := schemas.ResponseRejected
+	return nil, &schemas.BifrostError{
+		Provider: req.Provider,
+		Error: schemas.ErrorField{
+			Type:    &errorType,
+			Message: fmt.Sprintf("response rejected: %v", rejection),
+			Error:   rejection,
+		},
+	}
+}
+
+// RejectEmptyResponse is an AcceptResponse predicate that rejects chat and text completions
+// whose choices have neither content nor tool calls, e.g. an empty message with a stop finish reason.
+func RejectEmptyResponse(result *schemas.BifrostResponse) error {
+	for _, choice := range result.Choices {
+		if choice.BifrostNonStreamResponseChoice == nil {
+			continue
+		}
+		message := choice.Message
+		if message.AssistantMessage != nil && message.ToolCalls != nil && len(*message.ToolCalls) > 0 {
+			return nil
+		}
+		if message.Content.ContentStr != nil && strings.TrimSpace(*message.Content.ContentStr) != "" {
+			return nil
+		}
+		if message.Content.ContentBlocks != nil {
+			for _, block := range *message.Content.ContentBlocks {
+				if block.Text != nil && strings.TrimSpace(*block.Text) != "" {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("response has no content")
+}
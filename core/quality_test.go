@@ -0,0 +1,80 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// contentPlugin answers chat completions itself with a per-provider message content, and records
+// the outcomes its post-hook sees.
+type contentPlugin struct {
+	contents map[schemas.ModelProvider]string
+	results  []*schemas.BifrostResponse
+	errors   []*schemas.BifrostError
+}
+
+func (p *contentPlugin) GetName() string { return "content" }
+
+func (p *contentPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	response := &schemas.BifrostResponse{Choices: []schemas.BifrostResponseChoice{{
+		FinishReason: Ptr("stop"),
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: schemas.BifrostMessage{
+				Role:    schemas.ModelChatMessageRoleAssistant,
+				Content: schemas.MessageContent{ContentStr: Ptr(p.contents[req.Provider])},
+			},
+		},
+	}}}
+	response.ExtraFields.Provider = req.Provider
+	return req, &schemas.PluginShortCircuit{Response: response}, nil
+}
+
+func (p *contentPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	p.results = append(p.results, result)
+	p.errors = append(p.errors, err)
+	return result, err, nil
+}
+
+func (p *contentPlugin) Cleanup() error { return nil }
+
+func TestAcceptResponseTriesFallbacks(t *testing.T) {
+	plugin := &contentPlugin{contents: map[schemas.ModelProvider]string{schemas.OpenAI: " ", schemas.Anthropic: "Hello"}}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	req := &schemas.BifrostRequest{
+		Provider:       schemas.OpenAI,
+		Model:          "gpt-4o",
+		Fallbacks:      []schemas.Fallback{{Provider: schemas.Anthropic, Model: "claude-3-5-sonnet"}},
+		AcceptResponse: RejectEmptyResponse,
+		Input:          schemas.RequestInput{ChatCompletionInput: &[]schemas.BifrostMessage{schemas.UserMessage("Hello")}},
+	}
+	result, bifrostErr := bifrost.handleRequest(context.Background(), req, ChatCompletionRequest)
+	if bifrostErr != nil {
+		t.Fatalf("handleRequest() error = %+v", bifrostErr)
+	}
+	if result.ExtraFields.Provider != schemas.Anthropic {
+		t.Errorf("answered by %s, want the anthropic fallback", result.ExtraFields.Provider)
+	}
+	// The post-hooks see the rejected response as an error, so plugins never store it as a success
+	if len(plugin.errors) != 2 || plugin.results[0] != nil || plugin.errors[0] == nil || *plugin.errors[0].Error.Type != schemas.ResponseRejected ||
+		plugin.results[1] == nil || plugin.errors[1] != nil {
+		t.Errorf("post-hooks saw results %v and errors %v, want the rejection then the fallback's response", plugin.results, plugin.errors)
+	}
+
+	// Without an acceptable response, the rejection of the primary is returned
+	plugin.contents[schemas.Anthropic] = ""
+	_, bifrostErr = bifrost.handleRequest(context.Background(), req, ChatCompletionRequest)
+	if bifrostErr == nil || bifrostErr.Error.Type == nil || *bifrostErr.Error.Type != schemas.ResponseRejected || len(bifrostErr.AttemptHistory) != 2 {
+		t.Fatalf("handleRequest() error = %+v, want a response_rejected error with 2 attempts", bifrostErr)
+	}
+}
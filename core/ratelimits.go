@@ -0,0 +1,138 @@
+package bifrost
+
+import (
+	"strconv"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// minKeyRateLimitFactor is the smallest factor a key's weight is multiplied by because its rate
+// limit budget is exhausted, so that it is noticed again if its budget was reset early.
+const minKeyRateLimitFactor = 0.01
+
+// KeyRateLimit is the rate limit budget of a key, as reported by the rate limit headers of the
+// provider's latest response to a request made with it. Values the provider did not report are
+// nil (limits and remaining budgets) or zero (reset times).
+type KeyRateLimit struct {
+	Provider          schemas.ModelProvider // Provider of the key
+	RequestsLimit     *int                  // Requests allowed per rate limit window
+	RequestsRemaining *int                  // Requests left in the current window
+	RequestsReset     time.Time             // When the request budget is replenished
+	TokensLimit       *int                  // Tokens allowed per rate limit window
+	TokensRemaining   *int                  // Tokens left in the current window
+	TokensReset       time.Time             // When the token budget is replenished
+	UpdatedAt         time.Time             // Time of the response the budget was read from
+}
+
+// RemainingFraction returns the fraction of the key's request or token budget left at a time,
+// whichever is lower, between 0 and 1. Budgets whose reset time has passed count as full, as do
+// budgets without a reported limit.
+func (l KeyRateLimit) RemainingFraction(now time.Time) float64 {
+	fraction := 1.0
+	for _, budget := range []struct {
+		limit, remaining *int
+		reset            time.Time
+	}{
+		{l.RequestsLimit, l.RequestsRemaining, l.RequestsReset},
+		{l.TokensLimit, l.TokensRemaining, l.TokensReset},
+	} {
+		if budget.limit == nil || budget.remaining == nil || *budget.limit <= 0 {
+			continue
+		}
+		if !budget.reset.IsZero() && !now.Before(budget.reset) {
+			continue
+		}
+		fraction = min(fraction, max(float64(*budget.remaining)/float64(*budget.limit), 0))
+	}
+	return fraction
+}
+
+// parseKeyRateLimit reads a key's rate limit budget from the rate limit headers of a response
+// received at now. It supports the x-ratelimit-{limit,remaining,reset}-{requests,tokens}
+// headers of OpenAI compatible APIs, whose resets are durations (e.g. "6m0s"), and the
+// anthropic-ratelimit-{requests,tokens}-{limit,remaining,reset} headers of Anthropic, whose
+// resets are RFC 3339 times. It returns false if no budget was reported.
+func parseKeyRateLimit(headers map[string]string, now time.Time) (KeyRateLimit, bool) {
+	limit := KeyRateLimit{UpdatedAt: now}
+	header := func(openAIName, anthropicName string) string {
+		if value, ok := headers[openAIName]; ok {
+			return value
+		}
+		return headers[anthropicName]
+	}
+
+	limit.RequestsLimit = parseRateLimitCount(header("x-ratelimit-limit-requests", "anthropic-ratelimit-requests-limit"))
+	limit.RequestsRemaining = parseRateLimitCount(header("x-ratelimit-remaining-requests", "anthropic-ratelimit-requests-remaining"))
+	limit.RequestsReset = parseRateLimitReset(header("x-ratelimit-reset-requests", "anthropic-ratelimit-requests-reset"), now)
+	limit.TokensLimit = parseRateLimitCount(header("x-ratelimit-limit-tokens", "anthropic-ratelimit-tokens-limit"))
+	limit.TokensRemaining = parseRateLimitCount(header("x-ratelimit-remaining-tokens", "anthropic-ratelimit-tokens-remaining"))
+	limit.TokensReset = parseRateLimitReset(header("x-ratelimit-reset-tokens", "anthropic-ratelimit-tokens-reset"), now)
+
+	reported := limit.RequestsRemaining != nil || limit.TokensRemaining != nil
+	return limit, reported
+}
+
+// parseRateLimitCount parses a request or token count of a rate limit header (nil if invalid).
+func parseRateLimitCount(value string) *int {
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &count
+}
+
+// parseRateLimitReset parses the reset of a rate limit header, either a duration from now
+// (e.g. "1s", "6m0s" or "20ms"), a number of seconds, or an RFC 3339 time. It returns the zero
+// time if the value is missing or invalid.
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(duration)
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	if reset, err := time.Parse(time.RFC3339, value); err == nil {
+		return reset
+	}
+	return time.Time{}
+}
+
+// recordKeyRateLimit updates the rate limit budget of a key from the rate limit headers of the
+// provider's latest response to a request made with it, if the provider reported one.
+func (bifrost *Bifrost) recordKeyRateLimit(keyID string, providerKey schemas.ModelProvider, headers map[string]string) {
+	if keyID == "" || len(headers) == 0 {
+		return
+	}
+	limit, reported := parseKeyRateLimit(headers, time.Now())
+	if !reported {
+		return
+	}
+	limit.Provider = providerKey
+	bifrost.keyRateLimits.Store(keyID, limit)
+}
+
+// keyRateLimitFactor returns the factor a key's weight is multiplied by for
+// KeySelectionConfig.RateLimitAware: the fraction of its rate limit budget left, or 1 if
+// unknown.
+func (bifrost *Bifrost) keyRateLimitFactor(keyID string, now time.Time) float64 {
+	value, ok := bifrost.keyRateLimits.Load(keyID)
+	if !ok {
+		return 1
+	}
+	return max(value.(KeyRateLimit).RemainingFraction(now), minKeyRateLimitFactor)
+}
+
+// GetKeyRateLimits returns the latest rate limit budget reported by the providers for each key,
+// by key ID. Only keys with an ID whose provider reports rate limit headers are included.
+func (bifrost *Bifrost) GetKeyRateLimits() map[string]KeyRateLimit {
+	result := make(map[string]KeyRateLimit)
+	bifrost.keyRateLimits.Range(func(key, value any) bool {
+		result[key.(string)] = value.(KeyRateLimit)
+		return true
+	})
+	return result
+}
@@ -0,0 +1,98 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestParseKeyRateLimit(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	openAI, reported := parseKeyRateLimit(map[string]string{
+		"x-ratelimit-limit-requests":     "500",
+		"x-ratelimit-remaining-requests": "499",
+		"x-ratelimit-reset-requests":     "120ms",
+		"x-ratelimit-limit-tokens":       "30000",
+		"x-ratelimit-remaining-tokens":   "3000",
+		"x-ratelimit-reset-tokens":       "6m0s",
+	}, now)
+	if !reported || *openAI.RequestsRemaining != 499 || *openAI.TokensLimit != 30000 ||
+		!openAI.RequestsReset.Equal(now.Add(120*time.Millisecond)) || !openAI.TokensReset.Equal(now.Add(6*time.Minute)) {
+		t.Errorf("OpenAI rate limit = %+v", openAI)
+	}
+	if fraction := openAI.RemainingFraction(now); fraction != 0.1 {
+		t.Errorf("remaining fraction = %v, want the 10%% of tokens left", fraction)
+	}
+	// Once the token budget is replenished, only the request budget counts
+	if fraction := openAI.RemainingFraction(now.Add(time.Hour)); fraction != 1 {
+		t.Errorf("remaining fraction after the resets = %v, want 1", fraction)
+	}
+
+	anthropic, reported := parseKeyRateLimit(map[string]string{
+		"anthropic-ratelimit-requests-limit":     "50",
+		"anthropic-ratelimit-requests-remaining": "0",
+		"anthropic-ratelimit-requests-reset":     "2025-06-01T12:00:30Z",
+	}, now)
+	if !reported || *anthropic.RequestsRemaining != 0 || !anthropic.RequestsReset.Equal(now.Add(30*time.Second)) || anthropic.TokensRemaining != nil {
+		t.Errorf("Anthropic rate limit = %+v", anthropic)
+	}
+	if fraction := anthropic.RemainingFraction(now); fraction != 0 {
+		t.Errorf("remaining fraction = %v, want 0 for an exhausted key", fraction)
+	}
+
+	if _, reported := parseKeyRateLimit(map[string]string{"x-ratelimit-reset-requests": "1s"}, now); reported {
+		t.Error("parseKeyRateLimit() reported a budget without remaining counts")
+	}
+}
+
+func TestKeyRateLimitsTracked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Limit-Requests", "100")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "5")
+		w.Header().Set("X-RateLimit-Reset-Requests", "1m0s")
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys:   testKeys(),
+		config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account:      account,
+		Logger:       NewDefaultLogger(schemas.LogLevelError),
+		KeySelection: &schemas.KeySelectionConfig{RateLimitAware: true},
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+
+	limit, ok := bifrost.GetKeyRateLimits()["key"]
+	if !ok || limit.Provider != schemas.OpenAI || limit.RequestsRemaining == nil || *limit.RequestsRemaining != 5 {
+		t.Fatalf("rate limits = %+v, want 5 requests left for the key", bifrost.GetKeyRateLimits())
+	}
+
+	// The nearly exhausted key gets 5% of its weight
+	keys := []schemas.Key{{ID: "key", Weight: 1}, {ID: "fresh", Weight: 1}}
+	if weights := bifrost.appendKeyWeights(nil, keys, keys, "gpt-4o"); weights[0] != 0.05 || weights[1] != 1 {
+		t.Errorf("weights = %v, want [0.05 1]", weights)
+	}
+}
@@ -0,0 +1,74 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestProviderRequestIDCaptured(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		outcome, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		w.Header().Set("X-Request-Id", "req_"+outcome)
+		if outcome == "error" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"bad request","type":"invalid_request_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys:   testKeys(),
+		config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	request := func(outcome string) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+		return bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+			Provider: schemas.OpenAI,
+			Model:    "gpt-4o",
+			Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+			BaseURL:  Ptr(server.URL + "/" + outcome),
+		})
+	}
+
+	result, bifrostErr := request("ok")
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if result.ExtraFields.ProviderRequestID != "req_ok" {
+		t.Errorf("response provider request ID = %q, want req_ok", result.ExtraFields.ProviderRequestID)
+	}
+
+	_, bifrostErr = request("error")
+	if bifrostErr == nil {
+		t.Fatal("ChatCompletionRequest() succeeded, want an error")
+	}
+	if bifrostErr.ProviderRequestID != "req_error" {
+		t.Errorf("error provider request ID = %q, want req_error", bifrostErr.ProviderRequestID)
+	}
+}
+
+func TestResponseMetadataResetDropsRequestID(t *testing.T) {
+	metadata := &schemas.ResponseMetadata{RequestID: "req_previous"}
+	metadata.Reset(0)
+
+	bifrostErr := newBifrostErrorFromMsg("connection refused")
+	attachProviderRequestID(bifrostErr, metadata)
+	if bifrostErr.ProviderRequestID != "" {
+		t.Errorf("provider request ID = %q, want none after a failure without response", bifrostErr.ProviderRequestID)
+	}
+}
@@ -0,0 +1,75 @@
+package bifrost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestRequestedModelPreserved(t *testing.T) {
+	// The provider serves a dated snapshot of the requested model
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		if body.Model == "o1" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"message":"overloaded","type":"server_error"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"model":"%s-2024-08-06","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`, body.Model)
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys:   testKeys(),
+		config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if result.Model != "gpt-4o-2024-08-06" || result.ExtraFields.RequestedModel != "gpt-4o" {
+		t.Errorf("model = %q, requested model = %q, want gpt-4o-2024-08-06 and gpt-4o", result.Model, result.ExtraFields.RequestedModel)
+	}
+
+	// A response served by a fallback reports the model the caller asked for
+	result, bifrostErr = bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:  schemas.OpenAI,
+		Model:     "o1",
+		Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+		Fallbacks: []schemas.Fallback{{Provider: schemas.OpenAI, Model: "gpt-4o-mini"}},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() with a fallback error = %+v", bifrostErr)
+	}
+	if result.Model != "gpt-4o-mini-2024-08-06" || result.ExtraFields.RequestedModel != "o1" {
+		t.Errorf("model = %q, requested model = %q, want gpt-4o-mini-2024-08-06 and o1", result.Model, result.ExtraFields.RequestedModel)
+	}
+}
+
+func TestAttachRequestedModelFillsMissingModel(t *testing.T) {
+	resp := &schemas.BifrostResponse{}
+	attachRequestedModel(context.Background(), resp, "llama3")
+	if resp.Model != "llama3" || resp.ExtraFields.RequestedModel != "llama3" {
+		t.Errorf("model = %q, requested model = %q, want llama3 for both", resp.Model, resp.ExtraFields.RequestedModel)
+	}
+}
@@ -0,0 +1,87 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestResponseMetadataCaptured(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining-Requests", "99")
+		w.Header().Set("X-RateLimit-Remaining-Tokens", "1000")
+		w.Header().Set("Openai-Processing-Ms", "120")
+		w.Header().Set("X-Internal", "not captured")
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys: testKeys(),
+			config: networkConfig(schemas.NetworkConfig{
+				BaseURL:                        server.URL,
+				DefaultRequestTimeoutInSeconds: 5,
+				CaptureResponseHeaders:         []string{"x-ratelimit-remaining-*", "OpenAI-Processing-MS"},
+			}),
+		},
+		Logger: NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	// Only the allowlisted headers are kept, keyed by lower-case name
+	want := map[string]string{
+		"x-ratelimit-remaining-requests": "99",
+		"x-ratelimit-remaining-tokens":   "1000",
+		"openai-processing-ms":           "120",
+	}
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func() *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &messages}}
+	}
+
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), request())
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if result.ExtraFields.StatusCode != http.StatusOK || !reflect.DeepEqual(result.ExtraFields.ResponseHeaders, want) {
+		t.Errorf("status and headers = %d, %v, want %d, %v", result.ExtraFields.StatusCode, result.ExtraFields.ResponseHeaders, http.StatusOK, want)
+	}
+
+	// Streams carry them on the first chunk only
+	stream, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), request())
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+	}
+	var chunks []*schemas.BifrostResponse
+	for chunk := range stream {
+		if chunk.BifrostResponse != nil {
+			chunks = append(chunks, chunk.BifrostResponse)
+		}
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2", len(chunks))
+	}
+	if chunks[0].ExtraFields.StatusCode != http.StatusOK || !reflect.DeepEqual(chunks[0].ExtraFields.ResponseHeaders, want) {
+		t.Errorf("first chunk status and headers = %d, %v, want %d, %v", chunks[0].ExtraFields.StatusCode, chunks[0].ExtraFields.ResponseHeaders, http.StatusOK, want)
+	}
+	if last := chunks[len(chunks)-1]; last.ExtraFields.StatusCode != 0 || last.ExtraFields.ResponseHeaders != nil {
+		t.Errorf("last chunk status and headers = %d, %v, want none", last.ExtraFields.StatusCode, last.ExtraFields.ResponseHeaders)
+	}
+}
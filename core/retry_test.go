@@ -0,0 +1,350 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestClassifyRetry(t *testing.T) {
+	config := &schemas.ProviderConfig{}
+	tests := []struct {
+		name string
+		err  *schemas.BifrostError
+		want retryClass
+	}{
+		{"connection error", &schemas.BifrostError{Kind: schemas.ErrorKindConnection, Error: schemas.ErrorField{Message: schemas.ErrProviderRequest}}, retryConnection},
+		{"empty stream", &schemas.BifrostError{Kind: schemas.ErrorKindEmptyStream}, retryConnection},
+		{"message of a connection error without the kind", &schemas.BifrostError{Error: schemas.ErrorField{Message: schemas.ErrProviderRequest}}, retryNone},
+		{"retryable status", &schemas.BifrostError{StatusCode: Ptr(503)}, retryRequest},
+		{"client error", &schemas.BifrostError{StatusCode: Ptr(400)}, retryNone},
+		{"cancelled", &schemas.BifrostError{Error: schemas.ErrorField{Type: Ptr(schemas.RequestCancelled)}}, retryNone},
+		{"bifrost error", &schemas.BifrostError{IsBifrostError: true, Error: schemas.ErrorField{Message: "invalid request"}}, retryNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyRetry(tt.err, config); got != tt.want {
+				t.Errorf("classifyRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	take := func(budget retryBudget, classes ...retryClass) []bool {
+		taken := make([]bool, len(classes))
+		for i, class := range classes {
+			taken[i] = budget.take(class)
+		}
+		return taken
+	}
+	mixed := []retryClass{retryConnection, retryRequest, retryConnection, retryRequest}
+
+	for _, tt := range []struct {
+		name    string
+		network schemas.NetworkConfig
+		request *int
+		want    []bool
+	}{
+		// Without class budgets, connection and request retries share MaxRetries
+		{"shared", schemas.NetworkConfig{MaxRetries: 2}, nil, []bool{true, true, false, false}},
+		{"separate", schemas.NetworkConfig{MaxRetries: 2, ConnectionMaxRetries: Ptr(1), RequestMaxRetries: Ptr(1)}, nil, []bool{true, true, false, false}},
+		// Request retries have their own budget, connection retries use MaxRetries
+		{"one class budget", schemas.NetworkConfig{MaxRetries: 2, RequestMaxRetries: Ptr(0)}, nil, []bool{true, false, true, false}},
+		// The request's MaxRetries caps all classes together, but never raises the provider's budgets
+		{"request override", schemas.NetworkConfig{MaxRetries: 2, ConnectionMaxRetries: Ptr(5)}, Ptr(1), []bool{true, false, false, false}},
+		{"request disables retries", schemas.NetworkConfig{MaxRetries: 2}, Ptr(0), []bool{false, false, false, false}},
+		{"request above the provider budget", schemas.NetworkConfig{MaxRetries: 1}, Ptr(1000), []bool{true, false, false, false}},
+		{"request keeps class budgets", schemas.NetworkConfig{MaxRetries: 2, RequestMaxRetries: Ptr(0)}, Ptr(3), []bool{true, false, true, false}},
+	} {
+		if got := take(newRetryBudget(tt.network, tt.request), mixed...); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: retries taken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	budget := newRetryBudget(schemas.NetworkConfig{MaxRetries: 2}, nil)
+	if budget.take(retryNone) {
+		t.Error("a non-retryable error was retried")
+	}
+}
+
+// TestMixedRetriesShareMaxRetries checks that a request failing with a connection error, then
+// a retryable status, is retried at most MaxRetries times in total.
+func TestMixedRetriesShareMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			// Drop the connection without a response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+		}
+	}))
+	defer server.Close()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	for _, tt := range []struct {
+		maxRetries    int
+		wantCalls     int32
+		wantSucceeded bool
+	}{
+		{1, 2, false},
+		{2, 3, true},
+	} {
+		calls.Store(0)
+		account := &testAccount{
+			keys: testKeys(),
+			config: networkConfig(schemas.NetworkConfig{
+				BaseURL:                        server.URL,
+				DefaultRequestTimeoutInSeconds: 5,
+				MaxRetries:                     tt.maxRetries,
+				RetryBackoffInitial:            time.Millisecond,
+				RetryBackoffMax:                time.Millisecond,
+			}),
+		}
+		bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+
+		response, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+			Provider: schemas.OpenAI,
+			Model:    "gpt-4o",
+			Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		})
+		bifrost.Cleanup()
+		if succeeded := bifrostErr == nil; succeeded != tt.wantSucceeded {
+			t.Errorf("MaxRetries %d: succeeded = %v, want %v (error %+v)", tt.maxRetries, succeeded, tt.wantSucceeded, bifrostErr)
+		}
+		if got := calls.Load(); got != tt.wantCalls {
+			t.Errorf("MaxRetries %d: provider calls = %d, want %d", tt.maxRetries, got, tt.wantCalls)
+		}
+		if response != nil && !slices.Equal(response.ExtraFields.RetryStatusCodes, []int{0, 503}) {
+			t.Errorf("MaxRetries %d: retry status codes = %v, want [0 503]", tt.maxRetries, response.ExtraFields.RetryStatusCodes)
+		}
+	}
+}
+
+func TestBackoffWithJitter(t *testing.T) {
+	config := schemas.NetworkConfig{RetryBackoffInitial: 100 * time.Millisecond, RetryBackoffMax: time.Second}
+	tests := []struct {
+		name    string
+		attempt int
+		jitter  float64
+		want    time.Duration
+	}{
+		{"first retry, no jitter", 0, 0.5, 100 * time.Millisecond},
+		{"first retry, lower bound", 0, 0, 80 * time.Millisecond},
+		{"first retry, upper bound", 0, 1, 120 * time.Millisecond},
+		{"exponential growth", 3, 0.5, 800 * time.Millisecond},
+		{"capped at max", 4, 0.5, time.Second},
+		{"jitter never exceeds max", 4, 1, time.Second},
+		{"jitter below max", 4, 0, 800 * time.Millisecond},
+		{"high attempt does not overflow", 64, 0.5, time.Second},
+		{"max int attempt does not overflow", math.MaxInt, 0.5, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffWithJitter(tt.attempt, config, tt.jitter); got != tt.want {
+				t.Errorf("backoffWithJitter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffWithJitterLargeDurations(t *testing.T) {
+	config := schemas.NetworkConfig{RetryBackoffInitial: time.Duration(math.MaxInt64 / 4), RetryBackoffMax: time.Duration(math.MaxInt64)}
+
+	if got := backoffWithJitter(10, config, 0.5); got != time.Duration(math.MaxInt64) {
+		t.Errorf("backoffWithJitter() = %v, want %v", got, time.Duration(math.MaxInt64))
+	}
+}
+
+func TestComputeBackoffWithinBounds(t *testing.T) {
+	config := schemas.NetworkConfig{RetryBackoffInitial: 500 * time.Millisecond, RetryBackoffMax: 5 * time.Second}
+
+	for attempt := 0; attempt < 100; attempt++ {
+		got := ComputeBackoff(attempt, config)
+		if got <= 0 || got > config.RetryBackoffMax {
+			t.Fatalf("ComputeBackoff(%d) = %v, want within (0, %v]", attempt, got, config.RetryBackoffMax)
+		}
+	}
+}
+
+func TestRequestMaxRetriesDisablesRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			MaxRetries:                     2,
+			RetryBackoffInitial:            time.Millisecond,
+			RetryBackoffMax:                time.Millisecond,
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	}
+
+	for _, tt := range []struct {
+		maxRetries *int
+		wantCalls  int32
+	}{
+		{nil, 3},
+		{Ptr(0), 1},
+	} {
+		calls.Store(0)
+		req.MaxRetries = tt.maxRetries
+		if _, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), req); bifrostErr == nil {
+			t.Fatal("ChatCompletionRequest() succeeded, want a 503 error")
+		}
+		if got := calls.Load(); got != tt.wantCalls {
+			t.Errorf("provider calls with MaxRetries %v = %d, want %d", tt.maxRetries, got, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetriesReportedOnResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+		}
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			MaxRetries:                     2,
+			RetryBackoffInitial:            time.Millisecond,
+			RetryBackoffMax:                time.Millisecond,
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	}
+	response, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if response.ExtraFields.Retries != 2 || !slices.Equal(response.ExtraFields.RetryStatusCodes, []int{503, 429}) {
+		t.Errorf("retries = %d %v, want 2 [503 429]", response.ExtraFields.Retries, response.ExtraFields.RetryStatusCodes)
+	}
+
+	// A response without retries reports none
+	response, bifrostErr = bifrost.ChatCompletionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if response.ExtraFields.Retries != 0 || response.ExtraFields.RetryStatusCodes != nil {
+		t.Errorf("retries = %d %v, want none", response.ExtraFields.Retries, response.ExtraFields.RetryStatusCodes)
+	}
+}
+
+func TestRetryableStatusCodesRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt of each request fails with a 409
+		if calls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`)
+	}))
+	defer server.Close()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	for _, tt := range []struct {
+		name          string
+		retryable     []int
+		wantCalls     int32
+		wantSucceeded bool
+	}{
+		{"configured status code", []int{http.StatusConflict}, 2, true},
+		{"unconfigured status code", nil, 1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			account := &testAccount{
+				keys: testKeys(),
+				config: networkConfig(schemas.NetworkConfig{
+					BaseURL:                        server.URL,
+					DefaultRequestTimeoutInSeconds: 5,
+					MaxRetries:                     2,
+					RetryBackoffInitial:            time.Millisecond,
+					RetryBackoffMax:                time.Millisecond,
+					RetryableStatusCodes:           tt.retryable,
+				}),
+			}
+			bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			defer bifrost.Cleanup()
+
+			_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+				Provider: schemas.OpenAI,
+				Model:    "gpt-4o",
+				Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+			})
+			if succeeded := bifrostErr == nil; succeeded != tt.wantSucceeded {
+				t.Errorf("succeeded = %v, want %v (error %+v)", succeeded, tt.wantSucceeded, bifrostErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("provider calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
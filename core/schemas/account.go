@@ -6,12 +6,13 @@ import "context"
 // Key represents an API key and its associated configuration for a provider.
 // It contains the key value, supported models, and a weight for load balancing.
 type Key struct {
-	ID              string           `json:"id"`                          // The unique identifier for the key (not used by bifrost, but can be used by users to identify the key)
-	Value           string           `json:"value"`                       // The actual API key value
-	Models          []string         `json:"models"`                      // List of models this key can access
-	Weight          float64          `json:"weight"`                      // Weight for load balancing between multiple keys
-	AzureKeyConfig  *AzureKeyConfig  `json:"azure_key_config,omitempty"`  // Azure-specific key configuration
-	VertexKeyConfig *VertexKeyConfig `json:"vertex_key_config,omitempty"` // Vertex-specific key configuration
+	ID              string             `json:"id"`                          // The unique identifier for the key (not used by bifrost, but can be used by users to identify the key)
+	Value           string             `json:"value"`                       // The actual API key value
+	Models          []string           `json:"models"`                      // List of models this key can access
+	Weight          float64            `json:"weight"`                      // Weight for load balancing between multiple keys
+	ModelWeights    map[string]float64 `json:"model_weights,omitempty"`     // Weights for specific models, replacing Weight when selecting a key for them
+	AzureKeyConfig  *AzureKeyConfig    `json:"azure_key_config,omitempty"`  // Azure-specific key configuration
+	VertexKeyConfig *VertexKeyConfig   `json:"vertex_key_config,omitempty"` // Vertex-specific key configuration
 }
 
 // AzureKeyConfig represents the Azure-specific configuration.
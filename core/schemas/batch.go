@@ -0,0 +1,133 @@
+// Package schemas defines the core schemas and types used by the Bifrost system.
+package schemas
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bytedance/sonic"
+)
+
+// BatchStatus is the lifecycle state of a batch job, normalized across providers.
+type BatchStatus string
+
+const (
+	BatchStatusValidating BatchStatus = "validating"  // The provider is validating the submitted requests
+	BatchStatusInProgress BatchStatus = "in_progress" // Requests are being processed
+	BatchStatusFinalizing BatchStatus = "finalizing"  // Processing is done and results are being prepared
+	BatchStatusCompleted  BatchStatus = "completed"   // Results are available
+	BatchStatusFailed     BatchStatus = "failed"      // The batch failed as a whole (e.g. invalid input)
+	BatchStatusExpired    BatchStatus = "expired"     // The batch did not finish within the completion window
+	BatchStatusCancelling BatchStatus = "cancelling"  // A cancellation was requested and is in progress
+	BatchStatusCancelled  BatchStatus = "cancelled"   // The batch was cancelled
+)
+
+// IsTerminal reports whether the batch has reached a final state and will not change anymore.
+func (s BatchStatus) IsTerminal() bool {
+	switch s {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchRequest is a set of chat completion requests submitted together to a provider's
+// batch API. Batch APIs are asynchronous and cheaper than real-time requests, and are
+// meant for jobs that are not latency sensitive (e.g. offline evaluations).
+type BatchRequest struct {
+	Provider ModelProvider      `json:"provider"`
+	Model    string             `json:"model"`
+	Items    []BatchRequestItem `json:"items"`
+}
+
+// BatchRequestItem is a single chat completion request in a batch.
+// CustomID identifies the item's result and must be unique within the batch.
+type BatchRequestItem struct {
+	CustomID string           `json:"custom_id"`
+	Messages []BifrostMessage `json:"messages"`
+	Params   *ModelParameters `json:"params,omitempty"`
+}
+
+// BatchRequestCounts reports how many requests of a batch have been processed.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchJob describes a batch submitted to a provider. It is returned by Bifrost.CreateBatch
+// and can be stored (e.g. as JSON) and passed back later to poll, cancel or fetch results.
+type BatchJob struct {
+	ID            string             `json:"id"`
+	Provider      ModelProvider      `json:"provider"`
+	Model         string             `json:"model"`
+	KeyID         string             `json:"key_id,omitempty"` // ID of the key the batch was created with
+	Status        BatchStatus        `json:"status"`
+	RequestCounts BatchRequestCounts `json:"request_counts"`
+	CreatedAt     int64              `json:"created_at,omitempty"` // Unix timestamp in seconds
+	ExpiresAt     int64              `json:"expires_at,omitempty"` // Unix timestamp in seconds
+	// ResultsLocation is the provider's reference to the results (an output file ID or a results URL)
+	ResultsLocation string `json:"results_location,omitempty"`
+	// ErrorsLocation is the provider's reference to the failed items, if stored separately
+	ErrorsLocation string `json:"errors_location,omitempty"`
+}
+
+// BatchResult is the outcome of a single item of a batch, identified by its CustomID.
+// Exactly one of Response and Error is set. Providers only keep batch results for a limited time,
+// so results are often stored: their JSON holds the response as a PersistedPayload, so that
+// results stored with an older schema version (or before versioning) are migrated when read.
+type BatchResult struct {
+	CustomID string           `json:"custom_id"`
+	Response *BifrostResponse `json:"response,omitempty"`
+	Error    *BifrostError    `json:"error,omitempty"`
+}
+
+// persistedBatchResult is the JSON form of a BatchResult.
+type persistedBatchResult struct {
+	CustomID string          `json:"custom_id"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    *BifrostError   `json:"error,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, wrapping the response in a PersistedPayload.
+func (r BatchResult) MarshalJSON() ([]byte, error) {
+	persisted := persistedBatchResult{CustomID: r.CustomID, Error: r.Error}
+	if r.Response != nil {
+		response, err := MarshalPersistedResponse(r.Response)
+		if err != nil {
+			return nil, err
+		}
+		persisted.Response = response
+	}
+	return sonic.Marshal(persisted)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, migrating the response to the current schema version.
+func (r *BatchResult) UnmarshalJSON(data []byte) error {
+	var persisted persistedBatchResult
+	if err := sonic.Unmarshal(data, &persisted); err != nil {
+		return err
+	}
+	*r = BatchResult{CustomID: persisted.CustomID, Error: persisted.Error}
+	if len(persisted.Response) > 0 && string(persisted.Response) != "null" {
+		response, err := UnmarshalPersistedResponse(persisted.Response)
+		if err != nil {
+			return err
+		}
+		r.Response = response
+	}
+	return nil
+}
+
+// BatchProvider is implemented by providers that offer an asynchronous batch API.
+// Batch requests are sent directly to the provider and do not go through the provider queue.
+type BatchProvider interface {
+	// CreateBatch submits the items as a batch of chat completion requests for the model
+	CreateBatch(ctx context.Context, model string, key Key, items []BatchRequestItem) (*BatchJob, *BifrostError)
+	// GetBatch returns the current state of a batch
+	GetBatch(ctx context.Context, key Key, batch *BatchJob) (*BatchJob, *BifrostError)
+	// GetBatchResults returns the results of a completed batch
+	GetBatchResults(ctx context.Context, key Key, batch *BatchJob) ([]BatchResult, *BifrostError)
+	// CancelBatch requests the cancellation of a batch and returns its updated state
+	CancelBatch(ctx context.Context, key Key, batch *BatchJob) (*BatchJob, *BifrostError)
+}
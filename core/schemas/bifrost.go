@@ -2,7 +2,13 @@
 package schemas
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/bytedance/sonic"
 )
@@ -21,8 +27,216 @@ type BifrostConfig struct {
 	InitialPoolSize    int        // Initial pool size for sync pools in Bifrost. Higher values will reduce memory allocations but will increase memory usage.
 	DropExcessRequests bool       // If true, in cases where the queue is full, requests will not wait for the queue to be empty and will be dropped instead.
 	MCPConfig          *MCPConfig // MCP (Model Context Protocol) configuration for tool integration
+
+	// MaxConcurrentRequests caps the total number of in-flight provider calls across all providers,
+	// in addition to each provider's own concurrency. Open streams count until they are closed.
+	// Zero (default) means no global cap.
+	MaxConcurrentRequests int
+
+	// StreamBufferSize is the number of chunks buffered by the channel of each provider stream,
+	// between the loop reading the provider's response and the consumer. Larger buffers let the
+	// read loop run ahead of a consumer that is momentarily slower, at the cost of memory per
+	// stream. Zero (default) means DefaultStreamBufferSize.
+	StreamBufferSize int
+
+	// OnClientDisconnect, if set, is called when a worker cannot deliver a result because the
+	// client is gone (its context was cancelled or the send timed out). It runs synchronously
+	// on the worker goroutine, so it must return quickly.
+	OnClientDisconnect func(info ClientDisconnectInfo)
+
+	// OnRequestTiming, if set, is called after each request handled by a provider worker with the
+	// time it waited in the provider queue and the time the worker spent on it. It runs synchronously
+	// on the worker goroutine, so it must return quickly. Totals are also kept, see Bifrost.GetQueueStats.
+	OnRequestTiming func(timing RequestTiming)
+
+	// OnFirstToken, if set, is called once per stream with its time to first token, when the first
+	// chunk with content (text, thoughts, tool calls, audio or transcribed text) is received. It
+	// runs synchronously on the stream goroutine, so it must return quickly.
+	OnFirstToken func(timing FirstTokenTiming)
+	// OnStreamAbort, if set, is called when a chat completion stream is cancelled (e.g. the client
+	// disconnected) before the provider finished the generation. Bifrost closes the connection to
+	// the provider, which stops the generation, and reports the usage of the partial stream so
+	// that it can be billed: the provider's usage if it was received, otherwise an estimate.
+	// Streams stopped by Bifrost itself (StreamMaxOutputTokens, StopOnToolCall) are not reported.
+	OnStreamAbort func(info StreamAbortInfo)
+	// OnFailover, if set, is called every time a request moves on from a failed provider to one of
+	// its fallbacks, before the fallback is tried. A spike in failovers is an early sign that a
+	// provider is degraded. It is called synchronously, so it should return quickly.
+	OnFailover func(event FailoverEvent)
+
+	// NoMatchingKeyBehavior controls what happens when none of a provider's keys support the
+	// requested model. Defaults to NoMatchingKeyBehaviorError.
+	NoMatchingKeyBehavior NoMatchingKeyBehavior
+
+	// EmptyStreamBehavior controls what happens when a stream ends without any content, e.g. after
+	// a provider hiccup. Defaults to EmptyStreamBehaviorAllow.
+	EmptyStreamBehavior EmptyStreamBehavior
+
+	// StrictInit, if true, makes Init fail with a *NoProvidersError when none of the configured
+	// providers could be initialized, instead of starting an instance that fails every request.
+	StrictInit bool
+
+	// KeySelection, if set, adjusts the weights used to pick among the keys that support the
+	// requested model. By default keys are picked in proportion to their configured Weight.
+	KeySelection *KeySelectionConfig
+
+	// StreamFallbackAdapter, if true, lets a streaming request fall back to a provider that cannot
+	// stream: Bifrost issues a normal request to it and delivers the response as a single-chunk stream.
+	// Disabled by default since the client then receives the whole response at once.
+	StreamFallbackAdapter bool
+
+	// ProviderHealth, if set, tracks the health of each provider with a circuit breaker, and
+	// requests with fallbacks whose primary is unhealthy start directly from their first healthy
+	// fallback instead of paying the primary's failure latency. See ProviderHealthConfig.
+	ProviderHealth *ProviderHealthConfig
+
+	// StreamSequencing, if set, numbers the chunks of every stream returned to callers, so that they
+	// can detect chunks lost or reordered on their way, e.g. by a proxy. See BifrostStream.Sequence.
+	StreamSequencing *StreamSequencingConfig
+
+	// DefaultNetworkConfig, if set, provides the network settings (timeout, retries, backoff, extra
+	// headers, ...) of providers whose config leaves them unset, so that org-wide defaults are set in
+	// one place. BaseURL and PathOverrides are provider-specific and are never inherited. See ProviderConfig.ApplyDefaults.
+	DefaultNetworkConfig *NetworkConfig
+
+	// DefaultConcurrencyAndBufferSize, if set, provides the concurrency and buffer size of providers
+	// whose config leaves them unset.
+	DefaultConcurrencyAndBufferSize *ConcurrencyAndBufferSize
+}
+
+// ClientDisconnectReason describes why a result could not be delivered to the client.
+type ClientDisconnectReason string
+
+const (
+	ClientDisconnectReasonContextDone ClientDisconnectReason = "context_done" // The client's context was cancelled or timed out
+	ClientDisconnectReasonSendTimeout ClientDisconnectReason = "send_timeout" // Nobody received the result within the send timeout
+)
+
+// ClientDisconnectInfo carries the metadata of a request whose result could not be delivered.
+type ClientDisconnectInfo struct {
+	Provider    ModelProvider          // Provider that handled the request
+	Model       string                 // Model requested
+	RequestType string                 // Type of request (e.g. chat_completion)
+	Reason      ClientDisconnectReason // Why the result could not be delivered
+	Error       *BifrostError          // The undelivered error, if the request failed (nil on success)
+}
+
+// RequestTiming splits the time Bifrost spent on a request between waiting for a provider worker
+// and being served by it. A long QueueWait means the provider needs more concurrency, while a long
+// ServiceTime means the provider itself is slow.
+type RequestTiming struct {
+	Provider    ModelProvider // Provider that handled the request
+	Model       string        // Model requested
+	RequestType string        // Type of request (e.g. chat_completion)
+	// QueueWait is the time from the request being queued until a worker picked it up.
+	QueueWait time.Duration
+	// ServiceTime is the time the worker spent on the request, including key selection, retries and
+	// their backoff. For streaming requests it covers establishing the stream, not consuming it.
+	ServiceTime time.Duration
+	Failed      bool // True if the request failed
+}
+
+// FirstTokenTiming carries the time to first token (TTFT) of a stream: the time from the start of
+// the provider call that established the stream to the first chunk with content.
+type FirstTokenTiming struct {
+	Provider         ModelProvider // Provider that served the stream
+	Model            string        // Model requested
+	RequestType      string        // Type of request (e.g. chat_completion_stream)
+	TimeToFirstToken time.Duration
+}
+
+// StreamAbortInfo describes a chat completion stream that was cancelled before it finished.
+type StreamAbortInfo struct {
+	Provider    ModelProvider // Provider that served the stream
+	Model       string        // Model requested
+	RequestType string        // Type of request (e.g. chat_completion_stream)
+	Usage       LLMUsage      // Tokens consumed by the partial stream
+	Estimated   bool          // True if Usage is estimated, false if it was reported by the provider
+	Err         error         // Cause of the cancellation (e.g. context.Canceled)
+}
+
+// FailoverEvent describes a request falling back from a failed provider to one of its fallbacks.
+type FailoverEvent struct {
+	RequestType string       // Type of request (e.g. chat_completion)
+	Failed      AttemptError // Provider and model that failed, and why
+	Fallback    Fallback     // Provider and model tried next
+	Attempt     int          // Number of the fallback being tried, starting at 1 for the first fallback tried
+}
+
+// ProviderHealthConfig configures the circuit breaker of each provider. A provider's circuit opens
+// after FailureThreshold consecutive failed requests (connection errors, timeouts, rate limits and
+// server errors, after retries), which makes it unhealthy. While it is open, one request is let
+// through every ProbeInterval to probe the provider; a successful request closes the circuit.
+// Skipped providers are reported in BifrostResponseExtraFields.SkippedProviders.
+type ProviderHealthConfig struct {
+	FailureThreshold int           // Consecutive failed requests that open the circuit (defaults to DefaultProviderHealthFailureThreshold)
+	ProbeInterval    time.Duration // Time between probes of an open circuit (defaults to DefaultProviderHealthProbeInterval)
+}
+
+const (
+	DefaultProviderHealthFailureThreshold = 5
+	DefaultProviderHealthProbeInterval    = 30 * time.Second
+)
+
+// KeySelectionConfig adjusts the weighted random selection of a provider's keys.
+type KeySelectionConfig struct {
+	// NormalizeByModelCount divides each key's weight by the number of models it lists, so that a
+	// key shared by many models receives a proportional share of each model's traffic instead of its
+	// full weight. Keys with an empty Models list count as listing every model listed by the
+	// provider's keys.
+	NormalizeByModelCount bool
+
+	// ErrorRateHalfLife, if positive, deprioritizes keys whose recent requests failed: a key's weight
+	// is multiplied by its recent success rate, where outcomes lose half their influence every
+	// ErrorRateHalfLife. Only failures attributable to the key count (authentication errors,
+	// rate limits and server errors). Failing keys keep a small share of traffic, so they are
+	// picked again once they recover.
+	ErrorRateHalfLife time.Duration
+
+	// RateLimitAware deprioritizes keys close to their provider rate limits: a key's weight is
+	// multiplied by the fraction of its request or token budget left (whichever is lower), as
+	// reported by the rate limit headers of its latest response, until the budget is replenished.
+	// Exhausted keys keep a small share of traffic, so they are picked again after a reset that
+	// was not reported. See Bifrost.GetKeyRateLimits.
+	RateLimitAware bool
+}
+
+// NoMatchingKeyBehavior defines how key selection behaves when no key supports the requested model.
+// Keys with an empty Models list support all models and are always considered a match.
+type NoMatchingKeyBehavior string
+
+const (
+	// NoMatchingKeyBehaviorError fails the request with an error listing the models the keys do support.
+	NoMatchingKeyBehaviorError NoMatchingKeyBehavior = "error"
+	// NoMatchingKeyBehaviorAnyKey falls back to weighted selection across all of the provider's keys.
+	NoMatchingKeyBehaviorAnyKey NoMatchingKeyBehavior = "any_key"
+)
+
+// IsValid reports whether the behavior is one of the defined NoMatchingKeyBehavior values.
+func (b NoMatchingKeyBehavior) IsValid() bool {
+	switch b {
+	case NoMatchingKeyBehaviorError, NoMatchingKeyBehaviorAnyKey:
+		return true
+	}
+	return false
 }
 
+// EmptyStreamBehavior defines how streams that end without content (no text, thoughts, tool
+// calls, audio or transcribed text before the end of the stream) are handled.
+type EmptyStreamBehavior string
+
+const (
+	// EmptyStreamBehaviorAllow returns empty streams to the caller as successful streams.
+	EmptyStreamBehaviorAllow EmptyStreamBehavior = "allow"
+	// EmptyStreamBehaviorError holds back the chunks of a stream until one has content. A stream
+	// that ends before is a failed attempt of kind ErrorKindEmptyStream, retried like a
+	// connection error and falling back like any provider error.
+	// The chunks that precede the first content chunk are only delivered with it, and plugins'
+	// post-hooks only run for the chunks of streams that are delivered. Cancellation while
+	// waiting for content fails the request with a RequestCancelled error.
+	EmptyStreamBehaviorError EmptyStreamBehavior = "error"
+)
+
 // ModelChatMessageRole represents the role of a chat message
 type ModelChatMessageRole string
 
@@ -150,6 +364,318 @@ type BifrostRequest struct {
 	// Provider config must be available for each fallback's provider in account's GetConfigForProvider,
 	// else it will be skipped.
 	Fallbacks []Fallback `json:"fallbacks,omitempty"`
+
+	// BaseURL overrides the provider's configured base URL for this request only, reusing the
+	// provider's auth and HTTP client (e.g. for canary routing). It applies to the primary
+	// provider only, not to fallbacks, and is ignored by providers whose endpoints are derived
+	// from key or meta configuration (Azure, Bedrock, Vertex).
+	BaseURL *string `json:"base_url,omitempty"`
+
+	// MaxRetries, if set, caps the retries of this request across all kinds of retries. It can
+	// only lower the provider's retry budgets (NetworkConfig.MaxRetries, ConnectionMaxRetries and
+	// RequestMaxRetries), never raise them. Set it to 0 to fail fast on requests that are not
+	// idempotent or for which a retry's latency is worse than a failure. It also applies to fallbacks.
+	MaxRetries *int `json:"max_retries,omitempty"`
+
+	// MaxFallbacks, if set, caps how many of the Fallbacks are tried after the primary fails,
+	// bounding the latency and cost of a request with a long fallback list. Fallbacks skipped for
+	// lack of a config do not count, and those left untried are reported in the final error's
+	// SkippedFallbacks. Set it to 0 to only try the primary.
+	MaxFallbacks *int `json:"max_fallbacks,omitempty"`
+
+	// ExtraHeaders are sent to the provider with this request only, on top of the provider's
+	// NetworkConfig.ExtraHeaders, which they override. They let a shared instance attribute each
+	// request to its tenant (e.g. OpenAI-Organization and OpenAI-Project for billing). Only the
+	// headers in AllowedRequestHeaders are accepted. They also apply to fallbacks, except for the
+	// OpenAI headers, which are only sent to OpenAI.
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
+
+	// OnUsageUpdate, if set, is called for chat completion streams after each chunk with the
+	// running token usage. Counts are estimated from the streamed text until the provider reports
+	// its own usage, which then replaces the estimate. It runs on the stream goroutine, so it must
+	// return quickly.
+	OnUsageUpdate func(update UsageUpdate) `json:"-"`
+
+	// PostProcess, if set, is called with the response of a non-streaming request after the
+	// plugins' post-hooks, right before it is returned, and may modify it in place (e.g. strip a
+	// prefix the model always adds). It is a lightweight alternative to a plugin for one-off
+	// transformations. It is not called for streams, see PostProcessChunk.
+	PostProcess func(result *BifrostResponse) `json:"-"`
+
+	// PostProcessChunk, if set, is called with the response of each chunk of a stream after the
+	// plugins' post-hooks, right before it reaches the caller, and may modify it in place. Chunks
+	// are processed one at a time, as they arrive, so it cannot transform content that spans
+	// chunks (e.g. a prefix split across two deltas); callers that need the whole response should
+	// use a non-streaming request with PostProcess.
+	PostProcessChunk func(result *BifrostResponse) `json:"-"`
+
+	// AcceptResponse, if set, is called with each successful response of a non-streaming request,
+	// before the plugins' post-hooks. If it returns an error, the response is discarded: the
+	// post-hooks see an error of type ResponseRejected instead, and the next fallback is tried as
+	// if the provider had failed with it.
+	// It catches "successful but useless" responses, e.g. empty content (see
+	// bifrost.RejectEmptyResponse). It is also applied to the fallbacks' responses.
+	AcceptResponse func(result *BifrostResponse) error `json:"-"`
+
+	// StreamMaxOutputTokens, if positive, caps the output of chat completion streams regardless of
+	// the model's max_tokens. Streamed tokens are estimated like for OnUsageUpdate; once the cap is
+	// reached, the chunk is cut to fit and sent with the FinishReasonLength finish reason, and the
+	// provider stream is cancelled. The cap is applied before the plugins' post-hooks.
+	StreamMaxOutputTokens int `json:"stream_max_output_tokens,omitempty"`
+
+	// AutoContinue, if set, makes non-streaming chat completions continue generation when the
+	// response is truncated by the token limit, and returns the concatenated result.
+	AutoContinue *AutoContinueConfig `json:"auto_continue,omitempty"`
+
+	// TruncatedToolCallRetry, if set, retries non-streaming chat completions once with a higher
+	// max_tokens when a tool call's arguments are not valid JSON because the response was cut off
+	// by the token limit.
+	TruncatedToolCallRetry *TruncatedToolCallRetryConfig `json:"truncated_tool_call_retry,omitempty"`
+
+	// PrimaryCandidates, if set, splits primary traffic across providers and models (e.g. for A/B
+	// tests): for each request, one candidate is picked at random in proportion to its weight and
+	// replaces Provider and Model. Fallbacks still apply after the chosen primary. BaseURL only
+	// applies if the chosen candidate's provider is Provider. The choice is reported in
+	// ExtraFields.SelectedPrimary.
+	PrimaryCandidates []PrimaryCandidate `json:"primary_candidates,omitempty"`
+
+	// Hedging, if set, races the primary and fallbacks of non-streaming requests instead of
+	// trying them one after the other, trading extra provider cost for lower tail latency.
+	Hedging *HedgingConfig `json:"hedging,omitempty"`
+
+	// TranscriptionChunking, if set, splits the audio of transcription requests into segments
+	// that are transcribed one after the other, each with its own retries, and stitches the
+	// results with adjusted timestamps, so that a failure only retries the affected segment.
+	TranscriptionChunking *TranscriptionChunkingConfig `json:"transcription_chunking,omitempty"`
+
+	// EmbeddingRouting, if set, spreads the texts of embedding requests across several providers
+	// to increase throughput: the texts are split into batches that are sent in parallel to the
+	// routing targets, in proportion to their weights, and the embeddings are returned in the
+	// order of the texts. Unlike Fallbacks, which are only tried on failure, every target takes
+	// its share of the load. Provider and Model are ignored, and Fallbacks apply to each batch.
+	EmbeddingRouting *EmbeddingRoutingConfig `json:"embedding_routing,omitempty"`
+
+	// NormalizeEmbeddings, if true, scales the embeddings of embedding responses to unit L2 norm,
+	// whichever provider served them, so that similarity scores stay consistent when a fallback or
+	// a routing target does not normalize its embeddings. Zero vectors are returned as is.
+	NormalizeEmbeddings bool `json:"normalize_embeddings,omitempty"`
+
+	// OutputLanguage, if set, asks the model to respond in a given language, with a system
+	// instruction added to the messages of chat completion requests (after the caller's own system
+	// messages). It is ignored by other request types.
+	OutputLanguage *OutputLanguageConfig `json:"output_language,omitempty"`
+
+	// CaptureStreamTiming, if true, records the arrival time of the content chunks of a stream and
+	// reports their inter-chunk latency in ExtraFields.StreamTiming. Disabled by default.
+	CaptureStreamTiming bool `json:"capture_stream_timing,omitempty"`
+
+	// ExactMatch, if true, sends the request to exactly Provider and Model (and each fallback to
+	// exactly its provider and model), bypassing the rewrites Bifrost would otherwise apply: the
+	// skipping of unhealthy primaries (BifrostConfig.ProviderHealth), the replacement of
+	// DecommissionedModels, changes of the provider or model by plugin PreHooks (which cannot clear
+	// the flag either), the conversions between chat and text completions, the downscaling of
+	// images to ImageLimits and the normalization of penalties. It is an
+	// escape hatch to test a provider's raw behavior, e.g. for debugging or A/B control groups.
+	// Retries, Fallbacks and Hedging still apply as specified. It cannot be combined with
+	// PrimaryCandidates or EmbeddingRouting.
+	ExactMatch bool `json:"exact_match,omitempty"`
+}
+
+// OutputLanguageConfig hints the language the model should respond in.
+type OutputLanguageConfig struct {
+	// Language is the language to respond in, as a name or a locale, e.g. "French" or "pt-BR".
+	Language string `json:"language"`
+	// Instruction replaces the default instruction, "Respond in <Language>.", e.g. to word it in
+	// the language itself or to add locale conventions such as date and currency formats.
+	Instruction string `json:"instruction,omitempty"`
+}
+
+// AllowedRequestHeaders lists the headers, in canonical form, that a request can set in
+// BifrostRequest.ExtraHeaders. Auth headers are never allowed, as they would bypass key selection.
+var AllowedRequestHeaders = []string{
+	"Openai-Organization",
+	"Openai-Project",
+}
+
+// PrimaryCandidate is a provider and model that can be picked as the primary of a request.
+type PrimaryCandidate struct {
+	Provider ModelProvider `json:"provider"`
+	Model    string        `json:"model"`
+	Weight   float64       `json:"weight"` // Relative share of the requests, like Key.Weight
+}
+
+// SelectedPrimary is the primary picked among a request's PrimaryCandidates.
+type SelectedPrimary struct {
+	Provider ModelProvider `json:"provider"`
+	Model    string        `json:"model"`
+}
+
+// HedgingConfig configures hedged requests. The primary is sent first, and each fallback is
+// started DelayMs after the previous attempt, or as soon as an attempt fails, while earlier
+// attempts keep running. The first successful response is returned and the other attempts are
+// cancelled. Errors that do not allow fallbacks (e.g. a cancelled request) end the race.
+type HedgingConfig struct {
+	DelayMs int `json:"delay_ms"` // Delay in milliseconds before starting the next attempt (must be non-negative)
+}
+
+// TranscriptionChunkingConfig configures chunked transcriptions. Only WAV (PCM) audio can be
+// split; other audio is rejected. Segments are cut in a silence near their end when there is one,
+// so they can be a little shorter than SegmentSeconds. A request is cancelled through its context
+// between or during segments; to resume it, the results reported to OnProgress are passed back as
+// CompletedSegments.
+type TranscriptionChunkingConfig struct {
+	SegmentSeconds    int `json:"segment_seconds"`               // Duration of each segment in seconds (must be positive)
+	MaxSegmentRetries int `json:"max_segment_retries,omitempty"` // Retries of a failed segment, on top of the provider's own retries
+	// CompletedSegments are the results of the first segments from an earlier, interrupted run
+	// with the same audio and SegmentSeconds. They are not transcribed again.
+	CompletedSegments []BifrostTranscribe `json:"completed_segments,omitempty"`
+	// OnProgress, if set, is called after each segment is transcribed.
+	OnProgress func(progress TranscriptionProgress) `json:"-"`
+}
+
+// EmbeddingRoutingConfig configures the routing of embedding requests across providers. Batches
+// are assigned to the targets by smooth weighted round-robin, which carries over from one request
+// to the next, so requests of a single batch are spread across the targets too. All the targets
+// must return embeddings of the same dimension, e.g. by setting ModelParameters.Dimensions for
+// models that can shorten their embeddings; requests whose batches differ in dimension fail.
+type EmbeddingRoutingConfig struct {
+	Targets              []EmbeddingRoutingTarget `json:"targets"`
+	BatchSize            int                      `json:"batch_size"`                       // Texts per batch (must be positive)
+	MaxConcurrentBatches int                      `json:"max_concurrent_batches,omitempty"` // Batches sent at the same time (all of them if 0)
+}
+
+// EmbeddingRoutingTarget is a provider and model embedding requests are routed to.
+type EmbeddingRoutingTarget struct {
+	Provider ModelProvider `json:"provider"`
+	Model    string        `json:"model"`
+	Weight   float64       `json:"weight"` // Relative share of the batches, like Key.Weight
+}
+
+// EmbeddingBatch describes which provider embedded a batch of texts of a routed embedding request.
+type EmbeddingBatch struct {
+	Provider ModelProvider `json:"provider"`
+	Model    string        `json:"model"`
+	Offset   int           `json:"offset"` // Index of the first text of the batch
+	Count    int           `json:"count"`  // Number of texts in the batch
+}
+
+// TranscriptionProgress reports a transcribed segment of a chunked transcription.
+type TranscriptionProgress struct {
+	Segment  int               // Index of the segment, from 0
+	Segments int               // Total number of segments
+	Result   BifrostTranscribe // Transcription of the segment, with timestamps relative to the segment
+}
+
+// TruncatedToolCallRetryConfig configures the retry of chat completions whose tool calls were cut off.
+type TruncatedToolCallRetryConfig struct {
+	MaxTokens int `json:"max_tokens"` // max_tokens of the retried request (must be positive)
+}
+
+// AutoContinueConfig configures automatic continuation of truncated chat completions.
+// When a response stops because of the token limit (finish reason "length" or "max_tokens"),
+// Bifrost sends the partial answer back as an assistant turn followed by a user turn asking the
+// model to continue, until the model stops naturally or MaxContinuations is reached.
+type AutoContinueConfig struct {
+	MaxContinuations int    `json:"max_continuations"`         // Maximum number of follow-up requests (must be positive)
+	ContinuePrompt   string `json:"continue_prompt,omitempty"` // User turn sent to request a continuation (optional, defaults to a generic instruction)
+}
+
+// UsageUpdate carries the running token usage of a streaming request.
+type UsageUpdate struct {
+	Usage     LLMUsage // Running token counts for the request
+	Estimated bool     // True while counts are estimated, false once they come from the provider
+}
+
+// BifrostContextKey is a custom type for context keys set by Bifrost to prevent key collisions.
+type BifrostContextKey string
+
+const (
+	// BifrostContextKeyBaseURL carries the per-request base URL override to providers.
+	BifrostContextKeyBaseURL BifrostContextKey = "bifrost-base-url"
+	// BifrostContextKeyResponseMetadata carries the *ResponseMetadata providers record HTTP response details into.
+	BifrostContextKeyResponseMetadata BifrostContextKey = "bifrost-response-metadata"
+	// BifrostContextKeyBodyTransforms carries the provider's *BodyTransforms, applied around its HTTP calls.
+	BifrostContextKeyBodyTransforms BifrostContextKey = "bifrost-body-transforms"
+	// BifrostContextKeyStreamTap is set to true for streams of providers with LogStreamChunks enabled.
+	BifrostContextKeyStreamTap BifrostContextKey = "bifrost-stream-tap"
+	// BifrostContextKeyStreamBufferSize carries the buffer size of the chunk channels of streams.
+	BifrostContextKeyStreamBufferSize BifrostContextKey = "bifrost-stream-buffer-size"
+	// BifrostContextKeyRequestCompression carries the provider's *RequestCompressionConfig, applied to its request bodies.
+	BifrostContextKeyRequestCompression BifrostContextKey = "bifrost-request-compression"
+	// BifrostContextKeyRequestSigner carries the provider's RequestSigner, called before its HTTP calls.
+	BifrostContextKeyRequestSigner BifrostContextKey = "bifrost-request-signer"
+	// BifrostContextKeySelectedPrimary carries the *SelectedPrimary picked among the request's PrimaryCandidates.
+	BifrostContextKeySelectedPrimary BifrostContextKey = "bifrost-selected-primary"
+	// BifrostContextKeySkippedProviders carries the unhealthy providers ([]Fallback) skipped for the request.
+	BifrostContextKeySkippedProviders BifrostContextKey = "bifrost-skipped-providers"
+	// BifrostContextKeyExtraHeaders carries the request's ExtraHeaders (map[string]string) to providers.
+	BifrostContextKeyExtraHeaders BifrostContextKey = "bifrost-extra-headers"
+	// BifrostContextKeyRequestID carries the caller's request ID (string), e.g. for NetworkConfig.ContextHeaders.
+	// The HTTP transport sets it from the x-request-id header, or a generated ID.
+	BifrostContextKeyRequestID BifrostContextKey = "bifrost-request-id"
+	// BifrostContextKeyRenderedPrompt is set to true when the prompt of a text completion was rendered
+	// from chat messages with a ChatTemplate. Providers then send it without their own prompt framing.
+	BifrostContextKeyRenderedPrompt BifrostContextKey = "bifrost-rendered-prompt"
+)
+
+// ProviderRequestIDHeaders lists the response headers providers identify their requests with, in
+// order of preference: x-request-id (OpenAI, Groq), request-id (Anthropic), x-amzn-requestid
+// (Bedrock), apim-request-id and x-ms-request-id (Azure) and mistral-correlation-id (Mistral).
+var ProviderRequestIDHeaders = []string{"x-request-id", "request-id", "x-amzn-requestid", "apim-request-id", "x-ms-request-id", "mistral-correlation-id"}
+
+// ResponseMetadata collects the HTTP status and selected headers of the latest provider response
+// of a request. Bifrost copies them onto BifrostResponseExtraFields on success, and the provider's
+// request ID onto BifrostError on failure.
+type ResponseMetadata struct {
+	CaptureHeaders []string          // Header names to capture (case-insensitive); a trailing "*" matches a prefix
+	StatusCode     int               // HTTP status code of the latest response
+	Headers        map[string]string // Captured headers, keyed by lower-case name
+	RequestID      string            // Provider's request ID of the latest response, from ProviderRequestIDHeaders
+
+	CaptureRequestBody bool   // Whether providers record the body of their requests
+	RequestBody        []byte // Redacted body of the latest request, as JSON
+
+	// RateLimitHeaders are the rate limit headers of the latest response, whatever CaptureHeaders,
+	// keyed by lower-case name, from which Bifrost tracks the rate limit budget of each key
+	RateLimitHeaders map[string]string
+}
+
+// RateLimitHeaderPrefixes are the prefixes of the rate limit headers recorded in
+// ResponseMetadata.RateLimitHeaders: x-ratelimit- (OpenAI, Groq and other OpenAI compatible APIs)
+// and anthropic-ratelimit- (Anthropic).
+var RateLimitHeaderPrefixes = []string{"x-ratelimit-", "anthropic-ratelimit-"}
+
+// Reset records a new response's status code and drops headers and the request ID captured from earlier attempts.
+func (m *ResponseMetadata) Reset(statusCode int) {
+	m.StatusCode = statusCode
+	m.Headers = nil
+	m.RequestID = ""
+	m.RateLimitHeaders = nil
+}
+
+// SetHeader stores a response header if its name matches CaptureHeaders, and rate limit headers
+// in RateLimitHeaders.
+func (m *ResponseMetadata) SetHeader(name, value string) {
+	name = strings.ToLower(name)
+	for _, prefix := range RateLimitHeaderPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			if m.RateLimitHeaders == nil {
+				m.RateLimitHeaders = make(map[string]string)
+			}
+			m.RateLimitHeaders[name] = value
+			break
+		}
+	}
+	for _, pattern := range m.CaptureHeaders {
+		pattern = strings.ToLower(pattern)
+		if prefix, isPrefix := strings.CutSuffix(pattern, "*"); (isPrefix && strings.HasPrefix(name, prefix)) || name == pattern {
+			if m.Headers == nil {
+				m.Headers = make(map[string]string)
+			}
+			m.Headers[name] = value
+			return
+		}
+	}
 }
 
 // Fallback represents a fallback model to be used if the primary model is not available.
@@ -171,15 +697,61 @@ type ModelParameters struct {
 	StopSequences     *[]string   `json:"stop_sequences,omitempty"`      // Sequences that stop generation
 	PresencePenalty   *float64    `json:"presence_penalty,omitempty"`    // Penalizes repeated tokens
 	FrequencyPenalty  *float64    `json:"frequency_penalty,omitempty"`   // Penalizes frequent tokens
-	ParallelToolCalls *bool       `json:"parallel_tool_calls,omitempty"` // Enables parallel tool calls
+	RepetitionPenalty *float64    `json:"repetition_penalty,omitempty"`  // Multiplicative penalty of repeated tokens (1 means no penalty)
+	ParallelToolCalls *bool       `json:"parallel_tool_calls,omitempty"` // Allows several tool calls per response (provider default if nil, rejected by providers without the option)
 	EncodingFormat    *string     `json:"encoding_format,omitempty"`     // Format for embedding output (e.g., "float", "base64")
 	Dimensions        *int        `json:"dimensions,omitempty"`          // Number of dimensions for embedding output
 	User              *string     `json:"user,omitempty"`                // User identifier for tracking
+	Seed              *int        `json:"seed,omitempty"`                // Seed for best-effort deterministic sampling, see ExtraFields.Reproducibility
+	// LogitBias maps token IDs to a bias in [-100, 100] added to their logits before sampling.
+	// Only supported by providers whose capabilities report LogitBias (OpenAI and Azure); requests
+	// to other providers are rejected.
+	LogitBias map[int]float64 `json:"logit_bias,omitempty"`
+	// SafetySettings maps a safety category to a threshold (e.g. "HARM_CATEGORY_HATE_SPEECH": "BLOCK_ONLY_HIGH").
+	// Vertex Gemini models get the HARM_CATEGORY_* keys and Bedrock gets the guardrail keys below;
+	// other keys are dropped with a warning. Ignored by providers without the concept.
+	SafetySettings map[string]string `json:"safety_settings,omitempty"`
+	// StopOnToolCall ends the response after the first tool call. Parallel tool calls are disabled
+	// with the provider's own option, text after the tool call is dropped from responses, and
+	// streams are closed once the tool call's arguments are complete. Only supported by providers that can control parallel tool calls; requests to
+	// other providers are rejected.
+	StopOnToolCall bool `json:"stop_on_tool_call,omitempty"`
 	// Dynamic parameters that can be provider-specific, they are directly
 	// added to the request as is.
 	ExtraParams map[string]interface{} `json:"-"`
 }
 
+// Reserved SafetySettings keys used to configure a Bedrock guardrail instead of a category threshold.
+const (
+	SafetySettingBedrockGuardrailIdentifier = "guardrail_identifier" // Guardrail ID or ARN
+	SafetySettingBedrockGuardrailVersion    = "guardrail_version"    // Guardrail version (e.g. "1" or "DRAFT")
+	SafetySettingBedrockGuardrailTrace      = "guardrail_trace"      // "enabled" or "disabled"
+)
+
+// Embedding encoding formats accepted by ModelParameters.EncodingFormat.
+const (
+	EmbeddingEncodingFloat  = "float"  // Embeddings are returned as float arrays on BifrostResponse.Embedding
+	EmbeddingEncodingBase64 = "base64" // Embeddings are returned as-is on BifrostResponse.EmbeddingBase64
+)
+
+// FinishReasonContentFilter is the normalized finish reason reported when a provider's
+// safety system blocked or truncated the output. Such choices also have ContentFilter set.
+const FinishReasonContentFilter = "content_filter"
+
+// FinishReasonLength is the finish reason reported when the output was cut at a token limit,
+// including BifrostRequest.StreamMaxOutputTokens.
+const FinishReasonLength = "length"
+
+// FinishReasonToolCalls is the finish reason reported when the model stopped to call tools,
+// including streams stopped by ModelParameters.StopOnToolCall.
+const FinishReasonToolCalls = "tool_calls"
+
+// ContentFilterResult describes why a provider's safety system blocked or truncated a choice.
+type ContentFilterResult struct {
+	Reason     string   `json:"reason"`               // The provider's original finish reason (e.g. "SAFETY", "guardrail_intervened")
+	Categories []string `json:"categories,omitempty"` // Categories that triggered the filter, if reported by the provider
+}
+
 // FunctionParameters represents the parameters for a function definition.
 type FunctionParameters struct {
 	Type        string                 `json:"type"`                  // Type of the parameters
@@ -349,7 +921,9 @@ type ContentBlock struct {
 	ImageURL *ImageURLStruct  `json:"image_url,omitempty"`
 }
 
-// ToolMessage represents a message from a tool
+// ToolMessage represents a message from a tool.
+// The tool result itself is carried in the message Content, which may be a plain string or
+// content blocks mixing text and images (e.g. a chart rendered by an MCP tool).
 type ToolMessage struct {
 	ToolCallID *string `json:"tool_call_id,omitempty"`
 }
@@ -375,18 +949,71 @@ type BifrostResponse struct {
 	ID                string                     `json:"id,omitempty"`
 	Object            string                     `json:"object,omitempty"` // text.completion, chat.completion, or embedding
 	Choices           []BifrostResponseChoice    `json:"choices,omitempty"`
-	Embedding         [][]float32                `json:"data,omitempty"`       // Maps to "data" field in provider responses (e.g., OpenAI embedding format)
-	Speech            *BifrostSpeech             `json:"speech,omitempty"`     // Maps to "speech" field in provider responses (e.g., OpenAI speech format)
-	Transcribe        *BifrostTranscribe         `json:"transcribe,omitempty"` // Maps to "transcribe" field in provider responses (e.g., OpenAI transcription format)
+	Embedding         [][]float32                `json:"data,omitempty"`             // Maps to "data" field in provider responses (e.g., OpenAI embedding format)
+	EmbeddingBase64   []string                   `json:"embedding_base64,omitempty"` // Raw embeddings for the "base64" encoding format, see DecodeEmbeddings
+	Speech            *BifrostSpeech             `json:"speech,omitempty"`           // Maps to "speech" field in provider responses (e.g., OpenAI speech format)
+	Transcribe        *BifrostTranscribe         `json:"transcribe,omitempty"`       // Maps to "transcribe" field in provider responses (e.g., OpenAI transcription format)
 	Model             string                     `json:"model,omitempty"`
 	Created           int                        `json:"created,omitempty"` // The Unix timestamp (in seconds).
 	ServiceTier       *string                    `json:"service_tier,omitempty"`
 	SystemFingerprint *string                    `json:"system_fingerprint,omitempty"`
 	Usage             *LLMUsage                  `json:"usage,omitempty"`
+	Grounding         *GroundingMetadata         `json:"grounding,omitempty"` // Sources cited by a grounded response (e.g. web search results)
 	ExtraFields       BifrostResponseExtraFields `json:"extra_fields"`
 }
 
-// LLMUsage represents token usage information
+// DecodeEmbeddings returns the embeddings of the response as float slices, decoding
+// EmbeddingBase64 if the embeddings were requested in the "base64" encoding format.
+func (r *BifrostResponse) DecodeEmbeddings() ([][]float32, error) {
+	if len(r.EmbeddingBase64) == 0 {
+		return r.Embedding, nil
+	}
+
+	embeddings := make([][]float32, len(r.EmbeddingBase64))
+	for i, encoded := range r.EmbeddingBase64 {
+		embedding, err := DecodeBase64Embedding(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("embedding %d: %w", i, err)
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// EncodeBase64Embedding encodes an embedding as base64 little-endian float32 values, the
+// inverse of DecodeBase64Embedding.
+func EncodeBase64Embedding(embedding []float32) string {
+	const sizeOfFloat32 = 4
+	raw := make([]byte, len(embedding)*sizeOfFloat32)
+	for i, value := range embedding {
+		binary.LittleEndian.PutUint32(raw[i*sizeOfFloat32:], math.Float32bits(value))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// DecodeBase64Embedding decodes a base64-encoded embedding of little-endian float32 values,
+// as returned by OpenAI compatible APIs for the "base64" encoding format.
+func DecodeBase64Embedding(encoded string) ([]float32, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 embedding: %w", err)
+	}
+
+	const sizeOfFloat32 = 4
+	if len(decoded)%sizeOfFloat32 != 0 {
+		return nil, fmt.Errorf("malformed base64 embedding data: length not divisible by %d", sizeOfFloat32)
+	}
+
+	floats := make([]float32, len(decoded)/sizeOfFloat32)
+	for i := range floats {
+		floats[i] = math.Float32frombits(binary.LittleEndian.Uint32(decoded[i*sizeOfFloat32:]))
+	}
+	return floats, nil
+}
+
+// LLMUsage represents token usage information.
+// PromptTokens include cached prompt tokens and CompletionTokens include reasoning tokens, as in
+// OpenAI's usage; the details break them down for providers that report them.
 type LLMUsage struct {
 	PromptTokens            int                      `json:"prompt_tokens"`
 	CompletionTokens        int                      `json:"completion_tokens"`
@@ -410,8 +1037,9 @@ type AudioTokenDetails struct {
 // TokenDetails provides detailed information about token usage.
 // It is not provided by all model providers.
 type TokenDetails struct {
-	CachedTokens int `json:"cached_tokens,omitempty"`
-	AudioTokens  int `json:"audio_tokens,omitempty"`
+	CachedTokens        int `json:"cached_tokens,omitempty"`         // Prompt tokens read from the provider's prompt cache
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"` // Prompt tokens written to the provider's prompt cache (e.g. Anthropic)
+	AudioTokens         int `json:"audio_tokens,omitempty"`
 }
 
 // CompletionTokensDetails provides detailed information about completion token usage.
@@ -490,13 +1118,31 @@ type Annotation struct {
 	Citation Citation `json:"url_citation"`
 }
 
+// GroundingMetadata carries the sources a grounded response is based on, such as the web search
+// results or the documents cited by its content, normalized across providers.
+type GroundingMetadata struct {
+	Citations     []GroundingCitation `json:"citations,omitempty"`
+	SearchQueries []string            `json:"search_queries,omitempty"` // Queries the provider searched for, if reported
+}
+
+// GroundingCitation is a source cited by the content of a response choice.
+type GroundingCitation struct {
+	ChoiceIndex int    `json:"choice_index"`          // Index of the choice whose content cites the source
+	StartIndex  *int   `json:"start_index,omitempty"` // Start of the citing span in the choice's text content, in characters, if reported
+	EndIndex    *int   `json:"end_index,omitempty"`   // End (exclusive) of the citing span in the choice's text content, in characters, if reported
+	Title       string `json:"title,omitempty"`       // Title of the source (e.g. web page or document title)
+	URL         string `json:"url,omitempty"`         // URL of the source, if it is a web page
+	CitedText   string `json:"cited_text,omitempty"`  // Excerpt of the source the span is based on, if reported
+}
+
 // BifrostResponseChoice represents a choice in the completion result.
 // This struct can represent either a streaming or non-streaming response choice.
 // IMPORTANT: Only one of BifrostNonStreamResponseChoice or BifrostStreamResponseChoice
 // should be non-nil at a time.
 type BifrostResponseChoice struct {
-	Index        int     `json:"index"`
-	FinishReason *string `json:"finish_reason,omitempty"`
+	Index         int                  `json:"index"`
+	FinishReason  *string              `json:"finish_reason,omitempty"`
+	ContentFilter *ContentFilterResult `json:"content_filter,omitempty"` // Set when FinishReason is FinishReasonContentFilter
 
 	*BifrostNonStreamResponseChoice
 	*BifrostStreamResponseChoice
@@ -558,6 +1204,10 @@ type BifrostTranscribeNonStreamResponse struct {
 type BifrostTranscribeStreamResponse struct {
 	Type  *string `json:"type,omitempty"`  // "transcript.text.delta" or "transcript.text.done"
 	Delta *string `json:"delta,omitempty"` // For delta events
+	// IsFinal reports whether the chunk's text is stable. Interim chunks (IsFinal false) carry a
+	// provisional hypothesis that is superseded by a later final chunk, which carries the complete
+	// text of the segment. Providers without interim support only emit final chunks.
+	IsFinal bool `json:"is_final"`
 }
 
 // TranscriptionLogProb represents log probability information for transcription
@@ -606,15 +1256,138 @@ type BifrostResponseExtraFields struct {
 	ChatHistory *[]BifrostMessage `json:"chat_history,omitempty"`
 	BilledUsage *BilledLLMUsage   `json:"billed_usage,omitempty"`
 	RawResponse interface{}       `json:"raw_response"`
+	// RawRequest is the body of the request sent to the provider, with base64 payloads redacted.
+	// It is only set if the provider's SendBackRawRequest is enabled.
+	RawRequest json.RawMessage `json:"raw_request,omitempty"`
+
+	// StatusCode and ResponseHeaders describe the provider's HTTP response. Only headers listed in the
+	// provider's NetworkConfig.CaptureResponseHeaders are included (e.g. x-ratelimit-remaining-*).
+	StatusCode      int               `json:"status_code,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+
+	// ProviderRequestID is the provider's ID of the request (see ProviderRequestIDHeaders), to quote
+	// in support tickets about the response.
+	ProviderRequestID string `json:"provider_request_id,omitempty"`
+
+	// Continuations is the number of follow-up requests made by AutoContinue to complete the response.
+	Continuations int `json:"continuations,omitempty"`
+
+	// TruncationRetries is the number of times the request was retried by TruncatedToolCallRetry.
+	// Usage then includes the tokens of the truncated responses.
+	TruncationRetries int `json:"truncation_retries,omitempty"`
+
+	// Retries is the number of times the provider call that produced the response was retried
+	// after a connection error or a retryable status (see NetworkConfig.MaxRetries), and
+	// RetryStatusCodes is the status code of each retried attempt, in order, 0 for errors without
+	// one such as connection errors and timeouts. A response that needed retries can signal a
+	// degrading provider even though it succeeded. For streams, they are set on the first chunk.
+	Retries          int   `json:"retries,omitempty"`
+	RetryStatusCodes []int `json:"retry_status_codes,omitempty"`
+
+	// Warnings reports problems Bifrost detected in the response that did not fail the request,
+	// such as tool call arguments that are not valid JSON.
+	Warnings []ResponseWarning `json:"warnings,omitempty"`
+
+	// Reproducibility is set when the request had a seed, and reports whether it was applied.
+	Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+
+	// SelectedPrimary is the primary picked among the request's PrimaryCandidates. It is also set
+	// when a fallback served the response, in which case Provider differs from it.
+	SelectedPrimary *SelectedPrimary `json:"selected_primary,omitempty"`
+
+	// SkippedProviders lists the primary and fallbacks that were not tried because their circuit
+	// was open (see BifrostConfig.ProviderHealth), in order.
+	SkippedProviders []Fallback `json:"skipped_providers,omitempty"`
+
+	// EmbeddingBatches lists, in the order of the texts, the provider that embedded each batch of
+	// a request with EmbeddingRouting.
+	EmbeddingBatches []EmbeddingBatch `json:"embedding_batches,omitempty"`
+
+	// RequestedModel is the model the caller asked for, while Model holds the model the provider
+	// reported, which may be a dated snapshot or a routing alias of it. It is kept when the
+	// response was served by a fallback, a selected primary candidate or a decommission
+	// replacement, so that analytics can attribute the response to the original request.
+	RequestedModel string `json:"requested_model,omitempty"`
+
+	// TimeToFirstToken is the time in seconds from the start of the provider call to the first
+	// chunk with content of a stream. It is set on that chunk and every later chunk of the stream.
+	TimeToFirstToken *float64 `json:"time_to_first_token,omitempty"`
+
+	// StreamTiming summarizes the timing of the content chunks of a stream received so far, when
+	// BifrostRequest.CaptureStreamTiming is set. It is set on every chunk from the first chunk
+	// with content, so the last chunk of the stream carries the summary of the whole stream.
+	StreamTiming *StreamTiming `json:"stream_timing,omitempty"`
+}
+
+// StreamTiming summarizes the arrival of the content chunks of a stream. Durations are in seconds,
+// and gaps are the times between consecutive content chunks.
+type StreamTiming struct {
+	Chunks          int     `json:"chunks"`                      // Content chunks received
+	Duration        float64 `json:"duration"`                    // Time from the first to the latest content chunk
+	ChunksPerSecond float64 `json:"chunks_per_second,omitempty"` // Content chunks after the first per second of Duration
+	// TokensPerSecond is the provider's count of completion tokens per second of Duration, on
+	// chunks that report usage (usually the last one).
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+	MeanGap         float64 `json:"mean_gap"`
+	P50Gap          float64 `json:"p50_gap"`
+	P95Gap          float64 `json:"p95_gap"`
+	MaxGap          float64 `json:"max_gap"`
+	// MaxGapAt is the time from the first content chunk to the end of the longest gap, to locate
+	// mid-stream stalls.
+	MaxGapAt float64 `json:"max_gap_at"`
+}
+
+// ResponseWarningInvalidToolArguments is the code of the warning reported for tool calls whose
+// arguments are not valid JSON, e.g. because the response was cut off by the token limit.
+const ResponseWarningInvalidToolArguments = "invalid_tool_arguments"
+
+// ResponseWarning describes a problem in a response that did not fail the request.
+type ResponseWarning struct {
+	Code        string  `json:"code"`                   // Kind of problem, e.g. ResponseWarningInvalidToolArguments
+	Message     string  `json:"message"`                // Human-readable description
+	ChoiceIndex int     `json:"choice_index"`           // Index of the affected choice
+	ToolCallID  *string `json:"tool_call_id,omitempty"` // ID of the affected tool call, if any
+}
+
+// Reproducibility reports what happened to the seed of a request. A seed only makes sampling
+// deterministic on a given backend configuration: two runs are expected to match only when the
+// seed was applied and the SystemFingerprint (where the provider reports one) is the same.
+type Reproducibility struct {
+	Seed              int     `json:"seed"`                         // Seed requested by the caller
+	SeedApplied       bool    `json:"seed_applied"`                 // False if the provider has no seed parameter and the seed was dropped
+	SystemFingerprint *string `json:"system_fingerprint,omitempty"` // Backend configuration reported by the provider (e.g. OpenAI, Azure, Groq)
 }
 
 const (
-	RequestCancelled = "request_cancelled"
+	RequestCancelled     = "request_cancelled"
+	UnsupportedOperation = "unsupported_operation"
+	ResponseRejected     = "response_rejected"
+	ProviderUnhealthy    = "provider_unhealthy"    // The provider was skipped because its ProviderHealth circuit is open
+	InvalidRequest       = "invalid_request_error" // Malformed request, the path of the offending field is in ErrorField.Param
 )
 
+// ErrorCodeModelNotFound is the ErrorField.Code of provider errors saying that the requested model
+// does not exist. It is OpenAI's code, which the other providers map their equivalent errors to.
+const ErrorCodeModelNotFound = "model_not_found"
+
 type BifrostStream struct {
 	*BifrostResponse
 	*BifrostError
+
+	// Sequence numbers the chunks of the stream from 1 when BifrostConfig.StreamSequencing is set.
+	// A gap or a lower number than the previous chunk's means chunks were lost or reordered.
+	Sequence uint64 `json:"sequence,omitempty"`
+	// Checksum is the CRC-32 (IEEE) of the content streamed up to and including the chunk, when
+	// StreamSequencing.Checksums is set: the delta content of chat chunks in choice order, the audio
+	// of speech chunks and the delta text of transcription chunks. Error chunks add nothing.
+	Checksum *uint32 `json:"checksum,omitempty"`
+}
+
+// StreamSequencingConfig configures the numbering of stream chunks.
+type StreamSequencingConfig struct {
+	// Checksums also sets BifrostStream.Checksum on every chunk, so consumers can verify the
+	// content they reassembled.
+	Checksums bool
 }
 
 // BifrostError represents an error from the Bifrost system.
@@ -631,6 +1404,55 @@ type BifrostError struct {
 	StatusCode     *int          `json:"status_code,omitempty"`
 	Error          ErrorField    `json:"error"`
 	AllowFallbacks *bool         `json:"-"` // Optional: Controls fallback behavior (nil = true by default)
+
+	// Kind tells how an error without a status code came about, for the errors that Bifrost
+	// retries. Providers set it, it is empty for other errors.
+	Kind ErrorKind `json:"kind,omitempty"`
+
+	// AttemptHistory lists the error of every provider tried, in order (primary first), when the
+	// request failed after trying fallbacks. It is empty if no fallback was tried.
+	AttemptHistory []AttemptError `json:"attempt_history,omitempty"`
+
+	// SkippedFallbacks lists the fallbacks that were not tried because they are unusable for the
+	// request, e.g. their provider is not configured. When all fallbacks were skipped, the error
+	// message also says so, to tell it apart from a request without fallbacks.
+	SkippedFallbacks []SkippedFallback `json:"skipped_fallbacks,omitempty"`
+
+	// ProviderRequestID is the provider's ID of the failed request (see ProviderRequestIDHeaders),
+	// to quote in support tickets. It is empty if the provider did not respond.
+	ProviderRequestID string `json:"provider_request_id,omitempty"`
+}
+
+// AttemptError describes why one provider attempt of a request failed.
+type AttemptError struct {
+	Provider   ModelProvider `json:"provider"`
+	Model      string        `json:"model"`
+	StatusCode *int          `json:"status_code,omitempty"`
+	Type       *string       `json:"type,omitempty"`
+	Message    string        `json:"message"`
+	Kind       ErrorKind     `json:"kind,omitempty"`
+
+	ProviderRequestID string `json:"provider_request_id,omitempty"` // Provider's ID of the failed request
+}
+
+// ErrorKind classifies the errors that have no status code, as the retry policy, the health of
+// providers and metrics treat them differently.
+type ErrorKind string
+
+const (
+	// ErrorKindConnection marks requests that could not be sent, or that got no response, e.g.
+	// connection failures and timeouts. Their message is ErrProviderRequest.
+	ErrorKindConnection ErrorKind = "connection"
+	// ErrorKindEmptyStream marks streams that ended without content under EmptyStreamBehaviorError.
+	// Their message is ErrProviderEmptyStream.
+	ErrorKindEmptyStream ErrorKind = "empty_stream"
+)
+
+// SkippedFallback describes a fallback of a request that was not tried, and why.
+type SkippedFallback struct {
+	Provider ModelProvider `json:"provider"`
+	Model    string        `json:"model"`
+	Reason   string        `json:"reason"`
 }
 
 // ErrorField represents detailed error information.
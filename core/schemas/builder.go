@@ -0,0 +1,211 @@
+// Package schemas defines the core schemas and types used by the Bifrost system.
+package schemas
+
+import (
+	"errors"
+	"fmt"
+)
+
+// requestKind identifies which RequestInput field a RequestBuilder populates.
+type requestKind string
+
+const (
+	requestKindTextCompletion requestKind = "text completion"
+	requestKindChat           requestKind = "chat"
+	requestKindEmbedding      requestKind = "embedding"
+	requestKindSpeech         requestKind = "speech"
+	requestKindTranscription  requestKind = "transcription"
+)
+
+// RequestBuilder builds a BifrostRequest for one request type, setting the matching Input
+// field so callers cannot mix them up. Mistakes are collected and returned by Build.
+// Struct literals remain a valid way to construct requests.
+//
+// Example:
+//
+//	req, err := schemas.NewChatRequest(schemas.OpenAI, "gpt-4o-mini").
+//		WithMessages(schemas.SystemMessage("Be brief."), schemas.UserMessage("Hello!")).
+//		WithFallback(schemas.Anthropic, "claude-3-5-haiku-20241022").
+//		Build()
+type RequestBuilder struct {
+	req  BifrostRequest
+	kind requestKind
+	errs []error
+}
+
+// NewTextCompletionRequest starts a text completion request; set the prompt with WithText.
+func NewTextCompletionRequest(provider ModelProvider, model string) *RequestBuilder {
+	return newRequestBuilder(provider, model, requestKindTextCompletion)
+}
+
+// NewChatRequest starts a chat completion request (streaming or not); set the messages with WithMessages.
+func NewChatRequest(provider ModelProvider, model string) *RequestBuilder {
+	return newRequestBuilder(provider, model, requestKindChat)
+}
+
+// NewEmbeddingRequest starts an embedding request; set the texts with WithTexts.
+func NewEmbeddingRequest(provider ModelProvider, model string) *RequestBuilder {
+	return newRequestBuilder(provider, model, requestKindEmbedding)
+}
+
+// NewSpeechRequest starts a speech request (streaming or not); set the input with WithSpeechInput.
+func NewSpeechRequest(provider ModelProvider, model string) *RequestBuilder {
+	return newRequestBuilder(provider, model, requestKindSpeech)
+}
+
+// NewTranscriptionRequest starts a transcription request (streaming or not); set the input with WithTranscriptionInput.
+func NewTranscriptionRequest(provider ModelProvider, model string) *RequestBuilder {
+	return newRequestBuilder(provider, model, requestKindTranscription)
+}
+
+func newRequestBuilder(provider ModelProvider, model string, kind requestKind) *RequestBuilder {
+	return &RequestBuilder{
+		req: BifrostRequest{
+			Provider: provider,
+			Model:    model,
+		},
+		kind: kind,
+	}
+}
+
+// WithText sets the prompt of a text completion request.
+func (b *RequestBuilder) WithText(text string) *RequestBuilder {
+	if b.expectKind(requestKindTextCompletion, "WithText") {
+		b.req.Input.TextCompletionInput = &text
+	}
+	return b
+}
+
+// WithMessages appends messages to a chat request.
+func (b *RequestBuilder) WithMessages(messages ...BifrostMessage) *RequestBuilder {
+	if b.expectKind(requestKindChat, "WithMessages") {
+		if b.req.Input.ChatCompletionInput == nil {
+			b.req.Input.ChatCompletionInput = &[]BifrostMessage{}
+		}
+		*b.req.Input.ChatCompletionInput = append(*b.req.Input.ChatCompletionInput, messages...)
+	}
+	return b
+}
+
+// WithTexts appends input texts to an embedding request.
+func (b *RequestBuilder) WithTexts(texts ...string) *RequestBuilder {
+	if b.expectKind(requestKindEmbedding, "WithTexts") {
+		if b.req.Input.EmbeddingInput == nil {
+			b.req.Input.EmbeddingInput = &EmbeddingInput{}
+		}
+		b.req.Input.EmbeddingInput.Texts = append(b.req.Input.EmbeddingInput.Texts, texts...)
+	}
+	return b
+}
+
+// WithSpeechInput sets the input of a speech request.
+func (b *RequestBuilder) WithSpeechInput(input SpeechInput) *RequestBuilder {
+	if b.expectKind(requestKindSpeech, "WithSpeechInput") {
+		b.req.Input.SpeechInput = &input
+	}
+	return b
+}
+
+// WithTranscriptionInput sets the input of a transcription request.
+func (b *RequestBuilder) WithTranscriptionInput(input TranscriptionInput) *RequestBuilder {
+	if b.expectKind(requestKindTranscription, "WithTranscriptionInput") {
+		b.req.Input.TranscriptionInput = &input
+	}
+	return b
+}
+
+// WithParams sets the model parameters of the request.
+func (b *RequestBuilder) WithParams(params *ModelParameters) *RequestBuilder {
+	b.req.Params = params
+	return b
+}
+
+// WithFallback appends a fallback provider and model, tried in the order they are added.
+func (b *RequestBuilder) WithFallback(provider ModelProvider, model string) *RequestBuilder {
+	if provider == "" || model == "" {
+		b.errs = append(b.errs, fmt.Errorf("fallback %d: provider and model are required", len(b.req.Fallbacks)+1))
+		return b
+	}
+	b.req.Fallbacks = append(b.req.Fallbacks, Fallback{Provider: provider, Model: model})
+	return b
+}
+
+// WithBaseURL overrides the primary provider's base URL for this request.
+func (b *RequestBuilder) WithBaseURL(baseURL string) *RequestBuilder {
+	b.req.BaseURL = &baseURL
+	return b
+}
+
+// Build validates the request and returns it, or all the mistakes found while building it.
+func (b *RequestBuilder) Build() (*BifrostRequest, error) {
+	errs := b.errs
+
+	if b.req.Provider == "" {
+		errs = append(errs, errors.New("provider is required"))
+	}
+	if b.req.Model == "" {
+		errs = append(errs, errors.New("model is required"))
+	}
+
+	input := b.req.Input
+	switch b.kind {
+	case requestKindTextCompletion:
+		if input.TextCompletionInput == nil || *input.TextCompletionInput == "" {
+			errs = append(errs, errors.New("text completion request requires a prompt, set it with WithText"))
+		}
+	case requestKindChat:
+		if input.ChatCompletionInput == nil || len(*input.ChatCompletionInput) == 0 {
+			errs = append(errs, errors.New("chat request requires at least one message, add them with WithMessages"))
+		}
+	case requestKindEmbedding:
+		if input.EmbeddingInput == nil || len(input.EmbeddingInput.Texts) == 0 {
+			errs = append(errs, errors.New("embedding request requires at least one text, add them with WithTexts"))
+		}
+	case requestKindSpeech:
+		if input.SpeechInput == nil || input.SpeechInput.Input == "" {
+			errs = append(errs, errors.New("speech request requires input text, set it with WithSpeechInput"))
+		}
+	case requestKindTranscription:
+		if input.TranscriptionInput == nil || len(input.TranscriptionInput.File) == 0 {
+			errs = append(errs, errors.New("transcription request requires an audio file, set it with WithTranscriptionInput"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	req := b.req
+	return &req, nil
+}
+
+// expectKind records an error if a With method does not match the builder's request type.
+func (b *RequestBuilder) expectKind(kind requestKind, method string) bool {
+	if b.kind != kind {
+		b.errs = append(b.errs, fmt.Errorf("%s can only be used with %s requests, not %s requests", method, kind, b.kind))
+		return false
+	}
+	return true
+}
+
+// UserMessage returns a user chat message with text content.
+func UserMessage(text string) BifrostMessage {
+	return textMessage(ModelChatMessageRoleUser, text)
+}
+
+// SystemMessage returns a system chat message with text content.
+func SystemMessage(text string) BifrostMessage {
+	return textMessage(ModelChatMessageRoleSystem, text)
+}
+
+// AssistantTextMessage returns an assistant chat message with text content.
+func AssistantTextMessage(text string) BifrostMessage {
+	return textMessage(ModelChatMessageRoleAssistant, text)
+}
+
+func textMessage(role ModelChatMessageRole, text string) BifrostMessage {
+	return BifrostMessage{
+		Role:    role,
+		Content: MessageContent{ContentStr: &text},
+	}
+}
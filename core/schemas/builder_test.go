@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	req, err := NewChatRequest(OpenAI, "gpt-4o").
+		WithMessages(SystemMessage("Be brief."), UserMessage("Hello")).
+		WithFallback(Anthropic, "claude-3-5-sonnet").
+		WithBaseURL("https://canary.internal").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if req.Provider != OpenAI || req.Model != "gpt-4o" || req.Input.ChatCompletionInput == nil || len(*req.Input.ChatCompletionInput) != 2 ||
+		len(req.Fallbacks) != 1 || req.Fallbacks[0].Model != "claude-3-5-sonnet" || req.BaseURL == nil || *req.BaseURL != "https://canary.internal" {
+		t.Errorf("request = %+v, want the chat request that was built", req)
+	}
+	if req.Input.TextCompletionInput != nil || req.Input.EmbeddingInput != nil {
+		t.Errorf("input = %+v, want only the chat messages set", req.Input)
+	}
+}
+
+func TestRequestBuilderCollectsMistakes(t *testing.T) {
+	_, err := NewEmbeddingRequest(OpenAI, "").
+		WithMessages(UserMessage("Hello")).
+		WithFallback(Cohere, "").
+		Build()
+	if err == nil {
+		t.Fatal("Build() succeeded, want the mistakes")
+	}
+
+	// Every mistake is reported, not just the first one
+	for _, want := range []string{
+		"WithMessages can only be used with chat requests, not embedding requests",
+		"fallback 1: provider and model are required",
+		"model is required",
+		"embedding request requires at least one text",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Build() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
@@ -1,12 +1,31 @@
 // Package schemas defines the core schemas and types used by the Bifrost system.
 package schemas
 
+import "time"
+
 // MCPConfig represents the configuration for MCP integration in Bifrost.
 // It enables tool auto-discovery and execution from local and external MCP servers.
 type MCPConfig struct {
 	ClientConfigs []MCPClientConfig `json:"client_configs,omitempty"` // Per-client execution configurations
+
+	// MaxToolCallIterations caps how many model turns with MCP tool calls a single logical request
+	// may go through. Chat completion requests whose conversation holds more fail, and so do tool
+	// calls of further iterations when tool call tracking is enabled on the context.
+	// Defaults to DefaultMaxToolCallIterations.
+	MaxToolCallIterations int `json:"max_tool_call_iterations,omitempty"`
+
+	// DrainTimeoutSeconds bounds how long Cleanup waits for in-flight tool calls to finish before
+	// closing the client connections. Calls still running after it are cancelled. Defaults to
+	// DefaultMCPDrainTimeoutSeconds.
+	DrainTimeoutSeconds int `json:"drain_timeout_seconds,omitempty"`
 }
 
+// DefaultMaxToolCallIterations is the default limit on tool call iterations per logical request.
+const DefaultMaxToolCallIterations = 10
+
+// DefaultMCPDrainTimeoutSeconds is the default time Cleanup waits for in-flight tool calls.
+const DefaultMCPDrainTimeoutSeconds = 10
+
 // MCPClientConfig defines tool filtering for an MCP client.
 type MCPClientConfig struct {
 	Name             string            `json:"name"`                        // Client name
@@ -15,8 +34,54 @@ type MCPClientConfig struct {
 	StdioConfig      *MCPStdioConfig   `json:"stdio_config,omitempty"`      // STDIO configuration (required for STDIO connections)
 	ToolsToSkip      []string          `json:"tools_to_skip,omitempty"`     // Tools to exclude from this client
 	ToolsToExecute   []string          `json:"tools_to_execute,omitempty"`  // Tools to include from this client (if specified, only these are used)
+
+	// MaxConcurrentConnections caps the tool calls executed in parallel through this client, and the
+	// connections its HTTP or SSE transport keeps open to the server. Calls beyond the limit wait for
+	// a free slot. Defaults to DefaultMCPMaxConcurrentConnections. Ignored for STDIO clients.
+	MaxConcurrentConnections int `json:"max_concurrent_connections,omitempty"`
+
+	// HealthCheck enables a background loop that pings the server, so that a dead connection is
+	// detected before a tool call fails. Health checks are disabled if nil.
+	HealthCheck *MCPHealthCheckConfig `json:"health_check,omitempty"`
+
+	// Reconnect enables reconnecting the client in the background once it is disconnected: after a
+	// failed ReconnectMCPClient call, or after its health check disconnected it. Disabled if nil.
+	Reconnect *MCPReconnectConfig `json:"reconnect,omitempty"`
+}
+
+// DefaultMCPMaxConcurrentConnections is the default limit on parallel tool calls and pooled
+// connections per HTTP or SSE MCP client.
+const DefaultMCPMaxConcurrentConnections = 10
+
+// MCPHealthCheckConfig configures the background health check of an MCP client.
+// After FailureThreshold consecutive failed pings the client is disconnected, and reconnected
+// in the background if its Reconnect config is set.
+type MCPHealthCheckConfig struct {
+	IntervalSeconds  int `json:"interval_seconds,omitempty"`  // Time between pings (defaults to DefaultMCPHealthCheckIntervalSeconds)
+	FailureThreshold int `json:"failure_threshold,omitempty"` // Consecutive failed pings before disconnecting (defaults to DefaultMCPHealthCheckFailureThreshold)
+}
+
+const (
+	DefaultMCPHealthCheckIntervalSeconds  = 30 // Default time between health check pings
+	DefaultMCPHealthCheckFailureThreshold = 3  // Default number of consecutive failed pings before disconnecting
+)
+
+// MCPReconnectConfig configures how a disconnected MCP client is reconnected in the background.
+// Attempts are spaced by an exponential backoff with jitter, from InitialBackoffSeconds up to
+// MaxBackoffSeconds. After MaxAttempts failed attempts, Bifrost gives up and leaves the client
+// disconnected until the next ReconnectMCPClient call.
+type MCPReconnectConfig struct {
+	MaxAttempts           int `json:"max_attempts,omitempty"`            // Background attempts before giving up (defaults to DefaultMCPReconnectMaxAttempts)
+	InitialBackoffSeconds int `json:"initial_backoff_seconds,omitempty"` // Delay before the first attempt (defaults to DefaultMCPReconnectInitialBackoffSeconds)
+	MaxBackoffSeconds     int `json:"max_backoff_seconds,omitempty"`     // Maximum delay between attempts (defaults to DefaultMCPReconnectMaxBackoffSeconds)
 }
 
+const (
+	DefaultMCPReconnectMaxAttempts           = 10 // Default number of background reconnect attempts
+	DefaultMCPReconnectInitialBackoffSeconds = 1  // Default delay before the first background reconnect
+	DefaultMCPReconnectMaxBackoffSeconds     = 60 // Default maximum delay between background reconnects
+)
+
 // MCPConnectionType defines the communication protocol for MCP connections
 type MCPConnectionType string
 
@@ -49,4 +114,7 @@ type MCPClient struct {
 	Config MCPClientConfig    `json:"config"` // Tool filtering settings
 	Tools  []string           `json:"tools"`  // Available tools mapped by name
 	State  MCPConnectionState `json:"state"`  // Connection state
+
+	// LastHealthCheck is the time of the last health check ping, nil if health checks are disabled
+	LastHealthCheck *time.Time `json:"last_health_check,omitempty"`
 }
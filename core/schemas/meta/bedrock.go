@@ -11,6 +11,9 @@ type BedrockMetaConfig struct {
 	SessionToken      *string           `json:"session_token,omitempty"`      // AWS session token for temporary credentials
 	ARN               *string           `json:"arn,omitempty"`                // Amazon Resource Name for resource identification
 	InferenceProfiles map[string]string `json:"inference_profiles,omitempty"` // Mapping of model identifiers to inference profiles
+	// CrossRegionSourceRegions maps a cross-region inference profile prefix (e.g. "eu" in "eu.anthropic.claude-3-haiku-20240307-v1:0")
+	// to the region the profile is invoked from. Profiles without an entry are invoked from Region.
+	CrossRegionSourceRegions map[string]string `json:"cross_region_source_regions,omitempty"`
 }
 
 // GetSecretAccessKey returns the AWS secret access key.
@@ -42,3 +45,9 @@ func (c *BedrockMetaConfig) GetARN() *string {
 func (c *BedrockMetaConfig) GetInferenceProfiles() map[string]string {
 	return c.InferenceProfiles
 }
+
+// GetCrossRegionSourceRegions returns the source region for each cross-region inference profile prefix.
+// This routes cross-region profile requests to a region within the profile's geography.
+func (c *BedrockMetaConfig) GetCrossRegionSourceRegions() map[string]string {
+	return c.CrossRegionSourceRegions
+}
@@ -0,0 +1,162 @@
+// Package schemas defines the core schemas and types used by the Bifrost system.
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bytedance/sonic"
+)
+
+// SchemaVersion is the version of the serialized form of BifrostRequest and BifrostResponse.
+// It is incremented on incompatible changes to them (e.g. a renamed or restructured field),
+// together with the registration of the migrations from the previous version.
+const SchemaVersion = 1
+
+// PayloadKind identifies the type persisted in a PersistedPayload.
+type PayloadKind string
+
+const (
+	PayloadKindRequest  PayloadKind = "request"
+	PayloadKindResponse PayloadKind = "response"
+)
+
+// PersistedPayload is the envelope of a BifrostRequest or BifrostResponse persisted outside of the
+// process, e.g. by a cache, a request recorder or a batch job. It records the schema version the
+// payload was written with, so that payloads written by an older version can be migrated on read.
+type PersistedPayload struct {
+	SchemaVersion int             `json:"schema_version"`
+	Kind          PayloadKind     `json:"kind"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// SchemaMigration upgrades the JSON object of a persisted payload, in place, from the schema
+// version it was registered for to the next one.
+type SchemaMigration func(payload map[string]interface{}) error
+
+var (
+	schemaMigrationsMu sync.RWMutex
+	schemaMigrations   = map[PayloadKind]map[int]SchemaMigration{}
+)
+
+// RegisterSchemaMigration registers the migration of payloads of the given kind from schema version
+// fromVersion to fromVersion+1, replacing any earlier registration for the same kind and version.
+// Payloads are migrated one version at a time, so upgrading from version 1 to 3 runs the migrations
+// registered for versions 1 and 2.
+func RegisterSchemaMigration(kind PayloadKind, fromVersion int, migration SchemaMigration) {
+	schemaMigrationsMu.Lock()
+	defer schemaMigrationsMu.Unlock()
+
+	if schemaMigrations[kind] == nil {
+		schemaMigrations[kind] = map[int]SchemaMigration{}
+	}
+	schemaMigrations[kind][fromVersion] = migration
+}
+
+// MarshalPersistedRequest serializes a request with the current schema version, to be read back
+// with UnmarshalPersistedRequest.
+func MarshalPersistedRequest(req *BifrostRequest) ([]byte, error) {
+	return marshalPersisted(PayloadKindRequest, req)
+}
+
+// UnmarshalPersistedRequest reads a request written by MarshalPersistedRequest, migrating it to
+// the current schema version if it was written with an older one.
+func UnmarshalPersistedRequest(data []byte) (*BifrostRequest, error) {
+	var req BifrostRequest
+	if err := unmarshalPersisted(data, PayloadKindRequest, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// MarshalPersistedResponse serializes a response with the current schema version, to be read
+// back with UnmarshalPersistedResponse.
+func MarshalPersistedResponse(resp *BifrostResponse) ([]byte, error) {
+	return marshalPersisted(PayloadKindResponse, resp)
+}
+
+// UnmarshalPersistedResponse reads a response written by MarshalPersistedResponse, migrating it
+// to the current schema version if it was written with an older one.
+func UnmarshalPersistedResponse(data []byte) (*BifrostResponse, error) {
+	var resp BifrostResponse
+	if err := unmarshalPersisted(data, PayloadKindResponse, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// marshalPersisted wraps the JSON of v in a PersistedPayload with the current schema version.
+func marshalPersisted(kind PayloadKind, v interface{}) ([]byte, error) {
+	data, err := sonic.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return sonic.Marshal(PersistedPayload{SchemaVersion: SchemaVersion, Kind: kind, Data: data})
+}
+
+// unmarshalPersisted reads a PersistedPayload of the given kind into v, after running the
+// migrations from its schema version to the current one. Payloads without an envelope, e.g. the
+// plain JSON of a request written before versioning, are read as version 1.
+func unmarshalPersisted(data []byte, kind PayloadKind, v interface{}) error {
+	// Envelopes are told apart by their schema_version and kind: a plain payload may have a
+	// data field of its own (e.g. the embeddings of a response)
+	var fields struct {
+		SchemaVersion *int            `json:"schema_version"`
+		Kind          *PayloadKind    `json:"kind"`
+		Data          json.RawMessage `json:"data"`
+	}
+	if err := sonic.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to read persisted %s: %w", kind, err)
+	}
+	envelope := PersistedPayload{SchemaVersion: 1, Kind: kind, Data: data}
+	if fields.SchemaVersion != nil && fields.Kind != nil {
+		envelope = PersistedPayload{SchemaVersion: *fields.SchemaVersion, Kind: *fields.Kind, Data: fields.Data}
+	}
+
+	if envelope.Kind != kind {
+		return fmt.Errorf("persisted payload is a %s, not a %s", envelope.Kind, kind)
+	}
+	if envelope.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("persisted %s has schema version %d, newer than the supported version %d", kind, envelope.SchemaVersion, SchemaVersion)
+	}
+
+	payload := []byte(envelope.Data)
+	if envelope.SchemaVersion < SchemaVersion {
+		migrated, err := migratePersisted(kind, envelope.SchemaVersion, SchemaVersion, payload)
+		if err != nil {
+			return err
+		}
+		payload = migrated
+	}
+
+	if err := sonic.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("failed to read persisted %s: %w", kind, err)
+	}
+	return nil
+}
+
+// migratePersisted runs the migrations of a payload of the given kind from version to target.
+func migratePersisted(kind PayloadKind, version int, target int, data []byte) ([]byte, error) {
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep large integers (e.g. seeds) exact
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to read persisted %s: %w", kind, err)
+	}
+
+	schemaMigrationsMu.RLock()
+	defer schemaMigrationsMu.RUnlock()
+
+	for ; version < target; version++ {
+		migration := schemaMigrations[kind][version]
+		if migration == nil {
+			return nil, fmt.Errorf("no migration registered for persisted %s from schema version %d to %d", kind, version, version+1)
+		}
+		if err := migration(payload); err != nil {
+			return nil, fmt.Errorf("failed to migrate persisted %s from schema version %d to %d: %w", kind, version, version+1, err)
+		}
+	}
+	return json.Marshal(payload)
+}
@@ -0,0 +1,102 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPersistedRoundTrip(t *testing.T) {
+	seed := 9007199254740993
+	req := &BifrostRequest{
+		Provider: OpenAI,
+		Model:    "gpt-4o",
+		Input:    RequestInput{ChatCompletionInput: &[]BifrostMessage{UserMessage("Hello")}},
+		Params:   &ModelParameters{Seed: &seed},
+	}
+	data, err := MarshalPersistedRequest(req)
+	if err != nil {
+		t.Fatalf("MarshalPersistedRequest() error = %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"schema_version":1,"kind":"request","data":`) {
+		t.Errorf("persisted request = %s, want an envelope", data)
+	}
+	read, err := UnmarshalPersistedRequest(data)
+	if err != nil {
+		t.Fatalf("UnmarshalPersistedRequest() error = %v", err)
+	}
+	if read.Model != "gpt-4o" || *read.Params.Seed != 9007199254740993 || *(*read.Input.ChatCompletionInput)[0].Content.ContentStr != "Hello" {
+		t.Errorf("request = %+v, want the persisted one", read)
+	}
+
+	// A request is not read as a response
+	if _, err := UnmarshalPersistedResponse(data); err == nil {
+		t.Error("UnmarshalPersistedResponse() of a request succeeded, want an error")
+	}
+	// Nor is a payload written by a newer version
+	if _, err := UnmarshalPersistedRequest([]byte(`{"schema_version":2,"kind":"request","data":{}}`)); err == nil {
+		t.Error("UnmarshalPersistedRequest() of a newer version succeeded, want an error")
+	}
+}
+
+func TestPersistedLegacyPayloads(t *testing.T) {
+	// An embedding response written before versioning has a data field of its own
+	legacy := `{"object":"list","data":[[0.5,0.25]],"model":"text-embedding-3-small"}`
+	resp, err := UnmarshalPersistedResponse([]byte(legacy))
+	if err != nil {
+		t.Fatalf("UnmarshalPersistedResponse() of a legacy response error = %v", err)
+	}
+	if resp.Model != "text-embedding-3-small" || len(resp.Embedding) != 1 {
+		t.Errorf("response = %+v, want the legacy embedding response", resp)
+	}
+
+	// Batch results stored before versioning hold a plain response
+	var result BatchResult
+	if err := json.Unmarshal([]byte(`{"custom_id":"a","response":`+legacy+`}`), &result); err != nil {
+		t.Fatalf("json.Unmarshal() of a legacy batch result error = %v", err)
+	}
+	if result.CustomID != "a" || result.Response == nil || result.Response.Model != "text-embedding-3-small" {
+		t.Errorf("batch result = %+v, want the legacy response", result)
+	}
+
+	// and are written back with a versioned response
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"response":{"schema_version":1,"kind":"response"`) {
+		t.Errorf("batch result = %s, want a versioned response", data)
+	}
+	var read BatchResult
+	if err := json.Unmarshal(data, &read); err != nil || read.Response == nil || len(read.Response.Embedding) != 1 {
+		t.Errorf("batch result = %+v, error = %v, want the stored response", read, err)
+	}
+}
+
+func TestMigratePersisted(t *testing.T) {
+	kind := PayloadKind("migration-test")
+	RegisterSchemaMigration(kind, 1, func(payload map[string]interface{}) error {
+		payload["model"] = payload["model_name"]
+		delete(payload, "model_name")
+		return nil
+	})
+	RegisterSchemaMigration(kind, 2, func(payload map[string]interface{}) error {
+		payload["model"] = fmt.Sprintf("openai/%s", payload["model"])
+		return nil
+	})
+
+	// Migrations run one version at a time, keeping large integers exact
+	migrated, err := migratePersisted(kind, 1, 3, []byte(`{"model_name":"gpt-4o","seed":9007199254740993}`))
+	if err != nil {
+		t.Fatalf("migratePersisted() error = %v", err)
+	}
+	if want := `{"model":"openai/gpt-4o","seed":9007199254740993}`; string(migrated) != want {
+		t.Errorf("migrated payload = %s, want %s", migrated, want)
+	}
+
+	// A missing migration fails instead of misreading the payload
+	if _, err := migratePersisted(kind, 1, 4, []byte(`{"model_name":"gpt-4o"}`)); err == nil || !strings.Contains(err.Error(), "from schema version 3 to 4") {
+		t.Errorf("migratePersisted() without a migration error = %v, want the missing version", err)
+	}
+}
@@ -60,3 +60,16 @@ type Plugin interface {
 	// Returns any error that occurred during cleanup, which will be logged as a warning by the Bifrost instance.
 	Cleanup() error
 }
+
+// ConditionalPlugin is an optional interface for plugins that only apply to some requests.
+// ShouldRun is consulted before the plugin's PreHook with the request as modified by earlier
+// plugins. When it returns false, both the PreHook and the PostHook of the plugin are skipped
+// for that request, so plugins do not need to check applicability inside their hooks.
+//
+// Example: a vision moderation plugin can return false for requests without image content.
+type ConditionalPlugin interface {
+	Plugin
+
+	// ShouldRun reports whether the plugin's hooks should run for the request.
+	ShouldRun(ctx context.Context, req *BifrostRequest) bool
+}
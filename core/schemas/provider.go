@@ -4,6 +4,8 @@ package schemas
 import (
 	"context"
 	"maps"
+	"net/http"
+	"slices"
 	"time"
 )
 
@@ -15,6 +17,9 @@ const (
 	DefaultBufferSize              = 100
 	DefaultConcurrency             = 10
 	DefaultStreamBufferSize        = 100
+	// DefaultRequestCompressionMinSize is the body size in bytes below which requests are sent
+	// uncompressed when RequestCompression.MinSizeBytes is not set.
+	DefaultRequestCompressionMinSize = 1024
 )
 
 // Pre-defined errors for provider operations
@@ -25,6 +30,10 @@ const (
 	ErrProviderDecodeStructured  = "failed to decode provider's structured response"
 	ErrProviderDecodeRaw         = "failed to decode provider's raw response"
 	ErrProviderDecompress        = "failed to decompress provider's response"
+	ErrProviderRequestTransform  = "failed to transform request body for provider API"
+	ErrProviderResponseTransform = "failed to transform response body from provider API"
+	ErrProviderRequestSigning    = "failed to sign request for provider API"
+	ErrProviderEmptyStream       = "provider stream ended without content"
 )
 
 // NetworkConfig represents the network configuration for provider connections.
@@ -37,6 +46,97 @@ type NetworkConfig struct {
 	MaxRetries                     int               `json:"max_retries"`                        // Maximum number of retries
 	RetryBackoffInitial            time.Duration     `json:"retry_backoff_initial"`              // Initial backoff duration
 	RetryBackoffMax                time.Duration     `json:"retry_backoff_max"`                  // Maximum backoff duration
+	// RequestTimeoutsInSeconds override DefaultRequestTimeoutInSeconds per request type, e.g.
+	// {"chat_completion_stream": 300, "embedding": 10}. For streams, the timeout covers the whole stream.
+	RequestTimeoutsInSeconds map[string]int `json:"request_timeouts_in_seconds,omitempty"`
+	// PathOverrides replace the API paths appended to BaseURL, keyed by the default path, e.g.
+	// {"/v1/chat/completions": "/api/chat/completions"} for servers that expose endpoints under
+	// nonstandard paths. Supported by the providers that have a BaseURL.
+	PathOverrides map[string]string `json:"path_overrides,omitempty"`
+	// ConnectionMaxRetries caps retries of transport errors that occur before a response is
+	// received (e.g. connection refused or reset). When nil, they share MaxRetries with the
+	// other kinds of retries that have no cap of their own.
+	ConnectionMaxRetries *int `json:"connection_max_retries,omitempty"`
+	// RequestMaxRetries caps retries of responses with a retryable HTTP status code (e.g. 429, 503).
+	// When nil, they share MaxRetries with the other kinds of retries that have no cap of their own.
+	RequestMaxRetries *int `json:"request_max_retries,omitempty"`
+	// RetryableStatusCodes are retried in addition to the default set (429, 500, 502, 503, 504)
+	RetryableStatusCodes []int `json:"retryable_status_codes,omitempty"` // Additional provider-specific retryable status codes
+	// WarmupOnInit opens a connection to the provider's API when the provider is set up, so the
+	// first request does not pay for TLS and connection setup. Warmup runs in the background and
+	// covers both the regular and the streaming client. Warmup failures are logged, not fatal.
+	WarmupOnInit bool `json:"warmup_on_init,omitempty"`
+	// KeepaliveIntervalInSeconds, if positive, re-opens a connection to the provider's API at this
+	// interval in the background, so that connections do not go cold between bursts of traffic.
+	// Only providers implementing WarmupProvider support it. Disabled by default. It must be shorter
+	// than the 10 second idle timeout of provider connections, which would close them first.
+	KeepaliveIntervalInSeconds int `json:"keepalive_interval_in_seconds,omitempty"`
+	// CaptureResponseHeaders lists response headers copied onto BifrostResponse.ExtraFields.ResponseHeaders
+	// (case-insensitive; a trailing "*" matches a prefix, e.g. "x-ratelimit-remaining-*").
+	CaptureResponseHeaders []string `json:"capture_response_headers,omitempty"`
+	// ContextHeaders copy values of the request's context into outbound headers (e.g. trace or
+	// correlation IDs for an egress proxy). They override ExtraHeaders and are overridden by the
+	// request's own ExtraHeaders.
+	ContextHeaders []ContextHeader `json:"context_headers,omitempty"`
+	// UserAgent is sent as the User-Agent of every request to the provider, so that provider logs
+	// and egress proxies can identify Bifrost traffic. Defaults to DefaultUserAgent (bifrost/<version>).
+	// A User-Agent in ExtraHeaders takes precedence.
+	UserAgent string `json:"user_agent,omitempty"`
+	// ClientID, if set, is sent in the ClientIDHeader header of every request to the provider,
+	// e.g. to tell apart several Bifrost deployments.
+	ClientID string `json:"client_id,omitempty"`
+	// RequestCompression, if set, gzips request bodies (Content-Encoding: gzip), which speeds up
+	// large requests such as multimodal ones over slow links. Only enable it for providers and
+	// gateways that accept compressed bodies. Streaming requests are sent uncompressed.
+	RequestCompression *RequestCompressionConfig `json:"request_compression,omitempty"`
+}
+
+// RequestCompressionConfig configures the compression of request bodies sent to a provider.
+type RequestCompressionConfig struct {
+	// MinSizeBytes is the body size below which requests are sent uncompressed, as compressing
+	// small bodies costs more than it saves. Defaults to DefaultRequestCompressionMinSize.
+	MinSizeBytes int `json:"min_size_bytes,omitempty"`
+}
+
+// RequestTimeout returns the timeout of requests of the given type (e.g. "chat_completion"):
+// its RequestTimeoutsInSeconds override if positive, DefaultRequestTimeoutInSeconds otherwise.
+func (network NetworkConfig) RequestTimeout(requestType string) time.Duration {
+	if seconds := network.RequestTimeoutsInSeconds[requestType]; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(network.DefaultRequestTimeoutInSeconds) * time.Second
+}
+
+// RequestPath returns the path of the given default API path (e.g. "/v1/chat/completions"):
+// its PathOverrides entry if set, the default path otherwise.
+func (network NetworkConfig) RequestPath(defaultPath string) string {
+	if path := network.PathOverrides[defaultPath]; path != "" {
+		return path
+	}
+	return defaultPath
+}
+
+// MaxRequestTimeout returns the longest timeout of any request type. Providers configure their
+// HTTP clients with it, and shorter per request type timeouts are enforced per request.
+func (network NetworkConfig) MaxRequestTimeout() time.Duration {
+	timeout := time.Duration(network.DefaultRequestTimeoutInSeconds) * time.Second
+	for _, seconds := range network.RequestTimeoutsInSeconds {
+		timeout = max(timeout, time.Duration(seconds)*time.Second)
+	}
+	return timeout
+}
+
+// ClientIDHeader is the header NetworkConfig.ClientID is sent in.
+const ClientIDHeader = "X-Bifrost-Client-Id"
+
+// ContextHeader maps a context key to the header its value is sent in. String and fmt.Stringer
+// values are supported; other values are ignored. The Authorization header cannot be set.
+type ContextHeader struct {
+	ContextKey BifrostContextKey `json:"context_key"` // Key the value is read from, e.g. BifrostContextKeyRequestID
+	Header     string            `json:"header"`      // Header the value is sent in, e.g. "X-Request-ID"
+	// Generate sends a random UUID when the context has no value for ContextKey. The ID is
+	// generated once per request and sent with all of its retries and fallbacks.
+	Generate bool `json:"generate,omitempty"`
 }
 
 // DefaultNetworkConfig is the default network configuration for provider connections.
@@ -60,12 +160,30 @@ type MetaConfig interface {
 	GetARN() *string
 	// GetInferenceProfiles returns the inference profiles
 	GetInferenceProfiles() map[string]string
+	// GetCrossRegionSourceRegions returns the source region for each cross-region inference profile prefix
+	GetCrossRegionSourceRegions() map[string]string
 }
 
 // ConcurrencyAndBufferSize represents configuration for concurrent operations and buffer sizes.
 type ConcurrencyAndBufferSize struct {
 	Concurrency int `json:"concurrency"` // Number of concurrent operations. Also used as the initial pool size for the provider reponses.
 	BufferSize  int `json:"buffer_size"` // Size of the buffer
+
+	// Adaptive, if set, lets Bifrost tune the number of concurrent provider calls between
+	// MinConcurrency and Concurrency based on observed throttling, see AdaptiveConcurrencyConfig.
+	Adaptive *AdaptiveConcurrencyConfig `json:"adaptive,omitempty"`
+}
+
+// AdaptiveConcurrencyConfig configures the adaptive concurrency controller of a provider, which
+// follows additive-increase/multiplicative-decrease: the limit on concurrent provider calls starts
+// at Concurrency, is multiplied by DecreaseFactor when the provider throttles a request (HTTP 429)
+// or answers slower than LatencyThresholdMs, and grows back by one call per window of successful
+// calls. Open streams count toward the limit until they are closed, while their latency is the
+// one of the stream setup. Its current limit is reported in Bifrost.GetQueueStats.
+type AdaptiveConcurrencyConfig struct {
+	MinConcurrency     int     `json:"min_concurrency,omitempty"`      // Lowest limit (default: 1)
+	DecreaseFactor     float64 `json:"decrease_factor,omitempty"`      // Factor applied to the limit on throttling, between 0 and 1 (default: 0.5)
+	LatencyThresholdMs int     `json:"latency_threshold_ms,omitempty"` // Provider calls slower than this also decrease the limit (0 to only react to throttling)
 }
 
 // DefaultConcurrencyAndBufferSize is the default concurrency and buffer size for provider operations.
@@ -96,6 +214,17 @@ type ProxyConfig struct {
 	Password string    `json:"password"` // Password for proxy authentication
 }
 
+// TLSConfig holds the TLS settings of the connections to a provider, e.g. for self-hosted
+// servers whose certificates are signed by an internal CA. The CAs are trusted in addition to
+// the system's root CAs.
+type TLSConfig struct {
+	CACertPath string `json:"ca_cert_path,omitempty"` // Path of a PEM bundle of root CAs to trust
+	CACertPEM  string `json:"ca_cert_pem,omitempty"`  // PEM bundle of root CAs to trust
+	// InsecureSkipVerify disables the verification of the provider's certificate. It is meant for
+	// development only, and a warning is logged when a provider is set up with it.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
 // ProviderConfig represents the complete configuration for a provider.
 // An array of ProviderConfig needs to provided in GetConfigForProvider
 // in your account interface implementation.
@@ -106,7 +235,105 @@ type ProviderConfig struct {
 	// Logger instance, can be provided by the user or bifrost default logger is used if not provided
 	Logger              Logger       `json:"logger"`
 	ProxyConfig         *ProxyConfig `json:"proxy_config,omitempty"` // Proxy configuration
+	TLSConfig           *TLSConfig   `json:"tls_config,omitempty"`   // TLS configuration, e.g. custom root CAs
 	SendBackRawResponse bool         `json:"send_back_raw_response"` // Send raw response back in the bifrost response (default: false)
+	// SendBackRawRequest sends the body of the request that was actually sent to the provider back
+	// in BifrostResponse.ExtraFields.RawRequest, after plugins, MCP tool injection and RequestTransform
+	// ran. It is meant for debugging; base64 payloads are redacted (default: false).
+	SendBackRawRequest bool `json:"send_back_raw_request"`
+	// LogStreamChunks logs each raw chunk read from the provider's streams, followed by the chunk
+	// Bifrost parsed from it, at debug level with base64 payloads redacted. It is meant for
+	// debugging parsing mismatches with nonstandard providers (default: false).
+	LogStreamChunks bool `json:"log_stream_chunks,omitempty"`
+	// RequestTransform rewrites the raw body of every HTTP request sent to the provider, e.g. to add a
+	// field expected by a gateway in front of it. It is a lower-level escape hatch than ExtraParams.
+	RequestTransform BodyTransform `json:"-"`
+	// ResponseTransform rewrites the raw body of non-streaming provider responses before they are parsed.
+	ResponseTransform BodyTransform `json:"-"`
+	// RequestSigner is called just before every HTTP request is sent to the provider, after all
+	// headers are set and RequestTransform ran, to add custom authentication such as an HMAC
+	// signature of the body required by a gateway. Not supported by Bedrock and Vertex, which
+	// sign their requests themselves.
+	RequestSigner RequestSigner `json:"-"`
+	// DecommissionedModels maps retired model IDs to their replacements. When a request fails because
+	// its model was not found, it is retried once with the replacement, leaving time to migrate after
+	// the provider sunsets a model ID.
+	DecommissionedModels map[string]string `json:"decommissioned_models,omitempty"`
+	// ImageLimits, if set, downscales the image inputs of chat completions that exceed the limits
+	// before they are sent to the provider.
+	ImageLimits *ImageLimits `json:"image_limits,omitempty"`
+	// TextCompletionModels lists models of the provider that only support text completion. Chat
+	// completion requests to them are sent as text completions of the messages rendered with
+	// ChatTemplate, and the completion is returned as an assistant message. Streaming is not supported.
+	TextCompletionModels []string `json:"text_completion_models,omitempty"`
+	// ChatTemplate renders the messages of chat completions sent to TextCompletionModels
+	// (default: AnthropicChatTemplate for Anthropic and Bedrock's Anthropic models, and
+	// DefaultChatTemplate for the others). The rendered prompt is sent as is, without the
+	// provider's own framing of text completion prompts.
+	ChatTemplate *ChatTemplate `json:"chat_template,omitempty"`
+}
+
+// ImageLimits bounds the size of image inputs sent to a provider. Larger JPEG, PNG and GIF images,
+// given as data URLs, base64 or HTTP URLs (only if FetchURLs is set), are resized preserving their
+// aspect ratio and sent inline as data URLs. Images within the limits are sent unchanged.
+type ImageLimits struct {
+	MaxDimension int `json:"max_dimension,omitempty"` // Maximum width and height in pixels (0 means no limit)
+	MaxBytes     int `json:"max_bytes,omitempty"`     // Maximum size of the encoded image in bytes (0 means no limit)
+	// FetchURLs lets Bifrost fetch images given as http or https URLs to downscale them. Only
+	// public addresses are fetched. Without it, image URLs are sent to the provider unchanged.
+	FetchURLs bool `json:"fetch_urls,omitempty"`
+}
+
+// ChatTemplate renders chat messages as a text completion prompt. Each message is written as the
+// prefix of its role, its text and MessageSuffix, and the prompt ends with AssistantPrefix to cue
+// the reply. The role prefixes that start a new message are sent as stop sequences, and the reply
+// is cut at the first one in case the provider ignores them.
+type ChatTemplate struct {
+	SystemPrefix    string `json:"system_prefix"`
+	UserPrefix      string `json:"user_prefix"`
+	AssistantPrefix string `json:"assistant_prefix"`
+	ToolPrefix      string `json:"tool_prefix"`
+	MessageSuffix   string `json:"message_suffix"`
+}
+
+// DefaultChatTemplate is the ChatTemplate used when a provider does not configure one.
+var DefaultChatTemplate = ChatTemplate{
+	SystemPrefix:    "System: ",
+	UserPrefix:      "User: ",
+	AssistantPrefix: "Assistant: ",
+	ToolPrefix:      "Tool: ",
+	MessageSuffix:   "\n\n",
+}
+
+// AnthropicChatTemplate renders chat messages in the Human/Assistant framing of Anthropic's text
+// completion models. System messages are written before the first turn, without a prefix.
+var AnthropicChatTemplate = ChatTemplate{
+	UserPrefix:      "\n\nHuman: ",
+	AssistantPrefix: "\n\nAssistant: ",
+	ToolPrefix:      "\n\nHuman: ",
+}
+
+// BodyTransform rewrites a raw HTTP body. Returning an error fails the request.
+type BodyTransform func(body []byte) ([]byte, error)
+
+// SignableRequest is an HTTP request about to be sent to a provider, passed to a RequestSigner.
+// Changes to Header are applied to the request; the other fields are read-only.
+type SignableRequest struct {
+	Method string
+	URL    string // Full URL, including the query string
+	Path   string // URL path, e.g. "/v1/chat/completions"
+	Body   []byte // Final body, nil if the request has none
+	Header http.Header
+}
+
+// RequestSigner sets authentication headers on a request before it is sent. Returning an error
+// fails the request without sending it.
+type RequestSigner func(req *SignableRequest) error
+
+// BodyTransforms carries the body transforms of a provider to its HTTP calls.
+type BodyTransforms struct {
+	Request  BodyTransform
+	Response BodyTransform
 }
 
 func (config *ProviderConfig) CheckAndSetDefaults() {
@@ -134,20 +361,150 @@ func (config *ProviderConfig) CheckAndSetDefaults() {
 		config.NetworkConfig.RetryBackoffMax = DefaultRetryBackoffMax
 	}
 
+	if config.NetworkConfig.UserAgent == "" {
+		config.NetworkConfig.UserAgent = DefaultUserAgent
+	}
+
 	// Create a defensive copy of ExtraHeaders to prevent data races
 	if config.NetworkConfig.ExtraHeaders != nil {
 		headersCopy := make(map[string]string, len(config.NetworkConfig.ExtraHeaders))
 		maps.Copy(headersCopy, config.NetworkConfig.ExtraHeaders)
 		config.NetworkConfig.ExtraHeaders = headersCopy
 	}
+
+	// Create a defensive copy of RetryableStatusCodes for the same reason
+	if config.NetworkConfig.RetryableStatusCodes != nil {
+		config.NetworkConfig.RetryableStatusCodes = slices.Clone(config.NetworkConfig.RetryableStatusCodes)
+	}
+	if config.NetworkConfig.ContextHeaders != nil {
+		config.NetworkConfig.ContextHeaders = slices.Clone(config.NetworkConfig.ContextHeaders)
+	}
+}
+
+// ApplyDefaults fills the network and concurrency settings the config leaves unset (zero) from the
+// given defaults, which are ignored when nil. Extra headers are merged, with the provider's own
+// headers taking precedence, and BaseURL and PathOverrides, which are provider-specific, are never
+// inherited. As MaxRetries 0 means unset, a provider opts out of inherited retries by setting
+// ConnectionMaxRetries and RequestMaxRetries to 0.
+func (config *ProviderConfig) ApplyDefaults(network *NetworkConfig, concurrency *ConcurrencyAndBufferSize) {
+	if concurrency != nil {
+		if config.ConcurrencyAndBufferSize.Concurrency == 0 {
+			config.ConcurrencyAndBufferSize.Concurrency = concurrency.Concurrency
+		}
+		if config.ConcurrencyAndBufferSize.BufferSize == 0 {
+			config.ConcurrencyAndBufferSize.BufferSize = concurrency.BufferSize
+		}
+	}
+
+	if network == nil {
+		return
+	}
+
+	if len(network.ExtraHeaders) > 0 {
+		headers := make(map[string]string, len(network.ExtraHeaders)+len(config.NetworkConfig.ExtraHeaders))
+		maps.Copy(headers, network.ExtraHeaders)
+		maps.Copy(headers, config.NetworkConfig.ExtraHeaders)
+		config.NetworkConfig.ExtraHeaders = headers
+	}
+	if config.NetworkConfig.DefaultRequestTimeoutInSeconds == 0 {
+		config.NetworkConfig.DefaultRequestTimeoutInSeconds = network.DefaultRequestTimeoutInSeconds
+	}
+	if config.NetworkConfig.RequestTimeoutsInSeconds == nil {
+		config.NetworkConfig.RequestTimeoutsInSeconds = maps.Clone(network.RequestTimeoutsInSeconds)
+	}
+	if config.NetworkConfig.MaxRetries == 0 {
+		config.NetworkConfig.MaxRetries = network.MaxRetries
+	}
+	if config.NetworkConfig.RetryBackoffInitial == 0 {
+		config.NetworkConfig.RetryBackoffInitial = network.RetryBackoffInitial
+	}
+	if config.NetworkConfig.RetryBackoffMax == 0 {
+		config.NetworkConfig.RetryBackoffMax = network.RetryBackoffMax
+	}
+	if config.NetworkConfig.ConnectionMaxRetries == nil {
+		config.NetworkConfig.ConnectionMaxRetries = network.ConnectionMaxRetries
+	}
+	if config.NetworkConfig.RequestMaxRetries == nil {
+		config.NetworkConfig.RequestMaxRetries = network.RequestMaxRetries
+	}
+	if config.NetworkConfig.RetryableStatusCodes == nil {
+		config.NetworkConfig.RetryableStatusCodes = slices.Clone(network.RetryableStatusCodes)
+	}
+	if config.NetworkConfig.CaptureResponseHeaders == nil {
+		config.NetworkConfig.CaptureResponseHeaders = slices.Clone(network.CaptureResponseHeaders)
+	}
+	if config.NetworkConfig.ContextHeaders == nil {
+		config.NetworkConfig.ContextHeaders = slices.Clone(network.ContextHeaders)
+	}
+	if config.NetworkConfig.UserAgent == "" {
+		config.NetworkConfig.UserAgent = network.UserAgent
+	}
+	if config.NetworkConfig.ClientID == "" {
+		config.NetworkConfig.ClientID = network.ClientID
+	}
+	if config.NetworkConfig.KeepaliveIntervalInSeconds == 0 {
+		config.NetworkConfig.KeepaliveIntervalInSeconds = network.KeepaliveIntervalInSeconds
+	}
+	if config.NetworkConfig.RequestCompression == nil {
+		config.NetworkConfig.RequestCompression = network.RequestCompression
+	}
+	config.NetworkConfig.WarmupOnInit = config.NetworkConfig.WarmupOnInit || network.WarmupOnInit
 }
 
 type PostHookRunner func(ctx *context.Context, result *BifrostResponse, err *BifrostError) (*BifrostResponse, *BifrostError)
 
+// WarmupProvider is implemented by providers that can pre-establish a connection to their API.
+// Providers whose endpoint depends on the key or request (e.g. Azure, Bedrock, Vertex) do not implement it.
+type WarmupProvider interface {
+	// Warmup opens a connection to the provider's API and keeps it in the client's pool
+	Warmup(ctx context.Context) error
+}
+
+// ProviderCapabilities describes the operations and features a provider supports.
+// Support is per provider API; individual models may still lack a feature (e.g. vision).
+type ProviderCapabilities struct {
+	TextCompletion       bool `json:"text_completion"`
+	ChatCompletion       bool `json:"chat_completion"`
+	ChatCompletionStream bool `json:"chat_completion_stream"`
+	Embedding            bool `json:"embedding"`
+	Speech               bool `json:"speech"`
+	SpeechStream         bool `json:"speech_stream"`
+	Transcription        bool `json:"transcription"`
+	TranscriptionStream  bool `json:"transcription_stream"`
+	Tools                bool `json:"tools"`      // Chat requests can include tool definitions
+	Vision               bool `json:"vision"`     // Chat messages can include image content blocks
+	Batch                bool `json:"batch"`      // The provider implements BatchProvider
+	Seed                 bool `json:"seed"`       // Chat requests accept a sampling seed, see ModelParameters.Seed
+	LogitBias            bool `json:"logit_bias"` // Requests accept ModelParameters.LogitBias
+	// ParallelToolCalls reports whether parallel tool calls can be controlled, which
+	// ModelParameters.ParallelToolCalls and ModelParameters.StopOnToolCall require
+	ParallelToolCalls bool           `json:"parallel_tool_calls"`
+	Penalties         PenaltySupport `json:"penalties"` // Penalty parameters accepted, see ModelParameters
+}
+
+// PenaltySupport describes the penalty parameters a provider accepts. Penalties it does not
+// accept are translated to the ones it does, or dropped, and additive penalties are clamped
+// to its range.
+type PenaltySupport struct {
+	Additive   bool    `json:"additive"`   // presence_penalty and frequency_penalty
+	Min        float64 `json:"min"`        // Lowest additive penalty accepted
+	Max        float64 `json:"max"`        // Highest additive penalty accepted
+	Repetition bool    `json:"repetition"` // repetition_penalty
+}
+
+// ModelCapabilitiesProvider is implemented by providers that serve model families with different
+// features (e.g. Claude and Gemini models on Vertex). ModelCapabilities returns the capabilities
+// for the given model, while Capabilities describes the provider's own API.
+type ModelCapabilitiesProvider interface {
+	ModelCapabilities(model string) ProviderCapabilities
+}
+
 // Provider defines the interface for AI model providers.
 type Provider interface {
 	// GetProviderKey returns the provider's identifier
 	GetProviderKey() ModelProvider
+	// Capabilities returns the operations and features the provider supports
+	Capabilities() ProviderCapabilities
 	// TextCompletion performs a text completion request
 	TextCompletion(ctx context.Context, model string, key Key, text string, params *ModelParameters) (*BifrostResponse, *BifrostError)
 	// ChatCompletion performs a chat completion request
@@ -0,0 +1,43 @@
+// Package schemas defines the core schemas and types used by the Bifrost system.
+package schemas
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// coreModulePath is the import path of the Bifrost core module.
+const coreModulePath = "github.com/maximhq/bifrost/core"
+
+// Version is the version of the Bifrost core module built into the binary, read from its build
+// info (e.g. "1.1.11"). It is "dev" when the version is unknown, e.g. in a local checkout.
+var Version = coreModuleVersion()
+
+// DefaultUserAgent is the User-Agent sent to providers when NetworkConfig.UserAgent is empty.
+var DefaultUserAgent = "bifrost/" + Version
+
+// coreModuleVersion returns the version of the core module from the build info, or "dev".
+func coreModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+
+	module := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == coreModulePath {
+			module = dep
+			if dep.Replace != nil {
+				module = dep.Replace // local replacements have no version
+			}
+			break
+		}
+	}
+	if module == &info.Main && info.Main.Path != coreModulePath {
+		return "dev"
+	}
+	if module.Version == "" || module.Version == "(devel)" {
+		return "dev"
+	}
+	return strings.TrimPrefix(module.Version, "v")
+}
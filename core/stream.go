@@ -0,0 +1,442 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// forwardStreamUntil forwards the chunks of a provider stream to the returned channel until
+// isLast returns true for a chunk. That chunk is still forwarded, then the provider stream is
+// stopped with cancel. isLast may modify the chunk before it is sent, and is called sequentially.
+func forwardStreamUntil(ctx context.Context, cancel context.CancelFunc, stream chan *schemas.BifrostStream, isLast func(*schemas.BifrostStream) bool) chan *schemas.BifrostStream {
+	forwarded := make(chan *schemas.BifrostStream, cap(stream))
+
+	go func() {
+		defer close(forwarded)
+		defer func() {
+			cancel()
+			// Drain the provider stream, so that its goroutine is never blocked on a send
+			for range stream {
+			}
+		}()
+
+		for chunk := range stream {
+			last := isLast(chunk)
+
+			select {
+			case forwarded <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			if last {
+				return
+			}
+		}
+	}()
+
+	return forwarded
+}
+
+// releaseOnStreamEnd forwards the chunks of a provider stream and calls release once it is closed,
+// so that what is held for the stream (e.g. a concurrency slot) covers its whole lifetime. If ctx
+// is done before, the rest of the stream is drained, and release is called once it is closed.
+func releaseOnStreamEnd(ctx context.Context, stream chan *schemas.BifrostStream, release func()) chan *schemas.BifrostStream {
+	forwarded := make(chan *schemas.BifrostStream, cap(stream))
+
+	go func() {
+		defer release()
+		defer close(forwarded)
+
+		for chunk := range stream {
+			select {
+			case forwarded <- chunk:
+			case <-ctx.Done():
+				// Drain the provider stream, so that its goroutine is never blocked on a send
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+
+	return forwarded
+}
+
+// startProviderStream starts the provider stream of an attempt. The chunks are run through
+// postHookRunner after StreamMaxOutputTokens and StopOnToolCall are applied and, with
+// EmptyStreamBehaviorError, once the stream has content, so that plugins see the chunks returned
+// to the caller: cut at the output cap or the tool call, and never those of a discarded empty stream.
+func (bifrost *Bifrost) startProviderStream(provider schemas.Provider, config *schemas.ProviderConfig, req *ChannelMessage, key schemas.Key, postHookRunner schemas.PostHookRunner) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	maxOutputTokens := 0
+	stopOnToolCall := false
+	if req.Type == ChatCompletionStreamRequest {
+		maxOutputTokens = req.StreamMaxOutputTokens
+		stopOnToolCall = req.Params != nil && req.Params.StopOnToolCall
+	}
+	awaitContent := bifrost.emptyStreamBehavior == schemas.EmptyStreamBehaviorError
+	if maxOutputTokens <= 0 && !stopOnToolCall && !awaitContent {
+		return handleProviderStreamRequest(provider, config, req, key, postHookRunner, req.Type)
+	}
+
+	// The provider stream is stopped through its own context once the output cap or the tool call
+	// is reached, so that the chunks already sent are still delivered through the attempt's
+	// context, and the stop is not mistaken for an abort by the caller (see watchStreamAbort)
+	ctx := req.Context
+	var stop context.CancelFunc
+	req.Context, stop = context.WithCancel(ctx)
+	stream, bifrostErr := handleProviderStreamRequest(provider, config, req, key, passThroughPostHooks, req.Type)
+	req.Context = ctx
+	if bifrostErr != nil {
+		stop()
+		return nil, bifrostErr
+	}
+
+	switch {
+	case maxOutputTokens > 0:
+		stream = capStreamOutputTokens(ctx, stop, stream, maxOutputTokens)
+		if stopOnToolCall {
+			stream = stopStreamAtToolCall(ctx, stop, stream)
+		}
+	case stopOnToolCall:
+		stream = stopStreamAtToolCall(ctx, stop, stream)
+	default:
+		stream = releaseOnStreamEnd(ctx, stream, stop)
+	}
+	if awaitContent {
+		return awaitStreamContent(ctx, stream, postHookRunner)
+	}
+	return replayStream(ctx, nil, stream, postHookRunner), nil
+}
+
+// passThroughPostHooks is the post-hook runner given to providers whose stream chunks have their
+// post-hooks run later, by replayStream. It returns the chunk as is.
+func passThroughPostHooks(_ *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return result, bifrostErr
+}
+
+// awaitStreamContent implements EmptyStreamBehaviorError: it holds back the chunks of a provider
+// stream until one has content or an error, and returns a stream that replays them followed by
+// the rest of the provider stream. A stream that ends before is reported as an error, so that the
+// attempt is retried or falls back instead of returning an empty stream to the caller, and ctx
+// being done before is reported as a cancellation.
+//
+// The provider must have been given passThroughPostHooks: postHookRunner is run on each chunk as
+// it is forwarded, so that plugins never see the chunks of a discarded empty stream.
+func awaitStreamContent(ctx context.Context, stream chan *schemas.BifrostStream, postHookRunner schemas.PostHookRunner) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	var held []*schemas.BifrostStream
+	for {
+		select {
+		case chunk, ok := <-stream:
+			if !ok {
+				return nil, &schemas.BifrostError{
+					IsBifrostError: false,
+					Kind:           schemas.ErrorKindEmptyStream,
+					Error: schemas.ErrorField{
+						Message: schemas.ErrProviderEmptyStream,
+						Error:   fmt.Errorf("stream ended after %d chunks without content", len(held)),
+					},
+				}
+			}
+			held = append(held, chunk)
+			if chunk.BifrostError != nil || (chunk.BifrostResponse != nil && hasStreamedContent(chunk.BifrostResponse)) {
+				return replayStream(ctx, held, stream, postHookRunner), nil
+			}
+		case <-ctx.Done():
+			// Drain the provider stream, so that its goroutine is never blocked on a send
+			go func() {
+				for range stream {
+				}
+			}()
+			return nil, &schemas.BifrostError{
+				IsBifrostError: true,
+				Error: schemas.ErrorField{
+					Type:    Ptr(schemas.RequestCancelled),
+					Message: fmt.Sprintf("Request cancelled or timed out while waiting for stream content: %v", ctx.Err()),
+					Error:   ctx.Err(),
+				},
+			}
+		}
+	}
+}
+
+// replayStream returns a stream of the held chunks followed by the chunks of the provider stream,
+// each run through postHookRunner the way providers run their chunks through it.
+func replayStream(ctx context.Context, held []*schemas.BifrostStream, stream chan *schemas.BifrostStream, postHookRunner schemas.PostHookRunner) chan *schemas.BifrostStream {
+	replayed := make(chan *schemas.BifrostStream, cap(stream))
+
+	send := func(chunk *schemas.BifrostStream) bool {
+		result, bifrostErr := postHookRunner(&ctx, chunk.BifrostResponse, chunk.BifrostError)
+		if bifrostErr != nil && chunk.BifrostError == nil {
+			// A post-hook failing a response replaces it with the error
+			result = nil
+		}
+		select {
+		case replayed <- &schemas.BifrostStream{BifrostResponse: result, BifrostError: bifrostErr}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(replayed)
+		defer func() {
+			// Drain the provider stream, so that its goroutine is never blocked on a send
+			for range stream {
+			}
+		}()
+
+		for _, chunk := range held {
+			if !send(chunk) {
+				return
+			}
+		}
+		for chunk := range stream {
+			if !send(chunk) {
+				return
+			}
+		}
+	}()
+
+	return replayed
+}
+
+// stopStreamAtToolCall forwards the chunks of a chat completion stream until the arguments of
+// the first tool call are complete. Arguments are streamed as JSON fragments and are complete
+// once they form a valid JSON value. Tools without parameters may get empty arguments, which are
+// complete once the provider finishes the choice. The chunk completing them is sent with the
+// FinishReasonToolCalls finish reason, then the provider stream is stopped with cancel.
+func stopStreamAtToolCall(ctx context.Context, cancel context.CancelFunc, stream chan *schemas.BifrostStream) chan *schemas.BifrostStream {
+	var arguments []byte
+	toolCalled := false
+	return forwardStreamUntil(ctx, cancel, stream, func(chunk *schemas.BifrostStream) bool {
+		if chunk.BifrostResponse == nil || chunk.BifrostError != nil {
+			return false
+		}
+
+		complete := false
+		for _, choice := range chunk.BifrostResponse.Choices {
+			if choice.BifrostStreamResponseChoice == nil {
+				continue
+			}
+			for _, toolCall := range choice.Delta.ToolCalls {
+				toolCalled = true
+				arguments = append(arguments, toolCall.Function.Arguments...)
+				if len(bytes.TrimSpace(arguments)) > 0 && sonic.Valid(arguments) {
+					complete = true
+				}
+			}
+			if toolCalled && choice.FinishReason != nil {
+				complete = true
+			}
+		}
+		if !complete {
+			return false
+		}
+
+		for i := range chunk.BifrostResponse.Choices {
+			chunk.BifrostResponse.Choices[i].FinishReason = Ptr(schemas.FinishReasonToolCalls)
+		}
+		return true
+	})
+}
+
+// watchStreamAbort forwards the chunks of a chat completion stream and implements OnStreamAbort:
+// if ctx is done before the provider finished the generation, the hook is called with the usage
+// tracked so far once the provider stream is closed. The provider stops the generation by closing
+// its connection when ctx is done, so the usage reported is the one of the partial stream.
+func (bifrost *Bifrost) watchStreamAbort(ctx context.Context, req *schemas.BifrostRequest, stream chan *schemas.BifrostStream, tracker *streamUsageTracker) chan *schemas.BifrostStream {
+	forwarded := make(chan *schemas.BifrostStream, cap(stream))
+	provider, model := req.Provider, req.Model
+
+	go func() {
+		defer close(forwarded)
+
+		for chunk := range stream {
+			select {
+			case forwarded <- chunk:
+			case <-ctx.Done():
+				// Drain the provider stream, so that its goroutine is never blocked on a send
+				for range stream {
+				}
+			}
+		}
+
+		// The tracker is only updated by the provider goroutine, which is done once stream is closed
+		if ctx.Err() == nil || tracker.finished {
+			return
+		}
+		bifrost.reportStreamAbort(schemas.StreamAbortInfo{
+			Provider:    provider,
+			Model:       model,
+			RequestType: string(ChatCompletionStreamRequest),
+			Usage:       tracker.usage,
+			Estimated:   !tracker.final,
+			Err:         ctx.Err(),
+		})
+	}()
+
+	return forwarded
+}
+
+// reportStreamAbort calls the OnStreamAbort hook, recovering from panics in it.
+func (bifrost *Bifrost) reportStreamAbort(info schemas.StreamAbortInfo) {
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("OnStreamAbort hook panicked: %v", r))
+		}
+	}()
+
+	bifrost.logger.Debug(fmt.Sprintf("Stream of %s/%s aborted after %d completion tokens: %v", info.Provider, info.Model, info.Usage.CompletionTokens, info.Err))
+	bifrost.onStreamAbort(info)
+}
+
+// StreamTee fans the chunks of a stream out to several consumers, each receiving every chunk.
+// Create it with TeeStream.
+type StreamTee struct {
+	outputs       []chan *schemas.BifrostStream
+	detached      []chan struct{}
+	detach        []sync.Once
+	detachedCount atomic.Int32
+	cancel        context.CancelFunc
+}
+
+// TeeStream starts copying every chunk of stream to consumers independent channels, e.g. to
+// display a stream to a user while a moderation consumer reads it too. Each channel buffers up to
+// bufferSize chunks, so a fast consumer can run that far ahead of a slow one before it waits for
+// it: a slow consumer delays the others, but never makes the tee accumulate chunks beyond the
+// buffers. All channels are closed once stream is closed.
+//
+// A consumer that stops reading before the end of the stream must call Detach, otherwise the
+// other consumers eventually block. Once every consumer detached, cancel is called to stop the
+// provider, and the rest of the stream is drained and discarded. cancel should cancel the context
+// the stream was requested with; it may be nil.
+func TeeStream(stream chan *schemas.BifrostStream, cancel context.CancelFunc, consumers int, bufferSize int) *StreamTee {
+	tee := &StreamTee{
+		outputs:  make([]chan *schemas.BifrostStream, consumers),
+		detached: make([]chan struct{}, consumers),
+		detach:   make([]sync.Once, consumers),
+		cancel:   cancel,
+	}
+	for i := range consumers {
+		tee.outputs[i] = make(chan *schemas.BifrostStream, max(bufferSize, 0))
+		tee.detached[i] = make(chan struct{})
+	}
+
+	go tee.run(stream)
+	return tee
+}
+
+// Output returns the channel of the i-th consumer, from 0 to consumers-1.
+func (tee *StreamTee) Output(i int) <-chan *schemas.BifrostStream {
+	return tee.outputs[i]
+}
+
+// Detach stops sending chunks to the i-th consumer and closes its channel, which may still hold
+// chunks that were already buffered. The other consumers are not affected. It is safe to call Detach several
+// times, or after the stream ended.
+func (tee *StreamTee) Detach(i int) {
+	tee.detach[i].Do(func() {
+		close(tee.detached[i])
+		// Nobody reads the stream anymore, stop the provider instead of paying for unread tokens
+		if int(tee.detachedCount.Add(1)) == len(tee.outputs) && tee.cancel != nil {
+			tee.cancel()
+		}
+	})
+}
+
+// run copies the chunks of stream to the attached consumers until stream is closed.
+func (tee *StreamTee) run(stream chan *schemas.BifrostStream) {
+	attached := make([]bool, len(tee.outputs))
+	remaining := len(tee.outputs)
+	for i := range attached {
+		attached[i] = true
+	}
+	drop := func(i int) {
+		attached[i] = false
+		remaining--
+		close(tee.outputs[i])
+	}
+
+	for chunk := range stream {
+		for i, output := range tee.outputs {
+			if !attached[i] {
+				continue
+			}
+			select {
+			case <-tee.detached[i]:
+				drop(i)
+				continue
+			default:
+			}
+			select {
+			case output <- chunk:
+			case <-tee.detached[i]:
+				drop(i)
+			}
+		}
+		// Keep reading the stream without consumers, so that the provider goroutine is never blocked on a send
+		if remaining == 0 {
+			for range stream {
+			}
+			return
+		}
+	}
+
+	for i := range tee.outputs {
+		if attached[i] {
+			drop(i)
+		}
+	}
+}
+
+// sequenceStream implements BifrostConfig.StreamSequencing: it forwards the chunks of a stream
+// returned to a caller, numbering them and adding the running checksum of their content if enabled.
+func (bifrost *Bifrost) sequenceStream(ctx context.Context, stream chan *schemas.BifrostStream) chan *schemas.BifrostStream {
+	if bifrost.streamSequencing == nil || stream == nil {
+		return stream
+	}
+
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	checksums := bifrost.streamSequencing.Checksums
+	var sequence uint64
+	var checksum uint32
+	return forwardStreamUntil(ctx, cancel, stream, func(chunk *schemas.BifrostStream) bool {
+		sequence++
+		chunk.Sequence = sequence
+		if checksums {
+			checksum = updateStreamChecksum(checksum, chunk.BifrostResponse)
+			chunk.Checksum = Ptr(checksum)
+		}
+		return false
+	})
+}
+
+// updateStreamChecksum adds the content of a stream chunk to the running CRC-32 of a stream.
+func updateStreamChecksum(checksum uint32, response *schemas.BifrostResponse) uint32 {
+	if response == nil {
+		return checksum
+	}
+	for _, choice := range response.Choices {
+		if choice.BifrostStreamResponseChoice != nil && choice.Delta.Content != nil {
+			checksum = crc32.Update(checksum, crc32.IEEETable, []byte(*choice.Delta.Content))
+		}
+	}
+	if response.Speech != nil {
+		checksum = crc32.Update(checksum, crc32.IEEETable, response.Speech.Audio)
+	}
+	if response.Transcribe != nil && response.Transcribe.BifrostTranscribeStreamResponse != nil && response.Transcribe.Delta != nil {
+		checksum = crc32.Update(checksum, crc32.IEEETable, []byte(*response.Transcribe.Delta))
+	}
+	return checksum
+}
@@ -0,0 +1,615 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func toolCallChunk(arguments string) *schemas.BifrostStream {
+	return &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{
+		Choices: []schemas.BifrostResponseChoice{{
+			BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{
+				Delta: schemas.BifrostStreamDelta{ToolCalls: []schemas.ToolCall{{Function: schemas.FunctionCall{Arguments: arguments}}}},
+			},
+		}},
+	}}
+}
+
+func TestStopStreamAtToolCall(t *testing.T) {
+	stream := make(chan *schemas.BifrostStream, 5)
+	stream <- streamChunk("Let me check.")
+	stream <- toolCallChunk(`{"city":`)
+	stream <- toolCallChunk(`"Paris"}`)
+	stream <- toolCallChunk(`{"city":"Rome"}`)
+	close(stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var chunks []*schemas.BifrostStream
+	for chunk := range stopStreamAtToolCall(ctx, cancel, stream) {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	if finishReason := chunks[1].BifrostResponse.Choices[0].FinishReason; finishReason != nil {
+		t.Errorf("finish reason of a partial tool call chunk = %q, want none", *finishReason)
+	}
+	finishReason := chunks[2].BifrostResponse.Choices[0].FinishReason
+	if finishReason == nil || *finishReason != schemas.FinishReasonToolCalls {
+		t.Errorf("finish reason of the last chunk = %v, want %q", finishReason, schemas.FinishReasonToolCalls)
+	}
+	if ctx.Err() == nil {
+		t.Error("provider stream context was not cancelled")
+	}
+}
+
+func TestStopStreamAtToolCallWithoutArguments(t *testing.T) {
+	finished := toolCallChunk("")
+	finished.BifrostResponse.Choices[0].Delta.ToolCalls = nil
+	finished.BifrostResponse.Choices[0].FinishReason = Ptr("tool_use")
+
+	stream := make(chan *schemas.BifrostStream, 4)
+	stream <- toolCallChunk("")
+	stream <- finished
+	stream <- streamChunk("never sent")
+	close(stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var chunks []*schemas.BifrostStream
+	for chunk := range stopStreamAtToolCall(ctx, cancel, stream) {
+		chunks = append(chunks, chunk)
+	}
+
+	// Empty arguments are complete once the provider finishes the choice
+	if len(chunks) != 2 {
+		t.Fatalf("got %d chunks, want 2", len(chunks))
+	}
+	finishReason := chunks[1].BifrostResponse.Choices[0].FinishReason
+	if finishReason == nil || *finishReason != schemas.FinishReasonToolCalls {
+		t.Errorf("finish reason of the last chunk = %v, want %q", finishReason, schemas.FinishReasonToolCalls)
+	}
+}
+
+func TestStopOnToolCallParams(t *testing.T) {
+	params := &schemas.ModelParameters{StopOnToolCall: true}
+	got := stopOnToolCallParams(params)
+	if got.ParallelToolCalls == nil || *got.ParallelToolCalls {
+		t.Errorf("ParallelToolCalls = %v, want false", got.ParallelToolCalls)
+	}
+	if params.ParallelToolCalls != nil {
+		t.Error("caller's parameters were modified")
+	}
+
+	if param := unsupportedParam(params, schemas.ProviderCapabilities{ChatCompletion: true}); param != "stop_on_tool_call" {
+		t.Errorf("unsupportedParam() without parallel tool calls = %q, want stop_on_tool_call", param)
+	}
+}
+
+func TestTeeStream(t *testing.T) {
+	stream := make(chan *schemas.BifrostStream)
+	tee := TeeStream(stream, nil, 2, 1)
+
+	go func() {
+		for _, content := range []string{"a", "b", "c"} {
+			stream <- streamChunk(content)
+		}
+		close(stream)
+	}()
+
+	// Both consumers receive every chunk, in order
+	var received [2][]string
+	var wg sync.WaitGroup
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range tee.Output(i) {
+				received[i] = append(received[i], *chunk.BifrostResponse.Choices[0].Delta.Content)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, contents := range received {
+		if !slices.Equal(contents, []string{"a", "b", "c"}) {
+			t.Errorf("consumer %d received %q, want [a b c]", i, contents)
+		}
+	}
+}
+
+func TestTeeStreamDetach(t *testing.T) {
+	stream := make(chan *schemas.BifrostStream)
+	cancelled := 0
+	tee := TeeStream(stream, func() { cancelled++ }, 2, 0)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for range 10 {
+			stream <- streamChunk("chunk")
+		}
+		close(stream)
+	}()
+
+	// The second consumer never reads; once detached, it no longer blocks the first one
+	tee.Detach(1)
+	count := 0
+	for range tee.Output(0) {
+		count++
+	}
+	if count != 10 {
+		t.Errorf("first consumer received %d chunks, want 10", count)
+	}
+	if cancelled != 0 {
+		t.Error("upstream was cancelled while a consumer was still attached")
+	}
+
+	// Detaching every consumer cancels the upstream and drains the stream
+	tee.Detach(0)
+	tee.Detach(0)
+	if cancelled != 1 {
+		t.Errorf("upstream cancelled %d times after all consumers detached, want 1", cancelled)
+	}
+	stream = make(chan *schemas.BifrostStream)
+	tee = TeeStream(stream, nil, 1, 0)
+	tee.Detach(0)
+	for range 5 {
+		select {
+		case stream <- streamChunk("chunk"):
+		case <-time.After(time.Second):
+			t.Fatal("stream is not drained after all consumers detached")
+		}
+	}
+	close(stream)
+	<-sent
+}
+
+func TestWatchStreamAbortReportsPartialUsage(t *testing.T) {
+	var aborts []schemas.StreamAbortInfo
+	bifrost := newTestBifrost(nil)
+	bifrost.onStreamAbort = func(info schemas.StreamAbortInfo) { aborts = append(aborts, info) }
+
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}
+	tracker := newStreamUsageTracker(req)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The provider goroutine keeps sending after the cancellation, until its connection is closed
+	stream := make(chan *schemas.BifrostStream)
+	go func() {
+		defer close(stream)
+		for _, content := range []string{"12345678", "abcdefgh", "never read"} {
+			chunk := streamChunk(content)
+			tracker.update(chunk.BifrostResponse)
+			stream <- chunk
+		}
+	}()
+
+	forwarded := bifrost.watchStreamAbort(ctx, req, stream, tracker)
+	<-forwarded
+	cancel()
+	for range forwarded {
+	}
+
+	if len(aborts) != 1 {
+		t.Fatalf("OnStreamAbort called %d times, want 1", len(aborts))
+	}
+	abort := aborts[0]
+	if abort.Provider != schemas.OpenAI || abort.Model != "gpt-4o" || abort.Err != context.Canceled {
+		t.Errorf("abort = %+v, want openai/gpt-4o cancelled", abort)
+	}
+	if !abort.Estimated || abort.Usage.CompletionTokens < 2 {
+		t.Errorf("usage = %+v (estimated %v), want at least 2 estimated completion tokens", abort.Usage, abort.Estimated)
+	}
+}
+
+func TestWatchStreamAbortIgnoresFinishedStreams(t *testing.T) {
+	called := false
+	bifrost := newTestBifrost(nil)
+	bifrost.onStreamAbort = func(schemas.StreamAbortInfo) { called = true }
+
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}
+	tracker := newStreamUsageTracker(req)
+	last := streamChunk("done")
+	last.BifrostResponse.Choices[0].FinishReason = Ptr("stop")
+	tracker.update(last.BifrostResponse)
+
+	stream := make(chan *schemas.BifrostStream, 1)
+	stream <- last
+	close(stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for range bifrost.watchStreamAbort(ctx, req, stream, tracker) {
+	}
+
+	if called {
+		t.Error("OnStreamAbort called for a stream the provider finished")
+	}
+}
+
+func TestIntentionalStreamStopsAreNotAborts(t *testing.T) {
+	// The provider streams a tool call and some text, then keeps the stream open until it is stopped
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"lookup\",\"arguments\":\"{}\"}}]}}]}\n\n")
+		for range 10 {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"some more text\"}}]}\n\n")
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	aborts := 0
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys:   testKeys(),
+			config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+		},
+		Logger:        NewDefaultLogger(schemas.LogLevelError),
+		OnStreamAbort: func(schemas.StreamAbortInfo) { aborts++ },
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	for name, req := range map[string]*schemas.BifrostRequest{
+		"stop on tool call": {Params: &schemas.ModelParameters{StopOnToolCall: true}},
+		"output cap":        {StreamMaxOutputTokens: 5},
+	} {
+		req.Provider, req.Model = schemas.OpenAI, "gpt-4o"
+		req.Input = schemas.RequestInput{ChatCompletionInput: &messages}
+		chunks, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), req)
+		if bifrostErr != nil {
+			t.Fatalf("%s: ChatCompletionStreamRequest() error = %+v", name, bifrostErr)
+		}
+		for range chunks {
+		}
+		// The stream was stopped by Bifrost on purpose, not aborted by the caller
+		if aborts != 0 {
+			t.Errorf("%s: OnStreamAbort called %d times, want 0", name, aborts)
+		}
+	}
+}
+
+func TestSequenceStream(t *testing.T) {
+	bifrost := newTestBifrost(nil)
+	stream := make(chan *schemas.BifrostStream, 3)
+	stream <- streamChunk("Hello")
+	stream <- &schemas.BifrostStream{BifrostError: &schemas.BifrostError{Error: schemas.ErrorField{Message: "stream failed"}}}
+	stream <- streamChunk(", world")
+	close(stream)
+
+	if got := bifrost.sequenceStream(context.Background(), stream); got != stream {
+		t.Fatal("stream was forwarded with sequencing disabled")
+	}
+
+	bifrost.streamSequencing = &schemas.StreamSequencingConfig{Checksums: true}
+	var chunks []*schemas.BifrostStream
+	for chunk := range bifrost.sequenceStream(context.Background(), stream) {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	wantChecksums := []uint32{crc32.ChecksumIEEE([]byte("Hello")), crc32.ChecksumIEEE([]byte("Hello")), crc32.ChecksumIEEE([]byte("Hello, world"))}
+	for i, chunk := range chunks {
+		if chunk.Sequence != uint64(i+1) {
+			t.Errorf("chunk %d sequence = %d, want %d", i, chunk.Sequence, i+1)
+		}
+		if chunk.Checksum == nil || *chunk.Checksum != wantChecksums[i] {
+			t.Errorf("chunk %d checksum = %v, want %d", i, chunk.Checksum, wantChecksums[i])
+		}
+	}
+}
+
+func TestEmptyStreamBehavior(t *testing.T) {
+	// The first stream opens and finishes without content, the next ones stream a reply
+	var requests atomic.Int32
+	emptyStreams := int32(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if requests.Add(1) <= emptyStreams {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"},\"finish_reason\":\"stop\"}]}\n\n")
+		} else {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"},\"finish_reason\":\"stop\"}]}\n\n")
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			MaxRetries:                     1,
+			RetryBackoffInitial:            time.Millisecond,
+			RetryBackoffMax:                time.Millisecond,
+		}),
+	}
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	var postHooks []string
+	chunkCount := 0
+	stream := func(behavior schemas.EmptyStreamBehavior) (string, *schemas.BifrostError) {
+		postHooks, chunkCount = nil, 0
+		bifrost, err := Init(schemas.BifrostConfig{
+			Account:             account,
+			Plugins:             []schemas.Plugin{&recordingPlugin{name: "logging", calls: &postHooks}},
+			Logger:              NewDefaultLogger(schemas.LogLevelError),
+			EmptyStreamBehavior: behavior,
+		})
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		defer bifrost.Cleanup()
+
+		chunks, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+			Provider: schemas.OpenAI,
+			Model:    "gpt-4o",
+			Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		})
+		if bifrostErr != nil {
+			return "", bifrostErr
+		}
+		var content string
+		for chunk := range chunks {
+			chunkCount++
+			if chunk.BifrostError != nil {
+				t.Fatalf("stream error = %+v", chunk.BifrostError)
+			}
+			for _, choice := range chunk.Choices {
+				if choice.BifrostStreamResponseChoice != nil && choice.Delta.Content != nil {
+					content += *choice.Delta.Content
+				}
+			}
+		}
+		return content, nil
+	}
+
+	// By default, the empty stream is returned as is
+	if content, bifrostErr := stream(schemas.EmptyStreamBehaviorAllow); bifrostErr != nil || content != "" || requests.Load() != 1 {
+		t.Errorf("content = %q, error = %+v after %d requests, want the empty stream", content, bifrostErr, requests.Load())
+	}
+
+	// As an error, the empty stream is retried
+	requests.Store(0)
+	if content, bifrostErr := stream(schemas.EmptyStreamBehaviorError); bifrostErr != nil || content != "Hello" || requests.Load() != 2 {
+		t.Errorf("content = %q, error = %+v after %d requests, want the retried stream", content, bifrostErr, requests.Load())
+	}
+	// Plugins only see the chunks of the stream returned, not those of the empty one
+	if got := len(postHooks) - 1; got != chunkCount {
+		t.Errorf("post-hooks ran for %d chunks, want %d", got, chunkCount)
+	}
+
+	// Once retries are exhausted, the request fails
+	requests.Store(0)
+	emptyStreams = 2
+	_, bifrostErr := stream(schemas.EmptyStreamBehaviorError)
+	if bifrostErr == nil || bifrostErr.Error.Message != schemas.ErrProviderEmptyStream || requests.Load() != 2 {
+		t.Errorf("error = %+v after %d requests, want an empty stream error", bifrostErr, requests.Load())
+	}
+}
+
+func TestEmptyStreamHoldCancelled(t *testing.T) {
+	// The stream opens, then sends nothing until the client goes away
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			MaxRetries:                     1,
+			RetryBackoffInitial:            time.Millisecond,
+			RetryBackoffMax:                time.Millisecond,
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError), EmptyStreamBehavior: schemas.EmptyStreamBehaviorError})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	_, bifrostErr := bifrost.ChatCompletionStreamRequest(ctx, &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+
+	// Cancellation while waiting for content is reported as such, and not retried
+	if bifrostErr == nil || bifrostErr.Error.Type == nil || *bifrostErr.Error.Type != schemas.RequestCancelled || requests.Load() != 1 {
+		t.Errorf("error = %+v after %d requests, want a cancellation after 1 request", bifrostErr, requests.Load())
+	}
+}
+
+func TestGlobalConcurrencyHeldByOpenStreams(t *testing.T) {
+	// Every stream sends a first chunk, then stays open until finish is closed
+	var requests atomic.Int32
+	finish := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-finish
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+	defer func() {
+		select {
+		case <-finish:
+		default:
+			close(finish)
+		}
+	}()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{
+			keys: testKeys(),
+			config: sameConfig(schemas.ProviderConfig{
+				NetworkConfig:            schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5},
+				ConcurrencyAndBufferSize: schemas.ConcurrencyAndBufferSize{Concurrency: 4, BufferSize: 10},
+			}),
+		},
+		Logger:                NewDefaultLogger(schemas.LogLevelError),
+		MaxConcurrentRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func(ctx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return bifrost.ChatCompletionStreamRequest(ctx, &schemas.BifrostRequest{
+			Provider:   schemas.OpenAI,
+			Model:      "gpt-4o",
+			Input:      schemas.RequestInput{ChatCompletionInput: &messages},
+			MaxRetries: Ptr(0),
+		})
+	}
+
+	var streams []chan *schemas.BifrostStream
+	for range 2 {
+		stream, bifrostErr := request(context.Background())
+		if bifrostErr != nil {
+			t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+		}
+		<-stream
+		streams = append(streams, stream)
+	}
+
+	// The open streams hold both slots, so a third request waits for one
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, bifrostErr := request(ctx); bifrostErr == nil {
+		t.Fatal("ChatCompletionStreamRequest() with every slot held by a stream succeeded, want a cancellation")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("provider received %d requests, want 2", got)
+	}
+
+	// Once the streams are closed, their slots are released
+	close(finish)
+	for _, stream := range streams {
+		for range stream {
+		}
+	}
+	stream, bifrostErr := request(context.Background())
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() after the streams closed error = %+v", bifrostErr)
+	}
+	for range stream {
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("provider received %d requests, want 3", got)
+	}
+}
+
+// unstreamablePlugin stands in for a primary provider that fails and a fallback model that
+// cannot stream: the fallback's first request, the stream, is unsupported, and later ones answer.
+type unstreamablePlugin struct {
+	fallbackCalls int
+}
+
+func (p *unstreamablePlugin) GetName() string { return "unstreamable" }
+
+func (p *unstreamablePlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if req.Model != "fallback" {
+		return req, &schemas.PluginShortCircuit{Error: newBifrostErrorFromMsg("overloaded")}, nil
+	}
+	p.fallbackCalls++
+	if p.fallbackCalls == 1 {
+		unsupported := newBifrostErrorFromMsg("chat completion stream is not supported")
+		unsupported.Error.Type = Ptr(schemas.UnsupportedOperation)
+		return req, &schemas.PluginShortCircuit{Error: unsupported}, nil
+	}
+	content, stop := "Hi", "stop"
+	return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{Object: "chat.completion", Choices: []schemas.BifrostResponseChoice{{
+		FinishReason: &stop,
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: schemas.BifrostMessage{Role: schemas.ModelChatMessageRoleAssistant, Content: schemas.MessageContent{ContentStr: &content}},
+		},
+	}}}}, nil
+}
+
+func (p *unstreamablePlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *unstreamablePlugin) Cleanup() error { return nil }
+
+func TestStreamFallbackAdapter(t *testing.T) {
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	request := func(adapter bool) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		bifrost, err := Init(schemas.BifrostConfig{
+			Account:               &testAccount{keys: testKeys()},
+			Plugins:               []schemas.Plugin{&unstreamablePlugin{}},
+			Logger:                NewDefaultLogger(schemas.LogLevelError),
+			StreamFallbackAdapter: adapter,
+		})
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		t.Cleanup(bifrost.Cleanup)
+		return bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+			Provider:  schemas.OpenAI,
+			Model:     "gpt-4o",
+			Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+			Fallbacks: []schemas.Fallback{{Provider: schemas.OpenAI, Model: "fallback"}},
+		})
+	}
+
+	// Without the adapter, the fallback fails as it cannot stream
+	_, bifrostErr := request(false)
+	if bifrostErr == nil || len(bifrostErr.AttemptHistory) != 2 || bifrostErr.AttemptHistory[1].Type == nil ||
+		*bifrostErr.AttemptHistory[1].Type != schemas.UnsupportedOperation {
+		t.Errorf("ChatCompletionStreamRequest() error = %+v, want the unsupported stream among the attempts", bifrostErr)
+	}
+
+	// With it, the fallback's response is delivered as a single stream chunk
+	stream, bifrostErr := request(true)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+	}
+	var chunks []*schemas.BifrostStream
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 1 || chunks[0].BifrostResponse == nil {
+		t.Fatalf("got %d chunks, want the response as one chunk", len(chunks))
+	}
+	chunk := chunks[0].BifrostResponse
+	choice := chunk.Choices[0]
+	if chunk.Object != "chat.completion.chunk" || choice.BifrostStreamResponseChoice == nil || choice.Delta.Content == nil ||
+		*choice.Delta.Content != "Hi" || choice.FinishReason == nil || *choice.FinishReason != "stop" {
+		t.Errorf("chunk = %+v, want the message as a final delta", chunk)
+	}
+}
@@ -0,0 +1,89 @@
+package bifrost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestNetworkConfigRequestTimeout(t *testing.T) {
+	network := schemas.NetworkConfig{
+		DefaultRequestTimeoutInSeconds: 30,
+		RequestTimeoutsInSeconds:       map[string]int{"embedding": 5, "chat_completion_stream": 300, "speech": 0},
+	}
+
+	tests := []struct {
+		requestType string
+		want        time.Duration
+	}{
+		{"embedding", 5 * time.Second},
+		{"chat_completion_stream", 300 * time.Second},
+		{"chat_completion", 30 * time.Second},
+		{"speech", 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := network.RequestTimeout(tt.requestType); got != tt.want {
+			t.Errorf("RequestTimeout(%q) = %s, want %s", tt.requestType, got, tt.want)
+		}
+	}
+
+	if got := network.MaxRequestTimeout(); got != 300*time.Second {
+		t.Errorf("MaxRequestTimeout() = %s, want 5m0s", got)
+	}
+}
+
+func TestValidateRequestTimeouts(t *testing.T) {
+	if err := validateRequestTimeouts(schemas.NetworkConfig{RequestTimeoutsInSeconds: map[string]int{"embedding": 5}}); err != nil {
+		t.Errorf("validateRequestTimeouts() error = %v", err)
+	}
+	if err := validateRequestTimeouts(schemas.NetworkConfig{RequestTimeoutsInSeconds: map[string]int{"embeddings": 5}}); err == nil {
+		t.Error("validateRequestTimeouts() accepted an unknown request type")
+	}
+}
+
+func TestRequestTypeTimeoutEnforced(t *testing.T) {
+	// The server never answers, until the test is done
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 30,
+			RequestTimeoutsInSeconds:       map[string]int{"chat_completion": 1},
+			ConnectionMaxRetries:           Ptr(0),
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	start := time.Now()
+	_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+	if bifrostErr == nil {
+		t.Fatal("ChatCompletionRequest() succeeded, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("request took %s, want the 1s chat_completion timeout", elapsed)
+	}
+	if bifrostErr.Error.Message != schemas.ErrProviderRequest || bifrostErr.Error.Error == nil || !strings.Contains(bifrostErr.Error.Error.Error(), "timed out") {
+		t.Errorf("error = %+v, want a provider request timeout", bifrostErr.Error)
+	}
+}
@@ -0,0 +1,252 @@
+package bifrost
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// QueueStats sums up how long the requests of a provider waited in its queue and how long its
+// workers spent on them since Init. Comparing the averages tells whether requests are slow
+// because the provider needs more concurrency (long queue wait) or because the provider itself
+// is slow (long service time).
+type QueueStats struct {
+	Requests         int64         // Number of requests handled by the provider's workers
+	Failed           int64         // Number of those requests that failed
+	Queued           int           // Number of requests currently waiting in the queue
+	TotalQueueWait   time.Duration // Sum of the time requests waited for a worker
+	MaxQueueWait     time.Duration // Longest time a request waited for a worker
+	TotalServiceTime time.Duration // Sum of the time workers spent on requests
+	MaxServiceTime   time.Duration // Longest time a worker spent on a request
+
+	// Adaptive concurrency only (see ConcurrencyAndBufferSize.Adaptive)
+	ConcurrencyLimit int   // Current limit on concurrent provider calls (0 if adaptive concurrency is disabled)
+	Throttled        int64 // Number of provider calls that were throttled or slower than the latency threshold
+}
+
+// AverageQueueWait returns the mean time requests waited for a worker.
+func (s QueueStats) AverageQueueWait() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalQueueWait / time.Duration(s.Requests)
+}
+
+// AverageServiceTime returns the mean time workers spent on a request.
+func (s QueueStats) AverageServiceTime() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalServiceTime / time.Duration(s.Requests)
+}
+
+// queueStats accumulates the QueueStats of a provider.
+type queueStats struct {
+	mu    sync.Mutex
+	stats QueueStats
+}
+
+func (s *queueStats) add(timing schemas.RequestTiming) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Requests++
+	if timing.Failed {
+		s.stats.Failed++
+	}
+	s.stats.TotalQueueWait += timing.QueueWait
+	s.stats.MaxQueueWait = max(s.stats.MaxQueueWait, timing.QueueWait)
+	s.stats.TotalServiceTime += timing.ServiceTime
+	s.stats.MaxServiceTime = max(s.stats.MaxServiceTime, timing.ServiceTime)
+}
+
+func (s *queueStats) snapshot() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// recordRequestTiming adds the queue wait and service time of a request, which a worker dequeued at
+// dequeuedAt and has just finished, to its provider's stats and reports them to the OnRequestTiming
+// hook, if configured. A panicking hook is recovered so that it cannot take down the worker.
+func (bifrost *Bifrost) recordRequestTiming(req *ChannelMessage, dequeuedAt time.Time, failed bool) {
+	timing := schemas.RequestTiming{
+		Provider:    req.Provider,
+		Model:       req.Model,
+		RequestType: string(req.Type),
+		ServiceTime: time.Since(dequeuedAt),
+		Failed:      failed,
+	}
+	if !req.EnqueuedAt.IsZero() {
+		timing.QueueWait = dequeuedAt.Sub(req.EnqueuedAt)
+	}
+
+	stats, _ := bifrost.queueStats.LoadOrStore(req.Provider, &queueStats{})
+	stats.(*queueStats).add(timing)
+
+	if bifrost.onRequestTiming == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("OnRequestTiming hook panicked: %v", r))
+		}
+	}()
+
+	bifrost.onRequestTiming(timing)
+}
+
+// GetQueueStats returns the queue wait and service time stats of every provider that handled
+// requests since Init.
+func (bifrost *Bifrost) GetQueueStats() map[schemas.ModelProvider]QueueStats {
+	result := make(map[schemas.ModelProvider]QueueStats)
+	bifrost.queueStats.Range(func(key, value any) bool {
+		providerKey := key.(schemas.ModelProvider)
+		stats := value.(*queueStats).snapshot()
+		if queue, ok := bifrost.requestQueues.Load(providerKey); ok {
+			stats.Queued = len(queue.(chan ChannelMessage))
+		}
+		if limiter := bifrost.getAdaptiveLimiter(providerKey); limiter != nil {
+			stats.ConcurrencyLimit, stats.Throttled = limiter.snapshot()
+		}
+		result[providerKey] = stats
+		return true
+	})
+	return result
+}
+
+// firstTokenTimer measures the time to first token of a stream. Chunks of a stream are processed
+// sequentially, so it needs no locking.
+type firstTokenTimer struct {
+	start   time.Time     // Start of the provider call that established the stream
+	elapsed time.Duration // Time to first token, set once the first chunk with content is observed
+	seconds *float64      // elapsed in seconds, reported on the chunks (nil until observed)
+}
+
+// observe reports on the chunk the time to first token if it is known, and returns true if the
+// chunk is the first one with content.
+func (t *firstTokenTimer) observe(resp *schemas.BifrostResponse) bool {
+	if resp == nil || t.start.IsZero() {
+		return false
+	}
+	if t.seconds != nil {
+		resp.ExtraFields.TimeToFirstToken = t.seconds
+		return false
+	}
+	if !hasStreamedContent(resp) {
+		return false
+	}
+
+	t.elapsed = time.Since(t.start)
+	t.seconds = Ptr(t.elapsed.Seconds())
+	resp.ExtraFields.TimeToFirstToken = t.seconds
+	return true
+}
+
+// chunkTimer records the arrival of the content chunks of a stream, for
+// BifrostRequest.CaptureStreamTiming. Chunks of a stream are processed sequentially, so it needs
+// no locking.
+type chunkTimer struct {
+	first, last time.Time
+	chunks      int
+	gaps        []time.Duration // Gaps between consecutive content chunks, sorted
+	maxGapAt    time.Duration   // Time from the first content chunk to the end of the longest gap
+}
+
+// observe records the chunk if it has content, and reports on it the timing of the content
+// chunks received so far, once there is one.
+func (t *chunkTimer) observe(resp *schemas.BifrostResponse) {
+	if resp == nil {
+		return
+	}
+	if hasStreamedContent(resp) {
+		now := time.Now()
+		if t.chunks > 0 {
+			gap := now.Sub(t.last)
+			if len(t.gaps) == 0 || gap > t.gaps[len(t.gaps)-1] {
+				t.maxGapAt = now.Sub(t.first)
+			}
+			index, _ := slices.BinarySearch(t.gaps, gap)
+			t.gaps = slices.Insert(t.gaps, index, gap)
+		} else {
+			t.first = now
+		}
+		t.last = now
+		t.chunks++
+	}
+	if t.chunks == 0 {
+		return
+	}
+
+	duration := t.last.Sub(t.first)
+	timing := &schemas.StreamTiming{
+		Chunks:   t.chunks,
+		Duration: duration.Seconds(),
+	}
+	if len(t.gaps) > 0 {
+		timing.MeanGap = duration.Seconds() / float64(len(t.gaps))
+		timing.P50Gap = gapPercentile(t.gaps, 0.50).Seconds()
+		timing.P95Gap = gapPercentile(t.gaps, 0.95).Seconds()
+		timing.MaxGap = t.gaps[len(t.gaps)-1].Seconds()
+		timing.MaxGapAt = t.maxGapAt.Seconds()
+	}
+	if duration > 0 {
+		timing.ChunksPerSecond = float64(len(t.gaps)) / duration.Seconds()
+		if resp.Usage != nil && resp.Usage.CompletionTokens > 0 {
+			timing.TokensPerSecond = float64(resp.Usage.CompletionTokens) / duration.Seconds()
+		}
+	}
+	resp.ExtraFields.StreamTiming = timing
+}
+
+// gapPercentile returns the nearest-rank percentile of sorted gaps, with p between 0 and 1.
+func gapPercentile(gaps []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(gaps)))) - 1
+	return gaps[max(rank, 0)]
+}
+
+// hasStreamedContent reports whether a stream chunk carries content: text, thoughts or tool call
+// arguments, audio, or transcribed text.
+func hasStreamedContent(resp *schemas.BifrostResponse) bool {
+	if streamedChars(resp) > 0 {
+		return true
+	}
+	if resp.Speech != nil && len(resp.Speech.Audio) > 0 {
+		return true
+	}
+	if resp.Transcribe != nil {
+		if resp.Transcribe.Text != "" {
+			return true
+		}
+		if resp.Transcribe.BifrostTranscribeStreamResponse != nil && resp.Transcribe.Delta != nil && *resp.Transcribe.Delta != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// recordFirstToken reports the time to first token of a stream to the OnFirstToken hook, if
+// configured. A panicking hook is recovered so that it cannot take down the stream.
+func (bifrost *Bifrost) recordFirstToken(req *ChannelMessage, elapsed time.Duration) {
+	if bifrost.onFirstToken == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			bifrost.logger.Warn(fmt.Sprintf("OnFirstToken hook panicked: %v", r))
+		}
+	}()
+
+	bifrost.onFirstToken(schemas.FirstTokenTiming{
+		Provider:         req.Provider,
+		Model:            req.Model,
+		RequestType:      string(req.Type),
+		TimeToFirstToken: elapsed,
+	})
+}
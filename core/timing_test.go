@@ -0,0 +1,220 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestQueueStatsAdd(t *testing.T) {
+	var stats queueStats
+	stats.add(schemas.RequestTiming{QueueWait: 10 * time.Millisecond, ServiceTime: 100 * time.Millisecond})
+	stats.add(schemas.RequestTiming{QueueWait: 30 * time.Millisecond, ServiceTime: 300 * time.Millisecond, Failed: true})
+
+	snapshot := stats.snapshot()
+	if snapshot.Requests != 2 || snapshot.Failed != 1 {
+		t.Errorf("requests = %d, failed = %d, want 2 and 1", snapshot.Requests, snapshot.Failed)
+	}
+	if got := snapshot.AverageQueueWait(); got != 20*time.Millisecond {
+		t.Errorf("AverageQueueWait() = %s, want 20ms", got)
+	}
+	if got := snapshot.AverageServiceTime(); got != 200*time.Millisecond {
+		t.Errorf("AverageServiceTime() = %s, want 200ms", got)
+	}
+	if snapshot.MaxQueueWait != 30*time.Millisecond || snapshot.MaxServiceTime != 300*time.Millisecond {
+		t.Errorf("max queue wait = %s, max service time = %s, want 30ms and 300ms", snapshot.MaxQueueWait, snapshot.MaxServiceTime)
+	}
+}
+
+func TestRequestTimingRecorded(t *testing.T) {
+	timings := make(chan schemas.RequestTiming, 1)
+	// The account has no keys, so the worker fails the request right after dequeuing it
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account:         &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI}},
+		Logger:          NewDefaultLogger(schemas.LogLevelError),
+		OnRequestTiming: func(timing schemas.RequestTiming) { timings <- timing },
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{{Role: schemas.ModelChatMessageRoleUser, Content: schemas.MessageContent{ContentStr: Ptr("Hello")}}}
+	if _, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	}); bifrostErr == nil {
+		t.Fatal("ChatCompletionRequest() succeeded without keys")
+	}
+
+	select {
+	case timing := <-timings:
+		if timing.Provider != schemas.OpenAI || timing.RequestType != string(ChatCompletionRequest) || !timing.Failed {
+			t.Errorf("timing = %+v, want a failed openai chat completion", timing)
+		}
+		if timing.QueueWait < 0 || timing.ServiceTime < 0 {
+			t.Errorf("timing = %+v, want non-negative durations", timing)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnRequestTiming was not called")
+	}
+
+	stats := bifrost.GetQueueStats()[schemas.OpenAI]
+	if stats.Requests != 1 || stats.Failed != 1 {
+		t.Errorf("queue stats = %+v, want one failed request", stats)
+	}
+}
+
+func TestTimeToFirstToken(t *testing.T) {
+	// The role chunk is sent right away, the content only after a delay
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" world\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	timings := make(chan schemas.FirstTokenTiming, 2)
+	account := &testAccount{
+		keys:   testKeys(),
+		config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account:      account,
+		Logger:       NewDefaultLogger(schemas.LogLevelError),
+		OnFirstToken: func(timing schemas.FirstTokenTiming) { timings <- timing },
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	stream, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+	}
+
+	var ttfts []float64
+	for chunk := range stream {
+		if chunk.BifrostError != nil {
+			t.Fatalf("stream error = %+v", chunk.BifrostError)
+		}
+		if chunk.ExtraFields.TimeToFirstToken != nil {
+			ttfts = append(ttfts, *chunk.ExtraFields.TimeToFirstToken)
+		}
+	}
+	if len(ttfts) != 2 || ttfts[0] < 0.1 || ttfts[1] != ttfts[0] {
+		t.Errorf("time to first token by chunk = %v, want the same value of at least 0.1s on both content chunks", ttfts)
+	}
+
+	select {
+	case timing := <-timings:
+		if timing.Provider != schemas.OpenAI || timing.RequestType != string(ChatCompletionStreamRequest) || timing.TimeToFirstToken < 100*time.Millisecond {
+			t.Errorf("timing = %+v, want an openai chat completion stream with a TTFT of at least 100ms", timing)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnFirstToken was not called")
+	}
+	if len(timings) != 0 {
+		t.Error("OnFirstToken was called more than once")
+	}
+}
+
+func TestStreamTiming(t *testing.T) {
+	// The stream stalls before its third content chunk
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"One\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" two\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(150 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" three\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\" four\"},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":4,\"total_tokens\":5}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	account := &testAccount{
+		keys:   testKeys(),
+		config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Count to four")}
+	stream := func(capture bool) []*schemas.StreamTiming {
+		chunks, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+			Provider:            schemas.OpenAI,
+			Model:               "gpt-4o",
+			Input:               schemas.RequestInput{ChatCompletionInput: &messages},
+			CaptureStreamTiming: capture,
+		})
+		if bifrostErr != nil {
+			t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+		}
+		var timings []*schemas.StreamTiming
+		for chunk := range chunks {
+			if chunk.BifrostError != nil {
+				t.Fatalf("stream error = %+v", chunk.BifrostError)
+			}
+			if chunk.BifrostResponse != nil {
+				timings = append(timings, chunk.ExtraFields.StreamTiming)
+			}
+		}
+		return timings
+	}
+
+	for i, timing := range stream(false) {
+		if timing != nil {
+			t.Errorf("chunk %d stream timing = %+v, want none when not captured", i, timing)
+		}
+	}
+
+	timings := stream(true)
+	if len(timings) != 4 || timings[0] == nil || timings[0].Chunks != 1 || timings[0].MaxGap != 0 {
+		t.Fatalf("stream timings = %+v, want a summary on each content chunk", timings)
+	}
+	// The client can read the chunk before the stall a little after it was flushed, so the measured
+	// stall may be shorter than the server's 150ms
+	last := timings[len(timings)-1]
+	if last.Chunks != 4 || last.Duration < 0.1 {
+		t.Errorf("final timing = %+v, want 4 chunks over at least 0.1s", last)
+	}
+	if last.MaxGap < 0.1 || last.MaxGapAt < last.MaxGap || last.P50Gap >= last.MaxGap {
+		t.Errorf("final timing = %+v, want the stall as the longest gap", last)
+	}
+	if last.TokensPerSecond <= 0 || last.TokensPerSecond > 4/0.1 {
+		t.Errorf("tokens per second = %v, want 4 completion tokens over the stream's duration", last.TokensPerSecond)
+	}
+}
+
+func TestGapPercentile(t *testing.T) {
+	gaps := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{{0, 1}, {0.5, 5}, {0.95, 10}, {1, 10}} {
+		if got := gapPercentile(gaps, tc.p); got != tc.want {
+			t.Errorf("gapPercentile(%v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+}
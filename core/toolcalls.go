@@ -0,0 +1,93 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// checkToolCallArguments reports the tool calls of a chat completion whose arguments are not valid
+// JSON as warnings in the response's extra fields, instead of returning them silently broken.
+// If the request has a TruncatedToolCallRetry and an invalid tool call was cut off by the token
+// limit, the request is retried once with the configured max_tokens, with the provider and model
+// that produced the response. If the retry fails, the original response is returned.
+func (bifrost *Bifrost) checkToolCallArguments(ctx context.Context, req *schemas.BifrostRequest, result *schemas.BifrostResponse) *schemas.BifrostResponse {
+	if result == nil {
+		return result
+	}
+
+	warnings, truncated := invalidToolCallWarnings(result)
+	if len(warnings) == 0 {
+		return result
+	}
+	result.ExtraFields.Warnings = append(result.ExtraFields.Warnings, warnings...)
+	bifrost.logger.Warn(fmt.Sprintf("Provider %s returned %d tool call(s) with invalid JSON arguments", result.ExtraFields.Provider, len(warnings)))
+
+	retry := req.TruncatedToolCallRetry
+	if !truncated || retry == nil || (req.Params != nil && req.Params.MaxTokens != nil && *req.Params.MaxTokens >= retry.MaxTokens) {
+		return result
+	}
+
+	retryReq := newContinuationRequest(req, result.ExtraFields)
+	params := schemas.ModelParameters{}
+	if req.Params != nil {
+		params = *req.Params
+	}
+	params.MaxTokens = Ptr(retry.MaxTokens)
+	retryReq.Params = &params
+
+	retried, bifrostErr := bifrost.handleRequest(ctx, retryReq, ChatCompletionRequest)
+	if bifrostErr != nil {
+		bifrost.logger.Warn(fmt.Sprintf("Retry of truncated tool call failed, returning original response: %s", bifrostErr.Error.Message))
+		return result
+	}
+
+	retried.ExtraFields.TruncationRetries = result.ExtraFields.TruncationRetries + 1
+	if warnings, _ := invalidToolCallWarnings(retried); len(warnings) > 0 {
+		retried.ExtraFields.Warnings = append(retried.ExtraFields.Warnings, warnings...)
+	}
+	addUsage(retried, result.Usage)
+	return retried
+}
+
+// invalidToolCallWarnings returns a warning for each tool call of the response whose arguments
+// are not valid JSON, and whether one of them is in a choice cut off by the token limit.
+// Empty arguments are valid, as some providers send them for tools without parameters.
+func invalidToolCallWarnings(result *schemas.BifrostResponse) ([]schemas.ResponseWarning, bool) {
+	var warnings []schemas.ResponseWarning
+	truncated := false
+
+	for _, choice := range result.Choices {
+		if choice.BifrostNonStreamResponseChoice == nil || choice.Message.AssistantMessage == nil || choice.Message.ToolCalls == nil {
+			continue
+		}
+
+		for _, toolCall := range *choice.Message.ToolCalls {
+			arguments := strings.TrimSpace(toolCall.Function.Arguments)
+			if arguments == "" || sonic.ValidString(arguments) {
+				continue
+			}
+
+			name := ""
+			if toolCall.Function.Name != nil {
+				name = *toolCall.Function.Name
+			}
+			warning := schemas.ResponseWarning{
+				Code:        schemas.ResponseWarningInvalidToolArguments,
+				Message:     fmt.Sprintf("arguments of tool call %q are not valid JSON", name),
+				ChoiceIndex: choice.Index,
+				ToolCallID:  toolCall.ID,
+			}
+			if choice.FinishReason != nil && isTokenLimitFinishReason(*choice.FinishReason) {
+				warning.Message += " (the response was cut off by the token limit)"
+				truncated = true
+			}
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings, truncated
+}
@@ -0,0 +1,127 @@
+package bifrost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// toolCallResponse returns a chat completion with a single tool call with the given arguments.
+func toolCallResponse(arguments string, finishReason string) *schemas.BifrostResponse {
+	return &schemas.BifrostResponse{
+		Choices: []schemas.BifrostResponseChoice{{
+			FinishReason: Ptr(finishReason),
+			BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+				Message: schemas.BifrostMessage{
+					Role: schemas.ModelChatMessageRoleAssistant,
+					AssistantMessage: &schemas.AssistantMessage{
+						ToolCalls: &[]schemas.ToolCall{{
+							ID:       Ptr("call_1"),
+							Function: schemas.FunctionCall{Name: Ptr("get_weather"), Arguments: arguments},
+						}},
+					},
+				},
+			},
+		}},
+		Usage: &schemas.LLMUsage{CompletionTokens: 10, TotalTokens: 10},
+	}
+}
+
+// maxTokensPlugin answers chat completions with a tool call whose arguments are cut off unless
+// max_tokens is at least 1000, and counts the requests it answered.
+type maxTokensPlugin struct {
+	requests int
+}
+
+func (p *maxTokensPlugin) GetName() string { return "max-tokens" }
+
+func (p *maxTokensPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	p.requests++
+	response := toolCallResponse(`{"city": "Par`, "length")
+	if req.Params != nil && req.Params.MaxTokens != nil && *req.Params.MaxTokens >= 1000 {
+		response = toolCallResponse(`{"city": "Paris"}`, "tool_calls")
+	}
+	response.ExtraFields.Provider = req.Provider
+	return req, &schemas.PluginShortCircuit{Response: response}, nil
+}
+
+func (p *maxTokensPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *maxTokensPlugin) Cleanup() error { return nil }
+
+func TestInvalidToolCallWarnings(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      *schemas.BifrostResponse
+		wantWarnings  int
+		wantTruncated bool
+	}{
+		{"valid arguments", toolCallResponse(`{"city": "Paris"}`, "tool_calls"), 0, false},
+		{"empty arguments", toolCallResponse("", "tool_calls"), 0, false},
+		{"cut off by the token limit", toolCallResponse(`{"city": "Par`, "length"), 1, true},
+		{"invalid without truncation", toolCallResponse(`{city: Paris}`, "tool_calls"), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, truncated := invalidToolCallWarnings(tt.response)
+			if len(warnings) != tt.wantWarnings || truncated != tt.wantTruncated {
+				t.Fatalf("invalidToolCallWarnings() = %+v, %v, want %d warnings, truncated %v", warnings, truncated, tt.wantWarnings, tt.wantTruncated)
+			}
+			if len(warnings) > 0 && (warnings[0].Code != schemas.ResponseWarningInvalidToolArguments || *warnings[0].ToolCallID != "call_1") {
+				t.Errorf("warning = %+v, want an invalid_tool_arguments warning for call_1", warnings[0])
+			}
+		})
+	}
+}
+
+func TestTruncatedToolCallRetry(t *testing.T) {
+	plugin := &maxTokensPlugin{}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("What is the weather in Paris?")}
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:   &schemas.ModelParameters{MaxTokens: Ptr(100)},
+	}
+
+	// Without a retry, the broken tool call is returned with a warning
+	result, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if len(result.ExtraFields.Warnings) != 1 || result.ExtraFields.TruncationRetries != 0 {
+		t.Fatalf("extra fields = %+v, want one warning and no retry", result.ExtraFields)
+	}
+
+	req.TruncatedToolCallRetry = &schemas.TruncatedToolCallRetryConfig{MaxTokens: 1000}
+	result, bifrostErr = bifrost.ChatCompletionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionRequest() error = %+v", bifrostErr)
+	}
+	if len(result.ExtraFields.Warnings) != 0 || result.ExtraFields.TruncationRetries != 1 {
+		t.Errorf("extra fields = %+v, want no warning after one retry", result.ExtraFields)
+	}
+	if result.Usage.CompletionTokens != 20 {
+		t.Errorf("completion tokens = %d, want the usage of both requests", result.Usage.CompletionTokens)
+	}
+	if *req.Params.MaxTokens != 100 {
+		t.Errorf("original request max_tokens = %d, want it unchanged", *req.Params.MaxTokens)
+	}
+	if plugin.requests != 3 {
+		t.Errorf("provider requests = %d, want 3", plugin.requests)
+	}
+}
@@ -0,0 +1,321 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// wavChunkHeaderSize is the size of the ID and size fields preceding each chunk of a WAV file.
+const wavChunkHeaderSize = 8
+
+const (
+	// silenceWindowsPerSecond sets the length of the windows over which the loudness of the audio
+	// is measured to find where to cut it: 10ms.
+	silenceWindowsPerSecond = 100
+	// silenceThreshold is the mean amplitude, as a fraction of full scale, below which a window
+	// counts as silence.
+	silenceThreshold = 0.01
+)
+
+// wavAudio is a WAV file split into its format chunk and PCM samples.
+type wavAudio struct {
+	format        []byte // Body of the "fmt " chunk
+	data          []byte // Body of the "data" chunk
+	byteRate      int    // Bytes of samples per second
+	blockAlign    int    // Bytes per sample frame, across all channels
+	bitsPerSample int    // Bits per sample of a channel
+}
+
+// handleChunkedTranscription implements TranscriptionChunking. The audio is split into segments
+// of SegmentSeconds that are transcribed in order, skipping the CompletedSegments of an earlier
+// run. A segment that fails is retried up to MaxSegmentRetries times before the request fails.
+func (bifrost *Bifrost) handleChunkedTranscription(ctx context.Context, req *schemas.BifrostRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if bifrostErr := validateRequest(req, TranscriptionRequest); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	config := req.TranscriptionChunking
+	if ctx == nil {
+		ctx = bifrost.backgroundCtx
+	}
+
+	audio, err := parseWAV(req.Input.TranscriptionInput.File)
+	if err != nil {
+		return nil, newBifrostErrorFromMsg(err.Error()) // Already checked by validateRequest
+	}
+	segments := audio.split(config.SegmentSeconds)
+	if len(config.CompletedSegments) > len(segments) {
+		return nil, newBifrostErrorFromMsg(fmt.Sprintf("transcription_chunking has %d completed segments, but the audio only has %d", len(config.CompletedSegments), len(segments)))
+	}
+
+	segmentReq := *req
+	segmentReq.TranscriptionChunking = nil
+
+	var result *schemas.BifrostResponse
+	transcribe := &schemas.BifrostTranscribe{BifrostTranscribeNonStreamResponse: &schemas.BifrostTranscribeNonStreamResponse{}}
+	var texts []string
+	offset := 0.0
+
+	for i, segment := range segments {
+		var segmentResult schemas.BifrostTranscribe
+		if i < len(config.CompletedSegments) {
+			segmentResult = config.CompletedSegments[i]
+		} else {
+			input := *req.Input.TranscriptionInput
+			input.File = audio.encode(segment)
+			segmentReq.Input = schemas.RequestInput{TranscriptionInput: &input}
+
+			response, bifrostErr := bifrost.transcribeSegment(ctx, &segmentReq, config.MaxSegmentRetries)
+			if bifrostErr != nil {
+				bifrostErr.Error.Message = fmt.Sprintf("segment %d of %d: %s", i+1, len(segments), bifrostErr.Error.Message)
+				return nil, bifrostErr
+			}
+			if result == nil {
+				result = response
+			}
+			if response.Transcribe != nil {
+				segmentResult = *response.Transcribe
+			}
+
+			if config.OnProgress != nil {
+				config.OnProgress(schemas.TranscriptionProgress{Segment: i, Segments: len(segments), Result: segmentResult})
+			}
+		}
+
+		appendTranscriptionSegment(transcribe, segmentResult, offset)
+		if text := strings.TrimSpace(segmentResult.Text); text != "" {
+			texts = append(texts, text)
+		}
+		offset += float64(len(segment)) / float64(audio.byteRate)
+	}
+
+	if result == nil {
+		// Every segment was already completed
+		result = &schemas.BifrostResponse{Model: req.Model, ExtraFields: schemas.BifrostResponseExtraFields{Provider: req.Provider}}
+	}
+	transcribe.Text = strings.Join(texts, " ")
+	transcribe.Duration = &offset
+	result.Transcribe = transcribe
+	return result, nil
+}
+
+// validateTranscriptionChunking checks the TranscriptionChunking of a request of the given type,
+// for validateRequest. Only WAV (PCM) audio can be split into segments.
+func validateTranscriptionChunking(req *schemas.BifrostRequest, requestType RequestType) *schemas.BifrostError {
+	config := req.TranscriptionChunking
+	if requestType != TranscriptionRequest {
+		return newValidationError("TranscriptionChunking", fmt.Sprintf("cannot be set on a %s request", requestType))
+	}
+	if config.SegmentSeconds <= 0 {
+		return newValidationError("TranscriptionChunking.SegmentSeconds", fmt.Sprintf("must be positive, got %d", config.SegmentSeconds))
+	}
+	if config.MaxSegmentRetries < 0 {
+		return newValidationError("TranscriptionChunking.MaxSegmentRetries", fmt.Sprintf("must be non-negative, got %d", config.MaxSegmentRetries))
+	}
+	if _, err := parseWAV(req.Input.TranscriptionInput.File); err != nil {
+		return newValidationError("Input.TranscriptionInput.File", fmt.Sprintf("only WAV (PCM) audio can be chunked: %v", err))
+	}
+	return nil
+}
+
+// transcribeSegment transcribes a segment of a chunked transcription, retrying it up to
+// maxRetries times. Errors that do not allow fallbacks (e.g. a cancelled request) are not retried.
+func (bifrost *Bifrost) transcribeSegment(ctx context.Context, req *schemas.BifrostRequest, maxRetries int) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	var bifrostErr *schemas.BifrostError
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		var response *schemas.BifrostResponse
+		response, bifrostErr = bifrost.handleRequest(ctx, req, TranscriptionRequest)
+		if bifrostErr == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil || (bifrostErr.AllowFallbacks != nil && !*bifrostErr.AllowFallbacks) {
+			break
+		}
+		bifrost.logger.Warn(fmt.Sprintf("Transcription segment failed (attempt %d of %d): %s", attempt+1, maxRetries+1, bifrostErr.Error.Message))
+	}
+	return nil, bifrostErr
+}
+
+// appendTranscriptionSegment adds the words, segments and usage of a segment's transcription to
+// the stitched transcription, shifting its timestamps by the segment's offset in seconds.
+func appendTranscriptionSegment(transcribe *schemas.BifrostTranscribe, segment schemas.BifrostTranscribe, offset float64) {
+	if segment.BifrostTranscribeNonStreamResponse != nil {
+		if transcribe.Language == nil {
+			transcribe.Language = segment.Language
+		}
+		if transcribe.Task == nil {
+			transcribe.Task = segment.Task
+		}
+		for _, word := range segment.Words {
+			word.Start += offset
+			word.End += offset
+			transcribe.Words = append(transcribe.Words, word)
+		}
+		for _, s := range segment.Segments {
+			s.ID = len(transcribe.Segments)
+			s.Start += offset
+			s.End += offset
+			transcribe.Segments = append(transcribe.Segments, s)
+		}
+	}
+	transcribe.LogProbs = append(transcribe.LogProbs, segment.LogProbs...)
+
+	if segment.Usage == nil {
+		return
+	}
+	if transcribe.Usage == nil {
+		transcribe.Usage = &schemas.TranscriptionUsage{Type: segment.Usage.Type}
+	}
+	transcribe.Usage.InputTokens = addIntPtr(transcribe.Usage.InputTokens, segment.Usage.InputTokens)
+	transcribe.Usage.OutputTokens = addIntPtr(transcribe.Usage.OutputTokens, segment.Usage.OutputTokens)
+	transcribe.Usage.TotalTokens = addIntPtr(transcribe.Usage.TotalTokens, segment.Usage.TotalTokens)
+	transcribe.Usage.Seconds = addIntPtr(transcribe.Usage.Seconds, segment.Usage.Seconds)
+}
+
+// addIntPtr returns the sum of two optional counts, nil if both are nil.
+func addIntPtr(a, b *int) *int {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		return Ptr(*b)
+	}
+	return Ptr(*a + *b)
+}
+
+// parseWAV reads the format and data chunks of a RIFF WAV file with PCM samples.
+func parseWAV(file []byte) (*wavAudio, error) {
+	if len(file) < 12 || string(file[0:4]) != "RIFF" || string(file[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF WAVE file")
+	}
+
+	audio := &wavAudio{}
+	for pos := 12; pos+wavChunkHeaderSize <= len(file); {
+		id := string(file[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(file[pos+4 : pos+8]))
+		body := file[pos+wavChunkHeaderSize:]
+		if size > len(body) {
+			if id != "data" {
+				return nil, fmt.Errorf("chunk %q is truncated", id)
+			}
+			size = len(body) // Streamed WAV files may have a placeholder data size
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("format chunk is too short")
+			}
+			audio.format = body
+			audio.byteRate = int(binary.LittleEndian.Uint32(body[8:12]))
+			audio.blockAlign = int(binary.LittleEndian.Uint16(body[12:14]))
+			audio.bitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+		case "data":
+			audio.data = body
+		}
+		pos += wavChunkHeaderSize + size + size%2 // Chunks are padded to an even size
+	}
+
+	if audio.format == nil || audio.data == nil {
+		return nil, fmt.Errorf("missing format or data chunk")
+	}
+	if audio.byteRate <= 0 || audio.blockAlign <= 0 {
+		return nil, fmt.Errorf("invalid byte rate or block alignment")
+	}
+	return audio, nil
+}
+
+// split returns the samples of the audio in segments of at most the given number of seconds,
+// cut on sample frames. Unless the audio is already silent at the end of a segment, the segment is
+// cut at the quietest point of its last quarter instead, so that words are not split in two.
+func (audio *wavAudio) split(seconds int) [][]byte {
+	segmentFrames := max(audio.byteRate*seconds/audio.blockAlign, 1)
+	segmentSize := segmentFrames * audio.blockAlign
+	searchSize := segmentFrames / 4 * audio.blockAlign
+
+	var segments [][]byte
+	for start := 0; start < len(audio.data); {
+		end := min(start+segmentSize, len(audio.data))
+		if end < len(audio.data) && searchSize > 0 {
+			end = audio.quietestCut(end-searchSize, end)
+		}
+		segments = append(segments, audio.data[start:end])
+		start = end
+	}
+	return segments
+}
+
+// quietestCut returns the offset in the samples between from (excluded) and to (included) where
+// the audio is the quietest, on a frame boundary. It returns to if the audio is silent there, or
+// if the loudness of the samples cannot be measured.
+func (audio *wavAudio) quietestCut(from, to int) int {
+	window := max(audio.byteRate/silenceWindowsPerSecond/audio.blockAlign, 1) * audio.blockAlign
+	half := max(window/2/audio.blockAlign, 1) * audio.blockAlign
+
+	quietest, ok := audio.loudness(to-half, to+half)
+	if !ok || quietest < silenceThreshold {
+		return to
+	}
+	cut := to
+	for candidate := to - window; candidate > from; candidate -= window {
+		if loudness, _ := audio.loudness(candidate-half, candidate+half); loudness < quietest {
+			cut, quietest = candidate, loudness
+		}
+	}
+	return cut
+}
+
+// loudness returns the mean amplitude of the samples between the frame-aligned offsets from and
+// to, as a fraction of full scale. Only 8 and 16-bit PCM samples can be measured.
+func (audio *wavAudio) loudness(from, to int) (float64, bool) {
+	samples := audio.data[max(from, 0):min(to, len(audio.data))]
+
+	var total float64
+	var count int
+	switch audio.bitsPerSample {
+	case 8:
+		for _, sample := range samples {
+			total += math.Abs(float64(int(sample)-128)) / 128
+		}
+		count = len(samples)
+	case 16:
+		for i := 0; i+1 < len(samples); i += 2 {
+			total += math.Abs(float64(int16(binary.LittleEndian.Uint16(samples[i:])))) / 32768
+		}
+		count = len(samples) / 2
+	default:
+		return 0, false
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return total / float64(count), true
+}
+
+// encode returns a WAV file with the audio's format and the given samples.
+func (audio *wavAudio) encode(samples []byte) []byte {
+	var buf bytes.Buffer
+	size := 4 + wavChunkHeaderSize + len(audio.format) + len(audio.format)%2 + wavChunkHeaderSize + len(samples) + len(samples)%2
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(size))
+	buf.WriteString("WAVE")
+	for _, chunk := range []struct {
+		id   string
+		body []byte
+	}{{"fmt ", audio.format}, {"data", samples}} {
+		buf.WriteString(chunk.id)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(chunk.body)))
+		buf.Write(chunk.body)
+		if len(chunk.body)%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,187 @@
+package bifrost
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// testWAV returns a mono 16-bit WAV file of the given number of seconds at 8 kHz, in which
+// each second of samples is filled with the number of the second.
+func testWAV(seconds int) []byte {
+	const sampleRate = 8000
+	samples := make([]byte, 0, seconds*sampleRate*2)
+	for second := range seconds {
+		samples = append(samples, bytes.Repeat([]byte{byte(second), 0}, sampleRate)...)
+	}
+	return (&wavAudio{format: testWAVFormat(sampleRate)}).encode(samples)
+}
+
+// testWAVFormat returns the format chunk of mono 16-bit PCM audio at the given sample rate.
+func testWAVFormat(sampleRate int) []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1) // PCM
+	binary.LittleEndian.PutUint16(format[2:], 1) // Mono
+	binary.LittleEndian.PutUint32(format[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:], uint32(sampleRate*2))
+	binary.LittleEndian.PutUint16(format[12:], 2)
+	binary.LittleEndian.PutUint16(format[14:], 16)
+	return format
+}
+
+// segmentPlugin transcribes each segment as the numbers of the seconds it contains, and fails
+// the first attempt of the segment starting at failSecond.
+type segmentPlugin struct {
+	failSecond int
+	failed     bool
+	requests   int
+}
+
+func (p *segmentPlugin) GetName() string { return "segment" }
+
+func (p *segmentPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	p.requests++
+	audio, err := parseWAV(req.Input.TranscriptionInput.File)
+	if err != nil {
+		return req, &schemas.PluginShortCircuit{Error: newBifrostErrorFromMsg(err.Error())}, nil
+	}
+	first := int(audio.data[0])
+	if first == p.failSecond && !p.failed {
+		p.failed = true
+		return req, &schemas.PluginShortCircuit{Error: newBifrostErrorFromMsg("connection reset")}, nil
+	}
+
+	text := ""
+	for start := 0; start < len(audio.data); start += audio.byteRate {
+		text += fmt.Sprintf(" %d", audio.data[start])
+	}
+	seconds := float64(len(audio.data)) / float64(audio.byteRate)
+	return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{
+		Transcribe: &schemas.BifrostTranscribe{
+			Text:  text,
+			Usage: &schemas.TranscriptionUsage{Type: "duration", Seconds: Ptr(int(seconds))},
+			BifrostTranscribeNonStreamResponse: &schemas.BifrostTranscribeNonStreamResponse{
+				Segments: []schemas.TranscriptionSegment{{ID: 0, Start: 0, End: seconds, Text: text}},
+			},
+		},
+	}}, nil
+}
+
+func (p *segmentPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+
+func (p *segmentPlugin) Cleanup() error { return nil }
+
+func TestChunkedTranscription(t *testing.T) {
+	plugin := &segmentPlugin{failSecond: 2}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI}},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	var progress []schemas.TranscriptionProgress
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "whisper-1",
+		Input:    schemas.RequestInput{TranscriptionInput: &schemas.TranscriptionInput{File: testWAV(5)}},
+		TranscriptionChunking: &schemas.TranscriptionChunkingConfig{
+			SegmentSeconds:    2,
+			MaxSegmentRetries: 1,
+			OnProgress:        func(p schemas.TranscriptionProgress) { progress = append(progress, p) },
+		},
+	}
+
+	result, bifrostErr := bifrost.TranscriptionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("TranscriptionRequest() error = %+v", bifrostErr)
+	}
+	transcribe := result.Transcribe
+	if transcribe.Text != "0 1 2 3 4" || *transcribe.Duration != 5 || *transcribe.Usage.Seconds != 5 {
+		t.Errorf("transcription = %q over %vs, usage %ds, want \"0 1 2 3 4\" over 5s, usage 5s", transcribe.Text, *transcribe.Duration, *transcribe.Usage.Seconds)
+	}
+	if len(transcribe.Segments) != 3 || transcribe.Segments[2].ID != 2 || transcribe.Segments[2].Start != 4 || transcribe.Segments[2].End != 5 {
+		t.Errorf("segments = %+v, want 3 segments with shifted timestamps", transcribe.Segments)
+	}
+	if len(progress) != 3 || progress[1].Segment != 1 || progress[1].Segments != 3 {
+		t.Errorf("progress = %+v, want one report per segment", progress)
+	}
+	if plugin.requests != 4 {
+		t.Errorf("got %d requests, want 4 (one retry of the failed segment)", plugin.requests)
+	}
+
+	// Resuming skips the completed segments
+	plugin.requests = 0
+	req.TranscriptionChunking.CompletedSegments = []schemas.BifrostTranscribe{progress[0].Result, progress[1].Result}
+	result, bifrostErr = bifrost.TranscriptionRequest(context.Background(), req)
+	if bifrostErr != nil {
+		t.Fatalf("resumed TranscriptionRequest() error = %+v", bifrostErr)
+	}
+	if result.Transcribe.Text != "0 1 2 3 4" || plugin.requests != 1 {
+		t.Errorf("resumed transcription = %q after %d requests, want \"0 1 2 3 4\" after 1 request", result.Transcribe.Text, plugin.requests)
+	}
+}
+
+func TestChunkedTranscriptionRequiresWAV(t *testing.T) {
+	req := &schemas.BifrostRequest{
+		Provider:              schemas.OpenAI,
+		Model:                 "whisper-1",
+		Input:                 schemas.RequestInput{TranscriptionInput: &schemas.TranscriptionInput{File: []byte("ID3 mp3 audio")}},
+		TranscriptionChunking: &schemas.TranscriptionChunkingConfig{SegmentSeconds: 60},
+	}
+	bifrostErr := validateRequest(req, TranscriptionRequest)
+	if bifrostErr == nil || bifrostErr.Error.Param != "Input.TranscriptionInput.File" {
+		t.Fatalf("validateRequest() error = %+v, want non-WAV audio rejected", bifrostErr)
+	}
+
+	bifrost := newTestBifrost(nil)
+	if _, bifrostErr := bifrost.handleChunkedTranscription(context.Background(), req); bifrostErr == nil {
+		t.Fatal("handleChunkedTranscription() accepted non-WAV audio")
+	}
+}
+
+func TestWAVSplitCutsAtSilence(t *testing.T) {
+	// 5 seconds of loud audio at 8 kHz, silent for 20ms from 1.70s
+	const sampleRate = 8000
+	samples := make([]byte, 0, 5*sampleRate*2)
+	for i := range 5 * sampleRate {
+		sample := int16(16000)
+		if i%2 == 1 {
+			sample = -16000
+		}
+		if i >= sampleRate*170/100 && i < sampleRate*172/100 {
+			sample = 0
+		}
+		samples = binary.LittleEndian.AppendUint16(samples, uint16(sample))
+	}
+	audio, err := parseWAV((&wavAudio{format: testWAVFormat(sampleRate)}).encode(samples))
+	if err != nil {
+		t.Fatalf("parseWAV() error = %v", err)
+	}
+
+	segments := audio.split(2)
+	if len(segments) != 3 {
+		t.Fatalf("got %d segments, want 3", len(segments))
+	}
+	// The first segment ends in the silence rather than at 2s; the others fill the rest
+	if end := float64(len(segments[0])) / float64(audio.byteRate); end < 1.70 || end > 1.72 {
+		t.Errorf("first segment ends at %vs, want within the silence at 1.70s", end)
+	}
+	if total := len(segments[0]) + len(segments[1]) + len(segments[2]); total != len(samples) {
+		t.Errorf("segments hold %d bytes, want %d", total, len(samples))
+	}
+	for i, segment := range segments {
+		if len(segment)%audio.blockAlign != 0 {
+			t.Errorf("segment %d is not cut on a sample frame", i)
+		}
+	}
+}
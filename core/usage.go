@@ -0,0 +1,215 @@
+package bifrost
+
+import (
+	"context"
+	"unicode/utf8"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+// streamUsageTracker keeps running token counts for a chat completion stream and reports them
+// through the request's OnUsageUpdate callback, if any. Chunks of a stream are processed
+// sequentially, so it needs no locking.
+type streamUsageTracker struct {
+	onUpdate        func(schemas.UsageUpdate) // nil if the request has no OnUsageUpdate callback
+	promptTokens    int                       // estimated from the request messages
+	completionChars int                       // length of the text streamed so far
+	usage           schemas.LLMUsage          // running usage, as last reported
+	final           bool                      // true once the provider has reported its own usage
+	finished        bool                      // true once the provider has finished the generation
+}
+
+// newStreamUsageTracker returns a tracker for the request.
+func newStreamUsageTracker(req *schemas.BifrostRequest) *streamUsageTracker {
+	tracker := &streamUsageTracker{onUpdate: req.OnUsageUpdate}
+	if req.Input.ChatCompletionInput != nil {
+		promptChars := 0
+		for _, message := range *req.Input.ChatCompletionInput {
+			promptChars += len(messageText(message))
+		}
+		tracker.promptTokens = estimateTokenCount(promptChars)
+	}
+
+	return tracker
+}
+
+// update accounts for a stream chunk and reports the running usage.
+func (t *streamUsageTracker) update(resp *schemas.BifrostResponse) {
+	if t == nil || resp == nil {
+		return
+	}
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != nil {
+			t.finished = true
+		}
+	}
+	if t.final {
+		return
+	}
+
+	// The provider's usage is authoritative and replaces the running estimate
+	if resp.Usage != nil {
+		t.final = true
+		t.finished = true
+		t.usage = *resp.Usage
+		t.report()
+		return
+	}
+
+	t.completionChars += streamedChars(resp)
+
+	completionTokens := estimateTokenCount(t.completionChars)
+	t.usage = schemas.LLMUsage{
+		PromptTokens:     t.promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      t.promptTokens + completionTokens,
+	}
+	t.report()
+}
+
+// report passes the running usage to the OnUsageUpdate callback.
+func (t *streamUsageTracker) report() {
+	if t.onUpdate != nil {
+		t.onUpdate(schemas.UsageUpdate{Usage: t.usage, Estimated: !t.final})
+	}
+}
+
+// streamedChars returns the length of the text streamed in a chunk: content, thoughts and tool call arguments.
+func streamedChars(resp *schemas.BifrostResponse) int {
+	chars := 0
+	for _, choice := range resp.Choices {
+		if choice.BifrostStreamResponseChoice == nil {
+			continue
+		}
+		delta := choice.Delta
+		if delta.Content != nil {
+			chars += len(*delta.Content)
+		}
+		if delta.Thought != nil {
+			chars += len(*delta.Thought)
+		}
+		for _, toolCall := range delta.ToolCalls {
+			chars += len(toolCall.Function.Arguments)
+		}
+	}
+	return chars
+}
+
+// capStreamOutputTokens forwards the chunks of a chat completion stream until maxTokens output
+// tokens (estimated with estimateTokenCount) were streamed. The chunk that reaches the cap has its
+// content and thoughts cut to fit and its finish reason set to FinishReasonLength, then the
+// provider stream is stopped with cancel, which must not cancel ctx. Tool call arguments are
+// counted but never cut, as partial arguments could not be parsed.
+//
+// Providers report their token counts only with their usage, at the end of the stream, so the cap
+// is enforced on the estimate. A chunk reporting the provider's usage after the cap is still
+// forwarded, without its choices, so that the caller gets the usage of the partial generation if
+// the provider sent it before being stopped.
+func capStreamOutputTokens(ctx context.Context, cancel context.CancelFunc, stream chan *schemas.BifrostStream, maxTokens int) chan *schemas.BifrostStream {
+	forwarded := make(chan *schemas.BifrostStream, cap(stream))
+	remainingChars := maxTokens * 4 // inverse of estimateTokenCount
+
+	go func() {
+		defer close(forwarded)
+		defer func() {
+			cancel()
+			// Drain the provider stream, so that its goroutine is never blocked on a send
+			for range stream {
+			}
+		}()
+
+		capped := false
+		for chunk := range stream {
+			resp := chunk.BifrostResponse
+			switch {
+			case capped:
+				if resp == nil || resp.Usage == nil {
+					continue
+				}
+				resp.Choices = nil
+			case resp != nil && chunk.BifrostError == nil:
+				chars := streamedChars(resp)
+				if chars >= remainingChars {
+					cutStreamChunk(resp, remainingChars)
+					capped = true
+				}
+				remainingChars -= chars
+			}
+
+			select {
+			case forwarded <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			if capped {
+				if resp != nil && resp.Usage != nil {
+					return
+				}
+				cancel()
+			}
+		}
+	}()
+
+	return forwarded
+}
+
+// cutStreamChunk cuts the content and thoughts of a chunk to at most maxChars in total, and
+// marks its choices as finished because of the length limit.
+func cutStreamChunk(resp *schemas.BifrostResponse, maxChars int) {
+	cut := func(text *string) *string {
+		if text == nil {
+			return nil
+		}
+		if len(*text) > maxChars {
+			text = Ptr(truncateUTF8(*text, maxChars))
+		}
+		maxChars -= len(*text)
+		return text
+	}
+
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		if choice.BifrostStreamResponseChoice != nil {
+			choice.Delta.Thought = cut(choice.Delta.Thought)
+			choice.Delta.Content = cut(choice.Delta.Content)
+		}
+		choice.FinishReason = Ptr(schemas.FinishReasonLength)
+	}
+}
+
+// truncateUTF8 returns the longest prefix of text of at most maxBytes bytes that does not split a character.
+func truncateUTF8(text string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	if len(text) <= maxBytes {
+		return text
+	}
+	for maxBytes > 0 && !utf8.RuneStart(text[maxBytes]) {
+		maxBytes--
+	}
+	return text[:maxBytes]
+}
+
+// estimateTokenCount approximates a token count from a character count at 1 token per
+// 4 characters, the same rough heuristic providers use when a model reports no usage.
+func estimateTokenCount(chars int) int {
+	return chars / 4
+}
+
+// messageText returns the concatenated text content of a message.
+func messageText(message schemas.BifrostMessage) string {
+	if message.Content.ContentStr != nil {
+		return *message.Content.ContentStr
+	}
+
+	var text string
+	if message.Content.ContentBlocks != nil {
+		for _, block := range *message.Content.ContentBlocks {
+			if block.Type == schemas.ContentBlockTypeText && block.Text != nil {
+				text += *block.Text
+			}
+		}
+	}
+	return text
+}
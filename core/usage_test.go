@@ -0,0 +1,214 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func streamChunk(content string) *schemas.BifrostStream {
+	return &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{
+		Choices: []schemas.BifrostResponseChoice{{
+			BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{
+				Delta: schemas.BifrostStreamDelta{Content: &content},
+			},
+		}},
+	}}
+}
+
+func TestCapStreamOutputTokens(t *testing.T) {
+	stream := make(chan *schemas.BifrostStream, 4)
+	stream <- streamChunk("12345678")     // 2 tokens
+	stream <- streamChunk("abcdefghijkl") // 3 tokens, crosses the cap of 4
+	stream <- streamChunk("never sent")
+	close(stream)
+
+	providerCtx, cancel := context.WithCancel(context.Background())
+	var contents []string
+	var finishReason *string
+	for chunk := range capStreamOutputTokens(context.Background(), cancel, stream, 4) {
+		choice := chunk.BifrostResponse.Choices[0]
+		contents = append(contents, *choice.Delta.Content)
+		finishReason = choice.FinishReason
+	}
+
+	if len(contents) != 2 || contents[0] != "12345678" || contents[1] != "abcdefgh" {
+		t.Errorf("contents = %q, want [12345678 abcdefgh]", contents)
+	}
+	if finishReason == nil || *finishReason != schemas.FinishReasonLength {
+		t.Errorf("finish reason of the last chunk = %v, want %q", finishReason, schemas.FinishReasonLength)
+	}
+	if providerCtx.Err() == nil {
+		t.Error("provider stream context was not cancelled")
+	}
+}
+
+func TestCapStreamOutputTokensForwardsUsage(t *testing.T) {
+	usage := &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{
+		Choices: streamChunk("after the cap").Choices,
+		Usage:   &schemas.LLMUsage{PromptTokens: 5, CompletionTokens: 6, TotalTokens: 11},
+	}}
+	stream := make(chan *schemas.BifrostStream, 4)
+	stream <- streamChunk("abcdefghijkl") // crosses the cap of 2
+	stream <- streamChunk("never sent")
+	stream <- usage
+	close(stream)
+
+	_, cancel := context.WithCancel(context.Background())
+	var chunks []*schemas.BifrostStream
+	for chunk := range capStreamOutputTokens(context.Background(), cancel, stream, 2) {
+		chunks = append(chunks, chunk)
+	}
+
+	// The usage chunk is forwarded without the content streamed after the cap
+	if len(chunks) != 2 || *chunks[0].Choices[0].Delta.Content != "abcdefgh" {
+		t.Fatalf("got %d chunks, want the cut chunk and the usage", len(chunks))
+	}
+	if last := chunks[1]; last.Usage == nil || last.Usage.CompletionTokens != 6 || len(last.Choices) != 0 {
+		t.Errorf("last chunk = %+v, want the provider's usage without choices", last.BifrostResponse)
+	}
+}
+
+// streamContentPlugin records the content of the stream chunks its post-hook sees.
+type streamContentPlugin struct {
+	content string
+}
+
+func (p *streamContentPlugin) GetName() string { return "stream-content" }
+
+func (p *streamContentPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	return req, nil, nil
+}
+
+func (p *streamContentPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	if result != nil {
+		for _, choice := range result.Choices {
+			if choice.BifrostStreamResponseChoice != nil && choice.Delta.Content != nil {
+				p.content += *choice.Delta.Content
+			}
+		}
+	}
+	return result, err, nil
+}
+
+func (p *streamContentPlugin) Cleanup() error { return nil }
+
+func TestStreamMaxOutputTokensAppliesBeforePostHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"12345678", "abcdefghijkl", "never sent"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	plugin := &streamContentPlugin{}
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{keys: testKeys(), config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5})},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	stream, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:              schemas.OpenAI,
+		Model:                 "gpt-4o",
+		Input:                 schemas.RequestInput{ChatCompletionInput: &messages},
+		StreamMaxOutputTokens: 4,
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+	}
+	var content string
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-stream:
+			if !ok {
+				done = true
+				break
+			}
+			for _, choice := range chunk.Choices {
+				if choice.BifrostStreamResponseChoice != nil && choice.Delta.Content != nil {
+					content += *choice.Delta.Content
+				}
+			}
+		case <-timeout:
+			t.Fatal("stream was not closed")
+		}
+	}
+
+	// Plugins see the chunks as they are returned, cut at the cap
+	if content != "12345678abcdefgh" || plugin.content != content {
+		t.Errorf("content = %q, seen by plugins %q, want %q for both", content, plugin.content, "12345678abcdefgh")
+	}
+}
+
+func TestOnUsageUpdateReportsRunningUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"12345678", "abcdefgh"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":5,\"total_tokens\":8}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{keys: testKeys(), config: networkConfig(schemas.NetworkConfig{BaseURL: server.URL, DefaultRequestTimeoutInSeconds: 5})},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	var updates []schemas.UsageUpdate
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello world!")} // 3 tokens
+	stream, bifrostErr := bifrost.ChatCompletionStreamRequest(context.Background(), &schemas.BifrostRequest{
+		Provider:      schemas.OpenAI,
+		Model:         "gpt-4o",
+		Input:         schemas.RequestInput{ChatCompletionInput: &messages},
+		OnUsageUpdate: func(update schemas.UsageUpdate) { updates = append(updates, update) },
+	})
+	if bifrostErr != nil {
+		t.Fatalf("ChatCompletionStreamRequest() error = %+v", bifrostErr)
+	}
+	for range stream {
+	}
+
+	// Each content chunk adds to the estimate, then the provider's usage replaces it
+	want := []schemas.UsageUpdate{
+		{Usage: schemas.LLMUsage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}, Estimated: true},
+		{Usage: schemas.LLMUsage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7}, Estimated: true},
+		{Usage: schemas.LLMUsage{PromptTokens: 3, CompletionTokens: 5, TotalTokens: 8}},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("updates = %+v, want %+v", updates, want)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("héllo", 2); got != "h" {
+		t.Errorf("truncateUTF8() = %q, want %q", got, "h")
+	}
+	if got := truncateUTF8("hello", 10); got != "hello" {
+		t.Errorf("truncateUTF8() = %q, want %q", got, "hello")
+	}
+}
@@ -1,7 +1,16 @@
 package bifrost
 
 import (
+	"context"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
 	"math/rand"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
 	"time"
 
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -17,37 +26,544 @@ func providerRequiresKey(providerKey schemas.ModelProvider) bool {
 	return providerKey != schemas.Ollama && providerKey != schemas.SGL
 }
 
+// stopOnToolCallParams returns the parameters to send to the provider for a request with
+// StopOnToolCall set: parallel tool calls are disabled, so that the model makes a single tool
+// call per response. The caller's parameters are not modified.
+func stopOnToolCallParams(params *schemas.ModelParameters) *schemas.ModelParameters {
+	if params == nil || !params.StopOnToolCall || params.ParallelToolCalls != nil {
+		return params
+	}
+	paramsCopy := *params
+	paramsCopy.ParallelToolCalls = Ptr(false)
+	return &paramsCopy
+}
+
+// modelCapabilities returns the features a provider supports for a model, see
+// schemas.ModelCapabilitiesProvider.
+func modelCapabilities(provider schemas.Provider, model string) schemas.ProviderCapabilities {
+	if modelProvider, ok := provider.(schemas.ModelCapabilitiesProvider); ok {
+		return modelProvider.ModelCapabilities(model)
+	}
+	return provider.Capabilities()
+}
+
+// unsupportedParam returns the name of the first parameter set in params that a provider with
+// the given capabilities does not support, or "" if it supports all of them.
+func unsupportedParam(params *schemas.ModelParameters, capabilities schemas.ProviderCapabilities) string {
+	if params == nil {
+		return ""
+	}
+	if len(params.LogitBias) > 0 && !capabilities.LogitBias {
+		return "logit_bias"
+	}
+	if params.ParallelToolCalls != nil && !capabilities.ParallelToolCalls {
+		return "parallel_tool_calls"
+	}
+	if params.StopOnToolCall && !capabilities.ParallelToolCalls {
+		return "stop_on_tool_call"
+	}
+	return ""
+}
+
 func isStreamRequestType(reqType RequestType) bool {
 	return reqType == ChatCompletionStreamRequest || reqType == SpeechStreamRequest || reqType == TranscriptionStreamRequest
 }
 
-// calculateBackoff implements exponential backoff with jitter for retry attempts.
-func calculateBackoff(attempt int, config *schemas.ProviderConfig) time.Duration {
-	// Calculate an exponential backoff: initial * 2^attempt
-	backoff := min(config.NetworkConfig.RetryBackoffInitial*time.Duration(1<<uint(attempt)), config.NetworkConfig.RetryBackoffMax)
+// ComputeBackoff returns the delay Bifrost waits before retry number attempt (starting at 0)
+// of a provider request: RetryBackoffInitial doubled for each previous retry, capped at
+// RetryBackoffMax, with ±20% jitter. The jittered delay never exceeds RetryBackoffMax, and
+// high attempt counts do not overflow. It can be reused by retry wrappers around Bifrost to
+// back off the same way.
+func ComputeBackoff(attempt int, config schemas.NetworkConfig) time.Duration {
+	return backoffWithJitter(attempt, config, rand.Float64())
+}
+
+// backoffWithJitter computes the backoff of ComputeBackoff for a jitter value in [0, 1),
+// where 0 is the -20% bound and 1 the +20% bound.
+func backoffWithJitter(attempt int, config schemas.NetworkConfig, jitter float64) time.Duration {
+	initial, maxBackoff := config.RetryBackoffInitial, config.RetryBackoffMax
+	if initial <= 0 || maxBackoff <= 0 {
+		return 0
+	}
+
+	// Calculate an exponential backoff: initial * 2^attempt, doubling step by step so that
+	// the delay saturates at the maximum instead of overflowing
+	backoff := min(initial, maxBackoff)
+	for i := 0; i < attempt && backoff < maxBackoff; i++ {
+		if backoff > maxBackoff/2 {
+			backoff = maxBackoff
+			break
+		}
+		backoff *= 2
+	}
+
+	// Add jitter (±20%), comparing in floating point as the jittered delay may not fit in a Duration
+	jittered := float64(backoff) * (0.8 + 0.4*jitter)
+	if jittered >= float64(maxBackoff) {
+		return maxBackoff
+	}
+
+	return time.Duration(jittered)
+}
+
+// isModelNotFoundError reports whether a provider error says that the requested model does not
+// exist, i.e. has the schemas.ErrorCodeModelNotFound code that providers set on such errors.
+func isModelNotFoundError(bifrostError *schemas.BifrostError) bool {
+	return bifrostError != nil && !bifrostError.IsBifrostError &&
+		bifrostError.Error.Code != nil && *bifrostError.Error.Code == schemas.ErrorCodeModelNotFound
+}
+
+// isRetryableStatusCode reports whether a status code should be retried, consulting the
+// default retryable set and any additional codes configured for the provider.
+func isRetryableStatusCode(statusCode int, config *schemas.ProviderConfig) bool {
+	return retryableStatusCodes[statusCode] || slices.Contains(config.NetworkConfig.RetryableStatusCodes, statusCode)
+}
+
+// retryClass classifies a failed provider attempt for the retry policy.
+type retryClass int
+
+const (
+	retryNone       retryClass = iota // The error is not retried
+	retryConnection                   // Transport error before a response was received
+	retryRequest                      // Response with a retryable HTTP status code
+)
+
+// classifyRetry reports whether a failed attempt should be retried, and against which retry budget.
+func classifyRetry(bifrostError *schemas.BifrostError, config *schemas.ProviderConfig) retryClass {
+	if bifrostError.Error.Type != nil && *bifrostError.Error.Type == schemas.RequestCancelled {
+		return retryNone
+	}
+	if bifrostError.StatusCode != nil {
+		if isRetryableStatusCode(*bifrostError.StatusCode, config) {
+			return retryRequest
+		}
+		return retryNone
+	}
+	if bifrostError.Kind == schemas.ErrorKindConnection || bifrostError.Kind == schemas.ErrorKindEmptyStream {
+		return retryConnection
+	}
+	return retryNone
+}
+
+// retryBudget tracks the retries left for a provider call. Retry classes with a limit of their
+// own (ConnectionMaxRetries, RequestMaxRetries) are counted separately, while the others share
+// MaxRetries, so that a call is retried at most MaxRetries times in total unless the classes are
+// given separate limits. The request's own MaxRetries, if set, additionally caps the retries of
+// all classes together: it can lower the provider's budgets, never raise them.
+type retryBudget struct {
+	classLimits [retryRequest + 1]*int // Limit of each class, nil if it uses the shared budget
+	classUsed   [retryRequest + 1]int  // Retries used by each class with a limit
+	shared      int                    // Retries left in the shared budget
+	total       *int                   // Retries left for all classes together, nil if not capped
+}
 
-	// Add jitter (±20%)
-	jitter := float64(backoff) * (0.8 + 0.4*rand.Float64())
+// newRetryBudget returns the retry budget of a provider call.
+func newRetryBudget(network schemas.NetworkConfig, requestMaxRetries *int) retryBudget {
+	var budget retryBudget
+	budget.classLimits[retryConnection] = network.ConnectionMaxRetries
+	budget.classLimits[retryRequest] = network.RequestMaxRetries
+	budget.shared = network.MaxRetries
+	if requestMaxRetries != nil {
+		budget.total = Ptr(*requestMaxRetries)
+	}
+	return budget
+}
 
-	return time.Duration(jitter)
+// take uses one retry of a class, and reports false if its budget is exhausted or the class is
+// not retried.
+func (b *retryBudget) take(class retryClass) bool {
+	if class == retryNone || (b.total != nil && *b.total <= 0) {
+		return false
+	}
+	if limit := b.classLimits[class]; limit != nil {
+		if b.classUsed[class] >= *limit {
+			return false
+		}
+		b.classUsed[class]++
+	} else {
+		if b.shared <= 0 {
+			return false
+		}
+		b.shared--
+	}
+	if b.total != nil {
+		*b.total--
+	}
+	return true
+}
+
+// withAttemptTimeout derives the context of a provider attempt that is cancelled after timeout.
+// Stream contexts are only cancelled by the timer, as the stream outlives the attempt; cancel is
+// then only needed when the attempt fails.
+func withAttemptTimeout(ctx context.Context, timeout time.Duration, stream bool) (context.Context, context.CancelFunc) {
+	if !stream {
+		return context.WithTimeout(ctx, timeout)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	return ctx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// attemptTimedOut reports whether the attempt context was cancelled by its per request type
+// timeout rather than by the caller.
+func attemptTimedOut(attemptCtx, parentCtx context.Context) bool {
+	return parentCtx.Err() == nil && errors.Is(context.Cause(attemptCtx), context.DeadlineExceeded)
+}
+
+// requestTimeoutError replaces the cancellation error of an attempt that hit its per request
+// type timeout with a provider request error, so that it is retried and falls back like a
+// timeout of the provider's HTTP client.
+func requestTimeoutError(bifrostError *schemas.BifrostError, attemptCtx, parentCtx context.Context, timeout time.Duration) *schemas.BifrostError {
+	if bifrostError == nil || bifrostError.Error.Type == nil || *bifrostError.Error.Type != schemas.RequestCancelled || !attemptTimedOut(attemptCtx, parentCtx) {
+		return bifrostError
+	}
+	return &schemas.BifrostError{
+		IsBifrostError: false,
+		Kind:           schemas.ErrorKindConnection,
+		Error: schemas.ErrorField{
+			Message: schemas.ErrProviderRequest,
+			Error:   fmt.Errorf("request timed out after %s", timeout),
+		},
+	}
 }
 
-func validateRequest(req *schemas.BifrostRequest) *schemas.BifrostError {
+// validateRequestTimeouts checks that the per request type timeouts of a provider config name
+// known request types.
+func validateRequestTimeouts(network schemas.NetworkConfig) error {
+	for requestType := range network.RequestTimeoutsInSeconds {
+		switch RequestType(requestType) {
+		case TextCompletionRequest, ChatCompletionRequest, ChatCompletionStreamRequest, EmbeddingRequest,
+			SpeechRequest, SpeechStreamRequest, TranscriptionRequest, TranscriptionStreamRequest:
+		default:
+			return fmt.Errorf("unknown request type %q in request_timeouts_in_seconds", requestType)
+		}
+	}
+	return nil
+}
+
+// providerScopedRequestHeaders maps the headers of AllowedRequestHeaders that only mean something
+// to one provider to that provider.
+var providerScopedRequestHeaders = map[string]schemas.ModelProvider{
+	"Openai-Organization": schemas.OpenAI,
+	"Openai-Project":      schemas.OpenAI,
+}
+
+// scopeRequestHeaders returns the request's extra headers without those scoped to another
+// provider, so that e.g. OpenAI's organization is not sent to a fallback of another provider.
+// requestHeaders is returned unchanged if none of them are dropped.
+func scopeRequestHeaders(providerKey schemas.ModelProvider, requestHeaders map[string]string) map[string]string {
+	var headers map[string]string
+	for name := range requestHeaders {
+		if scope, ok := providerScopedRequestHeaders[textproto.CanonicalMIMEHeaderKey(name)]; ok && scope != providerKey {
+			if headers == nil {
+				headers = maps.Clone(requestHeaders)
+			}
+			delete(headers, name)
+		}
+	}
+	if headers == nil {
+		return requestHeaders
+	}
+	return headers
+}
+
+// withContextHeaders returns the request's extra headers together with the provider's context
+// headers, resolved from ctx. The request's own headers take precedence. If there are no context
+// headers, requestHeaders is returned unchanged. Generated values are read from ctx, where
+// withCorrelationIDs put them.
+func withContextHeaders(ctx context.Context, contextHeaders []schemas.ContextHeader, requestHeaders map[string]string) map[string]string {
+	if len(contextHeaders) == 0 {
+		return requestHeaders
+	}
+
+	headers := make(map[string]string, len(contextHeaders)+len(requestHeaders))
+	for _, contextHeader := range contextHeaders {
+		name := textproto.CanonicalMIMEHeaderKey(contextHeader.Header)
+		// Skip Authorization header for security reasons, as for ExtraHeaders
+		if name == "" || name == "Authorization" {
+			continue
+		}
+
+		var value string
+		switch v := ctx.Value(contextHeader.ContextKey).(type) {
+		case string:
+			value = v
+		case fmt.Stringer:
+			value = v.String()
+		}
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	for name, value := range requestHeaders {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	return headers
+}
+
+// withCorrelationIDs generates the correlation IDs of a request once, before its first attempt.
+// For every context header with Generate of the providers the request may be sent to, the primary
+// and its fallbacks, ctx gets a random UUID under the header's context key unless it already has
+// a value, so that all retries and fallbacks of the request send the same ID.
+func (bifrost *Bifrost) withCorrelationIDs(ctx context.Context, req *schemas.BifrostRequest) context.Context {
+	providers := make([]schemas.ModelProvider, 0, len(req.Fallbacks)+1)
+	providers = append(providers, req.Provider)
+	for _, fallback := range req.Fallbacks {
+		providers = append(providers, fallback.Provider)
+	}
+	for _, providerKey := range providers {
+		config, err := bifrost.account.GetConfigForProvider(providerKey)
+		if err != nil {
+			continue
+		}
+		for _, contextHeader := range config.NetworkConfig.ContextHeaders {
+			if contextHeader.Generate && ctx.Value(contextHeader.ContextKey) == nil {
+				ctx = context.WithValue(ctx, contextHeader.ContextKey, newCorrelationID())
+			}
+		}
+	}
+	return ctx
+}
+
+// withIdentificationHeaders returns the request's extra headers together with the provider's
+// User-Agent and client ID headers. The request's headers and a User-Agent in the provider's
+// ExtraHeaders take precedence. requestHeaders is not modified.
+func withIdentificationHeaders(network schemas.NetworkConfig, requestHeaders map[string]string) map[string]string {
+	identification := make(map[string]string, 2)
+	if network.UserAgent != "" {
+		identification["User-Agent"] = network.UserAgent
+	}
+	if network.ClientID != "" {
+		identification[schemas.ClientIDHeader] = network.ClientID
+	}
+	for name := range network.ExtraHeaders {
+		delete(identification, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	if len(identification) == 0 {
+		return requestHeaders
+	}
+
+	headers := make(map[string]string, len(identification)+len(requestHeaders))
+	maps.Copy(headers, identification)
+	for name, value := range requestHeaders {
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+	return headers
+}
+
+// newCorrelationID returns a random version 4 UUID.
+func newCorrelationID() string {
+	var id [16]byte
+	crand.Read(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+// validatePrimaryCandidates checks that every primary candidate has a provider, a model and a
+// non-negative weight, and that at least one candidate can be picked.
+func validatePrimaryCandidates(candidates []schemas.PrimaryCandidate) *schemas.BifrostError {
+	var totalWeight float64
+	for i, candidate := range candidates {
+		if candidate.Provider == "" || candidate.Model == "" {
+			return newBifrostErrorFromMsg(fmt.Sprintf("primary candidate %d: provider and model are required", i))
+		}
+		if candidate.Weight < 0 {
+			return newBifrostErrorFromMsg(fmt.Sprintf("primary candidate %d: weight must be non-negative, got %v", i, candidate.Weight))
+		}
+		totalWeight += candidate.Weight
+	}
+	if totalWeight <= 0 {
+		return newBifrostErrorFromMsg("at least one primary candidate must have a positive weight")
+	}
+	return nil
+}
+
+// validateRequest checks a request of the given type before it is sent to its provider. Malformed
+// requests are rejected with a validation error naming the offending field (see newValidationError).
+func validateRequest(req *schemas.BifrostRequest, requestType RequestType) *schemas.BifrostError {
 	if req == nil {
 		return newBifrostErrorFromMsg("bifrost request cannot be nil")
 	}
 
 	if req.Provider == "" {
-		return newBifrostErrorFromMsg("provider is required")
+		return newValidationError("Provider", "required")
 	}
 
 	if req.Model == "" {
-		return newBifrostErrorFromMsg("model is required")
+		return newValidationError("Model", "required")
+	}
+
+	if err := validateInput(req.Input, requestType); err != nil {
+		return err
+	}
+
+	if err := validateParams(req.Params); err != nil {
+		return err
+	}
+
+	for name := range req.ExtraHeaders {
+		if !slices.Contains(schemas.AllowedRequestHeaders, textproto.CanonicalMIMEHeaderKey(name)) {
+			return newValidationError(fmt.Sprintf("ExtraHeaders[%s]", name), fmt.Sprintf("cannot be set per request, allowed headers are: %s", strings.Join(schemas.AllowedRequestHeaders, ", ")))
+		}
+	}
+
+	if req.StreamMaxOutputTokens < 0 {
+		return newValidationError("StreamMaxOutputTokens", fmt.Sprintf("must be non-negative, got %d", req.StreamMaxOutputTokens))
+	}
+
+	if req.AutoContinue != nil && req.AutoContinue.MaxContinuations <= 0 {
+		return newValidationError("AutoContinue.MaxContinuations", fmt.Sprintf("must be positive, got %d", req.AutoContinue.MaxContinuations))
 	}
 
+	if req.TruncatedToolCallRetry != nil && req.TruncatedToolCallRetry.MaxTokens <= 0 {
+		return newValidationError("TruncatedToolCallRetry.MaxTokens", fmt.Sprintf("must be positive, got %d", req.TruncatedToolCallRetry.MaxTokens))
+	}
+
+	if req.MaxRetries != nil && *req.MaxRetries < 0 {
+		return newValidationError("MaxRetries", fmt.Sprintf("must be non-negative, got %d", *req.MaxRetries))
+	}
+
+	if req.MaxFallbacks != nil && *req.MaxFallbacks < 0 {
+		return newValidationError("MaxFallbacks", fmt.Sprintf("must be non-negative, got %d", *req.MaxFallbacks))
+	}
+
+	if req.OutputLanguage != nil && req.OutputLanguage.Language == "" && req.OutputLanguage.Instruction == "" {
+		return newValidationError("OutputLanguage.Language", "required unless Instruction is set")
+	}
+
+	if req.TranscriptionChunking != nil {
+		if err := validateTranscriptionChunking(req, requestType); err != nil {
+			return err
+		}
+	}
+
+	if req.Hedging != nil && req.Hedging.DelayMs < 0 {
+		return newValidationError("Hedging.DelayMs", fmt.Sprintf("must be non-negative, got %d", req.Hedging.DelayMs))
+	}
+
+	if req.Params != nil && req.Params.StopOnToolCall && req.Params.ParallelToolCalls != nil && *req.Params.ParallelToolCalls {
+		return newValidationError("Params.StopOnToolCall", "cannot be combined with Params.ParallelToolCalls")
+	}
+
+	if req.Params != nil && len(req.Params.LogitBias) > 0 {
+		for tokenID, bias := range req.Params.LogitBias {
+			if tokenID < 0 {
+				return newValidationError(fmt.Sprintf("Params.LogitBias[%d]", tokenID), "token ID must be non-negative")
+			}
+			if bias < -100 || bias > 100 {
+				return newValidationError(fmt.Sprintf("Params.LogitBias[%d]", tokenID), fmt.Sprintf("must be between -100 and 100, got %v", bias))
+			}
+		}
+	}
+
+	return nil
+}
+
+// requestInputs lists the input of each request type, in the order of the fields of schemas.RequestInput.
+var requestInputs = []struct {
+	field        string
+	requestTypes []RequestType
+	set          func(schemas.RequestInput) bool
+}{
+	{"TextCompletionInput", []RequestType{TextCompletionRequest}, func(input schemas.RequestInput) bool { return input.TextCompletionInput != nil }},
+	{"ChatCompletionInput", []RequestType{ChatCompletionRequest, ChatCompletionStreamRequest}, func(input schemas.RequestInput) bool { return input.ChatCompletionInput != nil }},
+	{"EmbeddingInput", []RequestType{EmbeddingRequest}, func(input schemas.RequestInput) bool { return input.EmbeddingInput != nil }},
+	{"SpeechInput", []RequestType{SpeechRequest, SpeechStreamRequest}, func(input schemas.RequestInput) bool { return input.SpeechInput != nil }},
+	{"TranscriptionInput", []RequestType{TranscriptionRequest, TranscriptionStreamRequest}, func(input schemas.RequestInput) bool { return input.TranscriptionInput != nil }},
+}
+
+// validateInput checks that the input of a request is the one of its type, and that it is not empty.
+// Inputs of other request types are rejected rather than silently ignored.
+func validateInput(input schemas.RequestInput, requestType RequestType) *schemas.BifrostError {
+	for _, candidate := range requestInputs {
+		if !slices.Contains(candidate.requestTypes, requestType) && candidate.set(input) {
+			return newValidationError("Input."+candidate.field, fmt.Sprintf("cannot be set on a %s request", requestType))
+		}
+	}
+
+	switch requestType {
+	case TextCompletionRequest:
+		if input.TextCompletionInput == nil || *input.TextCompletionInput == "" {
+			return newValidationError("Input.TextCompletionInput", "prompt required")
+		}
+	case ChatCompletionRequest, ChatCompletionStreamRequest:
+		if input.ChatCompletionInput == nil || len(*input.ChatCompletionInput) == 0 {
+			return newValidationError("Input.ChatCompletionInput", "at least one message required")
+		}
+		for i, message := range *input.ChatCompletionInput {
+			if message.Role == "" {
+				return newValidationError(fmt.Sprintf("Input.ChatCompletionInput[%d].Role", i), "required")
+			}
+		}
+	case EmbeddingRequest:
+		if input.EmbeddingInput == nil || len(input.EmbeddingInput.Texts) == 0 {
+			return newValidationError("Input.EmbeddingInput.Texts", "at least one text required")
+		}
+	case SpeechRequest, SpeechStreamRequest:
+		if input.SpeechInput == nil || input.SpeechInput.Input == "" {
+			return newValidationError("Input.SpeechInput.Input", "text required")
+		}
+	case TranscriptionRequest, TranscriptionStreamRequest:
+		if input.TranscriptionInput == nil || len(input.TranscriptionInput.File) == 0 {
+			return newValidationError("Input.TranscriptionInput.File", "audio file required")
+		}
+	}
 	return nil
 }
 
+// validateParams checks the ranges of the common model parameters. Providers may accept narrower
+// ranges (e.g. Anthropic's temperature is between 0 and 1), which are left to them to enforce.
+func validateParams(params *schemas.ModelParameters) *schemas.BifrostError {
+	if params == nil {
+		return nil
+	}
+
+	if params.Temperature != nil && (*params.Temperature < 0 || *params.Temperature > 2) {
+		return newValidationError("Params.Temperature", fmt.Sprintf("must be between 0 and 2, got %v", *params.Temperature))
+	}
+	if params.TopP != nil && (*params.TopP < 0 || *params.TopP > 1) {
+		return newValidationError("Params.TopP", fmt.Sprintf("must be between 0 and 1, got %v", *params.TopP))
+	}
+	if params.TopK != nil && *params.TopK <= 0 {
+		return newValidationError("Params.TopK", fmt.Sprintf("must be positive, got %d", *params.TopK))
+	}
+	if params.MaxTokens != nil && *params.MaxTokens <= 0 {
+		return newValidationError("Params.MaxTokens", fmt.Sprintf("must be positive, got %d", *params.MaxTokens))
+	}
+	if params.PresencePenalty != nil && (*params.PresencePenalty < -2 || *params.PresencePenalty > 2) {
+		return newValidationError("Params.PresencePenalty", fmt.Sprintf("must be between -2 and 2, got %v", *params.PresencePenalty))
+	}
+	if params.FrequencyPenalty != nil && (*params.FrequencyPenalty < -2 || *params.FrequencyPenalty > 2) {
+		return newValidationError("Params.FrequencyPenalty", fmt.Sprintf("must be between -2 and 2, got %v", *params.FrequencyPenalty))
+	}
+	if params.RepetitionPenalty != nil && *params.RepetitionPenalty <= 0 {
+		return newValidationError("Params.RepetitionPenalty", fmt.Sprintf("must be positive, got %v", *params.RepetitionPenalty))
+	}
+	if params.Dimensions != nil && *params.Dimensions <= 0 {
+		return newValidationError("Params.Dimensions", fmt.Sprintf("must be positive, got %d", *params.Dimensions))
+	}
+	return nil
+}
+
+// newValidationError creates the error of a malformed request. The path of the offending field
+// (e.g. "Params.Temperature") is reported in Error.Param and prefixes the message.
+func newValidationError(field string, reason string) *schemas.BifrostError {
+	return &schemas.BifrostError{
+		IsBifrostError: false,
+		StatusCode:     Ptr(http.StatusBadRequest),
+		Error: schemas.ErrorField{
+			Type:    Ptr(schemas.InvalidRequest),
+			Message: fmt.Sprintf("%s: %s", field, reason),
+			Param:   field,
+		},
+	}
+}
+
 // newBifrostError wraps a standard error into a BifrostError with IsBifrostError set to false.
 // This helper function reduces code duplication when handling non-Bifrost errors.
 func newBifrostError(err error) *schemas.BifrostError {
@@ -71,6 +587,203 @@ func newBifrostErrorFromMsg(message string) *schemas.BifrostError {
 	}
 }
 
+// isUnsupportedOperationError reports whether the error was returned by a provider
+// that does not support the requested operation.
+func isUnsupportedOperationError(bifrostErr *schemas.BifrostError) bool {
+	return bifrostErr != nil && bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.UnsupportedOperation
+}
+
+// newAttemptError summarizes the error of one provider attempt for BifrostError.AttemptHistory.
+func newAttemptError(req *schemas.BifrostRequest, bifrostErr *schemas.BifrostError) schemas.AttemptError {
+	attempt := schemas.AttemptError{
+		Provider:   req.Provider,
+		Model:      req.Model,
+		StatusCode: bifrostErr.StatusCode,
+		Type:       bifrostErr.Error.Type,
+		Message:    bifrostErr.Error.Message,
+		Kind:       bifrostErr.Kind,
+
+		ProviderRequestID: bifrostErr.ProviderRequestID,
+	}
+	if attempt.Message == "" && bifrostErr.Error.Error != nil {
+		attempt.Message = bifrostErr.Error.Error.Error()
+	}
+	return attempt
+}
+
+// fallbackLimitReached reports whether a request has tried as many fallbacks as its MaxFallbacks
+// allows. If so, the fallbacks left untried are returned to be reported as skipped.
+func fallbackLimitReached(req *schemas.BifrostRequest, tried int, untried []schemas.Fallback) ([]schemas.SkippedFallback, bool) {
+	if req.MaxFallbacks == nil || tried < *req.MaxFallbacks {
+		return nil, false
+	}
+	skipped := make([]schemas.SkippedFallback, len(untried))
+	for i, fallback := range untried {
+		skipped[i] = schemas.SkippedFallback{Provider: fallback.Provider, Model: fallback.Model, Reason: fmt.Sprintf("max_fallbacks of %d reached", *req.MaxFallbacks)}
+	}
+	return skipped, true
+}
+
+// attachSkippedFallbacks reports on the final error of a request the fallbacks that were not
+// tried. If all of its fallbacks were skipped, the message also says so: the error would otherwise
+// read as if the request had no fallbacks, hiding e.g. a typo in their provider names.
+func attachSkippedFallbacks(bifrostErr *schemas.BifrostError, skipped []schemas.SkippedFallback, fallbackCount int) {
+	if len(skipped) == 0 {
+		return
+	}
+	bifrostErr.SkippedFallbacks = skipped
+	if len(skipped) < fallbackCount {
+		return
+	}
+	reasons := make([]string, len(skipped))
+	for i, fallback := range skipped {
+		reasons[i] = fmt.Sprintf("%s/%s: %s", fallback.Provider, fallback.Model, fallback.Reason)
+	}
+	bifrostErr.Error.Message = fmt.Sprintf("%s (all %d fallbacks were skipped: %s)", bifrostErr.Error.Message, len(skipped), strings.Join(reasons, "; "))
+}
+
+// attachResponseMetadata copies the provider's raw HTTP status code, captured headers and
+// request body into the response's extra fields. Providers that do not record metadata leave it empty.
+func attachResponseMetadata(resp *schemas.BifrostResponse, metadata *schemas.ResponseMetadata) {
+	if resp == nil || metadata == nil {
+		return
+	}
+	if len(metadata.RequestBody) > 0 {
+		resp.ExtraFields.RawRequest = metadata.RequestBody
+	}
+	if metadata.StatusCode == 0 {
+		return
+	}
+
+	resp.ExtraFields.StatusCode = metadata.StatusCode
+	if len(metadata.Headers) > 0 {
+		resp.ExtraFields.ResponseHeaders = metadata.Headers
+	}
+	resp.ExtraFields.ProviderRequestID = metadata.RequestID
+}
+
+// attachProviderRequestID reports on an error the provider's request ID of the response it was
+// parsed from, if the provider sent one. An ID already set on the error is kept.
+func attachProviderRequestID(bifrostErr *schemas.BifrostError, metadata *schemas.ResponseMetadata) {
+	if bifrostErr.ProviderRequestID == "" && metadata.RequestID != "" {
+		bifrostErr.ProviderRequestID = metadata.RequestID
+	}
+}
+
+// attachSelectedPrimary reports on the response the primary picked among the request's
+// PrimaryCandidates, if any.
+func attachSelectedPrimary(ctx context.Context, resp *schemas.BifrostResponse) {
+	if resp == nil || ctx == nil {
+		return
+	}
+	if selected, ok := ctx.Value(schemas.BifrostContextKeySelectedPrimary).(*schemas.SelectedPrimary); ok {
+		resp.ExtraFields.SelectedPrimary = selected
+	}
+}
+
+// attachRetries reports on the response the retries of the provider call that produced it, by
+// the status code of each retried attempt.
+func attachRetries(resp *schemas.BifrostResponse, retryStatusCodes []int) {
+	if resp == nil || len(retryStatusCodes) == 0 {
+		return
+	}
+	resp.ExtraFields.Retries = len(retryStatusCodes)
+	resp.ExtraFields.RetryStatusCodes = retryStatusCodes
+}
+
+// requestedModelContextKey holds the model the caller asked for, before a primary candidate,
+// fallback or decommission replacement took its place.
+const requestedModelContextKey schemas.BifrostContextKey = "bifrost-requested-model"
+
+// withRequestedModel records the model of the caller's request in ctx, so that it is reported on
+// the response whichever model served it. It must be called before the request is rewritten.
+func withRequestedModel(ctx context.Context, req *schemas.BifrostRequest) context.Context {
+	if ctx == nil || req == nil || req.Model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestedModelContextKey, req.Model)
+}
+
+// attachRequestedModel records the model the caller asked for on the response, or model, the
+// model requested from the provider, if ctx does not hold one. model is used as the response's
+// model if the provider did not report one.
+func attachRequestedModel(ctx context.Context, resp *schemas.BifrostResponse, model string) {
+	if resp == nil {
+		return
+	}
+	resp.ExtraFields.RequestedModel = model
+	if ctx != nil {
+		if requested, ok := ctx.Value(requestedModelContextKey).(string); ok {
+			resp.ExtraFields.RequestedModel = requested
+		}
+	}
+	if resp.Model == "" {
+		resp.Model = model
+	}
+}
+
+// attachReproducibility reports on the response whether the request's seed was applied by the
+// provider, along with the provider's system fingerprint. Responses to requests without a seed
+// are left unchanged.
+func attachReproducibility(resp *schemas.BifrostResponse, capabilities schemas.ProviderCapabilities, params *schemas.ModelParameters) {
+	if resp == nil || params == nil || params.Seed == nil {
+		return
+	}
+
+	resp.ExtraFields.Reproducibility = &schemas.Reproducibility{
+		Seed:              *params.Seed,
+		SeedApplied:       capabilities.Seed,
+		SystemFingerprint: resp.SystemFingerprint,
+	}
+}
+
+// convertToStreamChunk rewrites a non-streaming chat completion response in place so
+// that each choice carries its message as a stream delta.
+func convertToStreamChunk(resp *schemas.BifrostResponse) {
+	if resp == nil {
+		return
+	}
+
+	resp.Object = "chat.completion.chunk"
+	for i, choice := range resp.Choices {
+		if choice.BifrostNonStreamResponseChoice == nil {
+			continue
+		}
+
+		message := choice.Message
+		role := string(message.Role)
+		delta := schemas.BifrostStreamDelta{
+			Role: &role,
+		}
+
+		if message.Content.ContentStr != nil {
+			delta.Content = message.Content.ContentStr
+		} else if message.Content.ContentBlocks != nil {
+			var text strings.Builder
+			for _, block := range *message.Content.ContentBlocks {
+				if block.Type == schemas.ContentBlockTypeText && block.Text != nil {
+					text.WriteString(*block.Text)
+				}
+			}
+			content := text.String()
+			delta.Content = &content
+		}
+
+		if message.AssistantMessage != nil {
+			delta.Thought = message.AssistantMessage.Thought
+			delta.Refusal = message.AssistantMessage.Refusal
+			if message.AssistantMessage.ToolCalls != nil {
+				delta.ToolCalls = *message.AssistantMessage.ToolCalls
+			}
+		}
+
+		resp.Choices[i].BifrostNonStreamResponseChoice = nil
+		resp.Choices[i].BifrostStreamResponseChoice = &schemas.BifrostStreamResponseChoice{
+			Delta: delta,
+		}
+	}
+}
+
 // newBifrostMessageChan creates a channel that sends a bifrost response.
 // It is used to send a bifrost response to the client.
 func newBifrostMessageChan(message *schemas.BifrostResponse) chan *schemas.BifrostStream {
@@ -0,0 +1,108 @@
+package bifrost
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestValidateRequestFieldPaths(t *testing.T) {
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	text := "Hello"
+
+	tests := []struct {
+		name        string
+		req         schemas.BifrostRequest
+		requestType RequestType
+		want        string
+	}{
+		{"missing model", schemas.BifrostRequest{Provider: schemas.OpenAI}, ChatCompletionRequest, "Model: required"},
+		{"no messages", schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &[]schemas.BifrostMessage{}}}, ChatCompletionStreamRequest, "Input.ChatCompletionInput: at least one message required"},
+		{"conflicting inputs", schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &messages, TextCompletionInput: &text}}, ChatCompletionRequest, "Input.TextCompletionInput: cannot be set on a chat_completion request"},
+		{"no texts", schemas.BifrostRequest{Input: schemas.RequestInput{EmbeddingInput: &schemas.EmbeddingInput{}}}, EmbeddingRequest, "Input.EmbeddingInput.Texts: at least one text required"},
+		{"no audio", schemas.BifrostRequest{Input: schemas.RequestInput{TranscriptionInput: &schemas.TranscriptionInput{}}}, TranscriptionRequest, "Input.TranscriptionInput.File: audio file required"},
+		{"temperature", schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &messages}, Params: &schemas.ModelParameters{Temperature: Ptr(2.5)}}, ChatCompletionRequest, "Params.Temperature: must be between 0 and 2, got 2.5"},
+		{"max tokens", schemas.BifrostRequest{Input: schemas.RequestInput{TextCompletionInput: &text}, Params: &schemas.ModelParameters{MaxTokens: Ptr(0)}}, TextCompletionRequest, "Params.MaxTokens: must be positive, got 0"},
+		{"logit bias", schemas.BifrostRequest{Input: schemas.RequestInput{ChatCompletionInput: &messages}, Params: &schemas.ModelParameters{LogitBias: map[int]float64{42: 200}}}, ChatCompletionRequest, "Params.LogitBias[42]: must be between -100 and 100, got 200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if req.Provider == "" {
+				req.Provider, req.Model = schemas.OpenAI, "gpt-4o"
+			}
+			err := validateRequest(&req, tt.requestType)
+			if err == nil {
+				t.Fatalf("validateRequest() accepted the request, want %q", tt.want)
+			}
+			if err.Error.Message != tt.want {
+				t.Errorf("message = %q, want %q", err.Error.Message, tt.want)
+			}
+			if err.Error.Type == nil || *err.Error.Type != schemas.InvalidRequest || err.StatusCode == nil || *err.StatusCode != http.StatusBadRequest {
+				t.Errorf("error = %+v, want an invalid request error with status 400", err)
+			}
+		})
+	}
+}
+
+func TestValidateRequestAcceptsValidParams(t *testing.T) {
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	req := &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+		Params:   &schemas.ModelParameters{Temperature: Ptr(0.0), TopP: Ptr(1.0), PresencePenalty: Ptr(-2.0)},
+	}
+	if err := validateRequest(req, ChatCompletionRequest); err != nil {
+		t.Errorf("validateRequest() error = %+v", err)
+	}
+}
+
+func TestUnsupportedParamsCheckedAgainstCapabilities(t *testing.T) {
+	bifrost, err := Init(schemas.BifrostConfig{
+		Account: &testAccount{providers: []schemas.ModelProvider{schemas.OpenAI, schemas.Cohere}},
+		Plugins: []schemas.Plugin{&latencyPlugin{failing: map[schemas.ModelProvider]bool{schemas.OpenAI: true}}},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	for _, tt := range []struct {
+		param  string
+		params schemas.ModelParameters
+	}{
+		{"logit_bias", schemas.ModelParameters{LogitBias: map[int]float64{42: -100}}},
+		{"parallel_tool_calls", schemas.ModelParameters{ParallelToolCalls: Ptr(false)}},
+		{"stop_on_tool_call", schemas.ModelParameters{StopOnToolCall: true}},
+	} {
+		t.Run(tt.param, func(t *testing.T) {
+			params := tt.params
+			_, bifrostErr := bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+				Provider: schemas.Cohere,
+				Model:    "command-r",
+				Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+				Params:   &params,
+			})
+			if bifrostErr == nil || bifrostErr.Error.Type == nil || *bifrostErr.Error.Type != schemas.UnsupportedOperation {
+				t.Errorf("request to Cohere error = %+v, want an unsupported operation error", bifrostErr)
+			}
+
+			// Fallbacks without support are skipped
+			_, bifrostErr = bifrost.ChatCompletionRequest(context.Background(), &schemas.BifrostRequest{
+				Provider:  schemas.OpenAI,
+				Model:     "gpt-4o",
+				Input:     schemas.RequestInput{ChatCompletionInput: &messages},
+				Params:    &params,
+				Fallbacks: []schemas.Fallback{{Provider: schemas.Cohere, Model: "command-r"}},
+			})
+			if bifrostErr == nil || len(bifrostErr.SkippedFallbacks) != 1 || bifrostErr.SkippedFallbacks[0].Reason != tt.param+" is not supported" {
+				t.Errorf("request with a Cohere fallback error = %+v, want the fallback skipped", bifrostErr)
+			}
+		})
+	}
+}
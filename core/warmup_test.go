@@ -0,0 +1,76 @@
+package bifrost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestWarmupOnInit(t *testing.T) {
+	server, pings, streamPings := newKeepaliveServer()
+	defer server.Close()
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			WarmupOnInit:                   true,
+		}),
+	}
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	// Both clients open a connection
+	if got := waitForPings(pings, 1); got != 1 {
+		t.Errorf("got %d warmup requests, want 1", got)
+	}
+	if got := waitForPings(streamPings, 1); got != 1 {
+		t.Errorf("got %d warmup requests from the streaming client, want 1", got)
+	}
+}
+
+func TestWarmupDoesNotBlockInit(t *testing.T) {
+	// The provider takes long to answer, and then fails
+	var warmups atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && !strings.HasPrefix(r.UserAgent(), "Go-http-client") {
+			warmups.Add(1)
+			<-release
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	account := &testAccount{
+		keys: testKeys(),
+		config: networkConfig(schemas.NetworkConfig{
+			BaseURL:                        server.URL,
+			DefaultRequestTimeoutInSeconds: 5,
+			WarmupOnInit:                   true,
+		}),
+	}
+	start := time.Now()
+	bifrost, err := Init(schemas.BifrostConfig{Account: account, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer bifrost.Cleanup()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Init() took %v, want it not to wait for the warmup", elapsed)
+	}
+	if got := waitForPings(&warmups, 1); got != 1 {
+		t.Errorf("got %d warmup requests, want 1", got)
+	}
+}
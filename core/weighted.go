@@ -0,0 +1,32 @@
+package bifrost
+
+import "math/rand/v2"
+
+// PickWeighted picks an index of weights at random, in proportion to its weight, without
+// allocating. It draws from the runtime's per-thread random source, so it does not contend on a
+// lock and is safe for concurrent use. Negative weights count as zero. If no weight is positive,
+// 0 is returned.
+func PickWeighted(weights []float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += max(weight, 0)
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	target := rand.Float64() * total
+	picked := 0
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		// Rounding can leave target just above the last weight: keep the last positive one
+		picked = i
+		if target < weight {
+			break
+		}
+		target -= weight
+	}
+	return picked
+}
@@ -0,0 +1,49 @@
+package bifrost
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedSelection(t *testing.T) {
+	weights := []float64{0, 1, -1, 3, 0.001, 0}
+
+	const picks = 100000
+	counts := make([]int, len(weights))
+	for range picks {
+		counts[PickWeighted(weights)]++
+	}
+	if counts[0] != 0 || counts[2] != 0 || counts[5] != 0 {
+		t.Errorf("counts = %v, want no picks of zero or negative weights", counts)
+	}
+	// Weights 1 and 3 share 3/4 of the picks
+	if share := float64(counts[3]) / float64(counts[1]+counts[3]); math.Abs(share-0.75) > 0.02 {
+		t.Errorf("counts = %v, want 3 times as many picks of index 3 as of index 1", counts)
+	}
+	if counts[4] == 0 {
+		t.Errorf("counts = %v, want a few picks of the small weight", counts)
+	}
+
+	for _, weights := range [][]float64{nil, {0, 0}, {-1}} {
+		if index := PickWeighted(weights); index != 0 {
+			t.Errorf("PickWeighted(%v) = %d, want 0", weights, index)
+		}
+	}
+}
+
+func TestWeightedSelectionDoesNotAllocate(t *testing.T) {
+	weights := []float64{2, 1, 1}
+	if allocs := testing.AllocsPerRun(100, func() { PickWeighted(weights) }); allocs != 0 {
+		t.Errorf("PickWeighted() allocates %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkPickWeighted(b *testing.B) {
+	weights := []float64{5, 3, 1, 1}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			PickWeighted(weights)
+		}
+	})
+}
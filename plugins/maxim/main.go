@@ -6,6 +6,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/maximhq/bifrost/core/schemas"
@@ -43,7 +46,37 @@ func NewMaximLoggerPlugin(apiKey string, logRepoId string) (schemas.Plugin, erro
 		return nil, err
 	}
 
-	plugin := &Plugin{logger}
+	plugin := &Plugin{logger: logger}
+
+	return plugin, nil
+}
+
+// SamplingConfig enables tail-based sampling of traces. The trace and generation of a request are
+// buffered until it completes, and only sent to Maxim if the request was slow, failed or is sampled.
+//
+// Fields:
+//   - LatencyThreshold: Requests taking at least this long are always traced (0 traces none for latency)
+//   - SampleRate: Fraction of the other successful requests to trace, between 0 and 1
+type SamplingConfig struct {
+	LatencyThreshold time.Duration
+	SampleRate       float64
+}
+
+// NewMaximLoggerPluginWithSampling initializes a Plugin like NewMaximLoggerPlugin that traces
+// requests according to the given sampling configuration.
+func NewMaximLoggerPluginWithSampling(apiKey string, logRepoId string, sampling SamplingConfig) (schemas.Plugin, error) {
+	if sampling.LatencyThreshold < 0 {
+		return nil, fmt.Errorf("sampling latency threshold must be non-negative, got %v", sampling.LatencyThreshold)
+	}
+	if sampling.SampleRate < 0 || sampling.SampleRate > 1 {
+		return nil, fmt.Errorf("sampling rate must be between 0 and 1, got %v", sampling.SampleRate)
+	}
+
+	plugin, err := NewMaximLoggerPlugin(apiKey, logRepoId)
+	if err != nil {
+		return nil, err
+	}
+	plugin.(*Plugin).sampling = &sampling
 
 	return plugin, nil
 }
@@ -62,6 +95,22 @@ const (
 	GenerationIDKey ContextKey = "generation-id"
 )
 
+// pendingTraceKey is the context key of the buffered trace of a request when sampling is enabled.
+const pendingTraceKey ContextKey = "bifrost-maxim-pending-trace"
+
+// pendingTrace is the trace and generation of a request, buffered until the request completes.
+// Streaming requests run PostHook for each chunk, so the decision is made once, on the final
+// chunk or the first error.
+type pendingTrace struct {
+	start      time.Time
+	trace      *logging.TraceConfig // nil when the generation is added to an existing trace
+	traceInput string
+	generation *logging.GenerationConfig
+
+	once sync.Once
+	kept bool
+}
+
 // The plugin provides request/response tracing functionality by integrating with Maxim's logging system.
 // It supports both chat completion and text completion requests, tracking the entire lifecycle of each request
 // including inputs, parameters, and responses.
@@ -81,8 +130,10 @@ const (
 //
 // Fields:
 //   - logger: A Maxim logger instance used for tracing requests and responses
+//   - sampling: The tail-based sampling configuration, or nil to trace every request
 type Plugin struct {
-	logger *logging.Logger
+	logger   *logging.Logger
+	sampling *SamplingConfig
 }
 
 // GetName returns the name of the plugin.
@@ -182,11 +233,12 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 		latestMessage = *req.Input.TextCompletionInput
 	}
 
+	var traceConfig *logging.TraceConfig
 	if traceID == "" {
 		// If traceID is not set, create a new trace
 		traceID = uuid.New().String()
 
-		traceConfig := logging.TraceConfig{
+		traceConfig = &logging.TraceConfig{
 			Id:   traceID,
 			Name: maxim.StrPtr(fmt.Sprintf("bifrost_%s", requestType)),
 			Tags: &tags,
@@ -195,10 +247,6 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 		if sessionID != "" {
 			traceConfig.SessionId = &sessionID
 		}
-
-		trace := plugin.logger.Trace(&traceConfig)
-
-		trace.SetInput(latestMessage)
 	}
 
 	// Convert ModelParameters to map[string]interface{}
@@ -213,14 +261,26 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 
 	generationID := uuid.New().String()
 
-	plugin.logger.AddGenerationToTrace(traceID, &logging.GenerationConfig{
-		Id:              generationID,
-		Model:           req.Model,
-		Provider:        string(req.Provider),
-		Tags:            &tags,
-		Messages:        messages,
-		ModelParameters: modelParams,
-	})
+	pending := &pendingTrace{
+		start:      time.Now(),
+		trace:      traceConfig,
+		traceInput: latestMessage,
+		generation: &logging.GenerationConfig{
+			Id:              generationID,
+			Model:           req.Model,
+			Provider:        string(req.Provider),
+			Tags:            &tags,
+			Messages:        messages,
+			ModelParameters: modelParams,
+		},
+	}
+
+	if plugin.sampling != nil && ctx != nil {
+		// Buffer the trace until PostHook decides whether to keep it
+		*ctx = context.WithValue(*ctx, pendingTraceKey, pending)
+	} else {
+		plugin.startTrace(traceID, pending)
+	}
 
 	if ctx != nil {
 		if _, ok := (*ctx).Value(TraceIDKey).(string); !ok {
@@ -232,6 +292,55 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 	return req, nil, nil
 }
 
+// startTrace sends the trace and generation of a request to Maxim.
+func (plugin *Plugin) startTrace(traceID string, pending *pendingTrace) {
+	if pending.trace != nil {
+		trace := plugin.logger.Trace(pending.trace)
+
+		trace.SetInput(pending.traceInput)
+	}
+
+	plugin.logger.AddGenerationToTrace(traceID, pending.generation)
+}
+
+// keepTrace reports whether the buffered trace of a request is kept: failed requests and
+// requests that reached the latency threshold always are, others with the sample rate.
+func (plugin *Plugin) keepTrace(pending *pendingTrace, bifrostErr *schemas.BifrostError) bool {
+	if bifrostErr != nil {
+		return true
+	}
+	if plugin.sampling.LatencyThreshold > 0 && time.Since(pending.start) >= plugin.sampling.LatencyThreshold {
+		return true
+	}
+	return rand.Float64() < plugin.sampling.SampleRate
+}
+
+// isFinalResult reports whether res completes its request: a non-streaming response, or the
+// chunk of a stream carrying its finish reason (or the usage of speech and transcription streams).
+func isFinalResult(res *schemas.BifrostResponse) bool {
+	if res == nil {
+		return true
+	}
+	if res.Speech != nil && res.Speech.BifrostSpeechStreamResponse != nil {
+		return res.Speech.Usage != nil
+	}
+	if res.Transcribe != nil && res.Transcribe.BifrostTranscribeStreamResponse != nil {
+		return res.Transcribe.Usage != nil
+	}
+
+	streaming := false
+	for _, choice := range res.Choices {
+		if choice.BifrostStreamResponseChoice == nil {
+			continue
+		}
+		streaming = true
+		if choice.FinishReason != nil {
+			return true
+		}
+	}
+	return !streaming
+}
+
 // PostHook is called after a request has been processed by Bifrost.
 // It completes the request trace by:
 // - Adding response data to the generation if a generation ID exists
@@ -243,6 +352,10 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 // The function gracefully handles cases where trace or generation IDs may be missing,
 // ensuring that partial logging is still performed when possible.
 //
+// With sampling enabled, the buffered trace is first either sent or dropped. Dropped traces
+// are not logged at all. For streams, the chunks before the final one are not logged, and the
+// decision is made on the final chunk or on an error.
+//
 // Parameters:
 //   - ctxRef: Pointer to the context.Context containing trace/generation IDs
 //   - res: The Bifrost response to be traced
@@ -256,6 +369,23 @@ func (plugin *Plugin) PostHook(ctxRef *context.Context, res *schemas.BifrostResp
 	if ctxRef != nil {
 		ctx := *ctxRef
 
+		if pending, ok := ctx.Value(pendingTraceKey).(*pendingTrace); ok {
+			if bifrostErr == nil && !isFinalResult(res) {
+				// Wait for the end of the stream, to measure its whole latency and see its errors
+				return res, bifrostErr, nil
+			}
+			pending.once.Do(func() {
+				pending.kept = plugin.keepTrace(pending, bifrostErr)
+				if pending.kept {
+					traceID, _ := ctx.Value(TraceIDKey).(string)
+					plugin.startTrace(traceID, pending)
+				}
+			})
+			if !pending.kept {
+				return res, bifrostErr, nil
+			}
+		}
+
 		generationID, ok := ctx.Value(GenerationIDKey).(string)
 		if ok {
 			if bifrostErr != nil {
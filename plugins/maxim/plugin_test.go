@@ -5,12 +5,19 @@ package maxim
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/maxim-go/logging"
 )
 
 // getPlugin initializes and returns a Plugin instance for testing purposes.
@@ -54,7 +61,7 @@ func (baseAccount *BaseAccount) GetConfiguredProviders() ([]schemas.ModelProvide
 
 // GetKeysForProvider returns a mock API key configuration for testing.
 // Uses the OPENAI_API_KEY environment variable for authentication.
-func (baseAccount *BaseAccount) GetKeysForProvider(providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+func (baseAccount *BaseAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
 	return []schemas.Key{
 		{
 			Value:  os.Getenv("OPENAI_API_KEY"),
@@ -126,3 +133,94 @@ func TestMaximLoggerPlugin(t *testing.T) {
 
 	client.Cleanup()
 }
+
+// newSampledPlugin returns a Plugin with the given sampling configuration, pushing its logs to a
+// test server instead of Maxim, and a function returning the logs pushed so far.
+func newSampledPlugin(t *testing.T, sampling SamplingConfig) (*Plugin, func() string) {
+	t.Helper()
+	var mu sync.Mutex
+	var pushed strings.Builder
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pushed.Write(body)
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	autoFlush := false
+	logger := logging.NewLogger(server.URL, "test-key", &logging.LoggerConfig{Id: "test-repo", AutoFlush: &autoFlush})
+	return &Plugin{logger: logger, sampling: &sampling}, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return pushed.String()
+	}
+}
+
+// streamChunk returns a chat completion stream chunk, the final one if finishReason is set.
+func streamChunk(content string, finishReason *string) *schemas.BifrostResponse {
+	return &schemas.BifrostResponse{Choices: []schemas.BifrostResponseChoice{{
+		FinishReason:                finishReason,
+		BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: schemas.BifrostStreamDelta{Content: &content}},
+	}}}
+}
+
+// startSampledRequest runs PreHook for a chat completion, returning its context and generation ID.
+func startSampledRequest(t *testing.T, plugin *Plugin) (context.Context, string) {
+	t.Helper()
+	ctx := context.Background()
+	messages := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	if _, _, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o-mini",
+		Input:    schemas.RequestInput{ChatCompletionInput: &messages},
+	}); err != nil {
+		t.Fatalf("PreHook() error = %v", err)
+	}
+	generationID, _ := ctx.Value(GenerationIDKey).(string)
+	return ctx, generationID
+}
+
+func TestSamplingConfigValidation(t *testing.T) {
+	for _, sampling := range []SamplingConfig{{LatencyThreshold: -time.Second}, {SampleRate: -0.1}, {SampleRate: 1.5}} {
+		if _, err := NewMaximLoggerPluginWithSampling("test-key", "test-repo", sampling); err == nil {
+			t.Errorf("NewMaximLoggerPluginWithSampling(%+v) accepted an invalid config", sampling)
+		}
+	}
+}
+
+func TestSamplingDecidesAtStreamEnd(t *testing.T) {
+	// Only slow requests are kept: the latency must be measured to the end of the stream
+	plugin, pushed := newSampledPlugin(t, SamplingConfig{LatencyThreshold: 50 * time.Millisecond})
+	ctx, generationID := startSampledRequest(t, plugin)
+
+	plugin.PostHook(&ctx, streamChunk("Hel", nil), nil)
+	time.Sleep(60 * time.Millisecond)
+	plugin.PostHook(&ctx, streamChunk("lo", bifrost.Ptr("stop")), nil)
+
+	if !strings.Contains(pushed(), generationID) {
+		t.Errorf("pushed logs = %q, want the generation of the slow stream", pushed())
+	}
+}
+
+func TestSamplingKeepsStreamErrors(t *testing.T) {
+	plugin, pushed := newSampledPlugin(t, SamplingConfig{})
+
+	// A stream failing after its first chunk is kept
+	ctx, generationID := startSampledRequest(t, plugin)
+	plugin.PostHook(&ctx, streamChunk("Hel", nil), nil)
+	plugin.PostHook(&ctx, nil, &schemas.BifrostError{Error: schemas.ErrorField{Message: "connection reset"}})
+	if logs := pushed(); !strings.Contains(logs, generationID) || !strings.Contains(logs, "connection reset") {
+		t.Errorf("pushed logs = %q, want the failed generation", logs)
+	}
+
+	// A fast stream finishing normally is dropped
+	ctx, generationID = startSampledRequest(t, plugin)
+	plugin.PostHook(&ctx, streamChunk("Hel", nil), nil)
+	plugin.PostHook(&ctx, streamChunk("lo", bifrost.Ptr("stop")), nil)
+	plugin.Cleanup()
+	if strings.Contains(pushed(), generationID) {
+		t.Errorf("pushed logs = %q, want the sampled out generation dropped", pushed())
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fasthttp/router"
 	bifrost "github.com/maximhq/bifrost/core"
@@ -23,15 +24,17 @@ import (
 
 // CompletionHandler manages HTTP requests for completion operations
 type CompletionHandler struct {
-	client *bifrost.Bifrost
-	logger schemas.Logger
+	client          *bifrost.Bifrost
+	logger          schemas.Logger
+	streamKeepalive time.Duration // Silence after which an SSE keepalive comment is sent (0 disables)
 }
 
 // NewCompletionHandler creates a new completion handler instance
-func NewCompletionHandler(client *bifrost.Bifrost, logger schemas.Logger) *CompletionHandler {
+func NewCompletionHandler(client *bifrost.Bifrost, logger schemas.Logger, streamKeepalive time.Duration) *CompletionHandler {
 	return &CompletionHandler{
-		client: client,
-		logger: logger,
+		client:          client,
+		logger:          logger,
+		streamKeepalive: streamKeepalive,
 	}
 }
 
@@ -44,12 +47,55 @@ type CompletionRequest struct {
 	Fallbacks []string                 `json:"fallbacks"` // Fallback providers and models in "provider/model" format
 	Stream    *bool                    `json:"stream"`    // Whether to stream the response
 
+	// AutoContinue continues truncated non-streaming chat completions (optional)
+	AutoContinue *schemas.AutoContinueConfig `json:"auto_continue,omitempty"`
+
+	// TruncatedToolCallRetry retries chat completions whose tool call arguments were cut off (optional)
+	TruncatedToolCallRetry *schemas.TruncatedToolCallRetryConfig `json:"truncated_tool_call_retry,omitempty"`
+
+	// Hedging races the fallbacks of non-streaming requests with staggered starts (optional)
+	Hedging *schemas.HedgingConfig `json:"hedging,omitempty"`
+
+	// PrimaryCandidates splits primary traffic across weighted models, overriding Model (optional)
+	PrimaryCandidates []PrimaryCandidate `json:"primary_candidates,omitempty"`
+
+	// ExtraHeaders are sent to the provider with this request, e.g. OpenAI-Organization (optional)
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
+
+	// StreamMaxOutputTokens caps the output of streamed chat completions (optional)
+	StreamMaxOutputTokens int `json:"stream_max_output_tokens,omitempty"`
+
+	// MaxRetries replaces the provider's retry budgets for this request, 0 disables retries (optional)
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// MaxFallbacks caps how many fallbacks are tried after the primary, 0 only tries the primary (optional)
+	MaxFallbacks *int `json:"max_fallbacks,omitempty"`
+
+	// OutputLanguage asks the model to respond in a given language, for chat completions (optional)
+	OutputLanguage *schemas.OutputLanguageConfig `json:"output_language,omitempty"`
+	// CaptureStreamTiming reports the inter-chunk latency of streams in extra_fields.stream_timing (optional)
+	CaptureStreamTiming bool `json:"capture_stream_timing,omitempty"`
+	// ExactMatch sends the request to exactly the given provider and model, bypassing health-based rerouting, decommissioned model replacements and plugin rewrites (optional)
+	ExactMatch bool `json:"exact_match,omitempty"`
+
 	// Speech inputs
 	Input          string                   `json:"input"`
 	Voice          schemas.SpeechVoiceInput `json:"voice"`
 	Instructions   string                   `json:"instructions"`
 	ResponseFormat string                   `json:"response_format"`
 	StreamFormat   *string                  `json:"stream_format,omitempty"`
+
+	// Embedding inputs
+	Texts []string `json:"texts"`
+	// NormalizeEmbeddings scales the returned embeddings to unit L2 norm (optional)
+	NormalizeEmbeddings bool `json:"normalize_embeddings,omitempty"`
+	// EmbeddingRouting spreads the texts across several providers in weighted batches, replacing Model (optional)
+	EmbeddingRouting *schemas.EmbeddingRoutingConfig `json:"embedding_routing,omitempty"`
+}
+
+// PrimaryCandidate is a weighted primary model in "provider/model" format
+type PrimaryCandidate struct {
+	Model  string  `json:"model"`
+	Weight float64 `json:"weight"`
 }
 
 type CompletionType string
@@ -59,6 +105,7 @@ const (
 	CompletionTypeChat          CompletionType = "chat"
 	CompletionTypeSpeech        CompletionType = "speech"
 	CompletionTypeTranscription CompletionType = "transcription"
+	CompletionTypeEmbedding     CompletionType = "embedding"
 )
 
 const (
@@ -160,6 +207,7 @@ func (h *CompletionHandler) RegisterRoutes(r *router.Router) {
 	r.POST("/v1/chat/completions", h.ChatCompletion)
 	r.POST("/v1/audio/speech", h.SpeechCompletion)
 	r.POST("/v1/audio/transcriptions", h.TranscriptionCompletion)
+	r.POST("/v1/embeddings", h.Embedding)
 }
 
 // TextCompletion handles POST /v1/text/completions - Process text completion requests
@@ -177,6 +225,11 @@ func (h *CompletionHandler) SpeechCompletion(ctx *fasthttp.RequestCtx) {
 	h.handleRequest(ctx, CompletionTypeSpeech)
 }
 
+// Embedding handles POST /v1/embeddings - Process embedding requests
+func (h *CompletionHandler) Embedding(ctx *fasthttp.RequestCtx) {
+	h.handleRequest(ctx, CompletionTypeEmbedding)
+}
+
 // TranscriptionCompletion handles POST /v1/audio/transcriptions - Process transcription requests
 func (h *CompletionHandler) TranscriptionCompletion(ctx *fasthttp.RequestCtx) {
 	// Parse multipart form
@@ -258,6 +311,25 @@ func (h *CompletionHandler) TranscriptionCompletion(ctx *fasthttp.RequestCtx) {
 		},
 	}
 
+	// Split long WAV audio into segments that are transcribed and retried separately
+	if segmentValues := form.Value["segment_seconds"]; len(segmentValues) > 0 && segmentValues[0] != "" {
+		segmentSeconds, err := strconv.Atoi(segmentValues[0])
+		if err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid segment_seconds: %v", err), h.logger)
+			return
+		}
+		bifrostReq.TranscriptionChunking = &schemas.TranscriptionChunkingConfig{SegmentSeconds: segmentSeconds}
+
+		if retryValues := form.Value["max_segment_retries"]; len(retryValues) > 0 && retryValues[0] != "" {
+			maxRetries, err := strconv.Atoi(retryValues[0])
+			if err != nil {
+				SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid max_segment_retries: %v", err), h.logger)
+				return
+			}
+			bifrostReq.TranscriptionChunking.MaxSegmentRetries = maxRetries
+		}
+	}
+
 	// Convert context
 	bifrostCtx := lib.ConvertToBifrostContext(ctx)
 	if bifrostCtx == nil {
@@ -295,19 +367,35 @@ func (h *CompletionHandler) handleRequest(ctx *fasthttp.RequestCtx, completionTy
 		return
 	}
 
-	if req.Model == "" {
+	if req.Model == "" && len(req.PrimaryCandidates) == 0 && (completionType != CompletionTypeEmbedding || req.EmbeddingRouting == nil) {
 		SendError(ctx, fasthttp.StatusBadRequest, "Model is required", h.logger)
 		return
 	}
 
-	model := strings.SplitN(req.Model, "/", 2)
-	if len(model) < 2 {
-		SendError(ctx, fasthttp.StatusBadRequest, "Model must be in the format of 'provider/model'", h.logger)
-		return
+	var provider, modelName string
+	if req.Model != "" {
+		model := strings.SplitN(req.Model, "/", 2)
+		if len(model) < 2 {
+			SendError(ctx, fasthttp.StatusBadRequest, "Model must be in the format of 'provider/model'", h.logger)
+			return
+		}
+		provider = model[0]
+		modelName = model[1]
 	}
 
-	provider := model[0]
-	modelName := model[1]
+	primaryCandidates := make([]schemas.PrimaryCandidate, len(req.PrimaryCandidates))
+	for i, candidate := range req.PrimaryCandidates {
+		candidateModel := strings.SplitN(candidate.Model, "/", 2)
+		if len(candidateModel) != 2 {
+			SendError(ctx, fasthttp.StatusBadRequest, "Primary candidate model must be in the format of 'provider/model'", h.logger)
+			return
+		}
+		primaryCandidates[i] = schemas.PrimaryCandidate{
+			Provider: schemas.ModelProvider(candidateModel[0]),
+			Model:    candidateModel[1],
+			Weight:   candidate.Weight,
+		}
+	}
 
 	fallbacks := make([]schemas.Fallback, len(req.Fallbacks))
 	for i, fallback := range req.Fallbacks {
@@ -324,10 +412,24 @@ func (h *CompletionHandler) handleRequest(ctx *fasthttp.RequestCtx, completionTy
 
 	// Create BifrostRequest
 	bifrostReq := &schemas.BifrostRequest{
-		Model:     modelName,
-		Provider:  schemas.ModelProvider(provider),
-		Params:    req.Params,
-		Fallbacks: fallbacks,
+		Model:             modelName,
+		Provider:          schemas.ModelProvider(provider),
+		Params:            req.Params,
+		Fallbacks:         fallbacks,
+		AutoContinue:      req.AutoContinue,
+		Hedging:           req.Hedging,
+		PrimaryCandidates: primaryCandidates,
+		ExtraHeaders:      req.ExtraHeaders,
+		MaxRetries:        req.MaxRetries,
+		MaxFallbacks:      req.MaxFallbacks,
+		OutputLanguage:    req.OutputLanguage,
+
+		StreamMaxOutputTokens:  req.StreamMaxOutputTokens,
+		TruncatedToolCallRetry: req.TruncatedToolCallRetry,
+		CaptureStreamTiming:    req.CaptureStreamTiming,
+		ExactMatch:             req.ExactMatch,
+		EmbeddingRouting:       req.EmbeddingRouting,
+		NormalizeEmbeddings:    req.NormalizeEmbeddings,
 	}
 
 	// Validate and set input based on completion type
@@ -365,6 +467,14 @@ func (h *CompletionHandler) handleRequest(ctx *fasthttp.RequestCtx, completionTy
 				ResponseFormat: req.ResponseFormat,
 			},
 		}
+	case CompletionTypeEmbedding:
+		if len(req.Texts) == 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "Texts are required for embedding", h.logger)
+			return
+		}
+		bifrostReq.Input = schemas.RequestInput{
+			EmbeddingInput: &schemas.EmbeddingInput{Texts: req.Texts},
+		}
 	}
 
 	// Convert context
@@ -400,6 +510,8 @@ func (h *CompletionHandler) handleRequest(ctx *fasthttp.RequestCtx, completionTy
 		resp, bifrostErr = h.client.ChatCompletionRequest(*bifrostCtx, bifrostReq)
 	case CompletionTypeSpeech:
 		resp, bifrostErr = h.client.SpeechRequest(*bifrostCtx, bifrostReq)
+	case CompletionTypeEmbedding:
+		resp, bifrostErr = h.client.EmbeddingRequest(*bifrostCtx, bifrostReq)
 	}
 
 	// Handle response
@@ -445,12 +557,28 @@ func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, ge
 	ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
 		defer w.Flush()
 
+		chunks, stopKeepalive := lib.WithKeepalive(stream, h.streamKeepalive)
+		defer stopKeepalive()
+
 		// Process streaming responses
-		for response := range stream {
+		for response := range chunks {
 			if response == nil {
 				continue
 			}
 
+			// Keep intermediaries from closing the connection during long silences
+			if response == lib.KeepaliveChunk {
+				if _, err := fmt.Fprint(w, lib.SSEKeepaliveComment); err != nil {
+					h.logger.Warn(fmt.Sprintf("Failed to write SSE keepalive: %v", err))
+					break
+				}
+				if err := w.Flush(); err != nil {
+					h.logger.Warn(fmt.Sprintf("Failed to flush SSE keepalive: %v", err))
+					break
+				}
+				continue
+			}
+
 			// Extract and validate the response data
 			data, valid := extractResponse(response)
 			if !valid {
@@ -464,8 +592,8 @@ func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, ge
 				continue
 			}
 
-			// Send as SSE data
-			if _, err := fmt.Fprintf(w, "data: %s\n\n", responseJSON); err != nil {
+			// Send as SSE data, with the chunk's sequence number as event id if enabled
+			if _, err := fmt.Fprintf(w, "%sdata: %s\n\n", lib.SSEEventID(response), responseJSON); err != nil {
 				h.logger.Warn(fmt.Sprintf("Failed to write SSE data: %v", err))
 				break
 			}
@@ -56,6 +56,17 @@ func (h *ConfigHandler) handleUpdateConfig(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	if req.DebugSampleRate < 0 || req.DebugSampleRate > 1 {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("debug_sample_rate must be between 0 and 1, got %v", req.DebugSampleRate), h.logger)
+		return
+	}
+
+	// Validated here, since an invalid value would only be rejected by Bifrost on the next start
+	if req.NoMatchingKeyBehavior != "" && !req.NoMatchingKeyBehavior.IsValid() {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("no_matching_key_behavior must be %q or %q, got %q", schemas.NoMatchingKeyBehaviorError, schemas.NoMatchingKeyBehaviorAnyKey, req.NoMatchingKeyBehavior), h.logger)
+		return
+	}
+
 	// Get current config with proper locking
 	currentConfig := h.store.ClientConfig
 	updatedConfig := currentConfig
@@ -73,6 +84,14 @@ func (h *ConfigHandler) handleUpdateConfig(ctx *fasthttp.RequestCtx) {
 
 	updatedConfig.EnableLogging = req.EnableLogging
 
+	// Takes effect on restart, since plugins are wired at startup
+	updatedConfig.DebugSampleRate = req.DebugSampleRate
+
+	// Takes effect on restart, since it is passed to Bifrost at initialization
+	if req.NoMatchingKeyBehavior != "" {
+		updatedConfig.NoMatchingKeyBehavior = req.NoMatchingKeyBehavior
+	}
+
 	// Update the store with the new config
 	h.store.ClientConfig = updatedConfig
 
@@ -3,6 +3,8 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/fasthttp/router"
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/transports/bifrost-http/integrations"
@@ -17,8 +19,9 @@ type IntegrationHandler struct {
 	extensions []integrations.ExtensionRouter
 }
 
-// NewIntegrationHandler creates a new integration handler instance
-func NewIntegrationHandler(client *bifrost.Bifrost) *IntegrationHandler {
+// NewIntegrationHandler creates a new integration handler instance.
+// streamKeepalive is the silence after which streaming routes send an SSE keepalive comment (0 disables).
+func NewIntegrationHandler(client *bifrost.Bifrost, streamKeepalive time.Duration) *IntegrationHandler {
 	// Initialize all available integration routers
 	extensions := []integrations.ExtensionRouter{
 		genai.NewGenAIRouter(client),
@@ -27,6 +30,12 @@ func NewIntegrationHandler(client *bifrost.Bifrost) *IntegrationHandler {
 		litellm.NewLiteLLMRouter(client),
 	}
 
+	for _, extension := range extensions {
+		if router, ok := extension.(interface{ SetStreamKeepalive(time.Duration) }); ok {
+			router.SetStreamKeepalive(streamKeepalive)
+		}
+	}
+
 	return &IntegrationHandler{
 		extensions: extensions,
 	}
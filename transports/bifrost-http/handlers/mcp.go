@@ -38,6 +38,7 @@ func (h *MCPHandler) RegisterRoutes(r *router.Router) {
 	r.PUT("/api/mcp/client/{name}", h.EditMCPClientTools)
 	r.DELETE("/api/mcp/client/{name}", h.RemoveMCPClient)
 	r.POST("/api/mcp/client/{name}/reconnect", h.ReconnectMCPClient)
+	r.GET("/api/mcp/client/{name}/health", h.GetMCPClientHealth)
 }
 
 // ExecuteTool handles POST /v1/mcp/tool/execute - Execute MCP tool
@@ -101,10 +102,11 @@ func (h *MCPHandler) GetMCPClients(ctx *fasthttp.RequestCtx) {
 		if connectedClient, exists := connectedClientsMap[configClient.Name]; exists {
 			// Client is connected, use the actual client data
 			clients = append(clients, schemas.MCPClient{
-				Name:   connectedClient.Name,
-				Config: h.store.RedactMCPClientConfig(connectedClient.Config),
-				Tools:  connectedClient.Tools,
-				State:  connectedClient.State,
+				Name:            connectedClient.Name,
+				Config:          h.store.RedactMCPClientConfig(connectedClient.Config),
+				Tools:           connectedClient.Tools,
+				State:           connectedClient.State,
+				LastHealthCheck: connectedClient.LastHealthCheck,
 			})
 		} else {
 			// Client is in config but not connected, mark as errored
@@ -139,6 +141,34 @@ func (h *MCPHandler) ReconnectMCPClient(ctx *fasthttp.RequestCtx) {
 	}, h.logger)
 }
 
+// GetMCPClientHealth handles GET /api/mcp/client/{name}/health - Get the connection state of an MCP client
+func (h *MCPHandler) GetMCPClientHealth(ctx *fasthttp.RequestCtx) {
+	name, err := getNameFromCtx(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid name: %v", err), h.logger)
+		return
+	}
+
+	clients, err := h.client.GetMCPClients()
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get MCP clients from Bifrost: %v", err), h.logger)
+		return
+	}
+
+	for _, client := range clients {
+		if client.Name == name {
+			SendJSON(ctx, map[string]any{
+				"name":              client.Name,
+				"state":             client.State,
+				"last_health_check": client.LastHealthCheck,
+			}, h.logger)
+			return
+		}
+	}
+
+	SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("MCP client %s not found", name), h.logger)
+}
+
 // AddMCPClient handles POST /api/mcp/client - Add a new MCP client
 func (h *MCPHandler) AddMCPClient(ctx *fasthttp.RequestCtx) {
 	var req schemas.MCPClientConfig
@@ -42,6 +42,13 @@ type AddProviderRequest struct {
 	ConcurrencyAndBufferSize *schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size,omitempty"` // Concurrency settings
 	ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`                // Proxy configuration
 	SendBackRawResponse      *bool                             `json:"send_back_raw_response,omitempty"`      // Include raw response in BifrostResponse
+	SendBackRawRequest       *bool                             `json:"send_back_raw_request,omitempty"`       // Include the request body sent to the provider in BifrostResponse
+	LogStreamChunks          *bool                             `json:"log_stream_chunks,omitempty"`           // Log raw and parsed stream chunks at debug level
+	DecommissionedModels     map[string]string                 `json:"decommissioned_models,omitempty"`       // Retired model IDs mapped to their replacements
+	ImageLimits              *schemas.ImageLimits              `json:"image_limits,omitempty"`                // Downscale larger image inputs
+	TextCompletionModels     []string                          `json:"text_completion_models,omitempty"`      // Models whose chat completions are sent as text completions
+	ChatTemplate             *schemas.ChatTemplate             `json:"chat_template,omitempty"`               // Renders chat messages for text completion models
+	TLSConfig                *schemas.TLSConfig                `json:"tls_config,omitempty"`                  // TLS settings, e.g. custom root CAs
 }
 
 // UpdateProviderRequest represents the request body for updating a provider
@@ -52,6 +59,13 @@ type UpdateProviderRequest struct {
 	ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size"`      // Concurrency settings
 	ProxyConfig              *schemas.ProxyConfig             `json:"proxy_config,omitempty"`           // Proxy configuration
 	SendBackRawResponse      *bool                            `json:"send_back_raw_response,omitempty"` // Include raw response in BifrostResponse
+	SendBackRawRequest       *bool                            `json:"send_back_raw_request,omitempty"`  // Include the request body sent to the provider in BifrostResponse
+	LogStreamChunks          *bool                            `json:"log_stream_chunks,omitempty"`      // Log raw and parsed stream chunks at debug level
+	DecommissionedModels     map[string]string                `json:"decommissioned_models,omitempty"`  // Retired model IDs mapped to their replacements (unchanged if omitted)
+	ImageLimits              *schemas.ImageLimits             `json:"image_limits,omitempty"`           // Downscale larger image inputs (unchanged if omitted)
+	TextCompletionModels     []string                         `json:"text_completion_models,omitempty"` // Models whose chat completions are sent as text completions (unchanged if omitted)
+	ChatTemplate             *schemas.ChatTemplate            `json:"chat_template,omitempty"`          // Renders chat messages for text completion models (unchanged if omitted)
+	TLSConfig                *schemas.TLSConfig               `json:"tls_config,omitempty"`             // TLS settings, e.g. custom root CAs
 }
 
 // ProviderResponse represents the response for provider operations
@@ -63,6 +77,13 @@ type ProviderResponse struct {
 	ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size"` // Concurrency settings
 	ProxyConfig              *schemas.ProxyConfig             `json:"proxy_config"`                // Proxy configuration
 	SendBackRawResponse      bool                             `json:"send_back_raw_response"`      // Include raw response in BifrostResponse
+	SendBackRawRequest       bool                             `json:"send_back_raw_request"`       // Include the request body sent to the provider in BifrostResponse
+	LogStreamChunks          bool                             `json:"log_stream_chunks"`           // Log raw and parsed stream chunks at debug level
+	DecommissionedModels     map[string]string                `json:"decommissioned_models"`       // Retired model IDs mapped to their replacements
+	ImageLimits              *schemas.ImageLimits             `json:"image_limits"`                // Downscale larger image inputs
+	TextCompletionModels     []string                         `json:"text_completion_models"`      // Models whose chat completions are sent as text completions
+	ChatTemplate             *schemas.ChatTemplate            `json:"chat_template"`               // Renders chat messages for text completion models
+	TLSConfig                *schemas.TLSConfig               `json:"tls_config"`                  // TLS settings, e.g. custom root CAs
 }
 
 // ListProvidersResponse represents the response for listing all providers
@@ -82,6 +103,7 @@ func (h *ProviderHandler) RegisterRoutes(r *router.Router) {
 	// Provider CRUD operations
 	r.GET("/api/providers", h.ListProviders)
 	r.GET("/api/providers/{provider}", h.GetProvider)
+	r.GET("/api/providers/{provider}/capabilities", h.GetProviderCapabilities)
 	r.POST("/api/providers", h.AddProvider)
 	r.PUT("/api/providers/{provider}", h.UpdateProvider)
 	r.DELETE("/api/providers/{provider}", h.DeleteProvider)
@@ -143,6 +165,23 @@ func (h *ProviderHandler) GetProvider(ctx *fasthttp.RequestCtx) {
 	SendJSON(ctx, response, h.logger)
 }
 
+// GetProviderCapabilities handles GET /api/providers/{provider}/capabilities - Get the operations and features a provider supports
+func (h *ProviderHandler) GetProviderCapabilities(ctx *fasthttp.RequestCtx) {
+	provider, err := getProviderFromCtx(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid provider: %v", err), h.logger)
+		return
+	}
+
+	capabilities, err := h.client.GetCapabilities(provider)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Provider not found: %v", err), h.logger)
+		return
+	}
+
+	SendJSON(ctx, capabilities, h.logger)
+}
+
 // AddProvider handles POST /api/providers - Add a new provider
 func (h *ProviderHandler) AddProvider(ctx *fasthttp.RequestCtx) {
 	var req AddProviderRequest
@@ -186,6 +225,13 @@ func (h *ProviderHandler) AddProvider(ctx *fasthttp.RequestCtx) {
 		NetworkConfig:            req.NetworkConfig,
 		ConcurrencyAndBufferSize: req.ConcurrencyAndBufferSize,
 		SendBackRawResponse:      req.SendBackRawResponse != nil && *req.SendBackRawResponse,
+		SendBackRawRequest:       req.SendBackRawRequest != nil && *req.SendBackRawRequest,
+		LogStreamChunks:          req.LogStreamChunks != nil && *req.LogStreamChunks,
+		DecommissionedModels:     req.DecommissionedModels,
+		ImageLimits:              req.ImageLimits,
+		TextCompletionModels:     req.TextCompletionModels,
+		ChatTemplate:             req.ChatTemplate,
+		TLSConfig:                req.TLSConfig,
 	}
 
 	// Handle meta config if provided
@@ -255,6 +301,11 @@ func (h *ProviderHandler) UpdateProvider(ctx *fasthttp.RequestCtx) {
 		NetworkConfig:            oldConfigRaw.NetworkConfig,
 		ConcurrencyAndBufferSize: oldConfigRaw.ConcurrencyAndBufferSize,
 		ProxyConfig:              oldConfigRaw.ProxyConfig,
+		DecommissionedModels:     oldConfigRaw.DecommissionedModels,
+		ImageLimits:              oldConfigRaw.ImageLimits,
+		TextCompletionModels:     oldConfigRaw.TextCompletionModels,
+		ChatTemplate:             oldConfigRaw.ChatTemplate,
+		TLSConfig:                oldConfigRaw.TLSConfig,
 	}
 
 	// Environment variable cleanup is now handled automatically by mergeKeys function
@@ -316,9 +367,28 @@ func (h *ProviderHandler) UpdateProvider(ctx *fasthttp.RequestCtx) {
 	config.ConcurrencyAndBufferSize = &req.ConcurrencyAndBufferSize
 	config.NetworkConfig = &req.NetworkConfig
 	config.ProxyConfig = req.ProxyConfig
+	config.TLSConfig = req.TLSConfig
 	if req.SendBackRawResponse != nil {
 		config.SendBackRawResponse = *req.SendBackRawResponse
 	}
+	if req.SendBackRawRequest != nil {
+		config.SendBackRawRequest = *req.SendBackRawRequest
+	}
+	if req.LogStreamChunks != nil {
+		config.LogStreamChunks = *req.LogStreamChunks
+	}
+	if req.DecommissionedModels != nil {
+		config.DecommissionedModels = req.DecommissionedModels
+	}
+	if req.ImageLimits != nil {
+		config.ImageLimits = req.ImageLimits
+	}
+	if req.TextCompletionModels != nil {
+		config.TextCompletionModels = req.TextCompletionModels
+	}
+	if req.ChatTemplate != nil {
+		config.ChatTemplate = req.ChatTemplate
+	}
 
 	// Update provider config in store (env vars will be processed by store)
 	if err := h.store.UpdateProviderConfig(provider, config); err != nil {
@@ -327,6 +397,12 @@ func (h *ProviderHandler) UpdateProvider(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	// Revoke deleted keys in Bifrost once the update is applied, so they are never selected again
+	// and in-flight requests stop using them
+	if len(keysToDelete) > 0 && h.client != nil {
+		h.client.RevokeKeys(provider, keysToDelete, true)
+	}
+
 	if err := h.store.SaveConfig(); err != nil {
 		h.logger.Warn(fmt.Sprintf("Failed to save configuration: %v", err))
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to save configuration: %v", err), h.logger)
@@ -415,6 +491,7 @@ func (h *ProviderHandler) mergeKeys(provider schemas.ModelProvider, oldRawKeys [
 	// Clean up environment variables for deleted and updated keys
 	h.store.CleanupEnvKeysForKeys(string(provider), keysToDelete)
 	h.store.CleanupEnvKeysForUpdatedKeys(string(provider), keysToUpdate)
+
 	// Create a map of indices to delete
 	toDelete := make(map[int]bool)
 	for _, key := range keysToDelete {
@@ -540,12 +617,19 @@ func (h *ProviderHandler) mergeMetaConfig(provider schemas.ModelProvider, oldCon
 }
 
 func (h *ProviderHandler) getProviderResponseFromConfig(provider schemas.ModelProvider, config lib.ProviderConfig) ProviderResponse {
-	if config.NetworkConfig == nil {
-		config.NetworkConfig = &schemas.DefaultNetworkConfig
+	// Show the settings Bifrost uses: each field the provider leaves unset is inherited from the
+	// client defaults, then from the built-in defaults
+	effective := schemas.ProviderConfig{}
+	if config.NetworkConfig != nil {
+		effective.NetworkConfig = *config.NetworkConfig
 	}
-	if config.ConcurrencyAndBufferSize == nil {
-		config.ConcurrencyAndBufferSize = &schemas.DefaultConcurrencyAndBufferSize
+	if config.ConcurrencyAndBufferSize != nil {
+		effective.ConcurrencyAndBufferSize = *config.ConcurrencyAndBufferSize
 	}
+	effective.ApplyDefaults(h.store.ClientConfig.DefaultNetworkConfig, h.store.ClientConfig.DefaultConcurrencyAndBufferSize)
+	effective.CheckAndSetDefaults()
+	config.NetworkConfig = &effective.NetworkConfig
+	config.ConcurrencyAndBufferSize = &effective.ConcurrencyAndBufferSize
 
 	return ProviderResponse{
 		Name:                     provider,
@@ -555,6 +639,13 @@ func (h *ProviderHandler) getProviderResponseFromConfig(provider schemas.ModelPr
 		ConcurrencyAndBufferSize: *config.ConcurrencyAndBufferSize,
 		ProxyConfig:              config.ProxyConfig,
 		SendBackRawResponse:      config.SendBackRawResponse,
+		SendBackRawRequest:       config.SendBackRawRequest,
+		LogStreamChunks:          config.LogStreamChunks,
+		DecommissionedModels:     config.DecommissionedModels,
+		ImageLimits:              config.ImageLimits,
+		TextCompletionModels:     config.TextCompletionModels,
+		ChatTemplate:             config.ChatTemplate,
+		TLSConfig:                config.TLSConfig,
 	}
 }
 
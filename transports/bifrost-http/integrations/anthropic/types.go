@@ -56,8 +56,9 @@ type AnthropicTool struct {
 
 // AnthropicToolChoice represents tool choice in Anthropic format
 type AnthropicToolChoice struct {
-	Type string `json:"type"`           // "auto", "any", "tool"
-	Name string `json:"name,omitempty"` // For type "tool"
+	Type                   string `json:"type"`                                // "auto", "any", "tool"
+	Name                   string `json:"name,omitempty"`                      // For type "tool"
+	DisableParallelToolUse *bool  `json:"disable_parallel_tool_use,omitempty"` // At most one tool use per response if true
 }
 
 // AnthropicMessageRequest represents an Anthropic messages API request
@@ -94,8 +95,25 @@ type AnthropicMessageResponse struct {
 
 // AnthropicUsage represents usage information in Anthropic format
 type AnthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// toAnthropicUsage converts Bifrost usage into Anthropic format, where input tokens
+// exclude the tokens read from or written to the prompt cache.
+func toAnthropicUsage(usage *schemas.LLMUsage) *AnthropicUsage {
+	anthropicUsage := &AnthropicUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+	}
+	if usage.TokenDetails != nil {
+		anthropicUsage.CacheReadInputTokens = usage.TokenDetails.CachedTokens
+		anthropicUsage.CacheCreationInputTokens = usage.TokenDetails.CacheCreationTokens
+		anthropicUsage.InputTokens -= usage.TokenDetails.CachedTokens + usage.TokenDetails.CacheCreationTokens
+	}
+	return anthropicUsage
 }
 
 // AnthropicMessageError represents an Anthropic messages API error response
@@ -411,6 +429,9 @@ func (r *AnthropicMessageRequest) ConvertToBifrostRequest() *schemas.BifrostRequ
 			}
 		}
 		bifrostReq.Params.ToolChoice = toolChoice
+		if r.ToolChoice.DisableParallelToolUse != nil {
+			bifrostReq.Params.ParallelToolCalls = bifrost.Ptr(!*r.ToolChoice.DisableParallelToolUse)
+		}
 	}
 
 	return bifrostReq
@@ -443,10 +464,7 @@ func DeriveAnthropicFromBifrostResponse(bifrostResp *schemas.BifrostResponse) *A
 
 	// Convert usage information
 	if bifrostResp.Usage != nil {
-		anthropicResp.Usage = &AnthropicUsage{
-			InputTokens:  bifrostResp.Usage.PromptTokens,
-			OutputTokens: bifrostResp.Usage.CompletionTokens,
-		}
+		anthropicResp.Usage = toAnthropicUsage(bifrostResp.Usage)
 	}
 
 	// Convert choices to content
@@ -455,7 +473,7 @@ func DeriveAnthropicFromBifrostResponse(bifrostResp *schemas.BifrostResponse) *A
 		choice := bifrostResp.Choices[0] // Anthropic typically returns one choice
 
 		if choice.FinishReason != nil {
-			anthropicResp.StopReason = choice.FinishReason
+			anthropicResp.StopReason = toAnthropicStopReason(choice.FinishReason)
 		}
 		if choice.StopString != nil {
 			anthropicResp.StopSequence = choice.StopString
@@ -576,7 +594,7 @@ func DeriveAnthropicStreamFromBifrostResponse(bifrostResp *schemas.BifrostRespon
 				streamResp.Type = "message_delta"
 				streamResp.Delta = &AnthropicStreamDelta{
 					Type:       "message_delta",
-					StopReason: choice.FinishReason,
+					StopReason: toAnthropicStopReason(choice.FinishReason),
 				}
 			}
 
@@ -611,10 +629,7 @@ func DeriveAnthropicStreamFromBifrostResponse(bifrostResp *schemas.BifrostRespon
 		if streamResp.Type == "" {
 			streamResp.Type = "message_delta"
 		}
-		streamResp.Usage = &AnthropicUsage{
-			InputTokens:  bifrostResp.Usage.PromptTokens,
-			OutputTokens: bifrostResp.Usage.CompletionTokens,
-		}
+		streamResp.Usage = toAnthropicUsage(bifrostResp.Usage)
 	}
 
 	// Set common fields
@@ -689,3 +704,12 @@ func DeriveAnthropicStreamFromBifrostError(bifrostErr *schemas.BifrostError) str
 	// Format as Anthropic SSE error event
 	return fmt.Sprintf("event: error\ndata: %s\n\n", jsonData)
 }
+
+// toAnthropicStopReason converts a Bifrost finish reason into an Anthropic stop reason.
+// Content filter blocks are reported as refusals, the closest Anthropic equivalent.
+func toAnthropicStopReason(finishReason *string) *string {
+	if *finishReason == schemas.FinishReasonContentFilter {
+		return bifrost.Ptr("refusal")
+	}
+	return finishReason
+}
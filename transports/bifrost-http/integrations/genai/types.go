@@ -494,7 +494,7 @@ func DeriveGenAIFromBifrostResponse(bifrostResp *schemas.BifrostResponse) *genai
 			Index: int32(choice.Index),
 		}
 		if choice.FinishReason != nil {
-			candidate.FinishReason = genai_sdk.FinishReason(*choice.FinishReason)
+			candidate.FinishReason = toGenAIFinishReason(*choice.FinishReason)
 		}
 
 		if bifrostResp.Usage != nil {
@@ -612,7 +612,7 @@ func DeriveGeminiStreamFromBifrostResponse(bifrostResp *schemas.BifrostResponse)
 
 		// Set finish reason if present
 		if choice.FinishReason != nil {
-			candidate.FinishReason = genai_sdk.FinishReason(*choice.FinishReason)
+			candidate.FinishReason = toGenAIFinishReason(*choice.FinishReason)
 		}
 
 		// Set token count if available
@@ -765,3 +765,12 @@ func isImageMimeType(mimeType string) bool {
 
 	return false
 }
+
+// toGenAIFinishReason converts a Bifrost finish reason into a GenAI finish reason.
+// Content filter blocks are reported as safety blocks.
+func toGenAIFinishReason(finishReason string) genai_sdk.FinishReason {
+	if finishReason == schemas.FinishReasonContentFilter {
+		return genai_sdk.FinishReasonSafety
+	}
+	return genai_sdk.FinishReason(finishReason)
+}
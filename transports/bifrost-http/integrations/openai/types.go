@@ -25,6 +25,8 @@ type OpenAIChatRequest struct {
 	TopLogProbs      *int                     `json:"top_logprobs,omitempty"`
 	ResponseFormat   interface{}              `json:"response_format,omitempty"`
 	Seed             *int                     `json:"seed,omitempty"`
+
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
 }
 
 // OpenAISpeechRequest represents an OpenAI speech synthesis request
@@ -224,6 +226,7 @@ func (r *OpenAIChatRequest) convertParameters() *schemas.ModelParameters {
 
 	params.Tools = r.Tools
 	params.ToolChoice = r.ToolChoice
+	params.ParallelToolCalls = r.ParallelToolCalls
 
 	// Direct field mapping
 	if r.MaxTokens != nil {
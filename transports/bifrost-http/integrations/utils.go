@@ -54,6 +54,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"bufio"
 
@@ -155,8 +156,9 @@ type RouteConfig struct {
 // It handles the common flow of: parse request → convert to Bifrost → execute → convert response.
 // Integration-specific logic is handled through the RouteConfig callbacks and converters.
 type GenericRouter struct {
-	client *bifrost.Bifrost // Bifrost client for executing requests
-	routes []RouteConfig    // List of route configurations
+	client          *bifrost.Bifrost // Bifrost client for executing requests
+	routes          []RouteConfig    // List of route configurations
+	streamKeepalive time.Duration    // Silence after which an SSE keepalive comment is sent (0 disables)
 }
 
 // NewGenericRouter creates a new generic router with the given bifrost client and route configurations.
@@ -168,6 +170,12 @@ func NewGenericRouter(client *bifrost.Bifrost, routes []RouteConfig) *GenericRou
 	}
 }
 
+// SetStreamKeepalive sets how long a stream may stay silent before an SSE keepalive comment
+// is sent to the client. A zero interval disables keepalives.
+func (g *GenericRouter) SetStreamKeepalive(interval time.Duration) {
+	g.streamKeepalive = interval
+}
+
 // RegisterRoutes registers all configured routes on the given fasthttp router.
 // This method implements the ExtensionRouter interface.
 func (g *GenericRouter) RegisterRoutes(r *router.Router) {
@@ -272,6 +280,7 @@ func (g *GenericRouter) createHandler(config RouteConfig) fasthttp.RequestHandle
 			g.sendError(ctx, config.ErrorConverter, newBifrostError(nil, "Model parameter is required"))
 			return
 		}
+		forwardRequestHeaders(ctx, bifrostReq)
 
 		// Check if streaming is requested
 		isStreaming := false
@@ -433,12 +442,26 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 	ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
 		defer w.Flush()
 
+		chunks, stopKeepalive := lib.WithKeepalive(streamChan, g.streamKeepalive)
+		defer stopKeepalive()
+
 		// Process streaming responses
-		for response := range streamChan {
+		for response := range chunks {
 			if response == nil {
 				continue
 			}
 
+			// Keep intermediaries from closing the connection during long silences
+			if response == lib.KeepaliveChunk {
+				if _, err := fmt.Fprint(w, lib.SSEKeepaliveComment); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+				continue
+			}
+
 			// Check for context cancellation
 			select {
 			case <-ctx.Done():
@@ -472,7 +495,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					// CUSTOM SSE FORMAT: The converter returned a complete SSE string
 					// This is used by providers like Anthropic that need custom event types
 					// Example: "event: error\ndata: {...}\n\n"
-					if _, err := fmt.Fprint(w, sseErrorString); err != nil {
+					if _, err := fmt.Fprint(w, lib.SSEEventID(response)+sseErrorString); err != nil {
 						return
 					}
 				} else {
@@ -494,7 +517,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					}
 
 					// Send error as SSE data
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", errorJSON); err != nil {
+					if _, err := fmt.Fprintf(w, "%sdata: %s\n\n", lib.SSEEventID(response), errorJSON); err != nil {
 						return
 					}
 				}
@@ -530,7 +553,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					// CUSTOM SSE FORMAT: The converter returned a complete SSE string
 					// This is used by providers like Anthropic that need custom event types
 					// Example: "event: content_block_delta\ndata: {...}\n\n"
-					if _, err := fmt.Fprint(w, sseString); err != nil {
+					if _, err := fmt.Fprint(w, lib.SSEEventID(response)+sseString); err != nil {
 						return // Network error, stop streaming
 					}
 				} else {
@@ -545,7 +568,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					}
 
 					// Send as SSE data
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", responseJSON); err != nil {
+					if _, err := fmt.Fprintf(w, "%sdata: %s\n\n", lib.SSEEventID(response), responseJSON); err != nil {
 						return // Network error, stop streaming
 					}
 				}
@@ -559,6 +582,22 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 	})
 }
 
+// forwardRequestHeaders copies the allowlisted per-request headers (e.g. OpenAI-Organization and
+// OpenAI-Project, as sent by the OpenAI SDKs) from the incoming request to the Bifrost request,
+// so they reach the provider.
+func forwardRequestHeaders(ctx *fasthttp.RequestCtx, bifrostReq *schemas.BifrostRequest) {
+	for _, name := range schemas.AllowedRequestHeaders {
+		value := ctx.Request.Header.Peek(name)
+		if len(value) == 0 {
+			continue
+		}
+		if bifrostReq.ExtraHeaders == nil {
+			bifrostReq.ExtraHeaders = make(map[string]string)
+		}
+		bifrostReq.ExtraHeaders[name] = string(value)
+	}
+}
+
 // sendStreamError sends an error in streaming format using the stream error converter if available
 func (g *GenericRouter) sendStreamError(ctx *fasthttp.RequestCtx, config RouteConfig, bifrostErr *schemas.BifrostError) {
 	var errorResponse interface{}
@@ -68,10 +68,9 @@ func (baseAccount *BaseAccount) GetConfigForProvider(providerKey schemas.ModelPr
 		providerConfig.ProxyConfig = config.ProxyConfig
 	}
 
+	// Unset network and concurrency settings are filled by Bifrost from the client defaults
 	if config.NetworkConfig != nil {
 		providerConfig.NetworkConfig = *config.NetworkConfig
-	} else {
-		providerConfig.NetworkConfig = schemas.DefaultNetworkConfig
 	}
 
 	if config.MetaConfig != nil {
@@ -80,11 +79,16 @@ func (baseAccount *BaseAccount) GetConfigForProvider(providerKey schemas.ModelPr
 
 	if config.ConcurrencyAndBufferSize != nil {
 		providerConfig.ConcurrencyAndBufferSize = *config.ConcurrencyAndBufferSize
-	} else {
-		providerConfig.ConcurrencyAndBufferSize = schemas.DefaultConcurrencyAndBufferSize
 	}
 
 	providerConfig.SendBackRawResponse = config.SendBackRawResponse
+	providerConfig.SendBackRawRequest = config.SendBackRawRequest
+	providerConfig.LogStreamChunks = config.LogStreamChunks
+	providerConfig.DecommissionedModels = config.DecommissionedModels
+	providerConfig.ImageLimits = config.ImageLimits
+	providerConfig.TextCompletionModels = config.TextCompletionModels
+	providerConfig.ChatTemplate = config.ChatTemplate
+	providerConfig.TLSConfig = config.TLSConfig
 
 	return providerConfig, nil
 }
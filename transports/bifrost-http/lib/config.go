@@ -13,6 +13,27 @@ type ClientConfig struct {
 	InitialPoolSize    int      `json:"initial_pool_size"`    // The initial pool size for the bifrost client
 	PrometheusLabels   []string `json:"prometheus_labels"`    // The labels to be used for prometheus metrics
 	EnableLogging      bool     `json:"enable_logging"`       // Enable logging of requests and responses
+	DebugSampleRate    float64  `json:"debug_sample_rate"`    // Fraction (0.0-1.0) of requests whose redacted bodies are logged at debug level
+
+	MaxConcurrentRequests  int                           `json:"max_concurrent_requests,omitempty"`  // Global cap on in-flight provider calls across all providers (0 = unbounded)
+	StreamBufferSize       int                           `json:"stream_buffer_size,omitempty"`       // Chunks buffered by each provider stream (0 = default of 100)
+	NoMatchingKeyBehavior  schemas.NoMatchingKeyBehavior `json:"no_matching_key_behavior,omitempty"` // "error" (default) or "any_key" when no key lists the requested model
+	StreamFallbackAdapter  bool                          `json:"stream_fallback_adapter,omitempty"`  // Serve streaming fallbacks to non-streaming providers as a single chunk
+	EmptyStreamBehavior    schemas.EmptyStreamBehavior   `json:"empty_stream_behavior,omitempty"`    // "allow" (default) or "error" to retry and fall back on streams without content
+	StrictInit             bool                          `json:"strict_init,omitempty"`              // Fail startup if no provider could be initialized
+	StreamKeepaliveSeconds int                           `json:"stream_keepalive_seconds,omitempty"` // Send an SSE keepalive comment after this many seconds of stream silence (0 = disabled)
+	StreamSequenceNumbers  bool                          `json:"stream_sequence_numbers,omitempty"`  // Number stream chunks in the SSE event id
+	StreamChecksums        bool                          `json:"stream_checksums,omitempty"`         // Add the running CRC-32 of the streamed content to the SSE event id (implies stream_sequence_numbers)
+
+	KeyWeightsByModelCount      bool `json:"key_weights_by_model_count,omitempty"`       // Divide key weights by the number of models each key lists
+	KeyErrorRateHalfLifeSeconds int  `json:"key_error_rate_half_life_seconds,omitempty"` // Deprioritize keys with recent failures, forgetting them with this half-life (0 = disabled)
+	KeyRateLimitAware           bool `json:"key_rate_limit_aware,omitempty"`             // Deprioritize keys close to the rate limits reported by their providers
+
+	ProviderHealthFailureThreshold     int `json:"provider_health_failure_threshold,omitempty"`      // Skip primaries after this many consecutive failures (0 = disabled)
+	ProviderHealthProbeIntervalSeconds int `json:"provider_health_probe_interval_seconds,omitempty"` // Seconds between probes of an unhealthy provider (0 = default of 30)
+
+	DefaultNetworkConfig            *schemas.NetworkConfig            `json:"default_network_config,omitempty"`              // Network settings inherited by providers that leave them unset
+	DefaultConcurrencyAndBufferSize *schemas.ConcurrencyAndBufferSize `json:"default_concurrency_and_buffer_size,omitempty"` // Concurrency settings inherited by providers that leave them unset
 }
 
 // ProviderConfig represents the configuration for a specific AI model provider.
@@ -24,6 +45,13 @@ type ProviderConfig struct {
 	ConcurrencyAndBufferSize *schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size,omitempty"` // Concurrency settings
 	ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`                // Proxy configuration
 	SendBackRawResponse      bool                              `json:"send_back_raw_response"`                // Include raw response in BifrostResponse
+	SendBackRawRequest       bool                              `json:"send_back_raw_request,omitempty"`       // Include the request body sent to the provider in BifrostResponse
+	LogStreamChunks          bool                              `json:"log_stream_chunks,omitempty"`           // Log raw and parsed stream chunks at debug level
+	DecommissionedModels     map[string]string                 `json:"decommissioned_models,omitempty"`       // Retired model IDs mapped to their replacements
+	ImageLimits              *schemas.ImageLimits              `json:"image_limits,omitempty"`                // Downscale larger image inputs
+	TextCompletionModels     []string                          `json:"text_completion_models,omitempty"`      // Models whose chat completions are sent as text completions
+	ChatTemplate             *schemas.ChatTemplate             `json:"chat_template,omitempty"`               // Renders chat messages for text completion models
+	TLSConfig                *schemas.TLSConfig                `json:"tls_config,omitempty"`                  // TLS settings, e.g. custom root CAs
 }
 
 // ConfigMap maps provider names to their configurations.
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/plugins/maxim"
 	"github.com/maximhq/bifrost/transports/bifrost-http/plugins/logging"
 	"github.com/maximhq/bifrost/transports/bifrost-http/plugins/telemetry"
@@ -60,6 +61,7 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx) *context.Context {
 		requestID = uuid.New().String()
 	}
 	bifrostCtx = context.WithValue(bifrostCtx, logging.ContextKey("request-id"), requestID)
+	bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyRequestID, requestID)
 
 	// Then process other headers
 	ctx.Request.Header.VisitAll(func(key, value []byte) {
@@ -0,0 +1,62 @@
+package lib
+
+import (
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// SSEKeepaliveComment is written to SSE streams during long silences. Lines starting with ':'
+// are comments that clients ignore, but they reset the idle timers of proxies and load balancers.
+const SSEKeepaliveComment = ": keepalive\n\n"
+
+// KeepaliveChunk is received from a stream wrapped by WithKeepalive when no chunk arrived
+// within the keepalive interval. Consumers identify it by pointer equality.
+var KeepaliveChunk = &schemas.BifrostStream{}
+
+// WithKeepalive forwards every chunk of stream to the returned channel, and sends KeepaliveChunk
+// whenever the stream has been silent for interval. The silence timer restarts with every chunk,
+// so no keepalives are sent while data is flowing. The returned channel is closed when stream is
+// closed. Call stop once done reading to release the forwarding goroutine.
+// If interval is not positive, stream is returned unchanged.
+func WithKeepalive(stream chan *schemas.BifrostStream, interval time.Duration) (chunks <-chan *schemas.BifrostStream, stop func()) {
+	if interval <= 0 {
+		return stream, func() {}
+	}
+
+	out := make(chan *schemas.BifrostStream)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+
+		for {
+			var chunk *schemas.BifrostStream
+			select {
+			case received, ok := <-stream:
+				if !ok {
+					return
+				}
+				chunk = received
+			case <-timer.C:
+				chunk = KeepaliveChunk
+			case <-done:
+				return
+			}
+
+			select {
+			case out <- chunk:
+			case <-done:
+				return
+			}
+			timer.Reset(interval)
+		}
+	}()
+
+	var once sync.Once
+	return out, func() { once.Do(func() { close(done) }) }
+}
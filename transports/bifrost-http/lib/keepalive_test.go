@@ -0,0 +1,54 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestWithKeepaliveFillsSilences(t *testing.T) {
+	stream := make(chan *schemas.BifrostStream)
+	chunks, stop := WithKeepalive(stream, 20*time.Millisecond)
+	defer stop()
+
+	first, second := &schemas.BifrostStream{}, &schemas.BifrostStream{}
+	go func() {
+		stream <- first
+		time.Sleep(70 * time.Millisecond)
+		stream <- second
+		close(stream)
+	}()
+
+	// Chunks are forwarded in order, with keepalives only during the silence between them
+	var received []*schemas.BifrostStream
+	for chunk := range chunks {
+		received = append(received, chunk)
+	}
+	if len(received) < 3 || received[0] != first || received[len(received)-1] != second {
+		t.Fatalf("received %d chunks, want the first chunk, keepalives and the second chunk", len(received))
+	}
+	for _, chunk := range received[1 : len(received)-1] {
+		if chunk != KeepaliveChunk {
+			t.Errorf("received %p between the chunks, want only keepalives", chunk)
+		}
+	}
+
+	// Stopping releases the reader of a stream that never closes
+	idle := make(chan *schemas.BifrostStream)
+	chunks, stopIdle := WithKeepalive(idle, time.Hour)
+	stopIdle()
+	select {
+	case _, ok := <-chunks:
+		if ok {
+			t.Error("received a chunk after stop, want the channel closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("channel still open after stop")
+	}
+
+	// Without an interval the stream is returned as is
+	if chunks, _ := WithKeepalive(idle, 0); chunks != (<-chan *schemas.BifrostStream)(idle) {
+		t.Error("WithKeepalive() with no interval wrapped the stream")
+	}
+}
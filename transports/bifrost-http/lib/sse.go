@@ -0,0 +1,20 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// SSEEventID returns the id line of the SSE event of a stream chunk, to be written before its data
+// line, or "" if the stream is not sequenced. The id is the chunk's sequence number, followed by
+// ':' and its checksum in hex if checksums are enabled (e.g. "id: 42:1c291ca3").
+func SSEEventID(chunk *schemas.BifrostStream) string {
+	if chunk.Sequence == 0 {
+		return ""
+	}
+	if chunk.Checksum != nil {
+		return fmt.Sprintf("id: %d:%08x\n", chunk.Sequence, *chunk.Checksum)
+	}
+	return fmt.Sprintf("id: %d\n", chunk.Sequence)
+}
@@ -3,9 +3,11 @@
 package lib
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -21,7 +23,7 @@ import (
 //
 // Features:
 //   - Pure in-memory storage for ultra-fast access
-//   - Environment variable processing for API keys and key-level configurations
+//   - Environment variable processing for API keys, key-level, network and meta configurations
 //   - Thread-safe operations with read-write mutexes
 //   - Real-time configuration updates via HTTP API
 //   - Explicit persistence control via WriteConfigToFile()
@@ -46,7 +48,7 @@ type ConfigStore struct {
 type EnvKeyInfo struct {
 	EnvVar     string // The environment variable name (without env. prefix)
 	Provider   string // The provider this key belongs to (empty for core/mcp configs)
-	KeyType    string // Type of key (e.g., "api_key", "azure_config", "vertex_config", "meta_config", "connection_string")
+	KeyType    string // Type of key (e.g., "api_key", "azure_config", "vertex_config", "meta_config", "network_config", "connection_string")
 	ConfigPath string // Path in config where this env var is used
 	KeyID      string // The key ID this env var belongs to (empty for non-key configs like meta_config, connection_string)
 }
@@ -143,6 +145,17 @@ func (s *ConfigStore) LoadFromConfig(configPath string) error {
 			return fmt.Errorf("failed to unmarshal providers: %w", err)
 		}
 
+		// Provider names are case-insensitive, so entries like "openAI" and "openai" would
+		// silently overwrite each other once lowercased, like entries with the exact same name
+		// do when decoded. Reject such configs explicitly.
+		names, err := providerEntryNames(data)
+		if err != nil {
+			return fmt.Errorf("failed to read provider entries: %w", err)
+		}
+		if err := checkDuplicateProviders(names); err != nil {
+			return err
+		}
+
 		// Create a temporary structure to unmarshal the full JSON with proper meta configs
 		var tempConfig struct {
 			Providers map[string]struct {
@@ -155,6 +168,7 @@ func (s *ConfigStore) LoadFromConfig(configPath string) error {
 		}
 
 		// Process each provider configuration
+	providers:
 		for rawProviderName, cfg := range rawProviders {
 			newEnvKeys := make(map[string]struct{})
 
@@ -181,52 +195,28 @@ func (s *ConfigStore) LoadFromConfig(configPath string) error {
 				}
 			}
 
+			// Process environment variables in network config (base URL and extra headers)
+			networkEnvKeys, err := s.processNetworkConfigEnvVars(&cfg, provider)
+			if err != nil {
+				s.cleanupEnvKeys(string(provider), "", newEnvKeys)
+				s.logger.Warn(fmt.Sprintf("failed to process env vars in network config for %s: %v", provider, err))
+				continue
+			}
+
 			// Process environment variables in keys (including key-level configs)
-			for i, key := range cfg.Keys {
-				if key.ID == "" {
+			for i := range cfg.Keys {
+				if cfg.Keys[i].ID == "" {
 					cfg.Keys[i].ID = uuid.NewString()
 				}
 
-				// Process API key value
-				processedValue, envVar, err := s.processEnvValue(key.Value)
-				if err != nil {
+				if err := s.processKeyEnvVars(&cfg.Keys[i], provider, newEnvKeys); err != nil {
 					s.cleanupEnvKeys(string(provider), "", newEnvKeys)
 					s.logger.Warn(fmt.Sprintf("failed to process env vars in keys for %s: %v", provider, err))
-					continue
-				}
-				cfg.Keys[i].Value = processedValue
-
-				// Track environment key if it came from env
-				if envVar != "" {
-					newEnvKeys[envVar] = struct{}{}
-					s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-						EnvVar:     envVar,
-						Provider:   string(provider),
-						KeyType:    "api_key",
-						ConfigPath: fmt.Sprintf("providers.%s.keys[%s]", provider, key.ID),
-						KeyID:      key.ID,
-					})
-				}
-
-				// Process Azure key config if present
-				if key.AzureKeyConfig != nil {
-					if err := s.processAzureKeyConfigEnvVars(&cfg.Keys[i], provider, i, newEnvKeys); err != nil {
-						s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-						s.logger.Warn(fmt.Sprintf("failed to process Azure key config env vars for %s: %v", provider, err))
-						continue
-					}
-				}
-
-				// Process Vertex key config if present
-				if key.VertexKeyConfig != nil {
-					if err := s.processVertexKeyConfigEnvVars(&cfg.Keys[i], provider, i, newEnvKeys); err != nil {
-						s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-						s.logger.Warn(fmt.Sprintf("failed to process Vertex key config env vars for %s: %v", provider, err))
-						continue
-					}
+					continue providers
 				}
 			}
 
+			s.trackNetworkConfigEnvKeys(provider, networkEnvKeys)
 			processedProviders[provider] = cfg
 		}
 
@@ -252,6 +242,67 @@ func (s *ConfigStore) LoadFromConfig(configPath string) error {
 	return nil
 }
 
+// providerEntryNames returns the names of the entries of the providers object of a config file,
+// in order. Unlike decoding into a map, which keeps the last of entries with the same name, every
+// entry is listed.
+func providerEntryNames(data []byte) ([]string, error) {
+	var config struct {
+		Providers json.RawMessage `json:"providers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Providers) == 0 || string(config.Providers) == "null" {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(config.Providers))
+	if _, err := decoder.Token(); err != nil { // Opening brace
+		return nil, err
+	}
+	var names []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, token.(string))
+
+		var entry json.RawMessage
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// checkDuplicateProviders returns an error if two or more provider entries resolve to the
+// same provider once their names are lowercased (e.g. "openAI" and "openai"), including
+// entries with the exact same name.
+// The error lists every duplicated provider along with the entry names that collide.
+func checkDuplicateProviders(names []string) error {
+	namesByProvider := make(map[string][]string)
+	for _, rawProviderName := range names {
+		provider := strings.ToLower(rawProviderName)
+		namesByProvider[provider] = append(namesByProvider[provider], rawProviderName)
+	}
+
+	var duplicates []string
+	for provider, names := range namesByProvider {
+		if len(names) > 1 {
+			sort.Strings(names)
+			duplicates = append(duplicates, fmt.Sprintf("%s (%s)", provider, strings.Join(names, ", ")))
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	sort.Strings(duplicates)
+	return fmt.Errorf("duplicate provider entries in config: %s", strings.Join(duplicates, "; "))
+}
+
 // processEnvValue checks and replaces environment variable references in configuration values.
 // Returns the processed value and the environment variable name if it was an env reference.
 // Supports the "env.VARIABLE_NAME" syntax for referencing environment variables.
@@ -307,9 +358,10 @@ func (s *ConfigStore) writeConfigToFile(configPath string) error {
 		redactedKeys := make([]schemas.Key, len(config.Keys))
 		for i, key := range config.Keys {
 			redactedKeys[i] = schemas.Key{
-				ID:     key.ID,
-				Models: key.Models,
-				Weight: key.Weight,
+				ID:           key.ID,
+				Models:       key.Models,
+				Weight:       key.Weight,
+				ModelWeights: key.ModelWeights,
 			}
 
 			// Restore API key value
@@ -386,7 +438,7 @@ func (s *ConfigStore) writeConfigToFile(configPath string) error {
 		}
 
 		if config.NetworkConfig != nil {
-			providerConfig["network_config"] = config.NetworkConfig
+			providerConfig["network_config"] = restoreNetworkConfigEnvVars(provider, config.NetworkConfig, envVarsByPath)
 		}
 
 		if config.ConcurrencyAndBufferSize != nil {
@@ -436,6 +488,10 @@ func (s *ConfigStore) getRestoredMCPConfig(envVarsByPath map[string]string) *sch
 			StdioConfig:    clientConfig.StdioConfig,
 			ToolsToExecute: append([]string{}, clientConfig.ToolsToExecute...),
 			ToolsToSkip:    append([]string{}, clientConfig.ToolsToSkip...),
+
+			MaxConcurrentConnections: clientConfig.MaxConcurrentConnections,
+			HealthCheck:              clientConfig.HealthCheck,
+			Reconnect:                clientConfig.Reconnect,
 		}
 
 		// Handle connection string with env variable restoration
@@ -548,74 +604,39 @@ func (s *ConfigStore) processMetaConfigEnvVars(rawMetaConfig json.RawMessage, pr
 			return nil, newEnvKeys, fmt.Errorf("failed to unmarshal Bedrock meta config: %w", err)
 		}
 
-		secretAccessKey, envVar, err := s.processEnvValue(bedrockMetaConfig.SecretAccessKey)
-		if err != nil {
-			return nil, newEnvKeys, err
-		}
-		if envVar != "" {
-			newEnvKeys[envVar] = struct{}{}
-			s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-				EnvVar:     envVar,
+		metaInfo := func(field string) EnvKeyInfo {
+			return EnvKeyInfo{
 				Provider:   string(provider),
 				KeyType:    "meta_config",
-				ConfigPath: fmt.Sprintf("providers.%s.meta_config.secret_access_key", provider),
+				ConfigPath: fmt.Sprintf("providers.%s.meta_config.%s", provider, field),
 				KeyID:      "", // Empty for meta config entries
-			})
+			}
 		}
-		bedrockMetaConfig.SecretAccessKey = secretAccessKey
 
-		if bedrockMetaConfig.Region != nil {
-			region, envVar, err := s.processEnvValue(*bedrockMetaConfig.Region)
-			if err != nil {
-				return nil, newEnvKeys, err
-			}
-			if envVar != "" {
-				newEnvKeys[envVar] = struct{}{}
-				s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-					EnvVar:     envVar,
-					Provider:   string(provider),
-					KeyType:    "meta_config",
-					ConfigPath: fmt.Sprintf("providers.%s.meta_config.region", provider),
-					KeyID:      "", // Empty for meta config entries
-				})
-			}
-			bedrockMetaConfig.Region = &region
+		secretAccessKey, err := s.resolveEnvValue(bedrockMetaConfig.SecretAccessKey, metaInfo("secret_access_key"), newEnvKeys)
+		if err != nil {
+			return nil, newEnvKeys, err
 		}
+		bedrockMetaConfig.SecretAccessKey = secretAccessKey
 
-		if bedrockMetaConfig.SessionToken != nil {
-			sessionToken, envVar, err := s.processEnvValue(*bedrockMetaConfig.SessionToken)
-			if err != nil {
-				return nil, newEnvKeys, err
-			}
-			if envVar != "" {
-				newEnvKeys[envVar] = struct{}{}
-				s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-					EnvVar:     envVar,
-					Provider:   string(provider),
-					KeyType:    "meta_config",
-					ConfigPath: fmt.Sprintf("providers.%s.meta_config.session_token", provider),
-					KeyID:      "", // Empty for meta config entries
-				})
-			}
-			bedrockMetaConfig.SessionToken = &sessionToken
+		optionalFields := []struct {
+			name  string
+			value **string
+		}{
+			{"region", &bedrockMetaConfig.Region},
+			{"session_token", &bedrockMetaConfig.SessionToken},
+			{"arn", &bedrockMetaConfig.ARN},
 		}
 
-		if bedrockMetaConfig.ARN != nil {
-			arn, envVar, err := s.processEnvValue(*bedrockMetaConfig.ARN)
+		for _, field := range optionalFields {
+			if *field.value == nil {
+				continue
+			}
+			processedValue, err := s.resolveEnvValue(**field.value, metaInfo(field.name), newEnvKeys)
 			if err != nil {
 				return nil, newEnvKeys, err
 			}
-			if envVar != "" {
-				newEnvKeys[envVar] = struct{}{}
-				s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-					EnvVar:     envVar,
-					Provider:   string(provider),
-					KeyType:    "meta_config",
-					ConfigPath: fmt.Sprintf("providers.%s.meta_config.arn", provider),
-					KeyID:      "", // Empty for meta config entries
-				})
-			}
-			bedrockMetaConfig.ARN = &arn
+			*field.value = &processedValue
 		}
 
 		processedJSON, err := json.Marshal(bedrockMetaConfig)
@@ -680,17 +701,23 @@ func (s *ConfigStore) GetProviderConfigRedacted(provider schemas.ModelProvider)
 
 	// Create redacted config with same structure but redacted values
 	redactedConfig := ProviderConfig{
-		NetworkConfig:            config.NetworkConfig,
+		NetworkConfig:            restoreNetworkConfigEnvVars(provider, config.NetworkConfig, envVarsByPath),
 		ConcurrencyAndBufferSize: config.ConcurrencyAndBufferSize,
+		DecommissionedModels:     config.DecommissionedModels,
+		ImageLimits:              config.ImageLimits,
+		TextCompletionModels:     config.TextCompletionModels,
+		ChatTemplate:             config.ChatTemplate,
+		TLSConfig:                config.TLSConfig,
 	}
 
 	// Create redacted keys
 	redactedConfig.Keys = make([]schemas.Key, len(config.Keys))
 	for i, key := range config.Keys {
 		redactedConfig.Keys[i] = schemas.Key{
-			ID:     key.ID,
-			Models: key.Models, // Copy slice reference - read-only so safe
-			Weight: key.Weight,
+			ID:           key.ID,
+			Models:       key.Models, // Copy slice reference - read-only so safe
+			Weight:       key.Weight,
+			ModelWeights: key.ModelWeights,
 		}
 
 		// Redact API key value
@@ -869,49 +896,26 @@ func (s *ConfigStore) AddProvider(provider schemas.ModelProvider, config Provide
 		config.MetaConfig = metaConfig
 	}
 
+	// Process environment variables in network config (base URL and extra headers)
+	networkEnvKeys, err := s.processNetworkConfigEnvVars(&config, provider)
+	if err != nil {
+		s.cleanupEnvKeys(string(provider), "", newEnvKeys)
+		return fmt.Errorf("failed to process env vars in network config: %w", err)
+	}
+
 	// Process environment variables in keys (including key-level configs)
-	for i, key := range config.Keys {
-		if key.ID == "" {
+	for i := range config.Keys {
+		if config.Keys[i].ID == "" {
 			config.Keys[i].ID = uuid.NewString()
 		}
 
-		// Process API key value
-		processedValue, envVar, err := s.processEnvValue(key.Value)
-		if err != nil {
+		if err := s.processKeyEnvVars(&config.Keys[i], provider, newEnvKeys); err != nil {
 			s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-			return fmt.Errorf("failed to process env var in key: %w", err)
-		}
-		config.Keys[i].Value = processedValue
-
-		// Track environment key if it came from env
-		if envVar != "" {
-			newEnvKeys[envVar] = struct{}{}
-			s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-				EnvVar:     envVar,
-				Provider:   string(provider),
-				KeyType:    "api_key",
-				ConfigPath: fmt.Sprintf("providers.%s.keys[%s]", provider, key.ID),
-				KeyID:      key.ID,
-			})
-		}
-
-		// Process Azure key config if present
-		if key.AzureKeyConfig != nil {
-			if err := s.processAzureKeyConfigEnvVars(&config.Keys[i], provider, i, newEnvKeys); err != nil {
-				s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-				return fmt.Errorf("failed to process Azure key config env vars: %w", err)
-			}
-		}
-
-		// Process Vertex key config if present
-		if key.VertexKeyConfig != nil {
-			if err := s.processVertexKeyConfigEnvVars(&config.Keys[i], provider, i, newEnvKeys); err != nil {
-				s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-				return fmt.Errorf("failed to process Vertex key config env vars: %w", err)
-			}
+			return fmt.Errorf("failed to process env vars in key: %w", err)
 		}
 	}
 
+	s.trackNetworkConfigEnvKeys(provider, networkEnvKeys)
 	s.Providers[provider] = config
 
 	s.logger.Info(fmt.Sprintf("Added provider: %s", provider))
@@ -967,49 +971,26 @@ func (s *ConfigStore) UpdateProviderConfig(provider schemas.ModelProvider, confi
 		}
 	}
 
+	// Process environment variables in network config (base URL and extra headers)
+	networkEnvKeys, err := s.processNetworkConfigEnvVars(&config, provider)
+	if err != nil {
+		s.cleanupEnvKeys(string(provider), "", newEnvKeys) // Clean up only new vars on failure
+		return fmt.Errorf("failed to process env vars in network config: %w", err)
+	}
+
 	// Process environment variables in keys (including key-level configs)
-	for i, key := range config.Keys {
-		if key.ID == "" {
+	for i := range config.Keys {
+		if config.Keys[i].ID == "" {
 			config.Keys[i].ID = uuid.NewString()
 		}
 
-		// Process API key value
-		processedValue, envVar, err := s.processEnvValue(key.Value)
-		if err != nil {
+		if err := s.processKeyEnvVars(&config.Keys[i], provider, newEnvKeys); err != nil {
 			s.cleanupEnvKeys(string(provider), "", newEnvKeys) // Clean up only new vars on failure
-			return fmt.Errorf("failed to process env var in key: %w", err)
-		}
-		config.Keys[i].Value = processedValue
-
-		// Track environment key if it came from env
-		if envVar != "" {
-			newEnvKeys[envVar] = struct{}{}
-			s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-				EnvVar:     envVar,
-				Provider:   string(provider),
-				KeyType:    "api_key",
-				ConfigPath: fmt.Sprintf("providers.%s.keys[%s]", provider, key.ID),
-				KeyID:      key.ID,
-			})
-		}
-
-		// Process Azure key config if present
-		if key.AzureKeyConfig != nil {
-			if err := s.processAzureKeyConfigEnvVars(&config.Keys[i], provider, i, newEnvKeys); err != nil {
-				s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-				return fmt.Errorf("failed to process Azure key config env vars: %w", err)
-			}
-		}
-
-		// Process Vertex key config if present
-		if key.VertexKeyConfig != nil {
-			if err := s.processVertexKeyConfigEnvVars(&config.Keys[i], provider, i, newEnvKeys); err != nil {
-				s.cleanupEnvKeys(string(provider), "", newEnvKeys)
-				return fmt.Errorf("failed to process Vertex key config env vars: %w", err)
-			}
+			return fmt.Errorf("failed to process env vars in key: %w", err)
 		}
 	}
 
+	s.trackNetworkConfigEnvKeys(provider, networkEnvKeys)
 	s.Providers[provider] = config
 
 	s.logger.Info(fmt.Sprintf("Updated configuration for provider: %s", provider))
@@ -1218,6 +1199,10 @@ func (s *ConfigStore) RedactMCPClientConfig(config schemas.MCPClientConfig) sche
 		StdioConfig:      config.StdioConfig,
 		ToolsToExecute:   append([]string{}, config.ToolsToExecute...),
 		ToolsToSkip:      append([]string{}, config.ToolsToSkip...),
+
+		MaxConcurrentConnections: config.MaxConcurrentConnections,
+		HealthCheck:              config.HealthCheck,
+		Reconnect:                config.Reconnect,
 	}
 
 	// Handle connection string if present
@@ -1458,7 +1443,6 @@ func (s *ConfigStore) autoDetectProviders() {
 							Weight: 1.0,
 						},
 					},
-					ConcurrencyAndBufferSize: &schemas.DefaultConcurrencyAndBufferSize,
 				}
 
 				// Add to providers map
@@ -1485,43 +1469,82 @@ func (s *ConfigStore) autoDetectProviders() {
 	}
 }
 
+// resolveEnvValue is the single resolver for every configuration field that supports
+// "env.VARIABLE_NAME" references. It substitutes the value, records the reference in
+// EnvKeys (so it can be restored on save and shown during redaction), and adds the
+// variable to newEnvKeys so callers can roll it back on failure.
+//
+// The info argument describes where the value lives; its EnvVar field is filled in here.
+// Missing variables produce an error that names the config path referencing them.
+func (s *ConfigStore) resolveEnvValue(value string, info EnvKeyInfo, newEnvKeys map[string]struct{}) (string, error) {
+	processedValue, envVar, err := s.processEnvValue(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", info.ConfigPath, err)
+	}
+	if envVar != "" {
+		info.EnvVar = envVar
+		newEnvKeys[envVar] = struct{}{}
+		s.EnvKeys[envVar] = append(s.EnvKeys[envVar], info)
+	}
+	return processedValue, nil
+}
+
+// processKeyEnvVars processes environment variables in a key's value and its
+// provider-specific key-level configuration (Azure, Vertex).
+func (s *ConfigStore) processKeyEnvVars(key *schemas.Key, provider schemas.ModelProvider, newEnvKeys map[string]struct{}) error {
+	processedValue, err := s.resolveEnvValue(key.Value, EnvKeyInfo{
+		Provider:   string(provider),
+		KeyType:    "api_key",
+		ConfigPath: fmt.Sprintf("providers.%s.keys[%s]", provider, key.ID),
+		KeyID:      key.ID,
+	}, newEnvKeys)
+	if err != nil {
+		return err
+	}
+	key.Value = processedValue
+
+	if key.AzureKeyConfig != nil {
+		if err := s.processAzureKeyConfigEnvVars(key, provider, newEnvKeys); err != nil {
+			return fmt.Errorf("failed to process Azure key config env vars: %w", err)
+		}
+	}
+
+	if key.VertexKeyConfig != nil {
+		if err := s.processVertexKeyConfigEnvVars(key, provider, newEnvKeys); err != nil {
+			return fmt.Errorf("failed to process Vertex key config env vars: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // processAzureKeyConfigEnvVars processes environment variables in Azure key configuration
-func (s *ConfigStore) processAzureKeyConfigEnvVars(key *schemas.Key, provider schemas.ModelProvider, keyIndex int, newEnvKeys map[string]struct{}) error {
+func (s *ConfigStore) processAzureKeyConfigEnvVars(key *schemas.Key, provider schemas.ModelProvider, newEnvKeys map[string]struct{}) error {
 	azureConfig := key.AzureKeyConfig
 
 	// Process Endpoint
-	processedEndpoint, envVar, err := s.processEnvValue(azureConfig.Endpoint)
+	processedEndpoint, err := s.resolveEnvValue(azureConfig.Endpoint, EnvKeyInfo{
+		Provider:   string(provider),
+		KeyType:    "azure_config",
+		ConfigPath: fmt.Sprintf("providers.%s.keys[%s].azure_key_config.endpoint", provider, key.ID),
+		KeyID:      key.ID,
+	}, newEnvKeys)
 	if err != nil {
 		return err
 	}
-	if envVar != "" {
-		newEnvKeys[envVar] = struct{}{}
-		s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-			EnvVar:     envVar,
-			Provider:   string(provider),
-			KeyType:    "azure_config",
-			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].azure_key_config.endpoint", provider, key.ID),
-			KeyID:      key.ID,
-		})
-	}
 	azureConfig.Endpoint = processedEndpoint
 
 	// Process APIVersion if present
 	if azureConfig.APIVersion != nil {
-		processedAPIVersion, envVar, err := s.processEnvValue(*azureConfig.APIVersion)
+		processedAPIVersion, err := s.resolveEnvValue(*azureConfig.APIVersion, EnvKeyInfo{
+			Provider:   string(provider),
+			KeyType:    "azure_config",
+			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].azure_key_config.api_version", provider, key.ID),
+			KeyID:      key.ID,
+		}, newEnvKeys)
 		if err != nil {
 			return err
 		}
-		if envVar != "" {
-			newEnvKeys[envVar] = struct{}{}
-			s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-				EnvVar:     envVar,
-				Provider:   string(provider),
-				KeyType:    "azure_config",
-				ConfigPath: fmt.Sprintf("providers.%s.keys[%s].azure_key_config.api_version", provider, key.ID),
-				KeyID:      key.ID,
-			})
-		}
 		azureConfig.APIVersion = &processedAPIVersion
 	}
 
@@ -1529,59 +1552,139 @@ func (s *ConfigStore) processAzureKeyConfigEnvVars(key *schemas.Key, provider sc
 }
 
 // processVertexKeyConfigEnvVars processes environment variables in Vertex key configuration
-func (s *ConfigStore) processVertexKeyConfigEnvVars(key *schemas.Key, provider schemas.ModelProvider, keyIndex int, newEnvKeys map[string]struct{}) error {
+func (s *ConfigStore) processVertexKeyConfigEnvVars(key *schemas.Key, provider schemas.ModelProvider, newEnvKeys map[string]struct{}) error {
 	vertexConfig := key.VertexKeyConfig
 
-	// Process ProjectID
-	processedProjectID, envVar, err := s.processEnvValue(vertexConfig.ProjectID)
-	if err != nil {
-		return err
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"project_id", &vertexConfig.ProjectID},
+		{"region", &vertexConfig.Region},
+		{"auth_credentials", &vertexConfig.AuthCredentials},
 	}
-	if envVar != "" {
-		newEnvKeys[envVar] = struct{}{}
-		s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-			EnvVar:     envVar,
+
+	for _, field := range fields {
+		processedValue, err := s.resolveEnvValue(*field.value, EnvKeyInfo{
 			Provider:   string(provider),
 			KeyType:    "vertex_config",
-			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].vertex_key_config.project_id", provider, key.ID),
+			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].vertex_key_config.%s", provider, key.ID, field.name),
 			KeyID:      key.ID,
-		})
+		}, newEnvKeys)
+		if err != nil {
+			return err
+		}
+		*field.value = processedValue
 	}
-	vertexConfig.ProjectID = processedProjectID
 
-	// Process Region
-	processedRegion, envVar, err := s.processEnvValue(vertexConfig.Region)
-	if err != nil {
-		return err
+	return nil
+}
+
+// processNetworkConfigEnvVars processes environment variables in a provider's network
+// configuration: the base URL and every extra header value. The network config is copied
+// before substitution so the caller's (possibly shared) struct is never mutated.
+//
+// The references found are returned instead of being tracked, so the provider's existing
+// references stay in place if this or a later step of the update fails. Once the update
+// succeeds, the caller passes them to trackNetworkConfigEnvKeys.
+func (s *ConfigStore) processNetworkConfigEnvVars(config *ProviderConfig, provider schemas.ModelProvider) ([]EnvKeyInfo, error) {
+	if config.NetworkConfig == nil {
+		return nil, nil
 	}
-	if envVar != "" {
-		newEnvKeys[envVar] = struct{}{}
-		s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-			EnvVar:     envVar,
-			Provider:   string(provider),
-			KeyType:    "vertex_config",
-			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].vertex_key_config.region", provider, key.ID),
-			KeyID:      key.ID,
-		})
+
+	networkConfig := *config.NetworkConfig
+	var envKeyInfos []EnvKeyInfo
+
+	resolve := func(value string, configPath string) (string, error) {
+		processedValue, envVar, err := s.processEnvValue(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", configPath, err)
+		}
+		if envVar != "" {
+			envKeyInfos = append(envKeyInfos, EnvKeyInfo{
+				EnvVar:     envVar,
+				Provider:   string(provider),
+				KeyType:    "network_config",
+				ConfigPath: configPath,
+			})
+		}
+		return processedValue, nil
 	}
-	vertexConfig.Region = processedRegion
 
-	// Process AuthCredentials
-	processedAuthCredentials, envVar, err := s.processEnvValue(vertexConfig.AuthCredentials)
+	baseURL, err := resolve(networkConfig.BaseURL, fmt.Sprintf("providers.%s.network_config.base_url", provider))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if envVar != "" {
-		newEnvKeys[envVar] = struct{}{}
-		s.EnvKeys[envVar] = append(s.EnvKeys[envVar], EnvKeyInfo{
-			EnvVar:     envVar,
-			Provider:   string(provider),
-			KeyType:    "vertex_config",
-			ConfigPath: fmt.Sprintf("providers.%s.keys[%s].vertex_key_config.auth_credentials", provider, key.ID),
-			KeyID:      key.ID,
-		})
+	networkConfig.BaseURL = baseURL
+
+	if networkConfig.ExtraHeaders != nil {
+		headers := make(map[string]string, len(networkConfig.ExtraHeaders))
+		for name, value := range networkConfig.ExtraHeaders {
+			processedValue, err := resolve(value, fmt.Sprintf("providers.%s.network_config.extra_headers.%s", provider, name))
+			if err != nil {
+				return nil, err
+			}
+			headers[name] = processedValue
+		}
+		networkConfig.ExtraHeaders = headers
 	}
-	vertexConfig.AuthCredentials = processedAuthCredentials
 
-	return nil
+	config.NetworkConfig = &networkConfig
+	return envKeyInfos, nil
+}
+
+// trackNetworkConfigEnvKeys replaces the tracked network config env references of a provider
+// with the ones returned by processNetworkConfigEnvVars.
+func (s *ConfigStore) trackNetworkConfigEnvKeys(provider schemas.ModelProvider, envKeyInfos []EnvKeyInfo) {
+	s.cleanupNetworkConfigEnvKeys(provider)
+	for _, info := range envKeyInfos {
+		s.EnvKeys[info.EnvVar] = append(s.EnvKeys[info.EnvVar], info)
+	}
+}
+
+// cleanupNetworkConfigEnvKeys removes all tracked network config env references for a provider.
+func (s *ConfigStore) cleanupNetworkConfigEnvKeys(provider schemas.ModelProvider) {
+	prefix := fmt.Sprintf("providers.%s.network_config.", provider)
+	for envVar, infos := range s.EnvKeys {
+		filteredInfos := make([]EnvKeyInfo, 0, len(infos))
+		for _, info := range infos {
+			if !strings.HasPrefix(info.ConfigPath, prefix) {
+				filteredInfos = append(filteredInfos, info)
+			}
+		}
+
+		if len(filteredInfos) == 0 {
+			delete(s.EnvKeys, envVar)
+		} else {
+			s.EnvKeys[envVar] = filteredInfos
+		}
+	}
+}
+
+// restoreNetworkConfigEnvVars returns a copy of the network config with env.* references
+// restored for the base URL and extra headers that were sourced from environment variables.
+func restoreNetworkConfigEnvVars(provider schemas.ModelProvider, networkConfig *schemas.NetworkConfig, envVarsByPath map[string]string) *schemas.NetworkConfig {
+	if networkConfig == nil {
+		return nil
+	}
+
+	restored := *networkConfig
+
+	if envVar, ok := envVarsByPath[fmt.Sprintf("providers.%s.network_config.base_url", provider)]; ok {
+		restored.BaseURL = "env." + envVar
+	}
+
+	if networkConfig.ExtraHeaders != nil {
+		restored.ExtraHeaders = make(map[string]string, len(networkConfig.ExtraHeaders))
+		for name, value := range networkConfig.ExtraHeaders {
+			path := fmt.Sprintf("providers.%s.network_config.extra_headers.%s", provider, name)
+			if envVar, ok := envVarsByPath[path]; ok {
+				restored.ExtraHeaders[name] = "env." + envVar
+			} else {
+				restored.ExtraHeaders[name] = value
+			}
+		}
+	}
+
+	return &restored
 }
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+func TestLoadFromConfigRejectsDuplicateProviders(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		providers string
+		want      string
+	}{
+		{"exact", `{"openai":{"keys":[]},"anthropic":{"keys":[]},"openai":{"keys":[]}}`, "openai (openai, openai)"},
+		{"case only", `{"openAI":{"keys":[]},"openai":{"keys":[]}}`, "openai (openAI, openai)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(`{"providers":`+tt.providers+`}`), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			store, _ := NewConfigStore(bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+			err := store.LoadFromConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFromConfig() error = %v, want the duplicate %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromConfigSkipsProvidersWithUnresolvedKeys(t *testing.T) {
+	t.Setenv("BIFROST_TEST_OPENAI_KEY", "sk-resolved")
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	config := `{"providers":{
+		"openai":{"keys":[{"value":"env.BIFROST_TEST_OPENAI_KEY","weight":1},{"value":"env.BIFROST_TEST_MISSING_KEY","weight":1}]},
+		"anthropic":{"keys":[{"value":"sk-ant","weight":1}]}
+	}}`
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, _ := NewConfigStore(bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	if err := store.LoadFromConfig(configPath); err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+
+	// The provider with a key that cannot be resolved is skipped entirely, along with the env
+	// references of its other keys
+	if _, ok := store.Providers[schemas.OpenAI]; ok {
+		t.Error("openai was registered with an unresolved key")
+	}
+	if _, ok := store.Providers[schemas.Anthropic]; !ok {
+		t.Error("anthropic was not registered")
+	}
+	if infos := store.EnvKeys["BIFROST_TEST_OPENAI_KEY"]; len(infos) != 0 {
+		t.Errorf("env references of the skipped provider = %+v, want none", infos)
+	}
+}
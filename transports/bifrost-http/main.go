@@ -52,12 +52,15 @@ package main
 import (
 	"embed"
 	"flag"
+	"fmt"
 	"log"
 	"mime"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fasthttp/router"
 	bifrost "github.com/maximhq/bifrost/core"
@@ -66,6 +69,7 @@ import (
 	"github.com/maximhq/bifrost/transports/bifrost-http/handlers"
 	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
 	"github.com/maximhq/bifrost/transports/bifrost-http/plugins/logging"
+	"github.com/maximhq/bifrost/transports/bifrost-http/plugins/sampling"
 	"github.com/maximhq/bifrost/transports/bifrost-http/plugins/telemetry"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -100,6 +104,34 @@ func init() {
 	pluginsToLoad = strings.Split(pluginString, ",")
 }
 
+// newMaximPlugin initializes the Maxim plugin from the MAXIM_* environment variables. Traces are
+// sampled if MAXIM_SAMPLE_RATE (a fraction between 0 and 1) or MAXIM_LATENCY_THRESHOLD (a duration
+// such as "2s") is set: failed and slow requests are always traced, the others at the sample rate.
+func newMaximPlugin() (schemas.Plugin, error) {
+	apiKey, logRepoID := os.Getenv("MAXIM_API_KEY"), os.Getenv("MAXIM_LOG_REPO_ID")
+	sampleRate, latencyThreshold := os.Getenv("MAXIM_SAMPLE_RATE"), os.Getenv("MAXIM_LATENCY_THRESHOLD")
+	if sampleRate == "" && latencyThreshold == "" {
+		return maxim.NewMaximLoggerPlugin(apiKey, logRepoID)
+	}
+
+	var sampling maxim.SamplingConfig
+	if sampleRate != "" {
+		rate, err := strconv.ParseFloat(sampleRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAXIM_SAMPLE_RATE %q: %w", sampleRate, err)
+		}
+		sampling.SampleRate = rate
+	}
+	if latencyThreshold != "" {
+		threshold, err := time.ParseDuration(latencyThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAXIM_LATENCY_THRESHOLD %q: %w", latencyThreshold, err)
+		}
+		sampling.LatencyThreshold = threshold
+	}
+	return maxim.NewMaximLoggerPluginWithSampling(apiKey, logRepoID, sampling)
+}
+
 // registerCollectorSafely attempts to register a Prometheus collector,
 // handling the case where it may already be registered.
 // It logs any errors that occur during registration, except for AlreadyRegisteredError.
@@ -278,7 +310,7 @@ func main() {
 				continue
 			}
 
-			maximPlugin, err := maxim.NewMaximLoggerPlugin(os.Getenv("MAXIM_API_KEY"), os.Getenv("MAXIM_LOG_REPO_ID"))
+			maximPlugin, err := newMaximPlugin()
 			if err != nil {
 				log.Printf("warning: failed to initialize maxim plugin: %v", err)
 				continue
@@ -315,13 +347,62 @@ func main() {
 		wsHandler = handlers.NewWebSocketHandler(loggingPlugin.GetPluginLogManager(), logger)
 	}
 
+	if store.ClientConfig.DebugSampleRate > 0 {
+		samplingPlugin, err := sampling.NewSamplingPlugin(&sampling.Config{
+			SampleRate: store.ClientConfig.DebugSampleRate,
+		}, logger)
+		if err != nil {
+			log.Printf("warning: failed to initialize sampling plugin: %v", err)
+		} else {
+			loadedPlugins = append(loadedPlugins, samplingPlugin)
+		}
+	}
+
+	var keySelection *schemas.KeySelectionConfig
+	if store.ClientConfig.KeyWeightsByModelCount || store.ClientConfig.KeyErrorRateHalfLifeSeconds > 0 || store.ClientConfig.KeyRateLimitAware {
+		keySelection = &schemas.KeySelectionConfig{
+			NormalizeByModelCount: store.ClientConfig.KeyWeightsByModelCount,
+			ErrorRateHalfLife:     time.Duration(store.ClientConfig.KeyErrorRateHalfLifeSeconds) * time.Second,
+			RateLimitAware:        store.ClientConfig.KeyRateLimitAware,
+		}
+	}
+
+	var providerHealth *schemas.ProviderHealthConfig
+	if store.ClientConfig.ProviderHealthFailureThreshold > 0 {
+		providerHealth = &schemas.ProviderHealthConfig{
+			FailureThreshold: store.ClientConfig.ProviderHealthFailureThreshold,
+			ProbeInterval:    time.Duration(store.ClientConfig.ProviderHealthProbeIntervalSeconds) * time.Second,
+		}
+	}
+
+	var streamSequencing *schemas.StreamSequencingConfig
+	if store.ClientConfig.StreamSequenceNumbers || store.ClientConfig.StreamChecksums {
+		streamSequencing = &schemas.StreamSequencingConfig{Checksums: store.ClientConfig.StreamChecksums}
+	}
+
 	client, err := bifrost.Init(schemas.BifrostConfig{
-		Account:            account,
-		InitialPoolSize:    store.ClientConfig.InitialPoolSize,
-		DropExcessRequests: store.ClientConfig.DropExcessRequests,
-		Plugins:            loadedPlugins,
-		MCPConfig:          store.MCPConfig,
-		Logger:             logger,
+		Account:               account,
+		InitialPoolSize:       store.ClientConfig.InitialPoolSize,
+		DropExcessRequests:    store.ClientConfig.DropExcessRequests,
+		Plugins:               loadedPlugins,
+		MCPConfig:             store.MCPConfig,
+		Logger:                logger,
+		NoMatchingKeyBehavior: store.ClientConfig.NoMatchingKeyBehavior,
+		KeySelection:          keySelection,
+		MaxConcurrentRequests: store.ClientConfig.MaxConcurrentRequests,
+		StreamBufferSize:      store.ClientConfig.StreamBufferSize,
+		OnClientDisconnect:    telemetry.RecordClientDisconnect,
+		OnRequestTiming:       telemetry.RecordRequestTiming,
+		OnFirstToken:          telemetry.RecordFirstToken,
+		OnFailover:            telemetry.RecordFailover,
+		StreamFallbackAdapter: store.ClientConfig.StreamFallbackAdapter,
+		EmptyStreamBehavior:   store.ClientConfig.EmptyStreamBehavior,
+		ProviderHealth:        providerHealth,
+		StreamSequencing:      streamSequencing,
+		StrictInit:            store.ClientConfig.StrictInit,
+
+		DefaultNetworkConfig:            store.ClientConfig.DefaultNetworkConfig,
+		DefaultConcurrencyAndBufferSize: store.ClientConfig.DefaultConcurrencyAndBufferSize,
 	})
 	if err != nil {
 		log.Fatalf("failed to initialize bifrost: %v", err)
@@ -331,9 +412,10 @@ func main() {
 
 	// Initialize handlers
 	providerHandler := handlers.NewProviderHandler(store, client, logger)
-	completionHandler := handlers.NewCompletionHandler(client, logger)
+	streamKeepalive := time.Duration(store.ClientConfig.StreamKeepaliveSeconds) * time.Second
+	completionHandler := handlers.NewCompletionHandler(client, logger, streamKeepalive)
 	mcpHandler := handlers.NewMCPHandler(client, logger, store)
-	integrationHandler := handlers.NewIntegrationHandler(client)
+	integrationHandler := handlers.NewIntegrationHandler(client, streamKeepalive)
 	configHandler := handlers.NewConfigHandler(client, logger, store, configPath)
 
 	// Set up WebSocket callback for real-time log updates
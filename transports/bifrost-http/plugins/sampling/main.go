@@ -0,0 +1,203 @@
+// Package sampling provides a debug plugin for Bifrost that logs a sampled subset of
+// full request and response bodies, along with their sizes and the request duration.
+// Secrets are redacted before anything is logged, and unsampled requests cost a single
+// random draw, so the plugin is cheap to keep enabled at low sample rates. Nothing is
+// serialized while the logger does not output debug messages.
+package sampling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	PluginName = "bifrost-http-sampling"
+
+	// redactedValue replaces any value considered sensitive in logged bodies
+	redactedValue = "[REDACTED]"
+)
+
+// ContextKey is a custom type for context keys to prevent collisions
+type ContextKey string
+
+// Context keys used to carry sampling state from PreHook to PostHook
+const (
+	SampledStartTimeContextKey ContextKey = "bifrost-sampling-start-time"
+)
+
+// sensitiveFieldPattern matches JSON field names whose values must never be logged.
+var sensitiveFieldPattern = regexp.MustCompile(`(?i)(api[_-]?key|secret|password|authorization|credential|access[_-]?token|session[_-]?token|auth[_-]?token|private[_-]?key)`)
+
+// sensitiveValuePattern matches string values that look like credentials regardless of their field name.
+var sensitiveValuePattern = regexp.MustCompile(`(?i)^(bearer\s+\S+|sk-[a-z0-9_-]{8,})`)
+
+// leveledLogger is implemented by loggers that report their level, such as bifrost.DefaultLogger.
+type leveledLogger interface {
+	GetLevel() schemas.LogLevel
+}
+
+// Config holds the configuration for the sampling plugin.
+type Config struct {
+	SampleRate float64 `json:"sample_rate"` // Probability (0.0-1.0) that a request is sampled and logged
+}
+
+// SamplingPlugin logs the full (redacted) request and response for a random subset of requests.
+// Logs are emitted at debug level through the Bifrost logger.
+type SamplingPlugin struct {
+	sampleRate float64
+	logger     schemas.Logger
+}
+
+// NewSamplingPlugin creates a new sampling plugin with the given configuration.
+// The sample rate must be between 0 and 1 (inclusive).
+func NewSamplingPlugin(config *Config, logger schemas.Logger) (*SamplingPlugin, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("sample rate must be between 0 and 1, got %v", config.SampleRate)
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	return &SamplingPlugin{
+		sampleRate: config.SampleRate,
+		logger:     logger,
+	}, nil
+}
+
+// GetName returns the name of the plugin.
+func (p *SamplingPlugin) GetName() string {
+	return PluginName
+}
+
+// PreHook decides whether the request is sampled. Sampled requests are logged and
+// their start time is stored in the context for the PostHook to compute duration.
+// Requests are not sampled while debug messages would be discarded.
+func (p *SamplingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if p.sampleRate <= 0 || req == nil || !p.debugEnabled() || rand.Float64() >= p.sampleRate {
+		return req, nil, nil
+	}
+
+	*ctx = context.WithValue(*ctx, SampledStartTimeContextKey, time.Now())
+
+	body, err := redactedJSON(req)
+	if err != nil {
+		return req, nil, fmt.Errorf("failed to serialize sampled request: %w", err)
+	}
+
+	p.logger.Debug(fmt.Sprintf("[%s] sampled request: provider=%s model=%s size=%d bytes body=%s",
+		PluginName, req.Provider, req.Model, len(body), body))
+
+	return req, nil, nil
+}
+
+// PostHook logs the response or error for sampled requests along with the request duration.
+// For streaming responses only the final chunk (or an error) is logged to avoid flooding the logs.
+func (p *SamplingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	startTime, ok := (*ctx).Value(SampledStartTimeContextKey).(time.Time)
+	if !ok {
+		return result, bifrostErr, nil
+	}
+
+	if bifrostErr == nil && isIntermediateStreamChunk(result) {
+		return result, bifrostErr, nil
+	}
+
+	duration := time.Since(startTime)
+
+	if bifrostErr != nil {
+		body, err := redactedJSON(bifrostErr)
+		if err != nil {
+			return result, bifrostErr, fmt.Errorf("failed to serialize sampled error: %w", err)
+		}
+		p.logger.Debug(fmt.Sprintf("[%s] sampled error: provider=%s duration=%s size=%d bytes body=%s",
+			PluginName, bifrostErr.Provider, duration, len(body), body))
+		return result, bifrostErr, nil
+	}
+
+	if result == nil {
+		return result, bifrostErr, nil
+	}
+
+	body, err := redactedJSON(result)
+	if err != nil {
+		return result, bifrostErr, fmt.Errorf("failed to serialize sampled response: %w", err)
+	}
+	p.logger.Debug(fmt.Sprintf("[%s] sampled response: provider=%s model=%s duration=%s size=%d bytes body=%s",
+		PluginName, result.ExtraFields.Provider, result.Model, duration, len(body), body))
+
+	return result, bifrostErr, nil
+}
+
+// Cleanup is a no-op for the sampling plugin.
+func (p *SamplingPlugin) Cleanup() error {
+	return nil
+}
+
+// debugEnabled reports whether the logger outputs debug messages. Loggers that do not report
+// their level are assumed to.
+func (p *SamplingPlugin) debugEnabled() bool {
+	if logger, ok := p.logger.(leveledLogger); ok {
+		return logger.GetLevel() == schemas.LogLevelDebug
+	}
+	return true
+}
+
+// isIntermediateStreamChunk reports whether the response is a streaming delta that is not the final chunk.
+func isIntermediateStreamChunk(result *schemas.BifrostResponse) bool {
+	if result == nil || len(result.Choices) == 0 {
+		return false
+	}
+	choice := result.Choices[0]
+	return choice.BifrostStreamResponseChoice != nil && choice.FinishReason == nil
+}
+
+// redactedJSON serializes the value to JSON with all sensitive fields and values replaced.
+func redactedJSON(value interface{}) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(redact(generic))
+}
+
+// redact walks a decoded JSON value and replaces sensitive fields and values.
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFieldPattern.MatchString(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redact(field)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+		return v
+	case string:
+		if sensitiveValuePattern.MatchString(v) {
+			return redactedValue
+		}
+		return v
+	default:
+		return v
+	}
+}
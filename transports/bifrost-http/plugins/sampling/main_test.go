@@ -0,0 +1,95 @@
+package sampling
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// debugLogger records the debug messages logged by the plugin.
+type debugLogger struct {
+	messages *[]string
+}
+
+func (l debugLogger) Debug(msg string) { *l.messages = append(*l.messages, msg) }
+func (l debugLogger) Info(string)      {}
+func (l debugLogger) Warn(string)      {}
+func (l debugLogger) Error(error)      {}
+
+func TestSampledRequestsAreLoggedRedacted(t *testing.T) {
+	var messages []string
+	plugin, err := NewSamplingPlugin(&Config{SampleRate: 1}, debugLogger{messages: &messages})
+	if err != nil {
+		t.Fatalf("NewSamplingPlugin() error = %v", err)
+	}
+
+	ctx := context.Background()
+	chat := []schemas.BifrostMessage{schemas.UserMessage("sk-abcdefghijkl")}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &chat}}
+	if _, _, err := plugin.PreHook(&ctx, req); err != nil {
+		t.Fatalf("PreHook() error = %v", err)
+	}
+
+	// Intermediate stream chunks are skipped, the final one is logged with the duration
+	content := "No."
+	chunk := &schemas.BifrostResponse{Choices: []schemas.BifrostResponseChoice{{
+		BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: schemas.BifrostStreamDelta{Content: &content}},
+	}}}
+	plugin.PostHook(&ctx, chunk, nil)
+
+	stop := "stop"
+	final := &schemas.BifrostResponse{Model: "gpt-4o", Choices: []schemas.BifrostResponseChoice{{
+		FinishReason:                &stop,
+		BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{},
+	}}}
+	final.ExtraFields.RawResponse = map[string]interface{}{"api_key": "plain", "note": "Bearer token123"}
+	plugin.PostHook(&ctx, final, nil)
+
+	if len(messages) != 2 {
+		t.Fatalf("logged %d messages, want the request and the final chunk: %q", len(messages), messages)
+	}
+	if !strings.Contains(messages[0], "sampled request") || strings.Contains(messages[0], "sk-abcdefghijkl") {
+		t.Errorf("request log = %q, want the request with the key redacted", messages[0])
+	}
+	if !strings.Contains(messages[1], "duration=") || strings.Contains(messages[1], "plain") || strings.Contains(messages[1], "token123") ||
+		strings.Count(messages[1], redactedValue) != 2 {
+		t.Errorf("response log = %q, want the response with the secret field and value redacted", messages[1])
+	}
+
+	// Requests that are not sampled are never logged
+	messages = nil
+	plugin, _ = NewSamplingPlugin(&Config{SampleRate: 0}, debugLogger{messages: &messages})
+	ctx = context.Background()
+	plugin.PreHook(&ctx, req)
+	plugin.PostHook(&ctx, final, nil)
+	if len(messages) != 0 {
+		t.Errorf("logged %q for an unsampled request", messages)
+	}
+}
+
+// leveledDebugLogger is a debugLogger that reports its level.
+type leveledDebugLogger struct {
+	debugLogger
+	level schemas.LogLevel
+}
+
+func (l leveledDebugLogger) GetLevel() schemas.LogLevel { return l.level }
+
+func TestNothingSampledWithoutDebugLogging(t *testing.T) {
+	var messages []string
+	plugin, _ := NewSamplingPlugin(&Config{SampleRate: 1}, leveledDebugLogger{debugLogger{messages: &messages}, schemas.LogLevelInfo})
+
+	ctx := context.Background()
+	chat := []schemas.BifrostMessage{schemas.UserMessage("Hello")}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: schemas.RequestInput{ChatCompletionInput: &chat}}
+	plugin.PreHook(&ctx, req)
+	plugin.PostHook(&ctx, &schemas.BifrostResponse{Model: "gpt-4o"}, nil)
+
+	// The request is not sampled, so neither it nor its response is serialized
+	if _, sampled := ctx.Value(SampledStartTimeContextKey).(time.Time); sampled || len(messages) != 0 {
+		t.Errorf("sampled = %v, logged %q, want nothing sampled below debug level", sampled, messages)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/valyala/fasthttp"
@@ -30,6 +31,18 @@ var (
 
 	bifrostUpstreamLatencySeconds *prometheus.HistogramVec
 
+	// bifrostClientDisconnectsTotal tracks results that could not be delivered because the client was gone.
+	bifrostClientDisconnectsTotal *prometheus.CounterVec
+
+	// bifrostQueueWaitSeconds tracks how long requests waited in a provider queue for a worker.
+	bifrostQueueWaitSeconds *prometheus.HistogramVec
+	// bifrostServiceTimeSeconds tracks how long provider workers spent on requests.
+	bifrostServiceTimeSeconds *prometheus.HistogramVec
+	// bifrostTimeToFirstTokenSeconds tracks the time from the provider call to the first content of streams.
+	bifrostTimeToFirstTokenSeconds *prometheus.HistogramVec
+	// bifrostFailoversTotal tracks requests falling back from a failed provider to another one.
+	bifrostFailoversTotal *prometheus.CounterVec
+
 	// customLabels stores the expected label names in order
 	customLabels  []string
 	isInitialized bool
@@ -101,9 +114,126 @@ func InitPrometheusMetrics(labels []string) {
 		append(bifrostDefaultLabels, labels...),
 	)
 
+	bifrostClientDisconnectsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bifrost_client_disconnects_total",
+			Help: "Total number of results Bifrost could not deliver because the client had disconnected.",
+		},
+		[]string{"provider", "request_type", "reason"},
+	)
+
+	bifrostQueueWaitSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_queue_wait_seconds",
+			Help:    "Time requests waited in a provider queue before a worker picked them up.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 10), // 0.5ms to ~131s
+		},
+		[]string{"provider", "request_type"},
+	)
+
+	bifrostServiceTimeSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_service_time_seconds",
+			Help:    "Time provider workers spent on requests, including retries.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"provider", "request_type", "status"},
+	)
+
+	bifrostTimeToFirstTokenSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_time_to_first_token_seconds",
+			Help:    "Time from the provider call to the first content chunk of streaming requests.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"provider", "request_type"},
+	)
+
+	bifrostFailoversTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bifrost_failovers_total",
+			Help: "Total number of times a request fell back from a failed provider to another one.",
+		},
+		[]string{"provider", "fallback_provider", "request_type", "error_type"},
+	)
+
 	isInitialized = true
 }
 
+// RecordClientDisconnect counts a result that could not be delivered to a disconnected client.
+// It is meant to be passed as the OnClientDisconnect hook when initializing Bifrost.
+func RecordClientDisconnect(info schemas.ClientDisconnectInfo) {
+	if bifrostClientDisconnectsTotal == nil {
+		return
+	}
+	bifrostClientDisconnectsTotal.WithLabelValues(string(info.Provider), info.RequestType, string(info.Reason)).Inc()
+}
+
+// RecordRequestTiming records the queue wait and service time of a request handled by a provider worker.
+// It is meant to be passed as the OnRequestTiming hook when initializing Bifrost.
+func RecordRequestTiming(timing schemas.RequestTiming) {
+	if bifrostQueueWaitSeconds == nil {
+		return
+	}
+	status := "success"
+	if timing.Failed {
+		status = "error"
+	}
+	bifrostQueueWaitSeconds.WithLabelValues(string(timing.Provider), timing.RequestType).Observe(timing.QueueWait.Seconds())
+	bifrostServiceTimeSeconds.WithLabelValues(string(timing.Provider), timing.RequestType, status).Observe(timing.ServiceTime.Seconds())
+}
+
+// RecordFirstToken records the time to first token of a streaming request.
+// It is meant to be passed as the OnFirstToken hook when initializing Bifrost.
+func RecordFirstToken(timing schemas.FirstTokenTiming) {
+	if bifrostTimeToFirstTokenSeconds == nil {
+		return
+	}
+	bifrostTimeToFirstTokenSeconds.WithLabelValues(string(timing.Provider), timing.RequestType).Observe(timing.TimeToFirstToken.Seconds())
+}
+
+// RecordFailover counts a request falling back from a failed provider to another one.
+// It is meant to be passed as the OnFailover hook when initializing Bifrost.
+func RecordFailover(event schemas.FailoverEvent) {
+	if bifrostFailoversTotal == nil {
+		return
+	}
+	bifrostFailoversTotal.WithLabelValues(string(event.Failed.Provider), string(event.Fallback.Provider), event.RequestType, failoverErrorType(event.Failed)).Inc()
+}
+
+// failoverErrorType maps the error of a failed attempt to one of a fixed set of error_type label
+// values, as the error types reported by providers are free-form and would make the label unbounded.
+func failoverErrorType(failed schemas.AttemptError) string {
+	if failed.Type != nil {
+		switch *failed.Type {
+		case schemas.RequestCancelled:
+			return "cancelled"
+		case schemas.ResponseRejected:
+			return "response_rejected"
+		case schemas.ProviderUnhealthy:
+			return "provider_unhealthy"
+		}
+	}
+	switch {
+	case failed.StatusCode == nil:
+		switch failed.Kind {
+		case schemas.ErrorKindConnection:
+			return "connection_error"
+		case schemas.ErrorKindEmptyStream:
+			return "empty_stream"
+		}
+		return "other"
+	case *failed.StatusCode == fasthttp.StatusTooManyRequests:
+		return "rate_limit"
+	case *failed.StatusCode >= 500:
+		return "server_error"
+	case *failed.StatusCode >= 400:
+		return "client_error"
+	default:
+		return "other"
+	}
+}
+
 // getPrometheusLabelValues takes an array of expected label keys and a map of header values,
 // and returns an array of values in the same order as the keys, using empty string for missing values.
 func getPrometheusLabelValues(expectedLabels []string, headerValues map[string]string) []string {
@@ -51,6 +51,13 @@ const providerMetaFields: Record<string, { title: string; fields: MetaField[] }>
         placeholder: '{ "model-id": "profile-name" }',
         isJson: true,
       },
+      {
+        name: 'cross_region_source_regions',
+        label: 'Cross-Region Source Regions (JSON format, Optional)',
+        type: 'textarea',
+        placeholder: '{ "eu": "eu-west-1", "apac": "ap-northeast-1" }',
+        isJson: true,
+      },
     ],
   },
 }
@@ -51,6 +51,7 @@ export interface MetaConfig {
   session_token?: string
   arn?: string
   inference_profiles?: Record<string, string>
+  cross_region_source_regions?: Record<string, string>
 
   // Generic fields for extensibility
   [key: string]: unknown
@@ -129,6 +130,7 @@ export interface CoreConfig {
   initial_pool_size?: number
   prometheus_labels?: string
   enable_logging?: boolean
+  debug_sample_rate?: number
 }
 
 // Utility types for form handling